@@ -0,0 +1,170 @@
+// Command migrate applies or rolls back the database migrations and prints the resulting version:
+//
+//	go run cmd/migrate/migrate.go -migrate=up|down|steps=N|version
+//
+// Rolling back drops tables and their data, so down and steps=N with N < 0 need -yes too.
+// A migration that fails halfway leaves the database dirty, which blocks every other migration. Once the
+// schema is fixed by hand, force=N -yes records version N as applied and clears the dirty state
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/vfa-khuongdv/golang-cms/internal/configs"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+	"github.com/vfa-khuongdv/golang-cms/pkg/migrator"
+)
+
+// migrationRunner is the part of migrator.Migrator the commands use
+type migrationRunner interface {
+	Up() error
+	Down() error
+	Steps(int) error
+	Force(int) error
+	Version() (uint, bool, error)
+}
+
+type command struct {
+	name    string
+	steps   int
+	version int
+}
+
+// parseCommand parses the value of the -migrate flag. Rolling back and forcing a version need confirmed, i.e. the
+// -yes flag: a rollback drops tables and their data, and recording a version the schema is not at breaks the next migrations
+func parseCommand(arg string, confirmed bool) (command, error) {
+	switch arg {
+	case "up", "version":
+		return command{name: arg}, nil
+	case "down":
+		if !confirmed {
+			return command{}, fmt.Errorf("down rolls back every migration and drops their data, add -yes to confirm")
+		}
+		return command{name: arg}, nil
+	}
+	if value, ok := strings.CutPrefix(arg, "steps="); ok {
+		steps, err := strconv.Atoi(value)
+		if err != nil || steps == 0 {
+			return command{}, fmt.Errorf("invalid steps %q, expected a non-zero integer", value)
+		}
+		if steps < 0 && !confirmed {
+			return command{}, fmt.Errorf("steps=%d rolls back migrations and drops their data, add -yes to confirm", steps)
+		}
+		return command{name: "steps", steps: steps}, nil
+	}
+	if value, ok := strings.CutPrefix(arg, "force="); ok {
+		version, err := strconv.Atoi(value)
+		if err != nil || version < -1 {
+			return command{}, fmt.Errorf("invalid version %q, expected a migration version or -1 for none", value)
+		}
+		if !confirmed {
+			return command{}, fmt.Errorf("force changes the recorded version without migrating, add -yes to confirm")
+		}
+		return command{name: "force", version: version}, nil
+	}
+	return command{}, fmt.Errorf("unknown command %q, expected up, down, steps=N, force=N or version", arg)
+}
+
+// runCommand runs cmd and returns the version the database is at afterwards
+func runCommand(m migrationRunner, cmd command) (string, error) {
+	var err error
+	switch cmd.name {
+	case "up":
+		err = m.Up()
+	case "down":
+		err = m.Down()
+	case "steps":
+		err = m.Steps(cmd.steps)
+	case "force":
+		err = m.Force(cmd.version)
+	}
+	if err != nil {
+		return "", err
+	}
+	return describeVersion(m)
+}
+
+// describeVersion tells the version the database is at and whether it is dirty, i.e. a migration failed halfway
+func describeVersion(m migrationRunner) (string, error) {
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return "no migration applied", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read migration version: %w", err)
+	}
+	if dirty {
+		return fmt.Sprintf("version %d, dirty", version), nil
+	}
+	return fmt.Sprintf("version %d, clean", version), nil
+}
+
+// migrationDSN returns the DSN the migrator connects to driver with, from the DB_* variables
+func migrationDSN(driver string) string {
+	host := utils.GetEnv("DB_HOST", "127.0.0.1")
+	port := utils.GetEnv("DB_PORT", configs.DefaultPort(driver))
+	user := utils.GetEnv("DB_USERNAME", "")
+	password := utils.GetEnv("DB_PASSWORD", "")
+	dbName := utils.GetEnv("DB_DATABASE", "")
+	if driver == configs.DB_DRIVER_POSTGRES {
+		return migrator.NewPostgresDSN(migrator.PostgresConfig{
+			Host:     host,
+			Port:     port,
+			User:     user,
+			Password: password,
+			DBName:   dbName,
+			SSLMode:  utils.GetEnv("DB_SSLMODE", ""),
+		})
+	}
+	return migrator.NewMySQLDSN(migrator.MySQLConfig{
+		Host:     host,
+		Port:     port,
+		User:     user,
+		Password: password,
+		DBName:   dbName,
+	})
+}
+
+func main() {
+	action := flag.String("migrate", "up", "Migration to run: up, down (rolls back every migration), steps=N (up for N > 0, down for N < 0), force=N or version")
+	path := flag.String("path", "", "Directory holding the migration files, by default the ones of DB_DRIVER")
+	yes := flag.Bool("yes", false, "Confirm down and steps=N with N < 0, which drop data, and force=N, which records version N as applied and clears the dirty state without migrating")
+	flag.Parse()
+
+	// Load env package
+	configs.LoadEnv()
+
+	// Init logger
+	logger.Init()
+
+	cmd, err := parseCommand(*action, *yes)
+	if err != nil {
+		logger.Fatalf("Invalid -migrate flag: %v", err)
+	}
+
+	driver := utils.GetEnv("DB_DRIVER", configs.DB_DRIVER_MYSQL)
+	if *path == "" {
+		*path = configs.MigrationsPath(driver)
+	}
+	m, err := migrator.NewMigrator(*path, driver, migrationDSN(driver))
+	if err != nil {
+		logger.Fatalf("Migration initialization failed: %v", err)
+	}
+	defer m.Close()
+
+	version, err := runCommand(m, cmd)
+	if err != nil {
+		logger.Fatalf("Migration %s failed: %v", *action, err)
+	}
+	// Printed on its own so scripts can read it
+	fmt.Println(version)
+	if strings.HasSuffix(version, ", dirty") {
+		logger.Warnf("The last migration failed halfway. Fix the schema by hand, then run -migrate=force=N -yes with the version it is at")
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRunner struct {
+	calls   []string
+	steps   int
+	forced  int
+	err     error
+	version uint
+	dirty   bool
+	verErr  error
+}
+
+func (f *fakeRunner) Up() error {
+	f.calls = append(f.calls, "up")
+	return f.err
+}
+
+func (f *fakeRunner) Down() error {
+	f.calls = append(f.calls, "down")
+	return f.err
+}
+
+func (f *fakeRunner) Steps(n int) error {
+	f.calls = append(f.calls, "steps")
+	f.steps = n
+	return f.err
+}
+
+func (f *fakeRunner) Force(version int) error {
+	f.calls = append(f.calls, "force")
+	f.forced = version
+	return f.err
+}
+
+func (f *fakeRunner) Version() (uint, bool, error) {
+	return f.version, f.dirty, f.verErr
+}
+
+func TestParseCommand(t *testing.T) {
+	for arg, expected := range map[string]command{
+		"up":       {name: "up"},
+		"down":     {name: "down"},
+		"version":  {name: "version"},
+		"steps=2":  {name: "steps", steps: 2},
+		"steps=-1": {name: "steps", steps: -1},
+		"force=19": {name: "force", version: 19},
+		"force=-1": {name: "force", version: -1},
+	} {
+		cmd, err := parseCommand(arg, true)
+		require.NoError(t, err, arg)
+		assert.Equal(t, expected, cmd, arg)
+	}
+
+	for _, arg := range []string{"", "sideways", "steps=", "steps=0", "steps=one", "force=", "force=-2", "force=latest"} {
+		_, err := parseCommand(arg, true)
+		assert.Error(t, err, arg)
+	}
+}
+
+func TestParseCommandNeedsConfirmation(t *testing.T) {
+	for _, arg := range []string{"force=19", "down", "steps=-1"} {
+		_, err := parseCommand(arg, false)
+
+		assert.ErrorContains(t, err, "-yes", arg)
+	}
+
+	for arg, expected := range map[string]command{
+		"up":      {name: "up"},
+		"steps=2": {name: "steps", steps: 2},
+		"version": {name: "version"},
+	} {
+		cmd, err := parseCommand(arg, false)
+		require.NoError(t, err, arg)
+		assert.Equal(t, expected, cmd, arg)
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	t.Run("Runs The Migration And Returns The Version", func(t *testing.T) {
+		for _, cmd := range []command{{name: "up"}, {name: "down"}, {name: "steps", steps: -1}, {name: "force", version: 19}} {
+			runner := &fakeRunner{version: 19}
+
+			version, err := runCommand(runner, cmd)
+
+			require.NoError(t, err)
+			assert.Equal(t, "version 19, clean", version)
+			assert.Equal(t, []string{cmd.name}, runner.calls)
+			assert.Equal(t, cmd.steps, runner.steps)
+			assert.Equal(t, cmd.version, runner.forced)
+		}
+	})
+
+	t.Run("Version Only Reads", func(t *testing.T) {
+		runner := &fakeRunner{version: 20, dirty: true}
+
+		version, err := runCommand(runner, command{name: "version"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "version 20, dirty", version)
+		assert.Empty(t, runner.calls)
+	})
+
+	t.Run("Nothing Applied", func(t *testing.T) {
+		version, err := runCommand(&fakeRunner{verErr: migrate.ErrNilVersion}, command{name: "down"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "no migration applied", version)
+	})
+
+	t.Run("Migration Error", func(t *testing.T) {
+		_, err := runCommand(&fakeRunner{err: errors.New("down migration failed")}, command{name: "down"})
+
+		assert.EqualError(t, err, "down migration failed")
+	})
+
+	t.Run("Version Error", func(t *testing.T) {
+		_, err := runCommand(&fakeRunner{verErr: errors.New("connection refused")}, command{name: "version"})
+
+		assert.ErrorContains(t, err, "connection refused")
+	})
+}
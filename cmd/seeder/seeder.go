@@ -14,13 +14,16 @@ func main() {
 	// Init logger
 	logger.Init()
 
-	// MySQL database configuration
+	// Database configuration
+	driver := utils.GetEnv("DB_DRIVER", configs.DB_DRIVER_MYSQL)
 	config := configs.DatabaseConfig{
+		Driver:   driver,
 		Host:     utils.GetEnv("DB_HOST", "127.0.0.1"),
-		Port:     utils.GetEnv("DB_PORT", "3306"),
+		Port:     utils.GetEnv("DB_PORT", configs.DefaultPort(driver)),
 		User:     utils.GetEnv("DB_USERNAME", ""),
 		Password: utils.GetEnv("DB_PASSWORD", ""),
 		DBName:   utils.GetEnv("DB_DATABASE", ""),
+		SSLMode:  utils.GetEnv("DB_SSLMODE", ""),
 	}
 
 	// Initialize database connection
@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"net/http"
+	"time"
 
 	"github.com/vfa-khuongdv/golang-cms/internal/configs"
+	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
 	"github.com/vfa-khuongdv/golang-cms/internal/routes"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
 	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
 	"github.com/vfa-khuongdv/golang-cms/pkg/migrator"
@@ -12,27 +18,112 @@ import (
 )
 
 func initializeDatabase() *gorm.DB {
+	driver := utils.GetEnv("DB_DRIVER", configs.DB_DRIVER_MYSQL)
 	config := configs.DatabaseConfig{
+		Driver:   driver,
 		Host:     utils.GetEnv("DB_HOST", "127.0.0.1"),
-		Port:     utils.GetEnv("DB_PORT", "3306"),
+		Port:     utils.GetEnv("DB_PORT", configs.DefaultPort(driver)),
 		User:     utils.GetEnv("DB_USERNAME", ""),
 		Password: utils.GetEnv("DB_PASSWORD", ""),
 		DBName:   utils.GetEnv("DB_DATABASE", ""),
+		SSLMode:  utils.GetEnv("DB_SSLMODE", ""),
+
+		MaxOpenConns:    utils.GetEnvAsInt("DB_MAX_OPEN_CONNS", configs.DEFAULT_MAX_OPEN_CONNS),
+		MaxIdleConns:    utils.GetEnvAsInt("DB_MAX_IDLE_CONNS", configs.DEFAULT_MAX_IDLE_CONNS),
+		ConnMaxLifetime: time.Duration(utils.GetEnvAsInt("DB_CONN_MAX_LIFETIME_SECONDS", int(configs.DEFAULT_CONN_MAX_LIFETIME/time.Second))) * time.Second,
+		ConnMaxIdleTime: time.Duration(utils.GetEnvAsInt("DB_CONN_MAX_IDLE_TIME_SECONDS", int(configs.DEFAULT_CONN_MAX_IDLE_TIME/time.Second))) * time.Second,
+		ReplicaHosts:    configs.ParseReplicaHosts(utils.GetEnv("DB_REPLICA_HOSTS", "")),
+		ReplicaPolicy:   utils.GetEnv("DB_REPLICA_POLICY", configs.REPLICA_POLICY_RANDOM),
 	}
 	return configs.InitDB(config)
 }
 
-func runMigrations() {
-	sqlConfig := migrator.MySQLConfig{
-		Host:     utils.GetEnv("DB_HOST", "127.0.0.1"),
-		Port:     utils.GetEnv("DB_PORT", "3306"),
-		User:     utils.GetEnv("DB_USERNAME", ""),
-		Password: utils.GetEnv("DB_PASSWORD", ""),
-		DBName:   utils.GetEnv("DB_DATABASE", ""),
+// initializeRedis returns the cache service and, when backed by Redis, the function closing its client.
+// Redis is used by default; a REDIS_ENABLED other than "true" selects the in-memory cache
+func initializeRedis() (services.RedisService, func() error) {
+	if utils.GetEnv("REDIS_ENABLED", "true") != "true" {
+		maxEntries := utils.GetEnvAsInt("MEMORY_CACHE_MAX_ENTRIES", services.DEFAULT_MEMORY_CACHE_MAX_ENTRIES)
+		logger.Warnf("REDIS_ENABLED is not true, using an in-memory cache with max %d entries. Login lockouts, rate limits "+
+			"and revoked access tokens are then only known to this process: do not run more than one instance", maxEntries)
+		return services.NewMemoryRedisService(maxEntries), nil
+	}
+
+	config := configs.RedisConfig{
+		Host:     utils.GetEnv("REDIS_HOST", "127.0.0.1"),
+		Port:     utils.GetEnv("REDIS_PORT", "6379"),
+		Password: utils.GetEnv("REDIS_PASSWORD", ""),
+		DB:       utils.GetEnvAsInt("REDIS_DB", 0),
+	}
+	client := configs.InitRedis(config)
+	return services.NewRedisService(client), client.Close
+}
+
+// warmCache pre-loads recently active profiles, bounded by CACHE_WARM_LIMIT users
+// and CACHE_WARM_TIMEOUT_SECONDS so a slow cache cannot stall startup
+func warmCache(ctx context.Context, db *gorm.DB, redisService services.RedisService) {
+	limit := utils.GetEnvAsInt("CACHE_WARM_LIMIT", 100)
+	timeout := time.Duration(utils.GetEnvAsInt("CACHE_WARM_TIMEOUT_SECONDS", 5)) * time.Second
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	warmer := services.NewCacheWarmerService(repositories.NewUserRepository(db), redisService)
+	if _, err := warmer.WarmProfiles(ctx, limit); err != nil {
+		logger.Warnf("Cache warm-up incomplete: %v", err)
+	}
+}
+
+// anonymizeDeletedAccounts anonymizes the accounts whose deletion grace period has passed right away and then
+// every interval, until ctx is done. It closes done when it returns
+func anonymizeDeletedAccounts(ctx context.Context, service services.AccountAnonymizationService, interval time.Duration, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if count, err := service.AnonymizeExpired(ctx); err != nil && ctx.Err() == nil {
+			logger.Errorf("Account anonymization stopped after %d accounts: %v", count, err)
+		} else if count > 0 {
+			logger.Infof("Anonymized %d accounts past their deletion grace period", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// migrationDSN returns the DSN the migrator connects to driver with, from the DB_* variables
+func migrationDSN(driver string) string {
+	host := utils.GetEnv("DB_HOST", "127.0.0.1")
+	port := utils.GetEnv("DB_PORT", configs.DefaultPort(driver))
+	user := utils.GetEnv("DB_USERNAME", "")
+	password := utils.GetEnv("DB_PASSWORD", "")
+	dbName := utils.GetEnv("DB_DATABASE", "")
+	if driver == configs.DB_DRIVER_POSTGRES {
+		return migrator.NewPostgresDSN(migrator.PostgresConfig{
+			Host:     host,
+			Port:     port,
+			User:     user,
+			Password: password,
+			DBName:   dbName,
+			SSLMode:  utils.GetEnv("DB_SSLMODE", ""),
+		})
 	}
-	dsn := migrator.NewMySQLDSN(sqlConfig)
+	return migrator.NewMySQLDSN(migrator.MySQLConfig{
+		Host:     host,
+		Port:     port,
+		User:     user,
+		Password: password,
+		DBName:   dbName,
+	})
+}
 
-	m, err := migrator.NewMigrator("internal/database/migrations", dsn)
+func runMigrations() {
+	driver := utils.GetEnv("DB_DRIVER", configs.DB_DRIVER_MYSQL)
+	m, err := migrator.NewMigrator(configs.MigrationsPath(driver), driver, migrationDSN(driver))
 	if err != nil {
 		logger.Fatalf("Migration initialization failed: %v", err)
 	}
@@ -41,7 +132,7 @@ func runMigrations() {
 	if err := m.Up(); err != nil {
 		logger.Fatalf("Migration failed: %v", err)
 	} else {
-		logger.Infof("MySQL migrations applied successfully!")
+		logger.Infof("%s migrations applied successfully!", driver)
 	}
 }
 
@@ -52,6 +143,10 @@ func main() {
 	// Initialize logger
 	logger.Init()
 
+	// Cancelled on SIGINT/SIGTERM; background work started below must derive from it
+	ctx, stop := shutdownContext(context.Background())
+	defer stop()
+
 	// Initialize database
 	db := initializeDatabase()
 
@@ -61,15 +156,68 @@ func main() {
 		runMigrations()
 	}
 
+	// Initialize cache
+	redisService, closeRedis := initializeRedis()
+
+	// Warm cache
+	if utils.GetEnv("CACHE_WARM_ON_START", "false") == "true" {
+		warmCache(ctx, db, redisService)
+	}
+
+	// Audit entries are written in the background and flushed on shutdown
+	auditService := services.NewAuditService(repositories.NewAuditLogRepository(db), utils.GetEnvAsInt("AUDIT_BUFFER_SIZE", services.DEFAULT_AUDIT_BUFFER_SIZE))
+
+	// Mails are sent in the background and waited for on shutdown, up to the shutdown timeout
+	shutdownTimeout := time.Duration(utils.GetEnvAsInt("SHUTDOWN_TIMEOUT", 15)) * time.Second
+	backgroundService := services.NewBackgroundService(shutdownTimeout)
+
+	// Accounts are anonymized in the background once their deletion grace period has passed
+	anonymizationDone := make(chan struct{})
+	anonymizationInterval := time.Duration(utils.GetEnvAsInt("ACCOUNT_ANONYMIZATION_INTERVAL_SECONDS", 3600)) * time.Second
+	if anonymizationInterval > 0 {
+		anonymizer := services.NewAccountAnonymizationService(repositories.NewUserRepository(db), redisService)
+		go anonymizeDeletedAccounts(ctx, anonymizer, anonymizationInterval, anonymizationDone)
+	} else {
+		close(anonymizationDone)
+	}
+
 	// Setup routes
-	router := routes.SetupRouter(db)
+	router := routes.SetupRouter(db, redisService, auditService, backgroundService)
 
 	// Initialize custom validator
 	utils.InitValidator()
 
 	// Start server
 	port := fmt.Sprintf(":%s", utils.GetEnv("PORT", "3000"))
-	if err := router.Run(port); err != nil {
+	listener, err := net.Listen("tcp", port)
+	if err != nil {
 		logger.Fatalf("Failed to start server: %v", err)
 	}
+
+	closers := []closer{
+		// The anonymization job is stopped before the database it uses is closed. Ctx is only
+		// cancelled already when the shutdown was signalled, not when the server failed
+		{name: "account anonymization", close: func() error {
+			stop()
+			<-anonymizationDone
+			return nil
+		}},
+		{name: "background tasks", close: backgroundService.Close},
+		// The audit writer needs the database, so it is flushed first
+		{name: "audit log", close: auditService.Close},
+		{name: "database", close: func() error {
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.Close()
+		}},
+	}
+	if closeRedis != nil {
+		closers = append(closers, closer{name: "redis", close: closeRedis})
+	}
+
+	if err := serve(ctx, &http.Server{Handler: router}, listener, shutdownTimeout, closers...); err != nil {
+		logger.Fatalf("Server exited with error: %v", err)
+	}
 }
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+// closer releases a resource once the server has stopped taking requests
+type closer struct {
+	name  string
+	close func() error
+}
+
+// shutdownContext returns a context that is cancelled when the process receives SIGINT or SIGTERM.
+// Background work should derive its context from it so it stops with the server
+func shutdownContext(parent context.Context) (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+}
+
+// serve handles requests on listener until ctx is cancelled, then stops accepting connections
+// and waits up to shutdownTimeout for in-flight requests. The closers run afterwards, in order,
+// even when the server fails. It returns the first error encountered
+func serve(ctx context.Context, server *http.Server, listener net.Listener, shutdownTimeout time.Duration, closers ...closer) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+	logger.Infof("Server listening on %s", listener.Addr())
+
+	var firstErr error
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			logger.Errorf("Server stopped unexpectedly: %v", err)
+			firstErr = err
+		}
+	case <-ctx.Done():
+		logger.Infof("Shutting down server, waiting up to %s for in-flight requests", shutdownTimeout)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Errorf("Server shutdown incomplete: %v", err)
+			firstErr = err
+		}
+	}
+
+	for _, c := range closers {
+		if err := c.close(); err != nil {
+			logger.Errorf("Failed to close %s: %v", c.name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		logger.Infof("Closed %s", c.name)
+	}
+
+	logger.Info("Server stopped")
+	return firstErr
+}
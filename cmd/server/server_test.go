@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServe(t *testing.T) {
+	t.Run("Signal drains in-flight requests then closes resources in order", func(t *testing.T) {
+		ctx, stop := shutdownContext(context.Background())
+		defer stop()
+
+		var mu sync.Mutex
+		var events []string
+		record := func(event string) func() error {
+			return func() error {
+				mu.Lock()
+				defer mu.Unlock()
+				events = append(events, event)
+				return nil
+			}
+		}
+
+		requestStarted := make(chan struct{})
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(requestStarted)
+			time.Sleep(200 * time.Millisecond)
+			_, _ = w.Write([]byte("done"))
+			_ = record("request")()
+		})
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		served := make(chan error, 1)
+		go func() {
+			served <- serve(ctx, &http.Server{Handler: handler}, listener, 5*time.Second,
+				closer{name: "database", close: record("database")},
+				closer{name: "redis", close: record("redis")},
+			)
+		}()
+
+		responded := make(chan string, 1)
+		go func() {
+			resp, err := http.Get("http://" + listener.Addr().String())
+			if err != nil {
+				responded <- err.Error()
+				return
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			responded <- string(body)
+		}()
+
+		<-requestStarted
+		process, err := os.FindProcess(os.Getpid())
+		require.NoError(t, err)
+		require.NoError(t, process.Signal(syscall.SIGTERM))
+
+		select {
+		case err := <-served:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("server did not shut down")
+		}
+		assert.Equal(t, "done", <-responded)
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, []string{"request", "database", "redis"}, events)
+	})
+
+	t.Run("Closers run and errors are reported when the server fails", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		// A closed listener makes Serve fail immediately
+		require.NoError(t, listener.Close())
+
+		closed := false
+		err = serve(context.Background(), &http.Server{}, listener, time.Second,
+			closer{name: "database", close: func() error {
+				closed = true
+				return nil
+			}},
+		)
+
+		assert.Error(t, err)
+		assert.True(t, closed)
+	})
+
+	t.Run("First closer error is returned and later closers still run", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		redisClosed := false
+		err = serve(ctx, &http.Server{}, listener, time.Second,
+			closer{name: "database", close: func() error { return errors.New("close failed") }},
+			closer{name: "redis", close: func() error {
+				redisClosed = true
+				return nil
+			}},
+		)
+
+		assert.EqualError(t, err, "close failed")
+		assert.True(t, redisClosed)
+	})
+}
@@ -4,30 +4,54 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"net"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
 )
 
 type DatabaseConfig struct {
+	Driver          string // DB_DRIVER_MYSQL (default) or DB_DRIVER_POSTGRES
 	Host            string
 	Port            string
 	User            string
 	Password        string
 	DBName          string
+	SSLMode         string // Postgres only: disable (default), require, verify-ca or verify-full
 	MaxOpenConns    int
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
 	ConnMaxIdleTime time.Duration
+	ReplicaHosts    []string // Read replicas as host or host:port, sharing the credentials and database of the primary
+	ReplicaPolicy   string   // How reads pick a replica: REPLICA_POLICY_RANDOM (default) or REPLICA_POLICY_ROUND_ROBIN
 }
 
+// Drivers of DatabaseConfig.Driver
+const (
+	DB_DRIVER_MYSQL    = "mysql"
+	DB_DRIVER_POSTGRES = "postgres"
+)
+
+// MIGRATIONS_PATH holds the MySQL migrations, and its postgres directory the same migrations for Postgres
+const MIGRATIONS_PATH = "internal/database/migrations"
+
+// Replica policies of DatabaseConfig.ReplicaPolicy
+const (
+	REPLICA_POLICY_RANDOM      = "random"
+	REPLICA_POLICY_ROUND_ROBIN = "round_robin"
+)
+
 var DB *gorm.DB
 
 var (
-	openGormConnection = func(dsn string) (*gorm.DB, error) {
-		return gorm.Open(mysql.Open(dsn), &gorm.Config{
+	openGormConnection = func(dialector gorm.Dialector) (*gorm.DB, error) {
+		return gorm.Open(dialector, &gorm.Config{
 			PrepareStmt: false,
 		})
 	}
@@ -37,6 +61,13 @@ var (
 	logFatalf = logger.Fatalf
 	logInfof  = logger.Infof
 	pingDBFn  = pingDB
+	// openDialector returns the dialector connecting to the primary or a read replica
+	openDialector = func(driver, dsn string) gorm.Dialector {
+		if driver == DB_DRIVER_POSTGRES {
+			return postgres.Open(dsn)
+		}
+		return mysql.Open(dsn)
+	}
 )
 
 // Default connection pool settings
@@ -54,21 +85,17 @@ const (
 	DEFAULT_CONN_MAX_LIFETIME  = 30 * time.Minute
 )
 
-// InitDB initializes MySQL with GORM and configures a resilient connection pool
+// InitDB initializes MySQL or Postgres, as picked by config.Driver, with GORM and configures a resilient connection pool
 func InitDB(config DatabaseConfig) *gorm.DB {
-	dsn := fmt.Sprintf(
-		"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=UTC",
-		config.User,
-		config.Password,
-		config.Host,
-		config.Port,
-		config.DBName,
-	)
+	setDefaults(&config)
+	if !IsSupportedDriver(config.Driver) {
+		logFatalf("Unsupported database driver %q, use %s or %s", config.Driver, DB_DRIVER_MYSQL, DB_DRIVER_POSTGRES)
+	}
 
 	// Open GORM connection
-	db, err := openGormConnection(dsn)
+	db, err := openGormConnection(openDialector(config.Driver, buildDSN(config, config.Host, config.Port)))
 	if err != nil {
-		logFatalf("Failed to connect to MySQL: %+v", err)
+		logFatalf("Failed to connect to %s: %+v", config.Driver, err)
 	}
 
 	// Get underlying sql.DB
@@ -80,8 +107,6 @@ func InitDB(config DatabaseConfig) *gorm.DB {
 	// =========================
 	// Connection Pool Settings
 	// =========================
-	setDefaults(&config)
-
 	sqlDB.SetMaxOpenConns(config.MaxOpenConns)
 	sqlDB.SetMaxIdleConns(config.MaxIdleConns)
 	sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
@@ -92,20 +117,136 @@ func InitDB(config DatabaseConfig) *gorm.DB {
 		logFatalf("Database ping failed: %+v", err)
 	}
 
+	// Route reads to the replicas, if any
+	if err := registerReplicas(db, config); err != nil {
+		logFatalf("Failed to connect to %s replicas: %+v", config.Driver, err)
+	}
+
 	logInfof(
-		"MySQL connected | open=%d idle=%d lifetime=%s idleTime=%s",
+		"%s connected | open=%d idle=%d lifetime=%s idleTime=%s replicas=%d",
+		config.Driver,
 		config.MaxOpenConns,
 		config.MaxIdleConns,
 		config.ConnMaxLifetime,
 		config.ConnMaxIdleTime,
+		len(config.ReplicaHosts),
 	)
 
 	DB = db
 	return db
 }
 
+// IsSupportedDriver reports whether driver is one of the DB_DRIVER_* values
+func IsSupportedDriver(driver string) bool {
+	return driver == DB_DRIVER_MYSQL || driver == DB_DRIVER_POSTGRES
+}
+
+// DefaultPort returns the port the server of driver listens on by default
+func DefaultPort(driver string) string {
+	if driver == DB_DRIVER_POSTGRES {
+		return "5432"
+	}
+	return "3306"
+}
+
+// MigrationsPath returns the directory of the migrations written for driver
+func MigrationsPath(driver string) string {
+	if driver == DB_DRIVER_POSTGRES {
+		return MIGRATIONS_PATH + "/postgres"
+	}
+	return MIGRATIONS_PATH
+}
+
+// ParseReplicaHosts splits the comma separated DB_REPLICA_HOSTS value, ignoring blank entries
+func ParseReplicaHosts(value string) []string {
+	var hosts []string
+	for _, host := range strings.Split(value, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// buildDSN returns the DSN of the server at host:port, with the driver, credentials and database of config
+func buildDSN(config DatabaseConfig, host, port string) string {
+	if config.Driver == DB_DRIVER_POSTGRES {
+		sslMode := config.SSLMode
+		if sslMode == "" {
+			sslMode = "disable"
+		}
+		dsn := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(config.User, config.Password),
+			Host:     net.JoinHostPort(host, port),
+			Path:     "/" + config.DBName,
+			RawQuery: url.Values{"sslmode": {sslMode}, "TimeZone": {"UTC"}}.Encode(),
+		}
+		return dsn.String()
+	}
+	return fmt.Sprintf(
+		"%s:%s@tcp(%s)/%s?charset=utf8mb4&parseTime=True&loc=UTC",
+		config.User,
+		config.Password,
+		net.JoinHostPort(host, port),
+		config.DBName,
+	)
+}
+
+// replicaDSNs returns the DSN of each replica. Replicas without a port use the port of the primary
+func replicaDSNs(config DatabaseConfig) []string {
+	dsns := make([]string, 0, len(config.ReplicaHosts))
+	for _, replica := range config.ReplicaHosts {
+		host, port, err := net.SplitHostPort(replica)
+		if err != nil {
+			host, port = replica, config.Port
+		}
+		dsns = append(dsns, buildDSN(config, host, port))
+	}
+	return dsns
+}
+
+// replicaPolicy returns the dbresolver policy named by the config, random if not set
+func replicaPolicy(name string) (dbresolver.Policy, error) {
+	switch name {
+	case "", REPLICA_POLICY_RANDOM:
+		return dbresolver.RandomPolicy{}, nil
+	case REPLICA_POLICY_ROUND_ROBIN:
+		return dbresolver.StrictRoundRobinPolicy(), nil
+	default:
+		return nil, fmt.Errorf("unknown replica policy %q", name)
+	}
+}
+
+// registerReplicas registers dbresolver so that queries outside transactions go to the replicas,
+// and writes, locking reads and transactions to the primary. Without replicas everything stays on the primary.
+// Replica pools get the pool settings of the primary
+func registerReplicas(db *gorm.DB, config DatabaseConfig) error {
+	if len(config.ReplicaHosts) == 0 {
+		return nil
+	}
+
+	policy, err := replicaPolicy(config.ReplicaPolicy)
+	if err != nil {
+		return err
+	}
+	replicas := make([]gorm.Dialector, 0, len(config.ReplicaHosts))
+	for _, dsn := range replicaDSNs(config) {
+		replicas = append(replicas, openDialector(config.Driver, dsn))
+	}
+
+	return db.Use(dbresolver.Register(dbresolver.Config{Replicas: replicas, Policy: policy}).
+		SetMaxOpenConns(config.MaxOpenConns).
+		SetMaxIdleConns(config.MaxIdleConns).
+		SetConnMaxLifetime(config.ConnMaxLifetime).
+		SetConnMaxIdleTime(config.ConnMaxIdleTime))
+}
+
 // setDefaults applies safe defaults if values are not provided
 func setDefaults(config *DatabaseConfig) {
+	if config.Driver == "" {
+		config.Driver = DB_DRIVER_MYSQL
+	}
 	if config.MaxOpenConns == 0 {
 		config.MaxOpenConns = DEFAULT_MAX_OPEN_CONNS
 	}
@@ -75,3 +75,20 @@ func TestInitDB(t *testing.T) {
 		assert.Equal(t, db, configs.DB)
 	})
 }
+
+func TestParseReplicaHosts(t *testing.T) {
+	assert.Nil(t, configs.ParseReplicaHosts(""))
+	assert.Equal(t, []string{"replica-1", "replica-2:3307"}, configs.ParseReplicaHosts(" replica-1, ,replica-2:3307 ,"))
+}
+
+func TestDriverHelpers(t *testing.T) {
+	assert.True(t, configs.IsSupportedDriver(configs.DB_DRIVER_MYSQL))
+	assert.True(t, configs.IsSupportedDriver(configs.DB_DRIVER_POSTGRES))
+	assert.False(t, configs.IsSupportedDriver("sqlite"))
+
+	assert.Equal(t, "3306", configs.DefaultPort(configs.DB_DRIVER_MYSQL))
+	assert.Equal(t, "5432", configs.DefaultPort(configs.DB_DRIVER_POSTGRES))
+
+	assert.Equal(t, "internal/database/migrations", configs.MigrationsPath(configs.DB_DRIVER_MYSQL))
+	assert.Equal(t, "internal/database/migrations/postgres", configs.MigrationsPath(configs.DB_DRIVER_POSTGRES))
+}
@@ -0,0 +1,41 @@
+package configs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type RedisConfig struct {
+	Host     string
+	Port     string
+	Password string
+	DB       int
+}
+
+var pingRedisFn = pingRedis
+
+// InitRedis creates a Redis client and verifies the server is reachable
+func InitRedis(config RedisConfig) *redis.Client {
+	client := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%s", config.Host, config.Port),
+		Password: config.Password,
+		DB:       config.DB,
+	})
+
+	if err := pingRedisFn(client); err != nil {
+		logFatalf("Redis ping failed: %+v", err)
+	}
+
+	logInfof("Redis connected | addr=%s:%s db=%d", config.Host, config.Port, config.DB)
+	return client
+}
+
+// pingRedis verifies Redis connectivity with timeout
+func pingRedis(client *redis.Client) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return client.Ping(ctx).Err()
+}
@@ -0,0 +1,51 @@
+package configs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitRedis(t *testing.T) {
+	originalFatalf := logFatalf
+	originalInfof := logInfof
+	originalPing := pingRedisFn
+	t.Cleanup(func() {
+		logFatalf = originalFatalf
+		logInfof = originalInfof
+		pingRedisFn = originalPing
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		pingRedisFn = originalPing
+		logFatalf = func(_ string, _ ...interface{}) {
+			panic("should-not-fatal")
+		}
+		logInfof = func(_ string, _ ...interface{}) {}
+
+		client := InitRedis(RedisConfig{Host: server.Host(), Port: server.Port()})
+		require.NotNil(t, client)
+		t.Cleanup(func() {
+			_ = client.Close()
+		})
+		assert.NoError(t, pingRedis(client))
+	})
+
+	t.Run("PingFailure", func(t *testing.T) {
+		pingRedisFn = func(_ *redis.Client) error {
+			return errors.New("ping failed")
+		}
+		logFatalf = func(_ string, _ ...interface{}) {
+			panic("fatal-redis")
+		}
+
+		assert.PanicsWithValue(t, "fatal-redis", func() {
+			_ = InitRedis(RedisConfig{Host: "127.0.0.1", Port: "6379"})
+		})
+	})
+}
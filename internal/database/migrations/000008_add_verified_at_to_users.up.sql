@@ -0,0 +1 @@
+ALTER TABLE `users` ADD COLUMN `verified_at` datetime(3) DEFAULT NULL AFTER `expired_at`;
@@ -0,0 +1 @@
+UPDATE `users` SET `verified_at` = NULL WHERE `verified_at` = `created_at`;
@@ -0,0 +1,15 @@
+CREATE TABLE `audit_logs` (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `actor_user_id` bigint UNSIGNED DEFAULT NULL,
+  `action` varchar(64) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `target_type` varchar(32) COLLATE utf8mb4_unicode_ci DEFAULT NULL,
+  `target_id` bigint UNSIGNED DEFAULT NULL,
+  `ip` varchar(45) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `user_agent` varchar(255) COLLATE utf8mb4_unicode_ci DEFAULT NULL,
+  `metadata` json DEFAULT NULL,
+  `created_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_audit_logs_actor_user_id` (`actor_user_id`),
+  KEY `idx_audit_logs_action` (`action`),
+  KEY `idx_audit_logs_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
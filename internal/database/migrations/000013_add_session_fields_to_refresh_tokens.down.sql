@@ -0,0 +1 @@
+ALTER TABLE `refresh_tokens` DROP COLUMN `last_used_at`, DROP COLUMN `user_agent`;
@@ -0,0 +1,3 @@
+ALTER TABLE `refresh_tokens`
+  ADD COLUMN `user_agent` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' AFTER `ip_address`,
+  ADD COLUMN `last_used_at` datetime(3) DEFAULT NULL AFTER `used_count`;
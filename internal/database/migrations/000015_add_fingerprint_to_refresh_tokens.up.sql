@@ -0,0 +1,2 @@
+ALTER TABLE `refresh_tokens`
+  ADD COLUMN `fingerprint` char(64) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' AFTER `user_agent`;
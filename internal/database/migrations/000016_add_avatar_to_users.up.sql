@@ -0,0 +1,3 @@
+ALTER TABLE `users`
+  ADD COLUMN `avatar` varchar(512) COLLATE utf8mb4_unicode_ci DEFAULT NULL AFTER `must_change_password`,
+  ADD COLUMN `avatar_key` varchar(255) COLLATE utf8mb4_unicode_ci DEFAULT NULL AFTER `avatar`;
@@ -0,0 +1,10 @@
+CREATE TABLE `user_preferences` (
+  `user_id` bigint UNSIGNED NOT NULL,
+  `timezone` varchar(64) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `locale` varchar(16) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `email_notifications` tinyint(1) NOT NULL DEFAULT 1,
+  `theme` varchar(16) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`user_id`),
+  CONSTRAINT `fk_user_preferences_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
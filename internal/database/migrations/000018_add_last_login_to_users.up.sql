@@ -0,0 +1,3 @@
+ALTER TABLE `users`
+  ADD COLUMN `last_login_at` datetime(3) DEFAULT NULL AFTER `avatar_key`,
+  ADD COLUMN `last_login_ip` varchar(45) COLLATE utf8mb4_unicode_ci DEFAULT NULL AFTER `last_login_at`;
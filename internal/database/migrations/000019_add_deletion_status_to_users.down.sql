@@ -0,0 +1 @@
+ALTER TABLE `users` DROP INDEX `idx_users_status_deletion_requested_at`, DROP COLUMN `deletion_requested_at`, DROP COLUMN `status`;
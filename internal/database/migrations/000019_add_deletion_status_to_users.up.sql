@@ -0,0 +1,4 @@
+ALTER TABLE `users`
+  ADD COLUMN `status` varchar(20) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT 'active' AFTER `last_login_ip`,
+  ADD COLUMN `deletion_requested_at` datetime(3) DEFAULT NULL AFTER `status`,
+  ADD INDEX `idx_users_status_deletion_requested_at` (`status`, `deletion_requested_at`);
@@ -0,0 +1,5 @@
+ALTER TABLE `users`
+  ADD COLUMN `pending_email` varchar(45) COLLATE utf8mb4_unicode_ci DEFAULT NULL AFTER `email`,
+  ADD COLUMN `email_change_token` varchar(100) COLLATE utf8mb4_unicode_ci DEFAULT NULL AFTER `expired_at`,
+  ADD COLUMN `email_change_expired_at` bigint DEFAULT NULL AFTER `email_change_token`,
+  ADD UNIQUE KEY `uni_users_email_change_token` (`email_change_token`);
@@ -0,0 +1 @@
+ALTER TABLE `users` ADD COLUMN `token_purpose` varchar(20) COLLATE utf8mb4_unicode_ci DEFAULT NULL AFTER `expired_at`;
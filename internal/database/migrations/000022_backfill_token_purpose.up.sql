@@ -0,0 +1 @@
+UPDATE `users` SET `token_purpose` = IF(`verified_at` IS NULL, 'verify_email', 'reset_password') WHERE `token` IS NOT NULL;
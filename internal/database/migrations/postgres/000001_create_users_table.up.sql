@@ -0,0 +1,18 @@
+CREATE TABLE users (
+  id bigserial NOT NULL,
+  password varchar(255) NOT NULL,
+  name varchar(45) NOT NULL,
+  birthday date DEFAULT NULL,
+  address varchar(255) DEFAULT NULL,
+  gender smallint NOT NULL,
+  token varchar(100) DEFAULT NULL,
+  email varchar(45) NOT NULL,
+  expired_at bigint DEFAULT NULL,
+  created_at timestamptz(3) DEFAULT NULL,
+  updated_at timestamptz(3) DEFAULT NULL,
+  deleted_at timestamptz(3) DEFAULT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT uni_users_email UNIQUE (email),
+  CONSTRAINT uni_users_token UNIQUE (token)
+);
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
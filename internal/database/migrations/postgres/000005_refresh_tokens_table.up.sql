@@ -0,0 +1,16 @@
+CREATE TABLE refresh_tokens (
+  id bigserial NOT NULL,
+  refresh_token varchar(60) NOT NULL,
+  ip_address varchar(45) NOT NULL,
+  used_count bigint DEFAULT 0,
+  expired_at bigint NOT NULL,
+  user_id bigint NOT NULL,
+  created_at timestamptz(3) DEFAULT NULL,
+  updated_at timestamptz(3) DEFAULT NULL,
+  deleted_at timestamptz(3) DEFAULT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT uni_refresh_tokens_refresh_token UNIQUE (refresh_token),
+  CONSTRAINT fk_refresh_tokens_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_refresh_tokens_deleted_at ON refresh_tokens (deleted_at);
+CREATE INDEX fk_refresh_tokens_user ON refresh_tokens (user_id);
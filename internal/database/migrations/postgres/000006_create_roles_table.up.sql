@@ -0,0 +1,8 @@
+CREATE TABLE roles (
+  id bigserial NOT NULL,
+  name varchar(45) NOT NULL,
+  created_at timestamptz(3) DEFAULT NULL,
+  updated_at timestamptz(3) DEFAULT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT uni_roles_name UNIQUE (name)
+);
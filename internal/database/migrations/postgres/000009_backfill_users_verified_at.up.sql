@@ -0,0 +1 @@
+UPDATE users SET verified_at = created_at WHERE verified_at IS NULL;
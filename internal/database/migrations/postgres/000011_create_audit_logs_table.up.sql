@@ -0,0 +1,15 @@
+CREATE TABLE audit_logs (
+  id bigserial NOT NULL,
+  actor_user_id bigint DEFAULT NULL,
+  action varchar(64) NOT NULL,
+  target_type varchar(32) DEFAULT NULL,
+  target_id bigint DEFAULT NULL,
+  ip varchar(45) NOT NULL,
+  user_agent varchar(255) DEFAULT NULL,
+  metadata jsonb DEFAULT NULL,
+  created_at timestamptz(3) DEFAULT NULL,
+  PRIMARY KEY (id)
+);
+CREATE INDEX idx_audit_logs_actor_user_id ON audit_logs (actor_user_id);
+CREATE INDEX idx_audit_logs_action ON audit_logs (action);
+CREATE INDEX idx_audit_logs_created_at ON audit_logs (created_at);
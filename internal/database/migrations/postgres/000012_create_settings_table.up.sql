@@ -0,0 +1,7 @@
+CREATE TABLE settings (
+  key varchar(64) NOT NULL,
+  value text NOT NULL,
+  type varchar(16) NOT NULL,
+  updated_at timestamptz(3) DEFAULT NULL,
+  PRIMARY KEY (key)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE refresh_tokens
+  ADD COLUMN user_agent varchar(255) NOT NULL DEFAULT '',
+  ADD COLUMN last_used_at timestamptz(3) DEFAULT NULL;
@@ -0,0 +1,8 @@
+CREATE TABLE user_login_ips (
+  user_id bigint NOT NULL,
+  ip_address varchar(45) NOT NULL,
+  first_seen_at timestamptz(3) NOT NULL,
+  last_seen_at timestamptz(3) NOT NULL,
+  PRIMARY KEY (user_id, ip_address),
+  CONSTRAINT fk_user_login_ips_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
@@ -0,0 +1 @@
+ALTER TABLE refresh_tokens ADD COLUMN fingerprint char(64) NOT NULL DEFAULT '';
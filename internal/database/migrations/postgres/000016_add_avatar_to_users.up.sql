@@ -0,0 +1,3 @@
+ALTER TABLE users
+  ADD COLUMN avatar varchar(512) DEFAULT NULL,
+  ADD COLUMN avatar_key varchar(255) DEFAULT NULL;
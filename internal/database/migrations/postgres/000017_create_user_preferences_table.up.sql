@@ -0,0 +1,10 @@
+CREATE TABLE user_preferences (
+  user_id bigint NOT NULL,
+  timezone varchar(64) NOT NULL,
+  locale varchar(16) NOT NULL,
+  email_notifications boolean NOT NULL DEFAULT true,
+  theme varchar(16) NOT NULL,
+  updated_at timestamptz(3) DEFAULT NULL,
+  PRIMARY KEY (user_id),
+  CONSTRAINT fk_user_preferences_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+ALTER TABLE users
+  ADD COLUMN last_login_at timestamptz(3) DEFAULT NULL,
+  ADD COLUMN last_login_ip varchar(45) DEFAULT NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_users_status_deletion_requested_at;
+ALTER TABLE users DROP COLUMN deletion_requested_at, DROP COLUMN status;
@@ -0,0 +1,4 @@
+ALTER TABLE users
+  ADD COLUMN status varchar(20) NOT NULL DEFAULT 'active',
+  ADD COLUMN deletion_requested_at timestamptz(3) DEFAULT NULL;
+CREATE INDEX idx_users_status_deletion_requested_at ON users (status, deletion_requested_at);
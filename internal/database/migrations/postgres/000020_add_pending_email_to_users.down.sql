@@ -0,0 +1 @@
+ALTER TABLE users DROP CONSTRAINT uni_users_email_change_token, DROP COLUMN email_change_expired_at, DROP COLUMN email_change_token, DROP COLUMN pending_email;
@@ -0,0 +1,5 @@
+ALTER TABLE users
+  ADD COLUMN pending_email varchar(45) DEFAULT NULL,
+  ADD COLUMN email_change_token varchar(100) DEFAULT NULL,
+  ADD COLUMN email_change_expired_at bigint DEFAULT NULL,
+  ADD CONSTRAINT uni_users_email_change_token UNIQUE (email_change_token);
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN token_purpose varchar(20) DEFAULT NULL;
@@ -0,0 +1 @@
+UPDATE users SET token_purpose = NULL;
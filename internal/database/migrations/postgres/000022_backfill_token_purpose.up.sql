@@ -0,0 +1 @@
+UPDATE users SET token_purpose = CASE WHEN verified_at IS NULL THEN 'verify_email' ELSE 'reset_password' END WHERE token IS NOT NULL;
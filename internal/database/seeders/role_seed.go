@@ -0,0 +1,20 @@
+package seeders
+
+import (
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// SeedRoles creates the default roles if they do not exist yet
+func SeedRoles(db *gorm.DB) error {
+	for _, name := range []string{constants.ROLE_ADMIN, constants.ROLE_USER} {
+		role := models.Role{Name: name}
+		if err := db.Where(models.Role{Name: name}).FirstOrCreate(&role).Error; err != nil {
+			logger.Errorf("Error creating role %s: %v", name, err)
+			return err
+		}
+	}
+	return nil
+}
@@ -8,6 +8,11 @@ import (
 // Run executes all seed functions to populate the database with initial data
 // It takes a GORM database connection as input and panics if any seeding operation fails
 func Run(db *gorm.DB) {
+	// SeedRoles seeds the roles table
+	if err := SeedRoles(db); err != nil {
+		logger.Errorf("Failed to seed roles: %+v", err)
+	}
+
 	// SeedUsers seeds the users table
 	if err := SeedUsers(db); err != nil {
 		logger.Errorf("Failed to seed users: %+v", err)
@@ -1,35 +1,50 @@
 package seeders
 
 import (
+	"time"
+
 	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
 	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
 	"gorm.io/gorm"
 )
 
 type UserSeeder struct {
-	User *models.User
+	User  *models.User
+	Roles []string
 }
 
 func SeedUsers(db *gorm.DB) error {
+	verifiedAt := time.Now()
 	users := []UserSeeder{
 		{
 			User: &models.User{
-				Name:     "John Doe",
-				Email:    "john@example.com",
-				Password: utils.HashPassword("password123"),
+				Name:       "John Doe",
+				Email:      "john@example.com",
+				Password:   utils.HashPassword("password123"),
+				VerifiedAt: &verifiedAt,
 			},
+			Roles: []string{constants.ROLE_ADMIN, constants.ROLE_USER},
 		},
 		{
 			User: &models.User{
-				Name:     "Jane Smith",
-				Email:    "jane@example.com",
-				Password: utils.HashPassword("password123"),
+				Name:       "Jane Smith",
+				Email:      "jane@example.com",
+				Password:   utils.HashPassword("password123"),
+				VerifiedAt: &verifiedAt,
 			},
+			Roles: []string{constants.ROLE_USER},
 		},
 	}
 
 	for _, userData := range users {
+		// Attach the seeded roles
+		if err := db.Where("name IN ?", userData.Roles).Find(&userData.User.Roles).Error; err != nil {
+			logger.Errorf("Error loading roles for user %s: %v", userData.User.Name, err)
+			continue
+		}
+
 		// Create new user
 		if err := db.Create(&userData.User).Error; err != nil {
 			logger.Errorf("Error creating user %s: %v", userData.User.Name, err)
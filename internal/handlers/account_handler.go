@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/audit"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+type AccountHandler interface {
+	DeleteAccount(c *gin.Context)
+	Reactivate(c *gin.Context)
+}
+
+type accountHandlerImpl struct {
+	accountDeletionService services.AccountDeletionService
+	auditLogger            audit.AuditLogger
+}
+
+func NewAccountHandler(accountDeletionService services.AccountDeletionService, auditLogger audit.AuditLogger) AccountHandler {
+	return &accountHandlerImpl{
+		accountDeletionService: accountDeletionService,
+		auditLogger:            auditLogger,
+	}
+}
+
+// DeleteAccount schedules the caller's account for deletion after the grace period and logs them out everywhere
+func (handler *accountHandlerImpl) DeleteAccount(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		utils.RespondWithError(ctx, apperror.NewParseError("Invalid UserID"))
+		return
+	}
+
+	var input dto.DeleteAccountInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		validateErr := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, input)
+		utils.RespondWithError(ctx, validateErr)
+		return
+	}
+
+	accessToken, _ := utils.GetAccessTokenFromContext(ctx)
+	res, err := handler.accountDeletionService.RequestDeletion(ctx.Request.Context(), userID, input.Password, accessToken)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Account deletion request failed for user %d: %v", userID, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	handler.auditLogger.Record(ctx, audit.ActionDeletionRequested, userID, audit.User(userID), map[string]any{
+		"deletion_scheduled_at": res.DeletionScheduledAt.Format(time.RFC3339),
+	})
+	utils.RespondWithOK(ctx, http.StatusOK, res)
+}
+
+// Reactivate cancels the deletion of the account with the given credentials during the grace period
+func (handler *accountHandlerImpl) Reactivate(ctx *gin.Context) {
+	var input dto.ReactivateAccountInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		validateErr := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, input)
+		utils.RespondWithError(ctx, validateErr)
+		return
+	}
+
+	userID, err := handler.accountDeletionService.Reactivate(ctx.Request.Context(), input.Email, input.Password)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Reactivation failed for email %s: %v", input.Email, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	handler.auditLogger.Record(ctx, audit.ActionAccountReactivated, userID, audit.User(userID), nil)
+	utils.RespondWithOK(ctx, http.StatusOK, gin.H{"message": "Account reactivated successfully"})
+}
@@ -0,0 +1,142 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/handlers"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/audit"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+func TestAccountHandler_DeleteAccount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	accessToken := &dto.AccessTokenInfo{ID: "token-id"}
+
+	newDeleteContext := func(body string, userID any) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("DELETE", "/api/v1/profile", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		if userID != nil {
+			c.Set("UserID", userID)
+			c.Set("AccessToken", accessToken)
+		}
+		return w, c
+	}
+
+	t.Run("Success Is Audited", func(t *testing.T) {
+		service := new(mocks.MockAccountDeletionService)
+		var auditBuf bytes.Buffer
+		handler := handlers.NewAccountHandler(service, audit.NewAuditLogger(&auditBuf))
+		scheduledAt := time.Date(2026, 11, 15, 10, 0, 0, 0, time.UTC)
+		service.On("RequestDeletion", mock.Anything, uint(1), "password123", accessToken).Return(&dto.AccountDeletionResponse{DeletionScheduledAt: scheduledAt}, nil).Once()
+
+		w, c := newDeleteContext(`{"password":"password123"}`, uint(1))
+		handler.DeleteAccount(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"deletion_scheduled_at":"2026-11-15T10:00:00Z"}`, w.Body.String())
+		var entry audit.Entry
+		require.NoError(t, json.Unmarshal(auditBuf.Bytes(), &entry))
+		assert.Equal(t, audit.ActionDeletionRequested, entry.Action)
+		assert.Equal(t, uint(1), entry.TargetID)
+		service.AssertExpectations(t)
+	})
+
+	t.Run("Missing Password", func(t *testing.T) {
+		service := new(mocks.MockAccountDeletionService)
+		handler := handlers.NewAccountHandler(service, discardAuditLogger)
+
+		w, c := newDeleteContext(`{}`, uint(1))
+		handler.DeleteAccount(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		service.AssertNotCalled(t, "RequestDeletion", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Invalid UserID ctx", func(t *testing.T) {
+		service := new(mocks.MockAccountDeletionService)
+		handler := handlers.NewAccountHandler(service, discardAuditLogger)
+
+		w, c := newDeleteContext(`{"password":"password123"}`, nil)
+		handler.DeleteAccount(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		service.AssertNotCalled(t, "RequestDeletion", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Wrong Password", func(t *testing.T) {
+		service := new(mocks.MockAccountDeletionService)
+		handler := handlers.NewAccountHandler(service, discardAuditLogger)
+		service.On("RequestDeletion", mock.Anything, uint(1), "wrong", accessToken).Return(nil, apperror.NewInvalidPasswordError("Password is incorrect")).Once()
+
+		w, c := newDeleteContext(`{"password":"wrong"}`, uint(1))
+		handler.DeleteAccount(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":3002`)
+	})
+}
+
+func TestAccountHandler_Reactivate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newReactivateContext := func(body string) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/v1/reactivate", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		return w, c
+	}
+
+	t.Run("Success Is Audited", func(t *testing.T) {
+		service := new(mocks.MockAccountDeletionService)
+		var auditBuf bytes.Buffer
+		handler := handlers.NewAccountHandler(service, audit.NewAuditLogger(&auditBuf))
+		service.On("Reactivate", mock.Anything, "pending@example.com", "password123").Return(uint(1), nil).Once()
+
+		w, c := newReactivateContext(`{"email":"pending@example.com","password":"password123"}`)
+		handler.Reactivate(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"message":"Account reactivated successfully"}`, w.Body.String())
+		var entry audit.Entry
+		require.NoError(t, json.Unmarshal(auditBuf.Bytes(), &entry))
+		assert.Equal(t, audit.ActionAccountReactivated, entry.Action)
+		assert.Equal(t, uint(1), entry.TargetID)
+	})
+
+	t.Run("Invalid Email", func(t *testing.T) {
+		service := new(mocks.MockAccountDeletionService)
+		handler := handlers.NewAccountHandler(service, discardAuditLogger)
+
+		w, c := newReactivateContext(`{"email":"not-an-email","password":"password123"}`)
+		handler.Reactivate(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		service.AssertNotCalled(t, "Reactivate", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Grace Period Ended", func(t *testing.T) {
+		service := new(mocks.MockAccountDeletionService)
+		handler := handlers.NewAccountHandler(service, discardAuditLogger)
+		service.On("Reactivate", mock.Anything, "pending@example.com", "password123").Return(uint(0), apperror.NewForbiddenError("The grace period to reactivate the account has ended")).Once()
+
+		w, c := newReactivateContext(`{"email":"pending@example.com","password":"password123"}`)
+		handler.Reactivate(c)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/middlewares"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+type AuditLogHandler interface {
+	GetAuditLogs(c *gin.Context)
+}
+
+type auditLogHandlerImpl struct {
+	auditService services.AuditService
+}
+
+func NewAuditLogHandler(auditService services.AuditService) AuditLogHandler {
+	return &auditLogHandlerImpl{
+		auditService: auditService,
+	}
+}
+
+// GetAuditLogs lists audit logs. Paging and sorting are prepared by middlewares.ListOptionsMiddleware,
+// actor, action and date range filters are read from the query string.
+func (handler *auditLogHandlerImpl) GetAuditLogs(ctx *gin.Context) {
+	opts, ok := middlewares.GetListOptions(ctx)
+	if !ok {
+		utils.RespondWithError(ctx, apperror.NewInternalServerError("List options are not available"))
+		return
+	}
+
+	var filter dto.AuditLogFilterInput
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		validateError := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, filter)
+		utils.RespondWithError(ctx, validateError)
+		return
+	}
+
+	logs, err := handler.auditService.GetAuditLogs(ctx.Request.Context(), opts, filter)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Get audit logs failed: %v", err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	utils.SetPaginationLinks(ctx, logs)
+	utils.RespondWithOK(ctx, http.StatusOK, logs)
+}
@@ -0,0 +1,125 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/handlers"
+	"github.com/vfa-khuongdv/golang-cms/internal/middlewares"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+func TestGetAuditLogs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	utils.InitValidator()
+
+	opts := dto.ListOptions{Page: 1, Limit: 20, SortBy: "created_at", SortDir: "desc"}
+
+	newGetAuditLogsContext := func(query string, withOptions bool) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/api/v1/audit-logs?"+query, nil)
+		if withOptions {
+			c.Set(middlewares.ListOptionsKey, opts)
+		}
+		return w, c
+	}
+
+	t.Run("GetAuditLogs - Success With Filters", func(t *testing.T) {
+		auditService := new(mocks.MockAuditService)
+		handler := handlers.NewAuditLogHandler(auditService)
+
+		actor := uint(1)
+		from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2025, 1, 31, 23, 59, 59, 0, time.UTC)
+		auditService.On("GetAuditLogs", mock.Anything, opts, mock.MatchedBy(func(filter dto.AuditLogFilterInput) bool {
+			return filter.ActorUserID != nil && *filter.ActorUserID == actor &&
+				filter.Action == "auth.login" &&
+				filter.From != nil && filter.From.Equal(from) &&
+				filter.To != nil && filter.To.Equal(to)
+		})).Return(&dto.Pagination[*models.AuditLog]{
+			Page:       1,
+			Limit:      20,
+			TotalItems: 1,
+			TotalPages: 1,
+			Data:       []*models.AuditLog{{ID: 3, ActorUserID: &actor, Action: "auth.login", IP: "127.0.0.1", Metadata: json.RawMessage(`{"email":"john@example.com"}`)}},
+		}, nil)
+
+		w, c := newGetAuditLogsContext("actor_user_id=1&action=auth.login&from=2025-01-01T00:00:00Z&to=2025-01-31T23:59:59Z", true)
+		handler.GetAuditLogs(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			TotalItems int `json:"total_items"`
+			Data       []struct {
+				Action   string         `json:"action"`
+				Metadata map[string]any `json:"metadata"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.TotalItems)
+		require.Len(t, response.Data, 1)
+		assert.Equal(t, "auth.login", response.Data[0].Action)
+		assert.Equal(t, "john@example.com", response.Data[0].Metadata["email"])
+		auditService.AssertExpectations(t)
+	})
+
+	t.Run("GetAuditLogs - Invalid Filters", func(t *testing.T) {
+		queries := map[string]string{
+			"Actor Not Positive":   "actor_user_id=0",
+			"Actor Not A Number":   "actor_user_id=abc",
+			"Action Too Long":      "action=" + strings.Repeat("a", 65),
+			"Date Not RFC 3339":    "from=2025-01-01",
+			"Range Ends Before":    "from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z",
+			"End Date Not RFC3339": "to=yesterday",
+		}
+		for name, query := range queries {
+			t.Run(name, func(t *testing.T) {
+				auditService := new(mocks.MockAuditService)
+				handler := handlers.NewAuditLogHandler(auditService)
+
+				w, c := newGetAuditLogsContext(query, true)
+				handler.GetAuditLogs(c)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				auditService.AssertNotCalled(t, "GetAuditLogs", mock.Anything, mock.Anything, mock.Anything)
+			})
+		}
+	})
+
+	t.Run("GetAuditLogs - Missing List Options", func(t *testing.T) {
+		auditService := new(mocks.MockAuditService)
+		handler := handlers.NewAuditLogHandler(auditService)
+
+		w, c := newGetAuditLogsContext("", false)
+		handler.GetAuditLogs(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("GetAuditLogs - Service Error", func(t *testing.T) {
+		auditService := new(mocks.MockAuditService)
+		handler := handlers.NewAuditLogHandler(auditService)
+		auditService.On("GetAuditLogs", mock.Anything, opts, dto.AuditLogFilterInput{}).Return(nil, apperror.NewDBQueryError("Failed to get audit logs"))
+
+		w, c := newGetAuditLogsContext("", true)
+		handler.GetAuditLogs(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, float64(apperror.ErrDBQuery), response["code"])
+	})
+}
@@ -1,62 +1,128 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/audit"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
 	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
 )
 
+// DEVICE_FINGERPRINT_HEADER carries the client's device fingerprint, e.g. a hash of its user agent and languages.
+// Refresh tokens are bound to the fingerprint they are created with
+const DEVICE_FINGERPRINT_HEADER = "X-Device-Fingerprint"
+
 type AuthHandler interface {
 	Login(c *gin.Context)
 	RefreshToken(c *gin.Context)
+	Logout(c *gin.Context)
+	LogoutAll(c *gin.Context)
 }
 
 type authHandlerImpl struct {
 	authService services.AuthService
+	auditLogger audit.AuditLogger
 }
 
-func NewAuthHandler(authService services.AuthService) AuthHandler {
+func NewAuthHandler(authService services.AuthService, auditLogger audit.AuditLogger) AuthHandler {
 	return &authHandlerImpl{
 		authService: authService,
+		auditLogger: auditLogger,
 	}
 }
 
 func (handler *authHandlerImpl) Login(ctx *gin.Context) {
 	var credentials dto.LoginInput
 	if err := ctx.ShouldBindJSON(&credentials); err != nil {
-		validateErr := utils.TranslateValidationErrors(err, credentials)
+		validateErr := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, credentials)
 		utils.RespondWithError(ctx, validateErr)
 		return
 	}
 
-	res, err := handler.authService.Login(ctx.Request.Context(), credentials.Email, credentials.Password, ctx.ClientIP())
+	res, err := handler.authService.Login(ctx.Request.Context(), credentials.Email, credentials.Password, ctx.ClientIP(), ctx.Request.UserAgent(), ctx.GetHeader(DEVICE_FINGERPRINT_HEADER))
 	if err != nil {
 		logger.WithContext(ctx.Request.Context()).Errorf("Login failed for email %s: %v", credentials.Email, err)
+		handler.auditLogger.Record(ctx, audit.ActionLoginFailed, 0, audit.Target{}, map[string]any{"email": credentials.Email, "reason": err.Error()})
 		utils.RespondWithError(ctx, err)
 		return
 	}
 
+	handler.auditLogger.Record(ctx, audit.ActionLogin, res.UserID, audit.User(res.UserID), map[string]any{"email": credentials.Email})
 	utils.RespondWithOK(ctx, http.StatusOK, res)
 }
 
 func (handler *authHandlerImpl) RefreshToken(ctx *gin.Context) {
 	var input dto.RefreshTokenInput
 	if err := ctx.ShouldBindJSON(&input); err != nil {
-		validationErr := utils.TranslateValidationErrors(err, input)
+		validationErr := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, input)
 		utils.RespondWithError(ctx, validationErr)
 		return
 	}
 
-	res, err := handler.authService.RefreshToken(ctx.Request.Context(), input.RefreshToken, input.AccessToken, ctx.ClientIP())
+	res, err := handler.authService.RefreshToken(ctx.Request.Context(), input.RefreshToken, input.AccessToken, ctx.ClientIP(), ctx.Request.UserAgent(), ctx.GetHeader(DEVICE_FINGERPRINT_HEADER))
 	if err != nil {
 		logger.WithContext(ctx.Request.Context()).Errorf("Token refresh failed: %v", err)
+		var mismatch *services.FingerprintMismatch
+		if errors.As(err, &mismatch) {
+			handler.auditLogger.Record(ctx, audit.ActionDeviceMismatch, mismatch.UserID, audit.User(mismatch.UserID), map[string]any{
+				"session_id": mismatch.SessionID, "mode": string(mismatch.Mode), "revoked": true,
+			})
+		}
 		utils.RespondWithError(ctx, err)
 		return
 	}
 
+	if res.FingerprintMismatch {
+		handler.auditLogger.Record(ctx, audit.ActionDeviceMismatch, res.UserID, audit.User(res.UserID), map[string]any{
+			"mode": string(services.FINGERPRINT_MODE_LOG), "revoked": false,
+		})
+	}
 	utils.RespondWithOK(ctx, http.StatusOK, res)
 }
+
+func (handler *authHandlerImpl) Logout(ctx *gin.Context) {
+	userId, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		utils.RespondWithError(ctx, apperror.NewParseError("Invalid UserID"))
+		return
+	}
+
+	var input dto.LogoutInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		utils.RespondWithError(ctx, apperror.NewUnauthorizedError("Refresh token is required"))
+		return
+	}
+
+	// The access token is revoked on a best-effort basis, so a missing one does not fail the logout
+	accessToken, _ := utils.GetAccessTokenFromContext(ctx)
+	if err := handler.authService.Logout(ctx.Request.Context(), userId, input.RefreshToken, accessToken); err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Logout failed for user %d: %v", userId, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	utils.RespondWithOK(ctx, http.StatusOK, gin.H{"message": "Logout successfully"})
+}
+
+func (handler *authHandlerImpl) LogoutAll(ctx *gin.Context) {
+	userId, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		utils.RespondWithError(ctx, apperror.NewParseError("Invalid UserID"))
+		return
+	}
+
+	accessToken, _ := utils.GetAccessTokenFromContext(ctx)
+	count, err := handler.authService.LogoutAll(ctx.Request.Context(), userId, accessToken)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Logout all failed for user %d: %v", userId, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	utils.RespondWithOK(ctx, http.StatusOK, gin.H{"message": "Logout all sessions successfully", "revoked_count": count})
+}
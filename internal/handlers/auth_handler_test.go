@@ -3,6 +3,7 @@ package handlers_test
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -12,21 +13,27 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/vfa-khuongdv/golang-cms/internal/handlers"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/audit"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
 	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
 	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
 )
 
+// discardAuditLogger is used by handler tests that do not inspect audit entries
+var discardAuditLogger = audit.NewAuditLogger(io.Discard)
+
 func TestLogin(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	t.Run("Login - Success", func(t *testing.T) {
 		mockService := new(mocks.MockAuthService)
-		handler := handlers.NewAuthHandler(mockService)
+		var auditBuf bytes.Buffer
+		handler := handlers.NewAuthHandler(mockService, audit.NewAuditLogger(&auditBuf))
 
 		// Mock the service method
-		mockService.On("Login", mock.Anything, "email@gmail.com", "testpassword", mock.Anything).Return(
+		mockService.On("Login", mock.Anything, "email@gmail.com", "testpassword", mock.Anything, mock.Anything, mock.Anything).Return(
 			&dto.LoginResponse{
 				AccessToken: dto.JwtResult{
 					Token:     "testtoken",
@@ -36,6 +43,8 @@ func TestLogin(t *testing.T) {
 					Token:     "testrefreshtoken",
 					ExpiresAt: 0,
 				},
+				User:   &dto.LoginUser{ID: 7, Name: "Test User", Email: "email@gmail.com", Roles: []string{"admin"}},
+				UserID: 7,
 			}, nil,
 		)
 
@@ -60,19 +69,27 @@ func TestLogin(t *testing.T) {
 		assert.JSONEq(t, `
 		{
 			"access_token": {"token":"testtoken","expires_at":0},
-			"refresh_token": {"token":"testrefreshtoken","expires_at":0}
+			"refresh_token": {"token":"testrefreshtoken","expires_at":0},
+			"user": {"id":7,"name":"Test User","email":"email@gmail.com","roles":["admin"]}
 		}
 		`, w.Body.String())
 		// Assert that the mock service method was called
 		mockService.AssertExpectations(t)
+
+		var entry audit.Entry
+		assert.NoError(t, json.Unmarshal(auditBuf.Bytes(), &entry))
+		assert.Equal(t, audit.ActionLogin, entry.Action)
+		assert.Equal(t, uint(7), entry.UserID)
+		assert.NotContains(t, auditBuf.String(), "testpassword")
 	})
 
 	t.Run("Login - Create Error", func(t *testing.T) {
 		mockService := new(mocks.MockAuthService)
-		handler := handlers.NewAuthHandler(mockService)
+		var auditBuf bytes.Buffer
+		handler := handlers.NewAuthHandler(mockService, audit.NewAuditLogger(&auditBuf))
 
 		// Mock the service method
-		mockService.On("Login", mock.Anything, "email@gmail.com", "testpassword", mock.Anything).Return(nil, apperror.NewUnauthorizedError("Invalid email or password"))
+		mockService.On("Login", mock.Anything, "email@gmail.com", "testpassword", mock.Anything, mock.Anything, mock.Anything).Return(nil, apperror.NewUnauthorizedError("Invalid email or password"))
 
 		requestBody := map[string]string{
 			"email":    "email@gmail.com",
@@ -99,6 +116,11 @@ func TestLogin(t *testing.T) {
 		assert.Equal(t, expectedBody["code"], actualBody["code"])
 		assert.Equal(t, expectedBody["message"], actualBody["message"])
 
+		var entry audit.Entry
+		assert.NoError(t, json.Unmarshal(auditBuf.Bytes(), &entry))
+		assert.Equal(t, audit.ActionLoginFailed, entry.Action)
+		assert.Equal(t, "email@gmail.com", entry.Metadata["email"])
+
 		// Assert mocks
 		mockService.AssertExpectations(t)
 	})
@@ -107,11 +129,12 @@ func TestLogin(t *testing.T) {
 
 		// Create a mock service and handler
 		mockService := new(mocks.MockAuthService)
-		handler := handlers.NewAuthHandler(mockService)
+		handler := handlers.NewAuthHandler(mockService, discardAuditLogger)
 
 		tests := []struct {
 			name           string
 			reqBody        string
+			language       string
 			expectedCode   float64
 			expectedMsg    string
 			expectedFields []apperror.FieldError
@@ -189,6 +212,27 @@ func TestLogin(t *testing.T) {
 					{Field: "password", Message: "password is required"},
 				},
 			},
+			{
+				name:         "Vietnamese",
+				reqBody:      `{"email":"not-an-email"}`,
+				language:     "vi-VN,vi;q=0.9,en;q=0.8",
+				expectedCode: float64(4001),
+				expectedMsg:  "Dữ liệu không hợp lệ",
+				expectedFields: []apperror.FieldError{
+					{Field: "email", Message: "email phải là địa chỉ email hợp lệ"},
+					{Field: "password", Message: "password là bắt buộc"},
+				},
+			},
+			{
+				name:         "UnsupportedLanguage",
+				reqBody:      `{"email":"user@example.com"}`,
+				language:     "fr-FR",
+				expectedCode: float64(4001),
+				expectedMsg:  "Validation failed",
+				expectedFields: []apperror.FieldError{
+					{Field: "password", Message: "password is required"},
+				},
+			},
 		}
 
 		for _, tc := range tests {
@@ -197,6 +241,7 @@ func TestLogin(t *testing.T) {
 				w := httptest.NewRecorder()
 				c, _ := gin.CreateTestContext(w)
 				c.Request, _ = http.NewRequest("POST", "/api/v1/login", bytes.NewBufferString(tc.reqBody))
+				c.Request.Header.Set("Accept-Language", tc.language)
 
 				// Call the handler method
 				handler.Login(c)
@@ -229,10 +274,10 @@ func TestRefreshToken(t *testing.T) {
 
 	t.Run("RefreshToken - Success", func(t *testing.T) {
 		mockService := new(mocks.MockAuthService)
-		handler := handlers.NewAuthHandler(mockService)
+		handler := handlers.NewAuthHandler(mockService, discardAuditLogger)
 
 		// Mock the service method
-		mockService.On("RefreshToken", mock.Anything, "testrefreshtoken", "testaccesstoken", mock.Anything).Return(
+		mockService.On("RefreshToken", mock.Anything, "testrefreshtoken", "testaccesstoken", mock.Anything, mock.Anything, mock.Anything).Return(
 			&dto.LoginResponse{
 				AccessToken: dto.JwtResult{
 					Token:     "newtesttoken",
@@ -274,10 +319,10 @@ func TestRefreshToken(t *testing.T) {
 
 	t.Run("RefreshToken - Success With AccessToken", func(t *testing.T) {
 		mockService := new(mocks.MockAuthService)
-		handler := handlers.NewAuthHandler(mockService)
+		handler := handlers.NewAuthHandler(mockService, discardAuditLogger)
 
 		// Mock the service method when using access token
-		mockService.On("RefreshToken", mock.Anything, "testrefreshtoken", "testaccesstoken", mock.Anything).Return(
+		mockService.On("RefreshToken", mock.Anything, "testrefreshtoken", "testaccesstoken", mock.Anything, mock.Anything, mock.Anything).Return(
 			&dto.LoginResponse{
 				AccessToken: dto.JwtResult{
 					Token:     "newtesttoken",
@@ -319,10 +364,10 @@ func TestRefreshToken(t *testing.T) {
 
 	t.Run("RefreshToken - Success With Both Tokens", func(t *testing.T) {
 		mockService := new(mocks.MockAuthService)
-		handler := handlers.NewAuthHandler(mockService)
+		handler := handlers.NewAuthHandler(mockService, discardAuditLogger)
 
 		// Mock the service method - should prefer refresh token
-		mockService.On("RefreshToken", mock.Anything, "testrefreshtoken", "testaccesstoken", mock.Anything).Return(
+		mockService.On("RefreshToken", mock.Anything, "testrefreshtoken", "testaccesstoken", mock.Anything, mock.Anything, mock.Anything).Return(
 			&dto.LoginResponse{
 				AccessToken: dto.JwtResult{
 					Token:     "newtesttoken",
@@ -364,10 +409,10 @@ func TestRefreshToken(t *testing.T) {
 
 	t.Run("RefreshToken - Error Invalid Token", func(t *testing.T) {
 		mockService := new(mocks.MockAuthService)
-		handler := handlers.NewAuthHandler(mockService)
+		handler := handlers.NewAuthHandler(mockService, discardAuditLogger)
 
 		// Mock the service method
-		mockService.On("RefreshToken", mock.Anything, "invalidtoken", "validaccesstoken", mock.Anything).Return(nil, apperror.NewUnauthorizedError("Invalid refresh token"))
+		mockService.On("RefreshToken", mock.Anything, "invalidtoken", "validaccesstoken", mock.Anything, mock.Anything, mock.Anything).Return(nil, apperror.NewUnauthorizedError("Invalid refresh token"))
 		reqBody := map[string]string{
 			"refresh_token": "invalidtoken",
 			"access_token":  "validaccesstoken",
@@ -401,7 +446,7 @@ func TestRefreshToken(t *testing.T) {
 
 	t.Run("RefreshToken - Validation Errors", func(t *testing.T) {
 		mockService := new(mocks.MockAuthService)
-		handler := handlers.NewAuthHandler(mockService)
+		handler := handlers.NewAuthHandler(mockService, discardAuditLogger)
 
 		tests := []struct {
 			name           string
@@ -480,4 +525,177 @@ func TestRefreshToken(t *testing.T) {
 		}
 	})
 
+	t.Run("RefreshToken - Device Mismatch Is Audited", func(t *testing.T) {
+		mockService := new(mocks.MockAuthService)
+		var auditBuf bytes.Buffer
+		handler := handlers.NewAuthHandler(mockService, audit.NewAuditLogger(&auditBuf))
+
+		appErr := apperror.NewDeviceMismatchError("Refresh token was issued to another device")
+		appErr.Err = &services.FingerprintMismatch{UserID: 3, SessionID: 7, Mode: services.FINGERPRINT_MODE_ENFORCE}
+		mockService.On("RefreshToken", mock.Anything, "testrefreshtoken", "testaccesstoken", mock.Anything, mock.Anything, "device-2").Return(nil, appErr)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/v1/refresh-token", bytes.NewBufferString(`{"refresh_token":"testrefreshtoken","access_token":"testaccesstoken"}`))
+		c.Request.Header.Set(handlers.DEVICE_FINGERPRINT_HEADER, "device-2")
+
+		handler.RefreshToken(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":3010`)
+		var entry audit.Entry
+		assert.NoError(t, json.Unmarshal(auditBuf.Bytes(), &entry))
+		assert.Equal(t, audit.ActionDeviceMismatch, entry.Action)
+		assert.Equal(t, uint(3), entry.UserID)
+		assert.Equal(t, map[string]any{"session_id": float64(7), "mode": "enforce", "revoked": true}, entry.Metadata)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("RefreshToken - Device Mismatch In Log Mode Is Audited", func(t *testing.T) {
+		mockService := new(mocks.MockAuthService)
+		var auditBuf bytes.Buffer
+		handler := handlers.NewAuthHandler(mockService, audit.NewAuditLogger(&auditBuf))
+
+		mockService.On("RefreshToken", mock.Anything, "testrefreshtoken", "testaccesstoken", mock.Anything, mock.Anything, "device-2").Return(
+			&dto.LoginResponse{UserID: 3, FingerprintMismatch: true}, nil,
+		)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/v1/refresh-token", bytes.NewBufferString(`{"refresh_token":"testrefreshtoken","access_token":"testaccesstoken"}`))
+		c.Request.Header.Set(handlers.DEVICE_FINGERPRINT_HEADER, "device-2")
+
+		handler.RefreshToken(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "fingerprint")
+		var entry audit.Entry
+		assert.NoError(t, json.Unmarshal(auditBuf.Bytes(), &entry))
+		assert.Equal(t, audit.ActionDeviceMismatch, entry.Action)
+		assert.Equal(t, map[string]any{"mode": "log", "revoked": false}, entry.Metadata)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestLogout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	accessToken := &dto.AccessTokenInfo{ID: "token-id"}
+
+	newLogoutContext := func(body string, userID any) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/v1/logout", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		if userID != nil {
+			c.Set("UserID", userID)
+			c.Set("AccessToken", accessToken)
+		}
+		return w, c
+	}
+
+	t.Run("Logout - Success", func(t *testing.T) {
+		mockService := new(mocks.MockAuthService)
+		handler := handlers.NewAuthHandler(mockService, discardAuditLogger)
+		mockService.On("Logout", mock.Anything, uint(1), "testrefreshtoken", accessToken).Return(nil)
+
+		w, c := newLogoutContext(`{"refresh_token":"testrefreshtoken"}`, uint(1))
+		handler.Logout(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"message":"Logout successfully"}`, w.Body.String())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Logout - Missing Refresh Token", func(t *testing.T) {
+		mockService := new(mocks.MockAuthService)
+		handler := handlers.NewAuthHandler(mockService, discardAuditLogger)
+
+		w, c := newLogoutContext(`{"access_token":"testaccesstoken"}`, uint(1))
+		handler.Logout(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		var response map[string]any
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(apperror.ErrUnauthorized), response["code"])
+		assert.Equal(t, "Refresh token is required", response["message"])
+		mockService.AssertNotCalled(t, "Logout", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Logout - Invalid UserID ctx", func(t *testing.T) {
+		mockService := new(mocks.MockAuthService)
+		handler := handlers.NewAuthHandler(mockService, discardAuditLogger)
+
+		w, c := newLogoutContext(`{"refresh_token":"testrefreshtoken"}`, nil)
+		handler.Logout(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "Logout", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Logout - Service Error", func(t *testing.T) {
+		mockService := new(mocks.MockAuthService)
+		handler := handlers.NewAuthHandler(mockService, discardAuditLogger)
+		mockService.On("Logout", mock.Anything, uint(1), "testrefreshtoken", accessToken).Return(apperror.NewDBDeleteError("Failed to delete refresh token"))
+
+		w, c := newLogoutContext(`{"refresh_token":"testrefreshtoken"}`, uint(1))
+		handler.Logout(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestLogoutAll(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	accessToken := &dto.AccessTokenInfo{ID: "token-id"}
+
+	t.Run("LogoutAll - Success", func(t *testing.T) {
+		mockService := new(mocks.MockAuthService)
+		handler := handlers.NewAuthHandler(mockService, discardAuditLogger)
+		mockService.On("LogoutAll", mock.Anything, uint(1), accessToken).Return(int64(3), nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/v1/logout-all", nil)
+		c.Set("UserID", uint(1))
+		c.Set("AccessToken", accessToken)
+
+		handler.LogoutAll(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"message":"Logout all sessions successfully","revoked_count":3}`, w.Body.String())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("LogoutAll - Invalid UserID ctx", func(t *testing.T) {
+		mockService := new(mocks.MockAuthService)
+		handler := handlers.NewAuthHandler(mockService, discardAuditLogger)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/v1/logout-all", nil)
+
+		handler.LogoutAll(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "LogoutAll", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("LogoutAll - Service Error", func(t *testing.T) {
+		mockService := new(mocks.MockAuthService)
+		handler := handlers.NewAuthHandler(mockService, discardAuditLogger)
+		mockService.On("LogoutAll", mock.Anything, uint(1), accessToken).Return(int64(0), apperror.NewDBDeleteError("Failed to delete refresh tokens"))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/v1/logout-all", nil)
+		c.Set("UserID", uint(1))
+		c.Set("AccessToken", accessToken)
+
+		handler.LogoutAll(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		mockService.AssertExpectations(t)
+	})
 }
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/audit"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+type AvatarHandler interface {
+	UploadAvatar(c *gin.Context)
+	DeleteAvatar(c *gin.Context)
+}
+
+type avatarHandlerImpl struct {
+	avatarService services.AvatarService
+	auditLogger   audit.AuditLogger
+}
+
+func NewAvatarHandler(avatarService services.AvatarService, auditLogger audit.AuditLogger) AvatarHandler {
+	return &avatarHandlerImpl{
+		avatarService: avatarService,
+		auditLogger:   auditLogger,
+	}
+}
+
+// UploadAvatar replaces the caller's avatar with the image uploaded in the "avatar" field of a multipart form
+func (handler *avatarHandlerImpl) UploadAvatar(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		utils.RespondWithError(ctx, apperror.NewParseError("Invalid UserID"))
+		return
+	}
+
+	file, err := multipartFile(ctx, "avatar")
+	if err != nil {
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	res, err := handler.avatarService.Upload(ctx.Request.Context(), userID, file)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Upload avatar failed for user %d: %v", userID, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	handler.auditLogger.Record(ctx, audit.ActionAvatarUpdated, userID, audit.User(userID), nil)
+	utils.RespondWithOK(ctx, http.StatusOK, res)
+}
+
+// DeleteAvatar removes the caller's avatar. It succeeds if they have none
+func (handler *avatarHandlerImpl) DeleteAvatar(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		utils.RespondWithError(ctx, apperror.NewParseError("Invalid UserID"))
+		return
+	}
+
+	if err := handler.avatarService.Delete(ctx.Request.Context(), userID); err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Delete avatar failed for user %d: %v", userID, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	handler.auditLogger.Record(ctx, audit.ActionAvatarDeleted, userID, audit.User(userID), nil)
+	utils.RespondWithOK(ctx, http.StatusOK, gin.H{"message": "Avatar deleted successfully"})
+}
@@ -0,0 +1,141 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/handlers"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/audit"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+func TestAvatarHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newUpload := func(field, fileName string, content []byte) (*httptest.ResponseRecorder, *gin.Context) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, _ := form.CreateFormFile(field, fileName)
+		_, _ = part.Write(content)
+		_ = form.Close()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/v1/profile/avatar", &body)
+		c.Request.Header.Set("Content-Type", form.FormDataContentType())
+		c.Set("UserID", uint(1))
+		return w, c
+	}
+
+	t.Run("UploadAvatar - Success Is Audited", func(t *testing.T) {
+		avatarService := new(mocks.MockAvatarService)
+		var auditBuf bytes.Buffer
+		handler := handlers.NewAvatarHandler(avatarService, audit.NewAuditLogger(&auditBuf))
+		avatarService.On("Upload", mock.Anything, uint(1), mock.MatchedBy(func(r io.Reader) bool {
+			content, _ := io.ReadAll(r)
+			return string(content) == "image-bytes"
+		})).Return(&dto.AvatarResponse{Avatar: "http://localhost:3000/uploads/avatars/1/a.png"}, nil)
+
+		w, c := newUpload("avatar", "me.png", []byte("image-bytes"))
+		handler.UploadAvatar(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"avatar":"http://localhost:3000/uploads/avatars/1/a.png"}`, w.Body.String())
+		var entry audit.Entry
+		require.NoError(t, json.Unmarshal(auditBuf.Bytes(), &entry))
+		assert.Equal(t, audit.ActionAvatarUpdated, entry.Action)
+		assert.Equal(t, uint(1), entry.TargetID)
+	})
+
+	t.Run("UploadAvatar - Missing Field", func(t *testing.T) {
+		avatarService := new(mocks.MockAvatarService)
+		handler := handlers.NewAvatarHandler(avatarService, discardAuditLogger)
+
+		w, c := newUpload("file", "me.png", []byte("image-bytes"))
+		handler.UploadAvatar(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		avatarService.AssertNotCalled(t, "Upload", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("UploadAvatar - Not Multipart", func(t *testing.T) {
+		avatarService := new(mocks.MockAvatarService)
+		handler := handlers.NewAvatarHandler(avatarService, discardAuditLogger)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/v1/profile/avatar", bytes.NewBufferString(`{}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("UserID", uint(1))
+		handler.UploadAvatar(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("UploadAvatar - Unsupported Type", func(t *testing.T) {
+		avatarService := new(mocks.MockAvatarService)
+		var auditBuf bytes.Buffer
+		handler := handlers.NewAvatarHandler(avatarService, audit.NewAuditLogger(&auditBuf))
+		avatarService.On("Upload", mock.Anything, uint(1), mock.Anything).Return(nil, apperror.NewUnsupportedMediaError("Avatar must be a JPEG, PNG or WebP image"))
+
+		w, c := newUpload("avatar", "me.png", []byte("not an image"))
+		handler.UploadAvatar(c)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		assert.Empty(t, auditBuf.String())
+	})
+
+	t.Run("UploadAvatar - Invalid UserID", func(t *testing.T) {
+		handler := handlers.NewAvatarHandler(new(mocks.MockAvatarService), discardAuditLogger)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/v1/profile/avatar", nil)
+		handler.UploadAvatar(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("DeleteAvatar - Success Is Audited", func(t *testing.T) {
+		avatarService := new(mocks.MockAvatarService)
+		var auditBuf bytes.Buffer
+		handler := handlers.NewAvatarHandler(avatarService, audit.NewAuditLogger(&auditBuf))
+		avatarService.On("Delete", mock.Anything, uint(1)).Return(nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("DELETE", "/api/v1/profile/avatar", nil)
+		c.Set("UserID", uint(1))
+		handler.DeleteAvatar(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var entry audit.Entry
+		require.NoError(t, json.Unmarshal(auditBuf.Bytes(), &entry))
+		assert.Equal(t, audit.ActionAvatarDeleted, entry.Action)
+	})
+
+	t.Run("DeleteAvatar - Error", func(t *testing.T) {
+		avatarService := new(mocks.MockAvatarService)
+		handler := handlers.NewAvatarHandler(avatarService, discardAuditLogger)
+		avatarService.On("Delete", mock.Anything, uint(1)).Return(apperror.NewDBUpdateError("Failed to delete avatar"))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("DELETE", "/api/v1/profile/avatar", nil)
+		c.Set("UserID", uint(1))
+		handler.DeleteAvatar(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
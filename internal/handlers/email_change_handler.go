@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/audit"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+type EmailChangeHandler interface {
+	RequestEmailChange(c *gin.Context)
+	ConfirmEmailChange(c *gin.Context)
+}
+
+type emailChangeHandlerImpl struct {
+	emailChangeService services.EmailChangeService
+	auditLogger        audit.AuditLogger
+}
+
+func NewEmailChangeHandler(emailChangeService services.EmailChangeService, auditLogger audit.AuditLogger) EmailChangeHandler {
+	return &emailChangeHandlerImpl{
+		emailChangeService: emailChangeService,
+		auditLogger:        auditLogger,
+	}
+}
+
+// RequestEmailChange mails a confirmation link to the new email of the caller. The email changes once it is opened
+func (handler *emailChangeHandlerImpl) RequestEmailChange(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		utils.RespondWithError(ctx, apperror.NewParseError("Invalid UserID"))
+		return
+	}
+
+	var input dto.ChangeEmailInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		validateErr := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, input)
+		utils.RespondWithError(ctx, validateErr)
+		return
+	}
+
+	if err := handler.emailChangeService.RequestEmailChange(ctx.Request.Context(), userID, &input); err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Email change request failed for user %d: %v", userID, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	handler.auditLogger.Record(ctx, audit.ActionEmailChangeRequest, userID, audit.User(userID), map[string]any{
+		"new_email": utils.NormalizeEmail(input.NewEmail),
+	})
+	utils.RespondWithOK(ctx, http.StatusOK, gin.H{"message": "Confirmation email sent to the new address"})
+}
+
+// ConfirmEmailChange replaces the email of the owner of the token with the one they requested
+func (handler *emailChangeHandlerImpl) ConfirmEmailChange(ctx *gin.Context) {
+	var input dto.ConfirmEmailChangeInput
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		validateErr := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, input)
+		utils.RespondWithError(ctx, validateErr)
+		return
+	}
+
+	user, err := handler.emailChangeService.ConfirmEmailChange(ctx.Request.Context(), input.Token)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Confirm email change failed: %v", err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	handler.auditLogger.Record(ctx, audit.ActionEmailChanged, user.ID, audit.User(user.ID), map[string]any{
+		"email": user.Email,
+	})
+	utils.RespondWithOK(ctx, http.StatusOK, gin.H{"message": "Email changed successfully"})
+}
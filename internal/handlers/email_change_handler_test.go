@@ -0,0 +1,138 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/handlers"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/audit"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+func TestEmailChangeHandler_RequestEmailChange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRequestContext := func(body string, userID any) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("PUT", "/api/v1/profile/email", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		if userID != nil {
+			c.Set("UserID", userID)
+		}
+		return w, c
+	}
+
+	t.Run("Success Is Audited", func(t *testing.T) {
+		service := new(mocks.MockEmailChangeService)
+		var auditBuf bytes.Buffer
+		handler := handlers.NewEmailChangeHandler(service, audit.NewAuditLogger(&auditBuf))
+		service.On("RequestEmailChange", mock.Anything, uint(1), &dto.ChangeEmailInput{NewEmail: "new@example.com", Password: "password123"}).Return(nil).Once()
+
+		w, c := newRequestContext(`{"new_email":"new@example.com","password":"password123"}`, uint(1))
+		handler.RequestEmailChange(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"message":"Confirmation email sent to the new address"}`, w.Body.String())
+		var entry audit.Entry
+		require.NoError(t, json.Unmarshal(auditBuf.Bytes(), &entry))
+		assert.Equal(t, audit.ActionEmailChangeRequest, entry.Action)
+		assert.Equal(t, uint(1), entry.TargetID)
+		service.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Email", func(t *testing.T) {
+		service := new(mocks.MockEmailChangeService)
+		handler := handlers.NewEmailChangeHandler(service, discardAuditLogger)
+
+		w, c := newRequestContext(`{"new_email":"not-an-email","password":"password123"}`, uint(1))
+		handler.RequestEmailChange(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		service.AssertNotCalled(t, "RequestEmailChange", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Invalid UserID ctx", func(t *testing.T) {
+		service := new(mocks.MockEmailChangeService)
+		handler := handlers.NewEmailChangeHandler(service, discardAuditLogger)
+
+		w, c := newRequestContext(`{"new_email":"new@example.com","password":"password123"}`, nil)
+		handler.RequestEmailChange(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		service.AssertNotCalled(t, "RequestEmailChange", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Duplicate Email", func(t *testing.T) {
+		service := new(mocks.MockEmailChangeService)
+		handler := handlers.NewEmailChangeHandler(service, discardAuditLogger)
+		service.On("RequestEmailChange", mock.Anything, uint(1), mock.Anything).Return(apperror.NewDuplicateEmailError("Email already registered")).Once()
+
+		w, c := newRequestContext(`{"new_email":"taken@example.com","password":"password123"}`, uint(1))
+		handler.RequestEmailChange(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestEmailChangeHandler_ConfirmEmailChange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newConfirmContext := func(query string) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/api/v1/confirm-email-change"+query, nil)
+		return w, c
+	}
+
+	t.Run("Success Is Audited", func(t *testing.T) {
+		service := new(mocks.MockEmailChangeService)
+		var auditBuf bytes.Buffer
+		handler := handlers.NewEmailChangeHandler(service, audit.NewAuditLogger(&auditBuf))
+		service.On("ConfirmEmailChange", mock.Anything, "token").Return(&models.User{ID: 1, Email: "new@example.com"}, nil).Once()
+
+		w, c := newConfirmContext("?token=token")
+		handler.ConfirmEmailChange(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"message":"Email changed successfully"}`, w.Body.String())
+		var entry audit.Entry
+		require.NoError(t, json.Unmarshal(auditBuf.Bytes(), &entry))
+		assert.Equal(t, audit.ActionEmailChanged, entry.Action)
+		assert.Equal(t, uint(1), entry.TargetID)
+	})
+
+	t.Run("Missing Token", func(t *testing.T) {
+		service := new(mocks.MockEmailChangeService)
+		handler := handlers.NewEmailChangeHandler(service, discardAuditLogger)
+
+		w, c := newConfirmContext("")
+		handler.ConfirmEmailChange(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		service.AssertNotCalled(t, "ConfirmEmailChange", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Expired Token", func(t *testing.T) {
+		service := new(mocks.MockEmailChangeService)
+		handler := handlers.NewEmailChangeHandler(service, discardAuditLogger)
+		service.On("ConfirmEmailChange", mock.Anything, "token").Return(nil, apperror.NewTokenExpiredError("Token has expired")).Once()
+
+		w, c := newConfirmContext("?token=token")
+		handler.ConfirmEmailChange(c)
+
+		assert.NotEqual(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Token has expired")
+	})
+}
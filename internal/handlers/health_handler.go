@@ -1,12 +1,90 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+	"gorm.io/gorm"
 )
 
-func HealthCheck(ctx *gin.Context) {
+// READINESS_TIMEOUT bounds how long a readiness probe waits for each dependency
+const READINESS_TIMEOUT = 2 * time.Second
+
+// readinessProbeKey is the cache key written and read back to check the cache round-trip
+const readinessProbeKey = "readyz:probe"
+
+type HealthHandler interface {
+	Healthz(c *gin.Context)
+	Readyz(c *gin.Context)
+}
+
+type healthHandlerImpl struct {
+	db           *gorm.DB
+	redisService services.RedisService
+}
+
+func NewHealthHandler(db *gorm.DB, redisService services.RedisService) HealthHandler {
+	return &healthHandlerImpl{
+		db:           db,
+		redisService: redisService,
+	}
+}
+
+// Healthz reports that the process is up. It does not check any dependency.
+func (handler *healthHandlerImpl) Healthz(ctx *gin.Context) {
 	utils.RespondWithOK(ctx, http.StatusOK, gin.H{"status": "healthy"})
 }
+
+// Readyz checks the database and the cache and responds 503 if either of them fails
+func (handler *healthHandlerImpl) Readyz(ctx *gin.Context) {
+	status := http.StatusOK
+	body := gin.H{"db": "ok", "redis": "ok"}
+
+	if err := handler.pingDB(ctx.Request.Context()); err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Readiness check failed for db: %v", err)
+		status = http.StatusServiceUnavailable
+		body["db"] = "error"
+	}
+	if err := handler.pingRedis(ctx.Request.Context()); err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Readiness check failed for redis: %v", err)
+		status = http.StatusServiceUnavailable
+		body["redis"] = "error"
+	}
+
+	utils.RespondWithOK(ctx, status, body)
+}
+
+func (handler *healthHandlerImpl) pingDB(ctx context.Context) error {
+	sqlDB, err := handler.db.DB()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, READINESS_TIMEOUT)
+	defer cancel()
+	return sqlDB.PingContext(ctx)
+}
+
+// pingRedis writes a short-lived value and reads it back
+func (handler *healthHandlerImpl) pingRedis(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, READINESS_TIMEOUT)
+	defer cancel()
+
+	if err := handler.redisService.Set(ctx, readinessProbeKey, "ok", READINESS_TIMEOUT); err != nil {
+		return err
+	}
+	value, err := handler.redisService.Get(ctx, readinessProbeKey)
+	if err != nil {
+		return err
+	}
+	if value != "ok" {
+		return errors.New("unexpected probe value")
+	}
+	return nil
+}
@@ -2,27 +2,110 @@ package handlers_test
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/vfa-khuongdv/golang-cms/internal/handlers"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
+func newHealthTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	return db
+}
+
+func serveHealth(handler handlers.HealthHandler, path string) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.GET("/healthz", handler.Healthz)
+	router.GET("/readyz", handler.Readyz)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	router.ServeHTTP(w, req)
+	return w
+}
+
 func TestHealthCheck(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	mockRouter := gin.Default()
-	mockRouter.GET("/health", handlers.HealthCheck)
 
-	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("GET", "/health", nil)
-	mockRouter.ServeHTTP(w, req)
-
-	assert.Equal(t, http.StatusOK, w.Code)
-	var response map[string]string
-	err := json.Unmarshal(w.Body.Bytes(), &response)
-	assert.NoError(t, err)
-	assert.Equal(t, "healthy", response["status"])
+	t.Run("Healthz", func(t *testing.T) {
+		handler := handlers.NewHealthHandler(newHealthTestDB(t), services.NewMemoryRedisService(0))
+
+		w := serveHealth(handler, "/healthz")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]string
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, "healthy", response["status"])
+	})
+
+	t.Run("Readyz - Healthy", func(t *testing.T) {
+		handler := handlers.NewHealthHandler(newHealthTestDB(t), services.NewMemoryRedisService(0))
+
+		w := serveHealth(handler, "/readyz")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"db":"ok","redis":"ok"}`, w.Body.String())
+	})
+
+	t.Run("Readyz - DB Down", func(t *testing.T) {
+		db := newHealthTestDB(t)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+		handler := handlers.NewHealthHandler(db, services.NewMemoryRedisService(0))
+
+		w := serveHealth(handler, "/readyz")
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.JSONEq(t, `{"db":"error","redis":"ok"}`, w.Body.String())
+	})
+
+	t.Run("Readyz - Redis Set Error", func(t *testing.T) {
+		redisService := new(mocks.MockRedisService)
+		redisService.On("Set", mock.Anything, "readyz:probe", "ok", handlers.READINESS_TIMEOUT).Return(errors.New("connection refused"))
+		handler := handlers.NewHealthHandler(newHealthTestDB(t), redisService)
+
+		w := serveHealth(handler, "/readyz")
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.JSONEq(t, `{"db":"ok","redis":"error"}`, w.Body.String())
+		redisService.AssertExpectations(t)
+	})
+
+	t.Run("Readyz - Redis Get Error", func(t *testing.T) {
+		redisService := new(mocks.MockRedisService)
+		redisService.On("Set", mock.Anything, "readyz:probe", "ok", handlers.READINESS_TIMEOUT).Return(nil)
+		redisService.On("Get", mock.Anything, "readyz:probe").Return("", services.ErrCacheMiss)
+		handler := handlers.NewHealthHandler(newHealthTestDB(t), redisService)
+
+		w := serveHealth(handler, "/readyz")
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.JSONEq(t, `{"db":"ok","redis":"error"}`, w.Body.String())
+	})
+
+	t.Run("Readyz - Redis Unexpected Value", func(t *testing.T) {
+		redisService := new(mocks.MockRedisService)
+		redisService.On("Set", mock.Anything, "readyz:probe", "ok", handlers.READINESS_TIMEOUT).Return(nil)
+		redisService.On("Get", mock.Anything, "readyz:probe").Return("stale", nil)
+		handler := handlers.NewHealthHandler(newHealthTestDB(t), redisService)
+
+		w := serveHealth(handler, "/readyz")
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.JSONEq(t, `{"db":"ok","redis":"error"}`, w.Body.String())
+	})
 }
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+)
+
+type JWTKeyHandler interface {
+	GetKeys(c *gin.Context)
+	GetJWKS(c *gin.Context)
+}
+
+type jwtKeyHandlerImpl struct {
+	jwtService services.JWTService
+}
+
+func NewJWTKeyHandler(jwtService services.JWTService) JWTKeyHandler {
+	return &jwtKeyHandlerImpl{jwtService: jwtService}
+}
+
+// GetKeys lists the IDs of the JWT signing keys and which one is active, so operators can check a rotation.
+// Secrets are never returned
+func (handler *jwtKeyHandlerImpl) GetKeys(ctx *gin.Context) {
+	utils.RespondWithOK(ctx, http.StatusOK, gin.H{"data": handler.jwtService.Keys()})
+}
+
+// GetJWKS serves the public keys access tokens are signed with as a JSON Web Key Set, so other services can
+// verify our tokens without sharing a secret. The set is empty with HS256. It is public and not wrapped like
+// other responses, since JWKS clients expect the bare set
+func (handler *jwtKeyHandlerImpl) GetJWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, handler.jwtService.JWKS())
+}
@@ -0,0 +1,52 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/handlers"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+func TestGetJWTKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys := []dto.JWTKeyResponse{{ID: "2024", Active: false}, {ID: "2025", Active: true}}
+	jwtService := new(mocks.MockJWTService)
+	jwtService.On("Keys").Return(keys).Once()
+
+	router := gin.New()
+	router.GET("/admin/jwt-keys", handlers.NewJWTKeyHandler(jwtService).GetKeys)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin/jwt-keys", nil)
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data": [{"id": "2024", "active": false}, {"id": "2025", "active": true}]}`, w.Body.String())
+	assert.NotContains(t, w.Body.String(), "secret")
+	jwtService.AssertExpectations(t)
+}
+
+func TestGetJWKS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwks := dto.JWKSResponse{Keys: []dto.JWK{{Kty: "RSA", Use: "sig", Alg: "RS256", Kid: "thumbprint", N: "modulus", E: "AQAB"}}}
+	jwtService := new(mocks.MockJWTService)
+	jwtService.On("JWKS").Return(jwks).Once()
+
+	router := gin.New()
+	router.GET("/.well-known/jwks.json", handlers.NewJWTKeyHandler(jwtService).GetJWKS)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"keys": [{"kty": "RSA", "use": "sig", "alg": "RS256", "kid": "thumbprint", "n": "modulus", "e": "AQAB"}]}`, w.Body.String())
+	assert.Equal(t, "public, max-age=300", w.Header().Get("Cache-Control"))
+	jwtService.AssertExpectations(t)
+}
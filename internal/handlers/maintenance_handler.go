@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/audit"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+type MaintenanceHandler interface {
+	SetMaintenance(c *gin.Context)
+}
+
+type maintenanceHandlerImpl struct {
+	maintenanceService services.MaintenanceService
+	auditLogger        audit.AuditLogger
+}
+
+func NewMaintenanceHandler(maintenanceService services.MaintenanceService, auditLogger audit.AuditLogger) MaintenanceHandler {
+	return &maintenanceHandlerImpl{
+		maintenanceService: maintenanceService,
+		auditLogger:        auditLogger,
+	}
+}
+
+// SetMaintenance turns the maintenance mode on or off and returns the resulting status
+func (handler *maintenanceHandlerImpl) SetMaintenance(ctx *gin.Context) {
+	var input dto.SetMaintenanceInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		validateError := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, input)
+		utils.RespondWithError(ctx, validateError)
+		return
+	}
+
+	status, err := handler.maintenanceService.SetStatus(ctx.Request.Context(), &input)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Set maintenance mode failed: %v", err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	adminID, _ := utils.GetUserIDFromContext(ctx)
+	handler.auditLogger.Record(ctx, audit.ActionMaintenanceUpdated, adminID, audit.Target{}, map[string]any{"enabled": status.Enabled, "mode": status.Mode})
+
+	utils.RespondWithOK(ctx, http.StatusOK, status)
+}
@@ -0,0 +1,87 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/handlers"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/audit"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+func TestSetMaintenance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	utils.InitValidator()
+
+	newContext := func(body string) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/v1/admin/maintenance", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("UserID", uint(1))
+		return w, c
+	}
+
+	t.Run("SetMaintenance - Success Is Audited", func(t *testing.T) {
+		maintenanceService := new(mocks.MockMaintenanceService)
+		var auditBuf bytes.Buffer
+		handler := handlers.NewMaintenanceHandler(maintenanceService, audit.NewAuditLogger(&auditBuf))
+		status := &dto.MaintenanceStatus{Enabled: true, Mode: constants.MAINTENANCE_MODE_READ_ONLY, Message: "Upgrading"}
+		maintenanceService.On("SetStatus", mock.Anything, mock.MatchedBy(func(input *dto.SetMaintenanceInput) bool {
+			return input.Enabled != nil && *input.Enabled && input.Mode == constants.MAINTENANCE_MODE_READ_ONLY && input.Message == "Upgrading"
+		})).Return(status, nil)
+
+		w, c := newContext(`{"enabled":true,"mode":"read_only","message":"Upgrading"}`)
+		handler.SetMaintenance(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"enabled":true,"mode":"read_only","message":"Upgrading"}`, w.Body.String())
+		var entry audit.Entry
+		require.NoError(t, json.Unmarshal(auditBuf.Bytes(), &entry))
+		assert.Equal(t, audit.ActionMaintenanceUpdated, entry.Action)
+		assert.Equal(t, true, entry.Metadata["enabled"])
+	})
+
+	t.Run("SetMaintenance - Invalid Body", func(t *testing.T) {
+		bodies := map[string]string{
+			"Missing Enabled": `{"mode":"full"}`,
+			"Unknown Mode":    `{"enabled":true,"mode":"partial"}`,
+			"Blank Message":   `{"enabled":true,"message":"   "}`,
+		}
+		for name, body := range bodies {
+			t.Run(name, func(t *testing.T) {
+				maintenanceService := new(mocks.MockMaintenanceService)
+				handler := handlers.NewMaintenanceHandler(maintenanceService, audit.NewAuditLogger(&bytes.Buffer{}))
+
+				w, c := newContext(body)
+				handler.SetMaintenance(c)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				maintenanceService.AssertNotCalled(t, "SetStatus", mock.Anything, mock.Anything)
+			})
+		}
+	})
+
+	t.Run("SetMaintenance - Service Error", func(t *testing.T) {
+		maintenanceService := new(mocks.MockMaintenanceService)
+		handler := handlers.NewMaintenanceHandler(maintenanceService, audit.NewAuditLogger(&bytes.Buffer{}))
+		maintenanceService.On("SetStatus", mock.Anything, mock.Anything).Return(nil, errors.New("db down"))
+
+		w, c := newContext(`{"enabled":false}`)
+		handler.SetMaintenance(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
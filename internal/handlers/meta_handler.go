@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+type MetaHandler interface {
+	GetErrorCodes(c *gin.Context)
+}
+
+type metaHandlerImpl struct{}
+
+func NewMetaHandler() MetaHandler {
+	return &metaHandlerImpl{}
+}
+
+// GetErrorCodes lists every error code the API can return, with its identifier, HTTP status and description
+func (handler *metaHandlerImpl) GetErrorCodes(ctx *gin.Context) {
+	utils.RespondWithOK(ctx, http.StatusOK, gin.H{"data": apperror.Catalog()})
+}
@@ -0,0 +1,38 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/handlers"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+func TestGetErrorCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/meta/error-codes", handlers.NewMetaHandler().GetErrorCodes)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/meta/error-codes", nil)
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data []apperror.Definition `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, apperror.Catalog(), response.Data)
+	assert.Contains(t, response.Data, apperror.Definition{
+		Code:        apperror.ErrNotFound,
+		Identifier:  "ERR_NOT_FOUND",
+		HttpStatus:  http.StatusNotFound,
+		Description: "Resource not found",
+	})
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/audit"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+type PreferencesHandler interface {
+	GetPreferences(c *gin.Context)
+	UpdatePreferences(c *gin.Context)
+}
+
+type preferencesHandlerImpl struct {
+	preferencesService services.PreferencesService
+	auditLogger        audit.AuditLogger
+}
+
+func NewPreferencesHandler(preferencesService services.PreferencesService, auditLogger audit.AuditLogger) PreferencesHandler {
+	return &preferencesHandlerImpl{
+		preferencesService: preferencesService,
+		auditLogger:        auditLogger,
+	}
+}
+
+// GetPreferences returns the caller's preferences, the defaults if they never changed them
+func (handler *preferencesHandlerImpl) GetPreferences(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		utils.RespondWithError(ctx, apperror.NewParseError("Invalid UserID"))
+		return
+	}
+
+	preference, err := handler.preferencesService.GetPreferences(ctx.Request.Context(), userID)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Get preferences failed for user %d: %v", userID, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	utils.RespondWithOK(ctx, http.StatusOK, preference)
+}
+
+// UpdatePreferences replaces every preference of the caller
+func (handler *preferencesHandlerImpl) UpdatePreferences(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		utils.RespondWithError(ctx, apperror.NewParseError("Invalid UserID"))
+		return
+	}
+
+	var input dto.UpdatePreferencesInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		validateError := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, input)
+		utils.RespondWithError(ctx, validateError)
+		return
+	}
+
+	preference, err := handler.preferencesService.UpdatePreferences(ctx.Request.Context(), userID, &input)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Update preferences failed for user %d: %v", userID, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	handler.auditLogger.Record(ctx, audit.ActionPreferencesUpdated, userID, audit.User(userID), nil)
+	utils.RespondWithOK(ctx, http.StatusOK, preference)
+}
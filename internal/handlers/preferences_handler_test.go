@@ -0,0 +1,117 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/handlers"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/audit"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+func TestPreferencesHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	updatedAt := time.Date(2025, time.March, 5, 14, 30, 0, 0, time.UTC)
+	preference := &models.UserPreference{UserID: 1, Timezone: "Asia/Ho_Chi_Minh", Locale: "vi", EmailNotifications: false, Theme: "dark", UpdatedAt: updatedAt}
+	const preferenceJSON = `{"timezone":"Asia/Ho_Chi_Minh","locale":"vi","email_notifications":false,"theme":"dark","updated_at":"2025-03-05T14:30:00Z"}`
+
+	newRequest := func(method, body string) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(method, "/api/v1/profile/preferences", bytes.NewBufferString(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("UserID", uint(1))
+		return w, c
+	}
+
+	t.Run("GetPreferences - Success", func(t *testing.T) {
+		preferencesService := new(mocks.MockPreferencesService)
+		handler := handlers.NewPreferencesHandler(preferencesService, discardAuditLogger)
+		preferencesService.On("GetPreferences", mock.Anything, uint(1)).Return(preference, nil)
+
+		w, c := newRequest("GET", "")
+		handler.GetPreferences(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, preferenceJSON, w.Body.String())
+	})
+
+	t.Run("GetPreferences - Service Error", func(t *testing.T) {
+		preferencesService := new(mocks.MockPreferencesService)
+		handler := handlers.NewPreferencesHandler(preferencesService, discardAuditLogger)
+		preferencesService.On("GetPreferences", mock.Anything, uint(1)).Return(nil, apperror.NewDBQueryError("Failed to get preferences"))
+
+		w, c := newRequest("GET", "")
+		handler.GetPreferences(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("UpdatePreferences - Success Is Audited", func(t *testing.T) {
+		preferencesService := new(mocks.MockPreferencesService)
+		var auditBuf bytes.Buffer
+		handler := handlers.NewPreferencesHandler(preferencesService, audit.NewAuditLogger(&auditBuf))
+		preferencesService.On("UpdatePreferences", mock.Anything, uint(1), mock.MatchedBy(func(input *dto.UpdatePreferencesInput) bool {
+			return input.Timezone == "Asia/Ho_Chi_Minh" && input.Locale == "vi" && !*input.EmailNotifications && input.Theme == "dark"
+		})).Return(preference, nil)
+
+		w, c := newRequest("PUT", `{"timezone":"Asia/Ho_Chi_Minh","locale":"vi","email_notifications":false,"theme":"dark"}`)
+		handler.UpdatePreferences(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, preferenceJSON, w.Body.String())
+		var entry audit.Entry
+		require.NoError(t, json.Unmarshal(auditBuf.Bytes(), &entry))
+		assert.Equal(t, audit.ActionPreferencesUpdated, entry.Action)
+		assert.Equal(t, uint(1), entry.TargetID)
+	})
+
+	t.Run("UpdatePreferences - Validation", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			body  string
+			field string
+		}{
+			{"BogusTimezone", `{"timezone":"Mars/Olympus_Mons","locale":"en","email_notifications":true,"theme":"light"}`, "timezone"},
+			{"ServerLocalTimezone", `{"timezone":"Local","locale":"en","email_notifications":true,"theme":"light"}`, "timezone"},
+			{"UnsupportedLocale", `{"timezone":"UTC","locale":"xx","email_notifications":true,"theme":"light"}`, "locale"},
+			{"UnknownTheme", `{"timezone":"UTC","locale":"en","email_notifications":true,"theme":"neon"}`, "theme"},
+			{"MissingEmailNotifications", `{"timezone":"UTC","locale":"en","theme":"light"}`, "email_notifications"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				preferencesService := new(mocks.MockPreferencesService)
+				handler := handlers.NewPreferencesHandler(preferencesService, discardAuditLogger)
+
+				w, c := newRequest("PUT", tt.body)
+				handler.UpdatePreferences(c)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), `"field":"`+tt.field+`"`)
+				preferencesService.AssertNotCalled(t, "UpdatePreferences", mock.Anything, mock.Anything, mock.Anything)
+			})
+		}
+	})
+
+	t.Run("UpdatePreferences - Service Error", func(t *testing.T) {
+		preferencesService := new(mocks.MockPreferencesService)
+		handler := handlers.NewPreferencesHandler(preferencesService, discardAuditLogger)
+		preferencesService.On("UpdatePreferences", mock.Anything, uint(1), mock.Anything).Return(nil, apperror.NewDBUpdateError("Failed to update preferences"))
+
+		w, c := newRequest("PUT", `{"timezone":"UTC","locale":"en","email_notifications":true,"theme":"light"}`)
+		handler.UpdatePreferences(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/audit"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+type SessionHandler interface {
+	GetSessions(c *gin.Context)
+	RevokeSession(c *gin.Context)
+}
+
+type sessionHandlerImpl struct {
+	refreshTokenService services.RefreshTokenService
+	auditLogger         audit.AuditLogger
+}
+
+func NewSessionHandler(refreshTokenService services.RefreshTokenService, auditLogger audit.AuditLogger) SessionHandler {
+	return &sessionHandlerImpl{
+		refreshTokenService: refreshTokenService,
+		auditLogger:         auditLogger,
+	}
+}
+
+// GetSessions lists where the caller is logged in, one entry per active refresh token
+func (handler *sessionHandlerImpl) GetSessions(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		utils.RespondWithError(ctx, apperror.NewParseError("Invalid UserID"))
+		return
+	}
+
+	sessions, err := handler.refreshTokenService.ListSessions(ctx.Request.Context(), userID)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("List sessions failed for user %d: %v", userID, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	utils.RespondWithOK(ctx, http.StatusOK, gin.H{"data": sessions})
+}
+
+// RevokeSession logs the caller out of one session. Its refresh token stops working at once;
+// access tokens already issued stay valid until they expire
+func (handler *sessionHandlerImpl) RevokeSession(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		utils.RespondWithError(ctx, apperror.NewParseError("Invalid UserID"))
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || sessionID == 0 {
+		utils.RespondWithError(ctx, apperror.NewParseError("Invalid session ID"))
+		return
+	}
+
+	if err := handler.refreshTokenService.RevokeSession(ctx.Request.Context(), userID, uint(sessionID)); err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Revoke session %d failed for user %d: %v", sessionID, userID, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	handler.auditLogger.Record(ctx, audit.ActionSessionRevoked, userID, audit.User(userID), map[string]any{"session_id": sessionID})
+	utils.RespondWithOK(ctx, http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
@@ -0,0 +1,91 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/handlers"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/audit"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+func TestSessionHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newContext := func(method, id string) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(method, "/api/v1/profile/sessions/"+id, nil)
+		if id != "" {
+			c.Params = gin.Params{{Key: "id", Value: id}}
+		}
+		c.Set("UserID", uint(1))
+		return w, c
+	}
+
+	t.Run("GetSessions - Success", func(t *testing.T) {
+		refreshTokenService := new(mocks.MockRefreshTokenService)
+		handler := handlers.NewSessionHandler(refreshTokenService, discardAuditLogger)
+		refreshTokenService.On("ListSessions", mock.Anything, uint(1)).Return([]dto.SessionResponse{
+			{ID: 3, Token: "********wxyz", IPAddress: "10.0.0.1", UserAgent: "Mozilla/5.0"},
+		}, nil)
+
+		w, c := newContext("GET", "")
+		handler.GetSessions(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Data []dto.SessionResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Data, 1)
+		assert.Equal(t, "********wxyz", response.Data[0].Token)
+	})
+
+	t.Run("RevokeSession - Success Is Audited", func(t *testing.T) {
+		refreshTokenService := new(mocks.MockRefreshTokenService)
+		var auditBuf bytes.Buffer
+		handler := handlers.NewSessionHandler(refreshTokenService, audit.NewAuditLogger(&auditBuf))
+		refreshTokenService.On("RevokeSession", mock.Anything, uint(1), uint(3)).Return(nil)
+
+		w, c := newContext("DELETE", "3")
+		handler.RevokeSession(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var entry audit.Entry
+		require.NoError(t, json.Unmarshal(auditBuf.Bytes(), &entry))
+		assert.Equal(t, audit.ActionSessionRevoked, entry.Action)
+		assert.Equal(t, float64(3), entry.Metadata["session_id"])
+	})
+
+	t.Run("RevokeSession - Not Found", func(t *testing.T) {
+		refreshTokenService := new(mocks.MockRefreshTokenService)
+		handler := handlers.NewSessionHandler(refreshTokenService, discardAuditLogger)
+		refreshTokenService.On("RevokeSession", mock.Anything, uint(1), uint(4)).Return(apperror.NewNotFoundError("Session not found"))
+
+		w, c := newContext("DELETE", "4")
+		handler.RevokeSession(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("RevokeSession - Invalid ID", func(t *testing.T) {
+		refreshTokenService := new(mocks.MockRefreshTokenService)
+		handler := handlers.NewSessionHandler(refreshTokenService, discardAuditLogger)
+
+		w, c := newContext("DELETE", "abc")
+		handler.RevokeSession(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		refreshTokenService.AssertNotCalled(t, "RevokeSession", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
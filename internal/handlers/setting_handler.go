@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/audit"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+// settingKeyPattern matches setting keys such as login.max_attempts
+var settingKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_.]{0,63}$`)
+
+type SettingHandler interface {
+	GetSettings(c *gin.Context)
+	GetSetting(c *gin.Context)
+	SetSetting(c *gin.Context)
+	DeleteSetting(c *gin.Context)
+}
+
+type settingHandlerImpl struct {
+	settingsService services.SettingsService
+	auditLogger     audit.AuditLogger
+}
+
+func NewSettingHandler(settingsService services.SettingsService, auditLogger audit.AuditLogger) SettingHandler {
+	return &settingHandlerImpl{
+		settingsService: settingsService,
+		auditLogger:     auditLogger,
+	}
+}
+
+func (handler *settingHandlerImpl) GetSettings(ctx *gin.Context) {
+	settings, err := handler.settingsService.ListSettings(ctx.Request.Context())
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Get settings failed: %v", err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	utils.RespondWithOK(ctx, http.StatusOK, gin.H{"data": settings})
+}
+
+func (handler *settingHandlerImpl) GetSetting(ctx *gin.Context) {
+	key, err := parseSettingKeyParam(ctx)
+	if err != nil {
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	setting, err := handler.settingsService.GetSetting(ctx.Request.Context(), key)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Get setting %s failed: %v", key, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	utils.RespondWithOK(ctx, http.StatusOK, setting)
+}
+
+// SetSetting creates the setting or replaces its value and type
+func (handler *settingHandlerImpl) SetSetting(ctx *gin.Context) {
+	key, err := parseSettingKeyParam(ctx)
+	if err != nil {
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	var input dto.SetSettingInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		validateError := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, input)
+		utils.RespondWithError(ctx, validateError)
+		return
+	}
+
+	setting, err := handler.settingsService.SetSetting(ctx.Request.Context(), key, &input)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Set setting %s failed: %v", key, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	adminID, _ := utils.GetUserIDFromContext(ctx)
+	handler.auditLogger.Record(ctx, audit.ActionSettingUpdated, adminID, audit.Target{}, map[string]any{"key": key, "value": setting.Value, "type": setting.Type})
+
+	utils.RespondWithOK(ctx, http.StatusOK, setting)
+}
+
+func (handler *settingHandlerImpl) DeleteSetting(ctx *gin.Context) {
+	key, err := parseSettingKeyParam(ctx)
+	if err != nil {
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	if err := handler.settingsService.DeleteSetting(ctx.Request.Context(), key); err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Delete setting %s failed: %v", key, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	adminID, _ := utils.GetUserIDFromContext(ctx)
+	handler.auditLogger.Record(ctx, audit.ActionSettingDeleted, adminID, audit.Target{}, map[string]any{"key": key})
+
+	utils.RespondWithOK(ctx, http.StatusOK, gin.H{"message": "Delete setting successfully"})
+}
+
+// parseSettingKeyParam reads the :key path parameter
+func parseSettingKeyParam(ctx *gin.Context) (string, error) {
+	key := ctx.Param("key")
+	if !settingKeyPattern.MatchString(key) {
+		return "", apperror.NewParseError("Invalid setting key")
+	}
+	return key, nil
+}
@@ -0,0 +1,159 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/handlers"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/audit"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+func TestSettingHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	utils.InitValidator()
+
+	newContext := func(method, key, body string) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(method, "/api/v1/settings/"+key, strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Params = gin.Params{{Key: "key", Value: key}}
+		c.Set("UserID", uint(1))
+		return w, c
+	}
+
+	t.Run("GetSettings - Success", func(t *testing.T) {
+		settingsService := new(mocks.MockSettingsService)
+		handler := handlers.NewSettingHandler(settingsService, audit.NewAuditLogger(&bytes.Buffer{}))
+		settingsService.On("ListSettings", mock.Anything).Return([]*models.Setting{{Key: "site.name", Value: "CMS", Type: "string"}}, nil)
+
+		w, c := newContext("GET", "", "")
+		handler.GetSettings(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Data []models.Setting `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Data, 1)
+		assert.Equal(t, "site.name", response.Data[0].Key)
+	})
+
+	t.Run("GetSetting - Not Found", func(t *testing.T) {
+		settingsService := new(mocks.MockSettingsService)
+		handler := handlers.NewSettingHandler(settingsService, audit.NewAuditLogger(&bytes.Buffer{}))
+		settingsService.On("GetSetting", mock.Anything, "missing").Return(nil, apperror.NewNotFoundError("Setting not found"))
+
+		w, c := newContext("GET", "missing", "")
+		handler.GetSetting(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid Key", func(t *testing.T) {
+		settingsService := new(mocks.MockSettingsService)
+		handler := handlers.NewSettingHandler(settingsService, audit.NewAuditLogger(&bytes.Buffer{}))
+
+		for _, key := range []string{"Upper", "1starts.with.digit", "has space", strings.Repeat("a", 65)} {
+			w, c := newContext("GET", key, "")
+			handler.GetSetting(c)
+			assert.Equal(t, http.StatusBadRequest, w.Code, key)
+		}
+		settingsService.AssertNotCalled(t, "GetSetting", mock.Anything, mock.Anything)
+	})
+
+	t.Run("SetSetting - Success Is Audited", func(t *testing.T) {
+		settingsService := new(mocks.MockSettingsService)
+		var auditBuf bytes.Buffer
+		handler := handlers.NewSettingHandler(settingsService, audit.NewAuditLogger(&auditBuf))
+		input := &dto.SetSettingInput{Value: "7", Type: "int"}
+		settingsService.On("SetSetting", mock.Anything, "login.max_attempts", input).
+			Return(&models.Setting{Key: "login.max_attempts", Value: "7", Type: "int"}, nil)
+
+		w, c := newContext("PUT", "login.max_attempts", `{"value":"7","type":"int"}`)
+		handler.SetSetting(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var entry audit.Entry
+		require.NoError(t, json.Unmarshal(auditBuf.Bytes(), &entry))
+		assert.Equal(t, audit.ActionSettingUpdated, entry.Action)
+		assert.Equal(t, uint(1), entry.UserID)
+		assert.Equal(t, "login.max_attempts", entry.Metadata["key"])
+		assert.Equal(t, "7", entry.Metadata["value"])
+	})
+
+	t.Run("SetSetting - Invalid Body", func(t *testing.T) {
+		bodies := map[string]string{
+			"Missing Type": `{"value":"7"}`,
+			"Unknown Type": `{"value":"7","type":"float"}`,
+			"Not JSON":     `value=7`,
+		}
+		for name, body := range bodies {
+			t.Run(name, func(t *testing.T) {
+				settingsService := new(mocks.MockSettingsService)
+				handler := handlers.NewSettingHandler(settingsService, audit.NewAuditLogger(&bytes.Buffer{}))
+
+				w, c := newContext("PUT", "login.max_attempts", body)
+				handler.SetSetting(c)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				settingsService.AssertNotCalled(t, "SetSetting", mock.Anything, mock.Anything, mock.Anything)
+			})
+		}
+	})
+
+	t.Run("SetSetting - Value Not Matching Type", func(t *testing.T) {
+		settingsService := new(mocks.MockSettingsService)
+		var auditBuf bytes.Buffer
+		handler := handlers.NewSettingHandler(settingsService, audit.NewAuditLogger(&auditBuf))
+		settingsService.On("SetSetting", mock.Anything, "login.max_attempts", mock.Anything).Return(nil, apperror.NewValidationError("Validation failed", []apperror.FieldError{
+			{Field: "value", Message: "value must be a valid int"},
+		}))
+
+		w, c := newContext("PUT", "login.max_attempts", `{"value":"abc","type":"int"}`)
+		handler.SetSetting(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Empty(t, auditBuf.String())
+	})
+
+	t.Run("DeleteSetting - Success Is Audited", func(t *testing.T) {
+		settingsService := new(mocks.MockSettingsService)
+		var auditBuf bytes.Buffer
+		handler := handlers.NewSettingHandler(settingsService, audit.NewAuditLogger(&auditBuf))
+		settingsService.On("DeleteSetting", mock.Anything, "site.name").Return(nil)
+
+		w, c := newContext("DELETE", "site.name", "")
+		handler.DeleteSetting(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var entry audit.Entry
+		require.NoError(t, json.Unmarshal(auditBuf.Bytes(), &entry))
+		assert.Equal(t, audit.ActionSettingDeleted, entry.Action)
+		assert.Equal(t, "site.name", entry.Metadata["key"])
+	})
+
+	t.Run("DeleteSetting - Not Found", func(t *testing.T) {
+		settingsService := new(mocks.MockSettingsService)
+		handler := handlers.NewSettingHandler(settingsService, audit.NewAuditLogger(&bytes.Buffer{}))
+		settingsService.On("DeleteSetting", mock.Anything, "missing").Return(apperror.NewNotFoundError("Setting not found"))
+
+		w, c := newContext("DELETE", "missing", "")
+		handler.DeleteSetting(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/middlewares"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+type UserActivityHandler interface {
+	GetUserActivity(c *gin.Context)
+}
+
+type userActivityHandlerImpl struct {
+	userActivityService services.UserActivityService
+}
+
+func NewUserActivityHandler(userActivityService services.UserActivityService) UserActivityHandler {
+	return &userActivityHandlerImpl{
+		userActivityService: userActivityService,
+	}
+}
+
+// GetUserActivity returns the last login, the active session count and the recent audit log entries of a user.
+// The entries are paged and sorted by the options of middlewares.ListOptionsMiddleware
+func (handler *userActivityHandlerImpl) GetUserActivity(ctx *gin.Context) {
+	id, err := parseUserIDParam(ctx)
+	if err != nil {
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	opts, ok := middlewares.GetListOptions(ctx)
+	if !ok {
+		utils.RespondWithError(ctx, apperror.NewInternalServerError("List options are not available"))
+		return
+	}
+
+	activity, err := handler.userActivityService.GetUserActivity(ctx.Request.Context(), id, opts)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Get activity of user %d failed: %v", id, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	utils.SetPaginationLinks(ctx, activity.RecentActivity)
+	utils.RespondWithOK(ctx, http.StatusOK, activity)
+}
@@ -0,0 +1,113 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/handlers"
+	"github.com/vfa-khuongdv/golang-cms/internal/middlewares"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+func TestUserActivityHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	opts := dto.ListOptions{Page: 1, Limit: 1, SortBy: "created_at", SortDir: "desc"}
+
+	newRequest := func(id string, withOptions bool) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/api/v1/users/"+id+"/activity?limit=1", nil)
+		c.Params = gin.Params{{Key: "id", Value: id}}
+		if withOptions {
+			c.Set(middlewares.ListOptionsKey, opts)
+		}
+		return w, c
+	}
+
+	t.Run("GetUserActivity - Success", func(t *testing.T) {
+		userActivityService := new(mocks.MockUserActivityService)
+		handler := handlers.NewUserActivityHandler(userActivityService)
+		lastLoginAt := time.Date(2025, time.March, 5, 14, 30, 0, 0, time.UTC)
+		lastLoginIP := "203.0.113.7"
+		actor := uint(7)
+		nextPage := 2
+		userActivityService.On("GetUserActivity", mock.Anything, uint(7), opts).Return(&dto.UserActivityResponse{
+			UserID:         7,
+			LastLoginAt:    &lastLoginAt,
+			LastLoginIP:    &lastLoginIP,
+			ActiveSessions: 2,
+			RecentActivity: &dto.Pagination[*models.AuditLog]{
+				Page: 1, Limit: 1, TotalItems: 2, TotalPages: 2, HasNext: true, NextPage: &nextPage,
+				Data: []*models.AuditLog{{ID: 9, ActorUserID: &actor, Action: "auth.login"}},
+			},
+		}, nil)
+
+		w, c := newRequest("7", true)
+		handler.GetUserActivity(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var body struct {
+			UserID         uint       `json:"user_id"`
+			LastLoginAt    *time.Time `json:"last_login_at"`
+			LastLoginIP    *string    `json:"last_login_ip"`
+			ActiveSessions int        `json:"active_sessions"`
+			RecentActivity struct {
+				TotalItems int                `json:"total_items"`
+				Next       string             `json:"next"`
+				Data       []*models.AuditLog `json:"data"`
+			} `json:"recent_activity"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, uint(7), body.UserID)
+		require.NotNil(t, body.LastLoginAt)
+		assert.True(t, lastLoginAt.Equal(*body.LastLoginAt))
+		assert.Equal(t, "203.0.113.7", *body.LastLoginIP)
+		assert.Equal(t, 2, body.ActiveSessions)
+		assert.Equal(t, 2, body.RecentActivity.TotalItems)
+		assert.Contains(t, body.RecentActivity.Next, "page=2")
+		require.Len(t, body.RecentActivity.Data, 1)
+		assert.Equal(t, "auth.login", body.RecentActivity.Data[0].Action)
+	})
+
+	t.Run("GetUserActivity - Invalid ID", func(t *testing.T) {
+		userActivityService := new(mocks.MockUserActivityService)
+		handler := handlers.NewUserActivityHandler(userActivityService)
+
+		w, c := newRequest("abc", true)
+		handler.GetUserActivity(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		userActivityService.AssertNotCalled(t, "GetUserActivity", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("GetUserActivity - Missing List Options", func(t *testing.T) {
+		userActivityService := new(mocks.MockUserActivityService)
+		handler := handlers.NewUserActivityHandler(userActivityService)
+
+		w, c := newRequest("7", false)
+		handler.GetUserActivity(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("GetUserActivity - User Not Found", func(t *testing.T) {
+		userActivityService := new(mocks.MockUserActivityService)
+		handler := handlers.NewUserActivityHandler(userActivityService)
+		userActivityService.On("GetUserActivity", mock.Anything, uint(7), opts).Return(nil, apperror.NewNotFoundError("User not found"))
+
+		w, c := newRequest("7", true)
+		handler.GetUserActivity(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
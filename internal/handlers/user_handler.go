@@ -1,43 +1,127 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"slices"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/middlewares"
 	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/audit"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
 	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
 	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+	"github.com/vfa-khuongdv/golang-cms/pkg/xlsx"
 )
 
 type UserHandler interface {
+	CreateUser(c *gin.Context)
+	VerifyEmail(c *gin.Context)
+	ResendVerification(c *gin.Context)
 	ForgotPassword(c *gin.Context)
 	ResetPassword(c *gin.Context)
 	ChangePassword(c *gin.Context)
 	GetProfile(c *gin.Context)
 	UpdateProfile(c *gin.Context)
+	GetUsers(c *gin.Context)
+	GetUser(c *gin.Context)
+	SearchUsers(c *gin.Context)
+	ExportUsers(c *gin.Context)
+	ImportUsers(c *gin.Context)
+	RestoreUser(c *gin.Context)
+	ForceResetPassword(c *gin.Context)
+	UnlockUser(c *gin.Context)
+	DeleteUsers(c *gin.Context)
+	GetUserRoles(c *gin.Context)
+	AssignRoles(c *gin.Context)
+	RemoveRoles(c *gin.Context)
 }
 
 type userHandlerImpl struct {
 	userService   services.UserService
 	mailerService services.MailerService
+	auditLogger   audit.AuditLogger
 }
 
 func NewUserHandler(
 	userService services.UserService,
 	mailerService services.MailerService,
+	auditLogger audit.AuditLogger,
 ) UserHandler {
 	return &userHandlerImpl{
 		userService:   userService,
 		mailerService: mailerService,
+		auditLogger:   auditLogger,
 	}
 }
 
+func (handler *userHandlerImpl) CreateUser(ctx *gin.Context) {
+	var input dto.CreateUserInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		validateError := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, input)
+		utils.RespondWithError(ctx, validateError)
+		return
+	}
+
+	user, err := handler.userService.CreateUser(ctx.Request.Context(), &input)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Create user failed for email %s: %v", input.Email, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	// A missing admin ID is recorded as an unknown actor rather than failing a request that already succeeded
+	adminID, _ := utils.GetUserIDFromContext(ctx)
+	handler.auditLogger.Record(ctx, audit.ActionUserCreated, adminID, audit.User(user.ID), map[string]any{"email": user.Email})
+	utils.RespondWithOK(ctx, http.StatusCreated, gin.H{"message": "Create user successfully"})
+}
+
+func (handler *userHandlerImpl) VerifyEmail(ctx *gin.Context) {
+	var input dto.VerifyEmailInput
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		validateError := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, input)
+		utils.RespondWithError(ctx, validateError)
+		return
+	}
+
+	if err := handler.userService.VerifyEmail(ctx.Request.Context(), input.Token); err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Verify email failed: %v", err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	utils.RespondWithOK(ctx, http.StatusOK, gin.H{"message": "Verify email successfully"})
+}
+
+func (handler *userHandlerImpl) ResendVerification(ctx *gin.Context) {
+	var input dto.ResendVerificationInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		validateError := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, input)
+		utils.RespondWithError(ctx, validateError)
+		return
+	}
+
+	if err := handler.userService.ResendVerification(ctx.Request.Context(), &input); err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Resend verification failed for email %s: %v", input.Email, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	utils.RespondWithOK(ctx, http.StatusOK, gin.H{"message": "If your email is in our system and not yet verified, you will receive a verification email"})
+}
+
 func (handler *userHandlerImpl) ForgotPassword(ctx *gin.Context) {
 	var input dto.ForgotPasswordInput
 	if err := ctx.ShouldBindJSON(&input); err != nil {
-		validateError := utils.TranslateValidationErrors(err, input)
+		validateError := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, input)
 		utils.RespondWithError(ctx, validateError)
 		return
 	}
@@ -55,18 +139,20 @@ func (handler *userHandlerImpl) ForgotPassword(ctx *gin.Context) {
 func (handler *userHandlerImpl) ResetPassword(ctx *gin.Context) {
 	var input dto.ResetPasswordInput
 	if err := ctx.ShouldBindJSON(&input); err != nil {
-		validateError := utils.TranslateValidationErrors(err, input)
+		validateError := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, input)
 		utils.RespondWithError(ctx, validateError)
 		return
 	}
 
-	_, err := handler.userService.ResetPassword(ctx.Request.Context(), &input)
+	user, err := handler.userService.ResetPassword(ctx.Request.Context(), &input)
 	if err != nil {
 		logger.WithContext(ctx.Request.Context()).Errorf("Reset password failed: %v", err)
 		utils.RespondWithError(ctx, err)
 		return
 	}
 
+	handler.auditLogger.Record(ctx, audit.ActionPasswordReset, user.ID, audit.User(user.ID), nil)
+
 	utils.RespondWithOK(ctx, http.StatusOK, gin.H{"message": "Reset password successfully"})
 }
 
@@ -79,7 +165,7 @@ func (handler *userHandlerImpl) ChangePassword(ctx *gin.Context) {
 
 	var input dto.ChangePasswordInput
 	if err := ctx.ShouldBindJSON(&input); err != nil {
-		validateError := utils.TranslateValidationErrors(err, input)
+		validateError := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, input)
 		utils.RespondWithError(ctx, validateError)
 		return
 	}
@@ -91,6 +177,8 @@ func (handler *userHandlerImpl) ChangePassword(ctx *gin.Context) {
 		return
 	}
 
+	handler.auditLogger.Record(ctx, audit.ActionPasswordChanged, userId, audit.User(userId), nil)
+
 	utils.RespondWithOK(ctx, http.StatusOK, gin.H{"message": "Change password successfully"})
 }
 
@@ -101,14 +189,14 @@ func (handler *userHandlerImpl) GetProfile(ctx *gin.Context) {
 		return
 	}
 
-	dbUser, err := handler.userService.GetProfile(ctx.Request.Context(), userId)
+	profile, err := handler.userService.GetProfile(ctx.Request.Context(), userId)
 	if err != nil {
 		logger.WithContext(ctx.Request.Context()).Errorf("Get profile failed for user %d: %v", userId, err)
 		utils.RespondWithError(ctx, err)
 		return
 	}
 
-	utils.RespondWithOK(ctx, http.StatusOK, dbUser)
+	utils.RespondWithOK(ctx, http.StatusOK, profile)
 }
 
 func (handler *userHandlerImpl) UpdateProfile(ctx *gin.Context) {
@@ -120,7 +208,7 @@ func (handler *userHandlerImpl) UpdateProfile(ctx *gin.Context) {
 
 	var input dto.UpdateProfileInput
 	if err := ctx.ShouldBindJSON(&input); err != nil {
-		validateError := utils.TranslateValidationErrors(err, input)
+		validateError := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, input)
 		utils.RespondWithError(ctx, validateError)
 		return
 	}
@@ -132,5 +220,360 @@ func (handler *userHandlerImpl) UpdateProfile(ctx *gin.Context) {
 		return
 	}
 
+	handler.auditLogger.Record(ctx, audit.ActionProfileUpdated, userId, audit.User(userId), map[string]any{"fields": updatedProfileFields(&input)})
+
 	utils.RespondWithOK(ctx, http.StatusOK, gin.H{"message": "Update profile successfully"})
 }
+
+// GetUsers lists users. Paging and sorting are prepared by middlewares.ListOptionsMiddleware,
+// gender and search filters are read from the query string.
+func (handler *userHandlerImpl) GetUsers(ctx *gin.Context) {
+	opts, ok := middlewares.GetListOptions(ctx)
+	if !ok {
+		utils.RespondWithError(ctx, apperror.NewInternalServerError("List options are not available"))
+		return
+	}
+
+	var filter dto.UserFilterInput
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		validateError := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, filter)
+		utils.RespondWithError(ctx, validateError)
+		return
+	}
+
+	users, err := handler.userService.GetUsers(ctx.Request.Context(), opts, filter)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Get users failed: %v", err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	page := dto.MapPagination(users, dto.NewUserResponse)
+	utils.SetPaginationLinks(ctx, page)
+	utils.RespondWithOK(ctx, http.StatusOK, page)
+}
+
+func (handler *userHandlerImpl) GetUser(ctx *gin.Context) {
+	id, err := parseUserIDParam(ctx)
+	if err != nil {
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	var input dto.GetUserInput
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		validateError := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, input)
+		utils.RespondWithError(ctx, validateError)
+		return
+	}
+
+	user, err := handler.userService.GetUser(ctx.Request.Context(), id, input.IncludeDeleted)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Get user %d failed: %v", id, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	utils.RespondWithOK(ctx, http.StatusOK, dto.NewUserResponse(user))
+}
+
+// SearchUsers finds users by name, email prefix or ID for the admin search box.
+// Only the ID, name, email and gender of each user are returned
+func (handler *userHandlerImpl) SearchUsers(ctx *gin.Context) {
+	var input dto.UserSearchInput
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		validateError := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, input)
+		utils.RespondWithError(ctx, validateError)
+		return
+	}
+
+	users, err := handler.userService.SearchUsers(ctx.Request.Context(), input.Query, input.Limit)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Search users failed: %v", err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	results := make([]dto.UserSearchResult, 0, len(users))
+	for _, user := range users {
+		results = append(results, dto.UserSearchResult{ID: user.ID, Name: user.Name, Email: user.Email, Gender: user.Gender})
+	}
+	utils.RespondWithOK(ctx, http.StatusOK, gin.H{"data": results})
+}
+
+// ExportUsers streams the users matching the list filters as a CSV or XLSX download.
+// Errors before the first rows are sent are returned as usual; later ones can only cut the download short
+func (handler *userHandlerImpl) ExportUsers(ctx *gin.Context) {
+	var filter dto.UserFilterInput
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		validateError := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, filter)
+		utils.RespondWithError(ctx, validateError)
+		return
+	}
+	var input dto.UserExportInput
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		validateError := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, input)
+		utils.RespondWithError(ctx, validateError)
+		return
+	}
+
+	format, contentType := constants.EXPORT_FORMAT_CSV, "text/csv; charset=utf-8"
+	if input.Format == constants.EXPORT_FORMAT_XLSX {
+		format, contentType = constants.EXPORT_FORMAT_XLSX, xlsx.ContentType
+	}
+	filename := fmt.Sprintf("users-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	adminID, _ := utils.GetUserIDFromContext(ctx)
+	if err := handler.userService.ExportUsers(ctx.Request.Context(), filter, format, ctx.Writer); err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Export users failed: %v", err)
+		if !ctx.Writer.Written() {
+			ctx.Writer.Header().Del("Content-Type")
+			ctx.Writer.Header().Del("Content-Disposition")
+			utils.RespondWithError(ctx, err)
+		}
+		return
+	}
+
+	handler.auditLogger.Record(ctx, audit.ActionUsersExported, adminID, audit.Target{}, map[string]any{"format": format, "search": filter.Search, "gender": filter.Gender, "include_deleted": filter.IncludeDeleted})
+}
+
+// ImportUsers creates users from the CSV uploaded in the "file" field of a multipart form and reports skipped rows.
+// The file is read as it is uploaded rather than stored first; with dry_run=true nothing is created
+func (handler *userHandlerImpl) ImportUsers(ctx *gin.Context) {
+	var input dto.ImportUsersInput
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		validateError := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, input)
+		utils.RespondWithError(ctx, validateError)
+		return
+	}
+
+	file, err := multipartFile(ctx, "file")
+	if err != nil {
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	report, err := handler.userService.ImportUsers(ctx.Request.Context(), file, input.DryRun)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Import users failed: %v", err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	if !report.DryRun {
+		adminID, _ := utils.GetUserIDFromContext(ctx)
+		handler.auditLogger.Record(ctx, audit.ActionUsersImported, adminID, audit.Target{}, map[string]any{"imported": report.Imported, "skipped": len(report.Skipped)})
+	}
+
+	utils.RespondWithOK(ctx, http.StatusOK, report)
+}
+
+// multipartFile returns the part of the multipart request body holding the form field, without buffering the body
+func multipartFile(ctx *gin.Context, field string) (io.Reader, error) {
+	reader, err := ctx.Request.MultipartReader()
+	if err != nil {
+		return nil, apperror.NewBadRequestError("Expected a multipart/form-data upload")
+	}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, apperror.NewBadRequestError(fmt.Sprintf("Missing the %s field", field))
+		}
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, apperror.NewPayloadTooLargeError(fmt.Sprintf("File must not be larger than %d bytes", tooLarge.Limit))
+		}
+		if err != nil {
+			return nil, apperror.NewBadRequestError("Malformed multipart upload")
+		}
+		if part.FormName() == field {
+			return part, nil
+		}
+	}
+}
+
+// RestoreUser restores a soft-deleted user. A user that is not deleted is returned unchanged.
+func (handler *userHandlerImpl) RestoreUser(ctx *gin.Context) {
+	id, err := parseUserIDParam(ctx)
+	if err != nil {
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	user, err := handler.userService.RestoreUser(ctx.Request.Context(), id)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Restore user %d failed: %v", id, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	adminID, _ := utils.GetUserIDFromContext(ctx)
+	handler.auditLogger.Record(ctx, audit.ActionUserRestored, adminID, audit.User(id), nil)
+
+	utils.RespondWithOK(ctx, http.StatusOK, dto.NewUserResponse(user))
+}
+
+// ForceResetPassword sets a temporary password for the user and returns it. It is shown only in this response.
+func (handler *userHandlerImpl) ForceResetPassword(ctx *gin.Context) {
+	adminID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		utils.RespondWithError(ctx, apperror.NewParseError("Invalid UserID"))
+		return
+	}
+
+	id, err := parseUserIDParam(ctx)
+	if err != nil {
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	temporaryPassword, err := handler.userService.ForceResetPassword(ctx.Request.Context(), id)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Force reset password for user %d failed: %v", id, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	handler.auditLogger.Record(ctx, audit.ActionPasswordForceReset, adminID, audit.User(id), nil)
+	utils.RespondWithOK(ctx, http.StatusOK, gin.H{"temporary_password": temporaryPassword})
+}
+
+// UnlockUser clears the failed logins of a user locked out by too many of them
+func (handler *userHandlerImpl) UnlockUser(ctx *gin.Context) {
+	adminID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		utils.RespondWithError(ctx, apperror.NewParseError("Invalid UserID"))
+		return
+	}
+
+	id, err := parseUserIDParam(ctx)
+	if err != nil {
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	if err := handler.userService.UnlockUser(ctx.Request.Context(), id); err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Unlock user %d failed: %v", id, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	handler.auditLogger.Record(ctx, audit.ActionUserUnlocked, adminID, audit.User(id), nil)
+	utils.RespondWithOK(ctx, http.StatusOK, gin.H{"message": "Unlock user successfully"})
+}
+
+// DeleteUsers soft-deletes several users at once and reports which IDs were deleted and which did not exist
+func (handler *userHandlerImpl) DeleteUsers(ctx *gin.Context) {
+	adminID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		utils.RespondWithError(ctx, apperror.NewParseError("Invalid UserID"))
+		return
+	}
+
+	var input dto.BulkDeleteUsersInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		validateError := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, input)
+		utils.RespondWithError(ctx, validateError)
+		return
+	}
+	if slices.Contains(input.IDs, adminID) {
+		utils.RespondWithError(ctx, apperror.NewBadRequestError("You cannot delete your own account"))
+		return
+	}
+
+	result, err := handler.userService.DeleteUsers(ctx.Request.Context(), input.IDs)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Bulk delete of users %v failed: %v", input.IDs, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	if len(result.Deleted) > 0 {
+		handler.auditLogger.Record(ctx, audit.ActionUsersDeleted, adminID, audit.Target{}, map[string]any{"deleted_user_ids": result.Deleted})
+	}
+	utils.RespondWithOK(ctx, http.StatusOK, result)
+}
+
+// GetUserRoles lists the roles of the user and the permissions they grant
+func (handler *userHandlerImpl) GetUserRoles(ctx *gin.Context) {
+	id, err := parseUserIDParam(ctx)
+	if err != nil {
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	roles, err := handler.userService.GetUserRoles(ctx.Request.Context(), id)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Get roles of user %d failed: %v", id, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	utils.RespondWithOK(ctx, http.StatusOK, gin.H{"data": roles})
+}
+
+// AssignRoles gives the user the roles listed in the body. Roles the user already has are left as they are
+func (handler *userHandlerImpl) AssignRoles(ctx *gin.Context) {
+	handler.changeRoles(ctx, "Assign", audit.ActionRolesAssigned, handler.userService.AssignRoles)
+}
+
+// RemoveRoles takes the roles listed in the body away from the user. Roles the user does not have are ignored
+func (handler *userHandlerImpl) RemoveRoles(ctx *gin.Context) {
+	handler.changeRoles(ctx, "Remove", audit.ActionRolesRemoved, handler.userService.RemoveRoles)
+}
+
+// changeRoles binds the role IDs, applies the change through the service and audits it
+func (handler *userHandlerImpl) changeRoles(ctx *gin.Context, verb, action string, change func(ctx context.Context, userID uint, roleIDs []uint) error) {
+	id, err := parseUserIDParam(ctx)
+	if err != nil {
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	var input dto.UserRolesInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		validateError := utils.TranslateValidationErrorsIn(utils.RequestLanguage(ctx), err, input)
+		utils.RespondWithError(ctx, validateError)
+		return
+	}
+
+	if err := change(ctx.Request.Context(), id, input.RoleIDs); err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("%s roles %v for user %d failed: %v", verb, input.RoleIDs, id, err)
+		utils.RespondWithError(ctx, err)
+		return
+	}
+
+	adminID, _ := utils.GetUserIDFromContext(ctx)
+	handler.auditLogger.Record(ctx, action, adminID, audit.User(id), map[string]any{"role_ids": input.RoleIDs})
+
+	utils.RespondWithOK(ctx, http.StatusOK, gin.H{"message": "Roles updated successfully"})
+}
+
+// updatedProfileFields lists the fields set in the profile update. Only names are audited, not values
+func updatedProfileFields(input *dto.UpdateProfileInput) []string {
+	fields := []string{}
+	if input.Name != nil {
+		fields = append(fields, "name")
+	}
+	if input.Birthday != nil {
+		fields = append(fields, "birthday")
+	}
+	if input.Address != nil {
+		fields = append(fields, "address")
+	}
+	if input.Gender != nil {
+		fields = append(fields, "gender")
+	}
+	return fields
+}
+
+// parseUserIDParam reads the :id path parameter as a positive user ID
+func parseUserIDParam(ctx *gin.Context) (uint, error) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		return 0, apperror.NewParseError("Invalid user ID")
+	}
+	return uint(id), nil
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -16,11 +18,14 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/vfa-khuongdv/golang-cms/internal/handlers"
+	"github.com/vfa-khuongdv/golang-cms/internal/middlewares"
 	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/audit"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
 	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
 	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+	"gorm.io/gorm"
 )
 
 func TestUpdateProfile(t *testing.T) {
@@ -32,7 +37,7 @@ func TestUpdateProfile(t *testing.T) {
 	t.Run("UpdateProfile - Success", func(t *testing.T) {
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 		userID := uint(1)
 		requestBody := map[string]any{
@@ -157,7 +162,7 @@ func TestUpdateProfile(t *testing.T) {
 				expectedCode: float64(4001),
 				expectedMsg:  "Validation failed",
 				expectedFields: []apperror.FieldError{
-					{Field: "gender", Message: "gender must be one of [1 2 3]"},
+					{Field: "gender", Message: "gender must be male, female, or other"},
 				},
 			},
 			{
@@ -166,15 +171,17 @@ func TestUpdateProfile(t *testing.T) {
 				expectedCode: float64(4001),
 				expectedMsg:  "Validation failed",
 				expectedFields: []apperror.FieldError{
-					{Field: "gender", Message: "gender must be one of [1 2 3]"},
+					{Field: "gender", Message: "gender must be male, female, or other"},
 				},
 			},
 			{
-				name:           "StringGender",
-				reqBody:        `{"name": "User", "birthday": "2000-01-01", "address": "123 Street", "gender": "male"}`,
-				expectedCode:   float64(4001),
-				expectedMsg:    "json: cannot unmarshal string into Go struct field UpdateProfileInput.gender of type int16",
-				expectedFields: nil, // specific error case
+				name:         "StringGender",
+				reqBody:      `{"name": "User", "birthday": "2000-01-01", "address": "123 Street", "gender": "male"}`,
+				expectedCode: float64(4001),
+				expectedMsg:  "Validation failed",
+				expectedFields: []apperror.FieldError{
+					{Field: "gender", Message: "gender must be a number"},
+				},
 			},
 		}
 
@@ -182,7 +189,7 @@ func TestUpdateProfile(t *testing.T) {
 			t.Run(tt.name, func(t *testing.T) {
 				userService := new(mocks.MockUserService)
 				mailerService := new(mocks.MockMailerService)
-				handler := handlers.NewUserHandler(userService, mailerService)
+				handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 				// Create a test context
 				w := httptest.NewRecorder()
@@ -218,7 +225,7 @@ func TestUpdateProfile(t *testing.T) {
 	t.Run("UpdateProfile - Invalid UserID ctx", func(t *testing.T) {
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 		// Create a test context
 		w := httptest.NewRecorder()
@@ -248,7 +255,7 @@ func TestUpdateProfile(t *testing.T) {
 	t.Run("UpdateProfile - User Not Found", func(t *testing.T) {
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 		userID := uint(1)
 		requestBody := map[string]any{
@@ -296,7 +303,7 @@ func TestUpdateProfile(t *testing.T) {
 	t.Run("Error Update User", func(t *testing.T) {
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 		userID := uint(1)
 		requestBody := map[string]any{
@@ -349,18 +356,33 @@ func TestGetProfile(t *testing.T) {
 	t.Run("Success get profile from database", func(t *testing.T) {
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
+		token := "reset-token"
+		expiredAt := int64(1700000000)
+		birthday := time.Date(1990, 1, 15, 0, 0, 0, 0, time.UTC)
 		user := &models.User{
 			ID:        1,
 			Email:     "email@example.com",
+			Password:  "$2a$10$hash",
 			Name:      "User",
+			Birthday:  &birthday,
 			Gender:    1,
+			Token:     &token,
+			ExpiredAt: &expiredAt,
 			CreatedAt: time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC),
 			UpdatedAt: time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC),
+			Roles:     []models.Role{{ID: 2, Name: "user", CreatedAt: time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)}},
+			Preferences: &models.UserPreference{
+				UserID:             1,
+				Timezone:           "UTC",
+				Locale:             "en",
+				EmailNotifications: true,
+				Theme:              "system",
+			},
 		}
 		// Mock the service method
-		userService.On("GetProfile", mock.Anything, uint(1)).Return(user, nil)
+		userService.On("GetProfile", mock.Anything, uint(1)).Return(dto.NewProfileResponse(user), nil)
 
 		// Create a test context
 		w := httptest.NewRecorder()
@@ -371,20 +393,25 @@ func TestGetProfile(t *testing.T) {
 		// Call the handler
 		handler.GetProfile(c)
 
-		// Assert the response
-		expectedBody := map[string]any{
-			"id":         float64(1),
-			"email":      "email@example.com",
-			"name":       "User",
-			"gender":     float64(1),
+		// Assert the response; the password, the token and its expiry are not on the profile
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{
+			"id": 1,
+			"email": "email@example.com",
+			"pending_email": null,
+			"name": "User",
+			"birthday": "1990-01-15",
+			"address": null,
+			"gender": 1,
+			"avatar": null,
+			"verified_at": null,
+			"must_change_password": false,
 			"created_at": "2023-10-01T00:00:00Z",
 			"updated_at": "2023-10-01T00:00:00Z",
-			"deleted_at": nil,
-		}
-		var actualBody map[string]any
-		_ = json.Unmarshal(w.Body.Bytes(), &actualBody)
-		assert.Equal(t, http.StatusOK, w.Code)
-		assert.Equal(t, expectedBody, actualBody)
+			"deleted_at": null,
+			"roles": [{"id": 2, "name": "user"}],
+			"preferences": {"timezone": "UTC", "locale": "en", "email_notifications": true, "theme": "system", "updated_at": "0001-01-01T00:00:00Z"}
+		}`, w.Body.String())
 
 		// Assert mocks
 		userService.AssertExpectations(t)
@@ -403,9 +430,9 @@ func TestGetProfile(t *testing.T) {
 			UpdatedAt: time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC),
 		}
 		// Mock the service to return the cached profile
-		userService.On("GetProfile", mock.Anything, uint(1)).Return(user, nil)
+		userService.On("GetProfile", mock.Anything, uint(1)).Return(dto.NewProfileResponse(user), nil)
 
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
@@ -417,13 +444,21 @@ func TestGetProfile(t *testing.T) {
 
 		// Assert the response
 		expectedBody := map[string]any{
-			"id":         float64(1),
-			"email":      "email@example.com",
-			"name":       "User",
-			"gender":     float64(1),
-			"created_at": "2023-10-01T00:00:00Z",
-			"updated_at": "2023-10-01T00:00:00Z",
-			"deleted_at": nil,
+			"id":                   float64(1),
+			"email":                "email@example.com",
+			"pending_email":        nil,
+			"name":                 "User",
+			"birthday":             nil,
+			"address":              nil,
+			"gender":               float64(1),
+			"avatar":               nil,
+			"verified_at":          nil,
+			"must_change_password": false,
+			"created_at":           "2023-10-01T00:00:00Z",
+			"updated_at":           "2023-10-01T00:00:00Z",
+			"deleted_at":           nil,
+			"roles":                []any{},
+			"preferences":          nil,
 		}
 
 		var actualBody map[string]any
@@ -440,7 +475,7 @@ func TestGetProfile(t *testing.T) {
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
 
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
@@ -470,9 +505,9 @@ func TestGetProfile(t *testing.T) {
 		mailerService := new(mocks.MockMailerService)
 		userId := uint(1)
 
-		userService.On("GetProfile", mock.Anything, userId).Return(&models.User{}, apperror.NewNotFoundError("User not found"))
+		userService.On("GetProfile", mock.Anything, userId).Return((*dto.ProfileResponse)(nil), apperror.NewNotFoundError("User not found"))
 
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
 		c.Request, _ = http.NewRequest("GET", "/api/v1/profile", nil)
@@ -510,9 +545,9 @@ func TestGetProfile(t *testing.T) {
 			CreatedAt: time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC),
 			UpdatedAt: time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC),
 		}
-		userService.On("GetProfile", mock.Anything, uint(1)).Return(user, nil)
+		userService.On("GetProfile", mock.Anything, uint(1)).Return(dto.NewProfileResponse(user), nil)
 
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
 		c.Request, _ = http.NewRequest("GET", "/api/v1/profile", nil)
@@ -522,13 +557,21 @@ func TestGetProfile(t *testing.T) {
 		// Assert the response
 		assert.Equal(t, http.StatusOK, w.Code)
 		expectedBody := map[string]any{
-			"id":         float64(1),
-			"email":      "email@example.com",
-			"name":       "User",
-			"gender":     float64(1),
-			"created_at": "2023-10-01T00:00:00Z",
-			"updated_at": "2023-10-01T00:00:00Z",
-			"deleted_at": nil,
+			"id":                   float64(1),
+			"email":                "email@example.com",
+			"pending_email":        nil,
+			"name":                 "User",
+			"birthday":             nil,
+			"address":              nil,
+			"gender":               float64(1),
+			"avatar":               nil,
+			"verified_at":          nil,
+			"must_change_password": false,
+			"created_at":           "2023-10-01T00:00:00Z",
+			"updated_at":           "2023-10-01T00:00:00Z",
+			"deleted_at":           nil,
+			"roles":                []any{},
+			"preferences":          nil,
 		}
 
 		var actualBody map[string]any
@@ -550,7 +593,8 @@ func TestChangePassword(t *testing.T) {
 	t.Run("ChangePassword - Success", func(t *testing.T) {
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
-		handler := handlers.NewUserHandler(userService, mailerService)
+		var auditBuf bytes.Buffer
+		handler := handlers.NewUserHandler(userService, mailerService, audit.NewAuditLogger(&auditBuf))
 
 		user := &models.User{
 			ID:        1,
@@ -563,16 +607,16 @@ func TestChangePassword(t *testing.T) {
 		}
 		requestBody := map[string]any{
 			"old_password":     "12345678",
-			"new_password":     "newpassword",
-			"confirm_password": "newpassword",
+			"new_password":     "NewPassw0rd",
+			"confirm_password": "NewPassw0rd",
 		}
 		body, _ := json.Marshal(requestBody)
 
 		// Mock the services methods
 		userService.On("ChangePassword", mock.Anything, uint(1), mock.MatchedBy(func(input *dto.ChangePasswordInput) bool {
 			return input.OldPassword == "12345678" &&
-				input.NewPassword == "newpassword" &&
-				input.ConfirmPassword == "newpassword"
+				input.NewPassword == "NewPassw0rd" &&
+				input.ConfirmPassword == "NewPassw0rd"
 		})).Return(user, nil)
 
 		// Create http request and context
@@ -588,6 +632,12 @@ func TestChangePassword(t *testing.T) {
 		assert.Equal(t, http.StatusOK, w.Code)
 		assert.JSONEq(t, `{"message":"Change password successfully"}`, w.Body.String())
 
+		var entry audit.Entry
+		assert.NoError(t, json.Unmarshal(auditBuf.Bytes(), &entry))
+		assert.Equal(t, audit.ActionPasswordChanged, entry.Action)
+		assert.Equal(t, uint(1), entry.UserID)
+		assert.NotContains(t, auditBuf.String(), "NewPassw0rd")
+
 		// Assert mocks
 		userService.AssertExpectations(t)
 		mailerService.AssertExpectations(t)
@@ -666,7 +716,7 @@ func TestChangePassword(t *testing.T) {
 			},
 			{
 				name:         "EmptyConfirmPassword",
-				reqBody:      `{"old_password":"12345678","new_password":"newpassword","confirm_password":""}`,
+				reqBody:      `{"old_password":"12345678","new_password":"NewPassw0rd","confirm_password":""}`,
 				expectedCode: float64(4001),
 				expectedMsg:  "Validation failed",
 				expectedFields: []apperror.FieldError{
@@ -675,7 +725,7 @@ func TestChangePassword(t *testing.T) {
 			},
 			{
 				name:         "ShortConfirmPassword",
-				reqBody:      `{"old_password":"12345678","new_password":"newpassword","confirm_password":"short"}`,
+				reqBody:      `{"old_password":"12345678","new_password":"NewPassw0rd","confirm_password":"short"}`,
 				expectedCode: float64(4001),
 				expectedMsg:  "Validation failed",
 				expectedFields: []apperror.FieldError{
@@ -684,7 +734,7 @@ func TestChangePassword(t *testing.T) {
 			},
 			{
 				name:         "LongConfirmPassword",
-				reqBody:      `{"old_password":"12345678","new_password":"newpassword","confirm_password":"` + strings.Repeat("a", 256) + `"}`,
+				reqBody:      `{"old_password":"12345678","new_password":"NewPassw0rd","confirm_password":"` + strings.Repeat("a", 256) + `"}`,
 				expectedCode: float64(4001),
 				expectedMsg:  "Validation failed",
 				expectedFields: []apperror.FieldError{
@@ -697,7 +747,7 @@ func TestChangePassword(t *testing.T) {
 			t.Run(tt.name, func(t *testing.T) {
 				userService := new(mocks.MockUserService)
 				mailerService := new(mocks.MockMailerService)
-				handler := handlers.NewUserHandler(userService, mailerService)
+				handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 				// Create http request and context
 				w := httptest.NewRecorder()
@@ -731,12 +781,12 @@ func TestChangePassword(t *testing.T) {
 	t.Run("ChangePassword - NotFound User", func(t *testing.T) {
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 		requestBody := map[string]any{
 			"old_password":     "12345678",
-			"new_password":     "newpassword",
-			"confirm_password": "newpassword",
+			"new_password":     "NewPassw0rd",
+			"confirm_password": "NewPassw0rd",
 		}
 		body, _ := json.Marshal(requestBody)
 
@@ -771,12 +821,12 @@ func TestChangePassword(t *testing.T) {
 	t.Run("ChangePassword - Old Password Mismatch", func(t *testing.T) {
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 		requestBody := map[string]any{
 			"old_password":     "wrongpassword",
-			"new_password":     "newpassword",
-			"confirm_password": "newpassword",
+			"new_password":     "NewPassw0rd",
+			"confirm_password": "NewPassw0rd",
 		}
 		body, _ := json.Marshal(requestBody)
 
@@ -812,11 +862,11 @@ func TestChangePassword(t *testing.T) {
 	t.Run("ChangePassword - New Password and Confirm Password Mismatch", func(t *testing.T) {
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 		requestBody := map[string]any{
 			"old_password":     "12345678",
-			"new_password":     "123456789",
+			"new_password":     "Passw0rd123",
 			"confirm_password": "differentpassword",
 		}
 		body, _ := json.Marshal(requestBody)
@@ -851,12 +901,12 @@ func TestChangePassword(t *testing.T) {
 	t.Run("ChangePassword - Failed To Update", func(t *testing.T) {
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 		requestBody := map[string]any{
 			"old_password":     "12345678",
-			"new_password":     "newpassword",
-			"confirm_password": "newpassword",
+			"new_password":     "NewPassw0rd",
+			"confirm_password": "NewPassw0rd",
 		}
 		body, _ := json.Marshal(requestBody)
 
@@ -891,7 +941,7 @@ func TestChangePassword(t *testing.T) {
 	t.Run("ChangePassword - User Not found from ctx", func(t *testing.T) {
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 		// Create a test context
 		w := httptest.NewRecorder()
@@ -904,7 +954,7 @@ func TestChangePassword(t *testing.T) {
 
 		// Assert the response
 		assert.Equal(t, http.StatusBadRequest, w.Code)
-		assert.JSONEq(t, fmt.Sprintf(`{"code":%d,"message":"Invalid UserID"}`, apperror.ErrParseError), w.Body.String())
+		assert.JSONEq(t, fmt.Sprintf(`{"code":%d,"error":"ERR_PARSE","message":"Invalid UserID"}`, apperror.ErrParseError), w.Body.String())
 
 		// Assert mocks
 		userService.AssertExpectations(t)
@@ -914,12 +964,12 @@ func TestChangePassword(t *testing.T) {
 	t.Run("ChangePassword - Old Password equal to New Password", func(t *testing.T) {
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 		requestBody := map[string]any{
-			"old_password":     "12345678",
-			"new_password":     "12345678",
-			"confirm_password": "12345678",
+			"old_password":     "Passw0rd123",
+			"new_password":     "Passw0rd123",
+			"confirm_password": "Passw0rd123",
 		}
 		body, _ := json.Marshal(requestBody)
 
@@ -954,12 +1004,12 @@ func TestChangePassword(t *testing.T) {
 	t.Run("ChangePassword - Hash Password Failed", func(t *testing.T) {
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 		requestBody := map[string]any{
 			"old_password":     "12345678",
-			"new_password":     "newpassword",
-			"confirm_password": "newpassword",
+			"new_password":     "NewPassw0rd",
+			"confirm_password": "NewPassw0rd",
 		}
 		body, _ := json.Marshal(requestBody)
 
@@ -1001,11 +1051,11 @@ func TestResetPassword(t *testing.T) {
 	t.Run("ResetPassword - Success", func(t *testing.T) {
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 		requestBody := map[string]any{
 			"token":        "token",
-			"new_password": "newpassword",
+			"new_password": "NewPassw0rd",
 		}
 		body, _ := json.Marshal(requestBody)
 
@@ -1032,12 +1082,12 @@ func TestResetPassword(t *testing.T) {
 	t.Run("ResetPassword - Not found user by token", func(t *testing.T) {
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 		requestBody := map[string]any{
 			"token":        "invalid-token",
-			"password":     "newpassword",
-			"new_password": "newpassword",
+			"password":     "NewPassw0rd",
+			"new_password": "NewPassw0rd",
 		}
 		body, _ := json.Marshal(requestBody)
 
@@ -1072,11 +1122,11 @@ func TestResetPassword(t *testing.T) {
 	t.Run("ResetPassword - Token Expired", func(t *testing.T) {
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 		requestBody := map[string]any{
 			"token":        "invalid-token",
-			"new_password": "newpassword",
+			"new_password": "NewPassw0rd",
 		}
 		body, _ := json.Marshal(requestBody)
 
@@ -1110,12 +1160,12 @@ func TestResetPassword(t *testing.T) {
 	t.Run("ResetPassword - Error Hashing Password Failed", func(t *testing.T) {
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 		requestBody := map[string]any{
 			"token":        "token",
-			"password":     "newpassword",
-			"new_password": "newpassword",
+			"password":     "NewPassw0rd",
+			"new_password": "NewPassw0rd",
 		}
 		body, _ := json.Marshal(requestBody)
 
@@ -1149,12 +1199,12 @@ func TestResetPassword(t *testing.T) {
 	t.Run("Error failed to UpdateUser", func(t *testing.T) {
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 		requestBody := map[string]any{
 			"token":        "token",
-			"password":     "newpassword",
-			"new_password": "newpassword",
+			"password":     "NewPassw0rd",
+			"new_password": "NewPassw0rd",
 		}
 		body, _ := json.Marshal(requestBody)
 
@@ -1250,7 +1300,7 @@ func TestResetPassword(t *testing.T) {
 			},
 			{
 				name:         "EmptyNewPassword",
-				reqBody:      `{"token":"valid-token","password":"newpassword","new_password":""}`,
+				reqBody:      `{"token":"valid-token","password":"NewPassw0rd","new_password":""}`,
 				expectedCode: 4001,
 				expectedMsg:  "Validation failed",
 				expectedField: []apperror.FieldError{
@@ -1262,7 +1312,7 @@ func TestResetPassword(t *testing.T) {
 			},
 			{
 				name:         "NewPasswordTooShort",
-				reqBody:      `{"token":"valid-token","password":"newpassword","new_password":"short"}`,
+				reqBody:      `{"token":"valid-token","password":"NewPassw0rd","new_password":"short"}`,
 				expectedCode: 4001,
 				expectedMsg:  "Validation failed",
 				expectedField: []apperror.FieldError{
@@ -1274,7 +1324,7 @@ func TestResetPassword(t *testing.T) {
 			},
 			{
 				name:         "NewPasswordTooLong",
-				reqBody:      `{"token":"valid-token","password":"newpassword","new_password":"` + strings.Repeat("a", 256) + `"}`,
+				reqBody:      `{"token":"valid-token","password":"NewPassw0rd","new_password":"` + strings.Repeat("a", 256) + `"}`,
 				expectedCode: 4001,
 				expectedMsg:  "Validation failed",
 				expectedField: []apperror.FieldError{
@@ -1290,7 +1340,7 @@ func TestResetPassword(t *testing.T) {
 			t.Run(tt.name, func(t *testing.T) {
 				userService := new(mocks.MockUserService)
 				mailerService := new(mocks.MockMailerService)
-				handler := handlers.NewUserHandler(userService, mailerService)
+				handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 				// Create a test context
 				w := httptest.NewRecorder()
@@ -1342,7 +1392,7 @@ func TestForgotPassword(t *testing.T) {
 
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 		requestBody := map[string]any{
 			"email": "test@example.com",
@@ -1413,7 +1463,7 @@ func TestForgotPassword(t *testing.T) {
 			t.Run(tc.name, func(t *testing.T) {
 				userService := new(mocks.MockUserService)
 				mailerService := new(mocks.MockMailerService)
-				handler := handlers.NewUserHandler(userService, mailerService)
+				handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 				// Create a test context
 				w := httptest.NewRecorder()
@@ -1443,18 +1493,18 @@ func TestForgotPassword(t *testing.T) {
 		}
 	})
 
-	t.Run("ForgotPassword - User Not Found", func(t *testing.T) {
+	t.Run("ForgotPassword - Too Many Requests", func(t *testing.T) {
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 		requestBody := map[string]any{
-			"email": "notfound@example.com",
+			"email": "limited@example.com",
 		}
 		body, _ := json.Marshal(requestBody)
 
 		// Mock the service method to return an error
-		userService.On("ForgotPassword", mock.Anything, mock.AnythingOfType("*dto.ForgotPasswordInput")).Return(apperror.NewNotFoundError("User not found"))
+		userService.On("ForgotPassword", mock.Anything, mock.AnythingOfType("*dto.ForgotPasswordInput")).Return(apperror.NewTooManyAttemptsError("Too many password reset requests, please try again later"))
 
 		// Create a test context
 		w := httptest.NewRecorder()
@@ -1466,12 +1516,12 @@ func TestForgotPassword(t *testing.T) {
 
 		// Assert the response
 		expectedBody := map[string]any{
-			"code":    float64(apperror.ErrNotFound),
-			"message": "User not found",
+			"code":    float64(apperror.ErrTooManyAttempts),
+			"message": "Too many password reset requests, please try again later",
 		}
 		var actualBody map[string]any
 		_ = json.Unmarshal(w.Body.Bytes(), &actualBody)
-		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
 		assert.Equal(t, expectedBody["code"], actualBody["code"])
 		assert.Equal(t, expectedBody["message"], actualBody["message"])
 
@@ -1483,7 +1533,7 @@ func TestForgotPassword(t *testing.T) {
 	t.Run("ForgotPassword - Update User Error", func(t *testing.T) {
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 		requestBody := map[string]any{
 			"email": "test@example.com",
@@ -1520,7 +1570,7 @@ func TestForgotPassword(t *testing.T) {
 	t.Run("ForgotPassword - JSON Parse Error", func(t *testing.T) {
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 		// Create a test context with invalid JSON
 		w := httptest.NewRecorder()
@@ -1541,7 +1591,7 @@ func TestForgotPassword(t *testing.T) {
 	t.Run("ForgotPassword - Service Error", func(t *testing.T) {
 		userService := new(mocks.MockUserService)
 		mailerService := new(mocks.MockMailerService)
-		handler := handlers.NewUserHandler(userService, mailerService)
+		handler := handlers.NewUserHandler(userService, mailerService, discardAuditLogger)
 
 		requestBody := map[string]any{
 			"email": "test@example.com",
@@ -1566,3 +1616,1154 @@ func TestForgotPassword(t *testing.T) {
 		mailerService.AssertExpectations(t)
 	})
 }
+
+func TestGetUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	utils.InitValidator()
+
+	opts := dto.ListOptions{Page: 2, Limit: 20, SortBy: "created_at", SortDir: "desc"}
+
+	newGetUsersContext := func(query string, withOptions bool) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/api/v1/users?"+query, nil)
+		if withOptions {
+			c.Set(middlewares.ListOptionsKey, opts)
+		}
+		return w, c
+	}
+
+	t.Run("GetUsers - Success With Filters", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+
+		gender := int16(1)
+		filter := dto.UserFilterInput{Gender: &gender, Search: "bob"}
+		prevPage := 1
+		userService.On("GetUsers", mock.Anything, opts, filter).Return(&dto.Pagination[*models.User]{
+			Page:       2,
+			Limit:      20,
+			TotalItems: 21,
+			TotalPages: 2,
+			HasPrev:    true,
+			PrevPage:   &prevPage,
+			Data:       []*models.User{{ID: 7, Name: "Bob", Email: "bob@example.com", Gender: 1}},
+		}, nil)
+
+		w, c := newGetUsersContext("gender=1&search=bob", true)
+		handler.GetUsers(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, float64(2), response["page"])
+		assert.Equal(t, float64(20), response["limit"])
+		assert.Equal(t, float64(21), response["total_items"])
+		assert.Equal(t, float64(2), response["total_pages"])
+		assert.Equal(t, true, response["has_prev"])
+		assert.Equal(t, false, response["has_next"])
+		assert.Equal(t, "/api/v1/users?gender=1&page=1&search=bob", response["prev"])
+		assert.NotContains(t, response, "next")
+		assert.Equal(t, []any{map[string]any{
+			"id":                   float64(7),
+			"email":                "bob@example.com",
+			"name":                 "Bob",
+			"birthday":             nil,
+			"address":              nil,
+			"gender":               float64(1),
+			"avatar":               nil,
+			"verified_at":          nil,
+			"must_change_password": false,
+			"created_at":           "0001-01-01T00:00:00Z",
+			"updated_at":           "0001-01-01T00:00:00Z",
+			"deleted_at":           nil,
+		}}, response["data"])
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("GetUsers - Invalid Gender", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+
+		w, c := newGetUsersContext("gender=9", true)
+		handler.GetUsers(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, float64(apperror.ErrValidationFailed), response["code"])
+		userService.AssertNotCalled(t, "GetUsers", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("GetUsers - Missing List Options", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+
+		w, c := newGetUsersContext("", false)
+		handler.GetUsers(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		userService.AssertNotCalled(t, "GetUsers", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("GetUsers - Service Error", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("GetUsers", mock.Anything, opts, dto.UserFilterInput{}).Return(nil, apperror.NewDBQueryError("Failed to get users"))
+
+		w, c := newGetUsersContext("", true)
+		handler.GetUsers(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		userService.AssertExpectations(t)
+	})
+}
+
+func TestGetUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newGetUserContext := func(id string, query ...string) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/api/v1/users/"+id+strings.Join(query, ""), nil)
+		c.Params = gin.Params{{Key: "id", Value: id}}
+		return w, c
+	}
+
+	t.Run("GetUser - Success", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		token := "reset-token"
+		expiredAt := int64(1700000000)
+		pendingEmail := "new@example.com"
+		userService.On("GetUser", mock.Anything, uint(7), false).Return(&models.User{
+			ID:           7,
+			Name:         "Bob",
+			Email:        "bob@example.com",
+			PendingEmail: &pendingEmail,
+			Password:     "$2a$10$hash",
+			Token:        &token,
+			ExpiredAt:    &expiredAt,
+			CreatedAt:    time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC),
+			UpdatedAt:    time.Date(2023, 10, 2, 0, 0, 0, 0, time.UTC),
+		}, nil)
+
+		w, c := newGetUserContext("7")
+		handler.GetUser(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		// The password, the reset token and its expiry are not on the response
+		assert.JSONEq(t, `{
+			"id": 7,
+			"email": "bob@example.com",
+			"name": "Bob",
+			"birthday": null,
+			"address": null,
+			"gender": 0,
+			"avatar": null,
+			"verified_at": null,
+			"must_change_password": false,
+			"created_at": "2023-10-01T00:00:00Z",
+			"updated_at": "2023-10-02T00:00:00Z",
+			"deleted_at": null
+		}`, w.Body.String())
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("GetUser - Deleted", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("GetUser", mock.Anything, uint(7), false).Return(nil, apperror.NewNotFoundError("User has been deleted"))
+
+		w, c := newGetUserContext("7")
+		handler.GetUser(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "User has been deleted", response["message"])
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("GetUser - Include Deleted", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		deletedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+		userService.On("GetUser", mock.Anything, uint(7), true).Return(&models.User{ID: 7, Name: "Bob", DeletedAt: gorm.DeletedAt{Time: deletedAt, Valid: true}}, nil)
+
+		w, c := newGetUserContext("7", "?include_deleted=true")
+		handler.GetUser(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "2024-03-01T12:00:00Z", response["deleted_at"])
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("GetUser - Invalid Include Deleted", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+
+		w, c := newGetUserContext("7", "?include_deleted=maybe")
+		handler.GetUser(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		userService.AssertNotCalled(t, "GetUser", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("GetUser - Invalid ID", func(t *testing.T) {
+		for _, id := range []string{"abc", "0", "-1"} {
+			userService := new(mocks.MockUserService)
+			handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+
+			w, c := newGetUserContext(id)
+			handler.GetUser(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, "id=%s", id)
+			var response map[string]any
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, float64(apperror.ErrParseError), response["code"])
+			userService.AssertNotCalled(t, "GetUser", mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestRestoreUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRestoreUserContext := func(id string) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/v1/users/"+id+"/restore", nil)
+		c.Params = gin.Params{{Key: "id", Value: id}}
+		return w, c
+	}
+
+	t.Run("RestoreUser - Success", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("RestoreUser", mock.Anything, uint(7)).Return(&models.User{ID: 7, Name: "Bob"}, nil)
+
+		w, c := newRestoreUserContext("7")
+		handler.RestoreUser(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, float64(7), response["id"])
+		assert.Nil(t, response["deleted_at"])
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("RestoreUser - Not Found", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("RestoreUser", mock.Anything, uint(7)).Return(nil, apperror.NewNotFoundError("User not found"))
+
+		w, c := newRestoreUserContext("7")
+		handler.RestoreUser(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("RestoreUser - Invalid ID", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+
+		w, c := newRestoreUserContext("abc")
+		handler.RestoreUser(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		userService.AssertNotCalled(t, "RestoreUser", mock.Anything, mock.Anything)
+	})
+}
+
+func TestForceResetPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newForceResetContext := func(id string, adminID any) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/v1/users/"+id+"/force-reset-password", nil)
+		c.Params = gin.Params{{Key: "id", Value: id}}
+		if adminID != nil {
+			c.Set("UserID", adminID)
+		}
+		return w, c
+	}
+
+	t.Run("ForceResetPassword - Success", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("ForceResetPassword", mock.Anything, uint(7)).Return("TemporaryPass123", nil)
+
+		w, c := newForceResetContext("7", uint(1))
+		handler.ForceResetPassword(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "TemporaryPass123", response["temporary_password"])
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("ForceResetPassword - Not Found", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("ForceResetPassword", mock.Anything, uint(7)).Return("", apperror.NewNotFoundError("User not found"))
+
+		w, c := newForceResetContext("7", uint(1))
+		handler.ForceResetPassword(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("ForceResetPassword - Invalid ID", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+
+		w, c := newForceResetContext("abc", uint(1))
+		handler.ForceResetPassword(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		userService.AssertNotCalled(t, "ForceResetPassword", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ForceResetPassword - Missing Admin ID", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+
+		w, c := newForceResetContext("7", nil)
+		handler.ForceResetPassword(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		userService.AssertNotCalled(t, "ForceResetPassword", mock.Anything, mock.Anything)
+	})
+}
+
+func TestUnlockUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newUnlockContext := func(id string, adminID any) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/v1/users/"+id+"/unlock", nil)
+		c.Params = gin.Params{{Key: "id", Value: id}}
+		if adminID != nil {
+			c.Set("UserID", adminID)
+		}
+		return w, c
+	}
+
+	t.Run("UnlockUser - Success", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("UnlockUser", mock.Anything, uint(7)).Return(nil)
+
+		w, c := newUnlockContext("7", uint(1))
+		handler.UnlockUser(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("UnlockUser - Not Found", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("UnlockUser", mock.Anything, uint(7)).Return(apperror.NewNotFoundError("User not found"))
+
+		w, c := newUnlockContext("7", uint(1))
+		handler.UnlockUser(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("UnlockUser - Invalid ID", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+
+		w, c := newUnlockContext("abc", uint(1))
+		handler.UnlockUser(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		userService.AssertNotCalled(t, "UnlockUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("UnlockUser - Missing Admin ID", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+
+		w, c := newUnlockContext("7", nil)
+		handler.UnlockUser(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		userService.AssertNotCalled(t, "UnlockUser", mock.Anything, mock.Anything)
+	})
+}
+
+func TestDeleteUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	utils.InitValidator()
+
+	newDeleteUsersContext := func(body string) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/v1/users/bulk-delete", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("UserID", uint(1))
+		return w, c
+	}
+
+	t.Run("DeleteUsers - Success", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("DeleteUsers", mock.Anything, []uint{2, 3}).Return(&dto.BulkDeleteUsersResult{Deleted: []uint{2}, NotFound: []uint{3}}, nil)
+
+		w, c := newDeleteUsersContext(`{"ids":[2,3]}`)
+		handler.DeleteUsers(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, []any{float64(2)}, response["deleted"])
+		assert.Equal(t, []any{float64(3)}, response["not_found"])
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("DeleteUsers - Invalid Input", func(t *testing.T) {
+		for _, body := range []string{`{}`, `{"ids":[]}`, `{"ids":[0]}`, `{"ids":[-1]}`, `{"ids":"1"}`} {
+			userService := new(mocks.MockUserService)
+			handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+
+			w, c := newDeleteUsersContext(body)
+			handler.DeleteUsers(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, "body=%s", body)
+			userService.AssertNotCalled(t, "DeleteUsers", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("DeleteUsers - Own Account", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+
+		w, c := newDeleteUsersContext(`{"ids":[1,2]}`)
+		handler.DeleteUsers(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		userService.AssertNotCalled(t, "DeleteUsers", mock.Anything, mock.Anything)
+	})
+
+	t.Run("DeleteUsers - Service Error", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("DeleteUsers", mock.Anything, []uint{2}).Return(nil, apperror.NewDBDeleteError("Failed to delete users"))
+
+		w, c := newDeleteUsersContext(`{"ids":[2]}`)
+		handler.DeleteUsers(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		userService.AssertExpectations(t)
+	})
+}
+
+func TestUserRoles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	utils.InitValidator()
+
+	newUserRolesContext := func(method, id, body string) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(method, "/api/v1/users/"+id+"/roles", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Params = gin.Params{{Key: "id", Value: id}}
+		c.Set("UserID", uint(1))
+		return w, c
+	}
+
+	t.Run("GetUserRoles - Success", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("GetUserRoles", mock.Anything, uint(7)).Return([]dto.UserRoleResponse{{ID: 1, Name: "admin", Permissions: []string{"users.read"}}}, nil)
+
+		w, c := newUserRolesContext("GET", "7", "")
+		handler.GetUserRoles(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"data":[{"id":1,"name":"admin","permissions":["users.read"]}]}`, w.Body.String())
+	})
+
+	t.Run("GetUserRoles - No Roles Is An Empty Array", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("GetUserRoles", mock.Anything, uint(7)).Return([]dto.UserRoleResponse{}, nil)
+
+		w, c := newUserRolesContext("GET", "7", "")
+		handler.GetUserRoles(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"data":[]}`, w.Body.String())
+	})
+
+	t.Run("GetUserRoles - User Not Found", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("GetUserRoles", mock.Anything, uint(7)).Return(nil, apperror.NewNotFoundError("User not found"))
+
+		w, c := newUserRolesContext("GET", "7", "")
+		handler.GetUserRoles(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("AssignRoles - Success", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("AssignRoles", mock.Anything, uint(7), []uint{2, 3}).Return(nil)
+
+		w, c := newUserRolesContext("POST", "7", `{"role_ids":[2,3]}`)
+		handler.AssignRoles(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("RemoveRoles - Success", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("RemoveRoles", mock.Anything, uint(7), []uint{2}).Return(nil)
+
+		w, c := newUserRolesContext("DELETE", "7", `{"role_ids":[2]}`)
+		handler.RemoveRoles(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("AssignRoles - Roles Not Found", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("AssignRoles", mock.Anything, uint(7), []uint{2, 99}).Return(apperror.NewNotFoundError("Roles not found: 99"))
+
+		w, c := newUserRolesContext("POST", "7", `{"role_ids":[2,99]}`)
+		handler.AssignRoles(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "Roles not found: 99")
+	})
+
+	t.Run("AssignRoles - Invalid Input", func(t *testing.T) {
+		for _, body := range []string{``, `{}`, `{"role_ids":[]}`, `{"role_ids":[0]}`, `{"role_ids":"1"}`} {
+			userService := new(mocks.MockUserService)
+			handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+
+			w, c := newUserRolesContext("POST", "7", body)
+			handler.AssignRoles(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, "body=%s", body)
+			userService.AssertNotCalled(t, "AssignRoles", mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("RemoveRoles - Invalid ID", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+
+		w, c := newUserRolesContext("DELETE", "abc", `{"role_ids":[2]}`)
+		handler.RemoveRoles(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		userService.AssertNotCalled(t, "RemoveRoles", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestCreateUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	utils.InitValidator()
+
+	validBody := `{"email":"new@example.com","password":"Password123!","name":"New User","birthday":"1990-01-01","address":"123 Main Street","gender":1}`
+
+	newCreateUserContext := func(body string) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/v1/users", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		return w, c
+	}
+
+	t.Run("CreateUser - Success", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("CreateUser", mock.Anything, mock.AnythingOfType("*dto.CreateUserInput")).Return(&models.User{ID: 3}, nil)
+
+		w, c := newCreateUserContext(validBody)
+		handler.CreateUser(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "Create user successfully", response["message"])
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("CreateUser - Validation Error", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+
+		w, c := newCreateUserContext(`{"email":"not-an-email"}`)
+		handler.CreateUser(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, float64(apperror.ErrValidationFailed), response["code"])
+		userService.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("CreateUser - Email Conflict", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("CreateUser", mock.Anything, mock.AnythingOfType("*dto.CreateUserInput")).
+			Return(nil, apperror.NewDuplicateEmailError("email already exists").WithFields(apperror.FieldError{Field: "email", Message: "email already exists"}))
+
+		w, c := newCreateUserContext(validBody)
+		handler.CreateUser(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.JSONEq(t, `{"code":3009,"error":"ERR_DUPLICATE_EMAIL","message":"email already exists","fields":[{"field":"email","message":"email already exists"}]}`, w.Body.String())
+		userService.AssertExpectations(t)
+	})
+}
+
+func TestVerifyEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	utils.InitValidator()
+
+	newVerifyEmailContext := func(query string) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/api/v1/verify-email?"+query, nil)
+		return w, c
+	}
+
+	t.Run("VerifyEmail - Success", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("VerifyEmail", mock.Anything, "abc").Return(nil)
+
+		w, c := newVerifyEmailContext("token=abc")
+		handler.VerifyEmail(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "Verify email successfully", response["message"])
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("VerifyEmail - Missing Token", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+
+		w, c := newVerifyEmailContext("")
+		handler.VerifyEmail(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		userService.AssertNotCalled(t, "VerifyEmail", mock.Anything, mock.Anything)
+	})
+
+	t.Run("VerifyEmail - Expired Token", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("VerifyEmail", mock.Anything, "abc").Return(apperror.NewTokenExpiredError("Token has expired"))
+
+		w, c := newVerifyEmailContext("token=abc")
+		handler.VerifyEmail(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, float64(apperror.ErrTokenExpired), response["code"])
+		userService.AssertExpectations(t)
+	})
+}
+
+func TestResendVerification(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	utils.InitValidator()
+
+	newResendContext := func(body string) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/v1/resend-verification", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		return w, c
+	}
+
+	t.Run("ResendVerification - Success", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("ResendVerification", mock.Anything, &dto.ResendVerificationInput{Email: "user@example.com"}).Return(nil)
+
+		w, c := newResendContext(`{"email":"user@example.com"}`)
+		handler.ResendVerification(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("ResendVerification - Invalid Email", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+
+		w, c := newResendContext(`{"email":"invalid"}`)
+		handler.ResendVerification(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		userService.AssertNotCalled(t, "ResendVerification", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ResendVerification - Throttled", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("ResendVerification", mock.Anything, mock.Anything).Return(apperror.New(http.StatusTooManyRequests, 429, "Please wait"))
+
+		w, c := newResendContext(`{"email":"user@example.com"}`)
+		handler.ResendVerification(c)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		userService.AssertExpectations(t)
+	})
+}
+
+func TestUserHandlerAuditEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	utils.InitValidator()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		params     gin.Params
+		body       string
+		actorID    uint
+		setup      func(userService *mocks.MockUserService)
+		call       func(handler handlers.UserHandler, c *gin.Context)
+		action     string
+		targetID   uint
+		metadata   map[string]any
+		wantStatus int
+	}{
+		{
+			name:   "CreateUser",
+			method: "POST", path: "/api/v1/users",
+			body:    `{"email":"new@example.com","password":"Password123!","name":"New User","birthday":"1990-01-01","address":"123 Main Street","gender":1}`,
+			actorID: 1,
+			setup: func(userService *mocks.MockUserService) {
+				userService.On("CreateUser", mock.Anything, mock.Anything).Return(&models.User{ID: 3, Email: "new@example.com"}, nil)
+			},
+			call:     func(handler handlers.UserHandler, c *gin.Context) { handler.CreateUser(c) },
+			action:   audit.ActionUserCreated,
+			targetID: 3,
+			metadata: map[string]any{"email": "new@example.com"},
+		},
+		{
+			name:   "ResetPassword",
+			method: "POST", path: "/api/v1/reset-password",
+			body: `{"token":"reset-token","new_password":"NewPassw0rd"}`,
+			setup: func(userService *mocks.MockUserService) {
+				userService.On("ResetPassword", mock.Anything, mock.Anything).Return(&models.User{ID: 5}, nil)
+			},
+			call:     func(handler handlers.UserHandler, c *gin.Context) { handler.ResetPassword(c) },
+			action:   audit.ActionPasswordReset,
+			targetID: 5,
+		},
+		{
+			name:   "ChangePassword",
+			method: "POST", path: "/api/v1/change-password",
+			body:    `{"old_password":"OldPassw0rd","new_password":"NewPassw0rd","confirm_password":"NewPassw0rd"}`,
+			actorID: 5,
+			setup: func(userService *mocks.MockUserService) {
+				userService.On("ChangePassword", mock.Anything, uint(5), mock.Anything).Return(&models.User{ID: 5}, nil)
+			},
+			call:     func(handler handlers.UserHandler, c *gin.Context) { handler.ChangePassword(c) },
+			action:   audit.ActionPasswordChanged,
+			targetID: 5,
+		},
+		{
+			name:   "UpdateProfile",
+			method: "PATCH", path: "/api/v1/profile",
+			body:    `{"name":"New Name","gender":2}`,
+			actorID: 5,
+			setup: func(userService *mocks.MockUserService) {
+				userService.On("UpdateProfile", mock.Anything, uint(5), mock.Anything).Return(nil)
+			},
+			call:     func(handler handlers.UserHandler, c *gin.Context) { handler.UpdateProfile(c) },
+			action:   audit.ActionProfileUpdated,
+			targetID: 5,
+			metadata: map[string]any{"fields": []any{"name", "gender"}},
+		},
+		{
+			name:   "RestoreUser",
+			method: "POST", path: "/api/v1/users/7/restore",
+			params:  gin.Params{{Key: "id", Value: "7"}},
+			actorID: 1,
+			setup: func(userService *mocks.MockUserService) {
+				userService.On("RestoreUser", mock.Anything, uint(7)).Return(&models.User{ID: 7}, nil)
+			},
+			call:     func(handler handlers.UserHandler, c *gin.Context) { handler.RestoreUser(c) },
+			action:   audit.ActionUserRestored,
+			targetID: 7,
+		},
+		{
+			name:   "ForceResetPassword",
+			method: "POST", path: "/api/v1/users/7/force-reset-password",
+			params:  gin.Params{{Key: "id", Value: "7"}},
+			actorID: 1,
+			setup: func(userService *mocks.MockUserService) {
+				userService.On("ForceResetPassword", mock.Anything, uint(7)).Return("TemporaryPassw0rd", nil)
+			},
+			call:     func(handler handlers.UserHandler, c *gin.Context) { handler.ForceResetPassword(c) },
+			action:   audit.ActionPasswordForceReset,
+			targetID: 7,
+		},
+		{
+			name:   "UnlockUser",
+			method: "POST", path: "/api/v1/users/7/unlock",
+			params:  gin.Params{{Key: "id", Value: "7"}},
+			actorID: 1,
+			setup: func(userService *mocks.MockUserService) {
+				userService.On("UnlockUser", mock.Anything, uint(7)).Return(nil)
+			},
+			call:     func(handler handlers.UserHandler, c *gin.Context) { handler.UnlockUser(c) },
+			action:   audit.ActionUserUnlocked,
+			targetID: 7,
+		},
+		{
+			name:   "DeleteUsers",
+			method: "POST", path: "/api/v1/users/bulk-delete",
+			body:    `{"ids":[7,8]}`,
+			actorID: 1,
+			setup: func(userService *mocks.MockUserService) {
+				userService.On("DeleteUsers", mock.Anything, []uint{7, 8}).Return(&dto.BulkDeleteUsersResult{Deleted: []uint{7}, NotFound: []uint{8}}, nil)
+			},
+			call:     func(handler handlers.UserHandler, c *gin.Context) { handler.DeleteUsers(c) },
+			action:   audit.ActionUsersDeleted,
+			metadata: map[string]any{"deleted_user_ids": []any{float64(7)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService := new(mocks.MockUserService)
+			var auditBuf bytes.Buffer
+			handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), audit.NewAuditLogger(&auditBuf))
+			tt.setup(userService)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Params = tt.params
+			if tt.actorID != 0 {
+				c.Set("UserID", tt.actorID)
+			}
+
+			tt.call(handler, c)
+
+			assert.Less(t, w.Code, http.StatusBadRequest)
+			var entry audit.Entry
+			require.NoError(t, json.Unmarshal(auditBuf.Bytes(), &entry))
+			assert.Equal(t, tt.action, entry.Action)
+			if tt.actorID != 0 {
+				assert.Equal(t, tt.actorID, entry.UserID)
+			} else {
+				// Public endpoints record the user the action was applied to as the actor
+				assert.Equal(t, tt.targetID, entry.UserID)
+			}
+			if tt.targetID != 0 {
+				assert.Equal(t, audit.TargetTypeUser, entry.TargetType)
+				assert.Equal(t, tt.targetID, entry.TargetID)
+			} else {
+				assert.Empty(t, entry.TargetType)
+			}
+			for key, value := range tt.metadata {
+				assert.Equal(t, value, entry.Metadata[key])
+			}
+			assert.NotContains(t, auditBuf.String(), "Passw0rd")
+			userService.AssertExpectations(t)
+		})
+	}
+
+	t.Run("FailedActionIsNotRecorded", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		var auditBuf bytes.Buffer
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), audit.NewAuditLogger(&auditBuf))
+		userService.On("RestoreUser", mock.Anything, uint(7)).Return(nil, apperror.NewNotFoundError("User not found"))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/v1/users/7/restore", nil)
+		c.Params = gin.Params{{Key: "id", Value: "7"}}
+		c.Set("UserID", uint(1))
+		handler.RestoreUser(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Empty(t, auditBuf.String())
+	})
+}
+
+func TestSearchUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	utils.InitValidator()
+
+	newSearchUsersContext := func(query string) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/api/v1/users/search?"+query, nil)
+		return w, c
+	}
+
+	t.Run("SearchUsers - Strips Sensitive Fields", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		token := "verification-token"
+		expiredAt := time.Now().Unix()
+		verifiedAt := time.Now()
+		userService.On("SearchUsers", mock.Anything, "bob", 5).Return([]*models.User{{
+			ID:                 7,
+			Name:               "Bob",
+			Email:              "bob@example.com",
+			Password:           "hashed-password",
+			Gender:             1,
+			Token:              &token,
+			ExpiredAt:          &expiredAt,
+			VerifiedAt:         &verifiedAt,
+			MustChangePassword: true,
+			Roles:              []models.Role{{ID: 1, Name: "admin"}},
+		}}, nil)
+
+		w, c := newSearchUsersContext("q=bob&limit=5")
+		handler.SearchUsers(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"data":[{"id":7,"name":"Bob","email":"bob@example.com","gender":1}]}`, w.Body.String())
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("SearchUsers - No Results", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("SearchUsers", mock.Anything, "nobody", 0).Return([]*models.User{}, nil)
+
+		w, c := newSearchUsersContext("q=nobody")
+		handler.SearchUsers(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"data":[]}`, w.Body.String())
+	})
+
+	t.Run("SearchUsers - Invalid Query", func(t *testing.T) {
+		queries := map[string]string{
+			"Missing":       "",
+			"Too Short":     "q=a",
+			"Blank":         "q=%20%20",
+			"Too Long":      "q=" + strings.Repeat("a", 101),
+			"Limit Too Big": "q=bob&limit=51",
+			"Limit Invalid": "q=bob&limit=abc",
+		}
+		for name, query := range queries {
+			t.Run(name, func(t *testing.T) {
+				userService := new(mocks.MockUserService)
+				handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+
+				w, c := newSearchUsersContext(query)
+				handler.SearchUsers(c)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				userService.AssertNotCalled(t, "SearchUsers", mock.Anything, mock.Anything, mock.Anything)
+			})
+		}
+	})
+
+	t.Run("SearchUsers - Service Error", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("SearchUsers", mock.Anything, "bob", 0).Return(nil, apperror.NewDBQueryError("Failed to search users"))
+
+		w, c := newSearchUsersContext("q=bob")
+		handler.SearchUsers(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestExportUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	utils.InitValidator()
+
+	newExportUsersContext := func(query string) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/api/v1/users/export?"+query, nil)
+		return w, c
+	}
+	writeExport := func(body string) func(mock.Arguments) {
+		return func(args mock.Arguments) {
+			_, _ = io.WriteString(args.Get(3).(io.Writer), body)
+		}
+	}
+
+	t.Run("ExportUsers - CSV By Default", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		gender := int16(2)
+		userService.On("ExportUsers", mock.Anything, dto.UserFilterInput{Gender: &gender, Search: "bob"}, "csv", mock.Anything).
+			Return(nil).Run(writeExport("id,name\n")).Once()
+
+		w, c := newExportUsersContext("gender=2&search=bob")
+		handler.ExportUsers(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Regexp(t, `^attachment; filename="users-\d{8}-\d{6}\.csv"$`, w.Header().Get("Content-Disposition"))
+		assert.Equal(t, "id,name\n", w.Body.String())
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("ExportUsers - XLSX", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("ExportUsers", mock.Anything, dto.UserFilterInput{IncludeDeleted: true}, "xlsx", mock.Anything).
+			Return(nil).Run(writeExport("PK")).Once()
+
+		w, c := newExportUsersContext("format=xlsx&include_deleted=true")
+		handler.ExportUsers(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", w.Header().Get("Content-Type"))
+		assert.Regexp(t, `\.xlsx"$`, w.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("ExportUsers - Invalid Query", func(t *testing.T) {
+		for name, query := range map[string]string{"Format": "format=pdf", "Gender": "gender=9"} {
+			t.Run(name, func(t *testing.T) {
+				userService := new(mocks.MockUserService)
+				handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+
+				w, c := newExportUsersContext(query)
+				handler.ExportUsers(c)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				userService.AssertNotCalled(t, "ExportUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			})
+		}
+	})
+
+	t.Run("ExportUsers - Error Before Any Rows", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("ExportUsers", mock.Anything, dto.UserFilterInput{}, "csv", mock.Anything).Return(apperror.NewDBQueryError("Failed to export users"))
+
+		w, c := newExportUsersContext("")
+		handler.ExportUsers(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+		assert.Empty(t, w.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("ExportUsers - Error After Rows Were Sent", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("ExportUsers", mock.Anything, dto.UserFilterInput{}, "csv", mock.Anything).
+			Return(apperror.NewDBQueryError("Failed to export users")).Run(writeExport("id,name\n1,Alice\n"))
+
+		w, c := newExportUsersContext("")
+		handler.ExportUsers(c)
+
+		// The download is cut short; no error body is appended to it
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "id,name\n1,Alice\n", w.Body.String())
+	})
+}
+
+func TestImportUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	utils.InitValidator()
+
+	newImportUsersContext := func(query, field, content string) (*httptest.ResponseRecorder, *gin.Context) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		if field != "" {
+			part, err := form.CreateFormFile(field, "users.csv")
+			require.NoError(t, err)
+			_, _ = part.Write([]byte(content))
+		}
+		require.NoError(t, form.Close())
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/v1/users/import?"+query, &body)
+		c.Request.Header.Set("Content-Type", form.FormDataContentType())
+		return w, c
+	}
+
+	t.Run("ImportUsers - Passes The Uploaded File", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		var uploaded string
+		report := &dto.UserImportReport{DryRun: true, Rows: 1, Imported: 1, Skipped: []dto.UserImportRowError{}}
+		userService.On("ImportUsers", mock.Anything, mock.Anything, true).Return(report, nil).Run(func(args mock.Arguments) {
+			data, _ := io.ReadAll(args.Get(1).(io.Reader))
+			uploaded = string(data)
+		}).Once()
+
+		w, c := newImportUsersContext("dry_run=true", "file", "email,name\n")
+		handler.ImportUsers(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "email,name\n", uploaded)
+		assert.JSONEq(t, `{"dry_run":true,"rows":1,"imported":1,"skipped":[]}`, w.Body.String())
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("ImportUsers - Missing File", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+
+		w, c := newImportUsersContext("", "attachment", "email\n")
+		handler.ImportUsers(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		userService.AssertNotCalled(t, "ImportUsers", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ImportUsers - Not Multipart", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/v1/users/import", strings.NewReader("email\n"))
+		c.Request.Header.Set("Content-Type", "text/csv")
+
+		handler.ImportUsers(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("ImportUsers - Service Error", func(t *testing.T) {
+		userService := new(mocks.MockUserService)
+		handler := handlers.NewUserHandler(userService, new(mocks.MockMailerService), discardAuditLogger)
+		userService.On("ImportUsers", mock.Anything, mock.Anything, false).Return(nil, apperror.NewBadRequestError("Missing column \"email\"")).Once()
+
+		w, c := newImportUsersContext("", "file", "name\n")
+		handler.ImportUsers(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Missing column")
+	})
+}
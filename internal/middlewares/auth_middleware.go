@@ -5,8 +5,10 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
 	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
 )
 
 // AuthMiddleware creates a Gin middleware function that handles JWT authentication
@@ -15,9 +17,10 @@ import (
 // - Authorization header exists and has "Bearer " prefix
 // - Token is valid and can be parsed
 // - Token has "access" scope
-// If validation succeeds, it sets the user ID from token claims in context
+// - Token has not been revoked by a logout
+// If validation succeeds, it sets the user ID and the access token from token claims in context
 // If validation fails, it returns 401 Unauthorized
-func AuthMiddleware(jwtService services.JWTService) gin.HandlerFunc {
+func AuthMiddleware(jwtService services.JWTService, tokenBlacklistService services.TokenBlacklistService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 
 		authHeader := ctx.GetHeader("Authorization")
@@ -34,7 +37,26 @@ func AuthMiddleware(jwtService services.JWTService) gin.HandlerFunc {
 			return
 		}
 
+		// Tokens issued before the jti claim was added cannot be revoked individually
+		tokenID := claims.RegisteredClaims.ID
+		if tokenID != "" {
+			revoked, err := tokenBlacklistService.IsRevoked(ctx.Request.Context(), tokenID)
+			if err != nil {
+				// Fail open so a cache outage does not lock every user out
+				logger.WithContext(ctx.Request.Context()).Warnf("Failed to check access token revocation: %v", err)
+			} else if revoked {
+				utils.RespondWithError(ctx, apperror.NewUnauthorizedError("Unauthorized"))
+				return
+			}
+		}
+
+		accessToken := &dto.AccessTokenInfo{ID: tokenID}
+		if claims.ExpiresAt != nil {
+			accessToken.ExpiresAt = claims.ExpiresAt.Time
+		}
+
 		ctx.Set("UserID", claims.ID)
+		ctx.Set("AccessToken", accessToken)
 		ctx.Next()
 	}
 }
@@ -1,6 +1,7 @@
 package middlewares
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -8,7 +9,11 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
 	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
 )
 
@@ -187,7 +192,7 @@ func TestAuthMiddleware_DirectCall(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			router := gin.New()
-			router.Use(AuthMiddleware(jwtService))
+			router.Use(AuthMiddleware(jwtService, services.NewTokenBlacklistService(services.NewMemoryRedisService(0))))
 			router.GET("/test", func(c *gin.Context) {
 				c.JSON(http.StatusOK, gin.H{"message": "success"})
 			})
@@ -221,7 +226,7 @@ func TestAuthMiddleware_WithRealJWT(t *testing.T) {
 
 	t.Run("Valid JWT access token", func(t *testing.T) {
 		router := gin.New()
-		router.Use(AuthMiddleware(jwtService))
+		router.Use(AuthMiddleware(jwtService, services.NewTokenBlacklistService(services.NewMemoryRedisService(0))))
 
 		var capturedUserID interface{}
 		router.GET("/test", func(c *gin.Context) {
@@ -238,6 +243,70 @@ func TestAuthMiddleware_WithRealJWT(t *testing.T) {
 		assert.Equal(t, http.StatusOK, w.Code)
 		assert.Equal(t, uint(123), capturedUserID)
 	})
+
+	t.Run("Sets the access token in context", func(t *testing.T) {
+		router := gin.New()
+		router.Use(AuthMiddleware(jwtService, services.NewTokenBlacklistService(services.NewMemoryRedisService(0))))
+
+		var accessToken *dto.AccessTokenInfo
+		router.GET("/test", func(c *gin.Context) {
+			accessToken, _ = utils.GetAccessTokenFromContext(c)
+			c.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+accessTokenResult.Token)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		if assert.NotNil(t, accessToken) {
+			assert.NotEmpty(t, accessToken.ID)
+			assert.Equal(t, accessTokenResult.ExpiresAt, accessToken.ExpiresAt.Unix())
+		}
+	})
+
+	t.Run("Revoked JWT access token", func(t *testing.T) {
+		tokenBlacklistService := services.NewTokenBlacklistService(services.NewMemoryRedisService(0))
+		claims, err := jwtService.ValidateToken(accessTokenResult.Token)
+		assert.NoError(t, err)
+		assert.NoError(t, tokenBlacklistService.Revoke(context.Background(), claims.RegisteredClaims.ID, claims.ExpiresAt.Time))
+
+		router := gin.New()
+		router.Use(AuthMiddleware(jwtService, tokenBlacklistService))
+		router.GET("/test", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+accessTokenResult.Token)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Cache failure lets the token through", func(t *testing.T) {
+		redisService := new(mocks.MockRedisService)
+		redisService.On("Exists", mock.Anything, mock.Anything).Return(false, apperror.NewCacheExistsError("connection refused"))
+
+		router := gin.New()
+		router.Use(AuthMiddleware(jwtService, services.NewTokenBlacklistService(redisService)))
+		router.GET("/test", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+accessTokenResult.Token)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		redisService.AssertExpectations(t)
+	})
 }
 
 // Helper function to check if authorization header has valid Bearer prefix
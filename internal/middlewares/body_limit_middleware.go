@@ -0,0 +1,25 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+// BodyLimitMiddleware rejects requests whose body is larger than maxBytes with 413 Request Entity Too Large.
+// A declared Content-Length is checked up front; otherwise reading past the limit fails with *http.MaxBytesError
+func BodyLimitMiddleware(maxBytes int64) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.ContentLength > maxBytes {
+			utils.RespondWithError(ctx, apperror.NewPayloadTooLargeError(fmt.Sprintf("Request body must not be larger than %d bytes", maxBytes)))
+			return
+		}
+		if ctx.Request.Body != nil {
+			ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBytes)
+		}
+		ctx.Next()
+	}
+}
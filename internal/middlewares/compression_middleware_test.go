@@ -0,0 +1,119 @@
+package middlewares_test
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/middlewares"
+)
+
+func TestCompressionMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	large := strings.Repeat("a", 2048)
+	router := gin.New()
+	router.Use(middlewares.CompressionMiddleware(1024))
+	router.GET("/small", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "ok"})
+	})
+	router.GET("/large", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": large})
+	})
+	router.GET("/image", func(c *gin.Context) {
+		c.Data(http.StatusOK, "image/png", []byte(large))
+	})
+	router.GET("/encoded", func(c *gin.Context) {
+		c.Header("Content-Encoding", "br")
+		c.Data(http.StatusOK, "application/json", []byte(large))
+	})
+	router.GET("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		for i := 1; i <= 3; i++ {
+			_, _ = c.Writer.WriteString("row" + strconv.Itoa(i) + "\n")
+			c.Writer.Flush()
+		}
+	})
+
+	get := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+	gunzip := func(t *testing.T, w *httptest.ResponseRecorder) string {
+		reader, err := gzip.NewReader(w.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	t.Run("Small Body Stays Uncompressed", func(t *testing.T) {
+		w := get("/small", "gzip")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		assert.JSONEq(t, `{"message":"ok"}`, w.Body.String())
+	})
+
+	t.Run("Large JSON Is Gzipped", func(t *testing.T) {
+		w := get("/large", "deflate, gzip;q=0.8")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		assert.Less(t, w.Body.Len(), len(large))
+		assert.JSONEq(t, `{"message":"`+large+`"}`, gunzip(t, w))
+	})
+
+	t.Run("Deflate When Gzip Is Not Accepted", func(t *testing.T) {
+		w := get("/large", "gzip;q=0, deflate")
+
+		assert.Equal(t, "deflate", w.Header().Get("Content-Encoding"))
+		body, err := io.ReadAll(flate.NewReader(w.Body))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"message":"`+large+`"}`, string(body))
+	})
+
+	t.Run("Identity Without Accept-Encoding", func(t *testing.T) {
+		w := get("/large", "")
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		assert.JSONEq(t, `{"message":"`+large+`"}`, w.Body.String())
+	})
+
+	t.Run("Other Content Types Stay Uncompressed", func(t *testing.T) {
+		w := get("/image", "gzip")
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, large, w.Body.String())
+	})
+
+	t.Run("Encoded Content Is Not Compressed Twice", func(t *testing.T) {
+		w := get("/encoded", "gzip")
+
+		assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, large, w.Body.String())
+	})
+
+	t.Run("Streamed Response Is Compressed And Flushed", func(t *testing.T) {
+		w := get("/stream", "gzip")
+
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.True(t, w.Flushed)
+		assert.Equal(t, "row1\nrow2\nrow3\n", gunzip(t, w))
+	})
+}
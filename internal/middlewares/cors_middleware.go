@@ -1,35 +1,56 @@
 package middlewares
 
 import (
+	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
 )
 
+// CORS_DEFAULT_ALLOWED_METHODS is used when CORS_ALLOWED_METHODS is not set
+const CORS_DEFAULT_ALLOWED_METHODS = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+
 // CORSMiddleware handles Cross-Origin Resource Sharing (CORS)
-// Security: Configure CORS_ALLOWED_ORIGINS environment variable with specific
-// origins (e.g., "http://localhost:5173,https://example.com"). Never use "*"
-// in production with credentials enabled.
+// It is configured from the environment when the router is built:
+//   - CORS_ALLOWED_ORIGINS: comma separated origins (e.g. "http://localhost:5173,https://example.com").
+//     When unset, no origin is allowed. "*" allows any origin.
+//   - CORS_ALLOWED_METHODS: comma separated methods allowed in preflight requests
+//   - CORS_ALLOW_CREDENTIALS: "true" to let browsers send cookies and auth headers; ignored with "*"
+//
+// CORS headers are only written for allowlisted origins. Preflight OPTIONS requests are
+// answered with 204 No Content and never reach the route handlers.
 func CORSMiddleware() gin.HandlerFunc {
+	allowedOrigins := parseCommaList(utils.GetEnv("CORS_ALLOWED_ORIGINS", ""))
+	allowedMethods := strings.Join(parseCommaList(utils.GetEnv("CORS_ALLOWED_METHODS", CORS_DEFAULT_ALLOWED_METHODS)), ", ")
+	allowCredentials := utils.GetEnv("CORS_ALLOW_CREDENTIALS", "false") == "true"
+
+	// Echoing any origin together with credentials would let every site act on behalf of the user
+	if allowCredentials && slices.Contains(allowedOrigins, "*") {
+		logger.Warn("CORS_ALLOW_CREDENTIALS is ignored because CORS_ALLOWED_ORIGINS allows any origin")
+		allowCredentials = false
+	}
+
 	return func(c *gin.Context) {
 		origin := c.Request.Header.Get("Origin")
-		allowedOrigins := utils.GetEnv("CORS_ALLOWED_ORIGINS", "http://localhost:5173")
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
 
-		// Check if origin is allowed and set appropriate headers
 		if isOriginAllowed(origin, allowedOrigins) {
-			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
-			c.Writer.Header().Set("Vary", "Origin")
+			header.Set("Access-Control-Allow-Origin", origin)
+			if allowCredentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+			header.Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID, X-Device-Fingerprint")
+			header.Set("Access-Control-Allow-Methods", allowedMethods)
+			header.Set("Access-Control-Max-Age", "86400") // 24 hours
+			header.Set("Access-Control-Expose-Headers", "Content-Length, Authorization, X-Request-ID")
 		}
 
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
-		c.Writer.Header().Set("Access-Control-Max-Age", "86400") // 24 hours
-		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, Authorization")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+		if c.Request.Method == http.MethodOptions {
+			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
 
@@ -38,20 +59,26 @@ func CORSMiddleware() gin.HandlerFunc {
 }
 
 // isOriginAllowed checks if the request origin is in the allowed origins list
-func isOriginAllowed(origin, allowedOrigins string) bool {
+func isOriginAllowed(origin string, allowedOrigins []string) bool {
 	if origin == "" {
 		return false
 	}
 
-	if allowedOrigins == "*" {
-		return true
-	}
-
-	origins := strings.SplitSeq(allowedOrigins, ",")
-	for allowed := range origins {
-		if strings.TrimSpace(allowed) == origin {
+	for _, allowed := range allowedOrigins {
+		if allowed == "*" || allowed == origin {
 			return true
 		}
 	}
 	return false
 }
+
+// parseCommaList splits a comma separated env value, trimming spaces and dropping empty entries
+func parseCommaList(value string) []string {
+	var items []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
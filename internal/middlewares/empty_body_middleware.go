@@ -1,37 +1,68 @@
 package middlewares
 
 import (
+	"bufio"
 	"bytes"
+	"errors"
 	"io"
 	"net/http"
+	"slices"
+	"unicode"
 
 	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
 	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
 )
 
 // Middleware to reject requests with empty JSON body
-func EmptyBodyMiddleware() gin.HandlerFunc {
+// Routes listed in skipPaths (as registered, e.g. "/api/v1/logout-all") take no body and are not checked
+func EmptyBodyMiddleware(skipPaths ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if slices.Contains(skipPaths, c.FullPath()) {
+			c.Next()
+			return
+		}
+
 		if c.Request.Method == http.MethodPost || c.Request.Method == http.MethodPut || c.Request.Method == http.MethodPatch {
-			var bodyBytes []byte
+			// Only the leading whitespace and the first byte after it are read, so large uploads are not buffered
+			var prefix []byte
+			var rest *bufio.Reader
 			var err error
-
-			// Check if body is not nil before reading
 			if c.Request.Body != nil {
-				bodyBytes, err = io.ReadAll(c.Request.Body)
+				rest = bufio.NewReader(c.Request.Body)
+				prefix, err = readUntilNonSpace(rest)
 			}
 
 			// Check if body is nil, error reading, or empty content
-			if c.Request.Body == nil || err != nil || len(bytes.TrimSpace(bodyBytes)) == 0 {
-				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-					"code":    apperror.ErrEmptyData,
-					"message": "Request body cannot be empty",
-				})
+			if c.Request.Body == nil || err != nil || len(bytes.TrimSpace(prefix)) == 0 {
+				utils.RespondWithError(c, apperror.NewEmptyDataError("Request body cannot be empty"))
 				return
 			}
-			// Replace the body so the handler can read it again
-			c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+			// Put the bytes read back in front of the rest so the handler can read the whole body
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(prefix), rest), c.Request.Body}
 		}
 		c.Next()
 	}
 }
+
+// readUntilNonSpace reads up to and including the first byte that is not whitespace.
+// At the end of the body it returns what was read and no error
+func readUntilNonSpace(reader io.ByteReader) ([]byte, error) {
+	var prefix []byte
+	for {
+		b, err := reader.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return prefix, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		prefix = append(prefix, b)
+		if !unicode.IsSpace(rune(b)) {
+			return prefix, nil
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -30,6 +31,7 @@ func TestEmptyBodyMiddleware_RejectsEmptyBody(t *testing.T) {
 
 	expectedJSON := fmt.Sprintf(`{
 		"code": %d,
+		"error": "ERR_EMPTY_DATA",
 		"message": "Request body cannot be empty"
 	}`, apperror.ErrEmptyData)
 
@@ -125,3 +127,41 @@ func TestEmptyBodyMiddleware_IgnoresDELETE(t *testing.T) {
 	assert.Equal(t, http.StatusOK, resp.Code)
 	assert.JSONEq(t, `{"message": "OK"}`, resp.Body.String())
 }
+
+func TestEmptyBodyMiddleware_SkipsConfiguredPaths(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middlewares.EmptyBodyMiddleware("/no-body"))
+	router.POST("/no-body", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "OK"})
+	})
+	router.POST("/with-body", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "OK"})
+	})
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/no-body", nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/with-body", nil))
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestEmptyBodyMiddleware_PassesLargeBodyThrough(t *testing.T) {
+	router := gin.New()
+	router.Use(middlewares.EmptyBodyMiddleware())
+	router.POST("/test", func(c *gin.Context) {
+		body, _ := c.GetRawData()
+		c.JSON(http.StatusOK, gin.H{"size": len(body), "start": string(body[:4])})
+	})
+
+	body := "  \n" + strings.Repeat("x", 1<<20)
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, fmt.Sprintf(`{"size": %d, "start": "  \nx"}`, len(body)), resp.Body.String())
+}
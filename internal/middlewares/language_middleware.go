@@ -0,0 +1,20 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+)
+
+// LanguageMiddleware picks the language of the messages for each request, from its lang query parameter
+// or else its Accept-Language header, and falls back to English
+// The language is:
+// - Stored in the Gin context, where utils.RequestLanguage reads it
+// - Sent back in the Content-Language response header
+func LanguageMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		language := utils.ParseLanguage(c.Query("lang"), c.GetHeader("Accept-Language"))
+		c.Set(utils.LanguageKey, language)
+		c.Writer.Header().Set("Content-Language", language)
+		c.Next()
+	}
+}
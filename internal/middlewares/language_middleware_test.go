@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+)
+
+func TestLanguageMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		query    string
+		header   string
+		expected string
+	}{
+		{name: "Defaults to English", expected: utils.LANGUAGE_EN},
+		{name: "Accept-Language header", header: "ja-JP,ja;q=0.9", expected: utils.LANGUAGE_JA},
+		{name: "Query parameter wins over the header", query: "?lang=vi", header: "ja", expected: utils.LANGUAGE_VI},
+		{name: "Query parameter with a region", query: "?lang=ja-JP", expected: utils.LANGUAGE_JA},
+		{name: "Unsupported query parameter falls back to the header", query: "?lang=fr", header: "vi", expected: utils.LANGUAGE_VI},
+		{name: "Unsupported languages fall back to English", query: "?lang=fr", header: "de-DE", expected: utils.LANGUAGE_EN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(LanguageMiddleware())
+			var captured string
+			router.GET("/test", func(c *gin.Context) {
+				captured = utils.RequestLanguage(c)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test"+tt.query, nil)
+			if tt.header != "" {
+				req.Header.Set("Accept-Language", tt.header)
+			}
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expected, captured)
+			assert.Equal(t, tt.expected, resp.Header().Get("Content-Language"))
+		})
+	}
+}
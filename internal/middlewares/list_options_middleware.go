@@ -0,0 +1,142 @@
+package middlewares
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+const (
+	// ListOptionsKey is the context key for storing the parsed list options
+	ListOptionsKey = "ListOptions"
+	// SortDirAsc and SortDirDesc are the accepted sort directions
+	SortDirAsc  = "asc"
+	SortDirDesc = "desc"
+)
+
+// ListOptionsMiddleware parses the page, limit and sort query parameters
+// into a dto.ListOptions stored in the Gin context
+// - page defaults to 1 and must be a positive integer
+// - limit defaults to defaults.Limit (constants.LIMIT when zero) and is capped at constants.MAX_LIMIT
+// - sorting is given as sort=<field>:<dir>, sort=<field> (asc), sort=-<field> (desc) or sort_by=<field>&sort_dir=<dir>
+// - the sort field defaults to defaults.SortBy and must be one of allowedSortFields
+// - the sort direction defaults to defaults.SortDir (desc when empty) and must be either asc or desc
+// - a cursor parameter switches to cursor paging: an empty cursor starts from the first row and
+// a cursor from a previous page continues after it with that page's sort; page cannot be combined with it
+// Invalid parameters are rejected before the handler runs
+func ListOptionsMiddleware(defaults dto.ListOptions, allowedSortFields ...string) gin.HandlerFunc {
+	if defaults.Limit <= 0 {
+		defaults.Limit = constants.LIMIT
+	}
+	if defaults.SortDir == "" {
+		defaults.SortDir = SortDirDesc
+	}
+
+	return func(c *gin.Context) {
+		page, err := parsePositiveQueryInt(c, "page", 1)
+		if err != nil {
+			utils.RespondWithError(c, err)
+			return
+		}
+
+		limit, err := parsePositiveQueryInt(c, "limit", defaults.Limit)
+		if err != nil {
+			utils.RespondWithError(c, err)
+			return
+		}
+		limit = min(limit, constants.MAX_LIMIT)
+
+		sortBy, sortDir := c.Query("sort_by"), c.Query("sort_dir")
+		if sort := strings.TrimSpace(c.Query("sort")); sort != "" {
+			sortBy, sortDir = parseSortParam(sort)
+		}
+
+		rawCursor, cursorMode := c.GetQuery("cursor")
+		var cursor *dto.Cursor
+		if cursorMode {
+			if c.Query("page") != "" {
+				utils.RespondWithError(c, apperror.NewParseError("page and cursor cannot be used together"))
+				return
+			}
+			cursor = &dto.Cursor{}
+			if rawCursor = strings.TrimSpace(rawCursor); rawCursor != "" {
+				if cursor, err = utils.DecodeCursor(rawCursor); err != nil {
+					utils.RespondWithError(c, apperror.NewParseError("Invalid cursor"))
+					return
+				}
+				sortBy, sortDir = cursor.SortBy, cursor.SortDir
+			}
+			page = 0
+		}
+
+		sortBy = strings.TrimSpace(sortBy)
+		if sortBy == "" {
+			sortBy = defaults.SortBy
+		}
+		if !slices.Contains(allowedSortFields, sortBy) {
+			utils.RespondWithError(c, apperror.NewParseError(fmt.Sprintf("Invalid sort field: %s", sortBy)))
+			return
+		}
+
+		sortDir = strings.ToLower(strings.TrimSpace(sortDir))
+		if sortDir == "" {
+			sortDir = defaults.SortDir
+		}
+		if sortDir != SortDirAsc && sortDir != SortDirDesc {
+			utils.RespondWithError(c, apperror.NewParseError(fmt.Sprintf("Invalid sort direction: %s", sortDir)))
+			return
+		}
+
+		c.Set(ListOptionsKey, dto.ListOptions{
+			Page:    page,
+			Limit:   limit,
+			SortBy:  sortBy,
+			SortDir: sortDir,
+			Cursor:  cursor,
+		})
+		c.Next()
+	}
+}
+
+// GetListOptions retrieves the list options from the Gin context
+// Returns false if ListOptionsMiddleware did not run for the request
+func GetListOptions(c *gin.Context) (dto.ListOptions, bool) {
+	if value, exists := c.Get(ListOptionsKey); exists {
+		if opts, ok := value.(dto.ListOptions); ok {
+			return opts, true
+		}
+	}
+	return dto.ListOptions{}, false
+}
+
+// parseSortParam splits the sort query value into field and direction.
+// It accepts "field:dir", "field" (ascending) and "-field" (descending).
+func parseSortParam(sort string) (string, string) {
+	if field, dir, found := strings.Cut(sort, ":"); found {
+		return field, dir
+	}
+	if field, found := strings.CutPrefix(sort, "-"); found {
+		return field, SortDirDesc
+	}
+	return sort, SortDirAsc
+}
+
+// parsePositiveQueryInt reads an optional positive integer query parameter
+func parsePositiveQueryInt(c *gin.Context, name string, defaultValue int) (int, error) {
+	raw := strings.TrimSpace(c.Query(name))
+	if raw == "" {
+		return defaultValue, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value <= 0 {
+		return 0, apperror.NewParseError(fmt.Sprintf("%s must be a positive integer", name))
+	}
+	return value, nil
+}
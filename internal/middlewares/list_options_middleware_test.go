@@ -0,0 +1,250 @@
+package middlewares_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/middlewares"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+func setupListOptionsRouter(handlerCalled *bool, captured *dto.ListOptions) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/items", middlewares.ListOptionsMiddleware(dto.ListOptions{Limit: 10, SortBy: "id"}, "id", "name", "created_at"), func(c *gin.Context) {
+		*handlerCalled = true
+		opts, ok := middlewares.GetListOptions(c)
+		if ok {
+			*captured = opts
+		}
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestListOptionsMiddleware(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		var called bool
+		var opts dto.ListOptions
+		router := setupListOptionsRouter(&called, &opts)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, called)
+		assert.Equal(t, dto.ListOptions{Page: 1, Limit: 10, SortBy: "id", SortDir: middlewares.SortDirDesc}, opts)
+	})
+
+	t.Run("ValidParams", func(t *testing.T) {
+		var called bool
+		var opts dto.ListOptions
+		router := setupListOptionsRouter(&called, &opts)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items?page=3&limit=20&sort_by=name&sort_dir=ASC", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, called)
+		assert.Equal(t, dto.ListOptions{Page: 3, Limit: 20, SortBy: "name", SortDir: middlewares.SortDirAsc}, opts)
+	})
+
+	t.Run("CombinedSortParam", func(t *testing.T) {
+		var called bool
+		var opts dto.ListOptions
+		router := setupListOptionsRouter(&called, &opts)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items?sort=created_at:asc", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "created_at", opts.SortBy)
+		assert.Equal(t, middlewares.SortDirAsc, opts.SortDir)
+	})
+
+	t.Run("PrefixSortParam", func(t *testing.T) {
+		tests := []struct {
+			query   string
+			sortBy  string
+			sortDir string
+		}{
+			{"sort=name", "name", middlewares.SortDirAsc},
+			{"sort=-created_at", "created_at", middlewares.SortDirDesc},
+		}
+		for _, tt := range tests {
+			var called bool
+			var opts dto.ListOptions
+			router := setupListOptionsRouter(&called, &opts)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items?"+tt.query, nil))
+
+			assert.Equal(t, http.StatusOK, w.Code, tt.query)
+			assert.Equal(t, tt.sortBy, opts.SortBy, tt.query)
+			assert.Equal(t, tt.sortDir, opts.SortDir, tt.query)
+		}
+	})
+
+	t.Run("RejectsInvalidPageAndLimit", func(t *testing.T) {
+		for _, query := range []string{"page=-1", "page=0", "page=abc", "limit=0", "limit=-5", "limit=abc"} {
+			var called bool
+			var opts dto.ListOptions
+			router := setupListOptionsRouter(&called, &opts)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items?"+query, nil))
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+			assert.False(t, called, query)
+
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, float64(apperror.ErrParseError), body["code"], query)
+		}
+	})
+
+	t.Run("CapsLimitAtMax", func(t *testing.T) {
+		var called bool
+		var opts dto.ListOptions
+		router := setupListOptionsRouter(&called, &opts)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items?limit=500", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, constants.MAX_LIMIT, opts.Limit)
+	})
+
+	t.Run("FallsBackToPackageDefaults", func(t *testing.T) {
+		var opts dto.ListOptions
+		router := gin.New()
+		router.GET("/items", middlewares.ListOptionsMiddleware(dto.ListOptions{SortBy: "id"}, "id"), func(c *gin.Context) {
+			opts, _ = middlewares.GetListOptions(c)
+		})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+
+		assert.Equal(t, dto.ListOptions{Page: 1, Limit: constants.LIMIT, SortBy: "id", SortDir: middlewares.SortDirDesc}, opts)
+	})
+
+	t.Run("RejectsUnknownSortField", func(t *testing.T) {
+		var called bool
+		var opts dto.ListOptions
+		router := setupListOptionsRouter(&called, &opts)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items?sort_by=password", nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.False(t, called, "handler must not run for an invalid sort field")
+
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, float64(apperror.ErrParseError), body["code"])
+		assert.Equal(t, "Invalid sort field: password", body["message"])
+	})
+
+	t.Run("RejectsInvalidSortDirection", func(t *testing.T) {
+		var called bool
+		var opts dto.ListOptions
+		router := setupListOptionsRouter(&called, &opts)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items?sort_dir=sideways", nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.False(t, called)
+	})
+
+	t.Run("RejectsUnknownPrefixedSortField", func(t *testing.T) {
+		var called bool
+		var opts dto.ListOptions
+		router := setupListOptionsRouter(&called, &opts)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items?sort=-password", nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.False(t, called)
+	})
+
+	t.Run("RejectsInjectionThroughCombinedSortParam", func(t *testing.T) {
+		var called bool
+		var opts dto.ListOptions
+		router := setupListOptionsRouter(&called, &opts)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items?sort=id%3BDROP%20TABLE%20users:asc", nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.False(t, called)
+	})
+
+	t.Run("EmptyCursorStartsCursorPaging", func(t *testing.T) {
+		var called bool
+		var opts dto.ListOptions
+		router := setupListOptionsRouter(&called, &opts)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items?cursor=&limit=5&sort=name", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, dto.ListOptions{Limit: 5, SortBy: "name", SortDir: middlewares.SortDirAsc, Cursor: &dto.Cursor{}}, opts)
+	})
+
+	t.Run("CursorCarriesItsSort", func(t *testing.T) {
+		var called bool
+		var opts dto.ListOptions
+		router := setupListOptionsRouter(&called, &opts)
+		cursor := dto.Cursor{SortBy: "created_at", SortDir: "asc", Value: []byte(`"2025-01-01T00:00:00Z"`), ID: 7}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items?sort=-name&cursor="+utils.EncodeCursor(cursor), nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, dto.ListOptions{Limit: 10, SortBy: "created_at", SortDir: middlewares.SortDirAsc, Cursor: &cursor}, opts)
+	})
+
+	t.Run("RejectsInvalidCursors", func(t *testing.T) {
+		queries := map[string]string{
+			"NotBase64":          "cursor=%25%25%25",
+			"NotJSON":            "cursor=" + base64.RawURLEncoding.EncodeToString([]byte("not json")),
+			"UnknownSortField":   "cursor=" + utils.EncodeCursor(dto.Cursor{SortBy: "password", SortDir: "asc", ID: 1}),
+			"InvalidDirection":   "cursor=" + utils.EncodeCursor(dto.Cursor{SortBy: "id", SortDir: "up", ID: 1}),
+			"CombinedWithPage":   "cursor=&page=2",
+			"MissingLastSeenRow": "cursor=" + utils.EncodeCursor(dto.Cursor{SortBy: "id", SortDir: "asc"}),
+		}
+		for name, query := range queries {
+			t.Run(name, func(t *testing.T) {
+				var called bool
+				var opts dto.ListOptions
+				router := setupListOptionsRouter(&called, &opts)
+
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items?"+query, nil))
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.False(t, called)
+			})
+		}
+	})
+
+	t.Run("GetListOptionsWithoutMiddleware", func(t *testing.T) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+		opts, ok := middlewares.GetListOptions(c)
+
+		assert.False(t, ok)
+		assert.Equal(t, dto.ListOptions{}, opts)
+	})
+}
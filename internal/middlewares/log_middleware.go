@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -16,15 +18,26 @@ import (
 
 const (
 	// MAX_BODY_SIZE is the maximum size of request and response body to log (64 KB)
+	// Larger bodies are not logged at all, since a cut-off body cannot be censored reliably
 	MAX_BODY_SIZE = 1 << 16 // 64 KB
 )
 
+// Placeholders logged instead of bodies that are not captured
+const (
+	bodyTooLarge    = "[body omitted: larger than 65536 bytes]"
+	bodyMultipart   = "[body omitted: multipart upload]"
+	bodyStreamed    = "[body omitted: streamed response or download]"
+	bodyInvalidJSON = "[body omitted: invalid JSON]"
+	bodyUnparsable  = "[body omitted: neither JSON nor form data]"
+)
+
 // sensitiveKeys are field names that contain sensitive data and should be censored in logs
 var sensitiveKeys = []string{
-	"password", "api-key", "token", "access_token", "refresh_token",
+	"password", "old_password", "new_password", "confirm_password", "temporary_password",
+	"api-key", "token", "access_token", "refresh_token", "secret",
 	"ccv", "credit_card", "debit_card", "social_security_number",
 	"ssn", "bank_account", "bank_account_number",
-	"email", "phone", "address", "cvv",
+	"email", "phone", "address", "cvv", "backup_codes",
 }
 
 // sensitiveHeaders are HTTP headers that contain sensitive information
@@ -44,6 +57,7 @@ type LogResponse struct {
 	RequestID  string `json:"request_id,omitempty"`
 	Method     string `json:"method"`
 	URL        string `json:"url"`
+	ClientIP   string `json:"client_ip"`
 	Header     any    `json:"header"`
 	Request    any    `json:"request,omitempty"`
 	Response   any    `json:"response,omitempty"`
@@ -51,17 +65,40 @@ type LogResponse struct {
 	StatusCode string `json:"status_code"`
 }
 
-// the bodyWriter is a custom ResponseWriter that captures the response body
+// the bodyWriter is a custom ResponseWriter that captures up to MAX_BODY_SIZE bytes of the response body.
+// Streamed responses and downloads are passed through without being captured.
 type bodyWriter struct {
 	gin.ResponseWriter
-	body *bytes.Buffer
+	body      *bytes.Buffer
+	truncated bool
+	streamed  bool
 }
 
 func (w *bodyWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
+	w.capture(b)
 	return w.ResponseWriter.Write(b)
 }
 
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *bodyWriter) capture(b []byte) {
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") || strings.HasPrefix(w.Header().Get("Content-Disposition"), "attachment") {
+		w.streamed = true
+	}
+	if w.streamed || w.truncated {
+		return
+	}
+	if w.body.Len()+len(b) > MAX_BODY_SIZE {
+		w.truncated = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(b)
+}
+
 // filterSensitiveHeaders creates a copy of headers with sensitive values censored
 func filterSensitiveHeaders(headers map[string][]string) map[string][]string {
 	filtered := make(map[string][]string, len(headers))
@@ -76,48 +113,133 @@ func filterSensitiveHeaders(headers map[string][]string) map[string][]string {
 	return filtered
 }
 
-func LogMiddleware() gin.HandlerFunc {
+// censorValues censors query or form values. Single values are logged as plain strings.
+func censorValues(values url.Values, maskFields []string) any {
+	data := make(map[string]any, len(values))
+	for key, items := range values {
+		if len(items) == 1 {
+			data[key] = items[0]
+			continue
+		}
+		list := make([]any, len(items))
+		for i, item := range items {
+			list[i] = item
+		}
+		data[key] = list
+	}
+	return utils.CensorSensitiveData(data, maskFields)
+}
+
+// censorBody converts a captured body into its loggable form. Form bodies are parsed as such; any other body is
+// parsed as JSON whatever its content type, since ShouldBindJSON binds a JSON body sent as text/plain or without a
+// Content-Type. A body that does not parse cannot be censored and is replaced by a placeholder
+func censorBody(contentType string, body []byte, maskFields []string) any {
+	if len(body) == 0 {
+		return ""
+	}
+	if strings.Contains(contentType, "application/x-www-form-urlencoded") {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return bodyUnparsable
+		}
+		return censorValues(values, maskFields)
+	}
+	var data any
+	if err := json.Unmarshal(body, &data); err != nil {
+		if strings.Contains(contentType, "application/json") {
+			return bodyInvalidJSON
+		}
+		return bodyUnparsable
+	}
+	return utils.CensorSensitiveData(data, maskFields)
+}
+
+// readRequestBody reads the request body for logging and puts it back for the handlers.
+// It returns ok=false when the body is larger than MAX_BODY_SIZE; the handlers still receive it in full.
+func readRequestBody(c *gin.Context, requestID string) (body []byte, ok bool) {
+	if c.Request.Body == nil {
+		return nil, true
+	}
+	if c.Request.ContentLength > MAX_BODY_SIZE {
+		return nil, false
+	}
+
+	bodyBytes, err := io.ReadAll(io.LimitReader(c.Request.Body, MAX_BODY_SIZE+1))
+	if err != nil {
+		logger.WithField("request_id", requestID).Errorf("Failed to read request body: %v", err)
+	}
+	c.Request.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(bodyBytes), c.Request.Body), Closer: c.Request.Body}
+	if len(bodyBytes) > MAX_BODY_SIZE {
+		return nil, false
+	}
+	return bodyBytes, true
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// LOG_ALL_BODIES in LogOptions.BodyPaths logs the bodies of every route
+const LOG_ALL_BODIES = "*"
+
+// LogOptions configures LogMiddlewareWithOptions
+type LogOptions struct {
+	// MaskFields are censored in addition to sensitiveKeys
+	MaskFields []string
+	// BodyPaths are the routes, as registered (e.g. "/api/v1/users/:id"), whose request and response bodies
+	// are logged. Capturing bodies costs a copy of each, so none are logged when it is empty
+	BodyPaths []string
+}
+
+// LogMiddleware logs one structured line per request with method, path, status, latency, client IP,
+// headers and bodies. Fields named in sensitiveKeys or extraMaskFields are censored in query
+// parameters and JSON or form bodies; other bodies are not logged. Bodies above MAX_BODY_SIZE,
+// multipart uploads and streamed responses are not captured.
+func LogMiddleware(extraMaskFields ...string) gin.HandlerFunc {
+	return LogMiddlewareWithOptions(LogOptions{MaskFields: extraMaskFields, BodyPaths: []string{LOG_ALL_BODIES}})
+}
+
+// LogMiddlewareWithOptions is LogMiddleware only logging the bodies of the routes in opts.BodyPaths.
+// Other routes are logged without bodies, and their responses are not captured at all
+func LogMiddlewareWithOptions(opts LogOptions) gin.HandlerFunc {
+	maskFields := append(slices.Clone(sensitiveKeys), opts.MaskFields...)
+	allBodies := slices.Contains(opts.BodyPaths, LOG_ALL_BODIES)
+
 	return func(c *gin.Context) {
+		logBodies := allBodies || slices.Contains(opts.BodyPaths, c.FullPath())
 		timeStart := time.Now()
 
 		logEntry := LogResponse{
 			RequestID: GetRequestID(c),
 			Method:    c.Request.Method,
-			URL:       c.Request.URL.String(),
+			URL:       c.Request.URL.Path,
+			ClientIP:  c.ClientIP(),
 			Header:    filterSensitiveHeaders(c.Request.Header),
-			Request:   c.Request.URL.Query(),
+		}
+		if len(c.Request.URL.RawQuery) > 0 {
+			logEntry.Request = censorValues(c.Request.URL.Query(), maskFields)
 		}
 
-		// Only log request body if method is POST or PUT, and limit to maxBodySize
-		if c.Request.Method == "POST" || c.Request.Method == "PUT" || c.Request.Method == "PATCH" {
-			var bodyBytes []byte
-			if c.Request.Body != nil {
-				var err error
-				bodyBytes, err = io.ReadAll(io.LimitReader(c.Request.Body, MAX_BODY_SIZE))
-				if err != nil {
-					logger.WithField("request_id", logEntry.RequestID).Errorf("Failed to read request body: %v", err)
-				}
-				c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-			}
-
-			if strings.Contains(c.Request.Header.Get("Content-Type"), "application/json") {
-				var requestBody any
-				if err := json.Unmarshal(bodyBytes, &requestBody); err == nil {
-					requestBody = utils.CensorSensitiveData(requestBody, sensitiveKeys)
-					logEntry.Request = requestBody
-				} else {
-					logEntry.Request = string(bodyBytes)
-				}
+		// Only log request body if method is POST, PUT or PATCH
+		if logBodies && (c.Request.Method == "POST" || c.Request.Method == "PUT" || c.Request.Method == "PATCH") {
+			contentType := c.Request.Header.Get("Content-Type")
+			if strings.HasPrefix(contentType, "multipart/") {
+				logEntry.Request = bodyMultipart
+			} else if bodyBytes, ok := readRequestBody(c, logEntry.RequestID); !ok {
+				logEntry.Request = bodyTooLarge
 			} else {
-				logEntry.Request = string(bodyBytes)
+				logEntry.Request = censorBody(contentType, bodyBytes, maskFields)
 			}
 		}
 
-		// Limit response body capture to MAX_BODY_SIZE
-		responseBody := bytes.NewBuffer(make([]byte, 0, MAX_BODY_SIZE))
-		c.Writer = &bodyWriter{
-			ResponseWriter: c.Writer,
-			body:           responseBody,
+		var writer *bodyWriter
+		if logBodies {
+			writer = &bodyWriter{
+				ResponseWriter: c.Writer,
+				body:           bytes.NewBuffer(make([]byte, 0, 1024)),
+			}
+			c.Writer = writer
 		}
 
 		c.Next()
@@ -125,37 +247,26 @@ func LogMiddleware() gin.HandlerFunc {
 		logEntry.Latency = fmt.Sprintf("%d (ms)", time.Since(timeStart).Milliseconds())
 		logEntry.StatusCode = fmt.Sprintf("%d", c.Writer.Status())
 
-		// Limit response body to MAX_BODY_SIZE for logging
-		respBodyBytes := responseBody.Bytes()
-		if len(respBodyBytes) > MAX_BODY_SIZE {
-			respBodyBytes = respBodyBytes[:MAX_BODY_SIZE]
-		}
-
-		// If response is JSON, unmarshal and censor sensitive data
-		if strings.Contains(c.Writer.Header().Get("Content-Type"), "application/json") {
-			var responseBodyData any
-			if err := json.Unmarshal(respBodyBytes, &responseBodyData); err == nil {
-				responseBodyData = utils.CensorSensitiveData(responseBodyData, sensitiveKeys)
-				logEntry.Response = responseBodyData
-			} else {
-				logEntry.Response = string(respBodyBytes)
-			}
-		} else {
-			logEntry.Response = string(respBodyBytes)
+		switch {
+		case writer == nil:
+		case writer.streamed:
+			logEntry.Response = bodyStreamed
+		case writer.truncated:
+			logEntry.Response = bodyTooLarge
+		default:
+			logEntry.Response = censorBody(c.Writer.Header().Get("Content-Type"), writer.body.Bytes(), maskFields)
 		}
 
-		// Use goroutine to write log entry to avoid blocking
-		go func(entry LogResponse) {
-			logger.WithFields(log.Fields{
-				"request_id":  entry.RequestID,
-				"method":      entry.Method,
-				"url":         entry.URL,
-				"status_code": entry.StatusCode,
-				"latency":     entry.Latency,
-				"header":      entry.Header,
-				"request":     entry.Request,
-				"response":    entry.Response,
-			}).Info("HTTP request completed")
-		}(logEntry)
+		logger.WithFields(log.Fields{
+			"request_id":  logEntry.RequestID,
+			"method":      logEntry.Method,
+			"url":         logEntry.URL,
+			"client_ip":   logEntry.ClientIP,
+			"status_code": logEntry.StatusCode,
+			"latency":     logEntry.Latency,
+			"header":      logEntry.Header,
+			"request":     logEntry.Request,
+			"response":    logEntry.Response,
+		}).Info("HTTP request completed")
 	}
 }
@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"sync"
 	"testing"
@@ -49,7 +52,7 @@ func TestLogMiddleware(t *testing.T) {
 	var buf syncBuffer
 	logrus.SetOutput(&buf)
 	logrus.SetFormatter(&logrus.JSONFormatter{})
-	defer logrus.SetOutput(nil) // Reset after test
+	defer logrus.SetOutput(os.Stderr) // Reset after test
 
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
@@ -119,7 +122,7 @@ func TestLogMiddleware_GetRequest(t *testing.T) {
 	var buf syncBuffer
 	logrus.SetOutput(&buf)
 	logrus.SetFormatter(&logrus.JSONFormatter{})
-	defer logrus.SetOutput(nil)
+	defer logrus.SetOutput(os.Stderr)
 
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
@@ -142,7 +145,8 @@ func TestLogMiddleware_GetRequest(t *testing.T) {
 	assert.Equal(t, "GET", logEntry["method"])
 	assert.Equal(t, "/ping", logEntry["url"])
 	assert.Equal(t, "200", logEntry["status_code"])
-	assert.Equal(t, "pong", logEntry["response"])
+	// Plain text cannot be censored, so it is not logged
+	assert.Equal(t, bodyUnparsable, logEntry["response"])
 }
 
 func TestLogMiddleware_LargeBody(t *testing.T) {
@@ -150,13 +154,16 @@ func TestLogMiddleware_LargeBody(t *testing.T) {
 	var buf syncBuffer
 	logrus.SetOutput(&buf)
 	logrus.SetFormatter(&logrus.JSONFormatter{})
-	defer logrus.SetOutput(nil)
+	defer logrus.SetOutput(os.Stderr)
 
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 	r.Use(LogMiddleware())
 
+	var received int
 	r.POST("/large", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		received = len(body)
 		c.Status(http.StatusOK)
 	})
 
@@ -166,26 +173,21 @@ func TestLogMiddleware_LargeBody(t *testing.T) {
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 
-	time.Sleep(50 * time.Millisecond)
-
 	var logEntry map[string]interface{}
 	err := json.Unmarshal(buf.Bytes(), &logEntry)
 	assert.NoError(t, err)
 
-	// The middleware reads up to 64KB.
-	// Since it's not JSON, it logs as string.
-	// We verify that it captured something and didn't crash.
-	assert.NotEmpty(t, logEntry["request"])
-	reqStr, ok := logEntry["request"].(string)
-	assert.True(t, ok)
-	assert.True(t, len(reqStr) <= (1<<16))
+	// The body is skipped in the log but the handler still receives all of it
+	assert.Equal(t, bodyTooLarge, logEntry["request"])
+	assert.Equal(t, len(largeBody), received)
 }
+
 func TestLogMiddleware_LargeResponseBody(t *testing.T) {
 	// Setup log capture with thread-safe buffer
 	var buf syncBuffer
 	logrus.SetOutput(&buf)
 	logrus.SetFormatter(&logrus.JSONFormatter{})
-	defer logrus.SetOutput(nil)
+	defer logrus.SetOutput(os.Stderr)
 
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
@@ -207,10 +209,9 @@ func TestLogMiddleware_LargeResponseBody(t *testing.T) {
 	err := json.Unmarshal(buf.Bytes(), &logEntry)
 	assert.NoError(t, err)
 
-	// Verify response was truncated to maxBodySize (64KB)
-	respStr, ok := logEntry["response"].(string)
-	assert.True(t, ok)
-	assert.LessOrEqual(t, len(respStr), 1<<16, "Response should be truncated to 64KB")
+	// Verify response larger than 64KB is not logged
+	assert.Equal(t, bodyTooLarge, logEntry["response"])
+	assert.Equal(t, (1<<16)+1000, w.Body.Len())
 }
 
 func TestLogMiddleware_SensitiveHeaders(t *testing.T) {
@@ -218,7 +219,7 @@ func TestLogMiddleware_SensitiveHeaders(t *testing.T) {
 	var buf syncBuffer
 	logrus.SetOutput(&buf)
 	logrus.SetFormatter(&logrus.JSONFormatter{})
-	defer logrus.SetOutput(nil)
+	defer logrus.SetOutput(os.Stderr)
 
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
@@ -270,7 +271,7 @@ func TestLogMiddleware_MalformedJSON(t *testing.T) {
 	var buf syncBuffer
 	logrus.SetOutput(&buf)
 	logrus.SetFormatter(&logrus.JSONFormatter{})
-	defer logrus.SetOutput(nil)
+	defer logrus.SetOutput(os.Stderr)
 
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
@@ -306,7 +307,7 @@ func TestLogMiddleware_NonJSONContentType(t *testing.T) {
 	var buf syncBuffer
 	logrus.SetOutput(&buf)
 	logrus.SetFormatter(&logrus.JSONFormatter{})
-	defer logrus.SetOutput(nil)
+	defer logrus.SetOutput(os.Stderr)
 
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
@@ -328,16 +329,16 @@ func TestLogMiddleware_NonJSONContentType(t *testing.T) {
 	err := json.Unmarshal(buf.Bytes(), &logEntry)
 	assert.NoError(t, err)
 
-	// Verify non-JSON content is logged as string
-	assert.Equal(t, "plain text request", logEntry["request"])
-	assert.Equal(t, "plain text response", logEntry["response"])
+	// Bodies that are neither JSON nor form data cannot be censored, so they are not logged
+	assert.Equal(t, bodyUnparsable, logEntry["request"])
+	assert.Equal(t, bodyUnparsable, logEntry["response"])
 }
 
 func TestLogMiddleware_RequestBodyReadError(t *testing.T) {
 	var buf syncBuffer
 	logrus.SetOutput(&buf)
 	logrus.SetFormatter(&logrus.JSONFormatter{})
-	defer logrus.SetOutput(nil)
+	defer logrus.SetOutput(os.Stderr)
 
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
@@ -363,7 +364,7 @@ func TestLogMiddleware_MarshalLogEntryError(t *testing.T) {
 	var buf syncBuffer
 	logrus.SetOutput(&buf)
 	logrus.SetFormatter(&logrus.JSONFormatter{})
-	defer logrus.SetOutput(nil)
+	defer logrus.SetOutput(os.Stderr)
 
 	originalMarshal := marshalLogEntry
 	marshalLogEntry = func(_ any) ([]byte, error) {
@@ -394,7 +395,7 @@ func TestLogMiddleware_Concurrent(t *testing.T) {
 	var buf syncBuffer
 	logrus.SetOutput(&buf)
 	logrus.SetFormatter(&logrus.JSONFormatter{})
-	defer logrus.SetOutput(nil)
+	defer logrus.SetOutput(os.Stderr)
 
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
@@ -449,7 +450,7 @@ func TestLogMiddleware_PUTandPATCH(t *testing.T) {
 			var buf syncBuffer
 			logrus.SetOutput(&buf)
 			logrus.SetFormatter(&logrus.JSONFormatter{})
-			defer logrus.SetOutput(nil)
+			defer logrus.SetOutput(os.Stderr)
 
 			gin.SetMode(gin.TestMode)
 			r := gin.New()
@@ -490,7 +491,7 @@ func TestLogMiddleware_EmptyBody(t *testing.T) {
 	var buf syncBuffer
 	logrus.SetOutput(&buf)
 	logrus.SetFormatter(&logrus.JSONFormatter{})
-	defer logrus.SetOutput(nil)
+	defer logrus.SetOutput(os.Stderr)
 
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
@@ -517,3 +518,280 @@ func TestLogMiddleware_EmptyBody(t *testing.T) {
 	// Empty body should not cause errors
 	assert.NotNil(t, logEntry["request"])
 }
+
+func TestLogMiddleware_PasswordsNeverLogged(t *testing.T) {
+	var buf syncBuffer
+	logrus.SetOutput(&buf)
+	logrus.SetFormatter(&logrus.JSONFormatter{})
+	defer logrus.SetOutput(os.Stderr)
+
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"JSON", "application/json", `{"old_password":"OldPassw0rd!","new_password":"NewPassw0rd!","user":{"password":"NestedPassw0rd!"}}`},
+		{"Form", "application/x-www-form-urlencoded", "old_password=OldPassw0rd%21&new_password=NewPassw0rd%21&password=NestedPassw0rd%21"},
+		// ShouldBindJSON ignores the Content-Type, so these bodies still reach the handlers
+		{"JSON As Plain Text", "text/plain", `{"old_password":"OldPassw0rd!","new_password":"NewPassw0rd!","user":{"password":"NestedPassw0rd!"}}`},
+		{"JSON Without Content Type", "", `{"old_password":"OldPassw0rd!","new_password":"NewPassw0rd!","user":{"password":"NestedPassw0rd!"}}`},
+		{"Unparsable", "text/plain", "old_password: OldPassw0rd! new_password: NewPassw0rd! password: NestedPassw0rd!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.mu.Lock()
+			buf.buf.Reset()
+			buf.mu.Unlock()
+
+			r := gin.New()
+			r.Use(LogMiddleware())
+			r.POST("/change-password", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"password": "NewPassw0rd!"})
+			})
+
+			req, _ := http.NewRequest("POST", "/change-password", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			output := string(buf.Bytes())
+			assert.NotEmpty(t, output)
+			assert.NotContains(t, output, "OldPassw0rd")
+			assert.NotContains(t, output, "NewPassw0rd")
+			assert.NotContains(t, output, "NestedPassw0rd")
+		})
+	}
+}
+
+func TestLogMiddleware_TemporaryPasswordNeverLogged(t *testing.T) {
+	var buf syncBuffer
+	logrus.SetOutput(&buf)
+	logrus.SetFormatter(&logrus.JSONFormatter{})
+	defer logrus.SetOutput(os.Stderr)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(LogMiddleware())
+	// The response of the admin force password reset
+	r.POST("/users/:id/force-reset-password", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"temporary_password": "TempPassw0rd!"})
+	})
+
+	req, _ := http.NewRequest("POST", "/users/1/force-reset-password", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var logEntry map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &logEntry))
+	response, ok := logEntry["response"].(map[string]interface{})
+	assert.True(t, ok)
+	assert.NotEqual(t, "TempPassw0rd!", response["temporary_password"])
+	assert.NotContains(t, string(buf.Bytes()), "TempPassw0rd")
+}
+
+func TestLogMiddleware_QueryTokenCensored(t *testing.T) {
+	var buf syncBuffer
+	logrus.SetOutput(&buf)
+	logrus.SetFormatter(&logrus.JSONFormatter{})
+	defer logrus.SetOutput(os.Stderr)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(LogMiddleware())
+	r.GET("/verify-email", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "ok"})
+	})
+
+	req, _ := http.NewRequest("GET", "/verify-email?token=verification-token-value&page=2", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var logEntry map[string]interface{}
+	err := json.Unmarshal(buf.Bytes(), &logEntry)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "/verify-email", logEntry["url"])
+	assert.NotContains(t, string(buf.Bytes()), "verification-token-value")
+	reqMap, ok := logEntry["request"].(map[string]interface{})
+	assert.True(t, ok)
+	assert.Equal(t, "2", reqMap["page"])
+}
+
+func TestLogMiddleware_ExtraMaskFields(t *testing.T) {
+	var buf syncBuffer
+	logrus.SetOutput(&buf)
+	logrus.SetFormatter(&logrus.JSONFormatter{})
+	defer logrus.SetOutput(os.Stderr)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(LogMiddleware("pin_code"))
+	r.POST("/pin", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	req, _ := http.NewRequest("POST", "/pin", strings.NewReader(`{"pin_code":"987654","name":"john"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var logEntry map[string]interface{}
+	err := json.Unmarshal(buf.Bytes(), &logEntry)
+	assert.NoError(t, err)
+
+	reqMap, ok := logEntry["request"].(map[string]interface{})
+	assert.True(t, ok)
+	assert.Equal(t, "9****4", reqMap["pin_code"])
+	assert.Equal(t, "john", reqMap["name"])
+}
+
+func TestLogMiddleware_MultipartSkipped(t *testing.T) {
+	var buf syncBuffer
+	logrus.SetOutput(&buf)
+	logrus.SetFormatter(&logrus.JSONFormatter{})
+	defer logrus.SetOutput(os.Stderr)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(LogMiddleware())
+
+	var uploaded string
+	r.POST("/upload", func(c *gin.Context) {
+		file, err := c.FormFile("file")
+		if assert.NoError(t, err) {
+			uploaded = file.Filename
+		}
+		c.Status(http.StatusOK)
+	})
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("file", "avatar.png")
+	_, _ = part.Write([]byte("file-content-bytes"))
+	_ = mw.WriteField("password", "UploadPassw0rd!")
+	_ = mw.Close()
+
+	req, _ := http.NewRequest("POST", "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "avatar.png", uploaded)
+
+	var logEntry map[string]interface{}
+	err := json.Unmarshal(buf.Bytes(), &logEntry)
+	assert.NoError(t, err)
+	assert.Equal(t, bodyMultipart, logEntry["request"])
+	assert.NotContains(t, string(buf.Bytes()), "file-content-bytes")
+	assert.NotContains(t, string(buf.Bytes()), "UploadPassw0rd")
+}
+
+func TestLogMiddleware_StreamingResponse(t *testing.T) {
+	var buf syncBuffer
+	logrus.SetOutput(&buf)
+	logrus.SetFormatter(&logrus.JSONFormatter{})
+	defer logrus.SetOutput(os.Stderr)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(LogMiddleware())
+	r.GET("/events", func(c *gin.Context) {
+		for i := range 3 {
+			c.SSEvent("message", i)
+			c.Writer.Flush()
+		}
+	})
+
+	req, _ := http.NewRequest("GET", "/events", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, w.Flushed)
+	assert.Contains(t, w.Body.String(), "data:2")
+
+	var logEntry map[string]interface{}
+	err := json.Unmarshal(buf.Bytes(), &logEntry)
+	assert.NoError(t, err)
+	assert.Equal(t, bodyStreamed, logEntry["response"])
+}
+
+func TestLogMiddleware_DownloadResponse(t *testing.T) {
+	var buf syncBuffer
+	logrus.SetOutput(&buf)
+	logrus.SetFormatter(&logrus.JSONFormatter{})
+	defer logrus.SetOutput(os.Stderr)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(LogMiddleware())
+	r.GET("/export", func(c *gin.Context) {
+		c.Header("Content-Disposition", `attachment; filename="users.csv"`)
+		c.Data(http.StatusOK, "text/csv", []byte("id,email\n1,someone@example.com\n"))
+	})
+
+	req, _ := http.NewRequest("GET", "/export", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var logEntry map[string]interface{}
+	err := json.Unmarshal(buf.Bytes(), &logEntry)
+	assert.NoError(t, err)
+	assert.Equal(t, bodyStreamed, logEntry["response"])
+	assert.NotContains(t, string(buf.Bytes()), "someone@example.com")
+}
+
+func TestLogMiddlewareWithOptions_BodyPaths(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(LogMiddlewareWithOptions(LogOptions{BodyPaths: []string{"/mfa/:id"}}))
+	handler := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"backup_codes": []string{"12345678", "87654321"}, "status": "ok"})
+	}
+	r.POST("/mfa/:id", handler)
+	r.POST("/other", handler)
+
+	logRequest := func(t *testing.T, path string) (map[string]interface{}, string) {
+		var buf syncBuffer
+		logrus.SetOutput(&buf)
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+		defer logrus.SetOutput(os.Stderr)
+
+		req, _ := http.NewRequest("POST", path, strings.NewReader(`{"secret":"JBSWY3DPEHPK3PXP","code":"123456"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		// The handler receives the body whether or not it is logged
+		assert.Equal(t, http.StatusOK, w.Code)
+		var logEntry map[string]interface{}
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &logEntry))
+		return logEntry, string(buf.Bytes())
+	}
+
+	t.Run("Listed Route", func(t *testing.T) {
+		logEntry, raw := logRequest(t, "/mfa/1")
+
+		reqMap, ok := logEntry["request"].(map[string]interface{})
+		assert.True(t, ok)
+		assert.Equal(t, "123456", reqMap["code"])
+		respMap, ok := logEntry["response"].(map[string]interface{})
+		assert.True(t, ok)
+		assert.Equal(t, "ok", respMap["status"])
+		assert.NotContains(t, raw, "JBSWY3DPEHPK3PXP")
+		assert.NotContains(t, raw, "12345678")
+	})
+
+	t.Run("Other Route", func(t *testing.T) {
+		logEntry, _ := logRequest(t, "/other")
+
+		assert.Equal(t, "200", logEntry["status_code"])
+		assert.Nil(t, logEntry["request"])
+		assert.Nil(t, logEntry["response"])
+	})
+}
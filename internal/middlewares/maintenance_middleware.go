@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+// MaintenanceMiddleware rejects requests with 503 Service Unavailable and a Retry-After header while the maintenance mode is on.
+// In read-only mode only GET, HEAD and OPTIONS requests are served.
+// Routes listed in allowedPaths (as registered, e.g. "/healthz") are always served
+func MaintenanceMiddleware(maintenanceService services.MaintenanceService, retryAfter time.Duration, allowedPaths ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if slices.Contains(allowedPaths, ctx.FullPath()) {
+			ctx.Next()
+			return
+		}
+
+		status := maintenanceService.GetStatus(ctx.Request.Context())
+		if !status.Enabled || (status.Mode == constants.MAINTENANCE_MODE_READ_ONLY && isReadOnlyMethod(ctx.Request.Method)) {
+			ctx.Next()
+			return
+		}
+
+		ctx.Header("Retry-After", strconv.Itoa(max(int(retryAfter.Seconds()), 1)))
+		utils.RespondWithError(ctx, apperror.NewUnavailableError(status.Message))
+	}
+}
+
+func isReadOnlyMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
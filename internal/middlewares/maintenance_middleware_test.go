@@ -0,0 +1,85 @@
+package middlewares_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/middlewares"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+func TestMaintenanceMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(status dto.MaintenanceStatus) *gin.Engine {
+		maintenanceService := new(mocks.MockMaintenanceService)
+		maintenanceService.On("GetStatus", mock.Anything).Return(status)
+
+		router := gin.New()
+		router.Use(middlewares.MaintenanceMiddleware(maintenanceService, 2*time.Minute, "/healthz", "/admin/maintenance"))
+		ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"message": "OK"}) }
+		router.GET("/healthz", ok)
+		router.POST("/admin/maintenance", ok)
+		router.GET("/items", ok)
+		router.HEAD("/items", ok)
+		router.POST("/items", ok)
+		router.PUT("/items", ok)
+		router.PATCH("/items", ok)
+		router.DELETE("/items", ok)
+		return router
+	}
+	serve := func(router *gin.Engine, method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+	writeMethods := []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+	t.Run("Disabled", func(t *testing.T) {
+		router := newRouter(dto.MaintenanceStatus{Enabled: false})
+		for _, method := range append(writeMethods, http.MethodGet) {
+			assert.Equal(t, http.StatusOK, serve(router, method, "/items").Code, method)
+		}
+	})
+
+	t.Run("Full Mode Blocks Every Method", func(t *testing.T) {
+		router := newRouter(dto.MaintenanceStatus{Enabled: true, Mode: constants.MAINTENANCE_MODE_FULL, Message: "Back at 10:00"})
+		for _, method := range append(writeMethods, http.MethodGet, http.MethodHead) {
+			w := serve(router, method, "/items")
+			assert.Equal(t, http.StatusServiceUnavailable, w.Code, method)
+			assert.Equal(t, "120", w.Header().Get("Retry-After"), method)
+		}
+
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(serve(router, http.MethodGet, "/items").Body.Bytes(), &response))
+		assert.Equal(t, float64(apperror.ErrUnavailable), response["code"])
+		assert.Equal(t, "Back at 10:00", response["message"])
+	})
+
+	t.Run("Read Only Mode Blocks Writes", func(t *testing.T) {
+		router := newRouter(dto.MaintenanceStatus{Enabled: true, Mode: constants.MAINTENANCE_MODE_READ_ONLY, Message: "Read only"})
+		assert.Equal(t, http.StatusOK, serve(router, http.MethodGet, "/items").Code)
+		assert.Equal(t, http.StatusOK, serve(router, http.MethodHead, "/items").Code)
+		for _, method := range writeMethods {
+			w := serve(router, method, "/items")
+			assert.Equal(t, http.StatusServiceUnavailable, w.Code, method)
+			assert.NotEmpty(t, w.Header().Get("Retry-After"), method)
+		}
+	})
+
+	t.Run("Allowed Paths Stay Reachable", func(t *testing.T) {
+		router := newRouter(dto.MaintenanceStatus{Enabled: true, Mode: constants.MAINTENANCE_MODE_FULL, Message: "Down"})
+		assert.Equal(t, http.StatusOK, serve(router, http.MethodGet, "/healthz").Code)
+		assert.Equal(t, http.StatusOK, serve(router, http.MethodPost, "/admin/maintenance").Code)
+	})
+}
@@ -0,0 +1,98 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// findMetric reports whether family name has a metric whose labels include all of the given labels
+func findMetric(t *testing.T, registry *prometheus.Registry, name string, labels map[string]string) bool {
+	t.Helper()
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			matched := 0
+			for _, pair := range metric.GetLabel() {
+				if value, ok := labels[pair.GetName()]; ok && value == pair.GetValue() {
+					matched++
+				}
+			}
+			if matched == len(labels) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	registry := prometheus.NewRegistry()
+	router := gin.New()
+	router.Use(MetricsMiddleware(registry))
+	router.GET("/users/:id", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"id": c.Param("id")})
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/unknown/path"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	t.Run("UsesRouteTemplateAsPath", func(t *testing.T) {
+		assert.True(t, findMetric(t, registry, "http_requests_total", map[string]string{"method": "GET", "path": "/users/:id", "status": "200"}))
+		assert.False(t, findMetric(t, registry, "http_requests_total", map[string]string{"path": "/users/1"}))
+	})
+
+	t.Run("GroupsUnmatchedRoutes", func(t *testing.T) {
+		assert.True(t, findMetric(t, registry, "http_requests_total", map[string]string{"path": unmatchedRoute, "status": "404"}))
+		assert.False(t, findMetric(t, registry, "http_requests_total", map[string]string{"path": "/unknown/path"}))
+	})
+
+	t.Run("RecordsCountAndDuration", func(t *testing.T) {
+		families, err := registry.Gather()
+		require.NoError(t, err)
+
+		counts := map[string]float64{}
+		samples := map[string]uint64{}
+		for _, family := range families {
+			for _, metric := range family.GetMetric() {
+				if family.GetName() == "http_requests_total" {
+					counts[labelValue(metric.GetLabel(), "path")] = metric.GetCounter().GetValue()
+				}
+				if family.GetName() == "http_request_duration_seconds" {
+					samples[labelValue(metric.GetLabel(), "path")] = metric.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+		assert.Equal(t, float64(2), counts["/users/:id"])
+		assert.Equal(t, uint64(2), samples["/users/:id"])
+	})
+
+	t.Run("RegisteringTwiceOnOneRegistryPanics", func(t *testing.T) {
+		assert.Panics(t, func() { MetricsMiddleware(registry) })
+		assert.NotPanics(t, func() { MetricsMiddleware(prometheus.NewRegistry()) })
+	})
+}
+
+func labelValue(labels []*dto.LabelPair, name string) string {
+	for _, pair := range labels {
+		if pair.GetName() == name {
+			return pair.GetValue()
+		}
+	}
+	return ""
+}
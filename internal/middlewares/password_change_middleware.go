@@ -0,0 +1,41 @@
+package middlewares
+
+import (
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+// PasswordChangeMiddleware blocks users whose password was reset by an administrator until they change it
+// It must run after AuthMiddleware, which sets the authenticated user ID in context
+// Routes listed in allowedPaths (as registered, e.g. "/api/v1/change-password") stay reachable
+// If the user must change the password, it returns 403 Forbidden with ErrPasswordChangeRequired
+func PasswordChangeMiddleware(userService services.UserService, allowedPaths ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if slices.Contains(allowedPaths, ctx.FullPath()) {
+			ctx.Next()
+			return
+		}
+
+		userID, err := utils.GetUserIDFromContext(ctx)
+		if err != nil {
+			utils.RespondWithError(ctx, apperror.NewUnauthorizedError("Unauthorized"))
+			return
+		}
+
+		profile, err := userService.GetProfile(ctx.Request.Context(), userID)
+		if err != nil {
+			utils.RespondWithError(ctx, err)
+			return
+		}
+		if profile.MustChangePassword {
+			utils.RespondWithError(ctx, apperror.NewPasswordChangeRequiredError("You must change your password before continuing"))
+			return
+		}
+
+		ctx.Next()
+	}
+}
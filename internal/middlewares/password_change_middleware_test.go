@@ -0,0 +1,124 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+func newPasswordChangeRouter(userService *mocks.MockUserService, userID any, nextCalled *bool) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if userID != nil {
+			c.Set("UserID", userID)
+		}
+		c.Next()
+	})
+	router.Use(PasswordChangeMiddleware(userService, "/change-password"))
+	next := func(c *gin.Context) {
+		*nextCalled = true
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	}
+	router.GET("/profile", next)
+	router.POST("/change-password", next)
+	return router
+}
+
+func TestPasswordChangeMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name               string
+		method             string
+		path               string
+		userID             any
+		setupMock          func(*mocks.MockUserService)
+		expectedStatusCode int
+		expectedErrorCode  int
+		expectNext         bool
+	}{
+		{
+			name:               "Allowed path skips the check",
+			method:             "POST",
+			path:               "/change-password",
+			userID:             uint(1),
+			setupMock:          func(m *mocks.MockUserService) {},
+			expectedStatusCode: http.StatusOK,
+			expectNext:         true,
+		},
+		{
+			name:               "Missing user ID",
+			method:             "GET",
+			path:               "/profile",
+			userID:             nil,
+			setupMock:          func(m *mocks.MockUserService) {},
+			expectedStatusCode: http.StatusUnauthorized,
+			expectNext:         false,
+		},
+		{
+			name:   "Password change not required",
+			method: "GET",
+			path:   "/profile",
+			userID: uint(1),
+			setupMock: func(m *mocks.MockUserService) {
+				m.On("GetProfile", mock.Anything, uint(1)).Return(&dto.ProfileResponse{UserResponse: dto.UserResponse{ID: 1}}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectNext:         true,
+		},
+		{
+			name:   "Password change required",
+			method: "GET",
+			path:   "/profile",
+			userID: uint(1),
+			setupMock: func(m *mocks.MockUserService) {
+				m.On("GetProfile", mock.Anything, uint(1)).Return(&dto.ProfileResponse{UserResponse: dto.UserResponse{ID: 1, MustChangePassword: true}}, nil)
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedErrorCode:  apperror.ErrPasswordChangeRequired,
+			expectNext:         false,
+		},
+		{
+			name:   "Profile lookup fails",
+			method: "GET",
+			path:   "/profile",
+			userID: uint(1),
+			setupMock: func(m *mocks.MockUserService) {
+				m.On("GetProfile", mock.Anything, uint(1)).Return((*dto.ProfileResponse)(nil), apperror.NewNotFoundError("User not found"))
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectNext:         false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService := new(mocks.MockUserService)
+			tt.setupMock(userService)
+
+			nextCalled := false
+			router := newPasswordChangeRouter(userService, tt.userID, &nextCalled)
+
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+			assert.Equal(t, tt.expectNext, nextCalled)
+			if tt.expectedErrorCode != 0 {
+				var body map[string]any
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, float64(tt.expectedErrorCode), body["code"])
+			}
+			userService.AssertExpectations(t)
+		})
+	}
+}
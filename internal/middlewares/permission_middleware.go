@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+// PermissionMiddleware creates a Gin middleware that only lets through users whose roles grant the permission
+// It must run after AuthMiddleware, which sets the authenticated user ID in context
+// If no user ID is present, it returns 401 Unauthorized
+// If none of the user's roles grants the permission, it returns 403 Forbidden
+func PermissionMiddleware(roleService services.RoleService, permission string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, err := utils.GetUserIDFromContext(ctx)
+		if err != nil {
+			utils.RespondWithError(ctx, apperror.NewUnauthorizedError("Unauthorized"))
+			return
+		}
+
+		permissions, err := roleService.GetPermissionsForUser(ctx.Request.Context(), userID)
+		if err != nil {
+			utils.RespondWithError(ctx, err)
+			return
+		}
+		if !slices.Contains(permissions, permission) {
+			logger.WithContext(ctx.Request.Context()).Warnf("User ID %d denied permission %s", userID, permission)
+			utils.RespondWithError(ctx, apperror.NewForbiddenError("You do not have permission to perform this action"))
+			return
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,116 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+func newPermissionRouter(roleService services.RoleService, userID any, nextCalled *bool) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if userID != nil {
+			c.Set("UserID", userID)
+		}
+		c.Next()
+	})
+	router.GET("/test", PermissionMiddleware(roleService, constants.PERMISSION_USERS_CREATE), func(c *gin.Context) {
+		*nextCalled = true
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+	return router
+}
+
+func TestPermissionMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name               string
+		userID             any
+		setupMock          func(*mocks.MockRoleService)
+		expectedStatusCode int
+		expectNext         bool
+	}{
+		{
+			name:               "Missing user ID",
+			userID:             nil,
+			setupMock:          func(m *mocks.MockRoleService) {},
+			expectedStatusCode: http.StatusUnauthorized,
+			expectNext:         false,
+		},
+		{
+			name:   "Permission granted",
+			userID: uint(1),
+			setupMock: func(m *mocks.MockRoleService) {
+				m.On("GetPermissionsForUser", mock.Anything, uint(1)).Return([]string{constants.PERMISSION_USERS_READ, constants.PERMISSION_USERS_CREATE}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectNext:         true,
+		},
+		{
+			name:   "Permission denied",
+			userID: uint(1),
+			setupMock: func(m *mocks.MockRoleService) {
+				m.On("GetPermissionsForUser", mock.Anything, uint(1)).Return([]string{constants.PERMISSION_USERS_READ}, nil)
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectNext:         false,
+		},
+		{
+			name:   "Permission lookup fails",
+			userID: uint(1),
+			setupMock: func(m *mocks.MockRoleService) {
+				m.On("GetPermissionsForUser", mock.Anything, uint(1)).Return(nil, apperror.NewDBQueryError("Failed to load user roles"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			expectNext:         false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roleService := new(mocks.MockRoleService)
+			tt.setupMock(roleService)
+
+			nextCalled := false
+			router := newPermissionRouter(roleService, tt.userID, &nextCalled)
+
+			req, _ := http.NewRequest("GET", "/test", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatusCode, w.Code)
+			assert.Equal(t, tt.expectNext, nextCalled)
+			roleService.AssertExpectations(t)
+		})
+	}
+
+	t.Run("Cache hit skips the database", func(t *testing.T) {
+		roleRepo := new(mocks.MockRoleRepository)
+		roleRepo.On("GetByUserID", mock.Anything, uint(1)).Return([]models.Role{{Name: constants.ROLE_ADMIN}}, nil).Once()
+		roleService := services.NewRoleService(roleRepo, services.NewMemoryRedisService(0))
+
+		nextCalled := false
+		router := newPermissionRouter(roleService, uint(1), &nextCalled)
+
+		for range 2 {
+			nextCalled = false
+			req, _ := http.NewRequest("GET", "/test", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.True(t, nextCalled)
+		}
+		roleRepo.AssertNumberOfCalls(t, "GetByUserID", 1)
+	})
+}
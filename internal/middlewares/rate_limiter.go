@@ -1,65 +1,60 @@
 package middlewares
 
 import (
-	"net/http"
-	"sync"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
 	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
 )
 
-type rateLimiter struct {
-	requests map[string][]time.Time
-	mu       sync.Mutex
-	limit    int
-	window   time.Duration
-}
-
-func newRateLimiter(limit int, window time.Duration) *rateLimiter {
-	return &rateLimiter{
-		requests: make(map[string][]time.Time),
-		limit:    limit,
-		window:   window,
+// RateLimit allows at most limit requests per window from each client IP
+// Counters live in the cache, so the limit holds across all instances of the API
+// The scope separates the counters of limiters applied to different routes
+// It uses a sliding window estimated from the counts of the current and previous fixed windows
+// When the limit is exceeded, it returns 429 Too Many Requests with a Retry-After header
+// If the cache is unavailable, requests are let through rather than locking everyone out
+// It panics if window is not positive, as the window index could not be computed
+func RateLimit(redisService services.RedisService, scope string, limit int, window time.Duration) gin.HandlerFunc {
+	if window <= 0 {
+		panic(fmt.Sprintf("rate limit window of %s must be positive, got %s", scope, window))
 	}
-}
-
-func (rl *rateLimiter) isAllowed(key string) bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	now := time.Now()
-	windowStart := now.Add(-rl.window)
-
-	var validRequests []time.Time
-	for _, t := range rl.requests[key] {
-		if t.After(windowStart) {
-			validRequests = append(validRequests, t)
+	return func(ctx *gin.Context) {
+		now := time.Now()
+		windowIndex := now.UnixNano() / int64(window)
+		keyPrefix := constants.RATE_LIMIT + scope + ":" + ctx.ClientIP() + ":"
+
+		// The current counter must outlive its own window, as it is read as the previous one next
+		current, err := redisService.Incr(ctx.Request.Context(), keyPrefix+strconv.FormatInt(windowIndex, 10), 2*window)
+		if err != nil {
+			logger.WithContext(ctx.Request.Context()).Warnf("Rate limit check for %s failed: %v", scope, err)
+			ctx.Next()
+			return
 		}
-	}
-
-	if len(validRequests) >= rl.limit {
-		rl.requests[key] = validRequests
-		return false
-	}
 
-	rl.requests[key] = append(validRequests, now)
-	return true
-}
-
-func RateLimiter(limit int, window time.Duration) gin.HandlerFunc {
-	limiter := newRateLimiter(limit, window)
-	return func(ctx *gin.Context) {
-		key := ctx.ClientIP()
+		var previous int64
+		value, err := redisService.Get(ctx.Request.Context(), keyPrefix+strconv.FormatInt(windowIndex-1, 10))
+		switch {
+		case err == nil:
+			previous, _ = strconv.ParseInt(value, 10, 64)
+		case !errors.Is(err, services.ErrCacheMiss):
+			logger.WithContext(ctx.Request.Context()).Warnf("Rate limit check for %s failed: %v", scope, err)
+		}
 
-		if !limiter.isAllowed(key) {
-			utils.RespondWithError(ctx, apperror.New(
-				http.StatusTooManyRequests,
-				429,
-				"Too many requests. Please try again later.",
-			))
-			ctx.Abort()
+		// The previous window counts in proportion to how much of it still overlaps the sliding window
+		elapsed := time.Duration(now.UnixNano() % int64(window))
+		estimated := float64(previous)*(1-float64(elapsed)/float64(window)) + float64(current)
+		if estimated > float64(limit) {
+			retryAfter := int(math.Ceil((window - elapsed).Seconds()))
+			ctx.Header("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+			utils.RespondWithError(ctx, apperror.NewTooManyRequestsError("Too many requests. Please try again later."))
 			return
 		}
 
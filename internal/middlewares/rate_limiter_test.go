@@ -8,7 +8,11 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/vfa-khuongdv/golang-cms/internal/middlewares"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
 )
 
 func TestRateLimiter(t *testing.T) {
@@ -16,7 +20,7 @@ func TestRateLimiter(t *testing.T) {
 
 	t.Run("Allows requests within limit", func(t *testing.T) {
 		router := gin.New()
-		router.Use(middlewares.RateLimiter(5, time.Second))
+		router.Use(middlewares.RateLimit(services.NewMemoryRedisService(0), "test", 5, time.Second))
 		router.GET("/test", func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{"message": "ok"})
 		})
@@ -29,9 +33,17 @@ func TestRateLimiter(t *testing.T) {
 		}
 	})
 
+	t.Run("Rejects a window that is not positive", func(t *testing.T) {
+		for _, window := range []time.Duration{0, -time.Second} {
+			assert.Panics(t, func() {
+				middlewares.RateLimit(services.NewMemoryRedisService(0), "test", 5, window)
+			})
+		}
+	})
+
 	t.Run("Blocks requests over limit", func(t *testing.T) {
 		router := gin.New()
-		router.Use(middlewares.RateLimiter(2, time.Second))
+		router.Use(middlewares.RateLimit(services.NewMemoryRedisService(0), "test", 2, time.Second))
 		router.GET("/test", func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{"message": "ok"})
 		})
@@ -50,11 +62,13 @@ func TestRateLimiter(t *testing.T) {
 		req3, _ := http.NewRequest("GET", "/test", nil)
 		router.ServeHTTP(w3, req3)
 		assert.Equal(t, http.StatusTooManyRequests, w3.Code)
+		assert.NotEmpty(t, w3.Header().Get("Retry-After"))
+		assert.Contains(t, w3.Body.String(), `"code":4008`)
 	})
 
 	t.Run("Different IPs have separate limits", func(t *testing.T) {
 		router := gin.New()
-		limiter := middlewares.RateLimiter(1, time.Second)
+		limiter := middlewares.RateLimit(services.NewMemoryRedisService(0), "test", 1, time.Second)
 		router.Use(limiter)
 		router.GET("/test", func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{"message": "ok"})
@@ -75,7 +89,7 @@ func TestRateLimiter(t *testing.T) {
 
 	t.Run("Resets after window expires", func(t *testing.T) {
 		router := gin.New()
-		router.Use(middlewares.RateLimiter(1, 500*time.Millisecond))
+		router.Use(middlewares.RateLimit(services.NewMemoryRedisService(0), "test", 1, 200*time.Millisecond))
 		router.GET("/test", func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{"message": "ok"})
 		})
@@ -90,11 +104,46 @@ func TestRateLimiter(t *testing.T) {
 		router.ServeHTTP(w2, req2)
 		assert.Equal(t, http.StatusTooManyRequests, w2.Code)
 
-		time.Sleep(600 * time.Millisecond)
+		// Two full windows later the previous window no longer counts
+		time.Sleep(450 * time.Millisecond)
 
 		w3 := httptest.NewRecorder()
 		req3, _ := http.NewRequest("GET", "/test", nil)
 		router.ServeHTTP(w3, req3)
 		assert.Equal(t, http.StatusOK, w3.Code)
 	})
+	t.Run("Scopes have separate limits", func(t *testing.T) {
+		redisService := services.NewMemoryRedisService(0)
+		router := gin.New()
+		router.GET("/login", middlewares.RateLimit(redisService, "login", 1, time.Minute), func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "ok"})
+		})
+		router.GET("/forgot", middlewares.RateLimit(redisService, "forgot", 1, time.Minute), func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "ok"})
+		})
+
+		for _, path := range []string{"/login", "/forgot"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", path, nil)
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code)
+		}
+	})
+
+	t.Run("Cache failure lets requests through", func(t *testing.T) {
+		redisService := new(mocks.MockRedisService)
+		redisService.On("Incr", mock.Anything, mock.Anything, 2*time.Minute).Return(int64(0), apperror.NewCacheSetError("connection refused"))
+
+		router := gin.New()
+		router.Use(middlewares.RateLimit(redisService, "test", 1, time.Minute))
+		router.GET("/test", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "ok"})
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		redisService.AssertExpectations(t)
+	})
 }
@@ -0,0 +1,25 @@
+package middlewares
+
+import (
+	"io"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+// RecoveryMiddleware turns a panic in a later handler into a 500 Internal Server Error
+// The panic and its stack trace are logged with the request ID, and the error body carries
+// the same request ID so a report from the client can be matched with the log line
+// It must run after RequestIDMiddleware
+func RecoveryMiddleware() gin.HandlerFunc {
+	// gin still detects broken connections itself and skips the handler for them
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		logger.WithContext(c.Request.Context()).
+			WithField("stack", string(debug.Stack())).
+			Errorf("Panic recovered on %s %s: %v", c.Request.Method, c.Request.URL.Path, recovered)
+		utils.RespondWithError(c, apperror.NewInternalServerError("Internal server error"))
+	})
+}
@@ -0,0 +1,63 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+func TestRecoveryMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func() *gin.Engine {
+		router := gin.New()
+		router.Use(RequestIDMiddleware(), RecoveryMiddleware())
+		router.GET("/panic", func(c *gin.Context) {
+			panic("something went wrong")
+		})
+		router.GET("/ok", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		return router
+	}
+
+	t.Run("Panic returns 500 with the request ID", func(t *testing.T) {
+		var logs bytes.Buffer
+		logrus.SetOutput(&logs)
+		defer logrus.SetOutput(os.Stderr)
+
+		req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+		req.Header.Set(RequestIDHeader, "req-panic-1")
+		resp := httptest.NewRecorder()
+
+		newRouter().ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		assert.Equal(t, float64(apperror.ErrInternalServer), body["code"])
+		assert.Equal(t, "req-panic-1", body["request_id"])
+
+		assert.Contains(t, logs.String(), "something went wrong")
+		assert.Contains(t, logs.String(), "req-panic-1")
+	})
+
+	t.Run("Requests without panic are untouched", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/ok", nil)
+		resp := httptest.NewRecorder()
+
+		newRouter().ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, resp.Body.String())
+	})
+}
@@ -11,10 +11,12 @@ const (
 	RequestIDHeader = "X-Request-ID"
 	// RequestIDKey is the context key for storing the request ID
 	RequestIDKey = "RequestID"
+	// MAX_REQUEST_ID_LENGTH is the longest client supplied request ID that is accepted
+	MAX_REQUEST_ID_LENGTH = 128
 )
 
 // RequestIDMiddleware adds a unique request ID to each request
-// If the client provides an X-Request-ID header, it will be used
+// If the client provides a well-formed X-Request-ID header, it will be used
 // Otherwise, a new UUID will be generated
 // The request ID is:
 // - Stored in the Gin context for use by handlers
@@ -24,7 +26,7 @@ func RequestIDMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestID := c.GetHeader(RequestIDHeader)
 
-		if requestID == "" {
+		if !isValidRequestID(requestID) {
 			requestID = uuid.New().String()
 		}
 
@@ -38,6 +40,21 @@ func RequestIDMiddleware() gin.HandlerFunc {
 	}
 }
 
+// isValidRequestID reports whether a client supplied request ID is safe to log and echo back:
+// at most MAX_REQUEST_ID_LENGTH letters, digits, '-', '_', '.' or ':'
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > MAX_REQUEST_ID_LENGTH {
+		return false
+	}
+	for _, r := range requestID {
+		isAlphaNum := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+		if !isAlphaNum && r != '-' && r != '_' && r != '.' && r != ':' {
+			return false
+		}
+	}
+	return true
+}
+
 // GetRequestID retrieves the request ID from the Gin context
 // Returns empty string if request ID is not found
 func GetRequestID(c *gin.Context) string {
@@ -3,6 +3,7 @@ package middlewares
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -69,6 +70,34 @@ func TestRequestIDMiddleware(t *testing.T) {
 		assert.Equal(t, clientRequestID, resp.Header().Get(RequestIDHeader))
 	})
 
+	t.Run("Replaces malformed client request ID", func(t *testing.T) {
+		invalidIDs := map[string]string{
+			"log injection": "abc\nlevel=error msg=forged",
+			"spaces":        "has spaces",
+			"too long":      strings.Repeat("a", MAX_REQUEST_ID_LENGTH+1),
+		}
+
+		for name, clientRequestID := range invalidIDs {
+			t.Run(name, func(t *testing.T) {
+				router := gin.New()
+				router.Use(RequestIDMiddleware())
+				router.GET("/test", func(c *gin.Context) {
+					c.Status(http.StatusOK)
+				})
+
+				req := httptest.NewRequest(http.MethodGet, "/test", nil)
+				req.Header.Set(RequestIDHeader, clientRequestID)
+				resp := httptest.NewRecorder()
+				router.ServeHTTP(resp, req)
+
+				requestID := resp.Header().Get(RequestIDHeader)
+				assert.NotEqual(t, clientRequestID, requestID)
+				_, err := uuid.Parse(requestID)
+				assert.NoError(t, err, "A new UUID should be generated")
+			})
+		}
+	})
+
 	t.Run("Request ID accessible in context", func(t *testing.T) {
 		// Arrange
 		router := gin.New()
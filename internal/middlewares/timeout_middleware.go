@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+// TimeoutMiddleware gives the context of each request a deadline of timeout, so database queries and cache calls
+// made with it are cancelled once the request has taken too long. Errors responded after the deadline become
+// 504 Gateway Timeout, and a handler that gave up without responding gets one too.
+// Routes listed in exemptPaths (as registered, e.g. "/api/v1/users/export") and a timeout of zero are not limited
+func TimeoutMiddleware(timeout time.Duration, exemptPaths ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if timeout <= 0 || slices.Contains(exemptPaths, ctx.FullPath()) {
+			ctx.Next()
+			return
+		}
+
+		requestCtx, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
+		defer cancel()
+		ctx.Request = ctx.Request.WithContext(requestCtx)
+
+		ctx.Next()
+
+		if errors.Is(requestCtx.Err(), context.DeadlineExceeded) {
+			logger.WithContext(requestCtx).Warnf("Request %s %s exceeded its timeout of %s", ctx.Request.Method, ctx.Request.URL.Path, timeout)
+			if !ctx.Writer.Written() {
+				utils.RespondWithError(ctx, apperror.NewRequestTimeoutError("Request timed out"))
+			}
+		}
+	}
+}
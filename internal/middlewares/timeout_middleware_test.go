@@ -0,0 +1,101 @@
+package middlewares_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/middlewares"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// slowRepository runs a query that never finishes on its own, like a repository stuck on a slow table
+type slowRepository struct {
+	db *gorm.DB
+}
+
+func (repo *slowRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := repo.db.WithContext(ctx).Raw("WITH RECURSIVE n(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM n) SELECT count(*) FROM n").Scan(&count).Error
+	return count, err
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	repo := &slowRepository{db: db}
+
+	newRouter := func(timeout time.Duration, queryErr chan<- error) *gin.Engine {
+		router := gin.New()
+		router.Use(middlewares.TimeoutMiddleware(timeout, "/exempt"))
+		slow := func(c *gin.Context) {
+			_, err := repo.Count(c.Request.Context())
+			queryErr <- err
+			utils.RespondWithError(c, apperror.NewDBQueryError("Failed to count"))
+		}
+		router.GET("/slow", slow)
+		deadline := func(c *gin.Context) {
+			_, hasDeadline := c.Request.Context().Deadline()
+			c.JSON(http.StatusOK, gin.H{"deadline": hasDeadline})
+		}
+		router.GET("/deadline", deadline)
+		router.GET("/exempt", deadline)
+		router.GET("/silent", func(c *gin.Context) {
+			<-c.Request.Context().Done()
+		})
+		router.GET("/fast", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "OK"})
+		})
+		return router
+	}
+	serve := func(router *gin.Engine, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	t.Run("Query Past The Deadline Is Cancelled", func(t *testing.T) {
+		queryErr := make(chan error, 1)
+		router := newRouter(50*time.Millisecond, queryErr)
+
+		start := time.Now()
+		w := serve(router, "/slow")
+
+		assert.Less(t, time.Since(start), 5*time.Second)
+		assert.Error(t, <-queryErr)
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, float64(apperror.ErrRequestTimeout), body["code"])
+		assert.Equal(t, "ERR_REQUEST_TIMEOUT", body["error"])
+	})
+
+	t.Run("Handler That Does Not Respond Gets 504", func(t *testing.T) {
+		router := newRouter(20*time.Millisecond, nil)
+
+		assert.Equal(t, http.StatusGatewayTimeout, serve(router, "/silent").Code)
+	})
+
+	t.Run("Fast Request Is Unaffected", func(t *testing.T) {
+		router := newRouter(time.Second, nil)
+
+		assert.Equal(t, http.StatusOK, serve(router, "/fast").Code)
+	})
+
+	t.Run("Exempt Path And Zero Timeout Have No Deadline", func(t *testing.T) {
+		assert.JSONEq(t, `{"deadline": true}`, serve(newRouter(time.Second, nil), "/deadline").Body.String())
+		assert.JSONEq(t, `{"deadline": false}`, serve(newRouter(time.Second, nil), "/exempt").Body.String())
+		assert.JSONEq(t, `{"deadline": false}`, serve(newRouter(0, nil), "/deadline").Body.String())
+	})
+}
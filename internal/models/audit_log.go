@@ -0,0 +1,25 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditLog is a persisted record of a security-sensitive action.
+// Entries are never updated or deleted, and have no foreign keys so they outlive the users they mention
+type AuditLog struct {
+	ID          uint            `gorm:"column:id;primaryKey" json:"id"`
+	ActorUserID *uint           `gorm:"column:actor_user_id;index" json:"actor_user_id"`
+	Action      string          `gorm:"column:action;type:varchar(64);not null;index" json:"action"`
+	TargetType  string          `gorm:"column:target_type;type:varchar(32)" json:"target_type,omitempty"`
+	TargetID    *uint           `gorm:"column:target_id" json:"target_id,omitempty"`
+	IP          string          `gorm:"column:ip;type:varchar(45);not null" json:"ip"`
+	UserAgent   string          `gorm:"column:user_agent;type:varchar(255)" json:"user_agent,omitempty"`
+	Metadata    json.RawMessage `gorm:"column:metadata;type:json" json:"metadata,omitempty"`
+	CreatedAt   time.Time       `gorm:"column:created_at;index" json:"created_at"`
+}
+
+// TableName specifies the table name for AuditLog model
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
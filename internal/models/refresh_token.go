@@ -10,7 +10,10 @@ type RefreshToken struct {
 	ID           uint           `gorm:"column:id;primaryKey" json:"id"`
 	RefreshToken string         `gorm:"column:refresh_token;type:varchar(60);not null;unique" json:"refresh_token"`
 	IpAddress    string         `gorm:"column:ip_address;type:varchar(45);not null" json:"ip_address"`
+	UserAgent    string         `gorm:"column:user_agent;type:varchar(255);not null;default:''" json:"user_agent"`
+	Fingerprint  string         `gorm:"column:fingerprint;type:char(64);not null;default:''" json:"-"` // SHA-256 of the device fingerprint, empty if not bound
 	UsedCount    int64          `gorm:"column:used_count;default:0" json:"used_count"`
+	LastUsedAt   *time.Time     `gorm:"column:last_used_at" json:"last_used_at"`
 	ExpiredAt    int64          `gorm:"column:expired_at;not null" json:"expired_at"`
 	UserID       uint           `gorm:"column:user_id;not null" json:"user_id"`
 	CreatedAt    time.Time      `gorm:"column:created_at" json:"created_at"`
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+type Role struct {
+	ID        uint      `gorm:"column:id;primaryKey" json:"id"`
+	Name      string    `gorm:"column:name;type:varchar(45);unique;not null" json:"name"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// TableName specifies the table name for Role model
+func (Role) TableName() string {
+	return "roles"
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Setting is a runtime setting or feature flag, editable by administrators without a redeploy.
+// Value is stored as text and parsed according to Type, one of the constants.SETTING_TYPE_* values
+type Setting struct {
+	Key       string    `gorm:"column:key;primaryKey;type:varchar(64)" json:"key"`
+	Value     string    `gorm:"column:value;type:text;not null" json:"value"`
+	Type      string    `gorm:"column:type;type:varchar(16);not null" json:"type"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// TableName specifies the table name for Setting model
+func (Setting) TableName() string {
+	return "settings"
+}
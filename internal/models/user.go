@@ -7,18 +7,34 @@ import (
 )
 
 type User struct {
-	ID        uint           `gorm:"column:id;primaryKey" json:"id"`
-	Email     string         `gorm:"column:email;type:varchar(45);unique;not null" json:"email"`
-	Password  string         `gorm:"column:password;type:varchar(255);not null" json:"-"`
-	Name      string         `gorm:"column:name;type:varchar(45);not null" json:"name"`
-	Birthday  *time.Time     `gorm:"column:birthday;type:date;default:null" json:"birthday,omitempty"`
-	Address   *string        `gorm:"column:address;type:varchar(255);default:null" json:"address,omitempty"`
-	Gender    int16          `gorm:"column:gender;type:smallint;not null" json:"gender"` // 1. Male, 2. Felmale, 3. Other
-	Token     *string        `gorm:"column:token;type:varchar(100);default:null;unique" json:"-"`
-	ExpiredAt *int64         `gorm:"column:expired_at;type:bigint;default:null" json:"expired_at,omitempty"`
-	CreatedAt time.Time      `gorm:"column:created_at" json:"created_at"`
-	UpdatedAt time.Time      `gorm:"column:updated_at" json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at;index" json:"deleted_at,omitempty"`
+	ID                   uint           `gorm:"column:id;primaryKey" json:"id"`
+	Email                string         `gorm:"column:email;type:varchar(45);unique;not null" json:"email"`
+	PendingEmail         *string        `gorm:"column:pending_email;type:varchar(45);default:null" json:"pending_email,omitempty"` // Email waiting for its owner to confirm the change
+	Password             string         `gorm:"column:password;type:varchar(255);not null" json:"-"`
+	Name                 string         `gorm:"column:name;type:varchar(45);not null" json:"name"`
+	Birthday             *time.Time     `gorm:"column:birthday;type:date;default:null" json:"birthday,omitempty"`
+	Address              *string        `gorm:"column:address;type:varchar(255);default:null" json:"address,omitempty"`
+	Gender               int16          `gorm:"column:gender;type:smallint;not null" json:"gender"` // 1. Male, 2. Felmale, 3. Other
+	Token                *string        `gorm:"column:token;type:varchar(100);default:null;unique" json:"-"`
+	ExpiredAt            *int64         `gorm:"column:expired_at;type:bigint;default:null" json:"expired_at,omitempty"`
+	TokenPurpose         *string        `gorm:"column:token_purpose;type:varchar(20);default:null" json:"-"`              // What Token may be used for, one of the constants.TOKEN_PURPOSE_* values
+	EmailChangeToken     *string        `gorm:"column:email_change_token;type:varchar(100);default:null;unique" json:"-"` // Hash of the token confirming PendingEmail
+	EmailChangeExpiredAt *int64         `gorm:"column:email_change_expired_at;type:bigint;default:null" json:"-"`
+	VerifiedAt           *time.Time     `gorm:"column:verified_at;default:null" json:"verified_at,omitempty"`
+	MustChangePassword   bool           `gorm:"column:must_change_password;not null;default:false" json:"must_change_password,omitempty"`
+	Avatar               *string        `gorm:"column:avatar;type:varchar(512);default:null" json:"avatar,omitempty"` // Public URL of the avatar
+	AvatarKey            *string        `gorm:"column:avatar_key;type:varchar(255);default:null" json:"-"`            // Storage key of the avatar
+	LastLoginAt          *time.Time     `gorm:"column:last_login_at;default:null" json:"-"`                           // Only shown in the user activity
+	LastLoginIP          *string        `gorm:"column:last_login_ip;type:varchar(45);default:null" json:"-"`          // Only shown in the user activity
+	Status               string         `gorm:"column:status;type:varchar(20);not null;default:active" json:"-"`
+	DeletionRequestedAt  *time.Time     `gorm:"column:deletion_requested_at;default:null" json:"-"` // When the user asked to delete their account
+	CreatedAt            time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt            time.Time      `gorm:"column:updated_at" json:"updated_at"`
+	DeletedAt            gorm.DeletedAt `gorm:"column:deleted_at;index" json:"deleted_at,omitempty"`
+
+	// Relations
+	Roles       []Role          `gorm:"many2many:user_roles;constraint:OnDelete:CASCADE" json:"roles,omitempty"`
+	Preferences *UserPreference `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"preferences,omitempty"` // Only loaded with profiles
 }
 
 // TableName specifies the table name for User model
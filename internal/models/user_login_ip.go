@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// UserLoginIP is an IP address a user has logged in from. A login from an address not listed for the user is a login from a new device
+type UserLoginIP struct {
+	UserID      uint      `gorm:"column:user_id;primaryKey;autoIncrement:false" json:"user_id"`
+	IpAddress   string    `gorm:"column:ip_address;primaryKey;type:varchar(45)" json:"ip_address"`
+	FirstSeenAt time.Time `gorm:"column:first_seen_at;not null" json:"first_seen_at"`
+	LastSeenAt  time.Time `gorm:"column:last_seen_at;not null" json:"last_seen_at"`
+}
+
+// TableName specifies the table name for UserLoginIP model
+func (UserLoginIP) TableName() string {
+	return "user_login_ips"
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// UserPreference holds the settings a user picks for themselves. A user has no row until they first
+// read or change their preferences, and is served the defaults until then
+type UserPreference struct {
+	UserID             uint      `gorm:"column:user_id;primaryKey;autoIncrement:false" json:"-"`
+	Timezone           string    `gorm:"column:timezone;type:varchar(64);not null" json:"timezone"` // IANA name, e.g. "Asia/Ho_Chi_Minh"
+	Locale             string    `gorm:"column:locale;type:varchar(16);not null" json:"locale"`     // One of constants.LOCALES
+	EmailNotifications bool      `gorm:"column:email_notifications;not null" json:"email_notifications"`
+	Theme              string    `gorm:"column:theme;type:varchar(16);not null" json:"theme"` // One of the constants.THEME_* values
+	UpdatedAt          time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// TableName specifies the table name for UserPreference model
+func (UserPreference) TableName() string {
+	return "user_preferences"
+}
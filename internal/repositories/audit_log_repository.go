@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+	"slices"
+
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// AuditLogSortFields lists the columns audit logs can be sorted by
+var AuditLogSortFields = []string{"id", "created_at"}
+
+type AuditLogRepository interface {
+	CreateBatch(ctx context.Context, logs []*models.AuditLog) error
+	GetAuditLogs(ctx context.Context, opts dto.ListOptions, filter dto.AuditLogFilterInput) (*dto.Pagination[*models.AuditLog], error)
+}
+
+type auditLogRepositoryImpl struct {
+	BaseRepository[models.AuditLog]
+}
+
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepositoryImpl{BaseRepository: NewBaseRepository[models.AuditLog](db, "audit log")}
+}
+
+// CreateBatch inserts the entries in a single statement
+func (repo *auditLogRepositoryImpl) CreateBatch(ctx context.Context, logs []*models.AuditLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	if err := repo.db.WithContext(ctx).Create(&logs).Error; err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to insert %d audit logs: %v", len(logs), err)
+		return apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to insert audit logs", err)
+	}
+	return nil
+}
+
+// GetAuditLogs returns a page of audit logs matching filter, sorted by opts.SortBy.
+// The date range is inclusive on both ends; an unknown or empty sort field falls back to id.
+// The page is read by offset or after opts.Cursor, see paginate.
+func (repo *auditLogRepositoryImpl) GetAuditLogs(ctx context.Context, opts dto.ListOptions, filter dto.AuditLogFilterInput) (*dto.Pagination[*models.AuditLog], error) {
+	query := repo.db.WithContext(ctx).Model(&models.AuditLog{})
+	if filter.ActorUserID != nil {
+		query = query.Where("actor_user_id = ?", *filter.ActorUserID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", *filter.To)
+	}
+
+	sortBy := opts.SortBy
+	if !slices.Contains(AuditLogSortFields, sortBy) {
+		sortBy = "id"
+	}
+	return repo.Paginate(ctx, query, opts, sortBy)
+}
@@ -0,0 +1,150 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupAuditLogTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.AuditLog{}))
+	return db
+}
+
+func TestAuditLogRepository(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	actor := func(id uint) *uint { return &id }
+	seed := func(t *testing.T, repo repositories.AuditLogRepository) {
+		require.NoError(t, repo.CreateBatch(context.Background(), []*models.AuditLog{
+			{ActorUserID: actor(1), Action: "auth.login", IP: "127.0.0.1", CreatedAt: base},
+			{ActorUserID: nil, Action: "auth.login_failed", IP: "127.0.0.1", Metadata: []byte(`{"email":"x@example.com"}`), CreatedAt: base.Add(time.Hour)},
+			{ActorUserID: actor(2), Action: "auth.login", IP: "127.0.0.1", CreatedAt: base.Add(2 * time.Hour)},
+			{ActorUserID: actor(1), Action: "user.password_changed", TargetType: "user", TargetID: actor(1), IP: "127.0.0.1", CreatedAt: base.Add(3 * time.Hour)},
+		}))
+	}
+	defaultOpts := dto.ListOptions{Page: 1, Limit: 10, SortBy: "created_at", SortDir: "desc"}
+
+	t.Run("CreateBatch - Persists Entries", func(t *testing.T) {
+		db := setupAuditLogTestDB(t)
+		repo := repositories.NewAuditLogRepository(db)
+
+		seed(t, repo)
+
+		var logs []models.AuditLog
+		require.NoError(t, db.Order("id").Find(&logs).Error)
+		require.Len(t, logs, 4)
+		assert.Nil(t, logs[1].ActorUserID)
+		assert.JSONEq(t, `{"email":"x@example.com"}`, string(logs[1].Metadata))
+		require.NotNil(t, logs[3].TargetID)
+		assert.Equal(t, uint(1), *logs[3].TargetID)
+	})
+
+	t.Run("CreateBatch - Empty Batch", func(t *testing.T) {
+		repo := repositories.NewAuditLogRepository(setupAuditLogTestDB(t))
+		assert.NoError(t, repo.CreateBatch(context.Background(), nil))
+	})
+
+	t.Run("CreateBatch - Database Error", func(t *testing.T) {
+		db := setupAuditLogTestDB(t)
+		repo := repositories.NewAuditLogRepository(db)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		err = repo.CreateBatch(context.Background(), []*models.AuditLog{{Action: "auth.login", IP: "127.0.0.1"}})
+
+		assert.Error(t, err)
+	})
+
+	tests := []struct {
+		name    string
+		opts    dto.ListOptions
+		filter  dto.AuditLogFilterInput
+		actions []string
+		total   int
+	}{
+		{
+			name:    "GetAuditLogs - Newest First",
+			opts:    defaultOpts,
+			actions: []string{"user.password_changed", "auth.login", "auth.login_failed", "auth.login"},
+			total:   4,
+		},
+		{
+			name:    "GetAuditLogs - Filter By Actor",
+			opts:    defaultOpts,
+			filter:  dto.AuditLogFilterInput{ActorUserID: actor(1)},
+			actions: []string{"user.password_changed", "auth.login"},
+			total:   2,
+		},
+		{
+			name:    "GetAuditLogs - Filter By Action",
+			opts:    defaultOpts,
+			filter:  dto.AuditLogFilterInput{Action: "auth.login"},
+			actions: []string{"auth.login", "auth.login"},
+			total:   2,
+		},
+		{
+			name: "GetAuditLogs - Filter By Inclusive Date Range",
+			opts: defaultOpts,
+			filter: func() dto.AuditLogFilterInput {
+				from, to := base.Add(time.Hour), base.Add(2*time.Hour)
+				return dto.AuditLogFilterInput{From: &from, To: &to}
+			}(),
+			actions: []string{"auth.login", "auth.login_failed"},
+			total:   2,
+		},
+		{
+			name:    "GetAuditLogs - Paging And Ascending Sort",
+			opts:    dto.ListOptions{Page: 2, Limit: 3, SortBy: "id", SortDir: "asc"},
+			actions: []string{"user.password_changed"},
+			total:   4,
+		},
+		{
+			name:    "GetAuditLogs - Unknown Sort Field Falls Back To ID",
+			opts:    dto.ListOptions{Page: 1, Limit: 1, SortBy: "action; DROP TABLE audit_logs", SortDir: "desc"},
+			actions: []string{"user.password_changed"},
+			total:   4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repositories.NewAuditLogRepository(setupAuditLogTestDB(t))
+			seed(t, repo)
+
+			result, err := repo.GetAuditLogs(context.Background(), tt.opts, tt.filter)
+
+			require.NoError(t, err)
+			actions := make([]string, 0, len(result.Data))
+			for _, log := range result.Data {
+				actions = append(actions, log.Action)
+			}
+			assert.Equal(t, tt.actions, actions)
+			assert.Equal(t, tt.total, result.TotalItems)
+			assert.Equal(t, tt.opts.Page, result.Page)
+		})
+	}
+
+	t.Run("GetAuditLogs - Database Error", func(t *testing.T) {
+		db := setupAuditLogTestDB(t)
+		repo := repositories.NewAuditLogRepository(db)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		result, err := repo.GetAuditLogs(context.Background(), defaultOpts, dto.AuditLogFilterInput{})
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+	})
+}
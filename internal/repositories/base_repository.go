@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// BaseRepository implements the CRUD methods repositories share for the model T, keyed by a uint ID.
+// Repositories embed it and add their specialised methods.
+// A missing row is returned as an ErrNotFound error "<Name> not found", and a duplicate value of a unique field as a 409
+// naming the field, see duplicateKeyError; other failures are logged and wrapped as ErrInternalServer
+type BaseRepository[T any] struct {
+	db         *gorm.DB
+	name       string   // Singular name of the model in messages, e.g. "refresh token"
+	findFields []string // Columns FindByField may filter on
+	// readPrimary pins GetByID and FindByField to the primary database, see ReadFromPrimary
+	readPrimary bool
+}
+
+// NewBaseRepository returns a BaseRepository for T. FindByField only accepts the columns in findFields
+func NewBaseRepository[T any](db *gorm.DB, name string, findFields ...string) BaseRepository[T] {
+	return BaseRepository[T]{db: db, name: name, findFields: findFields}
+}
+
+// ReadFromPrimary returns a copy of the repository whose GetByID and FindByField read from the primary database
+// even when read replicas are registered. Use it for models whose rows are read to be written back, cached or
+// checked against a token, which a lagging replica would return in a state that no longer holds
+func (repo BaseRepository[T]) ReadFromPrimary() BaseRepository[T] {
+	repo.readPrimary = true
+	return repo
+}
+
+func (repo BaseRepository[T]) GetByID(ctx context.Context, id uint) (*T, error) {
+	var entity T
+	if err := repo.reader(ctx).First(&entity, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repo.notFound()
+		}
+		logger.WithContext(ctx).Errorf("DB error: failed to fetch %s by id %d: %v", repo.name, id, err)
+		return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to fetch "+repo.name, err)
+	}
+	return &entity, nil
+}
+
+// FindByField returns the first row whose column field equals value. Columns not listed in findFields
+// are rejected with ErrBadRequest before any query is built
+func (repo BaseRepository[T]) FindByField(ctx context.Context, field string, value string) (*T, error) {
+	if !slices.Contains(repo.findFields, field) {
+		return nil, apperror.New(apperror.ErrBadRequest, 1002, "Invalid field")
+	}
+
+	var entity T
+	if err := repo.reader(ctx).Where(field+" = ?", value).First(&entity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repo.notFound()
+		}
+		logger.WithContext(ctx).Errorf("DB error: failed to fetch %s by field %s: %v", repo.name, field, err)
+		return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to fetch "+repo.name, err)
+	}
+	return &entity, nil
+}
+
+func (repo BaseRepository[T]) Create(ctx context.Context, entity *T) (*T, error) {
+	if err := repo.db.WithContext(ctx).Create(entity).Error; err != nil {
+		if dupErr := duplicateKeyError(err); dupErr != nil {
+			logger.WithContext(ctx).Warnf("Rejected %s with a duplicate %s", repo.name, dupErr.Fields[0].Field)
+			return nil, dupErr
+		}
+		logger.WithContext(ctx).Errorf("DB error: failed to create %s: %v", repo.name, err)
+		return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to create "+repo.name, err)
+	}
+	return entity, nil
+}
+
+// Update saves every field of entity
+func (repo BaseRepository[T]) Update(ctx context.Context, entity *T) error {
+	if err := repo.db.WithContext(ctx).Save(entity).Error; err != nil {
+		if dupErr := duplicateKeyError(err); dupErr != nil {
+			logger.WithContext(ctx).Warnf("Rejected %s with a duplicate %s", repo.name, dupErr.Fields[0].Field)
+			return dupErr
+		}
+		logger.WithContext(ctx).Errorf("DB error: failed to update %s: %v", repo.name, err)
+		return apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to update "+repo.name, err)
+	}
+	return nil
+}
+
+// Delete deletes the row with the ID, softly if T has a DeletedAt field. Deleting a missing row is not an error
+func (repo BaseRepository[T]) Delete(ctx context.Context, id uint) error {
+	var entity T
+	if err := repo.db.WithContext(ctx).Delete(&entity, id).Error; err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to delete %s id %d: %v", repo.name, id, err)
+		return apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to delete "+repo.name, err)
+	}
+	return nil
+}
+
+// Paginate returns a page of the rows of query, see paginate. sortBy must be a column of T
+func (repo BaseRepository[T]) Paginate(ctx context.Context, query *gorm.DB, opts dto.ListOptions, sortBy string) (*dto.Pagination[*T], error) {
+	return paginate[T](ctx, query, opts, sortBy, repo.name+"s")
+}
+
+// reader returns the session GetByID and FindByField query, on the primary if readPrimary is set
+func (repo BaseRepository[T]) reader(ctx context.Context) *gorm.DB {
+	if repo.readPrimary {
+		return onPrimary(repo.db.WithContext(ctx))
+	}
+	return repo.db.WithContext(ctx)
+}
+
+// onPrimary pins the queries of db to the primary database. Without registered read replicas it changes nothing
+func onPrimary(db *gorm.DB) *gorm.DB {
+	return db.Clauses(dbresolver.Write)
+}
+
+func (repo BaseRepository[T]) notFound() *apperror.AppError {
+	return apperror.New(apperror.ErrNotFound, 1001, strings.ToUpper(repo.name[:1])+repo.name[1:]+" not found")
+}
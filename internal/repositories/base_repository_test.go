@@ -0,0 +1,167 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// baseRepositoryCase describes how the shared suite builds and reads rows of one model
+type baseRepositoryCase[T any] struct {
+	name     string                // Model name given to the repository
+	field    string                // Column FindByField is allowed to filter on
+	newRow   func(value string) *T // Builds a row whose field column holds value
+	idOf     func(row *T) uint
+	valueOf  func(row *T) string
+	setValue func(row *T, value string)
+}
+
+func runBaseRepositorySuite[T any](t *testing.T, tc baseRepositoryCase[T]) {
+	ctx := context.Background()
+	setup := func(t *testing.T) (*gorm.DB, repositories.BaseRepository[T]) {
+		db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+		require.NoError(t, err)
+		require.NoError(t, db.AutoMigrate(new(T)))
+		return db, repositories.NewBaseRepository[T](db, tc.name, tc.field)
+	}
+	assertCode := func(t *testing.T, err error, code int) {
+		appErr, ok := apperror.ToAppError(err)
+		require.True(t, ok, "expected an AppError, got %v", err)
+		assert.Equal(t, code, appErr.Code)
+	}
+
+	t.Run("Create And GetByID", func(t *testing.T) {
+		_, repo := setup(t)
+
+		created, err := repo.Create(ctx, tc.newRow("first"))
+		require.NoError(t, err)
+		require.NotZero(t, tc.idOf(created))
+
+		found, err := repo.GetByID(ctx, tc.idOf(created))
+		require.NoError(t, err)
+		assert.Equal(t, "first", tc.valueOf(found))
+	})
+
+	t.Run("GetByID - Not Found", func(t *testing.T) {
+		_, repo := setup(t)
+
+		found, err := repo.GetByID(ctx, 999)
+
+		assert.Nil(t, found)
+		assertCode(t, err, apperror.ErrNotFound)
+	})
+
+	t.Run("FindByField", func(t *testing.T) {
+		_, repo := setup(t)
+		_, err := repo.Create(ctx, tc.newRow("wanted"))
+		require.NoError(t, err)
+
+		found, err := repo.FindByField(ctx, tc.field, "wanted")
+		require.NoError(t, err)
+		assert.Equal(t, "wanted", tc.valueOf(found))
+
+		_, err = repo.FindByField(ctx, tc.field, "missing")
+		assertCode(t, err, apperror.ErrNotFound)
+	})
+
+	t.Run("FindByField - Rejects Columns Not Listed", func(t *testing.T) {
+		_, repo := setup(t)
+
+		for _, field := range []string{"id", "sql;", tc.field + " = 1 OR " + tc.field} {
+			found, err := repo.FindByField(ctx, field, "x")
+			assert.Nil(t, found)
+			assertCode(t, err, apperror.ErrBadRequest)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		_, repo := setup(t)
+		row, err := repo.Create(ctx, tc.newRow("before"))
+		require.NoError(t, err)
+
+		tc.setValue(row, "after")
+		require.NoError(t, repo.Update(ctx, row))
+
+		found, err := repo.GetByID(ctx, tc.idOf(row))
+		require.NoError(t, err)
+		assert.Equal(t, "after", tc.valueOf(found))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		_, repo := setup(t)
+		row, err := repo.Create(ctx, tc.newRow("deleted"))
+		require.NoError(t, err)
+
+		require.NoError(t, repo.Delete(ctx, tc.idOf(row)))
+		_, err = repo.GetByID(ctx, tc.idOf(row))
+		assertCode(t, err, apperror.ErrNotFound)
+		// Deleting a missing row is not an error
+		assert.NoError(t, repo.Delete(ctx, tc.idOf(row)))
+	})
+
+	t.Run("Paginate", func(t *testing.T) {
+		db, repo := setup(t)
+		for _, value := range []string{"a", "b", "c"} {
+			_, err := repo.Create(ctx, tc.newRow(value))
+			require.NoError(t, err)
+		}
+
+		page, err := repo.Paginate(ctx, db.Model(new(T)), dto.ListOptions{Page: 1, Limit: 2, SortBy: tc.field, SortDir: "asc"}, tc.field)
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, page.TotalItems)
+		assert.True(t, page.HasNext)
+		require.Len(t, page.Data, 2)
+		assert.Equal(t, "a", tc.valueOf(page.Data[0]))
+		assert.Equal(t, "b", tc.valueOf(page.Data[1]))
+	})
+
+	t.Run("Database Error", func(t *testing.T) {
+		db, repo := setup(t)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		// Failures other than a missing row are not reported as ErrNotFound
+		_, err = repo.GetByID(ctx, 1)
+		appErr, ok := apperror.ToAppError(err)
+		require.True(t, ok)
+		assert.NotEqual(t, apperror.ErrNotFound, appErr.Code)
+		_, err = repo.Create(ctx, tc.newRow("x"))
+		assert.Error(t, err)
+	})
+}
+
+func TestBaseRepository(t *testing.T) {
+	t.Run("User", func(t *testing.T) {
+		runBaseRepositorySuite(t, baseRepositoryCase[models.User]{
+			name:  "user",
+			field: "email",
+			newRow: func(value string) *models.User {
+				return &models.User{Name: "User", Email: value, Password: "password", Gender: 1}
+			},
+			idOf:     func(user *models.User) uint { return user.ID },
+			valueOf:  func(user *models.User) string { return user.Email },
+			setValue: func(user *models.User, value string) { user.Email = value },
+		})
+	})
+
+	t.Run("Role", func(t *testing.T) {
+		runBaseRepositorySuite(t, baseRepositoryCase[models.Role]{
+			name:     "role",
+			field:    "name",
+			newRow:   func(value string) *models.Role { return &models.Role{Name: value} },
+			idOf:     func(role *models.Role) uint { return role.ID },
+			valueOf:  func(role *models.Role) string { return role.Name },
+			setValue: func(role *models.Role, value string) { role.Name = value },
+		})
+	})
+}
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+// MYSQL_ER_DUP_ENTRY is the MySQL error number of a duplicate key
+const MYSQL_ER_DUP_ENTRY = 1062
+
+// POSTGRES_UNIQUE_VIOLATION is the Postgres SQLSTATE of a duplicate key
+const POSTGRES_UNIQUE_VIOLATION = "23505"
+
+// uniqueField is the request field holding the value of a unique index, and the error returned when the value is taken
+type uniqueField struct {
+	name     string
+	newError func(message string) *apperror.AppError
+}
+
+// uniqueConstraintFields maps the unique indexes clients can collide on to their field. A duplicate key on
+// another index, e.g. a token, is an internal error. Add an entry along with each such unique index
+var uniqueConstraintFields = map[string]uniqueField{
+	"uni_users_email": {name: "email", newError: apperror.NewDuplicateEmailError},
+}
+
+// isDuplicateKeyError reports whether err is a unique constraint violation, from MySQL, Postgres or from the
+// sqlite databases of the tests
+func isDuplicateKeyError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == MYSQL_ER_DUP_ENTRY
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == POSTGRES_UNIQUE_VIOLATION
+	}
+	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+// duplicateKeyConstraint returns the name of the unique index a duplicate key error violates. Postgres returns
+// the constraint name and MySQL names the index, as "Duplicate entry 'x' for key 'users.uni_users_email'"; sqlite
+// names the column, as "UNIQUE constraint failed: users.email", whose index is named uni_<table>_<column> by the
+// migrations and by GORM
+func duplicateKeyConstraint(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.ConstraintName
+	}
+	message := err.Error()
+	if _, key, ok := strings.Cut(message, "for key '"); ok {
+		key = strings.TrimSuffix(key, "'")
+		return key[strings.LastIndex(key, ".")+1:]
+	}
+	if _, columns, ok := strings.Cut(message, "UNIQUE constraint failed: "); ok {
+		column, _, _ := strings.Cut(columns, ",")
+		if table, column, ok := strings.Cut(strings.TrimSpace(column), "."); ok {
+			return "uni_" + table + "_" + column
+		}
+	}
+	return ""
+}
+
+// duplicateKeyError translates a duplicate key on one of uniqueConstraintFields into a 409 naming the field.
+// It returns nil for other errors
+func duplicateKeyError(err error) *apperror.AppError {
+	if !isDuplicateKeyError(err) {
+		return nil
+	}
+	field, ok := uniqueConstraintFields[duplicateKeyConstraint(err)]
+	if !ok {
+		return nil
+	}
+	message := fmt.Sprintf("%s already exists", field.name)
+	return field.newError(message).WithFields(apperror.FieldError{Field: field.name, Message: message})
+}
@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+func TestDuplicateKeyError(t *testing.T) {
+	t.Run("MySQL Names The Index", func(t *testing.T) {
+		for _, key := range []string{"users.uni_users_email", "uni_users_email"} {
+			err := fmt.Errorf("insert failed: %w", &mysql.MySQLError{Number: MYSQL_ER_DUP_ENTRY, Message: "Duplicate entry 'a@example.com' for key '" + key + "'"})
+
+			dupErr := duplicateKeyError(err)
+
+			require.NotNil(t, dupErr, key)
+			assert.Equal(t, apperror.ErrDuplicateEmail, dupErr.Code)
+			assert.Equal(t, []apperror.FieldError{{Field: "email", Message: "email already exists"}}, dupErr.Fields)
+		}
+	})
+
+	t.Run("Postgres Names The Constraint", func(t *testing.T) {
+		err := fmt.Errorf("insert failed: %w", &pgconn.PgError{Code: POSTGRES_UNIQUE_VIOLATION, ConstraintName: "uni_users_email"})
+
+		dupErr := duplicateKeyError(err)
+
+		require.NotNil(t, dupErr)
+		assert.Equal(t, apperror.ErrDuplicateEmail, dupErr.Code)
+		assert.Equal(t, "email", dupErr.Fields[0].Field)
+		assert.Nil(t, duplicateKeyError(&pgconn.PgError{Code: POSTGRES_UNIQUE_VIOLATION, ConstraintName: "uni_users_token"}))
+	})
+
+	t.Run("Sqlite Names The Column", func(t *testing.T) {
+		dupErr := duplicateKeyError(errors.New("UNIQUE constraint failed: users.email"))
+
+		require.NotNil(t, dupErr)
+		assert.Equal(t, "email", dupErr.Fields[0].Field)
+	})
+
+	t.Run("Unmapped Index", func(t *testing.T) {
+		err := &mysql.MySQLError{Number: MYSQL_ER_DUP_ENTRY, Message: "Duplicate entry 'x' for key 'users.uni_users_token'"}
+
+		assert.Nil(t, duplicateKeyError(err))
+	})
+
+	t.Run("Other Errors", func(t *testing.T) {
+		assert.Nil(t, duplicateKeyError(nil))
+		assert.Nil(t, duplicateKeyError(errors.New("connection refused")))
+		assert.Nil(t, duplicateKeyError(&mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}))
+		assert.Nil(t, duplicateKeyError(&pgconn.PgError{Code: "40P01", Message: "deadlock detected"}))
+	})
+}
@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// schemaCache caches the parsed models used to read and decode cursor sort keys
+var schemaCache sync.Map
+
+// paginate loads one page of the filtered query into a Pagination, ordered by sortBy in opts.SortDir
+// with id descending as tie-breaker. sortBy must be a column of T; name is the plural of the rows, used in errors.
+//
+// In offset mode the page is opts.Page. In cursor mode the page starts after opts.Cursor and is found by
+// comparing (sortBy, id) instead of skipping rows with OFFSET, so it stays fast on large tables and
+// neither skips nor repeats rows when others are inserted during the iteration.
+func paginate[T any](ctx context.Context, query *gorm.DB, opts dto.ListOptions, sortBy, name string) (*dto.Pagination[*T], error) {
+	var totalRows int64
+	if err := query.Session(&gorm.Session{}).Count(&totalRows).Error; err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to count %s: %v", name, err)
+		return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to count "+name, err)
+	}
+
+	desc := opts.SortDir != "asc"
+	query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: sortBy}, Desc: desc})
+	if sortBy != "id" {
+		query = query.Order("id DESC")
+	}
+
+	pagination := &dto.Pagination[*T]{
+		Limit:      opts.Limit,
+		TotalItems: int(totalRows),
+		TotalPages: utils.CalculateTotalPages(totalRows, opts.Limit),
+	}
+
+	if opts.Cursor == nil {
+		var rows []*T
+		if err := query.Offset((opts.Page - 1) * opts.Limit).Limit(opts.Limit).Find(&rows).Error; err != nil {
+			logger.WithContext(ctx).Errorf("DB error: failed to fetch %s: %v", name, err)
+			return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to fetch "+name, err)
+		}
+		pagination.Page = opts.Page
+		if opts.Page < pagination.TotalPages {
+			next := opts.Page + 1
+			pagination.NextPage = &next
+		}
+		// Past the last page, the previous page is the last one
+		if prev := min(opts.Page-1, pagination.TotalPages); prev >= 1 {
+			pagination.PrevPage = &prev
+		}
+		pagination.HasNext = pagination.NextPage != nil
+		pagination.HasPrev = pagination.PrevPage != nil
+		pagination.Data = rows
+		return pagination, nil
+	}
+
+	field, err := sortField[T](query, sortBy)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Cursor.ID != 0 {
+		after, err := afterCursor(field, desc, opts.Cursor)
+		if err != nil {
+			logger.WithContext(ctx).Warnf("Rejected %s cursor: %v", name, err)
+			return nil, apperror.NewParseError("Invalid cursor")
+		}
+		query = query.Where(after)
+		pagination.HasPrev = true
+	}
+
+	// One extra row tells whether there is a next page
+	var rows []*T
+	if err := query.Limit(opts.Limit + 1).Find(&rows).Error; err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to fetch %s: %v", name, err)
+		return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to fetch "+name, err)
+	}
+	if len(rows) > opts.Limit {
+		rows = rows[:opts.Limit]
+		next, err := cursorAt(ctx, field, rows[len(rows)-1], opts)
+		if err != nil {
+			logger.WithContext(ctx).Errorf("Failed to build %s cursor: %v", name, err)
+			return nil, apperror.NewInternalServerError("Failed to build cursor")
+		}
+		pagination.HasNext = true
+		pagination.NextCursor = utils.EncodeCursor(next)
+	}
+	pagination.Data = rows
+	return pagination, nil
+}
+
+// sortField returns the field of T stored in column
+func sortField[T any](db *gorm.DB, column string) (*schema.Field, error) {
+	parsed, err := schema.Parse(new(T), &schemaCache, db.NamingStrategy)
+	if err != nil {
+		return nil, err
+	}
+	field := parsed.LookUpField(column)
+	if field == nil {
+		return nil, fmt.Errorf("unknown sort column %s", column)
+	}
+	return field, nil
+}
+
+// cursorAt returns the cursor pointing at row
+func cursorAt[T any](ctx context.Context, field *schema.Field, row *T, opts dto.ListOptions) (dto.Cursor, error) {
+	idField := field.Schema.LookUpField("id")
+	if idField == nil {
+		return dto.Cursor{}, errors.New("model has no id column")
+	}
+	id, _ := idField.ValueOf(ctx, reflect.ValueOf(row).Elem())
+	cursor := dto.Cursor{SortBy: field.DBName, SortDir: opts.SortDir, ID: uint(reflect.ValueOf(id).Uint())}
+	if field.DBName != "id" {
+		value, _ := field.ValueOf(ctx, reflect.ValueOf(row).Elem())
+		data, err := json.Marshal(value)
+		if err != nil {
+			return dto.Cursor{}, err
+		}
+		cursor.Value = data
+	}
+	return cursor, nil
+}
+
+// afterCursor returns the condition selecting the rows ordered after the cursor
+func afterCursor(field *schema.Field, desc bool, cursor *dto.Cursor) (clause.Expression, error) {
+	op := ">"
+	if desc {
+		op = "<"
+	}
+	id := clause.Column{Name: "id"}
+	if field.DBName == "id" {
+		return clause.Expr{SQL: "? " + op + " ?", Vars: []any{id, cursor.ID}}, nil
+	}
+
+	value := reflect.New(field.FieldType)
+	if err := json.Unmarshal(cursor.Value, value.Interface()); err != nil {
+		return nil, err
+	}
+	column := clause.Column{Name: field.DBName}
+	// Ties on the sort key are ordered by id descending
+	return clause.Expr{
+		SQL:  "(? " + op + " ? OR (? = ? AND ? < ?))",
+		Vars: []any{column, value.Elem().Interface(), column, value.Elem().Interface(), id, cursor.ID},
+	}, nil
+}
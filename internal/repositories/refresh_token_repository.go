@@ -13,30 +13,33 @@ import (
 
 type RefreshTokenRepository interface {
 	Create(ctx context.Context, token *models.RefreshToken) error
-	Update(ctx context.Context, token *models.RefreshToken) error
+	Rotate(ctx context.Context, token *models.RefreshToken, previous string) (bool, error)
 	FindByToken(ctx context.Context, token string) (*models.RefreshToken, error)
 	UpdateWithTx(ctx context.Context, token *models.RefreshToken, tx *gorm.DB) error
+	DeleteByToken(ctx context.Context, userID uint, token string) error
+	DeleteAllByUserID(ctx context.Context, userID uint) (int64, error)
+	FindActiveByUserID(ctx context.Context, userID uint) ([]*models.RefreshToken, error)
+	DeleteByID(ctx context.Context, userID, id uint) (bool, error)
 }
 
 type refreshTokenRepositoryImpl struct {
-	db *gorm.DB
+	BaseRepository[models.RefreshToken]
 }
 
 func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
-	return &refreshTokenRepositoryImpl{db: db}
+	return &refreshTokenRepositoryImpl{BaseRepository: NewBaseRepository[models.RefreshToken](db, "refresh token")}
 }
 
 func (repo *refreshTokenRepositoryImpl) Create(ctx context.Context, token *models.RefreshToken) error {
-	if err := repo.db.WithContext(ctx).Create(token).Error; err != nil {
-		logger.WithContext(ctx).Errorf("DB error: failed to create refresh token: %v", err)
-		return apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to create refresh token", err)
-	}
-	return nil
+	_, err := repo.BaseRepository.Create(ctx, token)
+	return err
 }
 
+// FindByToken returns the unexpired refresh token. It reads the primary, so a token revoked a moment ago is not
+// found on a lagging replica
 func (repo *refreshTokenRepositoryImpl) FindByToken(ctx context.Context, token string) (*models.RefreshToken, error) {
 	var refreshToken models.RefreshToken
-	if err := repo.db.WithContext(ctx).Where("refresh_token = ? and expired_at > ?", token, time.Now().Unix()).First(&refreshToken).Error; err != nil {
+	if err := onPrimary(repo.db.WithContext(ctx)).Where("refresh_token = ? and expired_at > ?", token, time.Now().Unix()).First(&refreshToken).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, apperror.New(apperror.ErrNotFound, 1001, "Refresh token not found or expired")
 		}
@@ -46,12 +49,20 @@ func (repo *refreshTokenRepositoryImpl) FindByToken(ctx context.Context, token s
 	return &refreshToken, nil
 }
 
-func (repo *refreshTokenRepositoryImpl) Update(ctx context.Context, token *models.RefreshToken) error {
-	if err := repo.db.WithContext(ctx).Save(token).Error; err != nil {
-		logger.WithContext(ctx).Errorf("DB error: failed to update refresh token: %v", err)
-		return apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to update refresh token", err)
+// Rotate saves the new value, expiry and client details of token, but only if the row still holds the token
+// previous, in a single conditional UPDATE. It returns false without changing anything when the token has been
+// revoked or rotated meanwhile, so a revoked session is never written back and of concurrent refreshes only one wins
+func (repo *refreshTokenRepositoryImpl) Rotate(ctx context.Context, token *models.RefreshToken, previous string) (bool, error) {
+	result := repo.db.WithContext(ctx).
+		Model(token).
+		Where("refresh_token = ?", previous).
+		Select("refresh_token", "fingerprint", "ip_address", "user_agent", "used_count", "last_used_at", "expired_at").
+		Updates(token)
+	if result.Error != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to rotate refresh token %d: %v", token.ID, result.Error)
+		return false, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to update refresh token", result.Error)
 	}
-	return nil
+	return result.RowsAffected == 1, nil
 }
 
 func (repo *refreshTokenRepositoryImpl) UpdateWithTx(ctx context.Context, token *models.RefreshToken, tx *gorm.DB) error {
@@ -61,3 +72,46 @@ func (repo *refreshTokenRepositoryImpl) UpdateWithTx(ctx context.Context, token
 	}
 	return nil
 }
+
+// DeleteByToken removes the refresh token owned by userID. Deleting a token that no longer exists is not an error.
+func (repo *refreshTokenRepositoryImpl) DeleteByToken(ctx context.Context, userID uint, token string) error {
+	if err := repo.db.WithContext(ctx).Where("refresh_token = ? AND user_id = ?", token, userID).Delete(&models.RefreshToken{}).Error; err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to delete refresh token: %v", err)
+		return apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to delete refresh token", err)
+	}
+	return nil
+}
+
+// DeleteAllByUserID removes every refresh token of the user and returns how many were removed
+func (repo *refreshTokenRepositoryImpl) DeleteAllByUserID(ctx context.Context, userID uint) (int64, error) {
+	result := repo.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.RefreshToken{})
+	if result.Error != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to delete refresh tokens of user %d: %v", userID, result.Error)
+		return 0, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to delete refresh tokens", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// FindActiveByUserID returns the unexpired refresh tokens of the user, most recently used first
+func (repo *refreshTokenRepositoryImpl) FindActiveByUserID(ctx context.Context, userID uint) ([]*models.RefreshToken, error) {
+	var tokens []*models.RefreshToken
+	err := repo.db.WithContext(ctx).
+		Where("user_id = ? AND expired_at > ?", userID, time.Now().Unix()).
+		Order("COALESCE(last_used_at, created_at) DESC").Order("id DESC").
+		Find(&tokens).Error
+	if err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to fetch refresh tokens of user %d: %v", userID, err)
+		return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to fetch refresh tokens", err)
+	}
+	return tokens, nil
+}
+
+// DeleteByID removes the refresh token with the ID if it belongs to userID, and reports whether it did
+func (repo *refreshTokenRepositoryImpl) DeleteByID(ctx context.Context, userID, id uint) (bool, error) {
+	result := repo.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&models.RefreshToken{})
+	if result.Error != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to delete refresh token %d of user %d: %v", id, userID, result.Error)
+		return false, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to delete refresh token", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -11,8 +11,19 @@ import (
 	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
 )
 
+// registerEmptyReplica routes the reads of db outside transactions to a new, empty SQLite database with the tables
+// of models, standing for a replica lagging behind db
+func registerEmptyReplica(t *testing.T, db *gorm.DB, models ...any) {
+	replicaFile := t.TempDir() + "/replica.db"
+	replica, err := gorm.Open(sqlite.Open(replicaFile), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, replica.AutoMigrate(models...))
+	require.NoError(t, db.Use(dbresolver.Register(dbresolver.Config{Replicas: []gorm.Dialector{sqlite.Open(replicaFile)}})))
+}
+
 // setupTestDB creates an in-memory SQLite database for testing
 func setupTestDB(t *testing.T) *gorm.DB {
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
@@ -160,7 +171,7 @@ func TestRefreshTokenRepository(t *testing.T) {
 		assert.Nil(t, foundItem)
 	})
 
-	t.Run("Update - Success", func(t *testing.T) {
+	t.Run("Rotate - Success", func(t *testing.T) {
 		// Arrange
 		db := setupTestDB(t)
 		repo := repositories.NewRefreshTokenRepository(db)
@@ -181,10 +192,11 @@ func TestRefreshTokenRepository(t *testing.T) {
 		item.ExpiredAt = time.Now().Unix() + int64(time.Hour)
 
 		// Act
-		err = repo.Update(context.Background(), item)
+		rotated, err := repo.Rotate(context.Background(), item, "test_original_refresh_token")
 
 		// Assert
 		require.NoError(t, err)
+		assert.True(t, rotated)
 
 		// Verify the update
 		foundItem, err := repo.FindByToken(context.Background(), item.RefreshToken)
@@ -197,6 +209,69 @@ func TestRefreshTokenRepository(t *testing.T) {
 		assert.Equal(t, item.ExpiredAt, foundItem.ExpiredAt)
 	})
 
+	t.Run("Rotate - Revoked Token Is Not Written Back", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB(t)
+		repo := repositories.NewRefreshTokenRepository(db)
+		item := &models.RefreshToken{RefreshToken: "revoked_token", ExpiredAt: time.Now().Unix() + int64(time.Hour), UserID: 1}
+		require.NoError(t, repo.Create(context.Background(), item))
+		require.NoError(t, repo.DeleteByToken(context.Background(), 1, "revoked_token"))
+
+		// Act
+		item.RefreshToken = "rotated_token"
+		rotated, err := repo.Rotate(context.Background(), item, "revoked_token")
+
+		// Assert
+		require.NoError(t, err)
+		assert.False(t, rotated)
+		var count int64
+		require.NoError(t, db.Unscoped().Model(&models.RefreshToken{}).Where("refresh_token = ?", "rotated_token").Count(&count).Error)
+		assert.Zero(t, count)
+	})
+
+	t.Run("Rotate - Token Rotated Meanwhile", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB(t)
+		repo := repositories.NewRefreshTokenRepository(db)
+		item := &models.RefreshToken{RefreshToken: "shared_token", ExpiredAt: time.Now().Unix() + int64(time.Hour), UserID: 1}
+		require.NoError(t, repo.Create(context.Background(), item))
+		first, second := *item, *item
+		first.RefreshToken = "first_token"
+		second.RefreshToken = "second_token"
+
+		// Act
+		firstRotated, err := repo.Rotate(context.Background(), &first, "shared_token")
+		require.NoError(t, err)
+		secondRotated, err := repo.Rotate(context.Background(), &second, "shared_token")
+		require.NoError(t, err)
+
+		// Assert
+		assert.True(t, firstRotated)
+		assert.False(t, secondRotated)
+		_, err = repo.FindByToken(context.Background(), "first_token")
+		assert.NoError(t, err)
+	})
+
+	t.Run("FindByToken - Reads The Primary", func(t *testing.T) {
+		// Arrange: a replica that did not get the token yet
+		db := setupTestDB(t)
+		registerEmptyReplica(t, db, &models.RefreshToken{})
+		repo := repositories.NewRefreshTokenRepository(db)
+		item := &models.RefreshToken{RefreshToken: "fresh_token", ExpiredAt: time.Now().Unix() + int64(time.Hour), UserID: 1}
+		require.NoError(t, repo.Create(context.Background(), item))
+
+		// Act
+		found, err := repo.FindByToken(context.Background(), "fresh_token")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, item.ID, found.ID)
+		// Listings still read the replica
+		sessions, err := repo.FindActiveByUserID(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Empty(t, sessions)
+	})
+
 	t.Run("UpdateWithTx - Success", func(t *testing.T) {
 		// Arrange
 		db := setupTestDB(t)
@@ -228,4 +303,194 @@ func TestRefreshTokenRepository(t *testing.T) {
 		require.NotNil(t, foundItem)
 		assert.Equal(t, int64(1), foundItem.UsedCount)
 	})
+
+	t.Run("DeleteByToken - Success", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB(t)
+		repo := repositories.NewRefreshTokenRepository(db)
+		item := &models.RefreshToken{
+			RefreshToken: "token_to_delete",
+			IpAddress:    "127.0.0.1",
+			ExpiredAt:    time.Now().Add(time.Hour).Unix(),
+			UserID:       1,
+		}
+		require.NoError(t, repo.Create(context.Background(), item))
+
+		// Act
+		err := repo.DeleteByToken(context.Background(), 1, "token_to_delete")
+
+		// Assert
+		require.NoError(t, err)
+		_, err = repo.FindByToken(context.Background(), "token_to_delete")
+		assert.Error(t, err)
+	})
+
+	t.Run("DeleteByToken - Already Deleted Is Not An Error", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB(t)
+		repo := repositories.NewRefreshTokenRepository(db)
+
+		// Act
+		err := repo.DeleteByToken(context.Background(), 1, "missing_token")
+
+		// Assert
+		require.NoError(t, err)
+	})
+
+	t.Run("DeleteByToken - Token Of Another User Is Kept", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB(t)
+		repo := repositories.NewRefreshTokenRepository(db)
+		item := &models.RefreshToken{
+			RefreshToken: "other_user_token",
+			IpAddress:    "127.0.0.1",
+			ExpiredAt:    time.Now().Add(time.Hour).Unix(),
+			UserID:       2,
+		}
+		require.NoError(t, repo.Create(context.Background(), item))
+
+		// Act
+		err := repo.DeleteByToken(context.Background(), 1, "other_user_token")
+
+		// Assert
+		require.NoError(t, err)
+		found, err := repo.FindByToken(context.Background(), "other_user_token")
+		require.NoError(t, err)
+		assert.Equal(t, uint(2), found.UserID)
+	})
+
+	t.Run("DeleteByToken - DB Error", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB(t)
+		repo := repositories.NewRefreshTokenRepository(db)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		// Act
+		err = repo.DeleteByToken(context.Background(), 1, "any_token")
+
+		// Assert
+		assert.Error(t, err)
+	})
+
+	t.Run("DeleteAllByUserID - Removes Only The User Tokens", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB(t)
+		repo := repositories.NewRefreshTokenRepository(db)
+		expiredAt := time.Now().Add(time.Hour).Unix()
+		for _, item := range []*models.RefreshToken{
+			{RefreshToken: "user1_token_a", IpAddress: "127.0.0.1", ExpiredAt: expiredAt, UserID: 1},
+			{RefreshToken: "user1_token_b", IpAddress: "127.0.0.1", ExpiredAt: expiredAt, UserID: 1},
+			{RefreshToken: "user2_token", IpAddress: "127.0.0.1", ExpiredAt: expiredAt, UserID: 2},
+		} {
+			require.NoError(t, repo.Create(context.Background(), item))
+		}
+
+		// Act
+		count, err := repo.DeleteAllByUserID(context.Background(), 1)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+		_, err = repo.FindByToken(context.Background(), "user1_token_a")
+		assert.Error(t, err)
+		_, err = repo.FindByToken(context.Background(), "user2_token")
+		assert.NoError(t, err)
+	})
+
+	t.Run("DeleteAllByUserID - No Tokens", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB(t)
+		repo := repositories.NewRefreshTokenRepository(db)
+
+		// Act
+		count, err := repo.DeleteAllByUserID(context.Background(), 1)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("DeleteAllByUserID - DB Error", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB(t)
+		repo := repositories.NewRefreshTokenRepository(db)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		// Act
+		_, err = repo.DeleteAllByUserID(context.Background(), 1)
+
+		// Assert
+		assert.Error(t, err)
+	})
+
+	t.Run("FindActiveByUserID - Excludes Revoked, Expired And Other Users Tokens", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB(t)
+		repo := repositories.NewRefreshTokenRepository(db)
+		now := time.Now()
+		earlier := now.Add(-time.Hour)
+		expiredAt := now.Add(time.Hour).Unix()
+		for _, item := range []*models.RefreshToken{
+			{RefreshToken: "stale", IpAddress: "127.0.0.1", ExpiredAt: expiredAt, LastUsedAt: &earlier, UserID: 1},
+			{RefreshToken: "recent", IpAddress: "127.0.0.1", ExpiredAt: expiredAt, LastUsedAt: &now, UserID: 1},
+			{RefreshToken: "expired", IpAddress: "127.0.0.1", ExpiredAt: now.Add(-time.Minute).Unix(), UserID: 1},
+			{RefreshToken: "revoked", IpAddress: "127.0.0.1", ExpiredAt: expiredAt, UserID: 1},
+			{RefreshToken: "other_user", IpAddress: "127.0.0.1", ExpiredAt: expiredAt, UserID: 2},
+		} {
+			require.NoError(t, repo.Create(context.Background(), item))
+		}
+		require.NoError(t, repo.DeleteByToken(context.Background(), 1, "revoked"))
+
+		// Act
+		tokens, err := repo.FindActiveByUserID(context.Background(), 1)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, tokens, 2)
+		assert.Equal(t, "recent", tokens[0].RefreshToken)
+		assert.Equal(t, "stale", tokens[1].RefreshToken)
+	})
+
+	t.Run("DeleteByID - Deletes Only Tokens Of The User", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB(t)
+		repo := repositories.NewRefreshTokenRepository(db)
+		token := &models.RefreshToken{RefreshToken: "user1_token", IpAddress: "127.0.0.1", ExpiredAt: time.Now().Add(time.Hour).Unix(), UserID: 1}
+		require.NoError(t, repo.Create(context.Background(), token))
+
+		// Act
+		deletedByOther, errOther := repo.DeleteByID(context.Background(), 2, token.ID)
+		deleted, err := repo.DeleteByID(context.Background(), 1, token.ID)
+		deletedAgain, errAgain := repo.DeleteByID(context.Background(), 1, token.ID)
+
+		// Assert
+		require.NoError(t, errOther)
+		require.NoError(t, err)
+		require.NoError(t, errAgain)
+		assert.False(t, deletedByOther)
+		assert.True(t, deleted)
+		assert.False(t, deletedAgain)
+		_, err = repo.FindByToken(context.Background(), "user1_token")
+		assert.Error(t, err)
+	})
+
+	t.Run("FindActiveByUserID - DB Error", func(t *testing.T) {
+		// Arrange
+		db := setupTestDB(t)
+		repo := repositories.NewRefreshTokenRepository(db)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		// Act
+		tokens, err := repo.FindActiveByUserID(context.Background(), 1)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, tokens)
+	})
 }
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+	"gorm.io/gorm"
+)
+
+type RoleRepository interface {
+	GetByUserID(ctx context.Context, userID uint) ([]models.Role, error)
+}
+
+type roleRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepositoryImpl{db: db}
+}
+
+// GetByUserID returns the roles assigned to the user. It reads the primary: the roles are cached right after
+// they change, and a lagging replica would keep a revoked role granted until the cache expires
+func (repo *roleRepositoryImpl) GetByUserID(ctx context.Context, userID uint) ([]models.Role, error) {
+	roles := []models.Role{}
+	err := onPrimary(repo.db.WithContext(ctx)).
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.id").
+		Find(&roles).Error
+	if err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to fetch roles of user %d: %v", userID, err)
+		return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to fetch roles", err)
+	}
+	return roles, nil
+}
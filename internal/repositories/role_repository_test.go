@@ -0,0 +1,81 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
+)
+
+func TestRoleRepository(t *testing.T) {
+	t.Run("GetByUserID - Returns Assigned Roles", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewRoleRepository(db)
+		admin := models.Role{Name: "admin"}
+		member := models.Role{Name: "user"}
+		require.NoError(t, db.Create(&admin).Error)
+		require.NoError(t, db.Create(&member).Error)
+		user := &models.User{Name: "User1", Email: "email1@example.com", Password: "password1", Gender: 1, Roles: []models.Role{admin}}
+		require.NoError(t, db.Create(user).Error)
+
+		// Act
+		roles, err := repo.GetByUserID(context.Background(), user.ID)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		assert.Equal(t, "admin", roles[0].Name)
+	})
+
+	t.Run("GetByUserID - Reads The Primary", func(t *testing.T) {
+		// Arrange: a replica that did not get the role assignment yet
+		db := setupUserTestDB(t)
+		registerEmptyReplica(t, db, &models.User{}, &models.Role{})
+		repo := repositories.NewRoleRepository(db)
+		admin := models.Role{Name: "admin"}
+		require.NoError(t, db.Create(&admin).Error)
+		user := &models.User{Name: "User1", Email: "email1@example.com", Password: "password1", Gender: 1, Roles: []models.Role{admin}}
+		require.NoError(t, db.Create(user).Error)
+
+		// Act
+		roles, err := repo.GetByUserID(context.Background(), user.ID)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		assert.Equal(t, "admin", roles[0].Name)
+	})
+
+	t.Run("GetByUserID - No Roles", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewRoleRepository(db)
+
+		// Act
+		roles, err := repo.GetByUserID(context.Background(), 1)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Empty(t, roles)
+	})
+
+	t.Run("GetByUserID - Database Error", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewRoleRepository(db)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		// Act
+		roles, err := repo.GetByUserID(context.Background(), 1)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, roles)
+	})
+}
@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SettingRepository interface {
+	GetAll(ctx context.Context) ([]*models.Setting, error)
+	GetByKey(ctx context.Context, key string) (*models.Setting, error)
+	Upsert(ctx context.Context, setting *models.Setting) error
+	Delete(ctx context.Context, key string) (bool, error)
+}
+
+type settingRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewSettingRepository(db *gorm.DB) SettingRepository {
+	return &settingRepositoryImpl{db: db}
+}
+
+// GetAll returns every setting ordered by key
+func (repo *settingRepositoryImpl) GetAll(ctx context.Context) ([]*models.Setting, error) {
+	var settings []*models.Setting
+	if err := repo.db.WithContext(ctx).Order(clause.OrderByColumn{Column: clause.Column{Name: "key"}}).Find(&settings).Error; err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to fetch settings: %v", err)
+		return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to fetch settings", err)
+	}
+	return settings, nil
+}
+
+func (repo *settingRepositoryImpl) GetByKey(ctx context.Context, key string) (*models.Setting, error) {
+	var setting models.Setting
+	if err := repo.db.WithContext(ctx).Where(settingKeyIs(key)).First(&setting).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.ErrNotFound, 1001, "Setting not found")
+		}
+		logger.WithContext(ctx).Errorf("DB error: failed to fetch setting %s: %v", key, err)
+		return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to fetch setting", err)
+	}
+	return &setting, nil
+}
+
+// Upsert creates the setting or replaces the value and type of the existing one with the same key
+func (repo *settingRepositoryImpl) Upsert(ctx context.Context, setting *models.Setting) error {
+	err := repo.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "type", "updated_at"}),
+	}).Create(setting).Error
+	if err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to save setting %s: %v", setting.Key, err)
+		return apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to save setting", err)
+	}
+	return nil
+}
+
+// Delete removes the setting and reports whether it existed
+func (repo *settingRepositoryImpl) Delete(ctx context.Context, key string) (bool, error) {
+	result := repo.db.WithContext(ctx).Where(settingKeyIs(key)).Delete(&models.Setting{})
+	if result.Error != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to delete setting %s: %v", key, result.Error)
+		return false, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to delete setting", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// settingKeyIs matches the setting with the key. key is a reserved word in MySQL, so the column is left to
+// the dialect to quote; unlike a struct condition, an empty key still matches only the setting with that key
+func settingKeyIs(key string) clause.Expression {
+	return clause.Eq{Column: clause.Column{Name: "key"}, Value: key}
+}
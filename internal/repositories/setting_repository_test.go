@@ -0,0 +1,123 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupSettingTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Setting{}))
+	return db
+}
+
+func TestSettingRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Upsert - Creates And Replaces", func(t *testing.T) {
+		repo := repositories.NewSettingRepository(setupSettingTestDB(t))
+
+		require.NoError(t, repo.Upsert(ctx, &models.Setting{Key: "login.max_attempts", Value: "5", Type: "int", UpdatedAt: time.Now()}))
+		require.NoError(t, repo.Upsert(ctx, &models.Setting{Key: "maintenance.enabled", Value: "false", Type: "bool", UpdatedAt: time.Now()}))
+		require.NoError(t, repo.Upsert(ctx, &models.Setting{Key: "login.max_attempts", Value: "banner", Type: "string", UpdatedAt: time.Now()}))
+
+		settings, err := repo.GetAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, settings, 2)
+		assert.Equal(t, "login.max_attempts", settings[0].Key)
+		assert.Equal(t, "banner", settings[0].Value)
+		assert.Equal(t, "string", settings[0].Type)
+		assert.Equal(t, "maintenance.enabled", settings[1].Key)
+	})
+
+	t.Run("GetByKey", func(t *testing.T) {
+		repo := repositories.NewSettingRepository(setupSettingTestDB(t))
+		require.NoError(t, repo.Upsert(ctx, &models.Setting{Key: "site.name", Value: "CMS", Type: "string"}))
+
+		setting, err := repo.GetByKey(ctx, "site.name")
+		require.NoError(t, err)
+		assert.Equal(t, "CMS", setting.Value)
+
+		setting, err = repo.GetByKey(ctx, "missing")
+		assert.Nil(t, setting)
+		appErr, ok := apperror.ToAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperror.ErrNotFound, appErr.Code)
+	})
+
+	t.Run("Delete - Reports Whether The Setting Existed", func(t *testing.T) {
+		repo := repositories.NewSettingRepository(setupSettingTestDB(t))
+		require.NoError(t, repo.Upsert(ctx, &models.Setting{Key: "site.name", Value: "CMS", Type: "string"}))
+
+		deleted, err := repo.Delete(ctx, "site.name")
+		require.NoError(t, err)
+		assert.True(t, deleted)
+
+		deleted, err = repo.Delete(ctx, "site.name")
+		require.NoError(t, err)
+		assert.False(t, deleted)
+	})
+
+	t.Run("Database Error", func(t *testing.T) {
+		db := setupSettingTestDB(t)
+		repo := repositories.NewSettingRepository(db)
+		require.NoError(t, db.Migrator().DropTable(&models.Setting{}))
+
+		_, err := repo.GetAll(ctx)
+		assert.Error(t, err)
+		_, err = repo.GetByKey(ctx, "site.name")
+		assert.Error(t, err)
+		assert.Error(t, repo.Upsert(ctx, &models.Setting{Key: "site.name", Value: "CMS", Type: "string"}))
+		_, err = repo.Delete(ctx, "site.name")
+		assert.Error(t, err)
+	})
+}
+
+// statementRecorder is a gorm logger keeping the SQL of every statement
+type statementRecorder struct {
+	logger.Interface
+	statements []string
+}
+
+func (r *statementRecorder) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, _ := fc()
+	r.statements = append(r.statements, sql)
+}
+
+func TestSettingRepository_QuotesKeyForTheDialect(t *testing.T) {
+	ctx := context.Background()
+	recorder := &statementRecorder{Interface: logger.Discard}
+	// Nothing is sent to the server in a dry run without transactions, so none needs to be running
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 port=1"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 recorder,
+	})
+	require.NoError(t, err)
+	repo := repositories.NewSettingRepository(db)
+
+	_, _ = repo.GetAll(ctx)
+	_, _ = repo.GetByKey(ctx, "site.name")
+	_, _ = repo.Delete(ctx, "site.name")
+
+	require.Len(t, recorder.statements, 3)
+	assert.Contains(t, recorder.statements[0], `ORDER BY "key"`)
+	assert.Contains(t, recorder.statements[1], `WHERE "key" = 'site.name'`)
+	assert.Contains(t, recorder.statements[2], `WHERE "key" = 'site.name'`)
+	for _, statement := range recorder.statements {
+		assert.NotContains(t, statement, "`")
+	}
+}
@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserLoginIPRepository interface {
+	Exists(ctx context.Context, userID uint, ipAddress string) (bool, error)
+	CountByUserID(ctx context.Context, userID uint) (int64, error)
+	Upsert(ctx context.Context, loginIP *models.UserLoginIP) error
+}
+
+type userLoginIPRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewUserLoginIPRepository(db *gorm.DB) UserLoginIPRepository {
+	return &userLoginIPRepositoryImpl{db: db}
+}
+
+// Exists reports whether the user has logged in from ipAddress before
+func (repo *userLoginIPRepositoryImpl) Exists(ctx context.Context, userID uint, ipAddress string) (bool, error) {
+	var count int64
+	if err := repo.db.WithContext(ctx).Model(&models.UserLoginIP{}).Where("user_id = ? AND ip_address = ?", userID, ipAddress).Count(&count).Error; err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to check login IP of user ID %d: %v", userID, err)
+		return false, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to check login IP", err)
+	}
+	return count > 0, nil
+}
+
+// CountByUserID returns the number of IP addresses the user has logged in from
+func (repo *userLoginIPRepositoryImpl) CountByUserID(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	if err := repo.db.WithContext(ctx).Model(&models.UserLoginIP{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to count login IPs of user ID %d: %v", userID, err)
+		return 0, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to count login IPs", err)
+	}
+	return count, nil
+}
+
+// Upsert records the login IP, or only moves LastSeenAt forward if the user already logged in from it
+func (repo *userLoginIPRepositoryImpl) Upsert(ctx context.Context, loginIP *models.UserLoginIP) error {
+	err := repo.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "ip_address"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_seen_at"}),
+	}).Create(loginIP).Error
+	if err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to save login IP of user ID %d: %v", loginIP.UserID, err)
+		return apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to save login IP", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupUserLoginIPTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.UserLoginIP{}))
+	return db
+}
+
+func TestUserLoginIPRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Upsert - Records And Touches", func(t *testing.T) {
+		db := setupUserLoginIPTestDB(t)
+		repo := repositories.NewUserLoginIPRepository(db)
+		first := time.Date(2025, time.March, 5, 14, 30, 0, 0, time.UTC)
+		later := first.Add(time.Hour)
+
+		require.NoError(t, repo.Upsert(ctx, &models.UserLoginIP{UserID: 1, IpAddress: "10.0.0.1", FirstSeenAt: first, LastSeenAt: first}))
+		require.NoError(t, repo.Upsert(ctx, &models.UserLoginIP{UserID: 1, IpAddress: "10.0.0.1", FirstSeenAt: later, LastSeenAt: later}))
+
+		var loginIPs []models.UserLoginIP
+		require.NoError(t, db.Find(&loginIPs).Error)
+		require.Len(t, loginIPs, 1)
+		assert.True(t, first.Equal(loginIPs[0].FirstSeenAt))
+		assert.True(t, later.Equal(loginIPs[0].LastSeenAt))
+	})
+
+	t.Run("Exists And CountByUserID", func(t *testing.T) {
+		repo := repositories.NewUserLoginIPRepository(setupUserLoginIPTestDB(t))
+		now := time.Now()
+		require.NoError(t, repo.Upsert(ctx, &models.UserLoginIP{UserID: 1, IpAddress: "10.0.0.1", FirstSeenAt: now, LastSeenAt: now}))
+		require.NoError(t, repo.Upsert(ctx, &models.UserLoginIP{UserID: 1, IpAddress: "10.0.0.2", FirstSeenAt: now, LastSeenAt: now}))
+		require.NoError(t, repo.Upsert(ctx, &models.UserLoginIP{UserID: 2, IpAddress: "10.0.0.3", FirstSeenAt: now, LastSeenAt: now}))
+
+		known, err := repo.Exists(ctx, 1, "10.0.0.2")
+		require.NoError(t, err)
+		assert.True(t, known)
+		// Addresses are known per user
+		known, err = repo.Exists(ctx, 1, "10.0.0.3")
+		require.NoError(t, err)
+		assert.False(t, known)
+
+		count, err := repo.CountByUserID(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+		count, err = repo.CountByUserID(ctx, 3)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
+	t.Run("Database Error", func(t *testing.T) {
+		db := setupUserLoginIPTestDB(t)
+		repo := repositories.NewUserLoginIPRepository(db)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		_, err = repo.Exists(ctx, 1, "10.0.0.1")
+		assert.Error(t, err)
+		_, err = repo.CountByUserID(ctx, 1)
+		assert.Error(t, err)
+		assert.Error(t, repo.Upsert(ctx, &models.UserLoginIP{UserID: 1, IpAddress: "10.0.0.1"}))
+	})
+}
@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserPreferenceRepository interface {
+	GetByUserID(ctx context.Context, userID uint) (*models.UserPreference, error)
+	CreateIfMissing(ctx context.Context, preference *models.UserPreference) error
+	Upsert(ctx context.Context, preference *models.UserPreference) error
+}
+
+type userPreferenceRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewUserPreferenceRepository(db *gorm.DB) UserPreferenceRepository {
+	return &userPreferenceRepositoryImpl{db: db}
+}
+
+// GetByUserID returns the preferences of the user. It reads the primary, as the preferences are read back right
+// after the defaults are saved
+func (repo *userPreferenceRepositoryImpl) GetByUserID(ctx context.Context, userID uint) (*models.UserPreference, error) {
+	var preference models.UserPreference
+	if err := onPrimary(repo.db.WithContext(ctx)).Where("user_id = ?", userID).First(&preference).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.ErrNotFound, 1001, "Preferences not found")
+		}
+		logger.WithContext(ctx).Errorf("DB error: failed to fetch preferences of user ID %d: %v", userID, err)
+		return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to fetch preferences", err)
+	}
+	return &preference, nil
+}
+
+// CreateIfMissing saves the preferences unless the user already has some, which are left untouched
+func (repo *userPreferenceRepositoryImpl) CreateIfMissing(ctx context.Context, preference *models.UserPreference) error {
+	err := repo.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoNothing: true,
+	}).Create(preference).Error
+	if err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to create preferences of user ID %d: %v", preference.UserID, err)
+		return apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to create preferences", err)
+	}
+	return nil
+}
+
+// Upsert creates the preferences or replaces every value of the existing ones
+func (repo *userPreferenceRepositoryImpl) Upsert(ctx context.Context, preference *models.UserPreference) error {
+	err := repo.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"timezone", "locale", "email_notifications", "theme", "updated_at"}),
+	}).Create(preference).Error
+	if err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to save preferences of user ID %d: %v", preference.UserID, err)
+		return apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to save preferences", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupUserPreferenceTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.UserPreference{}))
+	return db
+}
+
+func TestUserPreferenceRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("GetByUserID - Not Found", func(t *testing.T) {
+		repo := repositories.NewUserPreferenceRepository(setupUserPreferenceTestDB(t))
+
+		_, err := repo.GetByUserID(ctx, 1)
+
+		appErr, ok := apperror.ToAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperror.ErrNotFound, appErr.Code)
+	})
+
+	t.Run("CreateIfMissing - Keeps Existing", func(t *testing.T) {
+		repo := repositories.NewUserPreferenceRepository(setupUserPreferenceTestDB(t))
+
+		require.NoError(t, repo.CreateIfMissing(ctx, &models.UserPreference{UserID: 1, Timezone: "Asia/Tokyo", Locale: "vi", EmailNotifications: false, Theme: "dark", UpdatedAt: time.Now()}))
+		require.NoError(t, repo.CreateIfMissing(ctx, &models.UserPreference{UserID: 1, Timezone: "UTC", Locale: "en", EmailNotifications: true, Theme: "system", UpdatedAt: time.Now()}))
+
+		preference, err := repo.GetByUserID(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "Asia/Tokyo", preference.Timezone)
+		assert.Equal(t, "vi", preference.Locale)
+		assert.False(t, preference.EmailNotifications)
+		assert.Equal(t, "dark", preference.Theme)
+	})
+
+	t.Run("Upsert - Creates And Replaces", func(t *testing.T) {
+		repo := repositories.NewUserPreferenceRepository(setupUserPreferenceTestDB(t))
+
+		require.NoError(t, repo.Upsert(ctx, &models.UserPreference{UserID: 1, Timezone: "UTC", Locale: "en", EmailNotifications: true, Theme: "system", UpdatedAt: time.Now()}))
+		require.NoError(t, repo.Upsert(ctx, &models.UserPreference{UserID: 2, Timezone: "UTC", Locale: "en", EmailNotifications: true, Theme: "light", UpdatedAt: time.Now()}))
+		require.NoError(t, repo.Upsert(ctx, &models.UserPreference{UserID: 1, Timezone: "Europe/Paris", Locale: "vi", EmailNotifications: false, Theme: "dark", UpdatedAt: time.Now()}))
+
+		preference, err := repo.GetByUserID(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "Europe/Paris", preference.Timezone)
+		assert.Equal(t, "vi", preference.Locale)
+		assert.False(t, preference.EmailNotifications)
+		assert.Equal(t, "dark", preference.Theme)
+		other, err := repo.GetByUserID(ctx, 2)
+		require.NoError(t, err)
+		assert.Equal(t, "light", other.Theme)
+	})
+
+	t.Run("Database Error", func(t *testing.T) {
+		db := setupUserPreferenceTestDB(t)
+		repo := repositories.NewUserPreferenceRepository(db)
+		require.NoError(t, db.Migrator().DropTable(&models.UserPreference{}))
+
+		_, err := repo.GetByUserID(ctx, 1)
+		assert.Error(t, err)
+		assert.Error(t, repo.CreateIfMissing(ctx, &models.UserPreference{UserID: 1}))
+		assert.Error(t, repo.Upsert(ctx, &models.UserPreference{UserID: 1}))
+	})
+}
@@ -3,59 +3,171 @@ package repositories
 import (
 	"context"
 	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
-	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
 	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
 	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// EXISTING_EMAILS_BATCH_SIZE bounds the number of emails in one IN list of FindExistingEmails
+const EXISTING_EMAILS_BATCH_SIZE = 500
+
+// UserSortFields lists the columns users can be sorted by
+var UserSortFields = []string{"id", "name", "email", "gender", "created_at", "updated_at"}
+
 type UserRepository interface {
 	GetAll(ctx context.Context) ([]*models.User, error)
 	GetByID(ctx context.Context, id uint) (*models.User, error)
+	GetByIDWithRoles(ctx context.Context, id uint) (*models.User, error)
+	GetByIDs(ctx context.Context, ids []uint) ([]*models.User, error)
+	GetByIDUnscoped(ctx context.Context, id uint) (*models.User, error)
 	Create(ctx context.Context, user *models.User) (*models.User, error)
 	CreateWithTx(ctx context.Context, tx *gorm.DB, user *models.User) (*models.User, error)
+	CreateUsers(ctx context.Context, users []*models.User, batchSize int) error
+	FindExistingEmails(ctx context.Context, emails []string) ([]string, error)
 	Update(ctx context.Context, user *models.User) error
+	UpdateIfToken(ctx context.Context, user *models.User, token string) (bool, error)
+	UpdateLastLogin(ctx context.Context, user *models.User) error
+	UpdatePasswordIfUnchanged(ctx context.Context, user *models.User, oldHash string) (bool, error)
+	UpdateStatus(ctx context.Context, user *models.User, fromStatus string) (bool, error)
+	FindPendingDeletion(ctx context.Context, requestedBefore time.Time, limit int) ([]*models.User, error)
+	Anonymize(ctx context.Context, user *models.User) (bool, error)
+	UpdatePendingEmail(ctx context.Context, user *models.User) error
+	ConfirmEmailChange(ctx context.Context, user *models.User, token string) (bool, error)
 	Delete(ctx context.Context, userId uint) error
+	DeleteUsers(ctx context.Context, ids []uint) ([]uint, error)
+	Restore(ctx context.Context, userId uint) error
+	AssignRoles(ctx context.Context, userID uint, roleIDs []uint) error
+	AssignRolesWithTx(ctx context.Context, tx *gorm.DB, userID uint, roleIDs []uint) error
+	FindMissingRolesWithTx(ctx context.Context, tx *gorm.DB, roleIDs []uint) ([]uint, error)
+	RemoveRoles(ctx context.Context, userID uint, roleIDs []uint) error
 	FindByField(ctx context.Context, field string, value string) (*models.User, error)
-	GetUsers(ctx context.Context, page int, limit int) (*dto.Pagination[*models.User], error)
+	GetUsers(ctx context.Context, opts dto.ListOptions, filter dto.UserFilterInput) (*dto.Pagination[*models.User], error)
+	GetRecentlyActive(ctx context.Context, limit int) ([]*models.User, error)
+	Search(ctx context.Context, query string, limit int) ([]*models.User, error)
+	IterateUsers(ctx context.Context, filter dto.UserFilterInput, batchSize int, fn func(users []*models.User) error) error
 	BeginTx(ctx context.Context) (*gorm.DB, error)
 }
 
 type userRepositoryImpl struct {
-	db *gorm.DB
+	BaseRepository[models.User]
 }
 
 func NewUserRepository(db *gorm.DB) UserRepository {
-	return &userRepositoryImpl{db: db}
+	// Users are read by ID or token to be saved back, cached or checked, so single user lookups go to the primary.
+	// Listings, searches and exports read the replicas
+	return &userRepositoryImpl{BaseRepository: NewBaseRepository[models.User](db, "user", "name", "email", "token", "email_change_token").ReadFromPrimary()}
 }
 
-func (repo *userRepositoryImpl) GetUsers(ctx context.Context, page, limit int) (*dto.Pagination[*models.User], error) {
-	var totalRows int64
-	offset := (page - 1) * limit
-	db := repo.db.WithContext(ctx)
+// GetUsers returns a page of users matching filter, sorted by opts.SortBy.
+// The search term matches name or email; an unknown or empty sort field falls back to id.
+// Soft-deleted users are only included when filter.IncludeDeleted is set.
+// The page is read by offset or after opts.Cursor, see paginate.
+func (repo *userRepositoryImpl) GetUsers(ctx context.Context, opts dto.ListOptions, filter dto.UserFilterInput) (*dto.Pagination[*models.User], error) {
+	query := filterUsers(repo.db.WithContext(ctx).Model(&models.User{}), filter)
 
-	if err := db.Model(&models.User{}).Count(&totalRows).Error; err != nil {
-		logger.WithContext(ctx).Errorf("DB error: failed to count users: %v", err)
-		return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to count users", err)
+	sortBy := opts.SortBy
+	if !slices.Contains(UserSortFields, sortBy) {
+		sortBy = "id"
 	}
+	return repo.Paginate(ctx, query, opts, sortBy)
+}
 
+// IterateUsers calls fn with the users matching filter in batches of batchSize, in ID order.
+// Batches are read by ID, so only one batch is held in memory at a time. An error from fn stops the iteration and is returned.
+func (repo *userRepositoryImpl) IterateUsers(ctx context.Context, filter dto.UserFilterInput, batchSize int, fn func(users []*models.User) error) error {
 	var users []*models.User
-	if err := db.Offset(offset).Limit(limit).Order("id DESC").Find(&users).Error; err != nil {
-		logger.WithContext(ctx).Errorf("DB error: failed to fetch users: %v", err)
-		return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to fetch users", err)
+	var fnErr error
+	err := filterUsers(repo.db.WithContext(ctx), filter).FindInBatches(&users, batchSize, func(tx *gorm.DB, batch int) error {
+		fnErr = fn(users)
+		return fnErr
+	}).Error
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to iterate users: %v", err)
+		return apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to fetch users", err)
 	}
+	return nil
+}
 
-	pagination := &dto.Pagination[*models.User]{
-		Page:       page,
-		Limit:      limit,
-		TotalItems: int(totalRows),
-		TotalPages: utils.CalculateTotalPages(totalRows, limit),
-		Data:       users,
+// filterUsers applies the filters of the user list to query
+func filterUsers(query *gorm.DB, filter dto.UserFilterInput) *gorm.DB {
+	if filter.IncludeDeleted {
+		query = query.Unscoped()
 	}
-	return pagination, nil
+	if filter.Gender != nil {
+		query = query.Where("gender = ?", *filter.Gender)
+	}
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		pattern := "%" + escapeLike(search) + "%"
+		query = query.Where("(name LIKE ? ESCAPE '!' OR email LIKE ? ESCAPE '!')", pattern, pattern)
+	}
+	return query
+}
+
+// Search returns up to limit users whose email equals or starts with query, whose ID equals query
+// or whose name contains it. Results are ordered by relevance: exact email match first, then exact ID,
+// then email prefix, then name match, ties broken by name and ID.
+// Soft-deleted users are excluded. The name is matched anywhere, which no index can serve, and it is OR-ed
+// with the email and ID conditions, so every search scans the whole users table.
+func (repo *userRepositoryImpl) Search(ctx context.Context, query string, limit int) ([]*models.User, error) {
+	query = strings.TrimSpace(query)
+	email := strings.ToLower(query)
+	// An ID that cannot exist keeps the query shape the same when query is not a number
+	id, err := strconv.ParseUint(query, 10, 64)
+	if err != nil {
+		id = 0
+	}
+
+	var users []*models.User
+	err = repo.db.WithContext(ctx).
+		Where("email = ? OR id = ? OR email LIKE ? ESCAPE '!' OR name LIKE ? ESCAPE '!'", email, id, escapeLike(email)+"%", "%"+escapeLike(query)+"%").
+		// A single ORDER BY expression, as gorm drops an expression when more columns are added
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "CASE WHEN email = ? THEN 0 WHEN id = ? THEN 1 WHEN email LIKE ? ESCAPE '!' THEN 2 ELSE 3 END, name ASC, id ASC",
+			Vars: []any{email, id, escapeLike(email) + "%"},
+		}}).
+		Limit(limit).
+		Find(&users).Error
+	if err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to search users: %v", err)
+		return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to search users", err)
+	}
+	return users, nil
+}
+
+// escapeLike escapes the LIKE wildcards in value using '!' as the escape character
+func escapeLike(value string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(value)
+}
+
+// GetRecentlyActive returns up to limit users with their roles and preferences, ordered by their latest
+// refresh token activity, most recent first. Users without a refresh token are not included.
+func (repo *userRepositoryImpl) GetRecentlyActive(ctx context.Context, limit int) ([]*models.User, error) {
+	var users []*models.User
+	err := repo.db.WithContext(ctx).
+		Preload("Roles").
+		Preload("Preferences").
+		Joins("JOIN refresh_tokens ON refresh_tokens.user_id = users.id AND refresh_tokens.deleted_at IS NULL").
+		Group("users.id").
+		Order("MAX(refresh_tokens.updated_at) DESC").
+		Limit(limit).
+		Find(&users).Error
+	if err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to fetch recently active users: %v", err)
+		return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to fetch recently active users", err)
+	}
+	return users, nil
 }
 
 func (repo *userRepositoryImpl) GetAll(ctx context.Context) ([]*models.User, error) {
@@ -67,71 +179,386 @@ func (repo *userRepositoryImpl) GetAll(ctx context.Context) ([]*models.User, err
 	return users, nil
 }
 
-func (repo *userRepositoryImpl) GetByID(ctx context.Context, id uint) (*models.User, error) {
+// GetByIDWithRoles returns the user with its roles and preferences preloaded, one additional query each.
+// It reads the primary, as the result is cached
+func (repo *userRepositoryImpl) GetByIDWithRoles(ctx context.Context, id uint) (*models.User, error) {
 	var user models.User
-	if err := repo.db.WithContext(ctx).First(&user, id).Error; err != nil {
+	if err := onPrimary(repo.db.WithContext(ctx)).Preload("Roles").Preload("Preferences").First(&user, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, apperror.New(apperror.ErrNotFound, 1001, "User not found")
 		}
-		logger.WithContext(ctx).Errorf("DB error: failed to fetch user by id %d: %v", id, err)
+		logger.WithContext(ctx).Errorf("DB error: failed to fetch user with roles by id %d: %v", id, err)
 		return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to fetch user", err)
 	}
 	return &user, nil
 }
 
-func (repo *userRepositoryImpl) Create(ctx context.Context, user *models.User) (*models.User, error) {
-	if err := repo.db.WithContext(ctx).Create(user).Error; err != nil {
-		logger.WithContext(ctx).Errorf("DB error: failed to create user: %v", err)
-		return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to create user", err)
+// GetByIDs returns the users with the given IDs and their roles in two queries, whatever the number of IDs.
+// Missing and soft-deleted users are left out and the order is unspecified; see UsersByID to look them up
+func (repo *userRepositoryImpl) GetByIDs(ctx context.Context, ids []uint) ([]*models.User, error) {
+	users := []*models.User{}
+	if len(ids) == 0 {
+		return users, nil
 	}
-	return user, nil
+	if err := repo.db.WithContext(ctx).Preload("Roles").Where("id IN ?", ids).Find(&users).Error; err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to fetch users by ids %v: %v", ids, err)
+		return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to fetch users", err)
+	}
+	return users, nil
+}
+
+// UsersByID indexes users by their ID
+func UsersByID(users []*models.User) map[uint]*models.User {
+	byID := make(map[uint]*models.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+	return byID
+}
+
+// GetByIDUnscoped returns the user with its roles, including a soft-deleted one. It reads the primary, as the result is cached
+func (repo *userRepositoryImpl) GetByIDUnscoped(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := onPrimary(repo.db.WithContext(ctx)).Unscoped().Preload("Roles").First(&user, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(apperror.ErrNotFound, 1001, "User not found")
+		}
+		logger.WithContext(ctx).Errorf("DB error: failed to fetch unscoped user by id %d: %v", id, err)
+		return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to fetch user", err)
+	}
+	return &user, nil
 }
 
 func (repo *userRepositoryImpl) CreateWithTx(ctx context.Context, tx *gorm.DB, user *models.User) (*models.User, error) {
 	if err := tx.WithContext(ctx).Create(user).Error; err != nil {
+		if dupErr := duplicateKeyError(err); dupErr != nil {
+			logger.WithContext(ctx).Warnf("Rejected user with a duplicate %s", dupErr.Fields[0].Field)
+			return nil, dupErr
+		}
 		logger.WithContext(ctx).Errorf("DB error: failed to create user with tx: %v", err)
 		return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to create user", err)
 	}
 	return user, nil
 }
 
-func (repo *userRepositoryImpl) Update(ctx context.Context, user *models.User) error {
-	if err := repo.db.WithContext(ctx).Save(user).Error; err != nil {
-		logger.WithContext(ctx).Errorf("DB error: failed to update user id %d: %v", user.ID, err)
-		return apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to update user", err)
+// CreateUsers inserts users in batches of batchSize within one transaction, so either all or none are created
+func (repo *userRepositoryImpl) CreateUsers(ctx context.Context, users []*models.User, batchSize int) error {
+	if len(users) == 0 {
+		return nil
+	}
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(users, batchSize).Error
+	})
+	if err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to create %d users: %v", len(users), err)
+		return apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to create users", err)
 	}
 	return nil
 }
 
-func (repo *userRepositoryImpl) Delete(ctx context.Context, userId uint) error {
-	var user models.User
-	if err := repo.db.WithContext(ctx).Delete(&user, userId).Error; err != nil {
-		logger.WithContext(ctx).Errorf("DB error: failed to delete user id %d: %v", userId, err)
-		return apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to delete user", err)
+// FindExistingEmails returns which of emails are already registered, including by soft-deleted users,
+// as their unique index still holds the email. Emails are looked up EXISTING_EMAILS_BATCH_SIZE at a time
+func (repo *userRepositoryImpl) FindExistingEmails(ctx context.Context, emails []string) ([]string, error) {
+	existing := []string{}
+	for batch := range slices.Chunk(emails, EXISTING_EMAILS_BATCH_SIZE) {
+		var found []string
+		if err := repo.db.WithContext(ctx).Unscoped().Model(&models.User{}).Where("email IN ?", batch).Pluck("email", &found).Error; err != nil {
+			logger.WithContext(ctx).Errorf("DB error: failed to look up existing emails: %v", err)
+			return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to fetch users", err)
+		}
+		existing = append(existing, found...)
+	}
+	return existing, nil
+}
+
+// DeleteUsers soft-deletes the users with the given IDs in a single statement and returns the IDs
+// that were deleted. IDs of missing or already deleted users are left out
+func (repo *userRepositoryImpl) DeleteUsers(ctx context.Context, ids []uint) ([]uint, error) {
+	var deleted []uint
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the rows so the returned IDs match exactly what the delete below affects
+		if err := tx.Model(&models.User{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", ids).
+			Order("id").
+			Pluck("id", &deleted).Error; err != nil {
+			return err
+		}
+		if len(deleted) == 0 {
+			return nil
+		}
+		return tx.Delete(&models.User{}, deleted).Error
+	})
+	if err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to delete users %v: %v", ids, err)
+		return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to delete users", err)
+	}
+	return deleted, nil
+}
+
+// UpdateIfToken saves every field of user, but only if its token column still holds token, in a single
+// conditional UPDATE. It returns false without changing anything when the token has been replaced or
+// consumed meanwhile, so of several concurrent requests holding the same token only one succeeds
+func (repo *userRepositoryImpl) UpdateIfToken(ctx context.Context, user *models.User, token string) (bool, error) {
+	result := repo.db.WithContext(ctx).
+		Model(user).
+		Where("token = ?", token).
+		Select("*").
+		Omit(clause.Associations).
+		Updates(user)
+	if result.Error != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to update user id %d by token: %v", user.ID, result.Error)
+		return false, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to update user", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// UpdateLastLogin saves the last login time and IP address of the user and nothing else. Updated_at is left
+// as it is too, a login does not change the user. The rest of user is not written, so a login racing
+// with a profile update or password change cannot undo it
+func (repo *userRepositoryImpl) UpdateLastLogin(ctx context.Context, user *models.User) error {
+	err := repo.db.WithContext(ctx).
+		Model(user).
+		Select("last_login_at", "last_login_ip").
+		Omit("updated_at").
+		Updates(user).Error
+	if err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to update last login of user id %d: %v", user.ID, err)
+		return apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to update last login", err)
+	}
+	return nil
+}
+
+// UpdatePasswordIfUnchanged saves the password hash of the user and nothing else, but only if the stored hash is
+// still oldHash, in a single conditional UPDATE. Updated_at is left as it is, a new hash of the same password does
+// not change the user. It returns false without changing anything when the password was changed meanwhile, so the
+// new hash of an old password cannot undo the change, and the rest of user is never written back stale
+func (repo *userRepositoryImpl) UpdatePasswordIfUnchanged(ctx context.Context, user *models.User, oldHash string) (bool, error) {
+	result := repo.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ? AND password = ?", user.ID, oldHash).
+		UpdateColumn("password", user.Password)
+	if result.Error != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to update password of user id %d: %v", user.ID, result.Error)
+		return false, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to update password", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// UpdateStatus saves the status and deletion request time of the user, but only if its status is still
+// fromStatus, in a single conditional UPDATE. It returns false without changing anything otherwise, so a
+// reactivation cannot race with the anonymization of the same account
+func (repo *userRepositoryImpl) UpdateStatus(ctx context.Context, user *models.User, fromStatus string) (bool, error) {
+	result := repo.db.WithContext(ctx).
+		Model(user).
+		Where("status = ?", fromStatus).
+		Select("status", "deletion_requested_at").
+		Updates(user)
+	if result.Error != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to update status of user id %d: %v", user.ID, result.Error)
+		return false, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to update user status", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// FindPendingDeletion returns up to limit accounts pending deletion that asked for it before requestedBefore,
+// oldest request first. Soft-deleted users are included, their personal data has to go as well
+func (repo *userRepositoryImpl) FindPendingDeletion(ctx context.Context, requestedBefore time.Time, limit int) ([]*models.User, error) {
+	var users []*models.User
+	err := repo.db.WithContext(ctx).
+		Unscoped().
+		Where("status = ? AND deletion_requested_at < ?", constants.USER_STATUS_PENDING_DELETION, requestedBefore).
+		Order("deletion_requested_at ASC, id ASC").
+		Limit(limit).
+		Find(&users).Error
+	if err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to fetch accounts pending deletion: %v", err)
+		return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to fetch accounts pending deletion", err)
+	}
+	return users, nil
+}
+
+// Anonymize overwrites the personal data of an account pending deletion with the values in user and removes
+// its sessions and login IP addresses, in one transaction. The row itself is kept so audit entries still
+// point to it. It returns false without changing anything if the account is no longer pending deletion
+func (repo *userRepositoryImpl) Anonymize(ctx context.Context, user *models.User) (bool, error) {
+	anonymized := false
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().
+			Model(user).
+			Where("status = ?", constants.USER_STATUS_PENDING_DELETION).
+			Select("email", "pending_email", "password", "name", "birthday", "address", "token", "expired_at", "token_purpose", "email_change_token", "email_change_expired_at", "avatar", "avatar_key", "last_login_ip", "status").
+			Updates(user)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.UserLoginIP{}).Error; err != nil {
+			return err
+		}
+		anonymized = true
+		return nil
+	})
+	if err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to anonymize user id %d: %v", user.ID, err)
+		return false, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to anonymize user", err)
+	}
+	return anonymized, nil
+}
+
+// UpdatePendingEmail saves the pending email of the user and the token confirming it, and nothing else
+func (repo *userRepositoryImpl) UpdatePendingEmail(ctx context.Context, user *models.User) error {
+	err := repo.db.WithContext(ctx).
+		Model(user).
+		Select("pending_email", "email_change_token", "email_change_expired_at").
+		Updates(user).Error
+	if err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to update pending email of user id %d: %v", user.ID, err)
+		return apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to update pending email", err)
 	}
 	return nil
 }
 
-func (repo *userRepositoryImpl) FindByField(ctx context.Context, field string, value string) (*models.User, error) {
-	allowedFields := map[string]bool{
-		"name":  true,
-		"email": true,
-		"token": true,
+// ConfirmEmailChange saves the email of the user and clears its pending email, but only if its email change
+// token is still token, in a single conditional UPDATE. It returns false without changing anything when the
+// token has been replaced or consumed meanwhile. If another account took the email meanwhile, the unique index
+// on the email rejects the UPDATE and ErrDuplicateEmail is returned
+func (repo *userRepositoryImpl) ConfirmEmailChange(ctx context.Context, user *models.User, token string) (bool, error) {
+	result := repo.db.WithContext(ctx).
+		Model(user).
+		Where("email_change_token = ?", token).
+		Select("email", "pending_email", "email_change_token", "email_change_expired_at").
+		Updates(user)
+	if dupErr := duplicateKeyError(result.Error); dupErr != nil {
+		logger.WithContext(ctx).Warnf("Email change of user id %d rejected, the email is already registered", user.ID)
+		return false, dupErr
+	}
+	if result.Error != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to confirm email change of user id %d: %v", user.ID, result.Error)
+		return false, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to confirm email change", result.Error)
 	}
+	return result.RowsAffected == 1, nil
+}
 
-	if !allowedFields[field] {
-		return nil, apperror.New(apperror.ErrBadRequest, 1002, "Invalid field")
+// Restore clears deleted_at of a soft-deleted user
+func (repo *userRepositoryImpl) Restore(ctx context.Context, userId uint) error {
+	err := repo.db.WithContext(ctx).Unscoped().
+		Model(&models.User{}).
+		Where("id = ?", userId).
+		Update("deleted_at", nil).Error
+	if err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to restore user id %d: %v", userId, err)
+		return apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to restore user", err)
 	}
+	return nil
+}
 
-	var user models.User
-	if err := repo.db.WithContext(ctx).Where(field+" = ?", value).First(&user).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, apperror.New(apperror.ErrUnauthorized, 1003, "User not found")
+// AssignRoles adds the roles to the user in one transaction. Roles the user already has are kept as they are.
+// If any of the roles does not exist nothing changes and an ErrNotFound error lists the missing IDs
+func (repo *userRepositoryImpl) AssignRoles(ctx context.Context, userID uint, roleIDs []uint) error {
+	roleIDs = slices.Compact(slices.Sorted(slices.Values(roleIDs)))
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := requireRoles(tx, roleIDs); err != nil {
+			return err
 		}
-		logger.WithContext(ctx).Errorf("DB error: failed to fetch user by field %s: %v", field, err)
-		return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to fetch user", err)
+		return insertUserRoles(tx, userID, roleIDs)
+	})
+	return roleChangeError(ctx, "assign roles to", userID, err)
+}
+
+// AssignRolesWithTx adds the roles to the user within tx, without checking that they exist; see FindMissingRolesWithTx
+func (repo *userRepositoryImpl) AssignRolesWithTx(ctx context.Context, tx *gorm.DB, userID uint, roleIDs []uint) error {
+	if len(roleIDs) == 0 {
+		return nil
 	}
-	return &user, nil
+	if err := insertUserRoles(tx.WithContext(ctx), userID, roleIDs); err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to assign roles to user id %d with tx: %v", userID, err)
+		return apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to change user roles", err)
+	}
+	return nil
+}
+
+// FindMissingRolesWithTx returns the IDs of roleIDs, sorted and without duplicates, that do not exist.
+// The existing roles are read with a shared lock so they cannot be deleted before tx ends
+func (repo *userRepositoryImpl) FindMissingRolesWithTx(ctx context.Context, tx *gorm.DB, roleIDs []uint) ([]uint, error) {
+	if len(roleIDs) == 0 {
+		return nil, nil
+	}
+	missing, err := missingRoles(tx.WithContext(ctx), slices.Compact(slices.Sorted(slices.Values(roleIDs))))
+	if err != nil {
+		logger.WithContext(ctx).Errorf("DB error: failed to look up roles %v with tx: %v", roleIDs, err)
+		return nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to fetch roles", err)
+	}
+	return missing, nil
+}
+
+// RemoveRoles takes the roles away from the user in one transaction. Roles the user does not have are ignored.
+// If any of the roles does not exist nothing changes and an ErrNotFound error lists the missing IDs
+func (repo *userRepositoryImpl) RemoveRoles(ctx context.Context, userID uint, roleIDs []uint) error {
+	roleIDs = slices.Compact(slices.Sorted(slices.Values(roleIDs)))
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := requireRoles(tx, roleIDs); err != nil {
+			return err
+		}
+		return tx.Exec("DELETE FROM user_roles WHERE user_id = ? AND role_id IN ?", userID, roleIDs).Error
+	})
+	return roleChangeError(ctx, "remove roles from", userID, err)
+}
+
+// requireRoles returns an ErrNotFound error listing the IDs of roleIDs, sorted and without duplicates, that do not exist.
+// The roles are read with a shared lock so they cannot be deleted before the transaction ends
+func requireRoles(tx *gorm.DB, roleIDs []uint) error {
+	missing, err := missingRoles(tx, roleIDs)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		ids := make([]string, len(missing))
+		for i, id := range missing {
+			ids[i] = strconv.FormatUint(uint64(id), 10)
+		}
+		return apperror.NewNotFoundError("Roles not found: " + strings.Join(ids, ", "))
+	}
+	return nil
+}
+
+// missingRoles returns the IDs of roleIDs that do not exist, in the order of roleIDs.
+// The existing roles are read with a shared lock
+func missingRoles(tx *gorm.DB, roleIDs []uint) ([]uint, error) {
+	var found []uint
+	if err := tx.Model(&models.Role{}).Clauses(clause.Locking{Strength: "SHARE"}).Where("id IN ?", roleIDs).Pluck("id", &found).Error; err != nil {
+		return nil, err
+	}
+	var missing []uint
+	for _, id := range roleIDs {
+		if !slices.Contains(found, id) {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
+
+// insertUserRoles links the user to the roles, skipping links that already exist
+func insertUserRoles(tx *gorm.DB, userID uint, roleIDs []uint) error {
+	rows := make([]map[string]any, len(roleIDs))
+	for i, roleID := range roleIDs {
+		rows[i] = map[string]any{"user_id": userID, "role_id": roleID}
+	}
+	return tx.Table("user_roles").Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+// roleChangeError passes ErrNotFound errors of requireRoles through and logs and wraps other errors
+func roleChangeError(ctx context.Context, action string, userID uint, err error) error {
+	if err == nil {
+		return nil
+	}
+	if appErr, ok := apperror.ToAppError(err); ok && appErr.Code == apperror.ErrNotFound {
+		return appErr
+	}
+	logger.WithContext(ctx).Errorf("DB error: failed to %s user id %d: %v", action, userID, err)
+	return apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to change user roles", err)
 }
 
 func (repo *userRepositoryImpl) BeginTx(ctx context.Context) (*gorm.DB, error) {
@@ -2,13 +2,18 @@ package repositories_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/vfa-khuongdv/golang-cms/internal/models"
 	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
@@ -20,7 +25,7 @@ func setupUserTestDB(t *testing.T) *gorm.DB {
 	require.NotNil(t, db)
 
 	// Auto-migrate the models
-	err = db.AutoMigrate(&models.User{})
+	err = db.AutoMigrate(&models.User{}, &models.Role{}, &models.UserPreference{})
 	require.NoError(t, err)
 
 	return db
@@ -100,6 +105,87 @@ func TestUserRepository(t *testing.T) {
 		assert.Nil(t, user)
 	})
 
+	t.Run("GetByIDWithRoles - Success", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		mockUser := &models.User{
+			Name:     "Admin User",
+			Email:    "admin@example.com",
+			Password: "password",
+			Gender:   1,
+			Roles:    []models.Role{{Name: "admin"}, {Name: "editor"}},
+		}
+		_, err := repo.Create(context.Background(), mockUser)
+		require.NoError(t, err)
+		require.NoError(t, db.Create(&models.UserPreference{UserID: mockUser.ID, Timezone: "Asia/Tokyo", Locale: "en", Theme: "dark"}).Error)
+
+		var queries int
+		require.NoError(t, db.Callback().Query().After("gorm:query").Register("count_queries", func(*gorm.DB) {
+			queries++
+		}))
+		defer db.Callback().Query().Remove("count_queries")
+
+		// Act
+		user, err := repo.GetByIDWithRoles(context.Background(), mockUser.ID)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, user.Roles, 2)
+		assert.ElementsMatch(t, []string{"admin", "editor"}, []string{user.Roles[0].Name, user.Roles[1].Name})
+		require.NotNil(t, user.Preferences)
+		assert.Equal(t, "Asia/Tokyo", user.Preferences.Timezone)
+		// The user, its role links, the roles and the preferences
+		assert.LessOrEqual(t, queries, 4, "roles should be preloaded instead of queried per role")
+	})
+
+	t.Run("GetByIDWithRoles - No Roles", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		mockUser := &models.User{Name: "Plain User", Email: "plain@example.com", Password: "password", Gender: 1}
+		_, err := repo.Create(context.Background(), mockUser)
+		require.NoError(t, err)
+
+		// Act
+		user, err := repo.GetByIDWithRoles(context.Background(), mockUser.ID)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Empty(t, user.Roles)
+	})
+
+	t.Run("GetByIDWithRoles - Not Found Error", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+
+		// Act
+		user, err := repo.GetByIDWithRoles(context.Background(), 999)
+
+		// Assert
+		assert.Nil(t, user)
+		appErr, ok := apperror.ToAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperror.ErrNotFound, appErr.Code)
+	})
+
+	t.Run("GetByIDWithRoles - Database Error", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		// Act
+		user, err := repo.GetByIDWithRoles(context.Background(), 1)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, user)
+	})
+
 	t.Run("Create - Success", func(t *testing.T) {
 		// Arrange
 		db := setupUserTestDB(t)
@@ -286,6 +372,356 @@ func TestUserRepository(t *testing.T) {
 		assert.Equal(t, "newpassword", updatedUser.Password)
 	})
 
+	t.Run("UpdateIfToken - Consumes The Token Once", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		token := "token-hash"
+		user := &models.User{Name: "Reset User", Email: "reset@example.com", Password: "password", Gender: 1, Token: &token}
+		_, err := repo.Create(context.Background(), user)
+		require.NoError(t, err)
+		first, err := repo.GetByID(context.Background(), user.ID)
+		require.NoError(t, err)
+		second, err := repo.GetByID(context.Background(), user.ID)
+		require.NoError(t, err)
+
+		// Act: two requests loaded the user with the token, both try to consume it
+		first.Password, first.Token = "first-password", nil
+		firstOK, err := repo.UpdateIfToken(context.Background(), first, token)
+		require.NoError(t, err)
+		second.Password, second.Token = "second-password", nil
+		secondOK, err := repo.UpdateIfToken(context.Background(), second, token)
+		require.NoError(t, err)
+
+		// Assert
+		assert.True(t, firstOK)
+		assert.False(t, secondOK)
+		updated, err := repo.GetByID(context.Background(), user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "first-password", updated.Password)
+		assert.Nil(t, updated.Token)
+	})
+
+	t.Run("UpdateIfToken - Database Error", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		// Act
+		ok, err := repo.UpdateIfToken(context.Background(), &models.User{ID: 1}, "token-hash")
+
+		// Assert
+		assert.Error(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("UpdateLastLogin - Only Writes The Last Login", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		user := &models.User{Name: "Login User", Email: "login@example.com", Password: "password", Gender: 1}
+		_, err := repo.Create(context.Background(), user)
+		require.NoError(t, err)
+		stored, err := repo.GetByID(context.Background(), user.ID)
+		require.NoError(t, err)
+
+		// Act: the in-memory user is stale, only the last login may reach the database
+		loginAt := time.Now().Add(time.Minute).Truncate(time.Second)
+		ip := "203.0.113.7"
+		user.Name, user.Password = "Stale Name", "stale-password"
+		user.LastLoginAt, user.LastLoginIP = &loginAt, &ip
+		err = repo.UpdateLastLogin(context.Background(), user)
+
+		// Assert
+		require.NoError(t, err)
+		updated, err := repo.GetByID(context.Background(), user.ID)
+		require.NoError(t, err)
+		require.NotNil(t, updated.LastLoginAt)
+		assert.True(t, loginAt.Equal(*updated.LastLoginAt))
+		assert.Equal(t, &ip, updated.LastLoginIP)
+		assert.Equal(t, "Login User", updated.Name)
+		assert.Equal(t, "password", updated.Password)
+		assert.True(t, stored.UpdatedAt.Equal(updated.UpdatedAt))
+	})
+
+	t.Run("UpdateLastLogin - Database Error", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		// Act
+		err = repo.UpdateLastLogin(context.Background(), &models.User{ID: 1})
+
+		// Assert
+		assert.Error(t, err)
+	})
+
+	t.Run("UpdatePasswordIfUnchanged - Only Writes The Password", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		user := &models.User{Name: "Rehash User", Email: "rehash@example.com", Password: "old-hash", Gender: 1}
+		_, err := repo.Create(context.Background(), user)
+		require.NoError(t, err)
+		stored, err := repo.GetByID(context.Background(), user.ID)
+		require.NoError(t, err)
+
+		// Act: the in-memory user is stale, only the password may reach the database
+		user.Name, user.Password = "Stale Name", "new-hash"
+		saved, err := repo.UpdatePasswordIfUnchanged(context.Background(), user, "old-hash")
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, saved)
+		updated, err := repo.GetByID(context.Background(), user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "new-hash", updated.Password)
+		assert.Equal(t, "Rehash User", updated.Name)
+		assert.True(t, stored.UpdatedAt.Equal(updated.UpdatedAt))
+	})
+
+	t.Run("UpdatePasswordIfUnchanged - Keeps A Changed Password", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		user := &models.User{Name: "Changed User", Email: "changed@example.com", Password: "changed-hash", Gender: 1}
+		_, err := repo.Create(context.Background(), user)
+		require.NoError(t, err)
+
+		// Act
+		user.Password = "rehash-of-old-password"
+		saved, err := repo.UpdatePasswordIfUnchanged(context.Background(), user, "old-hash")
+
+		// Assert
+		require.NoError(t, err)
+		assert.False(t, saved)
+		updated, err := repo.GetByID(context.Background(), user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "changed-hash", updated.Password)
+	})
+
+	t.Run("UpdatePasswordIfUnchanged - Database Error", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		// Act
+		saved, err := repo.UpdatePasswordIfUnchanged(context.Background(), &models.User{ID: 1, Password: "new-hash"}, "old-hash")
+
+		// Assert
+		assert.Error(t, err)
+		assert.False(t, saved)
+	})
+
+	t.Run("UpdateStatus - Only From The Given Status", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		user := &models.User{Name: "Status User", Email: "status@example.com", Password: "password", Gender: 1}
+		_, err := repo.Create(context.Background(), user)
+		require.NoError(t, err)
+		requestedAt := time.Now().Truncate(time.Second)
+
+		// Act
+		user.Status, user.DeletionRequestedAt = constants.USER_STATUS_PENDING_DELETION, &requestedAt
+		updated, err := repo.UpdateStatus(context.Background(), user, constants.USER_STATUS_ACTIVE)
+		require.NoError(t, err)
+		// The status is no longer active, so a second update from active changes nothing
+		user.Status, user.DeletionRequestedAt = constants.USER_STATUS_ANONYMIZED, nil
+		updatedAgain, err := repo.UpdateStatus(context.Background(), user, constants.USER_STATUS_ACTIVE)
+		require.NoError(t, err)
+
+		// Assert
+		assert.True(t, updated)
+		assert.False(t, updatedAgain)
+		stored, err := repo.GetByID(context.Background(), user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, constants.USER_STATUS_PENDING_DELETION, stored.Status)
+		require.NotNil(t, stored.DeletionRequestedAt)
+		assert.True(t, requestedAt.Equal(*stored.DeletionRequestedAt))
+	})
+
+	t.Run("FindPendingDeletion - Selects Expired Requests Only", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		now := time.Now()
+		requested := func(ago time.Duration) *time.Time {
+			at := now.Add(-ago)
+			return &at
+		}
+		users := []*models.User{
+			{Name: "Expired Newer", Email: "expired-newer@example.com", Status: constants.USER_STATUS_PENDING_DELETION, DeletionRequestedAt: requested(31 * 24 * time.Hour)},
+			{Name: "Expired Older", Email: "expired-older@example.com", Status: constants.USER_STATUS_PENDING_DELETION, DeletionRequestedAt: requested(40 * 24 * time.Hour)},
+			{Name: "Within Grace", Email: "within-grace@example.com", Status: constants.USER_STATUS_PENDING_DELETION, DeletionRequestedAt: requested(29 * 24 * time.Hour)},
+			{Name: "Active", Email: "active@example.com", Status: constants.USER_STATUS_ACTIVE},
+			{Name: "Anonymized", Email: "anonymized@example.com", Status: constants.USER_STATUS_ANONYMIZED, DeletionRequestedAt: requested(60 * 24 * time.Hour)},
+			{Name: "Soft Deleted", Email: "soft-deleted@example.com", Status: constants.USER_STATUS_PENDING_DELETION, DeletionRequestedAt: requested(35 * 24 * time.Hour)},
+		}
+		for _, user := range users {
+			user.Password, user.Gender = "password", 1
+			_, err := repo.Create(context.Background(), user)
+			require.NoError(t, err)
+		}
+		require.NoError(t, repo.Delete(context.Background(), users[5].ID))
+
+		// Act
+		found, err := repo.FindPendingDeletion(context.Background(), now.Add(-30*24*time.Hour), 10)
+		require.NoError(t, err)
+		limited, err := repo.FindPendingDeletion(context.Background(), now.Add(-30*24*time.Hour), 1)
+		require.NoError(t, err)
+
+		// Assert: oldest request first, soft-deleted users included
+		names := make([]string, len(found))
+		for i, user := range found {
+			names[i] = user.Name
+		}
+		assert.Equal(t, []string{"Expired Older", "Soft Deleted", "Expired Newer"}, names)
+		require.Len(t, limited, 1)
+		assert.Equal(t, "Expired Older", limited[0].Name)
+	})
+
+	t.Run("FindPendingDeletion - Database Error", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		// Act
+		users, err := repo.FindPendingDeletion(context.Background(), time.Now(), 10)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, users)
+	})
+
+	t.Run("Anonymize - Replaces Personal Data And Removes Sessions", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		require.NoError(t, db.AutoMigrate(&models.RefreshToken{}, &models.UserLoginIP{}))
+		repo := repositories.NewUserRepository(db)
+		address, ip := "1 Main Street", "203.0.113.7"
+		requestedAt := time.Now().Add(-31 * 24 * time.Hour)
+		user := &models.User{
+			Name: "Pending User", Email: "pending@example.com", Password: "password", Gender: 1,
+			Address: &address, LastLoginIP: &ip, Status: constants.USER_STATUS_PENDING_DELETION, DeletionRequestedAt: &requestedAt,
+		}
+		_, err := repo.Create(context.Background(), user)
+		require.NoError(t, err)
+		require.NoError(t, db.Create(&models.RefreshToken{RefreshToken: "token", IpAddress: ip, UserID: user.ID}).Error)
+		require.NoError(t, db.Delete(&models.RefreshToken{}, "user_id = ?", user.ID).Error)
+		require.NoError(t, db.Create(&models.UserLoginIP{UserID: user.ID, IpAddress: ip, FirstSeenAt: time.Now(), LastSeenAt: time.Now()}).Error)
+
+		// Act
+		anonymized, err := repo.Anonymize(context.Background(), &models.User{
+			ID: user.ID, Email: "deleted@deleted.invalid", Password: "!", Name: "Deleted user", Status: constants.USER_STATUS_ANONYMIZED,
+		})
+
+		// Assert
+		require.NoError(t, err)
+		assert.True(t, anonymized)
+		stored, err := repo.GetByID(context.Background(), user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "deleted@deleted.invalid", stored.Email)
+		assert.Equal(t, "Deleted user", stored.Name)
+		assert.Nil(t, stored.Address)
+		assert.Nil(t, stored.LastLoginIP)
+		assert.Equal(t, constants.USER_STATUS_ANONYMIZED, stored.Status)
+		var sessions, loginIPs int64
+		db.Unscoped().Model(&models.RefreshToken{}).Where("user_id = ?", user.ID).Count(&sessions)
+		db.Model(&models.UserLoginIP{}).Where("user_id = ?", user.ID).Count(&loginIPs)
+		assert.Zero(t, sessions)
+		assert.Zero(t, loginIPs)
+	})
+
+	t.Run("Anonymize - Skips Reactivated Accounts", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		require.NoError(t, db.AutoMigrate(&models.RefreshToken{}, &models.UserLoginIP{}))
+		repo := repositories.NewUserRepository(db)
+		user := &models.User{Name: "Active User", Email: "active@example.com", Password: "password", Gender: 1}
+		_, err := repo.Create(context.Background(), user)
+		require.NoError(t, err)
+
+		// Act
+		anonymized, err := repo.Anonymize(context.Background(), &models.User{
+			ID: user.ID, Email: "deleted@deleted.invalid", Password: "!", Name: "Deleted user", Status: constants.USER_STATUS_ANONYMIZED,
+		})
+
+		// Assert
+		require.NoError(t, err)
+		assert.False(t, anonymized)
+		stored, err := repo.GetByID(context.Background(), user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "active@example.com", stored.Email)
+	})
+
+	t.Run("UpdatePendingEmail And ConfirmEmailChange - Swap The Email Once", func(t *testing.T) {
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		user := &models.User{Name: "User", Email: "old@example.com", Password: "password", Gender: 1}
+		_, err := repo.Create(context.Background(), user)
+		require.NoError(t, err)
+		pendingEmail, token, expiredAt := "new@example.com", "token-hash", time.Now().Add(time.Hour).Unix()
+		user.Name = "Not Saved"
+		user.PendingEmail, user.EmailChangeToken, user.EmailChangeExpiredAt = &pendingEmail, &token, &expiredAt
+
+		require.NoError(t, repo.UpdatePendingEmail(context.Background(), user))
+
+		stored, err := repo.FindByField(context.Background(), "email_change_token", token)
+		require.NoError(t, err)
+		assert.Equal(t, "old@example.com", stored.Email)
+		assert.Equal(t, "User", stored.Name)
+		require.NotNil(t, stored.PendingEmail)
+		assert.Equal(t, pendingEmail, *stored.PendingEmail)
+
+		stored.Email = pendingEmail
+		stored.PendingEmail, stored.EmailChangeToken, stored.EmailChangeExpiredAt = nil, nil, nil
+		confirmed, err := repo.ConfirmEmailChange(context.Background(), stored, token)
+		require.NoError(t, err)
+		assert.True(t, confirmed)
+
+		confirmedAgain, err := repo.ConfirmEmailChange(context.Background(), &models.User{ID: user.ID, Email: "other@example.com"}, token)
+		require.NoError(t, err)
+		assert.False(t, confirmedAgain)
+		changed, err := repo.GetByID(context.Background(), user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, pendingEmail, changed.Email)
+		assert.Nil(t, changed.PendingEmail)
+		assert.Nil(t, changed.EmailChangeToken)
+	})
+
+	t.Run("ConfirmEmailChange - Email Taken Meanwhile", func(t *testing.T) {
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		user := &models.User{Name: "User", Email: "old@example.com", Password: "password", Gender: 1}
+		require.NoError(t, db.Create(user).Error)
+		require.NoError(t, db.Create(&models.User{Name: "Other", Email: "taken@example.com", Password: "password", Gender: 1}).Error)
+		token := "token-hash"
+		require.NoError(t, db.Model(user).Update("email_change_token", token).Error)
+
+		confirmed, err := repo.ConfirmEmailChange(context.Background(), &models.User{ID: user.ID, Email: "taken@example.com"}, token)
+
+		assert.False(t, confirmed)
+		appErr, ok := apperror.ToAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperror.ErrDuplicateEmail, appErr.Code)
+		stored, err := repo.GetByID(context.Background(), user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "old@example.com", stored.Email)
+	})
+
 	t.Run("CreateWithTx - Duplicate Email Error", func(t *testing.T) {
 		// Arrange
 		db := setupUserTestDB(t)
@@ -310,12 +746,59 @@ func TestUserRepository(t *testing.T) {
 		createdUser, err := repo.CreateWithTx(context.Background(), tx, user2)
 
 		// Assert
-		assert.Error(t, err)
 		assert.Nil(t, createdUser)
+		appErr, ok := apperror.ToAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperror.ErrDuplicateEmail, appErr.Code)
+		assert.Equal(t, 409, appErr.HttpStatusCode)
+		assert.Equal(t, []apperror.FieldError{{Field: "email", Message: "email already exists"}}, appErr.Fields)
 
 		tx.Rollback()
 	})
 
+	t.Run("Create - Duplicate Email Is A Conflict On The Email", func(t *testing.T) {
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		require.NoError(t, db.Create(&models.User{Email: "duplicate@example.com", Name: "user1", Password: "pass"}).Error)
+
+		createdUser, err := repo.Create(context.Background(), &models.User{Email: "duplicate@example.com", Name: "user2", Password: "pass"})
+
+		assert.Nil(t, createdUser)
+		appErr, ok := apperror.ToAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperror.ErrDuplicateEmail, appErr.Code)
+		assert.Equal(t, []apperror.FieldError{{Field: "email", Message: "email already exists"}}, appErr.Fields)
+	})
+
+	t.Run("Update - Duplicate Email Is A Conflict On The Email", func(t *testing.T) {
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		require.NoError(t, db.Create(&models.User{Email: "taken@example.com", Name: "user1", Password: "pass"}).Error)
+		user := &models.User{Email: "user2@example.com", Name: "user2", Password: "pass"}
+		require.NoError(t, db.Create(user).Error)
+
+		user.Email = "taken@example.com"
+		err := repo.Update(context.Background(), user)
+
+		appErr, ok := apperror.ToAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperror.ErrDuplicateEmail, appErr.Code)
+	})
+
+	t.Run("Create - Duplicate Of An Unmapped Unique Field Is An Internal Error", func(t *testing.T) {
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		token := "same-token"
+		require.NoError(t, db.Create(&models.User{Email: "user1@example.com", Name: "user1", Password: "pass", EmailChangeToken: &token}).Error)
+
+		_, err := repo.Create(context.Background(), &models.User{Email: "user2@example.com", Name: "user2", Password: "pass", EmailChangeToken: &token})
+
+		appErr, ok := apperror.ToAppError(err)
+		require.True(t, ok)
+		assert.NotEqual(t, apperror.ErrDuplicateEmail, appErr.Code)
+		assert.Empty(t, appErr.Fields)
+	})
+
 	t.Run("CreateWithTx - Success", func(t *testing.T) {
 		db := setupUserTestDB(t)
 		repo := repositories.NewUserRepository(db)
@@ -367,7 +850,7 @@ func TestUserRepository(t *testing.T) {
 		}
 
 		// Act - First page
-		pagination, err := repo.GetUsers(context.Background(), 1, 2)
+		pagination, err := repo.GetUsers(context.Background(), dto.ListOptions{Page: 1, Limit: 2}, dto.UserFilterInput{})
 
 		// Assert
 		require.NoError(t, err)
@@ -396,7 +879,7 @@ func TestUserRepository(t *testing.T) {
 		}
 
 		// Act - Second page
-		pagination, err := repo.GetUsers(context.Background(), 2, 2)
+		pagination, err := repo.GetUsers(context.Background(), dto.ListOptions{Page: 2, Limit: 2}, dto.UserFilterInput{})
 
 		// Assert
 		require.NoError(t, err)
@@ -420,7 +903,7 @@ func TestUserRepository(t *testing.T) {
 		})
 		defer db.Callback().Query().Remove("force_find_error_only")
 
-		_, err := repo.GetUsers(context.Background(), 1, 10)
+		_, err := repo.GetUsers(context.Background(), dto.ListOptions{Page: 1, Limit: 10}, dto.UserFilterInput{})
 		assert.Error(t, err)
 	})
 
@@ -441,7 +924,7 @@ func TestUserRepository(t *testing.T) {
 		}
 
 		// Act - Last page
-		pagination, err := repo.GetUsers(context.Background(), 3, 2)
+		pagination, err := repo.GetUsers(context.Background(), dto.ListOptions{Page: 3, Limit: 2}, dto.UserFilterInput{})
 
 		// Assert
 		require.NoError(t, err)
@@ -464,7 +947,7 @@ func TestUserRepository(t *testing.T) {
 		}
 
 		// Act
-		pagination, err := repo.GetUsers(context.Background(), 5, 2)
+		pagination, err := repo.GetUsers(context.Background(), dto.ListOptions{Page: 5, Limit: 2}, dto.UserFilterInput{})
 
 		// Assert
 		require.NoError(t, err)
@@ -488,7 +971,7 @@ func TestUserRepository(t *testing.T) {
 		}
 
 		// Act
-		pagination, err := repo.GetUsers(context.Background(), 1, 10)
+		pagination, err := repo.GetUsers(context.Background(), dto.ListOptions{Page: 1, Limit: 10}, dto.UserFilterInput{})
 
 		// Assert
 		require.NoError(t, err)
@@ -510,10 +993,877 @@ func TestUserRepository(t *testing.T) {
 		require.NoError(t, err)
 
 		// Act
-		pagination, err := repo.GetUsers(context.Background(), 1, 10)
+		pagination, err := repo.GetUsers(context.Background(), dto.ListOptions{Page: 1, Limit: 10}, dto.UserFilterInput{})
 
 		// Assert
 		assert.Error(t, err)
 		assert.Nil(t, pagination)
 	})
+
+	t.Run("GetUsers - Filters And Sorting", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		mockUsers := []*models.User{
+			{Name: "Bob Smith", Email: "bob@example.com", Password: "password", Gender: 1},
+			{Name: "Alice", Email: "alice@bobcat.io", Password: "password", Gender: 2},
+			{Name: "Carol", Email: "carol@example.com", Password: "password", Gender: 1},
+			{Name: "Bobby", Email: "bobby@example.com", Password: "password", Gender: 2},
+			{Name: "100%_real", Email: "percent@example.com", Password: "password", Gender: 3},
+		}
+		for _, user := range mockUsers {
+			_, err := repo.Create(context.Background(), user)
+			require.NoError(t, err)
+		}
+		male, female := int16(1), int16(2)
+
+		names := func(users []*models.User) []string {
+			result := make([]string, len(users))
+			for i, user := range users {
+				result[i] = user.Name
+			}
+			return result
+		}
+
+		tests := []struct {
+			name     string
+			opts     dto.ListOptions
+			filter   dto.UserFilterInput
+			expected []string
+		}{
+			{
+				name:     "search matches name or email",
+				opts:     dto.ListOptions{Page: 1, Limit: 10, SortBy: "name", SortDir: "asc"},
+				filter:   dto.UserFilterInput{Search: "bob"},
+				expected: []string{"Alice", "Bob Smith", "Bobby"},
+			},
+			{
+				name:     "gender filter",
+				opts:     dto.ListOptions{Page: 1, Limit: 10, SortBy: "name", SortDir: "desc"},
+				filter:   dto.UserFilterInput{Gender: &male},
+				expected: []string{"Carol", "Bob Smith"},
+			},
+			{
+				name:     "search and gender combined",
+				opts:     dto.ListOptions{Page: 1, Limit: 10, SortBy: "email", SortDir: "asc"},
+				filter:   dto.UserFilterInput{Search: "bob", Gender: &female},
+				expected: []string{"Alice", "Bobby"},
+			},
+			{
+				name:     "wildcards in search are matched literally",
+				opts:     dto.ListOptions{Page: 1, Limit: 10},
+				filter:   dto.UserFilterInput{Search: "%_"},
+				expected: []string{"100%_real"},
+			},
+			{
+				name:     "no match",
+				opts:     dto.ListOptions{Page: 1, Limit: 10},
+				filter:   dto.UserFilterInput{Search: "nobody"},
+				expected: []string{},
+			},
+			{
+				name:     "unknown sort field falls back to id",
+				opts:     dto.ListOptions{Page: 1, Limit: 2, SortBy: "password; DROP TABLE users", SortDir: "asc"},
+				expected: []string{"Bob Smith", "Alice"},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// Act
+				pagination, err := repo.GetUsers(context.Background(), tt.opts, tt.filter)
+
+				// Assert
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, names(pagination.Data))
+			})
+		}
+
+		t.Run("total reflects filters", func(t *testing.T) {
+			pagination, err := repo.GetUsers(context.Background(), dto.ListOptions{Page: 1, Limit: 1}, dto.UserFilterInput{Search: "bob"})
+
+			require.NoError(t, err)
+			assert.Equal(t, 3, pagination.TotalItems)
+			assert.Equal(t, 3, pagination.TotalPages)
+			assert.Len(t, pagination.Data, 1)
+		})
+	})
+
+	t.Run("GetRecentlyActive - Orders By Latest Refresh Token Activity", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		require.NoError(t, db.AutoMigrate(&models.RefreshToken{}))
+		repo := repositories.NewUserRepository(db)
+
+		now := time.Now()
+		idle := &models.User{Name: "Idle", Email: "idle@example.com", Password: "password", Gender: 1}
+		older := &models.User{Name: "Older", Email: "older@example.com", Password: "password", Gender: 1}
+		recent := &models.User{Name: "Recent", Email: "recent@example.com", Password: "password", Gender: 1}
+		for _, user := range []*models.User{idle, older, recent} {
+			_, err := repo.Create(context.Background(), user)
+			require.NoError(t, err)
+		}
+
+		tokens := []models.RefreshToken{
+			{RefreshToken: "older-1", IpAddress: "127.0.0.1", ExpiredAt: now.Add(time.Hour).Unix(), UserID: older.ID, UpdatedAt: now.Add(-2 * time.Hour)},
+			{RefreshToken: "older-2", IpAddress: "127.0.0.1", ExpiredAt: now.Add(time.Hour).Unix(), UserID: older.ID, UpdatedAt: now.Add(-time.Hour)},
+			{RefreshToken: "recent-1", IpAddress: "127.0.0.1", ExpiredAt: now.Add(time.Hour).Unix(), UserID: recent.ID, UpdatedAt: now},
+		}
+		for i := range tokens {
+			require.NoError(t, db.Omit("User").Create(&tokens[i]).Error)
+		}
+
+		// Act
+		users, err := repo.GetRecentlyActive(context.Background(), 10)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, users, 2)
+		assert.Equal(t, recent.ID, users[0].ID)
+		assert.Equal(t, older.ID, users[1].ID)
+	})
+
+	t.Run("GetRecentlyActive - Respects Limit", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		require.NoError(t, db.AutoMigrate(&models.RefreshToken{}))
+		repo := repositories.NewUserRepository(db)
+
+		for i := 0; i < 3; i++ {
+			user := &models.User{Name: "User", Email: fmt.Sprintf("user%d@example.com", i), Password: "password", Gender: 1}
+			_, err := repo.Create(context.Background(), user)
+			require.NoError(t, err)
+			token := &models.RefreshToken{RefreshToken: fmt.Sprintf("token-%d", i), IpAddress: "127.0.0.1", ExpiredAt: time.Now().Add(time.Hour).Unix(), UserID: user.ID}
+			require.NoError(t, db.Omit("User").Create(token).Error)
+		}
+
+		// Act
+		users, err := repo.GetRecentlyActive(context.Background(), 2)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Len(t, users, 2)
+	})
+
+	t.Run("GetRecentlyActive - Database Error", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		// Act
+		users, err := repo.GetRecentlyActive(context.Background(), 10)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, users)
+	})
+
+	t.Run("GetByIDs - Returns Existing Users With Roles", func(t *testing.T) {
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		role := models.Role{Name: "editor"}
+		require.NoError(t, db.Create(&role).Error)
+		for i := range 4 {
+			user := &models.User{Name: fmt.Sprintf("User %d", i), Email: fmt.Sprintf("ids%d@example.com", i), Password: "password", Gender: 1}
+			if i == 0 {
+				user.Roles = []models.Role{role}
+			}
+			_, err := repo.Create(context.Background(), user)
+			require.NoError(t, err)
+		}
+		require.NoError(t, repo.Delete(context.Background(), 4))
+
+		users, err := repo.GetByIDs(context.Background(), []uint{3, 1, 4, 99, 1})
+
+		require.NoError(t, err)
+		byID := repositories.UsersByID(users)
+		assert.Len(t, users, 2)
+		assert.Len(t, byID, 2)
+		require.Contains(t, byID, uint(1))
+		require.Contains(t, byID, uint(3))
+		assert.Equal(t, "User 2", byID[3].Name)
+		require.Len(t, byID[1].Roles, 1)
+		assert.Equal(t, "editor", byID[1].Roles[0].Name)
+	})
+
+	t.Run("GetByIDs - No IDs", func(t *testing.T) {
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+
+		users, err := repo.GetByIDs(context.Background(), nil)
+
+		require.NoError(t, err)
+		assert.Empty(t, users)
+		assert.NotNil(t, users)
+	})
+
+	t.Run("GetByIDs - Database Error", func(t *testing.T) {
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		users, err := repo.GetByIDs(context.Background(), []uint{1})
+
+		assert.Error(t, err)
+		assert.Nil(t, users)
+	})
+
+	t.Run("CreateUsers - Creates All In Batches", func(t *testing.T) {
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		users := make([]*models.User, 5)
+		for i := range users {
+			users[i] = &models.User{Name: "Imported", Email: fmt.Sprintf("import%d@example.com", i), Password: "password", Gender: 1}
+		}
+
+		require.NoError(t, repo.CreateUsers(context.Background(), users, 2))
+
+		var count int64
+		require.NoError(t, db.Model(&models.User{}).Count(&count).Error)
+		assert.Equal(t, int64(5), count)
+		assert.NotZero(t, users[4].ID)
+	})
+
+	t.Run("CreateUsers - Rolls Back On Error", func(t *testing.T) {
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		_, err := repo.Create(context.Background(), &models.User{Name: "Existing", Email: "taken@example.com", Password: "password", Gender: 1})
+		require.NoError(t, err)
+		users := []*models.User{
+			{Name: "First", Email: "first@example.com", Password: "password", Gender: 1},
+			{Name: "Second", Email: "second@example.com", Password: "password", Gender: 1},
+			{Name: "Duplicate", Email: "taken@example.com", Password: "password", Gender: 1},
+		}
+
+		err = repo.CreateUsers(context.Background(), users, 2)
+
+		assert.Error(t, err)
+		var count int64
+		require.NoError(t, db.Model(&models.User{}).Count(&count).Error)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("FindExistingEmails - Includes Soft Deleted Users", func(t *testing.T) {
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		for _, email := range []string{"active@example.com", "deleted@example.com"} {
+			_, err := repo.Create(context.Background(), &models.User{Name: "User", Email: email, Password: "password", Gender: 1})
+			require.NoError(t, err)
+		}
+		require.NoError(t, repo.Delete(context.Background(), 2))
+		emails := []string{"new@example.com", "deleted@example.com", "active@example.com"}
+		for i := range repositories.EXISTING_EMAILS_BATCH_SIZE {
+			emails = append(emails, fmt.Sprintf("filler%d@example.com", i))
+		}
+
+		existing, err := repo.FindExistingEmails(context.Background(), emails)
+
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"active@example.com", "deleted@example.com"}, existing)
+	})
+
+	t.Run("GetByIDUnscoped - Returns Soft Deleted User", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		user := &models.User{Name: "User1", Email: "email1@example.com", Password: "password1", Gender: 1}
+		_, err := repo.Create(context.Background(), user)
+		require.NoError(t, err)
+		require.NoError(t, repo.Delete(context.Background(), user.ID))
+
+		// Act
+		found, err := repo.GetByIDUnscoped(context.Background(), user.ID)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, found.ID)
+		assert.True(t, found.DeletedAt.Valid)
+	})
+
+	t.Run("GetByIDUnscoped - Not Found Error", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+
+		// Act
+		found, err := repo.GetByIDUnscoped(context.Background(), 999)
+
+		// Assert
+		assert.Nil(t, found)
+		appErr, ok := apperror.ToAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperror.ErrNotFound, appErr.Code)
+	})
+
+	t.Run("GetByIDUnscoped - Database Error", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		// Act
+		found, err := repo.GetByIDUnscoped(context.Background(), 1)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, found)
+	})
+
+	t.Run("DeleteUsers - Deletes Existing And Skips Missing", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		users := []*models.User{
+			{Name: "User1", Email: "email1@example.com", Password: "password1", Gender: 1},
+			{Name: "User2", Email: "email2@example.com", Password: "password2", Gender: 1},
+			{Name: "User3", Email: "email3@example.com", Password: "password3", Gender: 1},
+		}
+		for _, user := range users {
+			_, err := repo.Create(context.Background(), user)
+			require.NoError(t, err)
+		}
+		require.NoError(t, repo.Delete(context.Background(), users[2].ID))
+
+		// Act
+		deleted, err := repo.DeleteUsers(context.Background(), []uint{users[0].ID, users[1].ID, users[2].ID, 999})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, []uint{users[0].ID, users[1].ID}, deleted)
+		var remaining int64
+		require.NoError(t, db.Model(&models.User{}).Count(&remaining).Error)
+		assert.Equal(t, int64(0), remaining)
+	})
+
+	t.Run("DeleteUsers - Nothing To Delete", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+
+		// Act
+		deleted, err := repo.DeleteUsers(context.Background(), []uint{1, 2})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Empty(t, deleted)
+	})
+
+	t.Run("DeleteUsers - Database Error", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		// Act
+		deleted, err := repo.DeleteUsers(context.Background(), []uint{1})
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, deleted)
+	})
+
+	t.Run("Restore - Clears Deleted At", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		user := &models.User{Name: "User1", Email: "email1@example.com", Password: "password1", Gender: 1}
+		_, err := repo.Create(context.Background(), user)
+		require.NoError(t, err)
+		require.NoError(t, repo.Delete(context.Background(), user.ID))
+
+		// Act
+		err = repo.Restore(context.Background(), user.ID)
+
+		// Assert
+		require.NoError(t, err)
+		restored, err := repo.GetByID(context.Background(), user.ID)
+		require.NoError(t, err)
+		assert.False(t, restored.DeletedAt.Valid)
+	})
+
+	t.Run("Restore - Database Error", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		// Act
+		err = repo.Restore(context.Background(), 1)
+
+		// Assert
+		assert.Error(t, err)
+	})
+
+	t.Run("GetUsers - Include Deleted", func(t *testing.T) {
+		// Arrange
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		active := &models.User{Name: "Active", Email: "active@example.com", Password: "password", Gender: 1}
+		deleted := &models.User{Name: "Deleted", Email: "deleted@example.com", Password: "password", Gender: 1}
+		for _, user := range []*models.User{active, deleted} {
+			_, err := repo.Create(context.Background(), user)
+			require.NoError(t, err)
+		}
+		require.NoError(t, repo.Delete(context.Background(), deleted.ID))
+		opts := dto.ListOptions{Page: 1, Limit: 10, SortBy: "id", SortDir: "asc"}
+
+		// Act
+		withoutDeleted, err := repo.GetUsers(context.Background(), opts, dto.UserFilterInput{})
+		require.NoError(t, err)
+		withDeleted, err := repo.GetUsers(context.Background(), opts, dto.UserFilterInput{IncludeDeleted: true})
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, 1, withoutDeleted.TotalItems)
+		assert.Equal(t, active.ID, withoutDeleted.Data[0].ID)
+		assert.Equal(t, 2, withDeleted.TotalItems)
+		require.Len(t, withDeleted.Data, 2)
+		assert.True(t, withDeleted.Data[1].DeletedAt.Valid)
+	})
+
+	t.Run("GetUsers - Page Flags", func(t *testing.T) {
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		for i := 1; i <= 5; i++ {
+			_, err := repo.Create(context.Background(), &models.User{Name: fmt.Sprintf("User%d", i), Email: fmt.Sprintf("user%d@example.com", i), Password: "password", Gender: 1})
+			require.NoError(t, err)
+		}
+
+		// pageNumber returns the page number, 0 for none
+		pageNumber := func(page *int) int {
+			if page == nil {
+				return 0
+			}
+			return *page
+		}
+		for page, expected := range map[int]struct {
+			hasPrev, hasNext   bool
+			prevPage, nextPage int
+		}{
+			1: {false, true, 0, 2},
+			2: {true, true, 1, 3},
+			3: {true, false, 2, 0},
+			// Past the last page the previous page is the last one
+			5: {true, false, 3, 0},
+		} {
+			pagination, err := repo.GetUsers(context.Background(), dto.ListOptions{Page: page, Limit: 2}, dto.UserFilterInput{})
+
+			require.NoError(t, err)
+			assert.Equal(t, expected.hasPrev, pagination.HasPrev, "page %d", page)
+			assert.Equal(t, expected.hasNext, pagination.HasNext, "page %d", page)
+			assert.Equal(t, expected.prevPage, pageNumber(pagination.PrevPage), "page %d", page)
+			assert.Equal(t, expected.nextPage, pageNumber(pagination.NextPage), "page %d", page)
+			assert.Empty(t, pagination.NextCursor)
+		}
+
+		// Without rows there is no page to go to
+		pagination, err := repo.GetUsers(context.Background(), dto.ListOptions{Page: 2, Limit: 2}, dto.UserFilterInput{Search: "nobody"})
+		require.NoError(t, err)
+		assert.False(t, pagination.HasPrev)
+		assert.Nil(t, pagination.PrevPage)
+	})
+
+	// traverse follows the cursors from the first page to the last and returns the IDs in order
+	traverse := func(t *testing.T, repo repositories.UserRepository, opts dto.ListOptions, beforeNext func(page int)) []uint {
+		var ids []uint
+		opts.Cursor = &dto.Cursor{}
+		for page := 1; ; page++ {
+			pagination, err := repo.GetUsers(context.Background(), opts, dto.UserFilterInput{})
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(pagination.Data), opts.Limit)
+			assert.Zero(t, pagination.Page)
+			assert.Equal(t, page > 1, pagination.HasPrev)
+			for _, user := range pagination.Data {
+				ids = append(ids, user.ID)
+			}
+			if !pagination.HasNext {
+				assert.Empty(t, pagination.NextCursor)
+				return ids
+			}
+			require.Len(t, pagination.Data, opts.Limit)
+			opts.Cursor, err = utils.DecodeCursor(pagination.NextCursor)
+			require.NoError(t, err)
+			if beforeNext != nil {
+				beforeNext(page)
+			}
+		}
+	}
+
+	t.Run("GetUsers - Cursor Traversal", func(t *testing.T) {
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		// Names, genders and creation times repeat so pages break inside runs of equal sort keys
+		for i := 1; i <= 8; i++ {
+			user := &models.User{
+				Name:      fmt.Sprintf("User%d", i%3),
+				Email:     fmt.Sprintf("user%d@example.com", i),
+				Password:  "password",
+				Gender:    int16(i%2 + 1),
+				CreatedAt: base.Add(time.Duration(i/3) * time.Hour),
+			}
+			_, err := repo.Create(context.Background(), user)
+			require.NoError(t, err)
+		}
+
+		for _, sort := range []struct{ by, dir string }{
+			{"id", "desc"}, {"id", "asc"}, {"name", "asc"}, {"name", "desc"}, {"gender", "desc"}, {"created_at", "desc"}, {"created_at", "asc"},
+		} {
+			t.Run(sort.by+" "+sort.dir, func(t *testing.T) {
+				opts := dto.ListOptions{Limit: 3, SortBy: sort.by, SortDir: sort.dir}
+
+				all, err := repo.GetUsers(context.Background(), dto.ListOptions{Page: 1, Limit: 100, SortBy: sort.by, SortDir: sort.dir}, dto.UserFilterInput{})
+				require.NoError(t, err)
+				expected := make([]uint, len(all.Data))
+				for i, user := range all.Data {
+					expected[i] = user.ID
+				}
+
+				assert.Equal(t, expected, traverse(t, repo, opts, nil))
+			})
+		}
+	})
+
+	t.Run("GetUsers - Cursor Stable When Rows Are Inserted", func(t *testing.T) {
+		for _, sort := range []struct{ by, dir string }{{"id", "desc"}, {"created_at", "asc"}, {"name", "asc"}} {
+			t.Run(sort.by+" "+sort.dir, func(t *testing.T) {
+				db := setupUserTestDB(t)
+				repo := repositories.NewUserRepository(db)
+				base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+				var original []uint
+				for i := 1; i <= 7; i++ {
+					user := &models.User{Name: fmt.Sprintf("User%d", i), Email: fmt.Sprintf("user%d@example.com", i), Password: "password", Gender: 1, CreatedAt: base.Add(time.Duration(i) * time.Minute)}
+					_, err := repo.Create(context.Background(), user)
+					require.NoError(t, err)
+					original = append(original, user.ID)
+				}
+
+				inserted := 0
+				ids := traverse(t, repo, dto.ListOptions{Limit: 2, SortBy: sort.by, SortDir: sort.dir}, func(page int) {
+					// A newer row and one sorting before everything already read
+					for _, name := range []string{"User0", "User9"} {
+						inserted++
+						user := &models.User{Name: name, Email: fmt.Sprintf("new%d@example.com", inserted), Password: "password", Gender: 1, CreatedAt: base.Add(time.Duration(-inserted) * time.Hour)}
+						_, err := repo.Create(context.Background(), user)
+						require.NoError(t, err)
+					}
+				})
+
+				seen := make(map[uint]bool, len(ids))
+				for _, id := range ids {
+					assert.False(t, seen[id], "user %d returned twice", id)
+					seen[id] = true
+				}
+				for _, id := range original {
+					assert.True(t, seen[id], "user %d skipped", id)
+				}
+			})
+		}
+	})
+
+	t.Run("GetUsers - Invalid Cursor Value", func(t *testing.T) {
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		_, err := repo.Create(context.Background(), &models.User{Name: "User", Email: "user@example.com", Password: "password", Gender: 1})
+		require.NoError(t, err)
+		cursor := &dto.Cursor{SortBy: "created_at", SortDir: "desc", Value: []byte(`"yesterday"`), ID: 1}
+
+		pagination, err := repo.GetUsers(context.Background(), dto.ListOptions{Limit: 2, SortBy: "created_at", SortDir: "desc", Cursor: cursor}, dto.UserFilterInput{})
+
+		assert.Nil(t, pagination)
+		appErr, ok := apperror.ToAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperror.ErrParseError, appErr.Code)
+	})
+
+	t.Run("Search - Orders By Relevance", func(t *testing.T) {
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		seed := []*models.User{
+			{Name: "Zed Alpha", Email: "zed@example.com"},
+			{Name: "Alice", Email: "alice@example.com"},
+			{Name: "Al Bundy", Email: "al@example.com"},
+			{Name: "Malcolm", Email: "malcolm@example.com"},
+			{Name: "Albert", Email: "albert@example.com"},
+			{Name: "Aaron", Email: "al@example.com.au"},
+			{Name: "Agent 4", Email: "agent@example.com"},
+			{Name: "Deleted Al", Email: "al.deleted@example.com"},
+		}
+		for _, user := range seed {
+			user.Password, user.Gender = "password", 1
+			_, err := repo.Create(context.Background(), user)
+			require.NoError(t, err)
+		}
+		require.NoError(t, repo.Delete(context.Background(), seed[7].ID))
+		malcolmID := fmt.Sprint(seed[3].ID)
+		require.Equal(t, "4", malcolmID)
+
+		tests := []struct {
+			name     string
+			query    string
+			limit    int
+			expected []string
+		}{
+			{
+				name:     "email prefix before name match, excluding deleted users",
+				query:    "al",
+				limit:    10,
+				expected: []string{"Aaron", "Al Bundy", "Albert", "Alice", "Malcolm", "Zed Alpha"},
+			},
+			{
+				name:     "exact email before email prefix",
+				query:    "al@example.com",
+				limit:    10,
+				expected: []string{"Al Bundy", "Aaron"},
+			},
+			{
+				name:     "exact email ignores case",
+				query:    " ALICE@example.com ",
+				limit:    10,
+				expected: []string{"Alice"},
+			},
+			{
+				name:     "exact ID before name match",
+				query:    malcolmID,
+				limit:    10,
+				expected: []string{"Malcolm", "Agent 4"},
+			},
+			{
+				name:     "limit keeps the most relevant",
+				query:    "al",
+				limit:    2,
+				expected: []string{"Aaron", "Al Bundy"},
+			},
+			{
+				name:     "wildcards are matched literally",
+				query:    "a%",
+				limit:    10,
+				expected: []string{},
+			},
+			{
+				name:     "no match",
+				query:    "nobody",
+				limit:    10,
+				expected: []string{},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				users, err := repo.Search(context.Background(), tt.query, tt.limit)
+
+				require.NoError(t, err)
+				names := make([]string, 0, len(users))
+				for _, user := range users {
+					names = append(names, user.Name)
+				}
+				assert.Equal(t, tt.expected, names)
+			})
+		}
+	})
+
+	t.Run("Search - Database Error", func(t *testing.T) {
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		users, err := repo.Search(context.Background(), "al", 10)
+
+		assert.Error(t, err)
+		assert.Nil(t, users)
+	})
+
+	t.Run("IterateUsers - Batches In ID Order With Filters", func(t *testing.T) {
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		for i := range 7 {
+			gender := int16(1 + i%2)
+			_, err := repo.Create(context.Background(), &models.User{Name: fmt.Sprintf("User %d", i), Email: fmt.Sprintf("user%d@example.com", i), Password: "password", Gender: gender})
+			require.NoError(t, err)
+		}
+		require.NoError(t, repo.Delete(context.Background(), 3))
+		male := int16(1)
+
+		var batches [][]uint
+		collect := func(users []*models.User) error {
+			ids := make([]uint, len(users))
+			for i, user := range users {
+				ids[i] = user.ID
+			}
+			batches = append(batches, ids)
+			return nil
+		}
+
+		require.NoError(t, repo.IterateUsers(context.Background(), dto.UserFilterInput{}, 3, collect))
+		assert.Equal(t, [][]uint{{1, 2, 4}, {5, 6, 7}}, batches)
+
+		batches = nil
+		require.NoError(t, repo.IterateUsers(context.Background(), dto.UserFilterInput{Gender: &male, IncludeDeleted: true}, 3, collect))
+		assert.Equal(t, [][]uint{{1, 3, 5}, {7}}, batches)
+
+		batches = nil
+		require.NoError(t, repo.IterateUsers(context.Background(), dto.UserFilterInput{Search: "nobody"}, 3, collect))
+		assert.Empty(t, batches)
+	})
+
+	t.Run("IterateUsers - Callback Error Stops Iteration", func(t *testing.T) {
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		for i := range 4 {
+			_, err := repo.Create(context.Background(), &models.User{Name: "User", Email: fmt.Sprintf("stop%d@example.com", i), Password: "password", Gender: 1})
+			require.NoError(t, err)
+		}
+		stopErr := fmt.Errorf("client went away")
+
+		calls := 0
+		err := repo.IterateUsers(context.Background(), dto.UserFilterInput{}, 2, func(users []*models.User) error {
+			calls++
+			return stopErr
+		})
+
+		assert.ErrorIs(t, err, stopErr)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("IterateUsers - Database Error", func(t *testing.T) {
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		err = repo.IterateUsers(context.Background(), dto.UserFilterInput{}, 10, func(users []*models.User) error { return nil })
+
+		assert.Error(t, err)
+	})
+
+	t.Run("AssignRoles And RemoveRoles - Change Only The Given Roles", func(t *testing.T) {
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		roles := []models.Role{{Name: "admin"}, {Name: "editor"}, {Name: "viewer"}}
+		require.NoError(t, db.Create(&roles).Error)
+		user := &models.User{Name: "User", Email: "roles@example.com", Password: "password", Gender: 1, Roles: []models.Role{roles[2]}}
+		require.NoError(t, db.Create(user).Error)
+		roleNames := func() []string {
+			var names []string
+			require.NoError(t, db.Table("roles").Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+				Where("user_roles.user_id = ?", user.ID).Order("roles.name").Pluck("roles.name", &names).Error)
+			return names
+		}
+
+		// The viewer role is already assigned and the editor role is given twice
+		require.NoError(t, repo.AssignRoles(context.Background(), user.ID, []uint{roles[1].ID, roles[2].ID, roles[1].ID}))
+		assert.Equal(t, []string{"editor", "viewer"}, roleNames())
+
+		// The admin role is not assigned and is ignored
+		require.NoError(t, repo.RemoveRoles(context.Background(), user.ID, []uint{roles[0].ID, roles[2].ID}))
+		assert.Equal(t, []string{"editor"}, roleNames())
+	})
+
+	t.Run("AssignRoles And RemoveRoles - Missing Roles Change Nothing", func(t *testing.T) {
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		role := models.Role{Name: "editor"}
+		require.NoError(t, db.Create(&role).Error)
+		user := &models.User{Name: "User", Email: "missing_roles@example.com", Password: "password", Gender: 1}
+		require.NoError(t, db.Create(user).Error)
+
+		err := repo.AssignRoles(context.Background(), user.ID, []uint{99, role.ID, 42, 99})
+
+		appErr, ok := apperror.ToAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperror.ErrNotFound, appErr.Code)
+		assert.Equal(t, "Roles not found: 42, 99", appErr.Message)
+		assert.Zero(t, db.Model(user).Association("Roles").Count())
+
+		require.NoError(t, repo.AssignRoles(context.Background(), user.ID, []uint{role.ID}))
+		err = repo.RemoveRoles(context.Background(), user.ID, []uint{role.ID, 7})
+
+		appErr, ok = apperror.ToAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, "Roles not found: 7", appErr.Message)
+		assert.Equal(t, int64(1), db.Model(user).Association("Roles").Count())
+	})
+
+	t.Run("AssignRoles - Database Error", func(t *testing.T) {
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		err = repo.AssignRoles(context.Background(), 1, []uint{1})
+
+		appErr, ok := apperror.ToAppError(err)
+		require.True(t, ok)
+		assert.NotEqual(t, apperror.ErrNotFound, appErr.Code)
+	})
+
+	t.Run("FindMissingRolesWithTx And AssignRolesWithTx - Roll Back With The Transaction", func(t *testing.T) {
+		db := setupUserTestDB(t)
+		repo := repositories.NewUserRepository(db)
+		role := models.Role{Name: "editor"}
+		require.NoError(t, db.Create(&role).Error)
+		user := &models.User{Name: "User", Email: "tx_roles@example.com", Password: "password", Gender: 1}
+		require.NoError(t, db.Create(user).Error)
+
+		tx, err := repo.BeginTx(context.Background())
+		require.NoError(t, err)
+		missing, err := repo.FindMissingRolesWithTx(context.Background(), tx, []uint{99, role.ID, 42, 99})
+		require.NoError(t, err)
+		assert.Equal(t, []uint{42, 99}, missing)
+
+		require.NoError(t, repo.AssignRolesWithTx(context.Background(), tx, user.ID, []uint{role.ID}))
+		assert.Equal(t, int64(1), tx.Model(user).Association("Roles").Count())
+		require.NoError(t, tx.Rollback().Error)
+		assert.Zero(t, db.Model(user).Association("Roles").Count())
+	})
+}
+
+func TestUserRepository_SingleUserLookupsReadThePrimary(t *testing.T) {
+	// Arrange: a replica that did not get the user yet
+	db := setupUserTestDB(t)
+	registerEmptyReplica(t, db, &models.User{}, &models.Role{}, &models.UserPreference{})
+	repo := repositories.NewUserRepository(db)
+	admin := models.Role{Name: "admin"}
+	require.NoError(t, db.Create(&admin).Error)
+	user := &models.User{Name: "User1", Email: "email1@example.com", Password: "password1", Gender: 1, Roles: []models.Role{admin}}
+	require.NoError(t, db.Create(user).Error)
+	ctx := context.Background()
+
+	// Act & Assert
+	found, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user.Email, found.Email)
+
+	found, err = repo.FindByField(ctx, "email", "email1@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+
+	found, err = repo.GetByIDWithRoles(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, found.Roles, 1)
+	assert.Equal(t, "admin", found.Roles[0].Name)
+
+	found, err = repo.GetByIDUnscoped(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, found.Roles, 1)
+
+	// Listings still read the replica
+	users, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, users)
 }
@@ -1,19 +1,31 @@
 package routes
 
 import (
+	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/vfa-khuongdv/golang-cms/internal/handlers"
 	"github.com/vfa-khuongdv/golang-cms/internal/middlewares"
 	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
 	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/audit"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
 	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+	"github.com/vfa-khuongdv/golang-cms/pkg/storage"
 	"gorm.io/gorm"
 )
 
-func SetupRouter(db *gorm.DB) *gin.Engine {
+// SetupRouter wires the API. The caller owns auditService and backgroundService and must close them after
+// the server stops, so audit entries still queued are written and mails still being sent are waited for
+func SetupRouter(db *gorm.DB, redisService services.RedisService, auditService services.AuditService, backgroundService services.BackgroundService) *gin.Engine {
 	// Set Gin mode from environment variable
 	ginMode := utils.GetEnv("GIN_MODE", "release")
 	gin.SetMode(ginMode)
@@ -25,62 +37,239 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 
 	// Set up Swagger documentation only in non-production environments
 	if stage != "prod" {
+		router.StaticFile("/api/v1/openapi.json", "./docs/swagger.json")
+		router.StaticFile("/docs", "./docs/swagger.html")
 		router.StaticFile("/docs/swagger.json", "./docs/swagger.json")
 		router.StaticFile("/swagger", "./docs/swagger.html")
 		router.StaticFile("/api-docs", "./docs/swagger.html")
 	}
 
+	// Each router gets its own metrics registry so collectors are never registered twice
+	metricsRegistry := prometheus.NewRegistry()
+	metricsRegistry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
 	// Initialize repositories
 	userRepo := repositories.NewUserRepository(db)
 	refreshRepo := repositories.NewRefreshTokenRepository(db)
+	roleRepo := repositories.NewRoleRepository(db)
+	settingRepo := repositories.NewSettingRepository(db)
+	loginIPRepo := repositories.NewUserLoginIPRepository(db)
+	preferenceRepo := repositories.NewUserPreferenceRepository(db)
 
 	// Initialize services
-	refreshTokenService := services.NewRefreshTokenService(refreshRepo)
+	fingerprintMode, err := services.ParseFingerprintMode(utils.GetEnv("REFRESH_TOKEN_FINGERPRINT_MODE", ""))
+	if err != nil {
+		logger.Fatalf("Invalid REFRESH_TOKEN_FINGERPRINT_MODE: %v", err)
+	}
+	refreshTokenService := services.NewRefreshTokenService(refreshRepo, fingerprintMode)
+	roleService := services.NewRoleService(roleRepo, redisService)
 	bcryptService := services.NewBcryptService()
 	mailerService := services.NewMailerService()
-	userService := services.NewUserService(userRepo, bcryptService, mailerService)
+	notificationService := services.NewNotificationService(loginIPRepo, mailerService)
+	userService := services.NewCachedUserService(
+		services.NewUserService(userRepo, bcryptService, mailerService, redisService, refreshTokenService, notificationService, backgroundService),
+		redisService,
+		metricsRegistry,
+	)
 	jwtService, err := services.NewJWTService()
 	if err != nil {
 		logger.Fatalf("Failed to initialize JWT service: %v", err)
 	}
-	authService := services.NewAuthService(userRepo, refreshTokenService, bcryptService, jwtService)
+	tokenBlacklistService := services.NewTokenBlacklistService(redisService)
+	authService := services.NewAuthService(userRepo, refreshTokenService, bcryptService, jwtService, redisService, notificationService)
+	settingsService := services.NewSettingsService(settingRepo, time.Duration(utils.GetEnvAsInt("SETTINGS_REFRESH_SECONDS", 30))*time.Second)
+	maintenanceService := services.NewMaintenanceService(settingsService)
+	fileStorage, err := newStorage()
+	if err != nil {
+		logger.Fatalf("Failed to initialize file storage: %v", err)
+	}
+	preferencesService := services.NewPreferencesService(preferenceRepo, redisService)
+	userActivityService := services.NewUserActivityService(userRepo, refreshTokenService, auditService)
+	accountDeletionService := services.NewAccountDeletionService(userRepo, bcryptService, refreshTokenService, redisService)
+	emailChangeService := services.NewEmailChangeService(userRepo, bcryptService, mailerService, notificationService, redisService)
+	avatarService := services.NewAvatarService(userRepo, fileStorage, redisService, int64(utils.GetEnvAsInt("AVATAR_MAX_BYTES", 2<<20)))
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(authService)
-	userHandler := handlers.NewUserHandler(userService, mailerService)
+	auditLogger := audit.NewAuditLogger(os.Stdout, auditService)
+	healthHandler := handlers.NewHealthHandler(db, redisService)
+	authHandler := handlers.NewAuthHandler(authService, auditLogger)
+	userHandler := handlers.NewUserHandler(userService, mailerService, auditLogger)
+	auditLogHandler := handlers.NewAuditLogHandler(auditService)
+	userActivityHandler := handlers.NewUserActivityHandler(userActivityService)
+	settingHandler := handlers.NewSettingHandler(settingsService, auditLogger)
+	maintenanceHandler := handlers.NewMaintenanceHandler(maintenanceService, auditLogger)
+	sessionHandler := handlers.NewSessionHandler(refreshTokenService, auditLogger)
+	avatarHandler := handlers.NewAvatarHandler(avatarService, auditLogger)
+	accountHandler := handlers.NewAccountHandler(accountDeletionService, auditLogger)
+	emailChangeHandler := handlers.NewEmailChangeHandler(emailChangeService, auditLogger)
+	preferencesHandler := handlers.NewPreferencesHandler(preferencesService, auditLogger)
+	metaHandler := handlers.NewMetaHandler()
+	jwtKeyHandler := handlers.NewJWTKeyHandler(jwtService)
 
 	// Add middleware
+	router.Use(middlewares.RequestIDMiddleware(), middlewares.LanguageMiddleware(), middlewares.CORSMiddleware(), middlewares.MetricsMiddleware(metricsRegistry))
+	// Registered before the request logger, which then sees the bodies uncompressed
+	router.Use(middlewares.CompressionMiddleware(utils.GetEnvAsInt("COMPRESSION_MIN_BYTES", 1024)))
+	if utils.GetEnv("HTTP_LOG_ENABLED", "true") == "true" {
+		router.Use(middlewares.LogMiddlewareWithOptions(middlewares.LogOptions{
+			MaskFields: envList("HTTP_LOG_MASK_FIELDS"),
+			BodyPaths:  envList("HTTP_LOG_BODY_PATHS"),
+		}))
+	}
 	router.Use(
-		middlewares.RequestIDMiddleware(),
-		middlewares.CORSMiddleware(),
-		middlewares.LogMiddleware(),
-		gin.Recovery(),
-		middlewares.EmptyBodyMiddleware(),
+		middlewares.RecoveryMiddleware(),
+		// Exports are streamed for as long as they take
+		middlewares.TimeoutMiddleware(time.Duration(utils.GetEnvAsInt("REQUEST_TIMEOUT_SECONDS", 30))*time.Second, "/api/v1/users/export"),
+		middlewares.EmptyBodyMiddleware("/api/v1/logout-all", "/api/v1/users/:id/restore", "/api/v1/users/:id/force-reset-password", "/api/v1/users/:id/unlock"),
+		// Probes and the switch stay reachable, and admins can still sign in to turn the maintenance mode off
+		middlewares.MaintenanceMiddleware(
+			maintenanceService,
+			time.Duration(utils.GetEnvAsInt("MAINTENANCE_RETRY_AFTER_SECONDS", 300))*time.Second,
+			"/healthz", "/readyz", "/metrics", "/.well-known/jwks.json", "/api/v1/login", "/api/v1/refresh-token", "/api/v1/admin/maintenance",
+		),
 	)
 
-	router.GET("/healthz", handlers.HealthCheck)
+	// Probes and metrics live outside /api/v1 so they are not rate limited or auth gated
+	router.GET("/healthz", healthHandler.Healthz)
+	router.GET("/readyz", healthHandler.Readyz)
+	router.GET("/metrics", gin.WrapH(promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{})))
+	// Other services fetch our public keys here to verify access tokens
+	router.GET("/.well-known/jwks.json", jwtKeyHandler.GetJWKS)
+	// Uploaded files kept on the local disk are served from here
+	if local, ok := fileStorage.(*storage.LocalStorage); ok {
+		router.Static(LOCAL_STORAGE_PATH, local.Dir)
+	}
 
 	// Setup API routes
+	rateLimitWindow := time.Duration(utils.GetEnvAsInt("RATE_LIMIT_WINDOW_SECONDS", 60)) * time.Second
+	if rateLimitWindow <= 0 {
+		logger.Fatalf("Invalid RATE_LIMIT_WINDOW_SECONDS: %s, it must be a positive number of seconds", rateLimitWindow)
+	}
+	authRateLimit := utils.GetEnvAsInt("AUTH_RATE_LIMIT_REQUESTS", 10)
+
 	api := router.Group("/api/v1")
+	api.Use(middlewares.RateLimit(redisService, "api", utils.GetEnvAsInt("RATE_LIMIT_REQUESTS", 100), rateLimitWindow))
 	{
-		// Public routes with rate limiting
 		public := api.Group("/")
-		public.Use(middlewares.RateLimiter(10, time.Minute))
 		{
-			public.POST("/login", authHandler.Login)
-			public.POST("/refresh-token", authHandler.RefreshToken)
-			public.POST("/forgot-password", userHandler.ForgotPassword)
-			public.POST("/reset-password", userHandler.ResetPassword)
+			// Credential and token endpoints get their own, tighter limits
+			public.POST("/login", middlewares.RateLimit(redisService, "login", authRateLimit, rateLimitWindow), authHandler.Login)
+			public.POST("/refresh-token", middlewares.RateLimit(redisService, "refresh_token", authRateLimit, rateLimitWindow), authHandler.RefreshToken)
+			public.POST("/forgot-password", middlewares.RateLimit(redisService, "forgot_password", authRateLimit, rateLimitWindow), userHandler.ForgotPassword)
+			public.POST("/reset-password", middlewares.RateLimit(redisService, "reset_password", authRateLimit, rateLimitWindow), userHandler.ResetPassword)
+			public.GET("/verify-email", middlewares.RateLimit(redisService, "verify_email", authRateLimit, rateLimitWindow), userHandler.VerifyEmail)
+			public.GET("/confirm-email-change", middlewares.RateLimit(redisService, "confirm_email_change", authRateLimit, rateLimitWindow), emailChangeHandler.ConfirmEmailChange)
+			public.POST("/resend-verification", middlewares.RateLimit(redisService, "resend_verification", authRateLimit, rateLimitWindow), userHandler.ResendVerification)
+			public.POST("/reactivate", middlewares.RateLimit(redisService, "reactivate", authRateLimit, rateLimitWindow), accountHandler.Reactivate)
+			public.GET("/meta/error-codes", metaHandler.GetErrorCodes)
 		}
 
+		// A user whose password was reset by an administrator can only change it or log out
+		passwordChange := middlewares.PasswordChangeMiddleware(userService, "/api/v1/change-password", "/api/v1/logout", "/api/v1/logout-all")
+
 		authenticated := api.Group("/")
-		authenticated.Use(middlewares.AuthMiddleware(jwtService))
+		authenticated.Use(middlewares.AuthMiddleware(jwtService, tokenBlacklistService), passwordChange)
 		{
+			authenticated.POST("/logout", authHandler.Logout)
+			authenticated.POST("/logout-all", authHandler.LogoutAll)
 			authenticated.POST("/change-password", userHandler.ChangePassword)
 			authenticated.GET("/profile", userHandler.GetProfile)
+			authenticated.GET("/me", userHandler.GetProfile)
 			authenticated.PATCH("/profile", userHandler.UpdateProfile)
+			authenticated.DELETE("/profile", accountHandler.DeleteAccount)
+			authenticated.PUT("/profile/email", middlewares.RateLimit(redisService, "change_email", authRateLimit, rateLimitWindow), emailChangeHandler.RequestEmailChange)
+			authenticated.GET("/profile/sessions", sessionHandler.GetSessions)
+			authenticated.DELETE("/profile/sessions/:id", sessionHandler.RevokeSession)
+			authenticated.POST("/profile/avatar", avatarHandler.UploadAvatar)
+			authenticated.DELETE("/profile/avatar", avatarHandler.DeleteAvatar)
+			authenticated.GET("/profile/preferences", preferencesHandler.GetPreferences)
+			authenticated.PUT("/profile/preferences", preferencesHandler.UpdatePreferences)
+		}
+
+		admin := api.Group("/")
+		admin.Use(middlewares.AuthMiddleware(jwtService, tokenBlacklistService), passwordChange)
+		{
+			admin.POST("/users", middlewares.PermissionMiddleware(roleService, constants.PERMISSION_USERS_CREATE), userHandler.CreateUser)
+			admin.GET("/users", middlewares.PermissionMiddleware(roleService, constants.PERMISSION_USERS_READ), middlewares.ListOptionsMiddleware(dto.ListOptions{Limit: 10, SortBy: "id"}, repositories.UserSortFields...), userHandler.GetUsers)
+			admin.GET("/users/search", middlewares.PermissionMiddleware(roleService, constants.PERMISSION_USERS_READ), userHandler.SearchUsers)
+			admin.GET("/users/export", middlewares.PermissionMiddleware(roleService, constants.PERMISSION_USERS_READ), userHandler.ExportUsers)
+			admin.POST("/users/import", middlewares.PermissionMiddleware(roleService, constants.PERMISSION_USERS_CREATE), middlewares.BodyLimitMiddleware(int64(utils.GetEnvAsInt("USER_IMPORT_MAX_BYTES", 5<<20))), userHandler.ImportUsers)
+			admin.GET("/users/:id", middlewares.PermissionMiddleware(roleService, constants.PERMISSION_USERS_READ), userHandler.GetUser)
+			admin.POST("/users/:id/restore", middlewares.PermissionMiddleware(roleService, constants.PERMISSION_USERS_RESTORE), userHandler.RestoreUser)
+			admin.POST("/users/bulk-delete", middlewares.PermissionMiddleware(roleService, constants.PERMISSION_USERS_DELETE), userHandler.DeleteUsers)
+			admin.POST("/users/:id/force-reset-password", middlewares.PermissionMiddleware(roleService, constants.PERMISSION_USERS_RESET_PASSWORD), userHandler.ForceResetPassword)
+			admin.POST("/users/:id/unlock", middlewares.PermissionMiddleware(roleService, constants.PERMISSION_USERS_UNLOCK), userHandler.UnlockUser)
+			admin.GET("/users/:id/activity", middlewares.PermissionMiddleware(roleService, constants.PERMISSION_AUDIT_LOGS_READ), middlewares.ListOptionsMiddleware(dto.ListOptions{Limit: 10, SortBy: "created_at"}, repositories.AuditLogSortFields...), userActivityHandler.GetUserActivity)
+			admin.GET("/users/:id/roles", middlewares.PermissionMiddleware(roleService, constants.PERMISSION_USERS_READ), userHandler.GetUserRoles)
+			admin.POST("/users/:id/roles", middlewares.PermissionMiddleware(roleService, constants.PERMISSION_USERS_ROLES), userHandler.AssignRoles)
+			admin.DELETE("/users/:id/roles", middlewares.PermissionMiddleware(roleService, constants.PERMISSION_USERS_ROLES), userHandler.RemoveRoles)
+			admin.GET("/audit-logs", middlewares.PermissionMiddleware(roleService, constants.PERMISSION_AUDIT_LOGS_READ), middlewares.ListOptionsMiddleware(dto.ListOptions{Limit: 20, SortBy: "created_at"}, repositories.AuditLogSortFields...), auditLogHandler.GetAuditLogs)
+			admin.GET("/settings", middlewares.PermissionMiddleware(roleService, constants.PERMISSION_SETTINGS_READ), settingHandler.GetSettings)
+			admin.GET("/settings/:key", middlewares.PermissionMiddleware(roleService, constants.PERMISSION_SETTINGS_READ), settingHandler.GetSetting)
+			admin.PUT("/settings/:key", middlewares.PermissionMiddleware(roleService, constants.PERMISSION_SETTINGS_WRITE), settingHandler.SetSetting)
+			admin.DELETE("/settings/:key", middlewares.PermissionMiddleware(roleService, constants.PERMISSION_SETTINGS_WRITE), settingHandler.DeleteSetting)
+			admin.POST("/admin/maintenance", middlewares.PermissionMiddleware(roleService, constants.PERMISSION_SETTINGS_WRITE), maintenanceHandler.SetMaintenance)
+			admin.GET("/admin/jwt-keys", middlewares.PermissionMiddleware(roleService, constants.PERMISSION_SETTINGS_READ), jwtKeyHandler.GetKeys)
 		}
 	}
 
 	return router
 }
+
+// envList returns the comma separated values of the environment variable, e.g. the extra field names
+// of HTTP_LOG_MASK_FIELDS that the request logger censors in addition to its defaults
+func envList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(utils.GetEnv(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// LOCAL_STORAGE_PATH is where files of the local storage are served
+const LOCAL_STORAGE_PATH = "/uploads"
+
+// newStorage returns the storage of uploaded files selected by STORAGE_DRIVER: local (the default) keeps them
+// in STORAGE_LOCAL_DIR and serves them under LOCAL_STORAGE_PATH, s3 keeps them in an S3 compatible bucket
+func newStorage() (storage.Storage, error) {
+	switch driver := utils.GetEnv("STORAGE_DRIVER", ""); driver {
+	case "", "local":
+		publicURL := utils.GetEnv("STORAGE_PUBLIC_URL", "")
+		if publicURL == "" {
+			publicURL = utils.GetEnv("APP_URL", "") + LOCAL_STORAGE_PATH
+		}
+		dir := utils.GetEnv("STORAGE_LOCAL_DIR", "")
+		if dir == "" {
+			dir = "./storage/uploads"
+		}
+		return storage.NewLocalStorage(dir, publicURL)
+	case "s3":
+		config := storage.S3Config{
+			Endpoint:        utils.GetEnv("S3_ENDPOINT", ""),
+			Region:          utils.GetEnv("S3_REGION", ""),
+			Bucket:          utils.GetEnv("S3_BUCKET", ""),
+			AccessKeyID:     utils.GetEnv("S3_ACCESS_KEY_ID", ""),
+			SecretAccessKey: utils.GetEnv("S3_SECRET_ACCESS_KEY", ""),
+			UsePathStyle:    utils.GetEnv("S3_USE_PATH_STYLE", "false") == "true",
+		}
+		if config.Bucket == "" || config.AccessKeyID == "" || config.SecretAccessKey == "" {
+			return nil, fmt.Errorf("S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY are required with STORAGE_DRIVER=s3")
+		}
+		if config.Region == "" {
+			config.Region = "us-east-1"
+		}
+		if config.Endpoint == "" {
+			config.Endpoint = "https://s3." + config.Region + ".amazonaws.com"
+		}
+		client := storage.NewS3Client(config)
+		publicURL := utils.GetEnv("STORAGE_PUBLIC_URL", "")
+		if publicURL == "" {
+			publicURL = client.BucketURL()
+		}
+		return storage.NewS3Storage(client, publicURL), nil
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER %q, expected local or s3", driver)
+	}
+}
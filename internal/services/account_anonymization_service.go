@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+// ACCOUNT_ANONYMIZATION_BATCH_SIZE is the number of accounts loaded at a time by AnonymizeExpired
+const ACCOUNT_ANONYMIZATION_BATCH_SIZE = 100
+
+type AccountAnonymizationService interface {
+	AnonymizeExpired(ctx context.Context) (int, error)
+}
+
+type accountAnonymizationServiceImpl struct {
+	repo         repositories.UserRepository
+	redisService RedisService
+	gracePeriod  time.Duration
+}
+
+func NewAccountAnonymizationService(repo repositories.UserRepository, redisService RedisService) AccountAnonymizationService {
+	return &accountAnonymizationServiceImpl{
+		repo:         repo,
+		redisService: redisService,
+		gracePeriod:  accountDeletionGracePeriodFromEnv(),
+	}
+}
+
+// AnonymizeExpired anonymizes every account whose deletion grace period has passed and returns how many were
+// anonymized. Accounts reactivated meanwhile are skipped. It stops at the first error or once ctx is done
+func (service *accountAnonymizationServiceImpl) AnonymizeExpired(ctx context.Context) (int, error) {
+	requestedBefore := time.Now().Add(-service.gracePeriod)
+	anonymized := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return anonymized, err
+		}
+
+		users, err := service.repo.FindPendingDeletion(ctx, requestedBefore, ACCOUNT_ANONYMIZATION_BATCH_SIZE)
+		if err != nil {
+			return anonymized, err
+		}
+
+		for _, user := range users {
+			ok, err := service.repo.Anonymize(ctx, anonymizedUser(user))
+			if err != nil {
+				return anonymized, err
+			}
+			if !ok {
+				continue
+			}
+			anonymized++
+			logger.WithContext(ctx).Infof("Anonymized account of user ID %d", user.ID)
+
+			for _, key := range []string{profileCacheKey(user.ID), userCacheKey(user.ID)} {
+				if err := CacheInvalidate(ctx, service.redisService, key); err != nil {
+					logger.WithContext(ctx).Warnf("Failed to invalidate cache key %s: %v", key, err)
+				}
+			}
+		}
+
+		// Anonymized and reactivated accounts no longer match, so the next batch starts after this one
+		if len(users) < ACCOUNT_ANONYMIZATION_BATCH_SIZE {
+			return anonymized, nil
+		}
+	}
+}
+
+// anonymizedUser returns the values the personal data of the user is overwritten with. The email is replaced
+// by a placeholder derived from its hash, which keeps the column unique without holding the address
+func anonymizedUser(user *models.User) *models.User {
+	return &models.User{
+		ID:       user.ID,
+		Email:    "deleted-" + utils.HashToken(user.Email)[:20] + "@deleted.invalid",
+		Password: "!", // Not a bcrypt hash, so no password matches it
+		Name:     "Deleted user",
+		Status:   constants.USER_STATUS_ANONYMIZED,
+	}
+}
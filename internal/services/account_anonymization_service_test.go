@@ -0,0 +1,108 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+func TestAccountAnonymizationService_AnonymizeExpired(t *testing.T) {
+	ctx := context.Background()
+	gracePeriodStart := func(requestedBefore time.Time) bool {
+		return requestedBefore.Before(time.Now().Add(-services.ACCOUNT_DELETION_GRACE_PERIOD + time.Minute))
+	}
+
+	t.Run("AnonymizesEveryExpiredAccount", func(t *testing.T) {
+		repo := new(mocks.MockUserRepository)
+		redisService := services.NewMemoryRedisService(0)
+		require.NoError(t, redisService.Set(ctx, constants.PROFILE+"1", "cached", 0))
+		users := []*models.User{{ID: 1, Email: "one@example.com"}, {ID: 2, Email: "two@example.com"}}
+		repo.On("FindPendingDeletion", mock.Anything, mock.MatchedBy(gracePeriodStart), services.ACCOUNT_ANONYMIZATION_BATCH_SIZE).Return(users, nil).Once()
+		var anonymized []*models.User
+		repo.On("Anonymize", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			anonymized = append(anonymized, args.Get(1).(*models.User))
+		}).Return(true, nil).Twice()
+
+		count, err := services.NewAccountAnonymizationService(repo, redisService).AnonymizeExpired(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+		repo.AssertExpectations(t)
+		require.Len(t, anonymized, 2)
+		for i, user := range anonymized {
+			assert.Equal(t, users[i].ID, user.ID)
+			assert.True(t, strings.HasPrefix(user.Email, "deleted-"))
+			assert.NotContains(t, user.Email, users[i].Email)
+			assert.LessOrEqual(t, len(user.Email), 45)
+			assert.Equal(t, "Deleted user", user.Name)
+			assert.Equal(t, constants.USER_STATUS_ANONYMIZED, user.Status)
+		}
+		assert.NotEqual(t, anonymized[0].Email, anonymized[1].Email)
+		exists, err := redisService.Exists(ctx, constants.PROFILE+"1")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("ContinuesWithTheNextBatch", func(t *testing.T) {
+		repo := new(mocks.MockUserRepository)
+		batch := make([]*models.User, services.ACCOUNT_ANONYMIZATION_BATCH_SIZE)
+		for i := range batch {
+			batch[i] = &models.User{ID: uint(i + 1), Email: "user" + strconv.Itoa(i) + "@example.com"}
+		}
+		repo.On("FindPendingDeletion", mock.Anything, mock.Anything, services.ACCOUNT_ANONYMIZATION_BATCH_SIZE).Return(batch, nil).Once()
+		repo.On("FindPendingDeletion", mock.Anything, mock.Anything, services.ACCOUNT_ANONYMIZATION_BATCH_SIZE).Return([]*models.User{}, nil).Once()
+		repo.On("Anonymize", mock.Anything, mock.Anything).Return(true, nil)
+
+		count, err := services.NewAccountAnonymizationService(repo, services.NewMemoryRedisService(0)).AnonymizeExpired(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, services.ACCOUNT_ANONYMIZATION_BATCH_SIZE, count)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("SkipsReactivatedAccounts", func(t *testing.T) {
+		repo := new(mocks.MockUserRepository)
+		repo.On("FindPendingDeletion", mock.Anything, mock.Anything, mock.Anything).Return([]*models.User{{ID: 1, Email: "one@example.com"}}, nil).Once()
+		repo.On("Anonymize", mock.Anything, mock.Anything).Return(false, nil).Once()
+
+		count, err := services.NewAccountAnonymizationService(repo, services.NewMemoryRedisService(0)).AnonymizeExpired(ctx)
+
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
+	t.Run("StopsAtTheFirstError", func(t *testing.T) {
+		repo := new(mocks.MockUserRepository)
+		users := []*models.User{{ID: 1, Email: "one@example.com"}, {ID: 2, Email: "two@example.com"}}
+		repo.On("FindPendingDeletion", mock.Anything, mock.Anything, mock.Anything).Return(users, nil).Once()
+		repo.On("Anonymize", mock.Anything, mock.Anything).Return(false, errors.New("db down")).Once()
+
+		count, err := services.NewAccountAnonymizationService(repo, services.NewMemoryRedisService(0)).AnonymizeExpired(ctx)
+
+		assert.Error(t, err)
+		assert.Zero(t, count)
+		repo.AssertNumberOfCalls(t, "Anonymize", 1)
+	})
+
+	t.Run("StopsWhenCancelled", func(t *testing.T) {
+		repo := new(mocks.MockUserRepository)
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := services.NewAccountAnonymizationService(repo, services.NewMemoryRedisService(0)).AnonymizeExpired(cancelled)
+
+		assert.ErrorIs(t, err, context.Canceled)
+		repo.AssertNotCalled(t, "FindPendingDeletion", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
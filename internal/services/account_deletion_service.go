@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+// ACCOUNT_DELETION_GRACE_PERIOD is how long a user can reactivate their account after asking to delete it,
+// unless ACCOUNT_DELETION_GRACE_DAYS overrides it
+const ACCOUNT_DELETION_GRACE_PERIOD = 30 * 24 * time.Hour
+
+type AccountDeletionService interface {
+	RequestDeletion(ctx context.Context, userID uint, password string, accessToken *dto.AccessTokenInfo) (*dto.AccountDeletionResponse, error)
+	Reactivate(ctx context.Context, email, password string) (uint, error)
+}
+
+type accountDeletionServiceImpl struct {
+	repo                repositories.UserRepository
+	bcryptService       BcryptService
+	refreshTokenService RefreshTokenService
+	redisService        RedisService
+	tokenBlacklist      TokenBlacklistService
+	gracePeriod         time.Duration
+}
+
+func NewAccountDeletionService(repo repositories.UserRepository, bcryptService BcryptService, refreshTokenService RefreshTokenService, redisService RedisService) AccountDeletionService {
+	return &accountDeletionServiceImpl{
+		repo:                repo,
+		bcryptService:       bcryptService,
+		refreshTokenService: refreshTokenService,
+		redisService:        redisService,
+		tokenBlacklist:      NewTokenBlacklistService(redisService),
+		gracePeriod:         accountDeletionGracePeriodFromEnv(),
+	}
+}
+
+// RequestDeletion schedules the account of the user for anonymization once the grace period has passed.
+// The password must be the current one. Every session is revoked at once, and the access token of the
+// request too; logging in is refused until the account is reactivated. Asking again changes nothing
+func (service *accountDeletionServiceImpl) RequestDeletion(ctx context.Context, userID uint, password string, accessToken *dto.AccessTokenInfo) (*dto.AccountDeletionResponse, error) {
+	user, err := service.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, apperror.NewNotFoundError("User not found")
+	}
+
+	if isValid := service.bcryptService.CheckPasswordHash(password, user.Password); !isValid {
+		return nil, apperror.NewInvalidPasswordError("Password is incorrect")
+	}
+
+	if user.Status != constants.USER_STATUS_PENDING_DELETION {
+		now := time.Now()
+		fromStatus := user.Status
+		user.Status = constants.USER_STATUS_PENDING_DELETION
+		user.DeletionRequestedAt = &now
+		updated, err := service.repo.UpdateStatus(ctx, user, fromStatus)
+		if err != nil {
+			return nil, apperror.NewDBUpdateError("Failed to schedule account deletion")
+		}
+		if !updated {
+			return nil, apperror.NewConflictError("The account was changed meanwhile, please try again")
+		}
+		logger.WithContext(ctx).Infof("Account deletion requested for user ID %d", userID)
+	}
+
+	// The deletion is already scheduled at this point, so failures to end the sessions are logged rather than returned
+	if _, err := service.refreshTokenService.DeleteAllByUserID(ctx, userID); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to revoke sessions after account deletion request for user ID %d: %v", userID, err)
+	}
+	if accessToken != nil {
+		if err := service.tokenBlacklist.Revoke(ctx, accessToken.ID, accessToken.ExpiresAt); err != nil {
+			logger.WithContext(ctx).Warnf("Failed to revoke access token for user ID %d: %v", userID, err)
+		}
+	}
+	service.invalidateUser(ctx, userID)
+
+	return &dto.AccountDeletionResponse{DeletionScheduledAt: user.DeletionRequestedAt.Add(service.gracePeriod)}, nil
+}
+
+// Reactivate cancels the deletion of the account with the given credentials and returns its user ID.
+// It fails once the grace period has passed, even if the account has not been anonymized yet
+func (service *accountDeletionServiceImpl) Reactivate(ctx context.Context, email, password string) (uint, error) {
+	email = utils.NormalizeEmail(email)
+	user, err := service.repo.FindByField(ctx, "email", email)
+	if err != nil || !service.bcryptService.CheckPasswordHash(password, user.Password) {
+		logger.WithContext(ctx).Warnf("Reactivation failed - invalid credentials for email: %s", email)
+		return 0, apperror.NewInvalidPasswordError("Invalid credentials")
+	}
+
+	if user.Status != constants.USER_STATUS_PENDING_DELETION || user.DeletionRequestedAt == nil {
+		return 0, apperror.NewBadRequestError("Account is not scheduled for deletion")
+	}
+	if time.Since(*user.DeletionRequestedAt) >= service.gracePeriod {
+		return 0, apperror.NewForbiddenError("The grace period to reactivate the account has ended")
+	}
+
+	user.Status = constants.USER_STATUS_ACTIVE
+	user.DeletionRequestedAt = nil
+	reactivated, err := service.repo.UpdateStatus(ctx, user, constants.USER_STATUS_PENDING_DELETION)
+	if err != nil {
+		return 0, apperror.NewDBUpdateError("Failed to reactivate account")
+	}
+	if !reactivated {
+		return 0, apperror.NewForbiddenError("The grace period to reactivate the account has ended")
+	}
+
+	service.invalidateUser(ctx, user.ID)
+	logger.WithContext(ctx).Infof("Account reactivated for user ID %d", user.ID)
+	return user.ID, nil
+}
+
+// invalidateUser drops the cached profile and user, which show the status of the account
+func (service *accountDeletionServiceImpl) invalidateUser(ctx context.Context, userID uint) {
+	for _, key := range []string{profileCacheKey(userID), userCacheKey(userID)} {
+		if err := CacheInvalidate(ctx, service.redisService, key); err != nil {
+			logger.WithContext(ctx).Warnf("Failed to invalidate cache key %s: %v", key, err)
+		}
+	}
+}
+
+// accountDeletionGracePeriodFromEnv reads ACCOUNT_DELETION_GRACE_DAYS. A value that is not a positive
+// number of days is logged and ACCOUNT_DELETION_GRACE_PERIOD is used instead
+func accountDeletionGracePeriodFromEnv() time.Duration {
+	value := utils.GetEnv("ACCOUNT_DELETION_GRACE_DAYS", "")
+	if value == "" {
+		return ACCOUNT_DELETION_GRACE_PERIOD
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days <= 0 {
+		logger.Warnf("Invalid ACCOUNT_DELETION_GRACE_DAYS %q, using the default of %s", value, ACCOUNT_DELETION_GRACE_PERIOD)
+		return ACCOUNT_DELETION_GRACE_PERIOD
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
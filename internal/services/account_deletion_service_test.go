@@ -0,0 +1,249 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+func TestAccountDeletionService_RequestDeletion(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (*mocks.MockUserRepository, *mocks.MockBcryptService, *mocks.MockRefreshTokenService, services.RedisService, services.AccountDeletionService) {
+		repo := new(mocks.MockUserRepository)
+		bcryptService := new(mocks.MockBcryptService)
+		refreshTokenService := new(mocks.MockRefreshTokenService)
+		redisService := services.NewMemoryRedisService(0)
+		return repo, bcryptService, refreshTokenService, redisService, services.NewAccountDeletionService(repo, bcryptService, refreshTokenService, redisService)
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		repo, bcryptService, refreshTokenService, redisService, service := setup()
+		user := &models.User{ID: 1, Password: "hashed", Status: constants.USER_STATUS_ACTIVE}
+		repo.On("GetByID", mock.Anything, uint(1)).Return(user, nil).Once()
+		bcryptService.On("CheckPasswordHash", "password123", "hashed").Return(true).Once()
+		repo.On("UpdateStatus", mock.Anything, mock.MatchedBy(func(user *models.User) bool {
+			return user.Status == constants.USER_STATUS_PENDING_DELETION && user.DeletionRequestedAt != nil
+		}), constants.USER_STATUS_ACTIVE).Return(true, nil).Once()
+		refreshTokenService.On("DeleteAllByUserID", mock.Anything, uint(1)).Return(int64(2), nil).Once()
+		accessToken := &dto.AccessTokenInfo{ID: "token-id", ExpiresAt: time.Now().Add(time.Hour)}
+		for _, key := range []string{constants.PROFILE + "1", constants.USER + "1"} {
+			require.NoError(t, redisService.Set(ctx, key, "cached", 0))
+		}
+
+		res, err := service.RequestDeletion(ctx, 1, "password123", accessToken)
+
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(services.ACCOUNT_DELETION_GRACE_PERIOD), res.DeletionScheduledAt, time.Minute)
+		repo.AssertExpectations(t)
+		refreshTokenService.AssertExpectations(t)
+		revoked, err := services.NewTokenBlacklistService(redisService).IsRevoked(ctx, "token-id")
+		require.NoError(t, err)
+		assert.True(t, revoked)
+		// The cached profile and user show the status, so neither may outlive the change
+		for _, key := range []string{constants.PROFILE + "1", constants.USER + "1"} {
+			exists, err := redisService.Exists(ctx, key)
+			require.NoError(t, err)
+			assert.False(t, exists, key)
+		}
+	})
+
+	t.Run("AlreadyPendingKeepsTheSchedule", func(t *testing.T) {
+		repo, bcryptService, refreshTokenService, _, service := setup()
+		requestedAt := time.Now().Add(-10 * 24 * time.Hour)
+		user := &models.User{ID: 1, Password: "hashed", Status: constants.USER_STATUS_PENDING_DELETION, DeletionRequestedAt: &requestedAt}
+		repo.On("GetByID", mock.Anything, uint(1)).Return(user, nil).Once()
+		bcryptService.On("CheckPasswordHash", "password123", "hashed").Return(true).Once()
+		refreshTokenService.On("DeleteAllByUserID", mock.Anything, uint(1)).Return(int64(0), nil).Once()
+
+		res, err := service.RequestDeletion(ctx, 1, "password123", nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, requestedAt.Add(services.ACCOUNT_DELETION_GRACE_PERIOD), res.DeletionScheduledAt)
+		repo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		repo, bcryptService, refreshTokenService, _, service := setup()
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&models.User{ID: 1, Password: "hashed"}, nil).Once()
+		bcryptService.On("CheckPasswordHash", "wrong", "hashed").Return(false).Once()
+
+		res, err := service.RequestDeletion(ctx, 1, "wrong", nil)
+
+		assert.Nil(t, res)
+		appErr, ok := apperror.ToAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperror.ErrInvalidPassword, appErr.Code)
+		repo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+		refreshTokenService.AssertNotCalled(t, "DeleteAllByUserID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("UserNotFound", func(t *testing.T) {
+		repo, _, _, _, service := setup()
+		repo.On("GetByID", mock.Anything, uint(1)).Return((*models.User)(nil), apperror.New(apperror.ErrNotFound, 1001, "User not found")).Once()
+
+		_, err := service.RequestDeletion(ctx, 1, "password123", nil)
+
+		appErr, ok := apperror.ToAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperror.ErrNotFound, appErr.Code)
+	})
+
+	t.Run("UpdateError", func(t *testing.T) {
+		repo, bcryptService, refreshTokenService, _, service := setup()
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&models.User{ID: 1, Password: "hashed", Status: constants.USER_STATUS_ACTIVE}, nil).Once()
+		bcryptService.On("CheckPasswordHash", "password123", "hashed").Return(true).Once()
+		repo.On("UpdateStatus", mock.Anything, mock.Anything, constants.USER_STATUS_ACTIVE).Return(false, errors.New("db down")).Once()
+
+		_, err := service.RequestDeletion(ctx, 1, "password123", nil)
+
+		appErr, ok := apperror.ToAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperror.ErrDBUpdate, appErr.Code)
+		refreshTokenService.AssertNotCalled(t, "DeleteAllByUserID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("SessionRevocationErrorDoesNotFail", func(t *testing.T) {
+		repo, bcryptService, refreshTokenService, _, service := setup()
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&models.User{ID: 1, Password: "hashed", Status: constants.USER_STATUS_ACTIVE}, nil).Once()
+		bcryptService.On("CheckPasswordHash", "password123", "hashed").Return(true).Once()
+		repo.On("UpdateStatus", mock.Anything, mock.Anything, constants.USER_STATUS_ACTIVE).Return(true, nil).Once()
+		refreshTokenService.On("DeleteAllByUserID", mock.Anything, uint(1)).Return(int64(0), errors.New("db down")).Once()
+
+		res, err := service.RequestDeletion(ctx, 1, "password123", nil)
+
+		require.NoError(t, err)
+		assert.NotNil(t, res)
+	})
+}
+
+func TestAccountDeletionService_Reactivate(t *testing.T) {
+	ctx := context.Background()
+	email := "pending@example.com"
+
+	setup := func() (*mocks.MockUserRepository, *mocks.MockBcryptService, services.AccountDeletionService) {
+		repo := new(mocks.MockUserRepository)
+		bcryptService := new(mocks.MockBcryptService)
+		return repo, bcryptService, services.NewAccountDeletionService(repo, bcryptService, new(mocks.MockRefreshTokenService), services.NewMemoryRedisService(0))
+	}
+	pendingUser := func(requestedAgo time.Duration) *models.User {
+		requestedAt := time.Now().Add(-requestedAgo)
+		return &models.User{ID: 1, Email: email, Password: "hashed", Status: constants.USER_STATUS_PENDING_DELETION, DeletionRequestedAt: &requestedAt}
+	}
+	assertCode := func(t *testing.T, err error, code int) {
+		appErr, ok := apperror.ToAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, code, appErr.Code)
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		repo, bcryptService, service := setup()
+		repo.On("FindByField", mock.Anything, "email", email).Return(pendingUser(24*time.Hour), nil).Once()
+		bcryptService.On("CheckPasswordHash", "password123", "hashed").Return(true).Once()
+		repo.On("UpdateStatus", mock.Anything, mock.MatchedBy(func(user *models.User) bool {
+			return user.Status == constants.USER_STATUS_ACTIVE && user.DeletionRequestedAt == nil
+		}), constants.USER_STATUS_PENDING_DELETION).Return(true, nil).Once()
+
+		userID, err := service.Reactivate(ctx, " Pending@Example.com ", "password123")
+
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), userID)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("InvalidatesCachedUser", func(t *testing.T) {
+		repo := new(mocks.MockUserRepository)
+		bcryptService := new(mocks.MockBcryptService)
+		redisService := services.NewMemoryRedisService(0)
+		service := services.NewAccountDeletionService(repo, bcryptService, new(mocks.MockRefreshTokenService), redisService)
+		repo.On("FindByField", mock.Anything, "email", email).Return(pendingUser(time.Hour), nil).Once()
+		bcryptService.On("CheckPasswordHash", "password123", "hashed").Return(true).Once()
+		repo.On("UpdateStatus", mock.Anything, mock.Anything, constants.USER_STATUS_PENDING_DELETION).Return(true, nil).Once()
+		for _, key := range []string{constants.PROFILE + "1", constants.USER + "1"} {
+			require.NoError(t, redisService.Set(ctx, key, "cached", 0))
+		}
+
+		_, err := service.Reactivate(ctx, email, "password123")
+
+		require.NoError(t, err)
+		for _, key := range []string{constants.PROFILE + "1", constants.USER + "1"} {
+			exists, err := redisService.Exists(ctx, key)
+			require.NoError(t, err)
+			assert.False(t, exists, key)
+		}
+	})
+
+	t.Run("InvalidCredentials", func(t *testing.T) {
+		repo, bcryptService, service := setup()
+		repo.On("FindByField", mock.Anything, "email", email).Return(pendingUser(24*time.Hour), nil).Once()
+		bcryptService.On("CheckPasswordHash", "wrong", "hashed").Return(false).Once()
+
+		_, err := service.Reactivate(ctx, email, "wrong")
+
+		assertCode(t, err, apperror.ErrInvalidPassword)
+		repo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("UnknownEmail", func(t *testing.T) {
+		repo, _, service := setup()
+		repo.On("FindByField", mock.Anything, "email", email).Return((*models.User)(nil), apperror.New(apperror.ErrNotFound, 1001, "User not found")).Once()
+
+		_, err := service.Reactivate(ctx, email, "password123")
+
+		assertCode(t, err, apperror.ErrInvalidPassword)
+	})
+
+	t.Run("NotPendingDeletion", func(t *testing.T) {
+		repo, bcryptService, service := setup()
+		repo.On("FindByField", mock.Anything, "email", email).Return(&models.User{ID: 1, Email: email, Password: "hashed", Status: constants.USER_STATUS_ACTIVE}, nil).Once()
+		bcryptService.On("CheckPasswordHash", "password123", "hashed").Return(true).Once()
+
+		_, err := service.Reactivate(ctx, email, "password123")
+
+		assertCode(t, err, apperror.ErrBadRequest)
+	})
+
+	t.Run("GracePeriodEnded", func(t *testing.T) {
+		repo, bcryptService, service := setup()
+		repo.On("FindByField", mock.Anything, "email", email).Return(pendingUser(services.ACCOUNT_DELETION_GRACE_PERIOD+time.Hour), nil).Once()
+		bcryptService.On("CheckPasswordHash", "password123", "hashed").Return(true).Once()
+
+		_, err := service.Reactivate(ctx, email, "password123")
+
+		assertCode(t, err, apperror.ErrForbidden)
+		repo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("AnonymizedMeanwhile", func(t *testing.T) {
+		repo, bcryptService, service := setup()
+		repo.On("FindByField", mock.Anything, "email", email).Return(pendingUser(24*time.Hour), nil).Once()
+		bcryptService.On("CheckPasswordHash", "password123", "hashed").Return(true).Once()
+		repo.On("UpdateStatus", mock.Anything, mock.Anything, constants.USER_STATUS_PENDING_DELETION).Return(false, nil).Once()
+
+		_, err := service.Reactivate(ctx, email, "password123")
+
+		assertCode(t, err, apperror.ErrForbidden)
+	})
+
+	t.Run("ConfiguredGracePeriod", func(t *testing.T) {
+		t.Setenv("ACCOUNT_DELETION_GRACE_DAYS", "7")
+		repo, bcryptService, service := setup()
+		repo.On("FindByField", mock.Anything, "email", email).Return(pendingUser(8*24*time.Hour), nil).Once()
+		bcryptService.On("CheckPasswordHash", "password123", "hashed").Return(true).Once()
+
+		_, err := service.Reactivate(ctx, email, "password123")
+
+		assertCode(t, err, apperror.ErrForbidden)
+	})
+}
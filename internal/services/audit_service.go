@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+// DEFAULT_AUDIT_BUFFER_SIZE is the number of entries queued when a non-positive size is given
+const DEFAULT_AUDIT_BUFFER_SIZE = 1000
+
+// AUDIT_BATCH_SIZE is the maximum number of entries written in one insert
+const AUDIT_BATCH_SIZE = 100
+
+type AuditService interface {
+	Record(ctx context.Context, log *models.AuditLog)
+	GetAuditLogs(ctx context.Context, opts dto.ListOptions, filter dto.AuditLogFilterInput) (*dto.Pagination[*models.AuditLog], error)
+	Close() error
+}
+
+// auditServiceImpl persists entries from a background goroutine so requests never wait on the insert.
+// Entries queued when the service closes are still written.
+type auditServiceImpl struct {
+	repo    repositories.AuditLogRepository
+	mu      sync.RWMutex
+	closed  bool
+	entries chan *models.AuditLog
+	done    chan struct{}
+}
+
+// NewAuditService starts the writer with room for bufferSize queued entries. Close must be called to flush them
+func NewAuditService(repo repositories.AuditLogRepository, bufferSize int) AuditService {
+	if bufferSize <= 0 {
+		bufferSize = DEFAULT_AUDIT_BUFFER_SIZE
+	}
+	service := &auditServiceImpl{
+		repo:    repo,
+		entries: make(chan *models.AuditLog, bufferSize),
+		done:    make(chan struct{}),
+	}
+	go service.run()
+	return service
+}
+
+// Record queues the entry. CreatedAt is set now, so it reflects the action rather than the write.
+// When the buffer is full or the service is closed, the entry is written synchronously instead of being dropped
+func (service *auditServiceImpl) Record(ctx context.Context, log *models.AuditLog) {
+	if log.CreatedAt.IsZero() {
+		log.CreatedAt = time.Now()
+	}
+
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+
+	if !service.closed {
+		select {
+		case service.entries <- log:
+			return
+		default:
+			logger.WithContext(ctx).Warnf("Audit buffer full, writing %s entry synchronously", log.Action)
+		}
+	}
+	service.write(context.WithoutCancel(ctx), []*models.AuditLog{log})
+}
+
+func (service *auditServiceImpl) GetAuditLogs(ctx context.Context, opts dto.ListOptions, filter dto.AuditLogFilterInput) (*dto.Pagination[*models.AuditLog], error) {
+	logs, err := service.repo.GetAuditLogs(ctx, opts, filter)
+	if err != nil {
+		if appErr, ok := apperror.ToAppError(err); ok && appErr.Code == apperror.ErrParseError {
+			return nil, err
+		}
+		logger.WithContext(ctx).Errorf("Failed to list audit logs: %v", err)
+		return nil, apperror.NewDBQueryError("Failed to get audit logs")
+	}
+	return logs, nil
+}
+
+// Close stops accepting queued entries and waits until those already queued are written
+func (service *auditServiceImpl) Close() error {
+	service.mu.Lock()
+	if service.closed {
+		service.mu.Unlock()
+		return nil
+	}
+	service.closed = true
+	close(service.entries)
+	service.mu.Unlock()
+
+	<-service.done
+	return nil
+}
+
+// run writes queued entries until the channel is closed, batching whatever has piled up since the last write
+func (service *auditServiceImpl) run() {
+	defer close(service.done)
+
+	for log := range service.entries {
+		batch := []*models.AuditLog{log}
+	collect:
+		for len(batch) < AUDIT_BATCH_SIZE {
+			select {
+			case next, ok := <-service.entries:
+				if !ok {
+					break collect
+				}
+				batch = append(batch, next)
+			default:
+				break collect
+			}
+		}
+		service.write(context.Background(), batch)
+	}
+}
+
+// write inserts the batch. Failed entries are logged in full so the trail can be rebuilt from the application log
+func (service *auditServiceImpl) write(ctx context.Context, batch []*models.AuditLog) {
+	if err := service.repo.CreateBatch(ctx, batch); err != nil {
+		for _, log := range batch {
+			logger.WithContext(ctx).Errorf("Failed to persist audit entry %s (actor %v, ip %s, metadata %s): %v",
+				log.Action, log.ActorUserID, log.IP, log.Metadata, err)
+		}
+	}
+}
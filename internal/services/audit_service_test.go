@@ -0,0 +1,194 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+// collectingAuditLogRepository records every written batch.
+// When gate is set, each write announces itself on started and then waits for gate to be closed
+type collectingAuditLogRepository struct {
+	mocks.MockAuditLogRepository
+	mu      sync.Mutex
+	batches [][]*models.AuditLog
+	started chan struct{}
+	gate    chan struct{}
+}
+
+func (r *collectingAuditLogRepository) CreateBatch(_ context.Context, logs []*models.AuditLog) error {
+	if r.gate != nil {
+		r.started <- struct{}{}
+		<-r.gate
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, logs)
+	return nil
+}
+
+func (r *collectingAuditLogRepository) logs() []*models.AuditLog {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var logs []*models.AuditLog
+	for _, batch := range r.batches {
+		logs = append(logs, batch...)
+	}
+	return logs
+}
+
+func (r *collectingAuditLogRepository) actions() []string {
+	var actions []string
+	for _, log := range r.logs() {
+		actions = append(actions, log.Action)
+	}
+	return actions
+}
+
+func TestAuditService_Record(t *testing.T) {
+	t.Run("CloseFlushesEveryQueuedEntry", func(t *testing.T) {
+		repo := &collectingAuditLogRepository{}
+		service := services.NewAuditService(repo, 0)
+
+		for i := range 1000 {
+			service.Record(context.Background(), &models.AuditLog{Action: fmt.Sprintf("action.%d", i)})
+		}
+		require.NoError(t, service.Close())
+
+		actions := repo.actions()
+		require.Len(t, actions, 1000)
+		// A single writer keeps the entries in the order they were recorded
+		assert.Equal(t, "action.0", actions[0])
+		assert.Equal(t, "action.999", actions[999])
+		for _, batch := range repo.batches {
+			assert.LessOrEqual(t, len(batch), services.AUDIT_BATCH_SIZE)
+		}
+	})
+
+	t.Run("ConcurrentRecordsAreNotLost", func(t *testing.T) {
+		repo := &collectingAuditLogRepository{}
+		service := services.NewAuditService(repo, 10)
+
+		var wg sync.WaitGroup
+		for range 20 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 50 {
+					service.Record(context.Background(), &models.AuditLog{Action: "auth.login"})
+				}
+			}()
+		}
+		wg.Wait()
+		require.NoError(t, service.Close())
+
+		assert.Len(t, repo.actions(), 1000)
+	})
+
+	t.Run("FullBufferWritesSynchronously", func(t *testing.T) {
+		repo := &collectingAuditLogRepository{started: make(chan struct{}, 3), gate: make(chan struct{})}
+		service := services.NewAuditService(repo, 1)
+
+		// The writer takes the first entry and blocks on it, the second fills the buffer
+		service.Record(context.Background(), &models.AuditLog{Action: "first"})
+		<-repo.started
+		service.Record(context.Background(), &models.AuditLog{Action: "queued"})
+
+		overflowed := make(chan struct{})
+		go func() {
+			service.Record(context.Background(), &models.AuditLog{Action: "overflow"})
+			close(overflowed)
+		}()
+		// The overflowing entry is written by the caller while the writer is still busy
+		<-repo.started
+		close(repo.gate)
+		<-overflowed
+		require.NoError(t, service.Close())
+
+		assert.ElementsMatch(t, []string{"first", "queued", "overflow"}, repo.actions())
+	})
+
+	t.Run("RecordAfterCloseIsWritten", func(t *testing.T) {
+		repo := &collectingAuditLogRepository{}
+		service := services.NewAuditService(repo, 0)
+		require.NoError(t, service.Close())
+		require.NoError(t, service.Close())
+
+		assert.NotPanics(t, func() {
+			service.Record(context.Background(), &models.AuditLog{Action: "late"})
+		})
+		assert.Equal(t, []string{"late"}, repo.actions())
+	})
+
+	t.Run("SetsCreatedAtWhenRecorded", func(t *testing.T) {
+		repo := &collectingAuditLogRepository{}
+		service := services.NewAuditService(repo, 0)
+		explicit := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		before := time.Now()
+		service.Record(context.Background(), &models.AuditLog{Action: "implicit"})
+		service.Record(context.Background(), &models.AuditLog{Action: "explicit", CreatedAt: explicit})
+		require.NoError(t, service.Close())
+
+		logs := repo.logs()
+		require.Len(t, logs, 2)
+		assert.False(t, logs[0].CreatedAt.Before(before))
+		assert.Equal(t, explicit, logs[1].CreatedAt)
+	})
+
+	t.Run("WriteFailureIsOnlyLogged", func(t *testing.T) {
+		repo := new(mocks.MockAuditLogRepository)
+		repo.On("CreateBatch", mock.Anything, mock.Anything).Return(errors.New("db down"))
+		service := services.NewAuditService(repo, 0)
+
+		service.Record(context.Background(), &models.AuditLog{Action: "auth.login"})
+		require.NoError(t, service.Close())
+
+		repo.AssertCalled(t, "CreateBatch", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuditService_GetAuditLogs(t *testing.T) {
+	opts := dto.ListOptions{Page: 1, Limit: 20, SortBy: "created_at", SortDir: "desc"}
+	action := dto.AuditLogFilterInput{Action: "auth.login"}
+
+	t.Run("Success", func(t *testing.T) {
+		repo := new(mocks.MockAuditLogRepository)
+		page := &dto.Pagination[*models.AuditLog]{Page: 1, Limit: 20, TotalItems: 1, TotalPages: 1, Data: []*models.AuditLog{{ID: 1, Action: "auth.login"}}}
+		repo.On("GetAuditLogs", mock.Anything, opts, action).Return(page, nil).Once()
+		service := services.NewAuditService(repo, 0)
+		defer service.Close()
+
+		result, err := service.GetAuditLogs(context.Background(), opts, action)
+
+		require.NoError(t, err)
+		assert.Equal(t, page, result)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("RepositoryError", func(t *testing.T) {
+		repo := new(mocks.MockAuditLogRepository)
+		repo.On("GetAuditLogs", mock.Anything, opts, action).Return(nil, errors.New("db down")).Once()
+		service := services.NewAuditService(repo, 0)
+		defer service.Close()
+
+		result, err := service.GetAuditLogs(context.Background(), opts, action)
+
+		assert.Nil(t, result)
+		appErr, ok := err.(*apperror.AppError)
+		require.True(t, ok)
+		assert.Equal(t, apperror.ErrDBQuery, appErr.Code)
+	})
+}
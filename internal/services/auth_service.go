@@ -2,16 +2,24 @@ package services
 
 import (
 	"context"
+	"errors"
+	"strconv"
+	"time"
 
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
 	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
 	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
 	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
 )
 
 type AuthService interface {
-	Login(ctx context.Context, email, password string, ipAddress string) (*dto.LoginResponse, error)
-	RefreshToken(ctx context.Context, refreshToken, accessToken string, ipAddress string) (*dto.LoginResponse, error)
+	Login(ctx context.Context, email, password string, ipAddress, userAgent, fingerprint string) (*dto.LoginResponse, error)
+	RefreshToken(ctx context.Context, refreshToken, accessToken string, ipAddress, userAgent, fingerprint string) (*dto.LoginResponse, error)
+	Logout(ctx context.Context, userID uint, refreshToken string, accessToken *dto.AccessTokenInfo) error
+	LogoutAll(ctx context.Context, userID uint, accessToken *dto.AccessTokenInfo) (int64, error)
 }
 
 type authServiceImpl struct {
@@ -19,29 +27,72 @@ type authServiceImpl struct {
 	refreshTokenService RefreshTokenService
 	bcryptService       BcryptService
 	jwtService          JWTService
+	redisService        RedisService
+	notificationService NotificationService
+	tokenBlacklist      TokenBlacklistService
+	maxLoginAttempts    int64
+	lockoutDuration     time.Duration
 }
 
-func NewAuthService(repo repositories.UserRepository, refreshTokenService RefreshTokenService, bcryptService BcryptService, jwtService JWTService) AuthService {
+func NewAuthService(repo repositories.UserRepository, refreshTokenService RefreshTokenService, bcryptService BcryptService, jwtService JWTService, redisService RedisService, notificationService NotificationService) AuthService {
 	return &authServiceImpl{
 		repo:                repo,
 		refreshTokenService: refreshTokenService,
 		bcryptService:       bcryptService,
 		jwtService:          jwtService,
+		redisService:        redisService,
+		notificationService: notificationService,
+		tokenBlacklist:      NewTokenBlacklistService(redisService),
+		maxLoginAttempts:    int64(utils.GetEnvAsInt("LOGIN_MAX_ATTEMPTS", 5)),
+		lockoutDuration:     time.Duration(utils.GetEnvAsInt("LOGIN_LOCKOUT_SECONDS", 900)) * time.Second,
 	}
 }
 
-func (service *authServiceImpl) Login(ctx context.Context, email, password string, ipAddress string) (*dto.LoginResponse, error) {
+// Login checks the credentials and issues a token pair. After maxLoginAttempts consecutive failures
+// for an email, further attempts are rejected until lockoutDuration has passed since the first failure.
+// The refresh token is bound to the device fingerprint if one is given. The response includes the user and their role names.
+func (service *authServiceImpl) Login(ctx context.Context, email, password string, ipAddress, userAgent, fingerprint string) (*dto.LoginResponse, error) {
+	// Only the email is normalized; the password is compared exactly as given
+	email = utils.NormalizeEmail(email)
 	logger.WithContext(ctx).Infof("Login attempt for email: %s", email)
 
+	failKey := constants.LOGIN_FAIL + email
+	if service.isLockedOut(ctx, failKey) {
+		logger.WithContext(ctx).Warnf("Login rejected - account locked for email: %s", email)
+		return nil, apperror.NewTooManyAttemptsError("Too many failed login attempts, please try again later")
+	}
+
 	user, err := service.repo.FindByField(ctx, "email", email)
 	if err != nil {
 		logger.WithContext(ctx).Warnf("Login failed - user not found: %s", email)
-		return nil, apperror.NewInvalidPasswordError("Invalid credentials")
+		return nil, service.recordLoginFailure(ctx, failKey)
 	}
 
 	if isValid := service.bcryptService.CheckPasswordHash(password, user.Password); !isValid {
 		logger.WithContext(ctx).Warnf("Login failed - invalid password for email: %s", email)
-		return nil, apperror.NewInvalidPasswordError("Invalid credentials")
+		return nil, service.recordLoginFailure(ctx, failKey)
+	}
+
+	if err := service.redisService.Delete(ctx, failKey); err != nil {
+		logger.WithContext(ctx).Warnf("Failed to reset failed login counter for email %s: %v", email, err)
+	}
+	service.rehashPassword(ctx, user, password)
+
+	if user.Status == constants.USER_STATUS_PENDING_DELETION {
+		logger.WithContext(ctx).Warnf("Login rejected - account pending deletion for user ID %d", user.ID)
+		return nil, apperror.NewAccountPendingDeletionError("Account is scheduled for deletion, reactivate it with POST /api/v1/reactivate")
+	}
+
+	if user.VerifiedAt == nil {
+		logger.WithContext(ctx).Warnf("Login rejected - email not verified for user ID %d", user.ID)
+		return nil, apperror.NewEmailNotVerifiedError("Email address has not been verified")
+	}
+
+	// Loaded before any token is issued, so a failure leaves no session behind
+	withRoles, err := service.repo.GetByIDWithRoles(ctx, user.ID)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to load roles of user ID %d: %v", user.ID, err)
+		return nil, apperror.NewDBQueryError("Failed to load user roles")
 	}
 
 	accessToken, err := service.jwtService.GenerateAccessToken(user.ID)
@@ -50,13 +101,21 @@ func (service *authServiceImpl) Login(ctx context.Context, email, password strin
 		return nil, apperror.NewInternalServerError("Failed to generate access token")
 	}
 
-	refreshToken, errToken := service.refreshTokenService.Create(ctx, user, ipAddress)
+	refreshToken, errToken := service.refreshTokenService.Create(ctx, user, ipAddress, userAgent, fingerprint)
 
 	if errToken != nil {
 		logger.WithContext(ctx).Errorf("Failed to create refresh token for user ID %d: %v", user.ID, errToken)
 		return nil, errToken
 	}
 
+	now := time.Now()
+	service.recordLastLogin(ctx, user, ipAddress, now)
+
+	// The session exists at this point, so a failure to notify does not fail the login
+	if err := service.notificationService.NotifyNewLogin(ctx, user, ipAddress, userAgent, now); err != nil {
+		logger.WithContext(ctx).Warnf("Failed to notify user ID %d of the login from %s: %v", user.ID, ipAddress, err)
+	}
+
 	logger.WithContext(ctx).Infof("Login successful for user ID %d", user.ID)
 
 	return &dto.LoginResponse{
@@ -68,13 +127,70 @@ func (service *authServiceImpl) Login(ctx context.Context, email, password strin
 			Token:     refreshToken.Token,
 			ExpiresAt: refreshToken.ExpiresAt,
 		},
+		User:               loginUser(user, withRoles.Roles),
+		MustChangePassword: user.MustChangePassword,
+		UserID:             user.ID,
 	}, nil
 }
 
-func (service *authServiceImpl) RefreshToken(ctx context.Context, refreshToken, accessToken string, ipAddress string) (*dto.LoginResponse, error) {
+// loginUser summarizes the user for the login response
+func loginUser(user *models.User, roles []models.Role) *dto.LoginUser {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = role.Name
+	}
+	return &dto.LoginUser{ID: user.ID, Name: user.Name, Email: user.Email, Roles: names}
+}
+
+// recordLastLogin saves when and from where the user logged in, shown to administrators in the user activity.
+// The login has succeeded already, so a failure is only logged
+func (service *authServiceImpl) recordLastLogin(ctx context.Context, user *models.User, ipAddress string, at time.Time) {
+	user.LastLoginAt = &at
+	user.LastLoginIP = &ipAddress
+	if err := service.repo.UpdateLastLogin(ctx, user); err != nil {
+		logger.WithContext(ctx).Warnf("Failed to record last login of user ID %d: %v", user.ID, err)
+	}
+}
+
+// rehashPassword re-hashes the password of the user if their hash was made with a lower cost than the configured
+// one, e.g. after BCRYPT_COST was raised. The login only has the plain password, so this is the only chance to
+// upgrade the hash without a reset. Failures are logged and the old hash is kept, the login goes on regardless
+func (service *authServiceImpl) rehashPassword(ctx context.Context, user *models.User, password string) {
+	if !service.bcryptService.NeedsRehash(user.Password) {
+		return
+	}
+	hash, err := service.bcryptService.HashPassword(password)
+	if err != nil {
+		logger.WithContext(ctx).Warnf("Failed to rehash password of user ID %d: %v", user.ID, err)
+		return
+	}
+
+	// Only the password column is written, and only if the password was not changed since the user was loaded
+	oldHash := user.Password
+	user.Password = hash
+	saved, err := service.repo.UpdatePasswordIfUnchanged(ctx, user, oldHash)
+	if err != nil {
+		user.Password = oldHash
+		logger.WithContext(ctx).Warnf("Failed to save rehashed password of user ID %d: %v", user.ID, err)
+		return
+	}
+	if !saved {
+		user.Password = oldHash
+		logger.WithContext(ctx).Infof("Not rehashing password of user ID %d, which was changed meanwhile", user.ID)
+		return
+	}
+	logger.WithContext(ctx).Infof("Rehashed password of user ID %d with the current cost", user.ID)
+}
+
+// RefreshToken rotates the refresh token and issues a new access token. A refresh token presented from another
+// device fails with ErrDeviceMismatch in the enforce fingerprint mode, see RefreshTokenService.Update
+func (service *authServiceImpl) RefreshToken(ctx context.Context, refreshToken, accessToken string, ipAddress, userAgent, fingerprint string) (*dto.LoginResponse, error) {
 	logger.WithContext(ctx).Infof("Token refresh attempt")
 
-	refreshResult, err := service.refreshTokenService.Update(ctx, refreshToken, ipAddress)
+	refreshResult, err := service.refreshTokenService.Update(ctx, refreshToken, ipAddress, userAgent, fingerprint)
+	if appErr, ok := apperror.ToAppError(err); ok && appErr.Code == apperror.ErrDeviceMismatch {
+		return nil, err
+	}
 	if err != nil {
 		logger.WithContext(ctx).Warnf("Token refresh failed - invalid refresh token")
 		return nil, apperror.NewUnauthorizedError("Invalid refresh token")
@@ -119,5 +235,66 @@ func (service *authServiceImpl) RefreshToken(ctx context.Context, refreshToken,
 			Token:     refreshResult.Token.Token,
 			ExpiresAt: refreshResult.Token.ExpiresAt,
 		},
+		UserID:              user.ID,
+		FingerprintMismatch: refreshResult.FingerprintMismatch != nil,
 	}, nil
 }
+
+// isLockedOut reports whether the failed login counter has reached the limit.
+// Cache errors are logged and treated as not locked so an unavailable cache does not block logins.
+func (service *authServiceImpl) isLockedOut(ctx context.Context, failKey string) bool {
+	value, err := service.redisService.Get(ctx, failKey)
+	if err != nil {
+		if !errors.Is(err, ErrCacheMiss) {
+			logger.WithContext(ctx).Warnf("Failed to read failed login counter %s: %v", failKey, err)
+		}
+		return false
+	}
+	count, err := strconv.ParseInt(value, 10, 64)
+	return err == nil && count >= service.maxLoginAttempts
+}
+
+// recordLoginFailure increments the failed login counter and returns the error for the failed attempt
+func (service *authServiceImpl) recordLoginFailure(ctx context.Context, failKey string) error {
+	count, err := service.redisService.Incr(ctx, failKey, service.lockoutDuration)
+	if err != nil {
+		logger.WithContext(ctx).Warnf("Failed to increment failed login counter %s: %v", failKey, err)
+	} else if count >= service.maxLoginAttempts {
+		logger.WithContext(ctx).Warnf("Account locked after %d failed login attempts: %s", count, failKey)
+		return apperror.NewTooManyAttemptsError("Too many failed login attempts, please try again later")
+	}
+	return apperror.NewInvalidPasswordError("Invalid credentials")
+}
+
+// Logout revokes the given refresh token of the user. It succeeds even if the token was already revoked or expired.
+func (service *authServiceImpl) Logout(ctx context.Context, userID uint, refreshToken string, accessToken *dto.AccessTokenInfo) error {
+	if err := service.refreshTokenService.Delete(ctx, userID, refreshToken); err != nil {
+		return err
+	}
+	service.revokeAccessToken(ctx, userID, accessToken)
+
+	logger.WithContext(ctx).Infof("Logout successful for user ID %d", userID)
+	return nil
+}
+
+// LogoutAll revokes every refresh token of the user and the access token of the request,
+// and returns how many refresh tokens were revoked
+func (service *authServiceImpl) LogoutAll(ctx context.Context, userID uint, accessToken *dto.AccessTokenInfo) (int64, error) {
+	count, err := service.refreshTokenService.DeleteAllByUserID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	service.revokeAccessToken(ctx, userID, accessToken)
+	return count, nil
+}
+
+// revokeAccessToken blacklists the access token for the rest of its lifetime. The refresh tokens are
+// already revoked, so a failure only leaves the access token usable until it expires and is not returned
+func (service *authServiceImpl) revokeAccessToken(ctx context.Context, userID uint, accessToken *dto.AccessTokenInfo) {
+	if accessToken == nil {
+		return
+	}
+	if err := service.tokenBlacklist.Revoke(ctx, accessToken.ID, accessToken.ExpiresAt); err != nil {
+		logger.WithContext(ctx).Warnf("Failed to revoke access token for user ID %d: %v", userID, err)
+	}
+}
@@ -3,18 +3,23 @@ package services_test
 import (
 	"context"
 	"errors"
+	"net/http"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
 	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
 	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
 	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -25,19 +30,31 @@ type AuthServiceTestSuite struct {
 	service             services.AuthService
 	bcryptService       *mocks.MockBcryptService
 	jwtService          *mocks.MockJWTService
+	redisService        services.RedisService
+	notificationService *mocks.MockNotificationService
 }
 
 func (s *AuthServiceTestSuite) SetupTest() {
 	s.repo = new(mocks.MockUserRepository)
+	// Recording the last login is covered by TestLoginRecordsLastLogin
+	s.repo.On("UpdateLastLogin", mock.Anything, mock.Anything).Return(nil).Maybe()
+	// Every user has the editor role unless a test says otherwise, see TestLoginRoleLoadError
+	s.repo.On("GetByIDWithRoles", mock.Anything, mock.Anything).Return(&models.User{Roles: []models.Role{{ID: 2, Name: "editor"}}}, nil).Maybe()
 	s.refreshTokenService = new(mocks.MockRefreshTokenService)
 	s.bcryptService = new(mocks.MockBcryptService)
+	// Stored hashes are current unless a test says otherwise, see TestLoginRehashesOutdatedPassword
+	s.bcryptService.On("NeedsRehash", mock.Anything).Return(false).Maybe()
 	s.jwtService = new(mocks.MockJWTService)
+	s.redisService = services.NewMemoryRedisService(0)
+	s.notificationService = new(mocks.MockNotificationService)
 
 	s.service = services.NewAuthService(
 		s.repo,
 		s.refreshTokenService,
 		s.bcryptService,
 		s.jwtService,
+		s.redisService,
+		s.notificationService,
 	)
 }
 
@@ -46,6 +63,8 @@ func (s *AuthServiceTestSuite) TestLogin() {
 	email := "test@example.com"
 	password := "password123"
 	ipAddress := "127.0.0.1"
+	userAgent := "Mozilla/5.0"
+	verifiedAt := time.Now()
 
 	tests := []struct {
 		name       string
@@ -56,17 +75,30 @@ func (s *AuthServiceTestSuite) TestLogin() {
 		{
 			name: "Success",
 			setupMocks: func() {
-				user := &models.User{ID: 1, Email: email, Password: "hashed_password"}
+				user := &models.User{ID: 1, Email: email, Password: "hashed_password", VerifiedAt: &verifiedAt}
 				s.repo.On("FindByField", mock.Anything, "email", email).Return(user, nil)
 				s.bcryptService.On("CheckPasswordHash", password, user.Password).Return(true)
 				s.jwtService.On("GenerateAccessToken", user.ID).Return(&dto.JwtResult{
 					Token:     "mocked-access-token",
 					ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
 				}, nil)
-				s.refreshTokenService.On("Create", mock.Anything, user, ipAddress).Return(&dto.JwtResult{
+				s.refreshTokenService.On("Create", mock.Anything, user, ipAddress, userAgent, "").Return(&dto.JwtResult{
 					Token:     "mocked-refresh-token",
 					ExpiresAt: time.Now().Add(24 * time.Hour).Unix(),
 				}, nil)
+				s.notificationService.On("NotifyNewLogin", mock.Anything, user, ipAddress, userAgent, mock.AnythingOfType("time.Time")).Return(nil).Once()
+			},
+		},
+		{
+			// The session is already created, so the login succeeds whatever happens to the email
+			name: "NotificationErrorDoesNotFailLogin",
+			setupMocks: func() {
+				user := &models.User{ID: 1, Email: email, Password: "hashed_password", VerifiedAt: &verifiedAt}
+				s.repo.On("FindByField", mock.Anything, "email", email).Return(user, nil)
+				s.bcryptService.On("CheckPasswordHash", password, user.Password).Return(true)
+				s.jwtService.On("GenerateAccessToken", user.ID).Return(&dto.JwtResult{Token: "mocked-access-token"}, nil)
+				s.refreshTokenService.On("Create", mock.Anything, user, ipAddress, userAgent, "").Return(&dto.JwtResult{Token: "mocked-refresh-token"}, nil)
+				s.notificationService.On("NotifyNewLogin", mock.Anything, user, ipAddress, userAgent, mock.AnythingOfType("time.Time")).Return(errors.New("smtp down")).Once()
 			},
 		},
 		{
@@ -80,17 +112,38 @@ func (s *AuthServiceTestSuite) TestLogin() {
 		{
 			name: "InvalidPassword",
 			setupMocks: func() {
-				user := &models.User{ID: 1, Email: email, Password: "hashed_password"}
+				user := &models.User{ID: 1, Email: email, Password: "hashed_password", VerifiedAt: &verifiedAt}
 				s.repo.On("FindByField", mock.Anything, "email", email).Return(user, nil)
 				s.bcryptService.On("CheckPasswordHash", password, user.Password).Return(false)
 			},
 			expectErr: true,
 			errCode:   apperror.ErrInvalidPassword,
 		},
+		{
+			name: "EmailNotVerified",
+			setupMocks: func() {
+				user := &models.User{ID: 1, Email: email, Password: "hashed_password"}
+				s.repo.On("FindByField", mock.Anything, "email", email).Return(user, nil)
+				s.bcryptService.On("CheckPasswordHash", password, user.Password).Return(true)
+			},
+			expectErr: true,
+			errCode:   apperror.ErrEmailNotVerified,
+		},
+		{
+			name: "AccountPendingDeletion",
+			setupMocks: func() {
+				requestedAt := time.Now().Add(-24 * time.Hour)
+				user := &models.User{ID: 1, Email: email, Password: "hashed_password", VerifiedAt: &verifiedAt, Status: constants.USER_STATUS_PENDING_DELETION, DeletionRequestedAt: &requestedAt}
+				s.repo.On("FindByField", mock.Anything, "email", email).Return(user, nil)
+				s.bcryptService.On("CheckPasswordHash", password, user.Password).Return(true)
+			},
+			expectErr: true,
+			errCode:   apperror.ErrAccountPendingDeletion,
+		},
 		{
 			name: "JwtError",
 			setupMocks: func() {
-				user := &models.User{ID: 1, Email: email, Password: utils.HashPassword(password)}
+				user := &models.User{ID: 1, Email: email, Password: utils.HashPassword(password), VerifiedAt: &verifiedAt}
 				s.repo.On("FindByField", mock.Anything, "email", email).Return(user, nil)
 				s.bcryptService.On("CheckPasswordHash", password, user.Password).Return(true)
 				s.jwtService.On("GenerateAccessToken", user.ID).Return(&dto.JwtResult{}, errors.New("Failed to generate JWT token"))
@@ -101,14 +154,14 @@ func (s *AuthServiceTestSuite) TestLogin() {
 		{
 			name: "RefreshTokenCreateError",
 			setupMocks: func() {
-				user := &models.User{ID: 1, Email: email, Password: "hashed_password"}
+				user := &models.User{ID: 1, Email: email, Password: "hashed_password", VerifiedAt: &verifiedAt}
 				s.repo.On("FindByField", mock.Anything, "email", email).Return(user, nil)
 				s.bcryptService.On("CheckPasswordHash", password, user.Password).Return(true)
 				s.jwtService.On("GenerateAccessToken", user.ID).Return(&dto.JwtResult{
 					Token:     "mocked-access-token",
 					ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
 				}, nil)
-				s.refreshTokenService.On("Create", mock.Anything, user, ipAddress).Return((*dto.JwtResult)(nil), errors.New("refresh create failed"))
+				s.refreshTokenService.On("Create", mock.Anything, user, ipAddress, userAgent, "").Return((*dto.JwtResult)(nil), errors.New("refresh create failed"))
 			},
 			expectErr: true,
 		},
@@ -120,7 +173,7 @@ func (s *AuthServiceTestSuite) TestLogin() {
 			s.SetupTest()
 			tt.setupMocks()
 
-			resp, err := s.service.Login(context.Background(), email, password, ipAddress)
+			resp, err := s.service.Login(context.Background(), email, password, ipAddress, userAgent, "")
 
 			if tt.expectErr {
 				assert.Error(t, err)
@@ -128,20 +181,280 @@ func (s *AuthServiceTestSuite) TestLogin() {
 				if appErr, ok := err.(*apperror.AppError); ok {
 					assert.Equal(t, tt.errCode, appErr.Code)
 				}
+				// Failed logins are never notified
+				s.notificationService.AssertNotCalled(t, "NotifyNewLogin", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 			} else {
 				assert.NoError(t, err)
 				assert.NotNil(t, resp)
 				assert.Equal(t, "mocked-refresh-token", resp.RefreshToken.Token)
+				assert.Equal(t, &dto.LoginUser{ID: 1, Email: email, Roles: []string{"editor"}}, resp.User)
+				s.notificationService.AssertExpectations(t)
 			}
 		})
 	}
 }
 
+func (s *AuthServiceTestSuite) TestLoginRoleLoadError() {
+	verifiedAt := time.Now()
+	user := &models.User{ID: 1, Email: "test@example.com", Password: "hashed_password", VerifiedAt: &verifiedAt}
+	repo := new(mocks.MockUserRepository)
+	repo.On("FindByField", mock.Anything, "email", user.Email).Return(user, nil)
+	repo.On("GetByIDWithRoles", mock.Anything, user.ID).Return((*models.User)(nil), errors.New("db down"))
+	s.bcryptService.On("CheckPasswordHash", "password123", user.Password).Return(true)
+	service := services.NewAuthService(repo, s.refreshTokenService, s.bcryptService, s.jwtService, s.redisService, s.notificationService)
+
+	resp, err := service.Login(context.Background(), user.Email, "password123", "127.0.0.1", "Mozilla/5.0", "")
+
+	assert.Nil(s.T(), resp)
+	appErr, ok := apperror.ToAppError(err)
+	require.True(s.T(), ok)
+	assert.Equal(s.T(), apperror.ErrDBQuery, appErr.Code)
+	// No token is issued without the roles
+	s.jwtService.AssertNotCalled(s.T(), "GenerateAccessToken", mock.Anything)
+	s.refreshTokenService.AssertNotCalled(s.T(), "Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *AuthServiceTestSuite) TestLoginNormalizesEmail() {
+	verifiedAt := time.Now()
+	user := &models.User{ID: 1, Email: "user@example.com", Password: "hashed_password", VerifiedAt: &verifiedAt}
+	s.repo.On("FindByField", mock.Anything, "email", "user@example.com").Return(user, nil)
+	// The password keeps its case
+	s.bcryptService.On("CheckPasswordHash", "PassWord123", user.Password).Return(true)
+	s.jwtService.On("GenerateAccessToken", user.ID).Return(&dto.JwtResult{Token: "mocked-access-token"}, nil)
+	s.refreshTokenService.On("Create", mock.Anything, user, "127.0.0.1", "Mozilla/5.0", "").Return(&dto.JwtResult{Token: "mocked-refresh-token"}, nil)
+	s.notificationService.On("NotifyNewLogin", mock.Anything, user, "127.0.0.1", "Mozilla/5.0", mock.AnythingOfType("time.Time")).Return(nil)
+
+	resp, err := s.service.Login(context.Background(), "  USER@EXAMPLE.COM ", "PassWord123", "127.0.0.1", "Mozilla/5.0", "")
+
+	s.Require().NoError(err)
+	s.Equal(user.ID, resp.UserID)
+	s.repo.AssertExpectations(s.T())
+	s.bcryptService.AssertExpectations(s.T())
+}
+
+func (s *AuthServiceTestSuite) TestLoginRehashesOutdatedPassword() {
+	verifiedAt := time.Now()
+	// login runs a successful login with its own bcrypt mock, whose stored hash needs a rehash
+	login := func(t *testing.T, hashErr error, saved bool, updateErr error) (*models.User, *dto.LoginResponse, error) {
+		s.SetupTest()
+		user := &models.User{ID: 1, Email: "user@example.com", Password: "old-cost-hash", VerifiedAt: &verifiedAt}
+		bcryptService := new(mocks.MockBcryptService)
+		bcryptService.On("CheckPasswordHash", "password123", "old-cost-hash").Return(true).Once()
+		bcryptService.On("NeedsRehash", "old-cost-hash").Return(true).Once()
+		bcryptService.On("HashPassword", "password123").Return("new-cost-hash", hashErr).Once()
+		if hashErr == nil {
+			s.repo.On("UpdatePasswordIfUnchanged", mock.Anything, user, "old-cost-hash").Return(saved, updateErr).Once()
+		}
+		s.repo.On("FindByField", mock.Anything, "email", user.Email).Return(user, nil).Once()
+		s.jwtService.On("GenerateAccessToken", user.ID).Return(&dto.JwtResult{Token: "mocked-access-token"}, nil).Once()
+		s.refreshTokenService.On("Create", mock.Anything, user, "127.0.0.1", "Mozilla/5.0", "").Return(&dto.JwtResult{Token: "mocked-refresh-token"}, nil).Once()
+		s.notificationService.On("NotifyNewLogin", mock.Anything, user, "127.0.0.1", "Mozilla/5.0", mock.AnythingOfType("time.Time")).Return(nil).Once()
+		service := services.NewAuthService(s.repo, s.refreshTokenService, bcryptService, s.jwtService, s.redisService, s.notificationService)
+
+		resp, err := service.Login(context.Background(), user.Email, "password123", "127.0.0.1", "Mozilla/5.0", "")
+
+		bcryptService.AssertExpectations(t)
+		s.repo.AssertExpectations(t)
+		return user, resp, err
+	}
+
+	s.T().Run("Success", func(t *testing.T) {
+		user, resp, err := login(t, nil, true, nil)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, resp)
+		assert.Equal(t, "new-cost-hash", user.Password)
+	})
+
+	s.T().Run("HashFailureDoesNotFailLogin", func(t *testing.T) {
+		user, resp, err := login(t, errors.New("hash failed"), false, nil)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, resp)
+		assert.Equal(t, "old-cost-hash", user.Password)
+	})
+
+	s.T().Run("UpdateFailureDoesNotFailLogin", func(t *testing.T) {
+		user, resp, err := login(t, nil, false, errors.New("db down"))
+
+		assert.NoError(t, err)
+		assert.NotNil(t, resp)
+		assert.Equal(t, "old-cost-hash", user.Password)
+	})
+
+	s.T().Run("PasswordChangedMeanwhileIsKept", func(t *testing.T) {
+		user, resp, err := login(t, nil, false, nil)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, resp)
+		assert.Equal(t, "old-cost-hash", user.Password)
+	})
+}
+
+func (s *AuthServiceTestSuite) TestLoginRecordsLastLogin() {
+	verifiedAt := time.Now()
+	// login runs a successful login whose last login update returns updateErr
+	login := func(t *testing.T, updateErr error) (*models.User, *dto.LoginResponse, error) {
+		s.SetupTest()
+		s.repo.ExpectedCalls = nil
+		user := &models.User{ID: 1, Email: "user@example.com", Password: "hashed_password", VerifiedAt: &verifiedAt}
+		s.repo.On("FindByField", mock.Anything, "email", user.Email).Return(user, nil).Once()
+		s.repo.On("UpdateLastLogin", mock.Anything, user).Return(updateErr).Once()
+		s.repo.On("GetByIDWithRoles", mock.Anything, user.ID).Return(&models.User{ID: user.ID}, nil).Once()
+		s.bcryptService.On("CheckPasswordHash", "password123", user.Password).Return(true).Once()
+		s.jwtService.On("GenerateAccessToken", user.ID).Return(&dto.JwtResult{Token: "mocked-access-token"}, nil).Once()
+		s.refreshTokenService.On("Create", mock.Anything, user, "127.0.0.1", "Mozilla/5.0", "").Return(&dto.JwtResult{Token: "mocked-refresh-token"}, nil).Once()
+		s.notificationService.On("NotifyNewLogin", mock.Anything, user, "127.0.0.1", "Mozilla/5.0", mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+		resp, err := s.service.Login(context.Background(), user.Email, "password123", "127.0.0.1", "Mozilla/5.0", "")
+
+		s.repo.AssertExpectations(t)
+		return user, resp, err
+	}
+
+	s.T().Run("Success", func(t *testing.T) {
+		before := time.Now()
+		user, resp, err := login(t, nil)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, resp)
+		if assert.NotNil(t, user.LastLoginAt) {
+			assert.False(t, user.LastLoginAt.Before(before))
+		}
+		assert.Equal(t, "127.0.0.1", *user.LastLoginIP)
+	})
+
+	s.T().Run("UpdateFailureDoesNotFailLogin", func(t *testing.T) {
+		_, resp, err := login(t, errors.New("db error"))
+
+		assert.NoError(t, err)
+		assert.NotNil(t, resp)
+	})
+}
+
+func (s *AuthServiceTestSuite) TestLoginLockout() {
+	email := "locked@example.com"
+	ipAddress := "127.0.0.1"
+	userAgent := "Mozilla/5.0"
+	verifiedAt := time.Now()
+	user := &models.User{ID: 1, Email: email, Password: "hashed_password", VerifiedAt: &verifiedAt}
+
+	s.T().Run("LocksAfterMaxAttempts", func(t *testing.T) {
+		s.SetupTest()
+		s.repo.On("FindByField", mock.Anything, "email", email).Return(user, nil)
+		s.bcryptService.On("CheckPasswordHash", "wrong", user.Password).Return(false)
+
+		for i := 1; i < 5; i++ {
+			_, err := s.service.Login(context.Background(), email, "wrong", ipAddress, userAgent, "")
+			appErr, ok := err.(*apperror.AppError)
+			assert.True(t, ok)
+			assert.Equal(t, apperror.ErrInvalidPassword, appErr.Code, "attempt %d", i)
+		}
+
+		_, err := s.service.Login(context.Background(), email, "wrong", ipAddress, userAgent, "")
+		appErr, ok := err.(*apperror.AppError)
+		assert.True(t, ok)
+		assert.Equal(t, apperror.ErrTooManyAttempts, appErr.Code)
+		assert.Equal(t, http.StatusTooManyRequests, appErr.HttpStatusCode)
+
+		// The correct password is rejected too while locked, and is not even checked
+		resp, err := s.service.Login(context.Background(), email, "password123", ipAddress, userAgent, "")
+		assert.Nil(t, resp)
+		appErr, ok = err.(*apperror.AppError)
+		assert.True(t, ok)
+		assert.Equal(t, apperror.ErrTooManyAttempts, appErr.Code)
+		s.bcryptService.AssertNotCalled(t, "CheckPasswordHash", "password123", user.Password)
+	})
+
+	s.T().Run("UnknownEmailCountsTowardsLockout", func(t *testing.T) {
+		s.SetupTest()
+		s.repo.On("FindByField", mock.Anything, "email", "ghost@example.com").Return((*models.User)(nil), gorm.ErrRecordNotFound)
+
+		for i := 0; i < 5; i++ {
+			_, _ = s.service.Login(context.Background(), "ghost@example.com", "wrong", ipAddress, userAgent, "")
+		}
+
+		_, err := s.service.Login(context.Background(), "GHOST@example.com ", "wrong", ipAddress, userAgent, "")
+		appErr, ok := err.(*apperror.AppError)
+		assert.True(t, ok)
+		assert.Equal(t, apperror.ErrTooManyAttempts, appErr.Code)
+	})
+
+	s.T().Run("SuccessResetsCounter", func(t *testing.T) {
+		s.SetupTest()
+		s.repo.On("FindByField", mock.Anything, "email", email).Return(user, nil)
+		s.bcryptService.On("CheckPasswordHash", "wrong", user.Password).Return(false)
+		s.bcryptService.On("CheckPasswordHash", "password123", user.Password).Return(true)
+		s.jwtService.On("GenerateAccessToken", user.ID).Return(&dto.JwtResult{Token: "access"}, nil)
+		s.refreshTokenService.On("Create", mock.Anything, user, ipAddress, userAgent, "").Return(&dto.JwtResult{Token: "refresh"}, nil)
+		s.notificationService.On("NotifyNewLogin", mock.Anything, user, ipAddress, userAgent, mock.AnythingOfType("time.Time")).Return(nil)
+
+		for i := 0; i < 4; i++ {
+			_, _ = s.service.Login(context.Background(), email, "wrong", ipAddress, userAgent, "")
+		}
+		_, err := s.service.Login(context.Background(), email, "password123", ipAddress, userAgent, "")
+		assert.NoError(t, err)
+
+		exists, _ := s.redisService.Exists(context.Background(), constants.LOGIN_FAIL+email)
+		assert.False(t, exists)
+
+		_, err = s.service.Login(context.Background(), email, "wrong", ipAddress, userAgent, "")
+		appErr, ok := err.(*apperror.AppError)
+		assert.True(t, ok)
+		assert.Equal(t, apperror.ErrInvalidPassword, appErr.Code)
+	})
+
+	s.T().Run("ConfigurableThreshold", func(t *testing.T) {
+		t.Setenv("LOGIN_MAX_ATTEMPTS", "2")
+		s.SetupTest()
+		s.repo.On("FindByField", mock.Anything, "email", email).Return(user, nil)
+		s.bcryptService.On("CheckPasswordHash", "wrong", user.Password).Return(false)
+
+		_, err := s.service.Login(context.Background(), email, "wrong", ipAddress, userAgent, "")
+		assert.Equal(t, apperror.ErrInvalidPassword, err.(*apperror.AppError).Code)
+		_, err = s.service.Login(context.Background(), email, "wrong", ipAddress, userAgent, "")
+		assert.Equal(t, apperror.ErrTooManyAttempts, err.(*apperror.AppError).Code)
+	})
+
+	s.T().Run("CacheErrorFailsOpen", func(t *testing.T) {
+		s.SetupTest()
+		redisService := new(mocks.MockRedisService)
+		service := services.NewAuthService(s.repo, s.refreshTokenService, s.bcryptService, s.jwtService, redisService, s.notificationService)
+
+		redisService.On("Get", mock.Anything, constants.LOGIN_FAIL+email).Return("", errors.New("redis down"))
+		redisService.On("Incr", mock.Anything, constants.LOGIN_FAIL+email, 15*time.Minute).Return(int64(0), errors.New("redis down"))
+		s.repo.On("FindByField", mock.Anything, "email", email).Return(user, nil)
+		s.bcryptService.On("CheckPasswordHash", "wrong", user.Password).Return(false)
+
+		_, err := service.Login(context.Background(), email, "wrong", ipAddress, userAgent, "")
+		appErr, ok := err.(*apperror.AppError)
+		assert.True(t, ok)
+		assert.Equal(t, apperror.ErrInvalidPassword, appErr.Code)
+		redisService.AssertExpectations(t)
+	})
+}
+
 // --------------------- REFRESH TOKEN TESTS ---------------------
+func (s *AuthServiceTestSuite) TestRefreshTokenReportsFingerprintMismatch() {
+	mismatch := &services.FingerprintMismatch{UserID: 1, SessionID: 7, Mode: services.FINGERPRINT_MODE_LOG}
+	mockRes := &services.RefreshTokenResult{UserId: 1, Token: &dto.JwtResult{Token: "new-refresh-token"}, FingerprintMismatch: mismatch}
+	s.refreshTokenService.On("Update", mock.Anything, "old-refresh-token", "127.0.0.1", "Mozilla/5.0", "device-2").Return(mockRes, nil)
+	s.jwtService.On("ValidateTokenIgnoreExpiration", "old-access-token").Return(&services.CustomClaims{ID: 1, Scope: services.TokenScopeAccess}, nil)
+	s.repo.On("GetByID", mock.Anything, uint(1)).Return(&models.User{ID: 1}, nil)
+	s.jwtService.On("GenerateAccessToken", uint(1)).Return(&dto.JwtResult{Token: "new-access-token"}, nil)
+
+	result, err := s.service.RefreshToken(context.Background(), "old-refresh-token", "old-access-token", "127.0.0.1", "Mozilla/5.0", "device-2")
+
+	s.NoError(err)
+	s.True(result.FingerprintMismatch)
+	s.Equal(uint(1), result.UserID)
+}
+
 func (s *AuthServiceTestSuite) TestRefreshToken() {
 	oldRefreshToken := "old-refresh-token"
 	oldAccessToken := "old-access-token"
 	ipAddress := "127.0.0.1"
+	userAgent := "Mozilla/5.0"
 	userID := uint(1)
 
 	tests := []struct {
@@ -158,7 +471,7 @@ func (s *AuthServiceTestSuite) TestRefreshToken() {
 				user := &models.User{ID: userID, Email: "user@example.com"}
 				claims := &services.CustomClaims{ID: userID, Scope: services.TokenScopeAccess}
 
-				s.refreshTokenService.On("Update", mock.Anything, oldRefreshToken, ipAddress).Return(mockRes, nil)
+				s.refreshTokenService.On("Update", mock.Anything, oldRefreshToken, ipAddress, userAgent, "").Return(mockRes, nil)
 				s.jwtService.On("ValidateTokenIgnoreExpiration", oldAccessToken).Return(claims, nil)
 				s.repo.On("GetByID", mock.Anything, userID).Return(user, nil)
 				s.jwtService.On("GenerateAccessToken", user.ID).Return(&dto.JwtResult{
@@ -170,11 +483,19 @@ func (s *AuthServiceTestSuite) TestRefreshToken() {
 		{
 			name: "UpdateError",
 			setupMocks: func() {
-				s.refreshTokenService.On("Update", mock.Anything, oldRefreshToken, ipAddress).Return(nil, apperror.NewUnauthorizedError("Invalid refresh token"))
+				s.refreshTokenService.On("Update", mock.Anything, oldRefreshToken, ipAddress, userAgent, "").Return(nil, apperror.NewUnauthorizedError("Invalid refresh token"))
 			},
 			expectErr: true,
 			errCode:   apperror.ErrUnauthorized,
 		},
+		{
+			name: "DeviceMismatch",
+			setupMocks: func() {
+				s.refreshTokenService.On("Update", mock.Anything, oldRefreshToken, ipAddress, userAgent, "").Return(nil, apperror.NewDeviceMismatchError("Refresh token was issued to another device"))
+			},
+			expectErr: true,
+			errCode:   apperror.ErrDeviceMismatch,
+		},
 		{
 			name: "GetByIDError",
 			setupMocks: func() {
@@ -182,7 +503,7 @@ func (s *AuthServiceTestSuite) TestRefreshToken() {
 				mockRes := &services.RefreshTokenResult{UserId: userID, Token: mockRefreshToken}
 				claims := &services.CustomClaims{ID: userID, Scope: services.TokenScopeAccess}
 
-				s.refreshTokenService.On("Update", mock.Anything, oldRefreshToken, ipAddress).Return(mockRes, nil)
+				s.refreshTokenService.On("Update", mock.Anything, oldRefreshToken, ipAddress, userAgent, "").Return(mockRes, nil)
 				s.jwtService.On("ValidateTokenIgnoreExpiration", oldAccessToken).Return(claims, nil)
 				s.repo.On("GetByID", mock.Anything, userID).Return((*models.User)(nil), gorm.ErrRecordNotFound)
 			},
@@ -197,7 +518,7 @@ func (s *AuthServiceTestSuite) TestRefreshToken() {
 				user := &models.User{ID: userID, Email: "user@example.com"}
 				claims := &services.CustomClaims{ID: userID, Scope: services.TokenScopeAccess}
 
-				s.refreshTokenService.On("Update", mock.Anything, oldRefreshToken, ipAddress).Return(mockRes, nil)
+				s.refreshTokenService.On("Update", mock.Anything, oldRefreshToken, ipAddress, userAgent, "").Return(mockRes, nil)
 				s.jwtService.On("ValidateTokenIgnoreExpiration", oldAccessToken).Return(claims, nil)
 				s.repo.On("GetByID", mock.Anything, userID).Return(user, nil)
 				s.jwtService.On("GenerateAccessToken", user.ID).Return(&dto.JwtResult{}, errors.New("Failed to generate JWT token"))
@@ -211,7 +532,7 @@ func (s *AuthServiceTestSuite) TestRefreshToken() {
 				mockRefreshToken := &dto.JwtResult{Token: "new-refresh-token", ExpiresAt: time.Now().Add(24 * time.Hour).Unix()}
 				mockRes := &services.RefreshTokenResult{UserId: userID, Token: mockRefreshToken}
 
-				s.refreshTokenService.On("Update", mock.Anything, oldRefreshToken, ipAddress).Return(mockRes, nil)
+				s.refreshTokenService.On("Update", mock.Anything, oldRefreshToken, ipAddress, userAgent, "").Return(mockRes, nil)
 				s.jwtService.On("ValidateTokenIgnoreExpiration", oldAccessToken).Return(nil, errors.New("Invalid token signature"))
 			},
 			expectErr: true,
@@ -226,7 +547,7 @@ func (s *AuthServiceTestSuite) TestRefreshToken() {
 				mockRes := &services.RefreshTokenResult{UserId: refreshUserID, Token: mockRefreshToken}
 				claims := &services.CustomClaims{ID: accessUserID, Scope: services.TokenScopeAccess}
 
-				s.refreshTokenService.On("Update", mock.Anything, oldRefreshToken, ipAddress).Return(mockRes, nil)
+				s.refreshTokenService.On("Update", mock.Anything, oldRefreshToken, ipAddress, userAgent, "").Return(mockRes, nil)
 				s.jwtService.On("ValidateTokenIgnoreExpiration", oldAccessToken).Return(claims, nil)
 			},
 			expectErr: true,
@@ -239,7 +560,7 @@ func (s *AuthServiceTestSuite) TestRefreshToken() {
 				mockRes := &services.RefreshTokenResult{UserId: userID, Token: mockRefreshToken}
 				claims := &services.CustomClaims{ID: userID, Scope: "other-scope"}
 
-				s.refreshTokenService.On("Update", mock.Anything, oldRefreshToken, ipAddress).Return(mockRes, nil)
+				s.refreshTokenService.On("Update", mock.Anything, oldRefreshToken, ipAddress, userAgent, "").Return(mockRes, nil)
 				s.jwtService.On("ValidateTokenIgnoreExpiration", oldAccessToken).Return(claims, nil)
 			},
 			expectErr: true,
@@ -253,7 +574,7 @@ func (s *AuthServiceTestSuite) TestRefreshToken() {
 			s.SetupTest()
 			tt.setupMocks()
 
-			result, err := s.service.RefreshToken(context.Background(), oldRefreshToken, oldAccessToken, ipAddress)
+			result, err := s.service.RefreshToken(context.Background(), oldRefreshToken, oldAccessToken, ipAddress, userAgent, "")
 
 			if tt.expectErr {
 				assert.Error(t, err)
@@ -269,7 +590,114 @@ func (s *AuthServiceTestSuite) TestRefreshToken() {
 	}
 }
 
+// ------------------------ LOGOUT TESTS ------------------------
+func (s *AuthServiceTestSuite) TestLogout() {
+	s.T().Run("Success", func(t *testing.T) {
+		s.SetupTest()
+		s.refreshTokenService.On("Delete", mock.Anything, uint(1), "refresh-token").Return(nil)
+		accessToken := &dto.AccessTokenInfo{ID: "token-id", ExpiresAt: time.Now().Add(time.Hour)}
+
+		err := s.service.Logout(context.Background(), 1, "refresh-token", accessToken)
+
+		assert.NoError(t, err)
+		s.refreshTokenService.AssertExpectations(t)
+		revoked, err := services.NewTokenBlacklistService(s.redisService).IsRevoked(context.Background(), "token-id")
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+	})
+
+	s.T().Run("WithoutAccessToken", func(t *testing.T) {
+		s.SetupTest()
+		s.refreshTokenService.On("Delete", mock.Anything, uint(1), "refresh-token").Return(nil)
+
+		err := s.service.Logout(context.Background(), 1, "refresh-token", nil)
+
+		assert.NoError(t, err)
+	})
+
+	s.T().Run("DeleteError", func(t *testing.T) {
+		s.SetupTest()
+		s.refreshTokenService.On("Delete", mock.Anything, uint(1), "refresh-token").Return(apperror.NewDBDeleteError("Failed to delete refresh token"))
+
+		accessToken := &dto.AccessTokenInfo{ID: "token-id", ExpiresAt: time.Now().Add(time.Hour)}
+
+		err := s.service.Logout(context.Background(), 1, "refresh-token", accessToken)
+
+		assert.Error(t, err)
+		appErr, ok := err.(*apperror.AppError)
+		assert.True(t, ok)
+		assert.Equal(t, apperror.ErrDBDelete, appErr.Code)
+	})
+}
+
+func (s *AuthServiceTestSuite) TestLogoutAll() {
+	s.T().Run("Success", func(t *testing.T) {
+		s.SetupTest()
+		s.refreshTokenService.On("DeleteAllByUserID", mock.Anything, uint(1)).Return(int64(3), nil)
+
+		accessToken := &dto.AccessTokenInfo{ID: "token-id", ExpiresAt: time.Now().Add(time.Hour)}
+
+		count, err := s.service.LogoutAll(context.Background(), 1, accessToken)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), count)
+		s.refreshTokenService.AssertExpectations(t)
+		revoked, err := services.NewTokenBlacklistService(s.redisService).IsRevoked(context.Background(), "token-id")
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+	})
+
+	s.T().Run("DeleteError", func(t *testing.T) {
+		s.SetupTest()
+		s.refreshTokenService.On("DeleteAllByUserID", mock.Anything, uint(1)).Return(int64(0), apperror.NewDBDeleteError("Failed to delete refresh tokens"))
+
+		count, err := s.service.LogoutAll(context.Background(), 1, nil)
+
+		assert.Error(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+}
+
 // --------------------- RUN TEST SUITE ---------------------
 func TestAuthServiceTestSuite(t *testing.T) {
 	suite.Run(t, new(AuthServiceTestSuite))
 }
+
+func TestLoginLeavesOtherColumnsUntouched(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Role{}, &models.UserPreference{}))
+	bcryptService := services.NewBcryptService()
+	hash, err := bcryptService.HashPassword("password123")
+	require.NoError(t, err)
+	verifiedAt := time.Now().Add(-time.Hour)
+	user := &models.User{Email: "user@example.com", Name: "Login User", Password: hash, Gender: 1, VerifiedAt: &verifiedAt}
+	require.NoError(t, db.Create(user).Error)
+	var before models.User
+	require.NoError(t, db.First(&before, user.ID).Error)
+
+	jwtService := new(mocks.MockJWTService)
+	jwtService.On("GenerateAccessToken", user.ID).Return(&dto.JwtResult{Token: "mocked-access-token"}, nil)
+	refreshTokenService := new(mocks.MockRefreshTokenService)
+	refreshTokenService.On("Create", mock.Anything, mock.Anything, "203.0.113.7", "Mozilla/5.0", "").Return(&dto.JwtResult{Token: "mocked-refresh-token"}, nil)
+	notificationService := new(mocks.MockNotificationService)
+	notificationService.On("NotifyNewLogin", mock.Anything, mock.Anything, "203.0.113.7", "Mozilla/5.0", mock.AnythingOfType("time.Time")).Return(nil)
+	service := services.NewAuthService(repositories.NewUserRepository(db), refreshTokenService, bcryptService, jwtService, services.NewMemoryRedisService(0), notificationService)
+
+	// Another request renames the user after the login loaded it
+	loginStarted := time.Now()
+	require.NoError(t, db.Model(&models.User{}).Where("id = ?", user.ID).UpdateColumn("name", "Renamed User").Error)
+	_, err = service.Login(context.Background(), user.Email, "password123", "203.0.113.7", "Mozilla/5.0", "")
+	require.NoError(t, err)
+
+	var after models.User
+	require.NoError(t, db.First(&after, user.ID).Error)
+	require.NotNil(t, after.LastLoginAt)
+	assert.False(t, after.LastLoginAt.Before(loginStarted))
+	require.NotNil(t, after.LastLoginIP)
+	assert.Equal(t, "203.0.113.7", *after.LastLoginIP)
+	assert.Equal(t, "Renamed User", after.Name)
+	assert.Equal(t, before.Password, after.Password)
+	assert.Equal(t, before.Email, after.Email)
+	assert.True(t, before.UpdatedAt.Equal(after.UpdatedAt))
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+	"github.com/vfa-khuongdv/golang-cms/pkg/storage"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+const (
+	AVATAR_SIZE          = 256  // Width and height of stored avatars, in pixels
+	AVATAR_MAX_DIMENSION = 4096 // Uploads wider or taller than this are refused before being decoded
+)
+
+type AvatarService interface {
+	Upload(ctx context.Context, userID uint, r io.Reader) (*dto.AvatarResponse, error)
+	Delete(ctx context.Context, userID uint) error
+}
+
+type avatarServiceImpl struct {
+	repo         repositories.UserRepository
+	storage      storage.Storage
+	redisService RedisService
+	maxBytes     int64
+}
+
+// NewAvatarService returns an AvatarService keeping avatars in storage. Uploads larger than maxBytes are refused
+func NewAvatarService(repo repositories.UserRepository, storage storage.Storage, redisService RedisService, maxBytes int64) AvatarService {
+	return &avatarServiceImpl{
+		repo:         repo,
+		storage:      storage,
+		redisService: redisService,
+		maxBytes:     maxBytes,
+	}
+}
+
+// Upload replaces the avatar of the user with the uploaded image. The type is told from the content, not from
+// a file name or header; JPEG, PNG and WebP images are accepted and stored as AVATAR_SIZE square PNGs.
+// Every upload gets a new key, so clients caching the previous avatar by URL pick up the change
+func (service *avatarServiceImpl) Upload(ctx context.Context, userID uint, r io.Reader) (*dto.AvatarResponse, error) {
+	data, err := io.ReadAll(io.LimitReader(r, service.maxBytes+1))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, apperror.NewPayloadTooLargeError(fmt.Sprintf("Avatar must not be larger than %d bytes", service.maxBytes))
+		}
+		return nil, apperror.NewBadRequestError("Failed to read the uploaded avatar")
+	}
+	if int64(len(data)) > service.maxBytes {
+		return nil, apperror.NewPayloadTooLargeError(fmt.Sprintf("Avatar must not be larger than %d bytes", service.maxBytes))
+	}
+
+	content, err := normalizeAvatar(data)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := service.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, apperror.NewNotFoundError("User not found")
+	}
+
+	key := fmt.Sprintf("avatars/%d/%s.png", userID, utils.GenerateRandomString(16))
+	if err := service.storage.Put(ctx, key, content, "image/png"); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to store avatar %s: %v", key, err)
+		return nil, apperror.NewInternalServerError("Failed to store avatar")
+	}
+
+	oldKey := user.AvatarKey
+	url := service.storage.URL(key)
+	user.Avatar = &url
+	user.AvatarKey = &key
+	if err := service.repo.Update(ctx, user); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to save avatar of user ID %d: %v", userID, err)
+		service.deleteFile(ctx, key)
+		return nil, apperror.NewDBUpdateError("Failed to update avatar")
+	}
+	if oldKey != nil {
+		service.deleteFile(ctx, *oldKey)
+	}
+	service.invalidate(ctx, userID)
+
+	logger.WithContext(ctx).Infof("Updated avatar of user ID %d", userID)
+	return &dto.AvatarResponse{Avatar: url}, nil
+}
+
+// Delete removes the avatar of the user. A user without one is left as is
+func (service *avatarServiceImpl) Delete(ctx context.Context, userID uint) error {
+	user, err := service.repo.GetByID(ctx, userID)
+	if err != nil {
+		return apperror.NewNotFoundError("User not found")
+	}
+	if user.AvatarKey == nil {
+		return nil
+	}
+
+	key := *user.AvatarKey
+	user.Avatar = nil
+	user.AvatarKey = nil
+	if err := service.repo.Update(ctx, user); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to remove avatar of user ID %d: %v", userID, err)
+		return apperror.NewDBUpdateError("Failed to delete avatar")
+	}
+	service.deleteFile(ctx, key)
+	service.invalidate(ctx, userID)
+
+	logger.WithContext(ctx).Infof("Deleted avatar of user ID %d", userID)
+	return nil
+}
+
+// deleteFile removes a file no user refers to anymore. A failure only leaves an orphan behind, so it is just logged
+func (service *avatarServiceImpl) deleteFile(ctx context.Context, key string) {
+	if err := service.storage.Delete(ctx, key); err != nil {
+		logger.WithContext(ctx).Warnf("Failed to delete avatar %s: %v", key, err)
+	}
+}
+
+// invalidate drops the cached profile and user, which carry the avatar URL
+func (service *avatarServiceImpl) invalidate(ctx context.Context, userID uint) {
+	for _, key := range []string{profileCacheKey(userID), userCacheKey(userID)} {
+		if err := CacheInvalidate(ctx, service.redisService, key); err != nil {
+			logger.WithContext(ctx).Warnf("Failed to invalidate %s: %v", key, err)
+		}
+	}
+}
+
+// normalizeAvatar checks that data is a JPEG, PNG or WebP image by its magic bytes, crops it to a centered
+// square and scales it to AVATAR_SIZE pixels, returning it encoded as PNG
+func normalizeAvatar(data []byte) ([]byte, error) {
+	var decode func(io.Reader) (image.Image, error)
+	var decodeConfig func(io.Reader) (image.Config, error)
+	switch http.DetectContentType(data) {
+	case "image/jpeg":
+		decode, decodeConfig = jpeg.Decode, jpeg.DecodeConfig
+	case "image/png":
+		decode, decodeConfig = png.Decode, png.DecodeConfig
+	case "image/webp":
+		decode, decodeConfig = webp.Decode, webp.DecodeConfig
+	default:
+		return nil, apperror.NewUnsupportedMediaError("Avatar must be a JPEG, PNG or WebP image")
+	}
+
+	// The header is checked first so a small file declaring a huge image is not decoded into memory
+	config, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, apperror.NewBadRequestError("Avatar image is corrupted")
+	}
+	if config.Width > AVATAR_MAX_DIMENSION || config.Height > AVATAR_MAX_DIMENSION {
+		return nil, apperror.NewBadRequestError(fmt.Sprintf("Avatar must not be larger than %dx%d pixels", AVATAR_MAX_DIMENSION, AVATAR_MAX_DIMENSION))
+	}
+	src, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, apperror.NewBadRequestError("Avatar image is corrupted")
+	}
+
+	bounds := src.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	crop := image.Rect(0, 0, side, side).Add(bounds.Min).Add(image.Pt((bounds.Dx()-side)/2, (bounds.Dy()-side)/2))
+	dst := image.NewRGBA(image.Rect(0, 0, AVATAR_SIZE, AVATAR_SIZE))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+
+	var out bytes.Buffer
+	if err := png.Encode(&out, dst); err != nil {
+		return nil, apperror.NewInternalServerError("Failed to encode avatar")
+	}
+	return out.Bytes(), nil
+}
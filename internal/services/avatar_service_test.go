@@ -0,0 +1,254 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/storage"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+// encodeTestImage returns a width x height image, red on the left half and blue on the right, encoded with encode
+func encodeTestImage(t *testing.T, width, height int, encode func(*bytes.Buffer, image.Image) error) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			if x < width/2 {
+				img.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.RGBA{B: 255, A: 255})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, encode(&buf, img))
+	return buf.Bytes()
+}
+
+func encodePNG(buf *bytes.Buffer, img image.Image) error {
+	return png.Encode(buf, img)
+}
+
+func encodeJPEG(buf *bytes.Buffer, img image.Image) error {
+	return jpeg.Encode(buf, img, nil)
+}
+
+func TestAvatarService_Upload(t *testing.T) {
+	ctx := context.Background()
+	const maxBytes = 64 << 10
+	newService := func(t *testing.T) (services.AvatarService, *mocks.MockUserRepository, *mocks.MockRedisService, string) {
+		dir := t.TempDir()
+		store, err := storage.NewLocalStorage(dir, "http://localhost:3000/uploads")
+		require.NoError(t, err)
+		repo := new(mocks.MockUserRepository)
+		redis := new(mocks.MockRedisService)
+		redis.On("Delete", mock.Anything, mock.Anything).Return(nil).Maybe()
+		return services.NewAvatarService(repo, store, redis, maxBytes), repo, redis, dir
+	}
+
+	t.Run("StoresASquarePNG", func(t *testing.T) {
+		service, repo, redis, dir := newService(t)
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&models.User{ID: 1}, nil).Once()
+		var saved *models.User
+		repo.On("Update", mock.Anything, mock.MatchedBy(func(user *models.User) bool {
+			saved = user
+			return true
+		})).Return(nil).Once()
+
+		res, err := service.Upload(ctx, 1, bytes.NewReader(encodeTestImage(t, 600, 300, encodePNG)))
+
+		require.NoError(t, err)
+		require.NotNil(t, saved.AvatarKey)
+		assert.True(t, strings.HasPrefix(*saved.AvatarKey, "avatars/1/"), *saved.AvatarKey)
+		assert.Equal(t, "http://localhost:3000/uploads/"+*saved.AvatarKey, res.Avatar)
+		assert.Equal(t, res.Avatar, *saved.Avatar)
+
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(*saved.AvatarKey)))
+		require.NoError(t, err)
+		stored, err := png.Decode(bytes.NewReader(content))
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, services.AVATAR_SIZE, services.AVATAR_SIZE), stored.Bounds())
+		// The 600x300 image is cropped to its center, where both halves still meet
+		r, _, b, _ := stored.At(10, 128).RGBA()
+		assert.True(t, r > b, "left side stays red")
+		r, _, b, _ = stored.At(245, 128).RGBA()
+		assert.True(t, b > r, "right side stays blue")
+
+		redis.AssertCalled(t, "Delete", mock.Anything, "profile:v2:1")
+		redis.AssertCalled(t, "Delete", mock.Anything, "user:1")
+	})
+
+	t.Run("ReplacesThePreviousAvatar", func(t *testing.T) {
+		service, repo, _, dir := newService(t)
+		oldKey, oldURL := "avatars/1/old.png", "http://localhost:3000/uploads/avatars/1/old.png"
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "avatars", "1"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "avatars", "1", "old.png"), []byte("old"), 0o644))
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&models.User{ID: 1, AvatarKey: &oldKey, Avatar: &oldURL}, nil).Once()
+		repo.On("Update", mock.Anything, mock.Anything).Return(nil).Once()
+
+		res, err := service.Upload(ctx, 1, bytes.NewReader(encodeTestImage(t, 300, 300, encodeJPEG)))
+
+		require.NoError(t, err)
+		assert.NotEqual(t, oldURL, res.Avatar, "a new upload gets a new URL")
+		_, err = os.Stat(filepath.Join(dir, "avatars", "1", "old.png"))
+		assert.True(t, os.IsNotExist(err), "the previous file is deleted")
+	})
+
+	t.Run("AcceptsWebP", func(t *testing.T) {
+		service, repo, _, _ := newService(t)
+		data, err := os.ReadFile("testdata/avatar.webp")
+		require.NoError(t, err)
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&models.User{ID: 1}, nil).Once()
+		repo.On("Update", mock.Anything, mock.Anything).Return(nil).Once()
+
+		_, err = service.Upload(ctx, 1, bytes.NewReader(data))
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("RejectsOtherContent", func(t *testing.T) {
+		service, repo, _, dir := newService(t)
+		gif := []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;")
+
+		for _, data := range [][]byte{gif, []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"), []byte("plain text")} {
+			_, err := service.Upload(ctx, 1, bytes.NewReader(data))
+			assertAppErrorCode(t, err, apperror.ErrUnsupportedMedia)
+		}
+		repo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+		entries, _ := os.ReadDir(dir)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("RejectsOversizeUploads", func(t *testing.T) {
+		service, repo, _, _ := newService(t)
+		data := append(encodeTestImage(t, 10, 10, encodePNG), make([]byte, maxBytes)...)
+
+		_, err := service.Upload(ctx, 1, bytes.NewReader(data))
+
+		assertAppErrorCode(t, err, apperror.ErrPayloadTooLarge)
+		repo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("RejectsHugeDimensions", func(t *testing.T) {
+		service, _, _, _ := newService(t)
+
+		_, err := service.Upload(ctx, 1, bytes.NewReader(encodeTestImage(t, services.AVATAR_MAX_DIMENSION+1, 1, encodePNG)))
+
+		assertAppErrorCode(t, err, apperror.ErrBadRequest)
+	})
+
+	t.Run("RejectsCorruptedImages", func(t *testing.T) {
+		service, _, _, _ := newService(t)
+		data := encodeTestImage(t, 10, 10, encodePNG)
+
+		_, err := service.Upload(ctx, 1, bytes.NewReader(data[:len(data)/2]))
+
+		assertAppErrorCode(t, err, apperror.ErrBadRequest)
+	})
+
+	t.Run("UserNotFound", func(t *testing.T) {
+		service, repo, _, _ := newService(t)
+		repo.On("GetByID", mock.Anything, uint(1)).Return(nil, assert.AnError).Once()
+
+		_, err := service.Upload(ctx, 1, bytes.NewReader(encodeTestImage(t, 10, 10, encodePNG)))
+
+		assertAppErrorCode(t, err, apperror.ErrNotFound)
+	})
+
+	t.Run("UpdateErrorDeletesTheNewFile", func(t *testing.T) {
+		service, repo, _, dir := newService(t)
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&models.User{ID: 1}, nil).Once()
+		repo.On("Update", mock.Anything, mock.Anything).Return(assert.AnError).Once()
+
+		_, err := service.Upload(ctx, 1, bytes.NewReader(encodeTestImage(t, 10, 10, encodePNG)))
+
+		assertAppErrorCode(t, err, apperror.ErrDBUpdate)
+		entries, _ := os.ReadDir(filepath.Join(dir, "avatars", "1"))
+		assert.Empty(t, entries)
+	})
+
+	t.Run("StorageError", func(t *testing.T) {
+		repo := new(mocks.MockUserRepository)
+		store := new(mocks.MockStorage)
+		service := services.NewAvatarService(repo, store, new(mocks.MockRedisService), maxBytes)
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&models.User{ID: 1}, nil).Once()
+		store.On("Put", mock.Anything, mock.Anything, mock.Anything, "image/png").Return(assert.AnError).Once()
+
+		_, err := service.Upload(ctx, 1, bytes.NewReader(encodeTestImage(t, 10, 10, encodePNG)))
+
+		assertAppErrorCode(t, err, apperror.ErrInternalServer)
+		repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAvatarService_Delete(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		repo := new(mocks.MockUserRepository)
+		store := new(mocks.MockStorage)
+		redis := new(mocks.MockRedisService)
+		service := services.NewAvatarService(repo, store, redis, 1024)
+		key, url := "avatars/1/a.png", "http://localhost:3000/uploads/avatars/1/a.png"
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&models.User{ID: 1, AvatarKey: &key, Avatar: &url}, nil).Once()
+		repo.On("Update", mock.Anything, mock.MatchedBy(func(user *models.User) bool {
+			return user.Avatar == nil && user.AvatarKey == nil
+		})).Return(nil).Once()
+		store.On("Delete", mock.Anything, key).Return(nil).Once()
+		redis.On("Delete", mock.Anything, "profile:v2:1").Return(nil).Once()
+		redis.On("Delete", mock.Anything, "cache_refresh:profile:v2:1").Return(nil).Once()
+		redis.On("Delete", mock.Anything, "user:1").Return(nil).Once()
+		redis.On("Delete", mock.Anything, "cache_refresh:user:1").Return(nil).Once()
+
+		assert.NoError(t, service.Delete(ctx, 1))
+		repo.AssertExpectations(t)
+		store.AssertExpectations(t)
+		redis.AssertExpectations(t)
+	})
+
+	t.Run("NoAvatar", func(t *testing.T) {
+		repo := new(mocks.MockUserRepository)
+		store := new(mocks.MockStorage)
+		service := services.NewAvatarService(repo, store, new(mocks.MockRedisService), 1024)
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&models.User{ID: 1}, nil).Once()
+
+		assert.NoError(t, service.Delete(ctx, 1))
+		repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		store.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("UpdateErrorKeepsTheFile", func(t *testing.T) {
+		repo := new(mocks.MockUserRepository)
+		store := new(mocks.MockStorage)
+		service := services.NewAvatarService(repo, store, new(mocks.MockRedisService), 1024)
+		key := "avatars/1/a.png"
+		repo.On("GetByID", mock.Anything, uint(1)).Return(&models.User{ID: 1, AvatarKey: &key}, nil).Once()
+		repo.On("Update", mock.Anything, mock.Anything).Return(assert.AnError).Once()
+
+		assertAppErrorCode(t, service.Delete(ctx, 1), apperror.ErrDBUpdate)
+		store.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("UserNotFound", func(t *testing.T) {
+		repo := new(mocks.MockUserRepository)
+		service := services.NewAvatarService(repo, new(mocks.MockStorage), new(mocks.MockRedisService), 1024)
+		repo.On("GetByID", mock.Anything, uint(1)).Return(nil, assert.AnError).Once()
+
+		assertAppErrorCode(t, service.Delete(ctx, 1), apperror.ErrNotFound)
+	})
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+// BackgroundService runs work that must not hold up the request starting it, such as sending mails,
+// so that the server can wait for it when shutting down
+type BackgroundService interface {
+	// Run runs task in the background with a context keeping the values of ctx but not its cancellation.
+	// That context is cancelled when Close stops waiting, so long tasks must stop once it is done
+	Run(ctx context.Context, task func(ctx context.Context))
+	// Close stops running tasks in the background and waits for those already running
+	Close() error
+}
+
+type backgroundServiceImpl struct {
+	timeout time.Duration
+	ctx     context.Context
+	cancel  context.CancelFunc
+	mu      sync.RWMutex
+	closed  bool
+	tasks   sync.WaitGroup
+}
+
+// NewBackgroundService returns a service whose Close waits up to timeout for the running tasks before cancelling them
+func NewBackgroundService(timeout time.Duration) BackgroundService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &backgroundServiceImpl{
+		timeout: timeout,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Run starts task in a tracked goroutine. Once the service is closed, task runs synchronously instead of being dropped
+func (service *backgroundServiceImpl) Run(ctx context.Context, task func(ctx context.Context)) {
+	taskCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(service.ctx, cancel)
+	run := func() {
+		defer cancel()
+		defer stop()
+		task(taskCtx)
+	}
+
+	// Tasks are added under the read lock, so none is added once Close has started waiting
+	service.mu.RLock()
+	if !service.closed {
+		service.tasks.Add(1)
+		service.mu.RUnlock()
+		go func() {
+			defer service.tasks.Done()
+			run()
+		}()
+		return
+	}
+	service.mu.RUnlock()
+
+	logger.WithContext(ctx).Warn("Background service closed, running task synchronously")
+	run()
+}
+
+// Close waits for the running tasks. Those still running after the timeout have their context cancelled
+// and are waited for to return, and an error reports that they were cut short
+func (service *backgroundServiceImpl) Close() error {
+	service.mu.Lock()
+	if service.closed {
+		service.mu.Unlock()
+		return nil
+	}
+	service.closed = true
+	service.mu.Unlock()
+	defer service.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		service.tasks.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(service.timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return nil
+	case <-timer.C:
+	}
+
+	service.cancel()
+	<-done
+	return fmt.Errorf("background tasks still running after %s were cancelled", service.timeout)
+}
@@ -0,0 +1,76 @@
+package services_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+)
+
+type backgroundTestKey struct{}
+
+func TestBackgroundService_Run(t *testing.T) {
+	t.Run("Task Outlives The Request Context", func(t *testing.T) {
+		service := services.NewBackgroundService(time.Second)
+		ctx, cancel := context.WithCancel(context.WithValue(context.Background(), backgroundTestKey{}, "request"))
+
+		gate := make(chan struct{})
+		result := make(chan string, 1)
+		service.Run(ctx, func(ctx context.Context) {
+			<-gate
+			if ctx.Err() != nil {
+				result <- "cancelled"
+				return
+			}
+			result <- ctx.Value(backgroundTestKey{}).(string)
+		})
+		cancel()
+		close(gate)
+
+		require.NoError(t, service.Close())
+		assert.Equal(t, "request", <-result)
+	})
+
+	t.Run("Close Waits For Running Tasks", func(t *testing.T) {
+		service := services.NewBackgroundService(time.Second)
+		var finished atomic.Int32
+		for range 3 {
+			service.Run(context.Background(), func(context.Context) {
+				time.Sleep(20 * time.Millisecond)
+				finished.Add(1)
+			})
+		}
+
+		require.NoError(t, service.Close())
+		assert.Equal(t, int32(3), finished.Load())
+	})
+
+	t.Run("Close Cancels Tasks Past The Timeout", func(t *testing.T) {
+		service := services.NewBackgroundService(20 * time.Millisecond)
+		var cancelled atomic.Bool
+		service.Run(context.Background(), func(ctx context.Context) {
+			<-ctx.Done()
+			cancelled.Store(true)
+		})
+
+		err := service.Close()
+
+		assert.Error(t, err)
+		assert.True(t, cancelled.Load(), "Close returned before the cancelled task")
+	})
+
+	t.Run("Runs Synchronously Once Closed", func(t *testing.T) {
+		service := services.NewBackgroundService(time.Second)
+		require.NoError(t, service.Close())
+
+		ran := false
+		service.Run(context.Background(), func(context.Context) { ran = true })
+
+		assert.True(t, ran)
+		assert.NoError(t, service.Close())
+	})
+}
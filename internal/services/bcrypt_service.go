@@ -1,7 +1,9 @@
 package services
 
 import (
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
 	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -9,22 +11,27 @@ type BcryptService interface {
 	HashPassword(password string) (string, error)
 	CheckPasswordHash(password, hashPassword string) bool
 	HashPasswordWithCost(password string, cost int) (string, error)
+	NeedsRehash(hashPassword string) bool
 }
 
-type bcryptServiceImpl struct{}
+type bcryptServiceImpl struct {
+	cost int
+}
 
+// NewBcryptService returns a BcryptService hashing with the cost of BCRYPT_COST, bcrypt.DefaultCost if unset or out of range
 func NewBcryptService() BcryptService {
-	return &bcryptServiceImpl{}
+	cost := utils.GetEnvAsInt("BCRYPT_COST", bcrypt.DefaultCost)
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		logger.Warnf("BCRYPT_COST %d is out of range [%d, %d], using %d", cost, bcrypt.MinCost, bcrypt.MaxCost, bcrypt.DefaultCost)
+		cost = bcrypt.DefaultCost
+	}
+	return &bcryptServiceImpl{cost: cost}
 }
 
-// HashPassword hashes a password using bcrypt with the default cost
+// HashPassword hashes a password using bcrypt with the configured cost
 // Returns the hashed password as a string, or an error if hashing fails
 func (s *bcryptServiceImpl) HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", apperror.NewInternalServerError(err.Error())
-	}
-	return string(hashedPassword), nil
+	return s.HashPasswordWithCost(password, s.cost)
 }
 
 // CheckPasswordHash compares a plain text password with a hashed password
@@ -43,3 +50,10 @@ func (s *bcryptServiceImpl) HashPasswordWithCost(password string, cost int) (str
 	}
 	return string(hashedPassword), nil
 }
+
+// NeedsRehash reports whether hashPassword was made with a lower cost than the configured one.
+// Hashes that are not bcrypt hashes are left alone
+func (s *bcryptServiceImpl) NeedsRehash(hashPassword string) bool {
+	cost, err := bcrypt.Cost([]byte(hashPassword))
+	return err == nil && cost < s.cost
+}
@@ -45,6 +45,46 @@ func TestBcryptService(t *testing.T) {
 		assert.Error(t, err, "HashPasswordWithCost should return error for invalid cost")
 	})
 
+	t.Run("ConfiguredCost", func(t *testing.T) {
+		t.Setenv("BCRYPT_COST", "5")
+		service := services.NewBcryptService()
+
+		hashedPassword, err := service.HashPassword("password")
+
+		require.NoError(t, err)
+		cost, err := bcrypt.Cost([]byte(hashedPassword))
+		require.NoError(t, err)
+		assert.Equal(t, 5, cost)
+	})
+
+	t.Run("CostOutOfRangeFallsBackToDefault", func(t *testing.T) {
+		t.Setenv("BCRYPT_COST", "99")
+		service := services.NewBcryptService()
+
+		hashedPassword, err := service.HashPassword("password")
+
+		require.NoError(t, err)
+		cost, err := bcrypt.Cost([]byte(hashedPassword))
+		require.NoError(t, err)
+		assert.Equal(t, bcrypt.DefaultCost, cost)
+	})
+
+	t.Run("NeedsRehash", func(t *testing.T) {
+		t.Setenv("BCRYPT_COST", "6")
+		service := services.NewBcryptService()
+		lower, err := service.HashPasswordWithCost("password", 5)
+		require.NoError(t, err)
+		current, err := service.HashPassword("password")
+		require.NoError(t, err)
+		higher, err := service.HashPasswordWithCost("password", 7)
+		require.NoError(t, err)
+
+		assert.True(t, service.NeedsRehash(lower))
+		assert.False(t, service.NeedsRehash(current))
+		assert.False(t, service.NeedsRehash(higher), "hashes are never downgraded")
+		assert.False(t, service.NeedsRehash("not-a-bcrypt-hash"))
+	})
+
 	t.Run("HashPasswordTooLong", func(t *testing.T) {
 		service := services.NewBcryptService()
 		tooLongPassword := strings.Repeat("a", 80)
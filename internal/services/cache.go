@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+// CACHE_REFRESH_LOCK_TTL bounds how long a refresh ahead of expiry may hold its lock
+const CACHE_REFRESH_LOCK_TTL = 30 * time.Second
+
+// CACHE_REFRESH_LOCK_TOKEN_LENGTH is the length of the token telling a refresh lock from those taken after it
+const CACHE_REFRESH_LOCK_TOKEN_LENGTH = 16
+
+// refreshAheadEntry is the form in which CacheGetOrRefresh stores values.
+// RefreshAt is the logical expiry, after which the value is still served but reloaded in the background
+type refreshAheadEntry struct {
+	Value     json.RawMessage `json:"value"`
+	RefreshAt time.Time       `json:"refresh_at"`
+}
+
+// CacheGetOrRefresh returns the value cached under key as JSON, or calls loader on a miss and caches its result for ttl.
+// Once ttl-refreshAhead has passed, the cached value is still returned while a single caller, holding a lock taken with SetNX,
+// reloads it in the background. This keeps popular entries from expiring under load and sending every concurrent request
+// to the loader at once. A refreshAhead of zero disables it. In the background the loader gets a context that is not
+// cancelled with the request. Values cached without a logical expiry are reloaded as if missing. A cached value that cannot be
+// parsed is deleted and reloaded too, so a corrupt entry cannot fail every caller until it expires.
+// Failures to read or write the cache are logged and the loaded value is returned anyway.
+// Loader errors are returned unchanged and nothing is cached. Drop cached values with CacheInvalidate.
+func CacheGetOrRefresh[T any](ctx context.Context, redisService RedisService, key string, ttl, refreshAhead time.Duration, loader func(ctx context.Context) (T, error)) (T, error) {
+	var value T
+
+	cached, err := redisService.Get(ctx, key)
+	if err == nil {
+		var entry refreshAheadEntry
+		parseErr := json.Unmarshal([]byte(cached), &entry)
+		if parseErr == nil && len(entry.Value) > 0 {
+			if parseErr = json.Unmarshal(entry.Value, &value); parseErr == nil {
+				if !time.Now().Before(entry.RefreshAt) {
+					refreshInBackground(ctx, redisService, key, ttl, refreshAhead, loader)
+				}
+				return value, nil
+			}
+		}
+		if parseErr != nil {
+			logger.WithContext(ctx).Warnf("Dropping cached value %s that cannot be parsed: %v", key, parseErr)
+			if err := redisService.Delete(ctx, key); err != nil {
+				logger.WithContext(ctx).Warnf("Failed to delete cached value %s: %v", key, err)
+			}
+			var zero T
+			value = zero
+		}
+	} else if !errors.Is(err, ErrCacheMiss) {
+		logger.WithContext(ctx).Warnf("Failed to read cached value %s: %v", key, err)
+	}
+
+	value, err = loader(ctx)
+	if err != nil {
+		return value, err
+	}
+	if err := CacheSetRefreshAhead(ctx, redisService, key, value, ttl, refreshAhead); err != nil {
+		logger.WithContext(ctx).Warnf("Failed to cache value %s: %v", key, err)
+	}
+	return value, nil
+}
+
+// CacheSetRefreshAhead stores value in the form read by CacheGetOrRefresh
+func CacheSetRefreshAhead[T any](ctx context.Context, redisService RedisService, key string, value T, ttl, refreshAhead time.Duration) error {
+	entry, err := encodeRefreshAhead(value, ttl, refreshAhead)
+	if err != nil {
+		return err
+	}
+	return redisService.Set(ctx, key, entry, ttl)
+}
+
+// encodeRefreshAhead returns value in the form read by CacheGetOrRefresh
+func encodeRefreshAhead[T any](value T, ttl, refreshAhead time.Duration) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	entry, err := json.Marshal(refreshAheadEntry{Value: data, RefreshAt: time.Now().Add(ttl - refreshAhead)})
+	if err != nil {
+		return "", err
+	}
+	return string(entry), nil
+}
+
+// CacheInvalidate deletes the value cached under key together with the lock of a refresh started by CacheGetOrRefresh,
+// so a refresh in progress does not write back the value it loaded before the change. Both deletes are attempted
+func CacheInvalidate(ctx context.Context, redisService RedisService, key string) error {
+	return errors.Join(redisService.Delete(ctx, key), redisService.Delete(ctx, constants.CACHE_REFRESH+key))
+}
+
+// refreshInBackground reloads and caches the value unless another caller is already doing so.
+// The lock holds a token unique to this refresh, and the value is only written while the lock still holds it,
+// checked in the same atomic step as the write. If CacheInvalidate deleted the lock meanwhile, even if another
+// refresh took it again since, the reloaded value may predate the change that invalidated the key and is discarded
+func refreshInBackground[T any](ctx context.Context, redisService RedisService, key string, ttl, refreshAhead time.Duration, loader func(ctx context.Context) (T, error)) {
+	lockKey := constants.CACHE_REFRESH + key
+	lockToken := utils.GenerateRandomString(CACHE_REFRESH_LOCK_TOKEN_LENGTH)
+	locked, err := redisService.SetNX(ctx, lockKey, lockToken, CACHE_REFRESH_LOCK_TTL)
+	if err != nil {
+		logger.WithContext(ctx).Warnf("Failed to lock refresh of cached value %s: %v", key, err)
+		return
+	}
+	if !locked {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		value, err := loader(ctx)
+		if err != nil {
+			logger.WithContext(ctx).Warnf("Failed to refresh cached value %s: %v", key, err)
+			releaseRefreshLock(ctx, redisService, key)
+			return
+		}
+
+		entry, err := encodeRefreshAhead(value, ttl, refreshAhead)
+		if err != nil {
+			logger.WithContext(ctx).Warnf("Failed to cache value %s: %v", key, err)
+			releaseRefreshLock(ctx, redisService, key)
+			return
+		}
+		stored, err := redisService.SetIfEquals(ctx, lockKey, lockToken, key, entry, ttl)
+		if err != nil {
+			logger.WithContext(ctx).Warnf("Failed to cache value %s: %v", key, err)
+			return
+		}
+		if !stored {
+			logger.WithContext(ctx).Infof("Discarding refreshed value %s, which was invalidated meanwhile", key)
+			return
+		}
+		releaseRefreshLock(ctx, redisService, key)
+	}()
+}
+
+// releaseRefreshLock deletes the lock taken by refreshInBackground
+func releaseRefreshLock(ctx context.Context, redisService RedisService, key string) {
+	if err := redisService.Delete(ctx, constants.CACHE_REFRESH+key); err != nil {
+		logger.WithContext(ctx).Warnf("Failed to release refresh lock of cached value %s: %v", key, err)
+	}
+}
@@ -0,0 +1,213 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+func TestCacheGetOrRefresh(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ServesStaleValueAndRefreshesOnce", func(t *testing.T) {
+		cache := services.NewMemoryRedisService(0)
+		// A refreshAhead equal to the TTL makes every cached value due for refresh
+		require.NoError(t, services.CacheSetRefreshAhead(ctx, cache, "item:1", "stale", time.Minute, time.Minute))
+		release := make(chan struct{})
+		var calls atomic.Int32
+		loader := func(ctx context.Context) (string, error) {
+			calls.Add(1)
+			<-release
+			return "fresh", nil
+		}
+
+		for range 3 {
+			value, err := services.CacheGetOrRefresh(ctx, cache, "item:1", time.Minute, time.Second, loader)
+			require.NoError(t, err)
+			assert.Equal(t, "stale", value)
+		}
+		close(release)
+
+		assert.Eventually(t, func() bool {
+			value, err := services.CacheGetOrRefresh(ctx, cache, "item:1", time.Minute, time.Second, loader)
+			return err == nil && value == "fresh"
+		}, time.Second, 5*time.Millisecond)
+		assert.Equal(t, int32(1), calls.Load())
+		_, err := cache.Get(ctx, "cache_refresh:item:1")
+		assert.ErrorIs(t, err, services.ErrCacheMiss)
+	})
+
+	t.Run("RefreshIsDiscardedAfterInvalidation", func(t *testing.T) {
+		cache := services.NewMemoryRedisService(0)
+		require.NoError(t, services.CacheSetRefreshAhead(ctx, cache, "item:6", "stale", time.Minute, time.Minute))
+		loading := make(chan struct{})
+		release := make(chan struct{})
+		loaded := make(chan struct{})
+
+		value, err := services.CacheGetOrRefresh(ctx, cache, "item:6", time.Minute, time.Second, func(ctx context.Context) (string, error) {
+			defer close(loaded)
+			close(loading)
+			<-release
+			return "loaded before the change", nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "stale", value)
+
+		<-loading
+		require.NoError(t, services.CacheInvalidate(ctx, cache, "item:6"))
+		value, err = services.CacheGetOrRefresh(ctx, cache, "item:6", time.Minute, time.Second, func(ctx context.Context) (string, error) {
+			return "loaded after the change", nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "loaded after the change", value)
+		close(release)
+		<-loaded
+
+		assert.Never(t, func() bool {
+			cached, err := services.CacheGetOrRefresh(ctx, cache, "item:6", time.Minute, time.Second, func(ctx context.Context) (string, error) {
+				return "", errors.New("loader must not be called for a cached value")
+			})
+			return err != nil || cached != "loaded after the change"
+		}, 50*time.Millisecond, 5*time.Millisecond)
+	})
+
+	t.Run("RefreshIsDiscardedWhenTheLockWasTakenAgain", func(t *testing.T) {
+		cache := services.NewMemoryRedisService(0)
+		require.NoError(t, services.CacheSetRefreshAhead(ctx, cache, "item:7", "stale", time.Minute, time.Minute))
+		loading := make(chan struct{})
+		release := make(chan struct{})
+		loaded := make(chan struct{})
+
+		_, err := services.CacheGetOrRefresh(ctx, cache, "item:7", time.Minute, time.Second, func(ctx context.Context) (string, error) {
+			defer close(loaded)
+			close(loading)
+			<-release
+			return "loaded before the change", nil
+		})
+		require.NoError(t, err)
+
+		// After the invalidation another refresh takes the lock, so the lock exists again when the first one writes
+		<-loading
+		require.NoError(t, services.CacheInvalidate(ctx, cache, "item:7"))
+		require.NoError(t, services.CacheSetRefreshAhead(ctx, cache, "item:7", "loaded after the change", time.Minute, time.Minute))
+		secondRelease := make(chan struct{})
+		defer close(secondRelease)
+		_, err = services.CacheGetOrRefresh(ctx, cache, "item:7", time.Minute, time.Second, func(ctx context.Context) (string, error) {
+			<-secondRelease
+			return "loaded by the second refresh", nil
+		})
+		require.NoError(t, err)
+		close(release)
+		<-loaded
+
+		assert.Never(t, func() bool {
+			cached, err := cache.Get(ctx, "item:7")
+			return err != nil || strings.Contains(cached, "loaded before the change")
+		}, 50*time.Millisecond, 5*time.Millisecond)
+	})
+
+	t.Run("FreshValueIsNotRefreshed", func(t *testing.T) {
+		cache := services.NewMemoryRedisService(0)
+		require.NoError(t, services.CacheSetRefreshAhead(ctx, cache, "item:2", "cached", time.Minute, time.Second))
+
+		value, err := services.CacheGetOrRefresh(ctx, cache, "item:2", time.Minute, time.Second, func(ctx context.Context) (string, error) {
+			t.Fatal("loader must not be called for a fresh value")
+			return "", nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "cached", value)
+	})
+
+	t.Run("CorruptValueIsDroppedAndReloaded", func(t *testing.T) {
+		for name, cached := range map[string]string{
+			"NotJSON":      "not-json",
+			"InvalidValue": `{"value":{"name":42},"refresh_at":"2999-01-01T00:00:00Z"}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				cache := services.NewMemoryRedisService(0)
+				require.NoError(t, cache.Set(ctx, "item:4", cached, time.Minute))
+
+				value, err := services.CacheGetOrRefresh(ctx, cache, "item:4", time.Minute, time.Second, func(ctx context.Context) (map[string]string, error) {
+					return map[string]string{"name": "loaded"}, nil
+				})
+
+				require.NoError(t, err)
+				assert.Equal(t, map[string]string{"name": "loaded"}, value)
+				// The reloaded value replaced the corrupt one
+				value, err = services.CacheGetOrRefresh(ctx, cache, "item:4", time.Minute, time.Second, func(ctx context.Context) (map[string]string, error) {
+					t.Fatal("loader must not be called for a cached value")
+					return nil, nil
+				})
+				require.NoError(t, err)
+				assert.Equal(t, "loaded", value["name"])
+			})
+		}
+	})
+
+	t.Run("CorruptValueIsDroppedWhenReloadFails", func(t *testing.T) {
+		cache := services.NewMemoryRedisService(0)
+		require.NoError(t, cache.Set(ctx, "item:5", "not-json", time.Minute))
+		loadErr := errors.New("db down")
+
+		_, err := services.CacheGetOrRefresh(ctx, cache, "item:5", time.Minute, time.Second, func(ctx context.Context) (string, error) {
+			return "", loadErr
+		})
+
+		assert.ErrorIs(t, err, loadErr)
+		exists, _ := cache.Exists(ctx, "item:5")
+		assert.False(t, exists)
+	})
+
+	t.Run("RawValueIsReloaded", func(t *testing.T) {
+		cache := services.NewMemoryRedisService(0)
+		// A plain JSON value, without a logical expiry
+		require.NoError(t, cache.Set(ctx, "item:3", `{"name":"raw"}`, time.Minute))
+
+		value, err := services.CacheGetOrRefresh(ctx, cache, "item:3", time.Minute, time.Second, func(ctx context.Context) (map[string]string, error) {
+			return map[string]string{"name": "loaded"}, nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "loaded", value["name"])
+	})
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("DeletesValueAndRefreshLock", func(t *testing.T) {
+		cache := services.NewMemoryRedisService(0)
+		require.NoError(t, cache.Set(ctx, "item:1", "cached", time.Minute))
+		require.NoError(t, cache.Set(ctx, "cache_refresh:item:1", "1", time.Minute))
+
+		require.NoError(t, services.CacheInvalidate(ctx, cache, "item:1"))
+
+		for _, key := range []string{"item:1", "cache_refresh:item:1"} {
+			exists, err := cache.Exists(ctx, key)
+			require.NoError(t, err)
+			assert.False(t, exists, key)
+		}
+	})
+
+	t.Run("AttemptsBothDeletes", func(t *testing.T) {
+		cache := new(mocks.MockRedisService)
+		deleteErr := errors.New("redis down")
+		cache.On("Delete", mock.Anything, "item:2").Return(deleteErr).Once()
+		cache.On("Delete", mock.Anything, "cache_refresh:item:2").Return(nil).Once()
+
+		err := services.CacheInvalidate(ctx, cache, "item:2")
+
+		assert.ErrorIs(t, err, deleteErr)
+		cache.AssertExpectations(t)
+	})
+}
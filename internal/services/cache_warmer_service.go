@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+// CACHE_WARMER_PROBE_KEY is looked up before warming to make sure the cache is reachable
+const CACHE_WARMER_PROBE_KEY = "cache_warmer:probe"
+
+type CacheWarmerService interface {
+	WarmProfiles(ctx context.Context, limit int) (int, error)
+}
+
+type cacheWarmerServiceImpl struct {
+	repo                repositories.UserRepository
+	redisService        RedisService
+	profileCacheTTL     time.Duration
+	profileRefreshAhead time.Duration
+}
+
+func NewCacheWarmerService(repo repositories.UserRepository, redisService RedisService) CacheWarmerService {
+	profileCacheTTL := profileCacheTTLFromEnv()
+	return &cacheWarmerServiceImpl{
+		repo:                repo,
+		redisService:        redisService,
+		profileCacheTTL:     profileCacheTTL,
+		profileRefreshAhead: profileRefreshAheadFromEnv(profileCacheTTL),
+	}
+}
+
+// WarmProfiles pre-loads the profiles of the most recently active users into the cache
+// Parameters:
+//   - ctx: Context bounding the whole warm-up; warming stops once it is done
+//   - limit: Maximum number of profiles to load
+//
+// Returns:
+//   - int: Number of profiles cached
+//   - error: Returns an error if the cache is unreachable or candidates cannot be loaded
+//
+// Warming stops at the first cache write failure so an unhealthy cache does not delay startup.
+func (service *cacheWarmerServiceImpl) WarmProfiles(ctx context.Context, limit int) (int, error) {
+	if limit <= 0 {
+		return 0, nil
+	}
+
+	if _, err := service.redisService.Exists(ctx, CACHE_WARMER_PROBE_KEY); err != nil {
+		logger.WithContext(ctx).Warnf("Skipping profile cache warm-up, cache unavailable: %v", err)
+		return 0, err
+	}
+
+	users, err := service.repo.GetRecentlyActive(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	warmed := 0
+	for _, user := range users {
+		if err := ctx.Err(); err != nil {
+			logger.WithContext(ctx).Warnf("Profile cache warm-up stopped after %d profiles: %v", warmed, err)
+			return warmed, err
+		}
+		if err := cacheProfile(ctx, service.redisService, user, service.profileCacheTTL, service.profileRefreshAhead); err != nil {
+			logger.WithContext(ctx).Warnf("Profile cache warm-up stopped after %d profiles: %v", warmed, err)
+			return warmed, err
+		}
+		warmed++
+	}
+
+	logger.WithContext(ctx).Infof("Warmed %d profiles into cache", warmed)
+	return warmed, nil
+}
@@ -0,0 +1,130 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCacheWarmerService(t *testing.T) {
+	t.Run("WarmProfiles - Caches Most Recently Active Users Within Limit", func(t *testing.T) {
+		// Arrange
+		db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+		require.NoError(t, err)
+		require.NoError(t, db.AutoMigrate(&models.User{}, &models.Role{}, &models.RefreshToken{}, &models.UserPreference{}))
+
+		now := time.Now()
+		users := make([]*models.User, 3)
+		for i := range users {
+			users[i] = &models.User{Name: fmt.Sprintf("User%d", i), Email: fmt.Sprintf("user%d@example.com", i), Password: "password", Gender: 1}
+			require.NoError(t, db.Create(users[i]).Error)
+			token := &models.RefreshToken{
+				RefreshToken: fmt.Sprintf("token-%d", i),
+				IpAddress:    "127.0.0.1",
+				ExpiredAt:    now.Add(time.Hour).Unix(),
+				UserID:       users[i].ID,
+				UpdatedAt:    now.Add(time.Duration(i) * time.Minute),
+			}
+			require.NoError(t, db.Omit("User").Create(token).Error)
+		}
+
+		cache := services.NewMemoryRedisService(10)
+		warmer := services.NewCacheWarmerService(repositories.NewUserRepository(db), cache)
+
+		// Act
+		warmed, err := warmer.WarmProfiles(context.Background(), 2)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 2, warmed)
+		for i, expected := range []bool{false, true, true} {
+			exists, err := cache.Exists(context.Background(), fmt.Sprintf("profile:v2:%d", users[i].ID))
+			require.NoError(t, err)
+			assert.Equal(t, expected, exists, "profile cache for user %d", users[i].ID)
+		}
+	})
+
+	t.Run("WarmProfiles - Zero Limit Does Nothing", func(t *testing.T) {
+		repo := new(mocks.MockUserRepository)
+		cache := new(mocks.MockRedisService)
+		warmer := services.NewCacheWarmerService(repo, cache)
+
+		warmed, err := warmer.WarmProfiles(context.Background(), 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, warmed)
+		repo.AssertExpectations(t)
+		cache.AssertExpectations(t)
+	})
+
+	t.Run("WarmProfiles - Cache Unavailable", func(t *testing.T) {
+		repo := new(mocks.MockUserRepository)
+		cache := new(mocks.MockRedisService)
+		cache.On("Exists", mock.Anything, services.CACHE_WARMER_PROBE_KEY).Return(false, apperror.NewCacheExistsError("connection refused"))
+		warmer := services.NewCacheWarmerService(repo, cache)
+
+		warmed, err := warmer.WarmProfiles(context.Background(), 10)
+
+		assert.Error(t, err)
+		assert.Equal(t, 0, warmed)
+		repo.AssertNotCalled(t, "GetRecentlyActive", mock.Anything, mock.Anything)
+	})
+
+	t.Run("WarmProfiles - Repository Error", func(t *testing.T) {
+		repo := new(mocks.MockUserRepository)
+		cache := new(mocks.MockRedisService)
+		cache.On("Exists", mock.Anything, services.CACHE_WARMER_PROBE_KEY).Return(false, nil)
+		repo.On("GetRecentlyActive", mock.Anything, 10).Return(nil, errors.New("db error"))
+		warmer := services.NewCacheWarmerService(repo, cache)
+
+		warmed, err := warmer.WarmProfiles(context.Background(), 10)
+
+		assert.Error(t, err)
+		assert.Equal(t, 0, warmed)
+	})
+
+	t.Run("WarmProfiles - Stops On Cache Write Failure", func(t *testing.T) {
+		repo := new(mocks.MockUserRepository)
+		cache := new(mocks.MockRedisService)
+		cache.On("Exists", mock.Anything, services.CACHE_WARMER_PROBE_KEY).Return(false, nil)
+		repo.On("GetRecentlyActive", mock.Anything, 10).Return([]*models.User{{ID: 1}, {ID: 2}}, nil)
+		cache.On("Set", mock.Anything, "profile:v2:1", mock.AnythingOfType("string"), services.PROFILE_CACHE_TTL).Return(apperror.NewCacheSetError("connection refused")).Once()
+		warmer := services.NewCacheWarmerService(repo, cache)
+
+		warmed, err := warmer.WarmProfiles(context.Background(), 10)
+
+		assert.Error(t, err)
+		assert.Equal(t, 0, warmed)
+		cache.AssertNotCalled(t, "Set", mock.Anything, "profile:v2:2", mock.Anything, mock.Anything)
+	})
+
+	t.Run("WarmProfiles - Stops When Context Is Done", func(t *testing.T) {
+		repo := new(mocks.MockUserRepository)
+		cache := new(mocks.MockRedisService)
+		ctx, cancel := context.WithCancel(context.Background())
+		cache.On("Exists", mock.Anything, services.CACHE_WARMER_PROBE_KEY).Return(false, nil)
+		repo.On("GetRecentlyActive", mock.Anything, 10).Run(func(_ mock.Arguments) {
+			cancel()
+		}).Return([]*models.User{{ID: 1}}, nil)
+		warmer := services.NewCacheWarmerService(repo, cache)
+
+		warmed, err := warmer.WarmProfiles(ctx, 10)
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 0, warmed)
+		cache.AssertNotCalled(t, "Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
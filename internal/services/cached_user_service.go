@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+// USER_CACHE_TTL is how long a user returned by GetUser is cached.
+// It also bounds staleness after VerifyEmail, which only knows the token and cannot invalidate the entry
+const USER_CACHE_TTL = 5 * time.Minute
+
+// cachedUserServiceImpl decorates a UserService, caching GetUser results and dropping
+// the cached user after every call that changes it. Other calls pass straight through
+type cachedUserServiceImpl struct {
+	UserService
+	redisService RedisService
+	lookups      *prometheus.CounterVec
+}
+
+// NewCachedUserService wraps next so GetUser is served from the cache. Cache hits and misses
+// are counted in the cache_requests_total collector registered with registerer
+func NewCachedUserService(next UserService, redisService RedisService, registerer prometheus.Registerer) UserService {
+	lookups := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_requests_total",
+		Help: "Total number of cache lookups by cache and result (hit or miss).",
+	}, []string{"cache", "result"})
+	registerer.MustRegister(lookups)
+
+	return &cachedUserServiceImpl{
+		UserService:  next,
+		redisService: redisService,
+		lookups:      lookups,
+	}
+}
+
+// GetUser returns the user from the cache, loading and caching it on a miss.
+// Soft-deleted users are cached too, so the includeDeleted check is applied here
+func (service *cachedUserServiceImpl) GetUser(ctx context.Context, id uint, includeDeleted bool) (*models.User, error) {
+	user, ok := service.cachedUser(ctx, id)
+	if ok {
+		service.lookups.WithLabelValues("user", "hit").Inc()
+	} else {
+		service.lookups.WithLabelValues("user", "miss").Inc()
+
+		var err error
+		user, err = service.UserService.GetUser(ctx, id, true)
+		if err != nil {
+			return nil, err
+		}
+		service.cacheUser(ctx, user)
+	}
+
+	if user.DeletedAt.Valid && !includeDeleted {
+		return nil, apperror.NewNotFoundError("User has been deleted")
+	}
+	return user, nil
+}
+
+func (service *cachedUserServiceImpl) UpdateProfile(ctx context.Context, userID uint, input *dto.UpdateProfileInput) error {
+	defer service.invalidate(ctx, userID)
+	return service.UserService.UpdateProfile(ctx, userID, input)
+}
+
+func (service *cachedUserServiceImpl) ChangePassword(ctx context.Context, userId uint, input *dto.ChangePasswordInput) (*models.User, error) {
+	defer service.invalidate(ctx, userId)
+	return service.UserService.ChangePassword(ctx, userId, input)
+}
+
+func (service *cachedUserServiceImpl) ResetPassword(ctx context.Context, input *dto.ResetPasswordInput) (*models.User, error) {
+	user, err := service.UserService.ResetPassword(ctx, input)
+	if user != nil {
+		service.invalidate(ctx, user.ID)
+	}
+	return user, err
+}
+
+func (service *cachedUserServiceImpl) ForceResetPassword(ctx context.Context, id uint) (string, error) {
+	defer service.invalidate(ctx, id)
+	return service.UserService.ForceResetPassword(ctx, id)
+}
+
+func (service *cachedUserServiceImpl) RestoreUser(ctx context.Context, id uint) (*models.User, error) {
+	defer service.invalidate(ctx, id)
+	return service.UserService.RestoreUser(ctx, id)
+}
+
+func (service *cachedUserServiceImpl) DeleteUsers(ctx context.Context, ids []uint) (*dto.BulkDeleteUsersResult, error) {
+	result, err := service.UserService.DeleteUsers(ctx, ids)
+	if result != nil {
+		for _, id := range result.Deleted {
+			service.invalidate(ctx, id)
+		}
+	}
+	return result, err
+}
+
+func (service *cachedUserServiceImpl) AssignRoles(ctx context.Context, userID uint, roleIDs []uint) error {
+	defer service.invalidate(ctx, userID)
+	return service.UserService.AssignRoles(ctx, userID, roleIDs)
+}
+
+func (service *cachedUserServiceImpl) RemoveRoles(ctx context.Context, userID uint, roleIDs []uint) error {
+	defer service.invalidate(ctx, userID)
+	return service.UserService.RemoveRoles(ctx, userID, roleIDs)
+}
+
+// cachedUser returns the cached user, or false on a miss. Unreadable entries count as a miss
+func (service *cachedUserServiceImpl) cachedUser(ctx context.Context, id uint) (*models.User, bool) {
+	cached, err := service.redisService.Get(ctx, userCacheKey(id))
+	if err != nil {
+		if !errors.Is(err, ErrCacheMiss) {
+			logger.WithContext(ctx).Warnf("Failed to read cached user ID %d: %v", id, err)
+		}
+		return nil, false
+	}
+
+	var user models.User
+	if err := json.Unmarshal([]byte(cached), &user); err != nil {
+		logger.WithContext(ctx).Warnf("Failed to parse cached user ID %d: %v", id, err)
+		return nil, false
+	}
+	return &user, true
+}
+
+// cacheUser stores the user without its secrets, so they can never be read back from the cache
+func (service *cachedUserServiceImpl) cacheUser(ctx context.Context, user *models.User) {
+	stripped := *user
+	stripped.Password = ""
+	stripped.Token = nil
+
+	data, err := json.Marshal(&stripped)
+	if err != nil {
+		logger.WithContext(ctx).Warnf("Failed to serialize user ID %d for cache: %v", user.ID, err)
+		return
+	}
+	if err := service.redisService.Set(ctx, userCacheKey(user.ID), string(data), USER_CACHE_TTL); err != nil {
+		logger.WithContext(ctx).Warnf("Failed to cache user ID %d: %v", user.ID, err)
+	}
+}
+
+// invalidate drops the cached user. It runs even when the wrapped call failed, as the change may have been partly applied
+func (service *cachedUserServiceImpl) invalidate(ctx context.Context, id uint) {
+	if err := service.redisService.Delete(ctx, userCacheKey(id)); err != nil {
+		logger.WithContext(ctx).Warnf("Failed to invalidate cached user ID %d: %v", id, err)
+	}
+}
+
+func userCacheKey(userID uint) string {
+	return constants.USER + strconv.Itoa(int(userID))
+}
@@ -0,0 +1,298 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+	"gorm.io/gorm"
+)
+
+// cacheLookups returns the value of cache_requests_total for the user cache and the given result
+func cacheLookups(t *testing.T, registry *prometheus.Registry, result string) float64 {
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != "cache_requests_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["cache"] == "user" && labels["result"] == result {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestCachedUserService_GetUser(t *testing.T) {
+	t.Run("MissThenHit", func(t *testing.T) {
+		inner := new(mocks.MockUserService)
+		inner.On("GetUser", mock.Anything, uint(1), true).Return(&models.User{ID: 1, Name: "Bob"}, nil).Once()
+		registry := prometheus.NewRegistry()
+		service := services.NewCachedUserService(inner, services.NewMemoryRedisService(0), registry)
+
+		first, err := service.GetUser(context.Background(), 1, false)
+		require.NoError(t, err)
+		second, err := service.GetUser(context.Background(), 1, false)
+		require.NoError(t, err)
+
+		assert.Equal(t, "Bob", first.Name)
+		assert.Equal(t, "Bob", second.Name)
+		assert.Equal(t, float64(1), cacheLookups(t, registry, "miss"))
+		assert.Equal(t, float64(1), cacheLookups(t, registry, "hit"))
+		inner.AssertExpectations(t)
+	})
+
+	t.Run("SecretsAreNeverCached", func(t *testing.T) {
+		token := "reset-token"
+		inner := new(mocks.MockUserService)
+		inner.On("GetUser", mock.Anything, uint(1), true).Return(&models.User{ID: 1, Password: "hash", Token: &token}, nil).Once()
+		redisService := new(mocks.MockRedisService)
+		redisService.On("Get", mock.Anything, constants.USER+"1").Return("", services.ErrCacheMiss).Once()
+		redisService.On("Set", mock.Anything, constants.USER+"1", mock.MatchedBy(func(value string) bool {
+			var cached map[string]any
+			require.NoError(t, json.Unmarshal([]byte(value), &cached))
+			_, hasPassword := cached["password"]
+			_, hasToken := cached["token"]
+			return !hasPassword && !hasToken
+		}), services.USER_CACHE_TTL).Return(nil).Once()
+		service := services.NewCachedUserService(inner, redisService, prometheus.NewRegistry())
+
+		user, err := service.GetUser(context.Background(), 1, false)
+
+		require.NoError(t, err)
+		// The caller still gets the loaded user untouched
+		assert.Equal(t, "hash", user.Password)
+		redisService.AssertExpectations(t)
+	})
+
+	t.Run("CachedDeletedUser", func(t *testing.T) {
+		deleted := &models.User{ID: 2, DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}
+		inner := new(mocks.MockUserService)
+		inner.On("GetUser", mock.Anything, uint(2), true).Return(deleted, nil).Once()
+		service := services.NewCachedUserService(inner, services.NewMemoryRedisService(0), prometheus.NewRegistry())
+
+		user, err := service.GetUser(context.Background(), 2, true)
+		require.NoError(t, err)
+		assert.True(t, user.DeletedAt.Valid)
+
+		user, err = service.GetUser(context.Background(), 2, false)
+		assert.Nil(t, user)
+		appErr, ok := err.(*apperror.AppError)
+		require.True(t, ok)
+		assert.Equal(t, apperror.ErrNotFound, appErr.Code)
+		inner.AssertExpectations(t)
+	})
+
+	t.Run("InnerErrorIsNotCached", func(t *testing.T) {
+		inner := new(mocks.MockUserService)
+		inner.On("GetUser", mock.Anything, uint(3), true).Return(nil, apperror.NewNotFoundError("User not found")).Twice()
+		service := services.NewCachedUserService(inner, services.NewMemoryRedisService(0), prometheus.NewRegistry())
+
+		for range 2 {
+			_, err := service.GetUser(context.Background(), 3, false)
+			assert.Error(t, err)
+		}
+		inner.AssertExpectations(t)
+	})
+
+	t.Run("CacheErrorsFallBackToInner", func(t *testing.T) {
+		inner := new(mocks.MockUserService)
+		inner.On("GetUser", mock.Anything, uint(1), true).Return(&models.User{ID: 1}, nil).Once()
+		redisService := new(mocks.MockRedisService)
+		redisService.On("Get", mock.Anything, constants.USER+"1").Return("", errors.New("redis down")).Once()
+		redisService.On("Set", mock.Anything, constants.USER+"1", mock.Anything, services.USER_CACHE_TTL).Return(errors.New("redis down")).Once()
+		service := services.NewCachedUserService(inner, redisService, prometheus.NewRegistry())
+
+		user, err := service.GetUser(context.Background(), 1, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), user.ID)
+		redisService.AssertExpectations(t)
+	})
+
+	t.Run("UnreadableEntryIsReloaded", func(t *testing.T) {
+		inner := new(mocks.MockUserService)
+		inner.On("GetUser", mock.Anything, uint(1), true).Return(&models.User{ID: 1}, nil).Once()
+		redisService := services.NewMemoryRedisService(0)
+		require.NoError(t, redisService.Set(context.Background(), constants.USER+"1", "not json", time.Minute))
+		service := services.NewCachedUserService(inner, redisService, prometheus.NewRegistry())
+
+		user, err := service.GetUser(context.Background(), 1, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), user.ID)
+		inner.AssertExpectations(t)
+	})
+}
+
+func TestCachedUserService_Invalidation(t *testing.T) {
+	ctx := context.Background()
+
+	// warm loads user 1 into the cache, so a later inner GetUser call proves the entry was dropped
+	newWarmService := func(t *testing.T, inner *mocks.MockUserService) services.UserService {
+		inner.On("GetUser", mock.Anything, uint(1), true).Return(&models.User{ID: 1, Name: "Before"}, nil).Once()
+		service := services.NewCachedUserService(inner, services.NewMemoryRedisService(0), prometheus.NewRegistry())
+		_, err := service.GetUser(ctx, 1, false)
+		require.NoError(t, err)
+		inner.On("GetUser", mock.Anything, uint(1), true).Return(&models.User{ID: 1, Name: "After"}, nil).Once()
+		return service
+	}
+
+	mutations := []struct {
+		name   string
+		setup  func(inner *mocks.MockUserService)
+		mutate func(service services.UserService) error
+	}{
+		{
+			name: "UpdateProfile",
+			setup: func(inner *mocks.MockUserService) {
+				inner.On("UpdateProfile", mock.Anything, uint(1), mock.Anything).Return(nil).Once()
+			},
+			mutate: func(service services.UserService) error {
+				return service.UpdateProfile(ctx, 1, &dto.UpdateProfileInput{})
+			},
+		},
+		{
+			name: "ChangePassword",
+			setup: func(inner *mocks.MockUserService) {
+				inner.On("ChangePassword", mock.Anything, uint(1), mock.Anything).Return(&models.User{ID: 1}, nil).Once()
+			},
+			mutate: func(service services.UserService) error {
+				_, err := service.ChangePassword(ctx, 1, &dto.ChangePasswordInput{})
+				return err
+			},
+		},
+		{
+			name: "ResetPassword",
+			setup: func(inner *mocks.MockUserService) {
+				inner.On("ResetPassword", mock.Anything, mock.Anything).Return(&models.User{ID: 1}, nil).Once()
+			},
+			mutate: func(service services.UserService) error {
+				_, err := service.ResetPassword(ctx, &dto.ResetPasswordInput{})
+				return err
+			},
+		},
+		{
+			name: "ForceResetPassword",
+			setup: func(inner *mocks.MockUserService) {
+				inner.On("ForceResetPassword", mock.Anything, uint(1)).Return("temporary", nil).Once()
+			},
+			mutate: func(service services.UserService) error {
+				_, err := service.ForceResetPassword(ctx, 1)
+				return err
+			},
+		},
+		{
+			name: "RestoreUser",
+			setup: func(inner *mocks.MockUserService) {
+				inner.On("RestoreUser", mock.Anything, uint(1)).Return(&models.User{ID: 1}, nil).Once()
+			},
+			mutate: func(service services.UserService) error {
+				_, err := service.RestoreUser(ctx, 1)
+				return err
+			},
+		},
+		{
+			name: "DeleteUsers",
+			setup: func(inner *mocks.MockUserService) {
+				inner.On("DeleteUsers", mock.Anything, []uint{1, 2}).Return(&dto.BulkDeleteUsersResult{Deleted: []uint{1}, NotFound: []uint{2}}, nil).Once()
+			},
+			mutate: func(service services.UserService) error {
+				_, err := service.DeleteUsers(ctx, []uint{1, 2})
+				return err
+			},
+		},
+		{
+			name: "AssignRoles",
+			setup: func(inner *mocks.MockUserService) {
+				inner.On("AssignRoles", mock.Anything, uint(1), []uint{2}).Return(nil).Once()
+			},
+			mutate: func(service services.UserService) error {
+				return service.AssignRoles(ctx, 1, []uint{2})
+			},
+		},
+		{
+			name: "RemoveRoles",
+			setup: func(inner *mocks.MockUserService) {
+				inner.On("RemoveRoles", mock.Anything, uint(1), []uint{2}).Return(nil).Once()
+			},
+			mutate: func(service services.UserService) error {
+				return service.RemoveRoles(ctx, 1, []uint{2})
+			},
+		},
+		{
+			name: "FailedUpdateStillInvalidates",
+			setup: func(inner *mocks.MockUserService) {
+				inner.On("UpdateProfile", mock.Anything, uint(1), mock.Anything).Return(apperror.NewDBUpdateError("update failed")).Once()
+			},
+			mutate: func(service services.UserService) error {
+				_ = service.UpdateProfile(ctx, 1, &dto.UpdateProfileInput{})
+				return nil
+			},
+		},
+	}
+
+	for _, tt := range mutations {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := new(mocks.MockUserService)
+			service := newWarmService(t, inner)
+			tt.setup(inner)
+
+			require.NoError(t, tt.mutate(service))
+
+			// No stale read: the next lookup goes back to the wrapped service
+			user, err := service.GetUser(ctx, 1, false)
+			require.NoError(t, err)
+			assert.Equal(t, "After", user.Name)
+			inner.AssertExpectations(t)
+		})
+	}
+
+	t.Run("RoleChangeDropsCachedUser", func(t *testing.T) {
+		inner := new(mocks.MockUserService)
+		redisService := services.NewMemoryRedisService(0)
+		inner.On("GetUser", mock.Anything, uint(1), true).Return(&models.User{ID: 1, Name: "Before"}, nil).Once()
+		service := services.NewCachedUserService(inner, redisService, prometheus.NewRegistry())
+		_, err := service.GetUser(ctx, 1, false)
+		require.NoError(t, err)
+		inner.On("GetUser", mock.Anything, uint(1), true).Return(&models.User{ID: 1, Name: "After"}, nil).Once()
+
+		roleService := services.NewRoleService(new(mocks.MockRoleRepository), redisService)
+		require.NoError(t, roleService.InvalidateUserRoles(ctx, 1))
+
+		user, err := service.GetUser(ctx, 1, false)
+		require.NoError(t, err)
+		assert.Equal(t, "After", user.Name)
+		inner.AssertExpectations(t)
+	})
+
+	t.Run("PassThroughCallsAreNotCached", func(t *testing.T) {
+		inner := new(mocks.MockUserService)
+		inner.On("GetProfile", mock.Anything, uint(1)).Return(&dto.ProfileResponse{UserResponse: dto.UserResponse{ID: 1}}, nil).Twice()
+		service := services.NewCachedUserService(inner, services.NewMemoryRedisService(0), prometheus.NewRegistry())
+
+		for range 2 {
+			_, err := service.GetProfile(ctx, 1)
+			require.NoError(t, err)
+		}
+		inner.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+// EMAIL_CHANGE_TOKEN_TTL is how long the link confirming a new email address stays valid
+const EMAIL_CHANGE_TOKEN_TTL = 24 * time.Hour
+
+type EmailChangeService interface {
+	RequestEmailChange(ctx context.Context, userID uint, input *dto.ChangeEmailInput) error
+	ConfirmEmailChange(ctx context.Context, token string) (*models.User, error)
+}
+
+type emailChangeServiceImpl struct {
+	repo                repositories.UserRepository
+	bcryptService       BcryptService
+	mailerService       MailerService
+	notificationService NotificationService
+	redisService        RedisService
+}
+
+func NewEmailChangeService(repo repositories.UserRepository, bcryptService BcryptService, mailerService MailerService, notificationService NotificationService, redisService RedisService) EmailChangeService {
+	return &emailChangeServiceImpl{
+		repo:                repo,
+		bcryptService:       bcryptService,
+		mailerService:       mailerService,
+		notificationService: notificationService,
+		redisService:        redisService,
+	}
+}
+
+// RequestEmailChange stores the new email of the user as pending and mails a confirmation link to it, and
+// tells the current address about the request. The password must be the current one. The email only changes
+// once the link is opened, so the user keeps logging in with the current one until then. A new request
+// replaces the pending one and its link
+func (service *emailChangeServiceImpl) RequestEmailChange(ctx context.Context, userID uint, input *dto.ChangeEmailInput) error {
+	user, err := service.repo.GetByID(ctx, userID)
+	if err != nil {
+		return apperror.NewNotFoundError("User not found")
+	}
+
+	if isValid := service.bcryptService.CheckPasswordHash(input.Password, user.Password); !isValid {
+		return apperror.NewInvalidPasswordError("Password is incorrect")
+	}
+
+	newEmail := utils.NormalizeEmail(input.NewEmail)
+	if newEmail == user.Email {
+		return apperror.NewBadRequestError("New email must be different from the current one")
+	}
+	if _, err := service.repo.FindByField(ctx, "email", newEmail); err == nil {
+		return apperror.NewDuplicateEmailError("Email already registered")
+	}
+
+	token := utils.GenerateRandomString(USER_TOKEN_LENGTH)
+	hash := utils.HashToken(token)
+	expiredAt := time.Now().Add(EMAIL_CHANGE_TOKEN_TTL).Unix()
+	user.PendingEmail = &newEmail
+	user.EmailChangeToken = &hash
+	user.EmailChangeExpiredAt = &expiredAt
+	if err := service.repo.UpdatePendingEmail(ctx, user); err != nil {
+		return apperror.NewDBUpdateError("Failed to save pending email")
+	}
+	service.invalidateProfile(ctx, user.ID)
+	logger.WithContext(ctx).Infof("Email change requested for user ID %d", user.ID)
+
+	// The confirmation goes to the new address, which proves the user owns it
+	if err := service.mailerService.SendMailEmailChangeConfirmation(MailData{
+		Email:     newEmail,
+		Name:      user.Name,
+		Token:     token,
+		ExpiresAt: time.Unix(expiredAt, 0),
+	}); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to send email change confirmation for user ID %d: %v", user.ID, err)
+		return err
+	}
+
+	if err := service.notificationService.NotifyEmailChangeRequested(ctx, user, newEmail); err != nil {
+		logger.WithContext(ctx).Warnf("Failed to notify user ID %d of the email change request: %v", user.ID, err)
+	}
+	return nil
+}
+
+// ConfirmEmailChange replaces the email of the owner of the token with their pending email and returns the user.
+// The email is checked again, since another account may have registered it after the request; the unique index
+// on the email settles the case where both happen at the same time
+func (service *emailChangeServiceImpl) ConfirmEmailChange(ctx context.Context, token string) (*models.User, error) {
+	tokenHash := utils.HashToken(token)
+	user, err := service.repo.FindByField(ctx, "email_change_token", tokenHash)
+	if err != nil || user.PendingEmail == nil {
+		return nil, apperror.NewNotFoundError("Invalid token")
+	}
+
+	if user.EmailChangeExpiredAt == nil || time.Now().Unix() > *user.EmailChangeExpiredAt {
+		return nil, apperror.NewTokenExpiredError("Token has expired")
+	}
+
+	if _, err := service.repo.FindByField(ctx, "email", *user.PendingEmail); err == nil {
+		return nil, apperror.NewDuplicateEmailError("Email already registered")
+	}
+
+	user.Email = *user.PendingEmail
+	user.PendingEmail = nil
+	user.EmailChangeToken = nil
+	user.EmailChangeExpiredAt = nil
+	// The token is consumed by the same UPDATE that sets the email, so of concurrent requests with the token only one succeeds
+	confirmed, err := service.repo.ConfirmEmailChange(ctx, user, tokenHash)
+	if err != nil {
+		if appErr, ok := apperror.ToAppError(err); ok && appErr.Code == apperror.ErrDuplicateEmail {
+			return nil, err
+		}
+		return nil, apperror.NewDBUpdateError("Failed to change email")
+	}
+	if !confirmed {
+		logger.WithContext(ctx).Warnf("Email change token of user ID %d was consumed by a concurrent request", user.ID)
+		return nil, apperror.NewNotFoundError("Invalid token")
+	}
+
+	service.invalidateProfile(ctx, user.ID)
+	logger.WithContext(ctx).Infof("Email changed for user ID %d", user.ID)
+	return user, nil
+}
+
+// invalidateProfile drops the cached profile and user, which show the email and the pending email
+func (service *emailChangeServiceImpl) invalidateProfile(ctx context.Context, userID uint) {
+	for _, key := range []string{profileCacheKey(userID), userCacheKey(userID)} {
+		if err := CacheInvalidate(ctx, service.redisService, key); err != nil {
+			logger.WithContext(ctx).Warnf("Failed to invalidate cache key %s: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+type emailChangeFixture struct {
+	repo                *mocks.MockUserRepository
+	bcryptService       *mocks.MockBcryptService
+	mailerService       *mocks.MockMailerService
+	notificationService *mocks.MockNotificationService
+	redisService        services.RedisService
+	service             services.EmailChangeService
+}
+
+func newEmailChangeFixture() *emailChangeFixture {
+	f := &emailChangeFixture{
+		repo:                new(mocks.MockUserRepository),
+		bcryptService:       new(mocks.MockBcryptService),
+		mailerService:       new(mocks.MockMailerService),
+		notificationService: new(mocks.MockNotificationService),
+		redisService:        services.NewMemoryRedisService(0),
+	}
+	f.service = services.NewEmailChangeService(f.repo, f.bcryptService, f.mailerService, f.notificationService, f.redisService)
+	return f
+}
+
+func assertProfileCacheEmpty(t *testing.T, redisService services.RedisService, userID string) {
+	for _, key := range []string{constants.PROFILE + userID, constants.USER + userID} {
+		exists, err := redisService.Exists(context.Background(), key)
+		require.NoError(t, err)
+		assert.False(t, exists, key)
+	}
+}
+
+func TestEmailChangeService_RequestEmailChange(t *testing.T) {
+	ctx := context.Background()
+	input := &dto.ChangeEmailInput{NewEmail: " New@Example.com ", Password: "password123"}
+	currentUser := func() *models.User {
+		return &models.User{ID: 1, Name: "John", Email: "old@example.com", Password: "hashed"}
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		f := newEmailChangeFixture()
+		require.NoError(t, f.redisService.Set(ctx, constants.PROFILE+"1", "cached", 0))
+		require.NoError(t, f.redisService.Set(ctx, constants.USER+"1", "cached", 0))
+		f.repo.On("GetByID", mock.Anything, uint(1)).Return(currentUser(), nil).Once()
+		f.bcryptService.On("CheckPasswordHash", "password123", "hashed").Return(true).Once()
+		f.repo.On("FindByField", mock.Anything, "email", "new@example.com").Return((*models.User)(nil), apperror.NewNotFoundError("User not found")).Once()
+		var saved *models.User
+		f.repo.On("UpdatePendingEmail", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			saved = args.Get(1).(*models.User)
+		}).Return(nil).Once()
+		var mail services.MailData
+		f.mailerService.On("SendMailEmailChangeConfirmation", mock.Anything).Run(func(args mock.Arguments) {
+			mail = args.Get(0).(services.MailData)
+		}).Return(nil).Once()
+		f.notificationService.On("NotifyEmailChangeRequested", mock.Anything, mock.Anything, "new@example.com").Return(nil).Once()
+
+		err := f.service.RequestEmailChange(ctx, 1, input)
+
+		require.NoError(t, err)
+		require.NotNil(t, saved)
+		// Login keeps using the current email until the change is confirmed
+		assert.Equal(t, "old@example.com", saved.Email)
+		assert.Equal(t, "new@example.com", *saved.PendingEmail)
+		assert.Equal(t, utils.HashToken(mail.Token), *saved.EmailChangeToken)
+		assert.WithinDuration(t, time.Now().Add(services.EMAIL_CHANGE_TOKEN_TTL), time.Unix(*saved.EmailChangeExpiredAt, 0), time.Minute)
+		assert.Equal(t, "new@example.com", mail.Email)
+		f.repo.AssertExpectations(t)
+		f.mailerService.AssertExpectations(t)
+		f.notificationService.AssertExpectations(t)
+		assertProfileCacheEmpty(t, f.redisService, "1")
+	})
+
+	t.Run("DuplicateEmail", func(t *testing.T) {
+		f := newEmailChangeFixture()
+		f.repo.On("GetByID", mock.Anything, uint(1)).Return(currentUser(), nil).Once()
+		f.bcryptService.On("CheckPasswordHash", "password123", "hashed").Return(true).Once()
+		f.repo.On("FindByField", mock.Anything, "email", "new@example.com").Return(&models.User{ID: 2, Email: "new@example.com"}, nil).Once()
+
+		err := f.service.RequestEmailChange(ctx, 1, input)
+
+		assertAppErrorCode(t, err, apperror.ErrDuplicateEmail)
+		f.repo.AssertNotCalled(t, "UpdatePendingEmail", mock.Anything, mock.Anything)
+		f.mailerService.AssertNotCalled(t, "SendMailEmailChangeConfirmation", mock.Anything)
+	})
+
+	t.Run("SameEmail", func(t *testing.T) {
+		f := newEmailChangeFixture()
+		f.repo.On("GetByID", mock.Anything, uint(1)).Return(currentUser(), nil).Once()
+		f.bcryptService.On("CheckPasswordHash", "password123", "hashed").Return(true).Once()
+
+		err := f.service.RequestEmailChange(ctx, 1, &dto.ChangeEmailInput{NewEmail: "Old@Example.com", Password: "password123"})
+
+		assertAppErrorCode(t, err, apperror.ErrBadRequest)
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		f := newEmailChangeFixture()
+		f.repo.On("GetByID", mock.Anything, uint(1)).Return(currentUser(), nil).Once()
+		f.bcryptService.On("CheckPasswordHash", "password123", "hashed").Return(false).Once()
+
+		err := f.service.RequestEmailChange(ctx, 1, input)
+
+		assertAppErrorCode(t, err, apperror.ErrInvalidPassword)
+		f.repo.AssertNotCalled(t, "UpdatePendingEmail", mock.Anything, mock.Anything)
+	})
+
+	t.Run("MailError", func(t *testing.T) {
+		f := newEmailChangeFixture()
+		f.repo.On("GetByID", mock.Anything, uint(1)).Return(currentUser(), nil).Once()
+		f.bcryptService.On("CheckPasswordHash", "password123", "hashed").Return(true).Once()
+		f.repo.On("FindByField", mock.Anything, "email", "new@example.com").Return((*models.User)(nil), apperror.NewNotFoundError("User not found")).Once()
+		f.repo.On("UpdatePendingEmail", mock.Anything, mock.Anything).Return(nil).Once()
+		f.mailerService.On("SendMailEmailChangeConfirmation", mock.Anything).Return(errors.New("smtp down")).Once()
+
+		err := f.service.RequestEmailChange(ctx, 1, input)
+
+		assert.Error(t, err)
+		f.notificationService.AssertNotCalled(t, "NotifyEmailChangeRequested", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("NotificationErrorDoesNotFail", func(t *testing.T) {
+		f := newEmailChangeFixture()
+		f.repo.On("GetByID", mock.Anything, uint(1)).Return(currentUser(), nil).Once()
+		f.bcryptService.On("CheckPasswordHash", "password123", "hashed").Return(true).Once()
+		f.repo.On("FindByField", mock.Anything, "email", "new@example.com").Return((*models.User)(nil), apperror.NewNotFoundError("User not found")).Once()
+		f.repo.On("UpdatePendingEmail", mock.Anything, mock.Anything).Return(nil).Once()
+		f.mailerService.On("SendMailEmailChangeConfirmation", mock.Anything).Return(nil).Once()
+		f.notificationService.On("NotifyEmailChangeRequested", mock.Anything, mock.Anything, "new@example.com").Return(errors.New("smtp down")).Once()
+
+		err := f.service.RequestEmailChange(ctx, 1, input)
+
+		require.NoError(t, err)
+	})
+}
+
+func TestEmailChangeService_ConfirmEmailChange(t *testing.T) {
+	ctx := context.Background()
+	tokenHash := utils.HashToken("token")
+	pendingUser := func(expiresIn time.Duration) *models.User {
+		pendingEmail := "new@example.com"
+		expiredAt := time.Now().Add(expiresIn).Unix()
+		return &models.User{ID: 1, Email: "old@example.com", PendingEmail: &pendingEmail, EmailChangeToken: &tokenHash, EmailChangeExpiredAt: &expiredAt}
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		f := newEmailChangeFixture()
+		require.NoError(t, f.redisService.Set(ctx, constants.PROFILE+"1", "cached", 0))
+		require.NoError(t, f.redisService.Set(ctx, constants.USER+"1", "cached", 0))
+		f.repo.On("FindByField", mock.Anything, "email_change_token", tokenHash).Return(pendingUser(time.Hour), nil).Once()
+		f.repo.On("FindByField", mock.Anything, "email", "new@example.com").Return((*models.User)(nil), apperror.NewNotFoundError("User not found")).Once()
+		f.repo.On("ConfirmEmailChange", mock.Anything, mock.MatchedBy(func(user *models.User) bool {
+			return user.Email == "new@example.com" && user.PendingEmail == nil && user.EmailChangeToken == nil && user.EmailChangeExpiredAt == nil
+		}), tokenHash).Return(true, nil).Once()
+
+		user, err := f.service.ConfirmEmailChange(ctx, "token")
+
+		require.NoError(t, err)
+		assert.Equal(t, "new@example.com", user.Email)
+		f.repo.AssertExpectations(t)
+		assertProfileCacheEmpty(t, f.redisService, "1")
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		f := newEmailChangeFixture()
+		f.repo.On("FindByField", mock.Anything, "email_change_token", tokenHash).Return((*models.User)(nil), apperror.NewNotFoundError("User not found")).Once()
+
+		_, err := f.service.ConfirmEmailChange(ctx, "token")
+
+		assertAppErrorCode(t, err, apperror.ErrNotFound)
+	})
+
+	t.Run("ExpiredToken", func(t *testing.T) {
+		f := newEmailChangeFixture()
+		f.repo.On("FindByField", mock.Anything, "email_change_token", tokenHash).Return(pendingUser(-time.Minute), nil).Once()
+
+		_, err := f.service.ConfirmEmailChange(ctx, "token")
+
+		assertAppErrorCode(t, err, apperror.ErrTokenExpired)
+		f.repo.AssertNotCalled(t, "ConfirmEmailChange", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("EmailTakenSinceTheRequest", func(t *testing.T) {
+		f := newEmailChangeFixture()
+		f.repo.On("FindByField", mock.Anything, "email_change_token", tokenHash).Return(pendingUser(time.Hour), nil).Once()
+		f.repo.On("FindByField", mock.Anything, "email", "new@example.com").Return(&models.User{ID: 2, Email: "new@example.com"}, nil).Once()
+
+		_, err := f.service.ConfirmEmailChange(ctx, "token")
+
+		assertAppErrorCode(t, err, apperror.ErrDuplicateEmail)
+		f.repo.AssertNotCalled(t, "ConfirmEmailChange", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("EmailTakenConcurrently", func(t *testing.T) {
+		f := newEmailChangeFixture()
+		f.repo.On("FindByField", mock.Anything, "email_change_token", tokenHash).Return(pendingUser(time.Hour), nil).Once()
+		f.repo.On("FindByField", mock.Anything, "email", "new@example.com").Return((*models.User)(nil), apperror.NewNotFoundError("User not found")).Once()
+		f.repo.On("ConfirmEmailChange", mock.Anything, mock.Anything, tokenHash).Return(false, apperror.NewDuplicateEmailError("Email already registered")).Once()
+
+		_, err := f.service.ConfirmEmailChange(ctx, "token")
+
+		assertAppErrorCode(t, err, apperror.ErrDuplicateEmail)
+	})
+
+	t.Run("TokenConsumedConcurrently", func(t *testing.T) {
+		f := newEmailChangeFixture()
+		f.repo.On("FindByField", mock.Anything, "email_change_token", tokenHash).Return(pendingUser(time.Hour), nil).Once()
+		f.repo.On("FindByField", mock.Anything, "email", "new@example.com").Return((*models.User)(nil), apperror.NewNotFoundError("User not found")).Once()
+		f.repo.On("ConfirmEmailChange", mock.Anything, mock.Anything, tokenHash).Return(false, nil).Once()
+
+		_, err := f.service.ConfirmEmailChange(ctx, "token")
+
+		assertAppErrorCode(t, err, apperror.ErrNotFound)
+	})
+}
@@ -1,18 +1,38 @@
 package services
 
 import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
 	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
 )
 
 var (
-	ErrJWTKeyMissing  = errors.New("JWT_KEY environment variable is required")
-	ErrJWTKeyTooShort = errors.New("JWT_KEY must be at least 32 characters long for security")
+	ErrJWTKeyMissing        = errors.New("JWT_KEY, JWT_KEYS or JWT_KEYS_FILE environment variable is required")
+	ErrJWTKeyTooShort       = errors.New("JWT_KEY must be at least 32 characters long for security")
+	ErrJWTKeysInvalid       = errors.New("JWT keys must be id:secret pairs with unique, non-empty IDs")
+	ErrJWTActiveKeyUnknown  = errors.New("JWT_ACTIVE_KEY must be the ID of a configured key when several keys are configured")
+	ErrJWTUnknownKeyID      = errors.New("token is signed with an unknown key")
+	ErrJWTNoKeyID           = errors.New("token has no key ID")
+	ErrJWTAlgoUnknown       = errors.New("JWT_ALGO must be HS256 or RS256")
+	ErrJWTPrivateKeyMissing = errors.New("JWT_PRIVATE_KEY or JWT_PRIVATE_KEY_FILE environment variable is required with RS256")
+	ErrJWTPrivateKeyInvalid = errors.New("JWT private key must be a PEM encoded RSA key of at least 2048 bits")
+	ErrJWTIssuerMissing     = errors.New("JWT_ISSUER and JWT_AUDIENCE must not be empty")
 )
 
 const (
@@ -20,6 +40,19 @@ const (
 	TokenScopeAccess = "access"
 )
 
+// DEFAULT_JWT_KEY_ID is the ID given to the key of JWT_KEY, used when no other keys are configured
+const DEFAULT_JWT_KEY_ID = "default"
+
+// MIN_RSA_KEY_BITS is the smallest RSA key accepted for RS256
+const MIN_RSA_KEY_BITS = 2048
+
+// DEFAULT_JWT_ISSUER and DEFAULT_JWT_AUDIENCE are the iss and aud claims of the tokens used unless
+// JWT_ISSUER and JWT_AUDIENCE set others. Give each environment its own so tokens do not cross them
+const (
+	DEFAULT_JWT_ISSUER   = "golang-cms"
+	DEFAULT_JWT_AUDIENCE = "golang-cms-api"
+)
+
 // CustomClaims represents JWT claims with a custom user ID field and scope
 type CustomClaims struct {
 	ID    uint   `json:"id"`
@@ -33,51 +66,242 @@ type JWTService interface {
 	ValidateToken(tokenString string) (*CustomClaims, error)
 	ValidateTokenWithScope(tokenString string, requiredScope string) (*CustomClaims, error)
 	ValidateTokenIgnoreExpiration(tokenString string) (*CustomClaims, error)
+	Keys() []dto.JWTKeyResponse
+	JWKS() dto.JWKSResponse
+}
+
+// jwtKey is a signing key and the ID written to the kid header of the tokens it signs.
+// HS256 keys are shared secrets; RS256 keys are RSA private keys whose public half is published in the JWKS
+type jwtKey struct {
+	ID         string `json:"id"`
+	Secret     string `json:"secret"`
+	privateKey *rsa.PrivateKey
+}
+
+// signingKey returns the key tokens are signed with
+func (key jwtKey) signingKey() interface{} {
+	if key.privateKey != nil {
+		return key.privateKey
+	}
+	return []byte(key.Secret)
+}
+
+// verificationKey returns the key signatures are checked with
+func (key jwtKey) verificationKey() interface{} {
+	if key.privateKey != nil {
+		return &key.privateKey.PublicKey
+	}
+	return []byte(key.Secret)
 }
 
-// jwtServiceImpl implements JWTService
+// jwtServiceImpl implements JWTService. Tokens are signed with the active key and validated with
+// the key named by their kid header, so keys can be rotated without logging every user out.
+// Only tokens signed with method are accepted, so an RS256 public key can never be used as an HS256 secret.
+// Only tokens issued by issuer for audience are accepted, so a secret shared by two environments does not
+// let the tokens of one be used in the other
 type jwtServiceImpl struct {
-	secret []byte
+	method    jwt.SigningMethod
+	keys      []jwtKey
+	active    jwtKey
+	accessTTL time.Duration
+	issuer    string
+	audience  string
 }
 
+// DEFAULT_ACCESS_TOKEN_TTL and DEFAULT_REFRESH_TOKEN_TTL are the token lifetimes used unless
+// ACCESS_TOKEN_TTL and REFRESH_TOKEN_TTL set others
+const (
+	DEFAULT_ACCESS_TOKEN_TTL  = time.Hour
+	DEFAULT_REFRESH_TOKEN_TTL = 30 * 24 * time.Hour
+)
+
 var (
-	signJWTToken = func(token *jwt.Token, secret []byte) (string, error) {
-		return token.SignedString(secret)
+	signJWTToken = func(token *jwt.Token, key interface{}) (string, error) {
+		return token.SignedString(key)
 	}
 	parseJWTWithClaims = func(tokenString string, claims jwt.Claims, keyFunc jwt.Keyfunc, options ...jwt.ParserOption) (*jwt.Token, error) {
 		return jwt.ParseWithClaims(tokenString, claims, keyFunc, options...)
 	}
 )
 
-// NewJWTService returns a new instance of jwtServiceImpl
+// NewJWTService returns a new instance of jwtServiceImpl signing with the algorithm of JWT_ALGO, HS256 by default.
+// With RS256 the RSA private key is read from JWT_PRIVATE_KEY_FILE or JWT_PRIVATE_KEY, see loadRSAKey.
+// With HS256 keys are read from JWT_KEYS_FILE, a JSON array of {"id": ..., "secret": ...} objects, or else from
+// JWT_KEYS, comma separated id:secret pairs, or else JWT_KEY alone.
+// JWT_ACTIVE_KEY selects the key new tokens are signed with; it may be omitted when there is a single key.
+// The iss and aud claims are read from JWT_ISSUER and JWT_AUDIENCE
 func NewJWTService() (JWTService, error) {
+	service := &jwtServiceImpl{
+		accessTTL: tokenTTLFromEnv("ACCESS_TOKEN_TTL", DEFAULT_ACCESS_TOKEN_TTL),
+		issuer:    strings.TrimSpace(utils.GetEnv("JWT_ISSUER", DEFAULT_JWT_ISSUER)),
+		audience:  strings.TrimSpace(utils.GetEnv("JWT_AUDIENCE", DEFAULT_JWT_AUDIENCE)),
+	}
+	if service.issuer == "" || service.audience == "" {
+		return nil, ErrJWTIssuerMissing
+	}
+
+	algo := strings.ToUpper(strings.TrimSpace(utils.GetEnv("JWT_ALGO", "")))
+	if algo == jwt.SigningMethodRS256.Alg() {
+		key, err := loadRSAKey()
+		if err != nil {
+			return nil, err
+		}
+		service.method, service.keys, service.active = jwt.SigningMethodRS256, []jwtKey{key}, key
+		return service, nil
+	}
+	if algo != "" && algo != jwt.SigningMethodHS256.Alg() {
+		return nil, ErrJWTAlgoUnknown
+	}
+
+	keys, err := loadJWTKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if key.ID == "" || seen[key.ID] {
+			return nil, ErrJWTKeysInvalid
+		}
+		seen[key.ID] = true
+		if len(key.Secret) < 32 {
+			return nil, ErrJWTKeyTooShort
+		}
+	}
+
+	activeID := strings.TrimSpace(utils.GetEnv("JWT_ACTIVE_KEY", ""))
+	if activeID == "" && len(keys) == 1 {
+		activeID = keys[0].ID
+	}
+	for _, key := range keys {
+		if key.ID == activeID {
+			service.method, service.keys, service.active = jwt.SigningMethodHS256, keys, key
+			return service, nil
+		}
+	}
+	return nil, ErrJWTActiveKeyUnknown
+}
+
+// tokenTTLFromEnv reads a token lifetime written as a duration such as 15m or 720h. Malformed and
+// non-positive values are logged and replaced by defaultValue, so a typo cannot issue expired tokens
+func tokenTTLFromEnv(key string, defaultValue time.Duration) time.Duration {
+	value := strings.TrimSpace(utils.GetEnv(key, ""))
+	if value == "" {
+		return defaultValue
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		logger.Warnf("Invalid %s %q, using the default of %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return ttl
+}
+
+// loadJWTKeys reads the configured keys, from the first of JWT_KEYS_FILE, JWT_KEYS and JWT_KEY that is set
+func loadJWTKeys() ([]jwtKey, error) {
+	if path := strings.TrimSpace(utils.GetEnv("JWT_KEYS_FILE", "")); path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read JWT_KEYS_FILE: %w", err)
+		}
+		var keys []jwtKey
+		if err := json.Unmarshal(content, &keys); err != nil {
+			return nil, fmt.Errorf("parse JWT_KEYS_FILE: %w", err)
+		}
+		if len(keys) == 0 {
+			return nil, ErrJWTKeyMissing
+		}
+		return keys, nil
+	}
+
+	if pairs := strings.TrimSpace(utils.GetEnv("JWT_KEYS", "")); pairs != "" {
+		var keys []jwtKey
+		for _, pair := range strings.Split(pairs, ",") {
+			id, secret, ok := strings.Cut(strings.TrimSpace(pair), ":")
+			if !ok {
+				return nil, ErrJWTKeysInvalid
+			}
+			keys = append(keys, jwtKey{ID: strings.TrimSpace(id), Secret: strings.TrimSpace(secret)})
+		}
+		return keys, nil
+	}
+
 	secret := strings.TrimSpace(utils.GetEnv("JWT_KEY", ""))
 	if secret == "" {
 		return nil, ErrJWTKeyMissing
 	}
-	if len(secret) < 32 {
-		return nil, ErrJWTKeyTooShort
+	return []jwtKey{{ID: DEFAULT_JWT_KEY_ID, Secret: secret}}, nil
+}
+
+// loadRSAKey reads the PEM encoded RSA private key, PKCS #1 or PKCS #8, from the file JWT_PRIVATE_KEY_FILE or else
+// from JWT_PRIVATE_KEY, where line breaks may be written as \n. The key ID is its RFC 7638 thumbprint, so a new key
+// always gets a new ID
+func loadRSAKey() (jwtKey, error) {
+	var content []byte
+	if path := strings.TrimSpace(utils.GetEnv("JWT_PRIVATE_KEY_FILE", "")); path != "" {
+		var err error
+		if content, err = os.ReadFile(path); err != nil {
+			return jwtKey{}, fmt.Errorf("read JWT_PRIVATE_KEY_FILE: %w", err)
+		}
+	} else if value := strings.TrimSpace(utils.GetEnv("JWT_PRIVATE_KEY", "")); value != "" {
+		content = []byte(strings.ReplaceAll(value, `\n`, "\n"))
+	} else {
+		return jwtKey{}, ErrJWTPrivateKeyMissing
 	}
-	return &jwtServiceImpl{
-		secret: []byte(secret),
-	}, nil
+
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return jwtKey{}, ErrJWTPrivateKeyInvalid
+	}
+	privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		parsed, pkcs8Err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if pkcs8Err != nil {
+			return jwtKey{}, ErrJWTPrivateKeyInvalid
+		}
+		var ok bool
+		if privateKey, ok = parsed.(*rsa.PrivateKey); !ok {
+			return jwtKey{}, ErrJWTPrivateKeyInvalid
+		}
+	}
+	if privateKey.N.BitLen() < MIN_RSA_KEY_BITS {
+		return jwtKey{}, ErrJWTPrivateKeyInvalid
+	}
+	return jwtKey{ID: rsaThumbprint(&privateKey.PublicKey), privateKey: privateKey}, nil
+}
+
+// rsaThumbprint returns the RFC 7638 thumbprint of the public key
+func rsaThumbprint(publicKey *rsa.PublicKey) string {
+	jwk := fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, rsaExponent(publicKey), base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()))
+	sum := sha256.Sum256([]byte(jwk))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// rsaExponent returns the public exponent as base64url encoded big-endian bytes, as JWKs carry it
+func rsaExponent(publicKey *rsa.PublicKey) string {
+	return base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
 }
 
 // GenerateAccessToken creates a new access JWT token for the given user ID
-// Access tokens have 1-hour expiration and can access all authenticated endpoints
+// Access tokens expire after ACCESS_TOKEN_TTL, 1 hour by default, and can access all authenticated endpoints
 func (s *jwtServiceImpl) GenerateAccessToken(id uint) (*dto.JwtResult, error) {
-	expiresAt := jwt.NewNumericDate(time.Now().Add(time.Hour))
+	expiresAt := jwt.NewNumericDate(time.Now().Add(s.accessTTL))
 	claims := CustomClaims{
 		ID:    id,
 		Scope: TokenScopeAccess,
 		RegisteredClaims: jwt.RegisteredClaims{
+			// The jti lets a single access token be revoked before it expires
+			ID:        uuid.NewString(),
+			Issuer:    s.issuer,
+			Audience:  jwt.ClaimStrings{s.audience},
 			ExpiresAt: expiresAt,
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := signJWTToken(token, s.secret)
+	token := jwt.NewWithClaims(s.method, claims)
+	token.Header["kid"] = s.active.ID
+	signedToken, err := signJWTToken(token, s.active.signingKey())
 	if err != nil {
 		return nil, err
 	}
@@ -90,9 +314,7 @@ func (s *jwtServiceImpl) GenerateAccessToken(id uint) (*dto.JwtResult, error) {
 
 // ValidateToken validates a JWT token string and returns the claims if valid
 func (s *jwtServiceImpl) ValidateToken(tokenString string) (*CustomClaims, error) {
-	token, err := parseJWTWithClaims(tokenString, &CustomClaims{}, func(t *jwt.Token) (interface{}, error) {
-		return s.secret, nil
-	})
+	token, err := s.parse(tokenString)
 
 	if err != nil {
 		return nil, err
@@ -124,9 +346,7 @@ func (s *jwtServiceImpl) ValidateTokenWithScope(tokenString string, requiredScop
 // This is useful when you want to extract user information from expired tokens
 // Returns error if token signature is invalid, but ignores exp claim
 func (s *jwtServiceImpl) ValidateTokenIgnoreExpiration(tokenString string) (*CustomClaims, error) {
-	token, err := parseJWTWithClaims(tokenString, &CustomClaims{}, func(t *jwt.Token) (interface{}, error) {
-		return s.secret, nil
-	}, jwt.WithoutClaimsValidation())
+	token, err := s.parse(tokenString, jwt.WithoutClaimsValidation())
 
 	if err != nil {
 		return nil, err
@@ -135,8 +355,70 @@ func (s *jwtServiceImpl) ValidateTokenIgnoreExpiration(tokenString string) (*Cus
 	if claims, ok := token.Claims.(*CustomClaims); ok {
 		// We do a basic signature validation here
 		// The token signature is valid if ParseWithClaims succeeded
+		// Claims validation is skipped as a whole, so the issuer and audience are checked here
+		if claims.Issuer != s.issuer {
+			return nil, jwt.ErrTokenInvalidIssuer
+		}
+		if !slices.Contains(claims.Audience, s.audience) {
+			return nil, jwt.ErrTokenInvalidAudience
+		}
 		return claims, nil
 	}
 
 	return nil, jwt.ErrInvalidType
 }
+
+// Keys lists the IDs of the configured keys and which one signs new tokens. Secrets are never returned
+func (s *jwtServiceImpl) Keys() []dto.JWTKeyResponse {
+	keys := make([]dto.JWTKeyResponse, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, dto.JWTKeyResponse{ID: key.ID, Active: key.ID == s.active.ID})
+	}
+	return keys
+}
+
+// JWKS returns the public keys tokens can be verified with, for services that verify tokens themselves.
+// It is empty with HS256, whose keys are secrets
+func (s *jwtServiceImpl) JWKS() dto.JWKSResponse {
+	jwks := dto.JWKSResponse{Keys: []dto.JWK{}}
+	for _, key := range s.keys {
+		if key.privateKey == nil {
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, dto.JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: s.method.Alg(),
+			Kid: key.ID,
+			N:   base64.RawURLEncoding.EncodeToString(key.privateKey.N.Bytes()),
+			E:   rsaExponent(&key.privateKey.PublicKey),
+		})
+	}
+	return jwks
+}
+
+// parse verifies the token with the key named by its kid header and checks its issuer and audience. Tokens whose
+// alg header is not the configured method are rejected before any key is looked up. Tokens without a kid were issued
+// before tokens carried a kid, iss and aud, so they are rejected and their users log in again
+func (s *jwtServiceImpl) parse(tokenString string, options ...jwt.ParserOption) (*jwt.Token, error) {
+	options = append([]jwt.ParserOption{
+		jwt.WithValidMethods([]string{s.method.Alg()}),
+		jwt.WithIssuer(s.issuer),
+		jwt.WithAudience(s.audience),
+	}, options...)
+	return parseJWTWithClaims(tokenString, &CustomClaims{}, s.keyFor, options...)
+}
+
+// keyFor returns the verification key of the key named by the kid header of the token
+func (s *jwtServiceImpl) keyFor(token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		return nil, ErrJWTNoKeyID
+	}
+	for _, key := range s.keys {
+		if key.ID == kid {
+			return key.verificationKey(), nil
+		}
+	}
+	return nil, ErrJWTUnknownKeyID
+}
@@ -22,7 +22,7 @@ func TestJWTService_InternalBranches(t *testing.T) {
 	require.NoError(t, err)
 
 	t.Run("GenerateAccessTokenSigningError", func(t *testing.T) {
-		signJWTToken = func(_ *jwt.Token, _ []byte) (string, error) {
+		signJWTToken = func(_ *jwt.Token, _ interface{}) (string, error) {
 			return "", errors.New("sign failed")
 		}
 
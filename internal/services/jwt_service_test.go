@@ -1,6 +1,14 @@
 package services_test
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -9,6 +17,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
 )
 
 func TestJWTService(t *testing.T) {
@@ -28,6 +37,34 @@ func TestJWTService(t *testing.T) {
 		assert.Equal(t, services.TokenScopeAccess, claims.Scope)
 	})
 
+	t.Run("GenerateAccessToken_ExpiresAfterTheConfiguredTTL", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			value string
+			ttl   time.Duration
+		}{
+			{name: "Configured", value: "5m", ttl: 5 * time.Minute},
+			{name: "Default", value: "", ttl: services.DEFAULT_ACCESS_TOKEN_TTL},
+			{name: "Malformed", value: "5 minutes", ttl: services.DEFAULT_ACCESS_TOKEN_TTL},
+			{name: "NotPositive", value: "-5m", ttl: services.DEFAULT_ACCESS_TOKEN_TTL},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Setenv("ACCESS_TOKEN_TTL", tt.value)
+				svc, err := services.NewJWTService()
+				require.NoError(t, err)
+
+				result, err := svc.GenerateAccessToken(456)
+				require.NoError(t, err)
+
+				claims, err := svc.ValidateToken(result.Token)
+				require.NoError(t, err)
+				assert.Equal(t, result.ExpiresAt, claims.ExpiresAt.Unix())
+				assert.InDelta(t, time.Now().Add(tt.ttl).Unix(), result.ExpiresAt, 1)
+			})
+		}
+	})
+
 	t.Run("ValidateTokenWithScope_AccessToken", func(t *testing.T) {
 		svc, err := services.NewJWTService()
 		require.NoError(t, err)
@@ -56,6 +93,7 @@ func TestJWTService(t *testing.T) {
 				IssuedAt:  jwt.NewNumericDate(time.Now()),
 			},
 		})
+		token.Header["kid"] = services.DEFAULT_JWT_KEY_ID
 		signedToken, err := token.SignedString([]byte("different_secret"))
 		require.NoError(t, err)
 
@@ -107,10 +145,13 @@ func TestJWTService(t *testing.T) {
 			ID:    21,
 			Scope: services.TokenScopeAccess,
 			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    services.DEFAULT_JWT_ISSUER,
+				Audience:  jwt.ClaimStrings{services.DEFAULT_JWT_AUDIENCE},
 				ExpiresAt: jwt.NewNumericDate(time.Now().Add(-1 * time.Hour)),
 				IssuedAt:  jwt.NewNumericDate(time.Now().Add(-2 * time.Hour)),
 			},
 		})
+		token.Header["kid"] = services.DEFAULT_JWT_KEY_ID
 		expiredToken, err := token.SignedString([]byte("this-is-a-very-long-secret-key-for-testing-purposes-32-chars"))
 		require.NoError(t, err)
 
@@ -129,3 +170,392 @@ func TestJWTService(t *testing.T) {
 		assert.Nil(t, claims)
 	})
 }
+
+func TestJWTServiceIssuerAndAudience(t *testing.T) {
+	secret := "this-is-a-very-long-secret-key-for-testing-purposes-32-chars"
+	t.Setenv("JWT_KEY", secret)
+	// mint signs a token with the shared secret as another environment would
+	mint := func(t *testing.T, issuer string, audience jwt.ClaimStrings, expiresIn time.Duration) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, &services.CustomClaims{
+			ID:    9,
+			Scope: services.TokenScopeAccess,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    issuer,
+				Audience:  audience,
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+			},
+		})
+		token.Header["kid"] = services.DEFAULT_JWT_KEY_ID
+		signed, err := token.SignedString([]byte(secret))
+		require.NoError(t, err)
+		return signed
+	}
+
+	t.Run("DefaultsAreSetOnGeneratedTokens", func(t *testing.T) {
+		svc, err := services.NewJWTService()
+		require.NoError(t, err)
+		result, err := svc.GenerateAccessToken(1)
+		require.NoError(t, err)
+
+		claims, err := svc.ValidateToken(result.Token)
+		require.NoError(t, err)
+		assert.Equal(t, services.DEFAULT_JWT_ISSUER, claims.Issuer)
+		assert.Equal(t, jwt.ClaimStrings{services.DEFAULT_JWT_AUDIENCE}, claims.Audience)
+	})
+
+	t.Run("ConfiguredValues", func(t *testing.T) {
+		t.Setenv("JWT_ISSUER", "https://auth.staging.example.com")
+		t.Setenv("JWT_AUDIENCE", "staging-api")
+		svc, err := services.NewJWTService()
+		require.NoError(t, err)
+		result, err := svc.GenerateAccessToken(1)
+		require.NoError(t, err)
+
+		claims, err := svc.ValidateToken(result.Token)
+		require.NoError(t, err)
+		assert.Equal(t, "https://auth.staging.example.com", claims.Issuer)
+		assert.Equal(t, jwt.ClaimStrings{"staging-api"}, claims.Audience)
+	})
+
+	t.Run("OtherIssuerRejected", func(t *testing.T) {
+		svc, err := services.NewJWTService()
+		require.NoError(t, err)
+		token := mint(t, "https://auth.staging.example.com", jwt.ClaimStrings{services.DEFAULT_JWT_AUDIENCE}, time.Hour)
+
+		_, err = svc.ValidateToken(token)
+		assert.ErrorIs(t, err, jwt.ErrTokenInvalidIssuer)
+		_, err = svc.ValidateTokenWithScope(token, services.TokenScopeAccess)
+		assert.ErrorIs(t, err, jwt.ErrTokenInvalidIssuer)
+		_, err = svc.ValidateTokenIgnoreExpiration(mint(t, "https://auth.staging.example.com", jwt.ClaimStrings{services.DEFAULT_JWT_AUDIENCE}, -time.Hour))
+		assert.ErrorIs(t, err, jwt.ErrTokenInvalidIssuer)
+	})
+
+	t.Run("OtherAudienceRejected", func(t *testing.T) {
+		svc, err := services.NewJWTService()
+		require.NoError(t, err)
+
+		_, err = svc.ValidateToken(mint(t, services.DEFAULT_JWT_ISSUER, jwt.ClaimStrings{"staging-api"}, time.Hour))
+		assert.ErrorIs(t, err, jwt.ErrTokenInvalidAudience)
+		_, err = svc.ValidateTokenIgnoreExpiration(mint(t, services.DEFAULT_JWT_ISSUER, nil, -time.Hour))
+		assert.ErrorIs(t, err, jwt.ErrTokenInvalidAudience)
+	})
+
+	t.Run("OneOfSeveralAudiencesAccepted", func(t *testing.T) {
+		svc, err := services.NewJWTService()
+		require.NoError(t, err)
+
+		claims, err := svc.ValidateToken(mint(t, services.DEFAULT_JWT_ISSUER, jwt.ClaimStrings{"reporting", services.DEFAULT_JWT_AUDIENCE}, time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, uint(9), claims.ID)
+	})
+
+	t.Run("EmptyValuesRejected", func(t *testing.T) {
+		t.Setenv("JWT_ISSUER", " ")
+		_, err := services.NewJWTService()
+		assert.ErrorIs(t, err, services.ErrJWTIssuerMissing)
+	})
+}
+
+func TestJWTServiceKeyRotation(t *testing.T) {
+	oldSecret := "old-secret-key-that-is-at-least-32-characters"
+	newSecret := "new-secret-key-that-is-at-least-32-characters"
+	// legacyToken is signed like tokens issued before kid headers and the iss and aud claims were added
+	legacyToken := func(t *testing.T, secret string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, &services.CustomClaims{
+			ID:    7,
+			Scope: services.TokenScopeAccess,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		})
+		signed, err := token.SignedString([]byte(secret))
+		require.NoError(t, err)
+		return signed
+	}
+	kidOf := func(t *testing.T, tokenString string) string {
+		token, _, err := jwt.NewParser().ParseUnverified(tokenString, &services.CustomClaims{})
+		require.NoError(t, err)
+		kid, _ := token.Header["kid"].(string)
+		return kid
+	}
+
+	t.Run("OldKeyStillValidatesUntilRemoved", func(t *testing.T) {
+		t.Setenv("JWT_KEYS", "2024:"+oldSecret)
+		before, err := services.NewJWTService()
+		require.NoError(t, err)
+		oldToken, err := before.GenerateAccessToken(1)
+		require.NoError(t, err)
+		assert.Equal(t, "2024", kidOf(t, oldToken.Token))
+
+		// Rotate: add the new key and sign with it, keeping the old one for validation
+		t.Setenv("JWT_KEYS", "2024:"+oldSecret+", 2025:"+newSecret)
+		t.Setenv("JWT_ACTIVE_KEY", "2025")
+		rotated, err := services.NewJWTService()
+		require.NoError(t, err)
+		newToken, err := rotated.GenerateAccessToken(2)
+		require.NoError(t, err)
+		assert.Equal(t, "2025", kidOf(t, newToken.Token))
+
+		claims, err := rotated.ValidateToken(oldToken.Token)
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), claims.ID)
+		claims, err = rotated.ValidateToken(newToken.Token)
+		require.NoError(t, err)
+		assert.Equal(t, uint(2), claims.ID)
+
+		// Once the old key is removed, its tokens stop validating
+		t.Setenv("JWT_KEYS", "2025:"+newSecret)
+		t.Setenv("JWT_ACTIVE_KEY", "")
+		after, err := services.NewJWTService()
+		require.NoError(t, err)
+		_, err = after.ValidateToken(oldToken.Token)
+		assert.ErrorIs(t, err, services.ErrJWTUnknownKeyID)
+		_, err = after.ValidateTokenIgnoreExpiration(oldToken.Token)
+		assert.ErrorIs(t, err, services.ErrJWTUnknownKeyID)
+	})
+
+	t.Run("TokensWithoutKidAreRejected", func(t *testing.T) {
+		// Such tokens were issued with JWT_KEY alone; their users log in again even if the secret is still configured
+		t.Setenv("JWT_KEYS", "legacy:"+oldSecret+",2025:"+newSecret)
+		t.Setenv("JWT_ACTIVE_KEY", "2025")
+		svc, err := services.NewJWTService()
+		require.NoError(t, err)
+
+		_, err = svc.ValidateToken(legacyToken(t, oldSecret))
+		assert.ErrorIs(t, err, services.ErrJWTNoKeyID)
+		_, err = svc.ValidateTokenIgnoreExpiration(legacyToken(t, newSecret))
+		assert.ErrorIs(t, err, services.ErrJWTNoKeyID)
+	})
+
+	t.Run("KeysFile", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jwt-keys.json")
+		require.NoError(t, os.WriteFile(path, []byte(`[{"id": "2024", "secret": "`+oldSecret+`"}, {"id": "2025", "secret": "`+newSecret+`"}]`), 0o600))
+		t.Setenv("JWT_KEYS_FILE", path)
+		t.Setenv("JWT_KEYS", "ignored:"+oldSecret)
+		t.Setenv("JWT_ACTIVE_KEY", "2024")
+
+		svc, err := services.NewJWTService()
+		require.NoError(t, err)
+
+		assert.Equal(t, []dto.JWTKeyResponse{{ID: "2024", Active: true}, {ID: "2025", Active: false}}, svc.Keys())
+		token, err := svc.GenerateAccessToken(3)
+		require.NoError(t, err)
+		assert.Equal(t, "2024", kidOf(t, token.Token))
+	})
+
+	t.Run("JWTKeyAloneGetsTheDefaultID", func(t *testing.T) {
+		t.Setenv("JWT_KEY", oldSecret)
+		svc, err := services.NewJWTService()
+		require.NoError(t, err)
+
+		assert.Equal(t, []dto.JWTKeyResponse{{ID: services.DEFAULT_JWT_KEY_ID, Active: true}}, svc.Keys())
+	})
+
+	t.Run("InvalidConfiguration", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			keys     string
+			active   string
+			expected error
+		}{
+			{name: "NotAPair", keys: "2024" + oldSecret, expected: services.ErrJWTKeysInvalid},
+			{name: "EmptyID", keys: ":" + oldSecret, expected: services.ErrJWTKeysInvalid},
+			{name: "DuplicateID", keys: "a:" + oldSecret + ",a:" + newSecret, active: "a", expected: services.ErrJWTKeysInvalid},
+			{name: "ShortSecret", keys: "a:short", expected: services.ErrJWTKeyTooShort},
+			{name: "ActiveKeyMissing", keys: "a:" + oldSecret + ",b:" + newSecret, expected: services.ErrJWTActiveKeyUnknown},
+			{name: "ActiveKeyUnknown", keys: "a:" + oldSecret, active: "b", expected: services.ErrJWTActiveKeyUnknown},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Setenv("JWT_KEYS", tt.keys)
+				t.Setenv("JWT_ACTIVE_KEY", tt.active)
+
+				svc, err := services.NewJWTService()
+
+				assert.Nil(t, svc)
+				assert.Equal(t, tt.expected, err)
+			})
+		}
+
+		t.Run("KeysFileUnreadable", func(t *testing.T) {
+			t.Setenv("JWT_KEYS_FILE", filepath.Join(t.TempDir(), "missing.json"))
+			_, err := services.NewJWTService()
+			assert.Error(t, err)
+		})
+
+		t.Run("KeysFileNotJSON", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "jwt-keys.json")
+			require.NoError(t, os.WriteFile(path, []byte("2024:"+oldSecret), 0o600))
+			t.Setenv("JWT_KEYS_FILE", path)
+			_, err := services.NewJWTService()
+			assert.Error(t, err)
+		})
+	})
+}
+
+func TestJWTServiceRS256(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pkcs1PEM := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}))
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	pkcs8PEM := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}))
+	secret := "this-is-a-very-long-secret-key-for-testing-purposes-32-chars"
+
+	newRS256Service := func(t *testing.T) services.JWTService {
+		t.Setenv("JWT_ALGO", "RS256")
+		t.Setenv("JWT_PRIVATE_KEY", pkcs1PEM)
+		svc, err := services.NewJWTService()
+		require.NoError(t, err)
+		return svc
+	}
+
+	t.Run("SignsAndValidates", func(t *testing.T) {
+		svc := newRS256Service(t)
+
+		result, err := svc.GenerateAccessToken(7)
+		require.NoError(t, err)
+		claims, err := svc.ValidateToken(result.Token)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint(7), claims.ID)
+		token, _, err := jwt.NewParser().ParseUnverified(result.Token, &services.CustomClaims{})
+		require.NoError(t, err)
+		assert.Equal(t, "RS256", token.Method.Alg())
+		assert.Equal(t, svc.Keys()[0].ID, token.Header["kid"])
+	})
+
+	t.Run("JWKSVerifiesTokens", func(t *testing.T) {
+		svc := newRS256Service(t)
+		result, err := svc.GenerateAccessToken(7)
+		require.NoError(t, err)
+
+		jwks := svc.JWKS()
+
+		// A third party rebuilds the public key from the set and verifies the token with it alone
+		require.Len(t, jwks.Keys, 1)
+		jwk := jwks.Keys[0]
+		assert.Equal(t, "RSA", jwk.Kty)
+		assert.Equal(t, "sig", jwk.Use)
+		assert.Equal(t, "RS256", jwk.Alg)
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		require.NoError(t, err)
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		require.NoError(t, err)
+		publicKey := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		token, err := jwt.ParseWithClaims(result.Token, &services.CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
+			assert.Equal(t, jwk.Kid, token.Header["kid"])
+			return publicKey, nil
+		}, jwt.WithValidMethods([]string{"RS256"}))
+		require.NoError(t, err)
+		assert.True(t, token.Valid)
+	})
+
+	t.Run("HS256TokenRejected", func(t *testing.T) {
+		t.Setenv("JWT_KEY", secret)
+		hs256, err := services.NewJWTService()
+		require.NoError(t, err)
+		hsToken, err := hs256.GenerateAccessToken(7)
+		require.NoError(t, err)
+		svc := newRS256Service(t)
+
+		claims, err := svc.ValidateToken(hsToken.Token)
+
+		assert.Nil(t, claims)
+		assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+	})
+
+	t.Run("AlgConfusionRejected", func(t *testing.T) {
+		// The classic attack: an HS256 token whose secret is the public key, which anyone can fetch from the JWKS
+		svc := newRS256Service(t)
+		publicPEM, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+		require.NoError(t, err)
+		forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &services.CustomClaims{
+			ID:               1,
+			Scope:            services.TokenScopeAccess,
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		})
+		forged.Header["kid"] = svc.Keys()[0].ID
+		for _, key := range [][]byte{publicPEM, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicPEM})} {
+			signed, err := forged.SignedString(key)
+			require.NoError(t, err)
+
+			claims, err := svc.ValidateToken(signed)
+			assert.Nil(t, claims)
+			assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+
+			claims, err = svc.ValidateTokenIgnoreExpiration(signed)
+			assert.Nil(t, claims)
+			assert.Error(t, err)
+		}
+	})
+
+	t.Run("RS256TokenRejectedByHS256", func(t *testing.T) {
+		rsToken, err := newRS256Service(t).GenerateAccessToken(7)
+		require.NoError(t, err)
+		t.Setenv("JWT_ALGO", "")
+		t.Setenv("JWT_KEY", secret)
+		hs256, err := services.NewJWTService()
+		require.NoError(t, err)
+
+		_, err = hs256.ValidateToken(rsToken.Token)
+
+		assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+		assert.Empty(t, hs256.JWKS().Keys, "HS256 secrets are never published")
+	})
+
+	t.Run("KeySources", func(t *testing.T) {
+		t.Setenv("JWT_ALGO", "rs256")
+		path := filepath.Join(t.TempDir(), "jwt.pem")
+		require.NoError(t, os.WriteFile(path, []byte(pkcs8PEM), 0o600))
+
+		t.Run("PKCS8File", func(t *testing.T) {
+			t.Setenv("JWT_PRIVATE_KEY_FILE", path)
+			svc, err := services.NewJWTService()
+			require.NoError(t, err)
+			assert.Equal(t, newRS256Service(t).Keys(), svc.Keys(), "the key ID only depends on the key")
+		})
+
+		t.Run("EscapedNewlines", func(t *testing.T) {
+			t.Setenv("JWT_PRIVATE_KEY", strings.ReplaceAll(pkcs1PEM, "\n", `\n`))
+			_, err := services.NewJWTService()
+			assert.NoError(t, err)
+		})
+	})
+
+	t.Run("InvalidConfiguration", func(t *testing.T) {
+		smallKey, err := rsa.GenerateKey(rand.Reader, 1024)
+		require.NoError(t, err)
+		smallPEM := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(smallKey)}))
+
+		tests := []struct {
+			name     string
+			algo     string
+			key      string
+			expected error
+		}{
+			{name: "UnknownAlgo", algo: "ES256", key: pkcs1PEM, expected: services.ErrJWTAlgoUnknown},
+			{name: "KeyMissing", algo: "RS256", key: "", expected: services.ErrJWTPrivateKeyMissing},
+			{name: "NotPEM", algo: "RS256", key: "not a key", expected: services.ErrJWTPrivateKeyInvalid},
+			{name: "KeyTooSmall", algo: "RS256", key: smallPEM, expected: services.ErrJWTPrivateKeyInvalid},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Setenv("JWT_ALGO", tt.algo)
+				t.Setenv("JWT_PRIVATE_KEY", tt.key)
+
+				svc, err := services.NewJWTService()
+
+				assert.Nil(t, svc)
+				assert.Equal(t, tt.expected, err)
+			})
+		}
+
+		t.Run("KeyFileUnreadable", func(t *testing.T) {
+			t.Setenv("JWT_ALGO", "RS256")
+			t.Setenv("JWT_PRIVATE_KEY_FILE", filepath.Join(t.TempDir(), "missing.pem"))
+			_, err := services.NewJWTService()
+			assert.Error(t, err)
+		})
+	})
+}
@@ -1,9 +1,9 @@
 package services
 
 import (
-	"bytes"
 	"fmt"
-	"html/template"
+	"net/url"
+	"time"
 
 	"github.com/vfa-khuongdv/golang-cms/internal/models"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
@@ -12,77 +12,116 @@ import (
 )
 
 type MailerService interface {
-	SendMailForgotPassword(user *models.User) error
+	SendMailForgotPassword(data MailData) error
+	SendMailVerification(data MailData) error
+	SendMailPasswordChanged(data MailData) error
+	SendMailNewLogin(data MailData) error
+	SendMailEmailChangeConfirmation(data MailData) error
+	SendMailEmailChangeRequested(data MailData) error
 }
 
-type mailerServiceImpl struct{}
+// MailData is what the emails show about their recipient. Fields a mail does not use may be left empty
+type MailData struct {
+	Email     string    // Address the mail is sent to
+	Name      string    // Name the mail greets
+	Token     string    // Token of the link in reset and verification mails
+	ExpiresAt time.Time // When the token expires; zero if the mail should not say
+	ChangedAt time.Time // When the password was changed, for the password changed notification
+	LoginAt   time.Time // When the login happened, for the new login notification
+	IPAddress string    // Address the login came from
+	UserAgent string    // User agent of the login
+	NewEmail  string    // Address the account is moved to, for the email change notification
+}
+
+// NewMailData returns the MailData of the user with the given token. The token must be the one mailed to the
+// user, not user.Token which only holds its hash
+func NewMailData(user *models.User, token string) MailData {
+	data := MailData{Email: user.Email, Name: user.Name, Token: token}
+	if user.ExpiredAt != nil {
+		data.ExpiresAt = time.Unix(*user.ExpiredAt, 0)
+	}
+	return data
+}
+
+// mailTemplateData is the data the templates are rendered with: the MailData and the link of the mail
+type mailTemplateData struct {
+	MailData
+	URL string
+}
+
+type mailerServiceImpl struct {
+	renderer *mailer.TemplateRenderer
+}
 
 var (
 	newEmailSender = func(config mailer.GomailSenderConfig) mailer.EmailSender {
 		return mailer.NewGomailSender(config)
 	}
-	parseTemplateFile = template.ParseFiles
 )
 
 func NewMailerService() MailerService {
-	return &mailerServiceImpl{}
+	return &mailerServiceImpl{renderer: mailer.NewTemplateRenderer()}
 }
 
-// SendMailForgotPassword sends a password reset email to the user
-// Parameters:
-//   - user: Pointer to models.User containing user information including email and reset token
-//
-// Returns:
-//   - error: Returns nil on success, error on failure
-//
-// The function:
-//  1. Creates SMTP config from environment variables
-//  2. Initializes mail sender
-//  3. Parses email template
-//  4. Executes template with user data
-//  5. Sends password reset email to user
-func (s *mailerServiceImpl) SendMailForgotPassword(user *models.User) error {
-
-	var config = mailer.GomailSenderConfig{
+// SendMailForgotPassword sends a password reset email with a link holding data.Token
+func (s *mailerServiceImpl) SendMailForgotPassword(data MailData) error {
+	// Construct reset password URL by combining frontend URL with user's reset token
+	link := utils.GetEnv("FRONTEND_URL", "") + "/reset-password?token=" + url.QueryEscape(data.Token)
+	return s.send(data, "Reset your password", mailer.TEMPLATE_FORGOT_PASSWORD, link)
+}
+
+// SendMailVerification sends an email address verification link holding data.Token
+func (s *mailerServiceImpl) SendMailVerification(data MailData) error {
+	// The link points straight at the API, which marks the user verified
+	link := utils.GetEnv("APP_URL", "") + "/api/v1/verify-email?token=" + url.QueryEscape(data.Token)
+	return s.send(data, "Verify your email address", mailer.TEMPLATE_VERIFY_EMAIL, link)
+}
+
+// SendMailPasswordChanged tells the user their password was changed at data.ChangedAt,
+// with a link to request a reset in case they did not change it
+func (s *mailerServiceImpl) SendMailPasswordChanged(data MailData) error {
+	link := utils.GetEnv("FRONTEND_URL", "") + "/forgot-password"
+	return s.send(data, "Your password was changed", mailer.TEMPLATE_PASSWORD_CHANGED, link)
+}
+
+// SendMailNewLogin tells the user their account was logged in to from data.IPAddress at data.LoginAt,
+// with a link to request a reset in case it was not them
+func (s *mailerServiceImpl) SendMailNewLogin(data MailData) error {
+	link := utils.GetEnv("FRONTEND_URL", "") + "/forgot-password"
+	return s.send(data, "New sign-in to your account", mailer.TEMPLATE_NEW_LOGIN, link)
+}
+
+// SendMailEmailChangeConfirmation sends data.Email, the new address of the user, a link holding data.Token that confirms the change
+func (s *mailerServiceImpl) SendMailEmailChangeConfirmation(data MailData) error {
+	// Like the verification link, it points straight at the API, which swaps the email
+	link := utils.GetEnv("APP_URL", "") + "/api/v1/confirm-email-change?token=" + url.QueryEscape(data.Token)
+	return s.send(data, "Confirm your new email address", mailer.TEMPLATE_CONFIRM_EMAIL_CHANGE, link)
+}
+
+// SendMailEmailChangeRequested tells the user at their current address that a change to data.NewEmail was requested
+// at data.ChangedAt, with a link to request a reset in case it was not them
+func (s *mailerServiceImpl) SendMailEmailChangeRequested(data MailData) error {
+	link := utils.GetEnv("FRONTEND_URL", "") + "/forgot-password"
+	return s.send(data, "A change of your email address was requested", mailer.TEMPLATE_EMAIL_CHANGE_REQUESTED, link)
+}
+
+// send renders the template with data and link and mails both the HTML and the plain-text version to data.Email,
+// through the SMTP server configured by the environment
+func (s *mailerServiceImpl) send(data MailData, subject string, templateName string, link string) error {
+	sender := newEmailSender(mailer.GomailSenderConfig{
 		Host:     utils.GetEnv("MAIL_HOST", "smtp.gmail.com"),
 		Port:     utils.GetEnvAsInt("MAIL_PORT", 587),
 		Username: utils.GetEnv("MAIL_USERNAME", ""),
 		Password: utils.GetEnv("MAIL_PASSWORD", ""),
 		From:     utils.GetEnv("MAIL_FROM", ""),
-	}
-
-	sender := newEmailSender(mailer.GomailSenderConfig{
-		From:     config.From,
-		Host:     config.Host,
-		Port:     config.Port,
-		Username: config.Username,
-		Password: config.Password,
 	})
 
-	// Parse the email template file
-	tmpl, err := parseTemplateFile("pkg/mailer/templates/forgot_template.html")
+	htmlBody, textBody, err := s.renderer.Render(templateName, mailTemplateData{MailData: data, URL: link})
 	if err != nil {
-		return fmt.Errorf("error parsing template: %w", err)
-	}
-
-	// Construct reset password URL by combining frontend URL with user's reset token
-	url := utils.GetEnv("FRONTEND_URL", "") + "/reset-password?token=" + *user.Token
-
-	// Prepare template data with user's name and reset URL
-	data := map[string]interface{}{
-		"Name": user.Name,
-		"URL":  url,
-	}
-	// Create buffer to store rendered HTML
-	var htmlBody bytes.Buffer
-	// Execute template with data and write to buffer
-	if err := tmpl.Execute(&htmlBody, data); err != nil {
 		return apperror.NewInternalServerError(fmt.Sprintf("error executing template: %+v", err))
 	}
-	// Send password reset email to user
-	if err := sender.Send([]string{user.Email}, "Reset your password", "", htmlBody.String()); err != nil {
+	if err := sender.Send([]string{data.Email}, subject, textBody, htmlBody); err != nil {
 		return apperror.NewInternalServerError(fmt.Sprintf("error sending email: %+v", err))
 	}
 	return nil
-
 }
@@ -2,73 +2,125 @@ package services
 
 import (
 	"errors"
-	"html/template"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/stretchr/testify/require"
 	"github.com/vfa-khuongdv/golang-cms/pkg/mailer"
 )
 
+// fakeEmailSender records the last mail instead of sending it
 type fakeEmailSender struct {
-	sendErr error
+	sendErr  error
+	to       []string
+	subject  string
+	textBody string
+	htmlBody string
 }
 
-func (f *fakeEmailSender) Send(_ []string, _ string, _ string, _ string) error {
+func (f *fakeEmailSender) Send(to []string, subject string, textBody string, htmlBody string) error {
+	f.to, f.subject, f.textBody, f.htmlBody = to, subject, textBody, htmlBody
 	return f.sendErr
 }
 
 func TestMailerService_InternalBranches(t *testing.T) {
 	originalSender := newEmailSender
-	originalParse := parseTemplateFile
 	t.Cleanup(func() {
 		newEmailSender = originalSender
-		parseTemplateFile = originalParse
 	})
-
-	token := "reset-token"
-	user := &models.User{
-		Email: "user@example.com",
-		Name:  "User",
-		Token: &token,
+	useSender := func(sender *fakeEmailSender) {
+		newEmailSender = func(_ mailer.GomailSenderConfig) mailer.EmailSender {
+			return sender
+		}
 	}
 
 	t.Setenv("FRONTEND_URL", "https://example.com")
+	t.Setenv("APP_URL", "https://api.example.com")
+	data := MailData{
+		Email:     "user@example.com",
+		Name:      "User",
+		Token:     "reset-token",
+		ExpiresAt: time.Date(2025, time.March, 5, 14, 30, 0, 0, time.UTC),
+	}
 
-	t.Run("TemplateExecuteError", func(t *testing.T) {
-		newEmailSender = func(_ mailer.GomailSenderConfig) mailer.EmailSender {
-			return &fakeEmailSender{}
-		}
-		parseTemplateFile = func(_ ...string) (*template.Template, error) {
-			return template.Must(template.New("bad").Parse(`{{.Name.Field}}`)), nil
-		}
+	t.Run("ForgotPasswordSendsBothBodies", func(t *testing.T) {
+		sender := &fakeEmailSender{}
+		useSender(sender)
 
-		err := NewMailerService().SendMailForgotPassword(user)
-		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "error executing template")
-	})
+		err := NewMailerService().SendMailForgotPassword(data)
 
-	t.Run("Success", func(t *testing.T) {
-		newEmailSender = func(_ mailer.GomailSenderConfig) mailer.EmailSender {
-			return &fakeEmailSender{}
-		}
-		parseTemplateFile = func(_ ...string) (*template.Template, error) {
-			return template.Must(template.New("ok").Parse(`Hi {{.Name}} - {{.URL}}`)), nil
+		require.NoError(t, err)
+		assert.Equal(t, []string{"user@example.com"}, sender.to)
+		assert.Equal(t, "Reset your password", sender.subject)
+		for _, body := range []string{sender.textBody, sender.htmlBody} {
+			assert.Contains(t, body, "User")
+			assert.Contains(t, body, "https://example.com/reset-password?token=reset-token")
+			assert.Contains(t, body, "March 5, 2025 14:30 UTC")
 		}
+		assert.Contains(t, sender.htmlBody, "<html")
+		assert.NotContains(t, sender.textBody, "<html")
+	})
+
+	t.Run("VerificationLinksToTheAPI", func(t *testing.T) {
+		sender := &fakeEmailSender{}
+		useSender(sender)
 
-		err := NewMailerService().SendMailForgotPassword(user)
-		assert.NoError(t, err)
+		withSpace := data
+		withSpace.Token = "verify token"
+
+		err := NewMailerService().SendMailVerification(withSpace)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Verify your email address", sender.subject)
+		assert.Contains(t, sender.textBody, "https://api.example.com/api/v1/verify-email?token=verify+token")
+		assert.Contains(t, sender.htmlBody, "https://api.example.com/api/v1/verify-email?token=verify&#43;token")
 	})
 
-	t.Run("SendErrorStillWrapped", func(t *testing.T) {
-		newEmailSender = func(_ mailer.GomailSenderConfig) mailer.EmailSender {
-			return &fakeEmailSender{sendErr: errors.New("smtp fail")}
+	t.Run("PasswordChanged", func(t *testing.T) {
+		sender := &fakeEmailSender{}
+		useSender(sender)
+
+		err := NewMailerService().SendMailPasswordChanged(MailData{
+			Email:     "user@example.com",
+			Name:      "User",
+			ChangedAt: time.Date(2025, time.March, 5, 14, 30, 0, 0, time.UTC),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "Your password was changed", sender.subject)
+		for _, body := range []string{sender.textBody, sender.htmlBody} {
+			assert.Contains(t, body, "March 5, 2025 14:30 UTC")
+			assert.Contains(t, body, "https://example.com/forgot-password")
 		}
-		parseTemplateFile = func(_ ...string) (*template.Template, error) {
-			return template.Must(template.New("ok").Parse(`Hi {{.Name}}`)), nil
+	})
+
+	t.Run("NewLogin", func(t *testing.T) {
+		sender := &fakeEmailSender{}
+		useSender(sender)
+
+		err := NewMailerService().SendMailNewLogin(MailData{
+			Email:     "user@example.com",
+			Name:      "User",
+			LoginAt:   time.Date(2025, time.March, 5, 14, 30, 0, 0, time.UTC),
+			IPAddress: "203.0.113.7",
+			UserAgent: "Mozilla/5.0",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "New sign-in to your account", sender.subject)
+		for _, body := range []string{sender.textBody, sender.htmlBody} {
+			assert.Contains(t, body, "March 5, 2025 14:30 UTC")
+			assert.Contains(t, body, "203.0.113.7")
+			assert.Contains(t, body, "Mozilla/5.0")
 		}
+	})
+
+	t.Run("SendErrorIsWrapped", func(t *testing.T) {
+		useSender(&fakeEmailSender{sendErr: errors.New("smtp fail")})
+
+		err := NewMailerService().SendMailVerification(data)
 
-		err := NewMailerService().SendMailForgotPassword(user)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "error sending email")
 	})
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+// DEFAULT_MAINTENANCE_MESSAGE is returned to blocked clients when no message was set
+const DEFAULT_MAINTENANCE_MESSAGE = "The service is under maintenance, please try again later"
+
+// MaintenanceService reads and switches the maintenance mode, kept in the maintenance.* settings
+type MaintenanceService interface {
+	GetStatus(ctx context.Context) dto.MaintenanceStatus
+	SetStatus(ctx context.Context, input *dto.SetMaintenanceInput) (*dto.MaintenanceStatus, error)
+}
+
+type maintenanceServiceImpl struct {
+	settingsService SettingsService
+}
+
+func NewMaintenanceService(settingsService SettingsService) MaintenanceService {
+	return &maintenanceServiceImpl{
+		settingsService: settingsService,
+	}
+}
+
+// GetStatus returns the current maintenance mode. Settings that cannot be read are logged
+// and the maintenance mode is reported as disabled, so a broken setting does not take the API down.
+// An unknown mode is reported as full
+func (service *maintenanceServiceImpl) GetStatus(ctx context.Context) dto.MaintenanceStatus {
+	enabled, err := service.settingsService.GetBool(ctx, constants.SETTING_MAINTENANCE_ENABLED, false)
+	if err != nil {
+		logger.WithContext(ctx).Warnf("Failed to read maintenance flag, assuming disabled: %v", err)
+	}
+	if !enabled {
+		return dto.MaintenanceStatus{Enabled: false}
+	}
+
+	mode, err := service.settingsService.GetString(ctx, constants.SETTING_MAINTENANCE_MODE, constants.MAINTENANCE_MODE_FULL)
+	if err != nil {
+		logger.WithContext(ctx).Warnf("Failed to read maintenance mode: %v", err)
+	}
+	if mode != constants.MAINTENANCE_MODE_READ_ONLY {
+		mode = constants.MAINTENANCE_MODE_FULL
+	}
+	message, err := service.settingsService.GetString(ctx, constants.SETTING_MAINTENANCE_MESSAGE, "")
+	if err != nil {
+		logger.WithContext(ctx).Warnf("Failed to read maintenance message: %v", err)
+	}
+	if message == "" {
+		message = DEFAULT_MAINTENANCE_MESSAGE
+	}
+	return dto.MaintenanceStatus{Enabled: true, Mode: mode, Message: message}
+}
+
+// SetStatus turns the maintenance mode on or off. The flag is written last when enabling,
+// so the mode and message are in place by the time requests are blocked
+func (service *maintenanceServiceImpl) SetStatus(ctx context.Context, input *dto.SetMaintenanceInput) (*dto.MaintenanceStatus, error) {
+	enabled := input.Enabled != nil && *input.Enabled
+	if enabled {
+		mode := input.Mode
+		if mode == "" {
+			mode = constants.MAINTENANCE_MODE_FULL
+		}
+		settings := []struct{ key, value string }{
+			{constants.SETTING_MAINTENANCE_MODE, mode},
+			{constants.SETTING_MAINTENANCE_MESSAGE, input.Message},
+		}
+		for _, setting := range settings {
+			if _, err := service.settingsService.SetSetting(ctx, setting.key, &dto.SetSettingInput{Value: setting.value, Type: constants.SETTING_TYPE_STRING}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if _, err := service.settingsService.SetSetting(ctx, constants.SETTING_MAINTENANCE_ENABLED, &dto.SetSettingInput{Value: strconv.FormatBool(enabled), Type: constants.SETTING_TYPE_BOOL}); err != nil {
+		return nil, err
+	}
+
+	status := service.GetStatus(ctx)
+	logger.WithContext(ctx).Infof("Maintenance mode set to %+v", status)
+	return &status, nil
+}
@@ -0,0 +1,108 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+func TestMaintenanceService_GetStatus(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Disabled", func(t *testing.T) {
+		settings := new(mocks.MockSettingsService)
+		settings.On("GetBool", mock.Anything, constants.SETTING_MAINTENANCE_ENABLED, false).Return(false, nil)
+
+		status := services.NewMaintenanceService(settings).GetStatus(ctx)
+
+		assert.Equal(t, dto.MaintenanceStatus{Enabled: false}, status)
+		settings.AssertNotCalled(t, "GetString", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Enabled", func(t *testing.T) {
+		settings := new(mocks.MockSettingsService)
+		settings.On("GetBool", mock.Anything, constants.SETTING_MAINTENANCE_ENABLED, false).Return(true, nil)
+		settings.On("GetString", mock.Anything, constants.SETTING_MAINTENANCE_MODE, constants.MAINTENANCE_MODE_FULL).Return(constants.MAINTENANCE_MODE_READ_ONLY, nil)
+		settings.On("GetString", mock.Anything, constants.SETTING_MAINTENANCE_MESSAGE, "").Return("Back soon", nil)
+
+		status := services.NewMaintenanceService(settings).GetStatus(ctx)
+
+		assert.Equal(t, dto.MaintenanceStatus{Enabled: true, Mode: constants.MAINTENANCE_MODE_READ_ONLY, Message: "Back soon"}, status)
+	})
+
+	t.Run("UnknownModeAndEmptyMessageFallBack", func(t *testing.T) {
+		settings := new(mocks.MockSettingsService)
+		settings.On("GetBool", mock.Anything, constants.SETTING_MAINTENANCE_ENABLED, false).Return(true, nil)
+		settings.On("GetString", mock.Anything, constants.SETTING_MAINTENANCE_MODE, constants.MAINTENANCE_MODE_FULL).Return("sideways", nil)
+		settings.On("GetString", mock.Anything, constants.SETTING_MAINTENANCE_MESSAGE, "").Return("", nil)
+
+		status := services.NewMaintenanceService(settings).GetStatus(ctx)
+
+		assert.Equal(t, dto.MaintenanceStatus{Enabled: true, Mode: constants.MAINTENANCE_MODE_FULL, Message: services.DEFAULT_MAINTENANCE_MESSAGE}, status)
+	})
+
+	t.Run("UnreadableFlagCountsAsDisabled", func(t *testing.T) {
+		settings := new(mocks.MockSettingsService)
+		settings.On("GetBool", mock.Anything, constants.SETTING_MAINTENANCE_ENABLED, false).Return(false, apperror.NewParseError("Setting maintenance.enabled is not a valid bool"))
+
+		status := services.NewMaintenanceService(settings).GetStatus(ctx)
+
+		assert.False(t, status.Enabled)
+	})
+}
+
+func TestMaintenanceService_SetStatus(t *testing.T) {
+	ctx := context.Background()
+	enabled, disabled := true, false
+
+	t.Run("EnableWritesModeAndMessageBeforeFlag", func(t *testing.T) {
+		settings := new(mocks.MockSettingsService)
+		var order []string
+		record := func(args mock.Arguments) { order = append(order, args.String(1)) }
+		settings.On("SetSetting", mock.Anything, constants.SETTING_MAINTENANCE_MODE, &dto.SetSettingInput{Value: constants.MAINTENANCE_MODE_FULL, Type: constants.SETTING_TYPE_STRING}).Return(nil, nil).Run(record).Once()
+		settings.On("SetSetting", mock.Anything, constants.SETTING_MAINTENANCE_MESSAGE, &dto.SetSettingInput{Value: "Upgrading", Type: constants.SETTING_TYPE_STRING}).Return(nil, nil).Run(record).Once()
+		settings.On("SetSetting", mock.Anything, constants.SETTING_MAINTENANCE_ENABLED, &dto.SetSettingInput{Value: "true", Type: constants.SETTING_TYPE_BOOL}).Return(nil, nil).Run(record).Once()
+		settings.On("GetBool", mock.Anything, constants.SETTING_MAINTENANCE_ENABLED, false).Return(true, nil)
+		settings.On("GetString", mock.Anything, constants.SETTING_MAINTENANCE_MODE, constants.MAINTENANCE_MODE_FULL).Return(constants.MAINTENANCE_MODE_FULL, nil)
+		settings.On("GetString", mock.Anything, constants.SETTING_MAINTENANCE_MESSAGE, "").Return("Upgrading", nil)
+
+		status, err := services.NewMaintenanceService(settings).SetStatus(ctx, &dto.SetMaintenanceInput{Enabled: &enabled, Message: "Upgrading"})
+
+		require.NoError(t, err)
+		assert.Equal(t, &dto.MaintenanceStatus{Enabled: true, Mode: constants.MAINTENANCE_MODE_FULL, Message: "Upgrading"}, status)
+		assert.Equal(t, []string{constants.SETTING_MAINTENANCE_MODE, constants.SETTING_MAINTENANCE_MESSAGE, constants.SETTING_MAINTENANCE_ENABLED}, order)
+		settings.AssertExpectations(t)
+	})
+
+	t.Run("DisableOnlyWritesFlag", func(t *testing.T) {
+		settings := new(mocks.MockSettingsService)
+		settings.On("SetSetting", mock.Anything, constants.SETTING_MAINTENANCE_ENABLED, &dto.SetSettingInput{Value: "false", Type: constants.SETTING_TYPE_BOOL}).Return(nil, nil).Once()
+		settings.On("GetBool", mock.Anything, constants.SETTING_MAINTENANCE_ENABLED, false).Return(false, nil)
+
+		status, err := services.NewMaintenanceService(settings).SetStatus(ctx, &dto.SetMaintenanceInput{Enabled: &disabled, Mode: constants.MAINTENANCE_MODE_READ_ONLY})
+
+		require.NoError(t, err)
+		assert.Equal(t, &dto.MaintenanceStatus{Enabled: false}, status)
+		settings.AssertExpectations(t)
+	})
+
+	t.Run("WriteErrorLeavesFlagUntouched", func(t *testing.T) {
+		settings := new(mocks.MockSettingsService)
+		settings.On("SetSetting", mock.Anything, constants.SETTING_MAINTENANCE_MODE, mock.Anything).Return(nil, errors.New("db down")).Once()
+
+		status, err := services.NewMaintenanceService(settings).SetStatus(ctx, &dto.SetMaintenanceInput{Enabled: &enabled})
+
+		assert.Nil(t, status)
+		assert.Error(t, err)
+		settings.AssertNotCalled(t, "SetSetting", mock.Anything, constants.SETTING_MAINTENANCE_ENABLED, mock.Anything)
+	})
+}
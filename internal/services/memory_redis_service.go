@@ -0,0 +1,246 @@
+package services
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+// DEFAULT_MEMORY_CACHE_MAX_ENTRIES is the capacity used when a non-positive size is given
+const DEFAULT_MEMORY_CACHE_MAX_ENTRIES = 10000
+
+// memoryCacheEvictablePrefixes are the prefixes of the keys holding values reloaded on a miss, which are the only
+// ones evicted. Other keys, such as login lockout and rate limit counters or revoked access tokens, enforce security
+// and are kept until they expire or are deleted, so flooding the cache cannot reset a lockout or un-revoke a token
+var memoryCacheEvictablePrefixes = []string{constants.PROFILE, constants.USER_ROLES, constants.USER, constants.CACHE_REFRESH}
+
+var memoryCacheNow = time.Now
+
+type memoryCacheEntry struct {
+	key       string
+	value     string
+	expiresAt time.Time // zero means the entry never expires
+	evictable bool
+}
+
+// memoryRedisServiceImpl is an in-process RedisService for single-instance or
+// development deployments running without Redis. Entries expire lazily on access.
+// Once maxEntries evictable entries are stored, the least recently used one is evicted.
+// The other entries are never evicted: expired ones are swept instead, once there are more than maxEntries of them.
+type memoryRedisServiceImpl struct {
+	mu         sync.Mutex
+	maxEntries int
+	items      map[string]*list.Element
+	order      *list.List // evictable entries, front is the most recently used one
+	kept       *list.List // entries that are never evicted
+	nextSweep  int        // number of kept entries at which the expired ones are swept
+}
+
+// NewMemoryRedisService returns an in-memory RedisService holding at most maxEntries evictable keys
+func NewMemoryRedisService(maxEntries int) RedisService {
+	if maxEntries <= 0 {
+		maxEntries = DEFAULT_MEMORY_CACHE_MAX_ENTRIES
+	}
+	return &memoryRedisServiceImpl{
+		maxEntries: maxEntries,
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
+		kept:       list.New(),
+		nextSweep:  maxEntries,
+	}
+}
+
+// Set stores the value under key. A ttl of zero keeps the key until it is deleted or, if evictable, evicted
+func (s *memoryRedisServiceImpl) Set(_ context.Context, key string, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.store(key, value, ttl)
+	return nil
+}
+
+// Get returns the value stored under key, or ErrCacheMiss if the key does not exist or has expired
+func (s *memoryRedisServiceImpl) Get(_ context.Context, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.lookup(key)
+	if !ok {
+		return "", ErrCacheMiss
+	}
+	s.listOf(element).MoveToFront(element)
+	return element.Value.(*memoryCacheEntry).value, nil
+}
+
+// Delete removes the key. Deleting a missing key is not an error
+func (s *memoryRedisServiceImpl) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.items[key]; ok {
+		s.removeElement(element)
+	}
+	return nil
+}
+
+// Exists reports whether the key is currently stored and not expired
+func (s *memoryRedisServiceImpl) Exists(_ context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.lookup(key)
+	return ok, nil
+}
+
+// Incr increments the integer stored under key and returns the new value.
+// The ttl is applied when the counter has none, so it expires a fixed time after its first increment
+func (s *memoryRedisServiceImpl) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.lookup(key)
+	if !ok {
+		s.store(key, "1", ttl)
+		return 1, nil
+	}
+
+	entry := element.Value.(*memoryCacheEntry)
+	count, err := strconv.ParseInt(entry.value, 10, 64)
+	if err != nil {
+		return 0, apperror.NewCacheSetError("value is not an integer")
+	}
+	count++
+	entry.value = strconv.FormatInt(count, 10)
+	if entry.expiresAt.IsZero() && ttl > 0 {
+		entry.expiresAt = memoryCacheNow().Add(ttl)
+	}
+	s.listOf(element).MoveToFront(element)
+	return count, nil
+}
+
+// SetNX stores the value under key only if the key does not exist or has expired, and reports whether it was stored
+func (s *memoryRedisServiceImpl) SetNX(_ context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.lookup(key); ok {
+		return false, nil
+	}
+	s.store(key, value, ttl)
+	return true, nil
+}
+
+// SetIfEquals stores the value under key only while guardKey holds guardValue, and reports whether it was stored
+func (s *memoryRedisServiceImpl) SetIfEquals(_ context.Context, guardKey string, guardValue string, key string, value string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	guard, ok := s.lookup(guardKey)
+	if !ok || guard.Value.(*memoryCacheEntry).value != guardValue {
+		return false, nil
+	}
+	s.store(key, value, ttl)
+	return true, nil
+}
+
+// store inserts or replaces the entry for key and evicts the least recently used evictable entries over capacity.
+// The caller must hold s.mu.
+func (s *memoryRedisServiceImpl) store(key string, value string, ttl time.Duration) {
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = memoryCacheNow().Add(ttl)
+	}
+
+	if element, ok := s.items[key]; ok {
+		entry := element.Value.(*memoryCacheEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		s.listOf(element).MoveToFront(element)
+		return
+	}
+
+	entry := &memoryCacheEntry{
+		key:       key,
+		value:     value,
+		expiresAt: expiresAt,
+		evictable: isMemoryCacheEvictable(key),
+	}
+	if !entry.evictable {
+		s.items[key] = s.kept.PushFront(entry)
+		if s.kept.Len() > s.nextSweep {
+			s.sweepKept()
+		}
+		return
+	}
+
+	s.items[key] = s.order.PushFront(entry)
+	for s.order.Len() > s.maxEntries {
+		s.removeElement(s.order.Back())
+	}
+}
+
+// sweepKept removes the expired entries that are never evicted, and sweeps again once their number doubles.
+// The caller must hold s.mu.
+func (s *memoryRedisServiceImpl) sweepKept() {
+	now := memoryCacheNow()
+	for element := s.kept.Front(); element != nil; {
+		next := element.Next()
+		if entry := element.Value.(*memoryCacheEntry); !entry.expiresAt.IsZero() && !now.Before(entry.expiresAt) {
+			s.removeElement(element)
+		}
+		element = next
+	}
+
+	s.nextSweep = max(s.maxEntries, 2*s.kept.Len())
+	if s.kept.Len() > s.maxEntries {
+		logger.Warnf("In-memory cache holds %d unexpired security keys, more than MEMORY_CACHE_MAX_ENTRIES %d; they are not evicted", s.kept.Len(), s.maxEntries)
+	}
+}
+
+// lookup returns the element for key, removing it first if it has expired.
+// The caller must hold s.mu.
+func (s *memoryRedisServiceImpl) lookup(key string) (*list.Element, bool) {
+	element, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*memoryCacheEntry)
+	if !entry.expiresAt.IsZero() && !memoryCacheNow().Before(entry.expiresAt) {
+		s.removeElement(element)
+		return nil, false
+	}
+	return element, true
+}
+
+// listOf returns the list holding the element
+func (s *memoryRedisServiceImpl) listOf(element *list.Element) *list.List {
+	if element.Value.(*memoryCacheEntry).evictable {
+		return s.order
+	}
+	return s.kept
+}
+
+// removeElement drops the element from both the index and its list.
+// The caller must hold s.mu.
+func (s *memoryRedisServiceImpl) removeElement(element *list.Element) {
+	s.listOf(element).Remove(element)
+	delete(s.items, element.Value.(*memoryCacheEntry).key)
+}
+
+// isMemoryCacheEvictable reports whether key holds a value reloaded on a miss, see memoryCacheEvictablePrefixes
+func isMemoryCacheEvictable(key string) bool {
+	for _, prefix := range memoryCacheEvictablePrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRedisService_TTLExpiry(t *testing.T) {
+	originalNow := memoryCacheNow
+	t.Cleanup(func() {
+		memoryCacheNow = originalNow
+	})
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	memoryCacheNow = func() time.Time { return now }
+
+	ctx := context.Background()
+	cache := NewMemoryRedisService(10)
+
+	require.NoError(t, cache.Set(ctx, "short", "value", time.Minute))
+	require.NoError(t, cache.Set(ctx, "forever", "value", 0))
+
+	t.Run("ValueAvailableBeforeExpiry", func(t *testing.T) {
+		now = now.Add(59 * time.Second)
+
+		value, err := cache.Get(ctx, "short")
+		require.NoError(t, err)
+		assert.Equal(t, "value", value)
+	})
+
+	t.Run("ValueExpiresAfterTTL", func(t *testing.T) {
+		now = now.Add(time.Second)
+
+		_, err := cache.Get(ctx, "short")
+		assert.ErrorIs(t, err, ErrCacheMiss)
+
+		exists, err := cache.Exists(ctx, "short")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("ZeroTTLNeverExpires", func(t *testing.T) {
+		now = now.Add(24 * time.Hour)
+
+		value, err := cache.Get(ctx, "forever")
+		require.NoError(t, err)
+		assert.Equal(t, "value", value)
+	})
+}
+
+func TestMemoryRedisService_IncrKeepsFirstTTL(t *testing.T) {
+	originalNow := memoryCacheNow
+	t.Cleanup(func() {
+		memoryCacheNow = originalNow
+	})
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	memoryCacheNow = func() time.Time { return now }
+
+	ctx := context.Background()
+	cache := NewMemoryRedisService(10)
+
+	_, err := cache.Incr(ctx, "counter", time.Minute)
+	require.NoError(t, err)
+
+	now = now.Add(59 * time.Second)
+	count, err := cache.Incr(ctx, "counter", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	now = now.Add(time.Second)
+	count, err = cache.Incr(ctx, "counter", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count, "counter restarts once the first ttl has passed")
+}
+
+func TestMemoryRedisService_IncrExpiresCounterWithoutTTL(t *testing.T) {
+	originalNow := memoryCacheNow
+	t.Cleanup(func() {
+		memoryCacheNow = originalNow
+	})
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	memoryCacheNow = func() time.Time { return now }
+
+	ctx := context.Background()
+	cache := NewMemoryRedisService(10)
+	require.NoError(t, cache.Set(ctx, "counter", "4", 0))
+
+	count, err := cache.Incr(ctx, "counter", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), count)
+
+	now = now.Add(time.Minute)
+	count, err = cache.Incr(ctx, "counter", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count, "the counter got the ttl of the increment")
+}
+
+func TestMemoryRedisService_SweepsExpiredSecurityKeys(t *testing.T) {
+	originalNow := memoryCacheNow
+	t.Cleanup(func() {
+		memoryCacheNow = originalNow
+	})
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	memoryCacheNow = func() time.Time { return now }
+
+	ctx := context.Background()
+	cache := NewMemoryRedisService(2).(*memoryRedisServiceImpl)
+
+	require.NoError(t, cache.Set(ctx, "rate_limit:old:1", "1", time.Minute))
+	require.NoError(t, cache.Set(ctx, "rate_limit:old:2", "1", time.Minute))
+	now = now.Add(time.Minute)
+
+	// Going over maxEntries sweeps the expired keys rather than evicting unexpired ones
+	require.NoError(t, cache.Set(ctx, "rate_limit:new:1", "1", time.Minute))
+	require.NoError(t, cache.Set(ctx, "rate_limit:new:2", "1", time.Minute))
+	require.NoError(t, cache.Set(ctx, "rate_limit:new:3", "1", time.Minute))
+
+	assert.Equal(t, 3, cache.kept.Len())
+	assert.Len(t, cache.items, 3)
+	assert.Equal(t, 6, cache.nextSweep)
+}
@@ -0,0 +1,185 @@
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+)
+
+func TestMemoryRedisService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("SetAndGet", func(t *testing.T) {
+		cache := services.NewMemoryRedisService(10)
+
+		require.NoError(t, cache.Set(ctx, "key", "value", time.Minute))
+
+		value, err := cache.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.Equal(t, "value", value)
+	})
+
+	t.Run("SetOverwritesExistingValue", func(t *testing.T) {
+		cache := services.NewMemoryRedisService(10)
+
+		require.NoError(t, cache.Set(ctx, "key", "old", time.Minute))
+		require.NoError(t, cache.Set(ctx, "key", "new", time.Minute))
+
+		value, err := cache.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.Equal(t, "new", value)
+	})
+
+	t.Run("GetMissingKey", func(t *testing.T) {
+		cache := services.NewMemoryRedisService(10)
+
+		value, err := cache.Get(ctx, "missing")
+		assert.ErrorIs(t, err, services.ErrCacheMiss)
+		assert.Empty(t, value)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		cache := services.NewMemoryRedisService(10)
+		require.NoError(t, cache.Set(ctx, "key", "value", time.Minute))
+
+		require.NoError(t, cache.Delete(ctx, "key"))
+		require.NoError(t, cache.Delete(ctx, "missing"))
+
+		_, err := cache.Get(ctx, "key")
+		assert.ErrorIs(t, err, services.ErrCacheMiss)
+	})
+
+	t.Run("Exists", func(t *testing.T) {
+		cache := services.NewMemoryRedisService(10)
+		require.NoError(t, cache.Set(ctx, "key", "value", 0))
+
+		exists, err := cache.Exists(ctx, "key")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		exists, err = cache.Exists(ctx, "missing")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("EvictsLeastRecentlyUsed", func(t *testing.T) {
+		cache := services.NewMemoryRedisService(2)
+		a, b, c := constants.PROFILE+"a", constants.PROFILE+"b", constants.PROFILE+"c"
+		require.NoError(t, cache.Set(ctx, a, "1", 0))
+		require.NoError(t, cache.Set(ctx, b, "2", 0))
+
+		// Touch a so b becomes the least recently used entry
+		_, err := cache.Get(ctx, a)
+		require.NoError(t, err)
+
+		require.NoError(t, cache.Set(ctx, c, "3", 0))
+
+		_, err = cache.Get(ctx, b)
+		assert.ErrorIs(t, err, services.ErrCacheMiss)
+
+		value, err := cache.Get(ctx, a)
+		require.NoError(t, err)
+		assert.Equal(t, "1", value)
+
+		value, err = cache.Get(ctx, c)
+		require.NoError(t, err)
+		assert.Equal(t, "3", value)
+	})
+
+	t.Run("SecurityKeysAreNotEvicted", func(t *testing.T) {
+		cache := services.NewMemoryRedisService(2)
+		lockout := constants.LOGIN_FAIL + "victim@example.com"
+		revoked := constants.REVOKED_ACCESS_TOKEN + "jti"
+		_, err := cache.Incr(ctx, lockout, time.Minute)
+		require.NoError(t, err)
+		require.NoError(t, cache.Set(ctx, revoked, "1", time.Minute))
+
+		// Failed logins for random emails and cached profiles flood the cache
+		for i := range 10 {
+			_, err := cache.Incr(ctx, fmt.Sprintf("%sflood%d@example.com", constants.LOGIN_FAIL, i), time.Minute)
+			require.NoError(t, err)
+			require.NoError(t, cache.Set(ctx, fmt.Sprintf("%s%d", constants.PROFILE, i), "{}", time.Minute))
+		}
+
+		count, err := cache.Incr(ctx, lockout, time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+		exists, err := cache.Exists(ctx, revoked)
+		require.NoError(t, err)
+		assert.True(t, exists)
+		// The cached profiles are still bounded
+		_, err = cache.Get(ctx, constants.PROFILE+"0")
+		assert.ErrorIs(t, err, services.ErrCacheMiss)
+	})
+
+	t.Run("Incr", func(t *testing.T) {
+		cache := services.NewMemoryRedisService(10)
+
+		for want := int64(1); want <= 3; want++ {
+			count, err := cache.Incr(ctx, "counter", time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, want, count)
+		}
+
+		value, err := cache.Get(ctx, "counter")
+		require.NoError(t, err)
+		assert.Equal(t, "3", value)
+
+		require.NoError(t, cache.Set(ctx, "text", "value", 0))
+		_, err = cache.Incr(ctx, "text", time.Minute)
+		assert.Error(t, err)
+	})
+
+	t.Run("SetNX", func(t *testing.T) {
+		cache := services.NewMemoryRedisService(10)
+
+		stored, err := cache.SetNX(ctx, "lock", "1", time.Minute)
+		require.NoError(t, err)
+		assert.True(t, stored)
+
+		stored, err = cache.SetNX(ctx, "lock", "2", time.Minute)
+		require.NoError(t, err)
+		assert.False(t, stored)
+
+		value, err := cache.Get(ctx, "lock")
+		require.NoError(t, err)
+		assert.Equal(t, "1", value)
+	})
+
+	t.Run("SetIfEquals", func(t *testing.T) {
+		cache := services.NewMemoryRedisService(10)
+		require.NoError(t, cache.Set(ctx, "guard", "mine", time.Minute))
+
+		stored, err := cache.SetIfEquals(ctx, "guard", "theirs", "guarded", "rejected", time.Minute)
+		require.NoError(t, err)
+		assert.False(t, stored)
+
+		stored, err = cache.SetIfEquals(ctx, "missing-guard", "mine", "guarded", "rejected", time.Minute)
+		require.NoError(t, err)
+		assert.False(t, stored)
+		_, err = cache.Get(ctx, "guarded")
+		assert.ErrorIs(t, err, services.ErrCacheMiss)
+
+		stored, err = cache.SetIfEquals(ctx, "guard", "mine", "guarded", "accepted", time.Minute)
+		require.NoError(t, err)
+		assert.True(t, stored)
+		value, err := cache.Get(ctx, "guarded")
+		require.NoError(t, err)
+		assert.Equal(t, "accepted", value)
+	})
+
+	t.Run("NonPositiveSizeUsesDefault", func(t *testing.T) {
+		cache := services.NewMemoryRedisService(0)
+
+		require.NoError(t, cache.Set(ctx, "key", "value", 0))
+		exists, err := cache.Exists(ctx, "key")
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+// NotificationService sends the user informational security emails. Callers only log its errors:
+// the operation being notified has already happened and must not fail because of the email
+type NotificationService interface {
+	NotifyPasswordChanged(ctx context.Context, user *models.User) error
+	NotifyNewLogin(ctx context.Context, user *models.User, ipAddress, userAgent string, at time.Time) error
+	NotifyEmailChangeRequested(ctx context.Context, user *models.User, newEmail string) error
+}
+
+type notificationServiceImpl struct {
+	loginIPRepo   repositories.UserLoginIPRepository
+	mailerService MailerService
+}
+
+func NewNotificationService(loginIPRepo repositories.UserLoginIPRepository, mailerService MailerService) NotificationService {
+	return &notificationServiceImpl{
+		loginIPRepo:   loginIPRepo,
+		mailerService: mailerService,
+	}
+}
+
+// NotifyPasswordChanged tells the user their password has just been changed
+func (service *notificationServiceImpl) NotifyPasswordChanged(ctx context.Context, user *models.User) error {
+	return service.mailerService.SendMailPasswordChanged(MailData{
+		Email:     user.Email,
+		Name:      user.Name,
+		ChangedAt: time.Now(),
+	})
+}
+
+// NotifyEmailChangeRequested tells the user at their current address that a change to newEmail was requested
+func (service *notificationServiceImpl) NotifyEmailChangeRequested(ctx context.Context, user *models.User, newEmail string) error {
+	return service.mailerService.SendMailEmailChangeRequested(MailData{
+		Email:     user.Email,
+		Name:      user.Name,
+		ChangedAt: time.Now(),
+		NewEmail:  newEmail,
+	})
+}
+
+// NotifyNewLogin remembers ipAddress as a known address of the user and, if the user has logged in before
+// but never from ipAddress, tells them about the login. The first login of a user is not notified
+func (service *notificationServiceImpl) NotifyNewLogin(ctx context.Context, user *models.User, ipAddress, userAgent string, at time.Time) error {
+	known, err := service.loginIPRepo.Exists(ctx, user.ID, ipAddress)
+	if err != nil {
+		return err
+	}
+	var previous int64
+	if !known {
+		if previous, err = service.loginIPRepo.CountByUserID(ctx, user.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := service.loginIPRepo.Upsert(ctx, &models.UserLoginIP{UserID: user.ID, IpAddress: ipAddress, FirstSeenAt: at, LastSeenAt: at}); err != nil {
+		return err
+	}
+	if known || previous == 0 {
+		return nil
+	}
+
+	logger.WithContext(ctx).Infof("Login from new IP address %s for user ID %d", ipAddress, user.ID)
+	return service.mailerService.SendMailNewLogin(MailData{
+		Email:     user.Email,
+		Name:      user.Name,
+		LoginAt:   at,
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+	})
+}
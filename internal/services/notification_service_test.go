@@ -0,0 +1,123 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+func TestNotificationService_NotifyPasswordChanged(t *testing.T) {
+	ctx := context.Background()
+	user := &models.User{ID: 1, Email: "user@example.com", Name: "User"}
+
+	t.Run("Success", func(t *testing.T) {
+		mailer := new(mocks.MockMailerService)
+		mailer.On("SendMailPasswordChanged", mock.MatchedBy(func(data services.MailData) bool {
+			return data.Email == user.Email && data.Name == user.Name && time.Since(data.ChangedAt) < time.Minute
+		})).Return(nil).Once()
+
+		err := services.NewNotificationService(new(mocks.MockUserLoginIPRepository), mailer).NotifyPasswordChanged(ctx, user)
+
+		assert.NoError(t, err)
+		mailer.AssertExpectations(t)
+	})
+
+	t.Run("MailError", func(t *testing.T) {
+		mailer := new(mocks.MockMailerService)
+		mailer.On("SendMailPasswordChanged", mock.Anything).Return(errors.New("smtp down")).Once()
+
+		err := services.NewNotificationService(new(mocks.MockUserLoginIPRepository), mailer).NotifyPasswordChanged(ctx, user)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestNotificationService_NotifyNewLogin(t *testing.T) {
+	ctx := context.Background()
+	user := &models.User{ID: 1, Email: "user@example.com", Name: "User"}
+	at := time.Date(2025, time.March, 5, 14, 30, 0, 0, time.UTC)
+	ip := "203.0.113.7"
+	setup := func() (*mocks.MockUserLoginIPRepository, *mocks.MockMailerService, services.NotificationService) {
+		repo := new(mocks.MockUserLoginIPRepository)
+		mailer := new(mocks.MockMailerService)
+		return repo, mailer, services.NewNotificationService(repo, mailer)
+	}
+	upserted := mock.MatchedBy(func(loginIP *models.UserLoginIP) bool {
+		return loginIP.UserID == user.ID && loginIP.IpAddress == ip && loginIP.LastSeenAt.Equal(at)
+	})
+
+	t.Run("NewIP", func(t *testing.T) {
+		repo, mailer, service := setup()
+		repo.On("Exists", ctx, user.ID, ip).Return(false, nil).Once()
+		repo.On("CountByUserID", ctx, user.ID).Return(int64(2), nil).Once()
+		repo.On("Upsert", ctx, upserted).Return(nil).Once()
+		mailer.On("SendMailNewLogin", services.MailData{
+			Email: user.Email, Name: user.Name, LoginAt: at, IPAddress: ip, UserAgent: "Mozilla/5.0",
+		}).Return(nil).Once()
+
+		err := service.NotifyNewLogin(ctx, user, ip, "Mozilla/5.0", at)
+
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+		mailer.AssertExpectations(t)
+	})
+
+	t.Run("KnownIPIsOnlyTouched", func(t *testing.T) {
+		repo, mailer, service := setup()
+		repo.On("Exists", ctx, user.ID, ip).Return(true, nil).Once()
+		repo.On("Upsert", ctx, upserted).Return(nil).Once()
+
+		err := service.NotifyNewLogin(ctx, user, ip, "Mozilla/5.0", at)
+
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+		mailer.AssertNotCalled(t, "SendMailNewLogin", mock.Anything)
+	})
+
+	t.Run("FirstLoginIsNotNotified", func(t *testing.T) {
+		repo, mailer, service := setup()
+		repo.On("Exists", ctx, user.ID, ip).Return(false, nil).Once()
+		repo.On("CountByUserID", ctx, user.ID).Return(int64(0), nil).Once()
+		repo.On("Upsert", ctx, upserted).Return(nil).Once()
+
+		err := service.NotifyNewLogin(ctx, user, ip, "Mozilla/5.0", at)
+
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+		mailer.AssertNotCalled(t, "SendMailNewLogin", mock.Anything)
+	})
+
+	t.Run("RepositoryErrors", func(t *testing.T) {
+		repo, mailer, service := setup()
+		repo.On("Exists", ctx, user.ID, ip).Return(false, errors.New("db down")).Once()
+		assert.Error(t, service.NotifyNewLogin(ctx, user, ip, "", at))
+
+		repo.On("Exists", ctx, user.ID, ip).Return(false, nil).Once()
+		repo.On("CountByUserID", ctx, user.ID).Return(int64(0), errors.New("db down")).Once()
+		assert.Error(t, service.NotifyNewLogin(ctx, user, ip, "", at))
+
+		repo.On("Exists", ctx, user.ID, ip).Return(true, nil).Once()
+		repo.On("Upsert", ctx, upserted).Return(errors.New("db down")).Once()
+		assert.Error(t, service.NotifyNewLogin(ctx, user, ip, "", at))
+
+		repo.AssertExpectations(t)
+		mailer.AssertNotCalled(t, "SendMailNewLogin", mock.Anything)
+	})
+
+	t.Run("MailError", func(t *testing.T) {
+		repo, mailer, service := setup()
+		repo.On("Exists", ctx, user.ID, ip).Return(false, nil).Once()
+		repo.On("CountByUserID", ctx, user.ID).Return(int64(1), nil).Once()
+		repo.On("Upsert", ctx, upserted).Return(nil).Once()
+		mailer.On("SendMailNewLogin", mock.Anything).Return(errors.New("smtp down")).Once()
+
+		assert.Error(t, service.NotifyNewLogin(ctx, user, ip, "", at))
+	})
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+type PreferencesService interface {
+	GetPreferences(ctx context.Context, userID uint) (*models.UserPreference, error)
+	UpdatePreferences(ctx context.Context, userID uint, input *dto.UpdatePreferencesInput) (*models.UserPreference, error)
+}
+
+type preferencesServiceImpl struct {
+	repo         repositories.UserPreferenceRepository
+	redisService RedisService
+}
+
+func NewPreferencesService(repo repositories.UserPreferenceRepository, redisService RedisService) PreferencesService {
+	return &preferencesServiceImpl{
+		repo:         repo,
+		redisService: redisService,
+	}
+}
+
+// GetPreferences returns the preferences of the user, saving the defaults on the first read
+func (service *preferencesServiceImpl) GetPreferences(ctx context.Context, userID uint) (*models.UserPreference, error) {
+	preference, err := service.repo.GetByUserID(ctx, userID)
+	if err == nil {
+		return preference, nil
+	}
+	if appErr, ok := apperror.ToAppError(err); !ok || appErr.Code != apperror.ErrNotFound {
+		return nil, apperror.NewDBQueryError("Failed to get preferences")
+	}
+
+	preference = defaultPreferences(userID)
+	preference.UpdatedAt = time.Now()
+	if err := service.repo.CreateIfMissing(ctx, preference); err != nil {
+		return nil, apperror.NewDBInsertError("Failed to create preferences")
+	}
+	// A concurrent request may have saved other preferences first, so the stored ones are returned
+	preference, err = service.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, apperror.NewDBQueryError("Failed to get preferences")
+	}
+
+	logger.WithContext(ctx).Infof("Created default preferences for user ID %d", userID)
+	return preference, nil
+}
+
+// UpdatePreferences replaces the preferences of the user. The input is validated when bound
+func (service *preferencesServiceImpl) UpdatePreferences(ctx context.Context, userID uint, input *dto.UpdatePreferencesInput) (*models.UserPreference, error) {
+	preference := &models.UserPreference{
+		UserID:             userID,
+		Timezone:           input.Timezone,
+		Locale:             input.Locale,
+		EmailNotifications: *input.EmailNotifications,
+		Theme:              input.Theme,
+		UpdatedAt:          time.Now(),
+	}
+	if err := service.repo.Upsert(ctx, preference); err != nil {
+		return nil, apperror.NewDBUpdateError("Failed to update preferences")
+	}
+
+	// Profiles embed the preferences
+	if err := CacheInvalidate(ctx, service.redisService, profileCacheKey(userID)); err != nil {
+		logger.WithContext(ctx).Warnf("Failed to invalidate cached profile for user ID %d: %v", userID, err)
+	}
+
+	logger.WithContext(ctx).Infof("Updated preferences for user ID %d", userID)
+	return preference, nil
+}
+
+// defaultPreferences returns the preferences of a user who has not picked their own
+func defaultPreferences(userID uint) *models.UserPreference {
+	return &models.UserPreference{
+		UserID:             userID,
+		Timezone:           constants.DEFAULT_TIMEZONE,
+		Locale:             constants.DEFAULT_LOCALE,
+		EmailNotifications: constants.DEFAULT_EMAIL_NOTIFICATIONS,
+		Theme:              constants.DEFAULT_THEME,
+	}
+}
+
+// withDefaultPreferences gives a profile without saved preferences the defaults, so profiles always carry them
+func withDefaultPreferences(user *models.User) *models.User {
+	if user.Preferences == nil {
+		user.Preferences = defaultPreferences(user.ID)
+	}
+	return user
+}
@@ -0,0 +1,124 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+func TestPreferencesService_GetPreferences(t *testing.T) {
+	ctx := context.Background()
+	notFound := apperror.New(apperror.ErrNotFound, 1001, "Preferences not found")
+
+	t.Run("Stored", func(t *testing.T) {
+		repo := new(mocks.MockUserPreferenceRepository)
+		stored := &models.UserPreference{UserID: 1, Timezone: "Asia/Tokyo", Locale: "vi", Theme: "dark"}
+		repo.On("GetByUserID", mock.Anything, uint(1)).Return(stored, nil).Once()
+
+		preference, err := services.NewPreferencesService(repo, new(mocks.MockRedisService)).GetPreferences(ctx, 1)
+
+		require.NoError(t, err)
+		assert.Equal(t, stored, preference)
+		repo.AssertNotCalled(t, "CreateIfMissing", mock.Anything, mock.Anything)
+	})
+
+	t.Run("CreatesDefaultsOnFirstRead", func(t *testing.T) {
+		repo := new(mocks.MockUserPreferenceRepository)
+		repo.On("GetByUserID", mock.Anything, uint(1)).Return(nil, notFound).Once()
+		var created *models.UserPreference
+		repo.On("CreateIfMissing", mock.Anything, mock.MatchedBy(func(preference *models.UserPreference) bool {
+			created = preference
+			return true
+		})).Return(nil).Once()
+		// The row read back is returned, in case a concurrent request saved it first
+		stored := &models.UserPreference{UserID: 1, Timezone: "UTC", Locale: "en", EmailNotifications: true, Theme: "system"}
+		repo.On("GetByUserID", mock.Anything, uint(1)).Return(stored, nil).Once()
+
+		preference, err := services.NewPreferencesService(repo, new(mocks.MockRedisService)).GetPreferences(ctx, 1)
+
+		require.NoError(t, err)
+		assert.Equal(t, stored, preference)
+		require.NotNil(t, created)
+		assert.Equal(t, uint(1), created.UserID)
+		assert.Equal(t, "UTC", created.Timezone)
+		assert.Equal(t, "en", created.Locale)
+		assert.True(t, created.EmailNotifications)
+		assert.Equal(t, "system", created.Theme)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("CreateError", func(t *testing.T) {
+		repo := new(mocks.MockUserPreferenceRepository)
+		repo.On("GetByUserID", mock.Anything, uint(1)).Return(nil, notFound).Once()
+		repo.On("CreateIfMissing", mock.Anything, mock.Anything).Return(assert.AnError).Once()
+
+		_, err := services.NewPreferencesService(repo, new(mocks.MockRedisService)).GetPreferences(ctx, 1)
+
+		assertAppErrorCode(t, err, apperror.ErrDBInsert)
+	})
+
+	t.Run("QueryError", func(t *testing.T) {
+		repo := new(mocks.MockUserPreferenceRepository)
+		repo.On("GetByUserID", mock.Anything, uint(1)).Return(nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to fetch preferences", assert.AnError)).Once()
+
+		_, err := services.NewPreferencesService(repo, new(mocks.MockRedisService)).GetPreferences(ctx, 1)
+
+		assertAppErrorCode(t, err, apperror.ErrDBQuery)
+		repo.AssertNotCalled(t, "CreateIfMissing", mock.Anything, mock.Anything)
+	})
+}
+
+func TestPreferencesService_UpdatePreferences(t *testing.T) {
+	ctx := context.Background()
+	notifications := false
+	input := &dto.UpdatePreferencesInput{Timezone: "Asia/Ho_Chi_Minh", Locale: "vi", EmailNotifications: &notifications, Theme: "dark"}
+
+	t.Run("SavesAndInvalidatesProfile", func(t *testing.T) {
+		repo := new(mocks.MockUserPreferenceRepository)
+		redis := new(mocks.MockRedisService)
+		repo.On("Upsert", mock.Anything, mock.MatchedBy(func(preference *models.UserPreference) bool {
+			return preference.UserID == 1 && preference.Timezone == "Asia/Ho_Chi_Minh" && preference.Locale == "vi" &&
+				!preference.EmailNotifications && preference.Theme == "dark" && !preference.UpdatedAt.IsZero()
+		})).Return(nil).Once()
+		redis.On("Delete", mock.Anything, "profile:v2:1").Return(nil).Once()
+		redis.On("Delete", mock.Anything, "cache_refresh:profile:v2:1").Return(nil).Once()
+
+		preference, err := services.NewPreferencesService(repo, redis).UpdatePreferences(ctx, 1, input)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Asia/Ho_Chi_Minh", preference.Timezone)
+		repo.AssertExpectations(t)
+		redis.AssertExpectations(t)
+	})
+
+	t.Run("CacheErrorIsOnlyLogged", func(t *testing.T) {
+		repo := new(mocks.MockUserPreferenceRepository)
+		redis := new(mocks.MockRedisService)
+		repo.On("Upsert", mock.Anything, mock.Anything).Return(nil).Once()
+		redis.On("Delete", mock.Anything, "profile:v2:1").Return(assert.AnError).Once()
+		redis.On("Delete", mock.Anything, "cache_refresh:profile:v2:1").Return(nil).Once()
+
+		_, err := services.NewPreferencesService(repo, redis).UpdatePreferences(ctx, 1, input)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("SaveError", func(t *testing.T) {
+		repo := new(mocks.MockUserPreferenceRepository)
+		redis := new(mocks.MockRedisService)
+		repo.On("Upsert", mock.Anything, mock.Anything).Return(assert.AnError).Once()
+
+		_, err := services.NewPreferencesService(repo, redis).UpdatePreferences(ctx, 1, input)
+
+		assertAppErrorCode(t, err, apperror.ErrDBUpdate)
+		redis.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+// ErrCacheMiss is returned by RedisService.Get when the key does not exist or has expired
+var ErrCacheMiss = errors.New("cache miss")
+
+// RedisService defines the key-value cache operations used by the application
+type RedisService interface {
+	Set(ctx context.Context, key string, value string, ttl time.Duration) error
+	Get(ctx context.Context, key string) (string, error)
+	Delete(ctx context.Context, key string) error
+	Exists(ctx context.Context, key string) (bool, error)
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error)
+	SetIfEquals(ctx context.Context, guardKey string, guardValue string, key string, value string, ttl time.Duration) (bool, error)
+}
+
+// redisServiceImpl implements RedisService on top of a Redis server
+type redisServiceImpl struct {
+	client *redis.Client
+}
+
+// NewRedisService returns a RedisService backed by the given Redis client
+func NewRedisService(client *redis.Client) RedisService {
+	return &redisServiceImpl{
+		client: client,
+	}
+}
+
+// Set stores the value under key. A ttl of zero keeps the key until it is deleted
+func (s *redisServiceImpl) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	if err := s.client.Set(ctx, key, value, ttl).Err(); err != nil {
+		return apperror.NewCacheSetError(err.Error())
+	}
+	return nil
+}
+
+// Get returns the value stored under key, or ErrCacheMiss if the key does not exist
+func (s *redisServiceImpl) Get(ctx context.Context, key string) (string, error) {
+	value, err := s.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrCacheMiss
+	}
+	if err != nil {
+		return "", apperror.NewCacheGetError(err.Error())
+	}
+	return value, nil
+}
+
+// Delete removes the key. Deleting a missing key is not an error
+func (s *redisServiceImpl) Delete(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, key).Err(); err != nil {
+		return apperror.NewCacheDeleteError(err.Error())
+	}
+	return nil
+}
+
+// Exists reports whether the key is currently stored
+func (s *redisServiceImpl) Exists(ctx context.Context, key string) (bool, error) {
+	count, err := s.client.Exists(ctx, key).Result()
+	if err != nil {
+		return false, apperror.NewCacheExistsError(err.Error())
+	}
+	return count > 0, nil
+}
+
+// Incr increments the integer stored under key and returns the new value.
+// The ttl is applied when the counter has none, so it expires a fixed time after its first increment.
+// INCR and EXPIRE NX run in one MULTI transaction, so a counter can never be left without an expiry
+func (s *redisServiceImpl) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	var incr *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, key)
+		if ttl > 0 {
+			pipe.ExpireNX(ctx, key, ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, apperror.NewCacheSetError(err.Error())
+	}
+	return incr.Val(), nil
+}
+
+// SetNX stores the value under key only if the key does not exist, and reports whether it was stored
+func (s *redisServiceImpl) SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	stored, err := s.client.SetNX(ctx, key, value, ttl).Result()
+	if err != nil {
+		return false, apperror.NewCacheSetError(err.Error())
+	}
+	return stored, nil
+}
+
+// setIfEqualsScript sets KEYS[2] to ARGV[2] for ARGV[3] milliseconds, or without expiry when it is 0,
+// only if KEYS[1] holds ARGV[1]
+var setIfEqualsScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+if ARGV[3] == "0" then
+	redis.call("SET", KEYS[2], ARGV[2])
+else
+	redis.call("SET", KEYS[2], ARGV[2], "PX", ARGV[3])
+end
+return 1
+`)
+
+// SetIfEquals stores the value under key only while guardKey holds guardValue, and reports whether it was stored.
+// The check and the write run in one Lua script, so a change of guardKey cannot land between them
+func (s *redisServiceImpl) SetIfEquals(ctx context.Context, guardKey string, guardValue string, key string, value string, ttl time.Duration) (bool, error) {
+	stored, err := setIfEqualsScript.Run(ctx, s.client, []string{guardKey, key}, guardValue, value, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, apperror.NewCacheSetError(err.Error())
+	}
+	return stored == 1, nil
+}
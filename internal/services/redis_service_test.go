@@ -0,0 +1,159 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+func TestRedisService(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+	cache := services.NewRedisService(client)
+
+	t.Run("SetAndGet", func(t *testing.T) {
+		require.NoError(t, cache.Set(ctx, "key", "value", time.Minute))
+
+		value, err := cache.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.Equal(t, "value", value)
+		assert.Equal(t, time.Minute, server.TTL("key"))
+	})
+
+	t.Run("GetMissingKey", func(t *testing.T) {
+		_, err := cache.Get(ctx, "missing")
+		assert.ErrorIs(t, err, services.ErrCacheMiss)
+	})
+
+	t.Run("GetExpiredKey", func(t *testing.T) {
+		require.NoError(t, cache.Set(ctx, "expiring", "value", time.Second))
+		server.FastForward(2 * time.Second)
+
+		_, err := cache.Get(ctx, "expiring")
+		assert.ErrorIs(t, err, services.ErrCacheMiss)
+	})
+
+	t.Run("DeleteAndExists", func(t *testing.T) {
+		require.NoError(t, cache.Set(ctx, "key", "value", 0))
+
+		exists, err := cache.Exists(ctx, "key")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		require.NoError(t, cache.Delete(ctx, "key"))
+
+		exists, err = cache.Exists(ctx, "key")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("Incr", func(t *testing.T) {
+		count, err := cache.Incr(ctx, "counter", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+		assert.Equal(t, time.Minute, server.TTL("counter"))
+
+		server.FastForward(30 * time.Second)
+		count, err = cache.Incr(ctx, "counter", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+		assert.Equal(t, 30*time.Second, server.TTL("counter"), "ttl is only set on the first increment")
+
+		require.NoError(t, cache.Set(ctx, "text", "value", 0))
+		_, err = cache.Incr(ctx, "text", time.Minute)
+		assertAppErrorCode(t, err, apperror.ErrCacheSet)
+	})
+
+	t.Run("IncrExpiresCounterLeftWithoutTTL", func(t *testing.T) {
+		// As left by an INCR whose EXPIRE never ran
+		require.NoError(t, cache.Set(ctx, "stuck", "4", 0))
+
+		count, err := cache.Incr(ctx, "stuck", time.Minute)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), count)
+		assert.Equal(t, time.Minute, server.TTL("stuck"))
+	})
+
+	t.Run("SetNX", func(t *testing.T) {
+		stored, err := cache.SetNX(ctx, "lock", "1", time.Minute)
+		require.NoError(t, err)
+		assert.True(t, stored)
+		assert.Equal(t, time.Minute, server.TTL("lock"))
+
+		stored, err = cache.SetNX(ctx, "lock", "2", time.Minute)
+		require.NoError(t, err)
+		assert.False(t, stored)
+		value, _ := cache.Get(ctx, "lock")
+		assert.Equal(t, "1", value)
+	})
+
+	t.Run("SetIfEquals", func(t *testing.T) {
+		require.NoError(t, cache.Set(ctx, "guard", "mine", time.Minute))
+
+		stored, err := cache.SetIfEquals(ctx, "guard", "theirs", "guarded", "rejected", time.Minute)
+		require.NoError(t, err)
+		assert.False(t, stored)
+		assert.False(t, server.Exists("guarded"))
+
+		stored, err = cache.SetIfEquals(ctx, "guard", "mine", "guarded", "accepted", time.Minute)
+		require.NoError(t, err)
+		assert.True(t, stored)
+		value, _ := cache.Get(ctx, "guarded")
+		assert.Equal(t, "accepted", value)
+		assert.Equal(t, time.Minute, server.TTL("guarded"))
+
+		// A ttl of zero keeps the key until it is deleted
+		stored, err = cache.SetIfEquals(ctx, "guard", "mine", "guarded", "kept", 0)
+		require.NoError(t, err)
+		assert.True(t, stored)
+		assert.Zero(t, server.TTL("guarded"))
+
+		stored, err = cache.SetIfEquals(ctx, "missing-guard", "mine", "guarded", "rejected", time.Minute)
+		require.NoError(t, err)
+		assert.False(t, stored)
+	})
+
+	t.Run("ServerUnavailable", func(t *testing.T) {
+		broken := services.NewRedisService(redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1}))
+
+		err := broken.Set(ctx, "key", "value", time.Minute)
+		assertAppErrorCode(t, err, apperror.ErrCacheSet)
+
+		_, err = broken.Get(ctx, "key")
+		assertAppErrorCode(t, err, apperror.ErrCacheGet)
+
+		err = broken.Delete(ctx, "key")
+		assertAppErrorCode(t, err, apperror.ErrCacheDelete)
+
+		_, err = broken.Exists(ctx, "key")
+		assertAppErrorCode(t, err, apperror.ErrCacheExists)
+
+		_, err = broken.Incr(ctx, "key", time.Minute)
+		assertAppErrorCode(t, err, apperror.ErrCacheSet)
+
+		_, err = broken.SetNX(ctx, "key", "value", time.Minute)
+		assertAppErrorCode(t, err, apperror.ErrCacheSet)
+
+		_, err = broken.SetIfEquals(ctx, "guard", "token", "key", "value", time.Minute)
+		assertAppErrorCode(t, err, apperror.ErrCacheSet)
+	})
+}
+
+func assertAppErrorCode(t *testing.T, err error, code int) {
+	t.Helper()
+	appErr, ok := apperror.ToAppError(err)
+	require.True(t, ok, "expected AppError, got %v", err)
+	assert.Equal(t, code, appErr.Code)
+}
@@ -2,7 +2,10 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/vfa-khuongdv/golang-cms/internal/models"
 	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
@@ -13,27 +16,79 @@ import (
 )
 
 type RefreshTokenService interface {
-	Create(ctx context.Context, user *models.User, ipAddress string) (*dto.JwtResult, error)
-	Update(ctx context.Context, token string, ipAddress string) (*RefreshTokenResult, error)
+	Create(ctx context.Context, user *models.User, ipAddress, userAgent, fingerprint string) (*dto.JwtResult, error)
+	Update(ctx context.Context, token string, ipAddress, userAgent, fingerprint string) (*RefreshTokenResult, error)
+	Delete(ctx context.Context, userID uint, token string) error
+	DeleteAllByUserID(ctx context.Context, userID uint) (int64, error)
+	ListSessions(ctx context.Context, userID uint) ([]dto.SessionResponse, error)
+	RevokeSession(ctx context.Context, userID, sessionID uint) error
+}
+
+// MAX_USER_AGENT_LENGTH is the length of the user_agent column; longer user agents are cut
+const MAX_USER_AGENT_LENGTH = 255
+
+// FingerprintMode is how a refresh token presented with another device fingerprint than the one it is bound to is treated
+type FingerprintMode string
+
+const (
+	FINGERPRINT_MODE_OFF     FingerprintMode = "off"     // Fingerprints are bound but never checked
+	FINGERPRINT_MODE_LOG     FingerprintMode = "log"     // Mismatches are reported, the refresh goes on
+	FINGERPRINT_MODE_ENFORCE FingerprintMode = "enforce" // Mismatches are reported, the token is revoked and the refresh fails
+)
+
+// ParseFingerprintMode parses off, log or enforce; an empty value is off
+func ParseFingerprintMode(value string) (FingerprintMode, error) {
+	switch mode := FingerprintMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "":
+		return FINGERPRINT_MODE_OFF, nil
+	case FINGERPRINT_MODE_OFF, FINGERPRINT_MODE_LOG, FINGERPRINT_MODE_ENFORCE:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown fingerprint mode %q, expected off, log or enforce", value)
+	}
+}
+
+// FingerprintMismatch describes a refresh token presented with another device fingerprint than the one it is bound to.
+// In enforce mode it is the underlying error of the ErrDeviceMismatch error Update returns
+type FingerprintMismatch struct {
+	UserID    uint
+	SessionID uint
+	Mode      FingerprintMode
+}
+
+func (mismatch *FingerprintMismatch) Error() string {
+	return fmt.Sprintf("refresh token %d of user ID %d presented from another device", mismatch.SessionID, mismatch.UserID)
 }
 
 type refreshTokenServiceImpl struct {
-	repo repositories.RefreshTokenRepository
+	repo            repositories.RefreshTokenRepository
+	fingerprintMode FingerprintMode
+	ttl             time.Duration
 }
 
-func NewRefreshTokenService(repo repositories.RefreshTokenRepository) RefreshTokenService {
+// NewRefreshTokenService returns a RefreshTokenService. Tokens are always bound to the device fingerprint they are
+// created with, if any; fingerprintMode decides what happens when another fingerprint presents them.
+// Tokens expire after REFRESH_TOKEN_TTL, 30 days by default
+func NewRefreshTokenService(repo repositories.RefreshTokenRepository, fingerprintMode FingerprintMode) RefreshTokenService {
 	return &refreshTokenServiceImpl{
-		repo: repo,
+		repo:            repo,
+		fingerprintMode: fingerprintMode,
+		ttl:             tokenTTLFromEnv("REFRESH_TOKEN_TTL", DEFAULT_REFRESH_TOKEN_TTL),
 	}
 }
 
-func (service *refreshTokenServiceImpl) Create(ctx context.Context, user *models.User, ipAddress string) (*dto.JwtResult, error) {
+// Create creates a refresh token for the user, bound to the device fingerprint if one is given
+func (service *refreshTokenServiceImpl) Create(ctx context.Context, user *models.User, ipAddress, userAgent, fingerprint string) (*dto.JwtResult, error) {
 	tokenString := utils.GenerateRandomString(60)
-	expiredAt := time.Now().Add(time.Hour * 24 * 30).Unix()
+	now := time.Now()
+	expiredAt := now.Add(service.ttl).Unix()
 	token := models.RefreshToken{
 		RefreshToken: tokenString,
 		IpAddress:    ipAddress,
+		UserAgent:    truncateUserAgent(userAgent),
+		Fingerprint:  hashFingerprint(fingerprint),
 		UsedCount:    0,
+		LastUsedAt:   &now,
 		ExpiredAt:    expiredAt,
 		UserID:       user.ID,
 	}
@@ -55,32 +110,157 @@ func (service *refreshTokenServiceImpl) Create(ctx context.Context, user *models
 type RefreshTokenResult struct {
 	Token  *dto.JwtResult
 	UserId uint
+	// FingerprintMismatch is set when the token was presented from another device in log mode
+	FingerprintMismatch *FingerprintMismatch
 }
 
-func (service *refreshTokenServiceImpl) Update(ctx context.Context, tokenString string, ipAddress string) (*RefreshTokenResult, error) {
+// Update rotates the refresh token. A token revoked or rotated while it is being refreshed is reported as not found. A token bound to a device fingerprint is checked against fingerprint as
+// configured by the fingerprint mode; in enforce mode a mismatch revokes the token and fails with ErrDeviceMismatch.
+// Tokens created before fingerprints were bound get bound to the first fingerprint they are presented with
+func (service *refreshTokenServiceImpl) Update(ctx context.Context, tokenString string, ipAddress, userAgent, fingerprint string) (*RefreshTokenResult, error) {
 	result, err := service.repo.FindByToken(ctx, tokenString)
 	if err != nil {
 		return nil, apperror.NewNotFoundError("Refresh token not found or expired")
 	}
 
+	presented := hashFingerprint(fingerprint)
+	var mismatch *FingerprintMismatch
+	if result.Fingerprint == "" {
+		result.Fingerprint = presented
+	} else if !utils.ConstantTimeCompare(presented, result.Fingerprint) && service.fingerprintMode != FINGERPRINT_MODE_OFF {
+		mismatch = &FingerprintMismatch{UserID: result.UserID, SessionID: result.ID, Mode: service.fingerprintMode}
+		logger.WithContext(ctx).Warnf("Refresh token %d of user ID %d presented from another device (mode %s)", result.ID, result.UserID, service.fingerprintMode)
+		if service.fingerprintMode == FINGERPRINT_MODE_ENFORCE {
+			return nil, service.revokeMismatched(ctx, result, mismatch)
+		}
+	}
+
+	previous := result.RefreshToken
 	newToken := utils.GenerateRandomString(60)
-	expiredAt := time.Now().Add(time.Hour * 24 * 30).Unix()
+	now := time.Now()
+	expiredAt := now.Add(service.ttl).Unix()
 
 	result.RefreshToken = newToken
 	result.ExpiredAt = expiredAt
 	result.IpAddress = ipAddress
+	result.UserAgent = truncateUserAgent(userAgent)
 	result.UsedCount += 1
+	result.LastUsedAt = &now
 
-	if err := service.repo.Update(ctx, result); err != nil {
+	rotated, err := service.repo.Rotate(ctx, result, previous)
+	if err != nil {
 		logger.WithContext(ctx).Errorf("Failed to update refresh token: %v", err)
 		return nil, apperror.NewDBUpdateError("Failed to update refresh token")
 	}
+	if !rotated {
+		logger.WithContext(ctx).Warnf("Refresh token %d of user ID %d was revoked or rotated by a concurrent request", result.ID, result.UserID)
+		return nil, apperror.NewNotFoundError("Refresh token not found or expired")
+	}
 
 	return &RefreshTokenResult{
 		Token: &dto.JwtResult{
 			Token:     newToken,
 			ExpiresAt: expiredAt,
 		},
-		UserId: result.UserID,
+		UserId:              result.UserID,
+		FingerprintMismatch: mismatch,
 	}, nil
 }
+
+// revokeMismatched deletes the token presented from another device and returns the ErrDeviceMismatch error.
+// A failure to delete is only logged: the refresh fails either way
+func (service *refreshTokenServiceImpl) revokeMismatched(ctx context.Context, token *models.RefreshToken, mismatch *FingerprintMismatch) error {
+	if _, err := service.repo.DeleteByID(ctx, token.UserID, token.ID); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to revoke refresh token %d of user ID %d: %v", token.ID, token.UserID, err)
+	}
+	appErr := apperror.NewDeviceMismatchError("Refresh token was issued to another device")
+	appErr.Err = mismatch
+	return appErr
+}
+
+func (service *refreshTokenServiceImpl) Delete(ctx context.Context, userID uint, tokenString string) error {
+	if err := service.repo.DeleteByToken(ctx, userID, tokenString); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to delete refresh token for user ID %d: %v", userID, err)
+		return apperror.NewDBDeleteError("Failed to delete refresh token")
+	}
+	return nil
+}
+
+func (service *refreshTokenServiceImpl) DeleteAllByUserID(ctx context.Context, userID uint) (int64, error) {
+	count, err := service.repo.DeleteAllByUserID(ctx, userID)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to delete refresh tokens for user ID %d: %v", userID, err)
+		return 0, apperror.NewDBDeleteError("Failed to delete refresh tokens")
+	}
+
+	logger.WithContext(ctx).Infof("Deleted %d refresh tokens for user ID %d", count, userID)
+	return count, nil
+}
+
+// ListSessions returns the active sessions of the user, i.e. its unexpired refresh tokens, with the tokens masked
+func (service *refreshTokenServiceImpl) ListSessions(ctx context.Context, userID uint) ([]dto.SessionResponse, error) {
+	tokens, err := service.repo.FindActiveByUserID(ctx, userID)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to list sessions for user ID %d: %v", userID, err)
+		return nil, apperror.NewDBQueryError("Failed to list sessions")
+	}
+
+	sessions := make([]dto.SessionResponse, len(tokens))
+	for i, token := range tokens {
+		sessions[i] = dto.SessionResponse{
+			ID:         token.ID,
+			Token:      maskRefreshToken(token.RefreshToken),
+			IPAddress:  token.IpAddress,
+			UserAgent:  token.UserAgent,
+			CreatedAt:  token.CreatedAt,
+			LastUsedAt: token.LastUsedAt,
+			ExpiresAt:  token.ExpiredAt,
+		}
+	}
+	return sessions, nil
+}
+
+// RevokeSession deletes one session of the user. Sessions of other users are reported as not found
+func (service *refreshTokenServiceImpl) RevokeSession(ctx context.Context, userID, sessionID uint) error {
+	deleted, err := service.repo.DeleteByID(ctx, userID, sessionID)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to revoke session %d for user ID %d: %v", sessionID, userID, err)
+		return apperror.NewDBDeleteError("Failed to revoke session")
+	}
+	if !deleted {
+		return apperror.NewNotFoundError("Session not found")
+	}
+
+	logger.WithContext(ctx).Infof("Revoked session %d for user ID %d", sessionID, userID)
+	return nil
+}
+
+// maskRefreshToken keeps only the last four characters of the token, enough to tell sessions apart
+func maskRefreshToken(token string) string {
+	if len(token) <= 4 {
+		return strings.Repeat("*", len(token))
+	}
+	return strings.Repeat("*", 8) + token[len(token)-4:]
+}
+
+// hashFingerprint returns the SHA-256 hex digest of the client's device fingerprint, so it is stored with a fixed
+// length whatever the client sends. No fingerprint gives an empty string, which means the token is not bound
+func hashFingerprint(fingerprint string) string {
+	fingerprint = strings.TrimSpace(fingerprint)
+	if fingerprint == "" {
+		return ""
+	}
+	return utils.HashToken(fingerprint)
+}
+
+// truncateUserAgent cuts the user agent to MAX_USER_AGENT_LENGTH bytes without splitting a character
+func truncateUserAgent(userAgent string) string {
+	if len(userAgent) <= MAX_USER_AGENT_LENGTH {
+		return userAgent
+	}
+	cut := MAX_USER_AGENT_LENGTH
+	for cut > 0 && !utf8.RuneStart(userAgent[cut]) {
+		cut--
+	}
+	return userAgent[:cut]
+}
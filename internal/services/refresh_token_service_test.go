@@ -2,7 +2,9 @@ package services_test
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
 
 	originErrors "errors"
 
@@ -11,6 +13,9 @@ import (
 	"github.com/stretchr/testify/suite"
 	"github.com/vfa-khuongdv/golang-cms/internal/models"
 	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
 	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
 )
 
@@ -22,7 +27,7 @@ type RefreshTokenServiceTestSuite struct {
 
 func (s *RefreshTokenServiceTestSuite) SetupTest() {
 	s.repo = new(mocks.MockRefreshTokenRepository)
-	s.refreshTokenService = services.NewRefreshTokenService(s.repo)
+	s.refreshTokenService = services.NewRefreshTokenService(s.repo, services.FINGERPRINT_MODE_OFF)
 }
 
 func (s *RefreshTokenServiceTestSuite) TestCreate() {
@@ -31,13 +36,14 @@ func (s *RefreshTokenServiceTestSuite) TestCreate() {
 		Email: "test@example.com",
 	}
 	ipAddress := "127.0.0.1"
+	userAgent := "Mozilla/5.0"
 
 	s.T().Run("Success", func(t *testing.T) {
 		s.repo.On("Create", mock.Anything, mock.MatchedBy(func(token *models.RefreshToken) bool {
-			return token.UserID == user.ID && token.IpAddress == ipAddress
+			return token.UserID == user.ID && token.IpAddress == ipAddress && token.UserAgent == userAgent && token.LastUsedAt != nil
 		})).Return(nil)
 
-		result, err := s.refreshTokenService.Create(context.Background(), user, ipAddress)
+		result, err := s.refreshTokenService.Create(context.Background(), user, ipAddress, userAgent, "")
 
 		assert.NoError(t, err)
 		assert.NotNil(t, result)
@@ -49,13 +55,36 @@ func (s *RefreshTokenServiceTestSuite) TestCreate() {
 
 	s.T().Run("Error", func(t *testing.T) {
 		s.repo = new(mocks.MockRefreshTokenRepository) // reset
-		s.refreshTokenService = services.NewRefreshTokenService(s.repo)
+		s.refreshTokenService = services.NewRefreshTokenService(s.repo, services.FINGERPRINT_MODE_OFF)
 
 		s.repo.On("Create", mock.Anything, mock.Anything).Return(originErrors.New("database error"))
-		_, err := s.refreshTokenService.Create(context.Background(), user, ipAddress)
+		_, err := s.refreshTokenService.Create(context.Background(), user, ipAddress, userAgent, "")
 		assert.Error(t, err)
 		s.repo.AssertExpectations(t)
 	})
+
+	s.T().Run("ExpiresAfterTheConfiguredTTL", func(t *testing.T) {
+		for _, tt := range []struct {
+			name  string
+			value string
+			ttl   time.Duration
+		}{
+			{name: "Configured", value: "2h", ttl: 2 * time.Hour},
+			{name: "Default", value: "", ttl: services.DEFAULT_REFRESH_TOKEN_TTL},
+			{name: "Malformed", value: "30 days", ttl: services.DEFAULT_REFRESH_TOKEN_TTL},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Setenv("REFRESH_TOKEN_TTL", tt.value)
+				repo := new(mocks.MockRefreshTokenRepository)
+				repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+				result, err := services.NewRefreshTokenService(repo, services.FINGERPRINT_MODE_OFF).Create(context.Background(), user, ipAddress, userAgent, "")
+
+				assert.NoError(t, err)
+				assert.InDelta(t, time.Now().Add(tt.ttl).Unix(), result.ExpiresAt, 1)
+			})
+		}
+	})
 }
 
 func (s *RefreshTokenServiceTestSuite) TestUpdate() {
@@ -69,9 +98,11 @@ func (s *RefreshTokenServiceTestSuite) TestUpdate() {
 
 	s.T().Run("Success", func(t *testing.T) {
 		s.repo.On("FindByToken", mock.Anything, "existing_token").Return(originalToken, nil).Once()
-		s.repo.On("Update", mock.Anything, mock.AnythingOfType("*models.RefreshToken")).Return(nil).Once()
+		s.repo.On("Rotate", mock.Anything, mock.MatchedBy(func(token *models.RefreshToken) bool {
+			return token.IpAddress == "127.0.0.2" && token.UserAgent == "curl/8.0" && token.LastUsedAt != nil
+		}), "existing_token").Return(true, nil).Once()
 
-		result, err := s.refreshTokenService.Update(context.Background(), "existing_token", "127.0.0.2")
+		result, err := s.refreshTokenService.Update(context.Background(), "existing_token", "127.0.0.2", "curl/8.0", "")
 
 		assert.NoError(t, err)
 		assert.NotNil(t, result)
@@ -85,7 +116,7 @@ func (s *RefreshTokenServiceTestSuite) TestUpdate() {
 	s.T().Run("TokenNotFound", func(t *testing.T) {
 		s.repo.On("FindByToken", mock.Anything, "missing_token").Return((*models.RefreshToken)(nil), assert.AnError).Once()
 
-		result, err := s.refreshTokenService.Update(context.Background(), "missing_token", "127.0.0.1")
+		result, err := s.refreshTokenService.Update(context.Background(), "missing_token", "127.0.0.1", "curl/8.0", "")
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
@@ -93,11 +124,22 @@ func (s *RefreshTokenServiceTestSuite) TestUpdate() {
 		s.repo.AssertExpectations(t)
 	})
 
+	s.T().Run("RevokedMeanwhile", func(t *testing.T) {
+		s.repo.On("FindByToken", mock.Anything, "existing_token").Return(&models.RefreshToken{ID: 3, RefreshToken: "existing_token", UserID: 1}, nil).Once()
+		s.repo.On("Rotate", mock.Anything, mock.AnythingOfType("*models.RefreshToken"), "existing_token").Return(false, nil).Once()
+
+		result, err := s.refreshTokenService.Update(context.Background(), "existing_token", "127.0.0.1", "curl/8.0", "")
+
+		assert.Nil(t, result)
+		assertAppErrorCode(t, err, apperror.ErrNotFound)
+		s.repo.AssertExpectations(t)
+	})
+
 	s.T().Run("Error", func(t *testing.T) {
-		s.repo.On("FindByToken", mock.Anything, "existing_token").Return(originalToken, nil).Once()
-		s.repo.On("Update", mock.Anything, mock.AnythingOfType("*models.RefreshToken")).Return(originErrors.New("Update item error")).Once()
+		s.repo.On("FindByToken", mock.Anything, "existing_token").Return(&models.RefreshToken{RefreshToken: "existing_token", UserID: 1}, nil).Once()
+		s.repo.On("Rotate", mock.Anything, mock.AnythingOfType("*models.RefreshToken"), "existing_token").Return(false, originErrors.New("Update item error")).Once()
 
-		result, err := s.refreshTokenService.Update(context.Background(), "existing_token", "127.0.0.1")
+		result, err := s.refreshTokenService.Update(context.Background(), "existing_token", "127.0.0.1", "curl/8.0", "")
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
@@ -106,6 +148,231 @@ func (s *RefreshTokenServiceTestSuite) TestUpdate() {
 	})
 }
 
+func (s *RefreshTokenServiceTestSuite) TestDelete() {
+	s.T().Run("Success", func(t *testing.T) {
+		s.repo.On("DeleteByToken", mock.Anything, uint(1), "existing_token").Return(nil).Once()
+
+		err := s.refreshTokenService.Delete(context.Background(), 1, "existing_token")
+
+		assert.NoError(t, err)
+		s.repo.AssertExpectations(t)
+	})
+
+	s.T().Run("Error", func(t *testing.T) {
+		s.repo.On("DeleteByToken", mock.Anything, uint(1), "existing_token").Return(originErrors.New("Delete item error")).Once()
+
+		err := s.refreshTokenService.Delete(context.Background(), 1, "existing_token")
+
+		assert.Error(t, err)
+		s.repo.AssertExpectations(t)
+	})
+}
+
+func (s *RefreshTokenServiceTestSuite) TestDeleteAllByUserID() {
+	s.T().Run("Success", func(t *testing.T) {
+		s.repo.On("DeleteAllByUserID", mock.Anything, uint(1)).Return(int64(2), nil).Once()
+
+		count, err := s.refreshTokenService.DeleteAllByUserID(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+		s.repo.AssertExpectations(t)
+	})
+
+	s.T().Run("Error", func(t *testing.T) {
+		s.repo.On("DeleteAllByUserID", mock.Anything, uint(1)).Return(int64(0), originErrors.New("Delete items error")).Once()
+
+		count, err := s.refreshTokenService.DeleteAllByUserID(context.Background(), 1)
+
+		assert.Error(t, err)
+		assert.Equal(t, int64(0), count)
+		s.repo.AssertExpectations(t)
+	})
+}
+
+func (s *RefreshTokenServiceTestSuite) TestListSessions() {
+	s.T().Run("Success", func(t *testing.T) {
+		lastUsedAt := time.Now()
+		s.repo.On("FindActiveByUserID", mock.Anything, uint(1)).Return([]*models.RefreshToken{
+			{ID: 3, RefreshToken: "abcdefghijklmnopqrstuvwxyz", IpAddress: "10.0.0.1", UserAgent: "Mozilla/5.0", LastUsedAt: &lastUsedAt, ExpiredAt: 1700000000},
+		}, nil).Once()
+
+		sessions, err := s.refreshTokenService.ListSessions(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []dto.SessionResponse{
+			{ID: 3, Token: "********wxyz", IPAddress: "10.0.0.1", UserAgent: "Mozilla/5.0", LastUsedAt: &lastUsedAt, ExpiresAt: 1700000000},
+		}, sessions)
+		s.repo.AssertExpectations(t)
+	})
+
+	s.T().Run("Error", func(t *testing.T) {
+		s.repo.On("FindActiveByUserID", mock.Anything, uint(1)).Return(nil, originErrors.New("db error")).Once()
+
+		sessions, err := s.refreshTokenService.ListSessions(context.Background(), 1)
+
+		assert.Nil(t, sessions)
+		assertAppErrorCode(t, err, apperror.ErrDBQuery)
+	})
+}
+
+func (s *RefreshTokenServiceTestSuite) TestRevokeSession() {
+	s.T().Run("Success", func(t *testing.T) {
+		s.repo.On("DeleteByID", mock.Anything, uint(1), uint(3)).Return(true, nil).Once()
+
+		err := s.refreshTokenService.RevokeSession(context.Background(), 1, 3)
+
+		assert.NoError(t, err)
+		s.repo.AssertExpectations(t)
+	})
+
+	s.T().Run("NotFoundOrOtherUser", func(t *testing.T) {
+		s.repo.On("DeleteByID", mock.Anything, uint(1), uint(4)).Return(false, nil).Once()
+
+		err := s.refreshTokenService.RevokeSession(context.Background(), 1, 4)
+
+		assertAppErrorCode(t, err, apperror.ErrNotFound)
+	})
+
+	s.T().Run("Error", func(t *testing.T) {
+		s.repo.On("DeleteByID", mock.Anything, uint(1), uint(3)).Return(false, originErrors.New("db error")).Once()
+
+		err := s.refreshTokenService.RevokeSession(context.Background(), 1, 3)
+
+		assertAppErrorCode(t, err, apperror.ErrDBDelete)
+	})
+}
+
+func (s *RefreshTokenServiceTestSuite) TestCreateTruncatesUserAgent() {
+	userAgent := strings.Repeat("a", services.MAX_USER_AGENT_LENGTH-1) + "é"
+	s.repo.On("Create", mock.Anything, mock.MatchedBy(func(token *models.RefreshToken) bool {
+		return token.UserAgent == strings.Repeat("a", services.MAX_USER_AGENT_LENGTH-1)
+	})).Return(nil).Once()
+
+	_, err := s.refreshTokenService.Create(context.Background(), &models.User{ID: 1}, "127.0.0.1", userAgent, "")
+
+	s.NoError(err)
+	s.repo.AssertExpectations(s.T())
+}
+
+func (s *RefreshTokenServiceTestSuite) TestCreateBindsFingerprint() {
+	s.repo.On("Create", mock.Anything, mock.MatchedBy(func(token *models.RefreshToken) bool {
+		return token.Fingerprint == utils.HashToken("device-1")
+	})).Return(nil).Once()
+
+	_, err := s.refreshTokenService.Create(context.Background(), &models.User{ID: 1}, "127.0.0.1", "curl/8.0", "device-1")
+
+	s.NoError(err)
+	s.repo.AssertExpectations(s.T())
+}
+
+func (s *RefreshTokenServiceTestSuite) TestUpdateFingerprint() {
+	bound := func() *models.RefreshToken {
+		return &models.RefreshToken{ID: 7, RefreshToken: "bound_token", Fingerprint: utils.HashToken("device-1"), UserID: 1}
+	}
+
+	s.T().Run("BindsUnboundToken", func(t *testing.T) {
+		repo := new(mocks.MockRefreshTokenRepository)
+		service := services.NewRefreshTokenService(repo, services.FINGERPRINT_MODE_ENFORCE)
+		repo.On("FindByToken", mock.Anything, "old_token").Return(&models.RefreshToken{ID: 7, RefreshToken: "old_token", UserID: 1}, nil).Once()
+		repo.On("Rotate", mock.Anything, mock.MatchedBy(func(token *models.RefreshToken) bool {
+			return token.Fingerprint == utils.HashToken("device-1")
+		}), "old_token").Return(true, nil).Once()
+
+		result, err := service.Update(context.Background(), "old_token", "127.0.0.1", "curl/8.0", "device-1")
+
+		assert.NoError(t, err)
+		assert.Nil(t, result.FingerprintMismatch)
+		repo.AssertExpectations(t)
+	})
+
+	s.T().Run("SameDevice", func(t *testing.T) {
+		repo := new(mocks.MockRefreshTokenRepository)
+		service := services.NewRefreshTokenService(repo, services.FINGERPRINT_MODE_ENFORCE)
+		repo.On("FindByToken", mock.Anything, "bound_token").Return(bound(), nil).Once()
+		repo.On("Rotate", mock.Anything, mock.AnythingOfType("*models.RefreshToken"), "bound_token").Return(true, nil).Once()
+
+		result, err := service.Update(context.Background(), "bound_token", "127.0.0.1", "curl/8.0", "device-1")
+
+		assert.NoError(t, err)
+		assert.Nil(t, result.FingerprintMismatch)
+		repo.AssertExpectations(t)
+	})
+
+	s.T().Run("OffIgnoresMismatch", func(t *testing.T) {
+		repo := new(mocks.MockRefreshTokenRepository)
+		service := services.NewRefreshTokenService(repo, services.FINGERPRINT_MODE_OFF)
+		repo.On("FindByToken", mock.Anything, "bound_token").Return(bound(), nil).Once()
+		repo.On("Rotate", mock.Anything, mock.MatchedBy(func(token *models.RefreshToken) bool {
+			return token.Fingerprint == utils.HashToken("device-1")
+		}), "bound_token").Return(true, nil).Once()
+
+		result, err := service.Update(context.Background(), "bound_token", "127.0.0.1", "curl/8.0", "device-2")
+
+		assert.NoError(t, err)
+		assert.Nil(t, result.FingerprintMismatch)
+		repo.AssertExpectations(t)
+	})
+
+	s.T().Run("LogReportsMismatch", func(t *testing.T) {
+		repo := new(mocks.MockRefreshTokenRepository)
+		service := services.NewRefreshTokenService(repo, services.FINGERPRINT_MODE_LOG)
+		repo.On("FindByToken", mock.Anything, "bound_token").Return(bound(), nil).Once()
+		repo.On("Rotate", mock.Anything, mock.AnythingOfType("*models.RefreshToken"), "bound_token").Return(true, nil).Once()
+
+		result, err := service.Update(context.Background(), "bound_token", "127.0.0.1", "curl/8.0", "device-2")
+
+		assert.NoError(t, err)
+		assert.Equal(t, &services.FingerprintMismatch{UserID: 1, SessionID: 7, Mode: services.FINGERPRINT_MODE_LOG}, result.FingerprintMismatch)
+		repo.AssertExpectations(t)
+	})
+
+	s.T().Run("EnforceRevokesToken", func(t *testing.T) {
+		repo := new(mocks.MockRefreshTokenRepository)
+		service := services.NewRefreshTokenService(repo, services.FINGERPRINT_MODE_ENFORCE)
+		repo.On("FindByToken", mock.Anything, "bound_token").Return(bound(), nil).Once()
+		repo.On("DeleteByID", mock.Anything, uint(1), uint(7)).Return(true, nil).Once()
+
+		result, err := service.Update(context.Background(), "bound_token", "127.0.0.1", "curl/8.0", "device-2")
+
+		assert.Nil(t, result)
+		assertAppErrorCode(t, err, apperror.ErrDeviceMismatch)
+		var mismatch *services.FingerprintMismatch
+		assert.True(t, originErrors.As(err, &mismatch))
+		assert.Equal(t, uint(7), mismatch.SessionID)
+		repo.AssertExpectations(t)
+		repo.AssertNotCalled(t, "Rotate", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	s.T().Run("EnforceWithoutFingerprint", func(t *testing.T) {
+		repo := new(mocks.MockRefreshTokenRepository)
+		service := services.NewRefreshTokenService(repo, services.FINGERPRINT_MODE_ENFORCE)
+		repo.On("FindByToken", mock.Anything, "bound_token").Return(bound(), nil).Once()
+		repo.On("DeleteByID", mock.Anything, uint(1), uint(7)).Return(false, originErrors.New("db error")).Once()
+
+		_, err := service.Update(context.Background(), "bound_token", "127.0.0.1", "curl/8.0", "")
+
+		assertAppErrorCode(t, err, apperror.ErrDeviceMismatch)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestParseFingerprintMode(t *testing.T) {
+	for value, expected := range map[string]services.FingerprintMode{
+		"":          services.FINGERPRINT_MODE_OFF,
+		"off":       services.FINGERPRINT_MODE_OFF,
+		"log":       services.FINGERPRINT_MODE_LOG,
+		" Enforce ": services.FINGERPRINT_MODE_ENFORCE,
+	} {
+		mode, err := services.ParseFingerprintMode(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, expected, mode, value)
+	}
+
+	_, err := services.ParseFingerprintMode("strict")
+	assert.Error(t, err)
+}
+
 func TestRefreshTokenServiceTestSuite(t *testing.T) {
 	suite.Run(t, new(RefreshTokenServiceTestSuite))
 }
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+// USER_ROLES_CACHE_TTL is how long the role names of a user are cached before they are reloaded
+const USER_ROLES_CACHE_TTL = 10 * time.Minute
+
+type RoleService interface {
+	GetPermissionsForUser(ctx context.Context, userID uint) ([]string, error)
+	InvalidateUserRoles(ctx context.Context, userID uint) error
+}
+
+type roleServiceImpl struct {
+	repo         repositories.RoleRepository
+	redisService RedisService
+}
+
+func NewRoleService(repo repositories.RoleRepository, redisService RedisService) RoleService {
+	return &roleServiceImpl{
+		repo:         repo,
+		redisService: redisService,
+	}
+}
+
+// GetPermissionsForUser returns the permissions granted by all roles of the user, without duplicates
+func (service *roleServiceImpl) GetPermissionsForUser(ctx context.Context, userID uint) ([]string, error) {
+	userRoles, err := service.roleNamesForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := []string{}
+	for _, role := range userRoles {
+		for _, permission := range constants.ROLE_PERMISSIONS[role] {
+			if !slices.Contains(permissions, permission) {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	return permissions, nil
+}
+
+// InvalidateUserRoles drops the cached role names of the user, and the cached user whose roles are embedded.
+// It must be called whenever the roles of a user change.
+func (service *roleServiceImpl) InvalidateUserRoles(ctx context.Context, userID uint) error {
+	for _, key := range []string{userRolesCacheKey(userID), userCacheKey(userID)} {
+		if err := service.redisService.Delete(ctx, key); err != nil {
+			logger.WithContext(ctx).Errorf("Failed to invalidate cached roles for user ID %d: %v", userID, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// roleNamesForUser returns the role names of the user from the cache, loading and caching them on a miss.
+// Cache errors are logged and the roles are read from the database instead.
+func (service *roleServiceImpl) roleNamesForUser(ctx context.Context, userID uint) ([]string, error) {
+	cacheKey := userRolesCacheKey(userID)
+
+	cached, err := service.redisService.Get(ctx, cacheKey)
+	if err == nil {
+		var names []string
+		if err := json.Unmarshal([]byte(cached), &names); err == nil {
+			return names, nil
+		}
+		logger.WithContext(ctx).Warnf("Ignoring invalid cached roles for user ID %d", userID)
+	} else if !errors.Is(err, ErrCacheMiss) {
+		logger.WithContext(ctx).Warnf("Failed to read cached roles for user ID %d: %v", userID, err)
+	}
+
+	roles, err := service.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to load roles for user ID %d: %v", userID, err)
+		return nil, apperror.NewDBQueryError("Failed to load user roles")
+	}
+
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+
+	data, _ := json.Marshal(names)
+	if err := service.redisService.Set(ctx, cacheKey, string(data), USER_ROLES_CACHE_TTL); err != nil {
+		logger.WithContext(ctx).Warnf("Failed to cache roles for user ID %d: %v", userID, err)
+	}
+	return names, nil
+}
+
+func userRolesCacheKey(userID uint) string {
+	return constants.USER_ROLES + strconv.Itoa(int(userID))
+}
@@ -0,0 +1,129 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+func TestRoleService_GetPermissionsForUser(t *testing.T) {
+	t.Run("CombinesRolePermissions", func(t *testing.T) {
+		repo := new(mocks.MockRoleRepository)
+		repo.On("GetByUserID", mock.Anything, uint(1)).Return([]models.Role{{Name: constants.ROLE_USER}, {Name: constants.ROLE_ADMIN}}, nil).Once()
+		service := services.NewRoleService(repo, services.NewMemoryRedisService(0))
+
+		permissions, err := service.GetPermissionsForUser(context.Background(), 1)
+
+		require.NoError(t, err)
+		assert.ElementsMatch(t, constants.ROLE_PERMISSIONS[constants.ROLE_ADMIN], permissions)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("NoRoles", func(t *testing.T) {
+		repo := new(mocks.MockRoleRepository)
+		repo.On("GetByUserID", mock.Anything, uint(1)).Return([]models.Role{}, nil).Once()
+		service := services.NewRoleService(repo, services.NewMemoryRedisService(0))
+
+		permissions, err := service.GetPermissionsForUser(context.Background(), 1)
+
+		require.NoError(t, err)
+		assert.Empty(t, permissions)
+	})
+
+	t.Run("ServesRolesFromCache", func(t *testing.T) {
+		repo := new(mocks.MockRoleRepository)
+		repo.On("GetByUserID", mock.Anything, uint(1)).Return([]models.Role{{Name: constants.ROLE_ADMIN}}, nil).Once()
+		cache := services.NewMemoryRedisService(0)
+		service := services.NewRoleService(repo, cache)
+
+		_, err := service.GetPermissionsForUser(context.Background(), 1)
+		require.NoError(t, err)
+		permissions, err := service.GetPermissionsForUser(context.Background(), 1)
+		require.NoError(t, err)
+
+		assert.Contains(t, permissions, constants.PERMISSION_USERS_CREATE)
+		repo.AssertNumberOfCalls(t, "GetByUserID", 1)
+
+		cached, err := cache.Get(context.Background(), constants.USER_ROLES+"1")
+		require.NoError(t, err)
+		assert.JSONEq(t, `["admin"]`, cached)
+	})
+
+	t.Run("InvalidateReloadsRoles", func(t *testing.T) {
+		repo := new(mocks.MockRoleRepository)
+		repo.On("GetByUserID", mock.Anything, uint(1)).Return([]models.Role{{Name: constants.ROLE_ADMIN}}, nil).Once()
+		repo.On("GetByUserID", mock.Anything, uint(1)).Return([]models.Role{{Name: constants.ROLE_USER}}, nil).Once()
+		service := services.NewRoleService(repo, services.NewMemoryRedisService(0))
+
+		_, err := service.GetPermissionsForUser(context.Background(), 1)
+		require.NoError(t, err)
+		require.NoError(t, service.InvalidateUserRoles(context.Background(), 1))
+
+		permissions, err := service.GetPermissionsForUser(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Empty(t, permissions)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("CacheErrorFallsBackToDatabase", func(t *testing.T) {
+		repo := new(mocks.MockRoleRepository)
+		repo.On("GetByUserID", mock.Anything, uint(1)).Return([]models.Role{{Name: constants.ROLE_ADMIN}}, nil).Once()
+		redisService := new(mocks.MockRedisService)
+		redisService.On("Get", mock.Anything, constants.USER_ROLES+"1").Return("", errors.New("redis down"))
+		redisService.On("Set", mock.Anything, constants.USER_ROLES+"1", `["admin"]`, services.USER_ROLES_CACHE_TTL).Return(errors.New("redis down"))
+		service := services.NewRoleService(repo, redisService)
+
+		permissions, err := service.GetPermissionsForUser(context.Background(), 1)
+
+		require.NoError(t, err)
+		assert.Contains(t, permissions, constants.PERMISSION_USERS_READ)
+		redisService.AssertExpectations(t)
+	})
+
+	t.Run("RepositoryError", func(t *testing.T) {
+		repo := new(mocks.MockRoleRepository)
+		repo.On("GetByUserID", mock.Anything, uint(1)).Return(nil, errors.New("db error")).Once()
+		service := services.NewRoleService(repo, services.NewMemoryRedisService(0))
+
+		permissions, err := service.GetPermissionsForUser(context.Background(), 1)
+
+		assert.Nil(t, permissions)
+		appErr, ok := apperror.ToAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperror.ErrDBQuery, appErr.Code)
+	})
+}
+
+func TestRoleService_InvalidateUserRoles(t *testing.T) {
+	t.Run("DropsRolesAndCachedUser", func(t *testing.T) {
+		redisService := new(mocks.MockRedisService)
+		redisService.On("Delete", mock.Anything, constants.USER_ROLES+"1").Return(nil)
+		redisService.On("Delete", mock.Anything, constants.USER+"1").Return(nil)
+		service := services.NewRoleService(new(mocks.MockRoleRepository), redisService)
+
+		err := service.InvalidateUserRoles(context.Background(), 1)
+
+		assert.NoError(t, err)
+		redisService.AssertExpectations(t)
+	})
+
+	t.Run("CacheError", func(t *testing.T) {
+		redisService := new(mocks.MockRedisService)
+		redisService.On("Delete", mock.Anything, constants.USER_ROLES+"1").Return(apperror.NewCacheDeleteError("redis down"))
+		service := services.NewRoleService(new(mocks.MockRoleRepository), redisService)
+
+		err := service.InvalidateUserRoles(context.Background(), 1)
+
+		assert.Error(t, err)
+		redisService.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+// DEFAULT_SETTINGS_REFRESH_INTERVAL is how long settings are served from memory when a non-positive interval is given
+const DEFAULT_SETTINGS_REFRESH_INTERVAL = 30 * time.Second
+
+// SettingsService reads and manages runtime settings. The typed getters return def when the key is not set,
+// and def together with an error when the stored value cannot be read as the requested type,
+// so callers can log the error and carry on with the default.
+type SettingsService interface {
+	GetString(ctx context.Context, key string, def string) (string, error)
+	GetInt(ctx context.Context, key string, def int) (int, error)
+	GetBool(ctx context.Context, key string, def bool) (bool, error)
+	ListSettings(ctx context.Context) ([]*models.Setting, error)
+	GetSetting(ctx context.Context, key string) (*models.Setting, error)
+	SetSetting(ctx context.Context, key string, input *dto.SetSettingInput) (*models.Setting, error)
+	DeleteSetting(ctx context.Context, key string) error
+}
+
+// settingsServiceImpl keeps every setting in memory and reloads them all once refreshInterval has passed.
+// Writes through this service take effect at once; writes made by other instances within refreshInterval.
+type settingsServiceImpl struct {
+	repo            repositories.SettingRepository
+	refreshInterval time.Duration
+	mu              sync.RWMutex
+	values          map[string]*models.Setting
+	loadedAt        time.Time
+}
+
+func NewSettingsService(repo repositories.SettingRepository, refreshInterval time.Duration) SettingsService {
+	if refreshInterval <= 0 {
+		refreshInterval = DEFAULT_SETTINGS_REFRESH_INTERVAL
+	}
+	return &settingsServiceImpl{
+		repo:            repo,
+		refreshInterval: refreshInterval,
+	}
+}
+
+// GetString returns the value of the setting whatever its type
+func (service *settingsServiceImpl) GetString(ctx context.Context, key string, def string) (string, error) {
+	setting, err := service.lookup(ctx, key)
+	if err != nil || setting == nil {
+		return def, err
+	}
+	return setting.Value, nil
+}
+
+func (service *settingsServiceImpl) GetInt(ctx context.Context, key string, def int) (int, error) {
+	setting, err := service.lookupTyped(ctx, key, constants.SETTING_TYPE_INT)
+	if err != nil || setting == nil {
+		return def, err
+	}
+	value, err := strconv.Atoi(setting.Value)
+	if err != nil {
+		return def, apperror.NewParseError(fmt.Sprintf("Setting %s is not a valid int: %q", key, setting.Value))
+	}
+	return value, nil
+}
+
+func (service *settingsServiceImpl) GetBool(ctx context.Context, key string, def bool) (bool, error) {
+	setting, err := service.lookupTyped(ctx, key, constants.SETTING_TYPE_BOOL)
+	if err != nil || setting == nil {
+		return def, err
+	}
+	value, err := strconv.ParseBool(setting.Value)
+	if err != nil {
+		return def, apperror.NewParseError(fmt.Sprintf("Setting %s is not a valid bool: %q", key, setting.Value))
+	}
+	return value, nil
+}
+
+// ListSettings returns every setting from the database, bypassing the cache
+func (service *settingsServiceImpl) ListSettings(ctx context.Context) ([]*models.Setting, error) {
+	settings, err := service.repo.GetAll(ctx)
+	if err != nil {
+		return nil, apperror.NewDBQueryError("Failed to get settings")
+	}
+	return settings, nil
+}
+
+// GetSetting returns the setting from the database, bypassing the cache
+func (service *settingsServiceImpl) GetSetting(ctx context.Context, key string) (*models.Setting, error) {
+	setting, err := service.repo.GetByKey(ctx, key)
+	if err != nil {
+		if appErr, ok := apperror.ToAppError(err); ok && appErr.Code == apperror.ErrNotFound {
+			return nil, apperror.NewNotFoundError("Setting not found")
+		}
+		return nil, apperror.NewDBQueryError("Failed to get setting")
+	}
+	return setting, nil
+}
+
+// SetSetting creates or replaces the setting. The value must parse as the given type and is stored
+// in its canonical form, e.g. "TRUE" is stored as "true"
+func (service *settingsServiceImpl) SetSetting(ctx context.Context, key string, input *dto.SetSettingInput) (*models.Setting, error) {
+	value, err := normalizeSettingValue(input.Type, input.Value)
+	if err != nil {
+		return nil, apperror.NewValidationError("Validation failed", []apperror.FieldError{
+			{Field: "value", Message: fmt.Sprintf("value must be a valid %s", input.Type)},
+		})
+	}
+
+	setting := &models.Setting{Key: key, Value: value, Type: input.Type, UpdatedAt: time.Now()}
+	if err := service.repo.Upsert(ctx, setting); err != nil {
+		return nil, apperror.NewDBUpdateError("Failed to save setting")
+	}
+	service.invalidate()
+
+	logger.WithContext(ctx).Infof("Setting %s set to %q (%s)", key, value, input.Type)
+	return setting, nil
+}
+
+func (service *settingsServiceImpl) DeleteSetting(ctx context.Context, key string) error {
+	deleted, err := service.repo.Delete(ctx, key)
+	if err != nil {
+		return apperror.NewDBDeleteError("Failed to delete setting")
+	}
+	if !deleted {
+		return apperror.NewNotFoundError("Setting not found")
+	}
+	service.invalidate()
+
+	logger.WithContext(ctx).Infof("Setting %s deleted", key)
+	return nil
+}
+
+// lookupTyped is lookup rejecting a setting stored with another type than settingType
+func (service *settingsServiceImpl) lookupTyped(ctx context.Context, key string, settingType string) (*models.Setting, error) {
+	setting, err := service.lookup(ctx, key)
+	if err != nil || setting == nil {
+		return nil, err
+	}
+	if setting.Type != settingType {
+		return nil, apperror.NewParseError(fmt.Sprintf("Setting %s is of type %s, not %s", key, setting.Type, settingType))
+	}
+	return setting, nil
+}
+
+// lookup returns the cached setting, or nil when it is not set, reloading all settings when the cache is stale.
+// When the reload fails the stale settings keep being served until the next refresh is due,
+// and an error is only returned when nothing was ever loaded.
+func (service *settingsServiceImpl) lookup(ctx context.Context, key string) (*models.Setting, error) {
+	service.mu.RLock()
+	if service.isFresh() {
+		setting := service.values[key]
+		service.mu.RUnlock()
+		return setting, nil
+	}
+	service.mu.RUnlock()
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	// Another caller may have reloaded while the lock was released
+	if service.isFresh() {
+		return service.values[key], nil
+	}
+
+	settings, err := service.repo.GetAll(ctx)
+	if err != nil {
+		if service.values == nil {
+			return nil, apperror.NewDBQueryError("Failed to load settings")
+		}
+		logger.WithContext(ctx).Warnf("Failed to reload settings, serving cached values: %v", err)
+		service.loadedAt = time.Now()
+		return service.values[key], nil
+	}
+
+	values := make(map[string]*models.Setting, len(settings))
+	for _, setting := range settings {
+		values[setting.Key] = setting
+	}
+	service.values = values
+	service.loadedAt = time.Now()
+	return values[key], nil
+}
+
+// isFresh reports whether the cached settings can be served. The caller must hold mu
+func (service *settingsServiceImpl) isFresh() bool {
+	return service.values != nil && time.Since(service.loadedAt) < service.refreshInterval
+}
+
+// invalidate makes the next lookup reload the settings. The stale values are kept in case the reload fails
+func (service *settingsServiceImpl) invalidate() {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	service.loadedAt = time.Time{}
+}
+
+// normalizeSettingValue parses value as settingType and returns its canonical form
+func normalizeSettingValue(settingType, value string) (string, error) {
+	switch settingType {
+	case constants.SETTING_TYPE_INT:
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return "", err
+		}
+		return strconv.Itoa(parsed), nil
+	case constants.SETTING_TYPE_BOOL:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatBool(parsed), nil
+	case constants.SETTING_TYPE_STRING:
+		return value, nil
+	}
+	return "", fmt.Errorf("unknown setting type %q", settingType)
+}
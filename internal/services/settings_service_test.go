@@ -0,0 +1,239 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+func TestSettingsService_Getters(t *testing.T) {
+	ctx := context.Background()
+	stored := []*models.Setting{
+		{Key: "site.name", Value: "CMS", Type: "string"},
+		{Key: "login.max_attempts", Value: "7", Type: "int"},
+		{Key: "maintenance.enabled", Value: "true", Type: "bool"},
+		{Key: "broken.int", Value: "abc", Type: "int"},
+		{Key: "broken.bool", Value: "maybe", Type: "bool"},
+	}
+	newService := func() services.SettingsService {
+		repo := new(mocks.MockSettingRepository)
+		repo.On("GetAll", mock.Anything).Return(stored, nil).Once()
+		return services.NewSettingsService(repo, time.Minute)
+	}
+
+	t.Run("StoredValues", func(t *testing.T) {
+		service := newService()
+
+		name, err := service.GetString(ctx, "site.name", "default")
+		require.NoError(t, err)
+		assert.Equal(t, "CMS", name)
+		attempts, err := service.GetInt(ctx, "login.max_attempts", 5)
+		require.NoError(t, err)
+		assert.Equal(t, 7, attempts)
+		enabled, err := service.GetBool(ctx, "maintenance.enabled", false)
+		require.NoError(t, err)
+		assert.True(t, enabled)
+		// Any setting can be read as a string
+		raw, err := service.GetString(ctx, "login.max_attempts", "")
+		require.NoError(t, err)
+		assert.Equal(t, "7", raw)
+	})
+
+	t.Run("MissingKeysReturnDefault", func(t *testing.T) {
+		service := newService()
+
+		name, err := service.GetString(ctx, "missing", "default")
+		require.NoError(t, err)
+		assert.Equal(t, "default", name)
+		attempts, err := service.GetInt(ctx, "missing", 5)
+		require.NoError(t, err)
+		assert.Equal(t, 5, attempts)
+		enabled, err := service.GetBool(ctx, "missing", true)
+		require.NoError(t, err)
+		assert.True(t, enabled)
+	})
+
+	t.Run("CoercionErrorsReturnDefault", func(t *testing.T) {
+		service := newService()
+
+		attempts, err := service.GetInt(ctx, "broken.int", 5)
+		assert.Equal(t, 5, attempts)
+		assertAppErrorCode(t, err, apperror.ErrParseError)
+
+		enabled, err := service.GetBool(ctx, "broken.bool", true)
+		assert.True(t, enabled)
+		assertAppErrorCode(t, err, apperror.ErrParseError)
+	})
+
+	t.Run("TypeMismatchReturnsDefault", func(t *testing.T) {
+		service := newService()
+
+		attempts, err := service.GetInt(ctx, "maintenance.enabled", 5)
+		assert.Equal(t, 5, attempts)
+		assertAppErrorCode(t, err, apperror.ErrParseError)
+
+		enabled, err := service.GetBool(ctx, "site.name", false)
+		assert.False(t, enabled)
+		assertAppErrorCode(t, err, apperror.ErrParseError)
+	})
+}
+
+func TestSettingsService_Cache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ServedFromMemoryUntilRefreshIsDue", func(t *testing.T) {
+		repo := new(mocks.MockSettingRepository)
+		repo.On("GetAll", mock.Anything).Return([]*models.Setting{{Key: "login.max_attempts", Value: "7", Type: "int"}}, nil).Once()
+		service := services.NewSettingsService(repo, time.Minute)
+
+		for range 3 {
+			attempts, err := service.GetInt(ctx, "login.max_attempts", 5)
+			require.NoError(t, err)
+			assert.Equal(t, 7, attempts)
+		}
+		repo.AssertNumberOfCalls(t, "GetAll", 1)
+	})
+
+	t.Run("ReloadedAfterRefreshInterval", func(t *testing.T) {
+		repo := new(mocks.MockSettingRepository)
+		repo.On("GetAll", mock.Anything).Return([]*models.Setting{{Key: "login.max_attempts", Value: "7", Type: "int"}}, nil).Once()
+		repo.On("GetAll", mock.Anything).Return([]*models.Setting{{Key: "login.max_attempts", Value: "3", Type: "int"}}, nil).Once()
+		service := services.NewSettingsService(repo, 10*time.Millisecond)
+
+		attempts, _ := service.GetInt(ctx, "login.max_attempts", 5)
+		assert.Equal(t, 7, attempts)
+		time.Sleep(20 * time.Millisecond)
+		attempts, _ = service.GetInt(ctx, "login.max_attempts", 5)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("InvalidatedBySetSetting", func(t *testing.T) {
+		repo := new(mocks.MockSettingRepository)
+		repo.On("GetAll", mock.Anything).Return([]*models.Setting{{Key: "maintenance.enabled", Value: "false", Type: "bool"}}, nil).Once()
+		repo.On("Upsert", mock.Anything, mock.MatchedBy(func(setting *models.Setting) bool {
+			return setting.Key == "maintenance.enabled" && setting.Value == "true" && setting.Type == "bool"
+		})).Return(nil).Once()
+		repo.On("GetAll", mock.Anything).Return([]*models.Setting{{Key: "maintenance.enabled", Value: "true", Type: "bool"}}, nil).Once()
+		service := services.NewSettingsService(repo, time.Hour)
+
+		enabled, _ := service.GetBool(ctx, "maintenance.enabled", false)
+		assert.False(t, enabled)
+
+		setting, err := service.SetSetting(ctx, "maintenance.enabled", &dto.SetSettingInput{Value: "TRUE", Type: "bool"})
+		require.NoError(t, err)
+		assert.Equal(t, "true", setting.Value)
+
+		enabled, _ = service.GetBool(ctx, "maintenance.enabled", false)
+		assert.True(t, enabled)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("InvalidatedByDeleteSetting", func(t *testing.T) {
+		repo := new(mocks.MockSettingRepository)
+		repo.On("GetAll", mock.Anything).Return([]*models.Setting{{Key: "site.name", Value: "CMS", Type: "string"}}, nil).Once()
+		repo.On("Delete", mock.Anything, "site.name").Return(true, nil).Once()
+		repo.On("GetAll", mock.Anything).Return([]*models.Setting{}, nil).Once()
+		service := services.NewSettingsService(repo, time.Hour)
+
+		name, _ := service.GetString(ctx, "site.name", "default")
+		assert.Equal(t, "CMS", name)
+		require.NoError(t, service.DeleteSetting(ctx, "site.name"))
+		name, _ = service.GetString(ctx, "site.name", "default")
+		assert.Equal(t, "default", name)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("FailedReloadServesStaleValues", func(t *testing.T) {
+		repo := new(mocks.MockSettingRepository)
+		repo.On("GetAll", mock.Anything).Return([]*models.Setting{{Key: "login.max_attempts", Value: "7", Type: "int"}}, nil).Once()
+		repo.On("GetAll", mock.Anything).Return(nil, errors.New("db down")).Once()
+		service := services.NewSettingsService(repo, 10*time.Millisecond)
+
+		_, _ = service.GetInt(ctx, "login.max_attempts", 5)
+		time.Sleep(20 * time.Millisecond)
+		attempts, err := service.GetInt(ctx, "login.max_attempts", 5)
+		require.NoError(t, err)
+		assert.Equal(t, 7, attempts)
+	})
+
+	t.Run("FailedFirstLoadReturnsDefault", func(t *testing.T) {
+		repo := new(mocks.MockSettingRepository)
+		repo.On("GetAll", mock.Anything).Return(nil, errors.New("db down")).Once()
+		service := services.NewSettingsService(repo, time.Minute)
+
+		attempts, err := service.GetInt(ctx, "login.max_attempts", 5)
+		assert.Equal(t, 5, attempts)
+		assertAppErrorCode(t, err, apperror.ErrDBQuery)
+	})
+}
+
+func TestSettingsService_Manage(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("SetSetting - Rejects Values Not Matching The Type", func(t *testing.T) {
+		cases := map[string]dto.SetSettingInput{
+			"Int":  {Value: "abc", Type: "int"},
+			"Bool": {Value: "maybe", Type: "bool"},
+		}
+		for name, input := range cases {
+			t.Run(name, func(t *testing.T) {
+				repo := new(mocks.MockSettingRepository)
+				service := services.NewSettingsService(repo, time.Minute)
+
+				setting, err := service.SetSetting(ctx, "some.key", &input)
+
+				assert.Nil(t, setting)
+				var validationErr *apperror.ValidationError
+				require.ErrorAs(t, err, &validationErr)
+				assert.Equal(t, "value", validationErr.Fields[0].Field)
+				repo.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything)
+			})
+		}
+	})
+
+	t.Run("SetSetting - Repository Error", func(t *testing.T) {
+		repo := new(mocks.MockSettingRepository)
+		repo.On("Upsert", mock.Anything, mock.Anything).Return(errors.New("db down")).Once()
+		service := services.NewSettingsService(repo, time.Minute)
+
+		_, err := service.SetSetting(ctx, "site.name", &dto.SetSettingInput{Value: "CMS", Type: "string"})
+		assertAppErrorCode(t, err, apperror.ErrDBUpdate)
+	})
+
+	t.Run("GetSetting - Not Found", func(t *testing.T) {
+		repo := new(mocks.MockSettingRepository)
+		repo.On("GetByKey", mock.Anything, "missing").Return(nil, apperror.NewNotFoundError("Setting not found")).Once()
+		service := services.NewSettingsService(repo, time.Minute)
+
+		_, err := service.GetSetting(ctx, "missing")
+		assertAppErrorCode(t, err, apperror.ErrNotFound)
+	})
+
+	t.Run("DeleteSetting - Not Found", func(t *testing.T) {
+		repo := new(mocks.MockSettingRepository)
+		repo.On("Delete", mock.Anything, "missing").Return(false, nil).Once()
+		service := services.NewSettingsService(repo, time.Minute)
+
+		err := service.DeleteSetting(ctx, "missing")
+		assertAppErrorCode(t, err, apperror.ErrNotFound)
+	})
+
+	t.Run("ListSettings - Repository Error", func(t *testing.T) {
+		repo := new(mocks.MockSettingRepository)
+		repo.On("GetAll", mock.Anything).Return(nil, errors.New("db down")).Once()
+		service := services.NewSettingsService(repo, time.Minute)
+
+		_, err := service.ListSettings(ctx)
+		assertAppErrorCode(t, err, apperror.ErrDBQuery)
+	})
+}
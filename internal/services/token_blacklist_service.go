@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+)
+
+// TokenBlacklistService tracks access tokens revoked before they expire, keyed by their jti
+type TokenBlacklistService interface {
+	Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+}
+
+type tokenBlacklistServiceImpl struct {
+	redisService RedisService
+}
+
+func NewTokenBlacklistService(redisService RedisService) TokenBlacklistService {
+	return &tokenBlacklistServiceImpl{
+		redisService: redisService,
+	}
+}
+
+// Revoke blacklists the token until it expires. Expired tokens are already rejected, so they are not stored
+func (service *tokenBlacklistServiceImpl) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if tokenID == "" || ttl <= 0 {
+		return nil
+	}
+	return service.redisService.Set(ctx, constants.REVOKED_ACCESS_TOKEN+tokenID, "1", ttl)
+}
+
+// IsRevoked reports whether the token has been revoked
+func (service *tokenBlacklistServiceImpl) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	return service.redisService.Exists(ctx, constants.REVOKED_ACCESS_TOKEN+tokenID)
+}
@@ -0,0 +1,73 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+func TestTokenBlacklistService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("RevokedUntilExpiry", func(t *testing.T) {
+		redisService := services.NewMemoryRedisService(0)
+		blacklist := services.NewTokenBlacklistService(redisService)
+
+		require.NoError(t, blacklist.Revoke(ctx, "token-id", time.Now().Add(time.Hour)))
+
+		revoked, err := blacklist.IsRevoked(ctx, "token-id")
+		require.NoError(t, err)
+		assert.True(t, revoked)
+
+		revoked, err = blacklist.IsRevoked(ctx, "other-token-id")
+		require.NoError(t, err)
+		assert.False(t, revoked)
+	})
+
+	t.Run("StoredWithRemainingLifetime", func(t *testing.T) {
+		redisService := new(mocks.MockRedisService)
+		var ttl time.Duration
+		redisService.On("Set", ctx, constants.REVOKED_ACCESS_TOKEN+"token-id", "1", mock.MatchedBy(func(d time.Duration) bool {
+			ttl = d
+			return true
+		})).Return(nil).Once()
+
+		require.NoError(t, services.NewTokenBlacklistService(redisService).Revoke(ctx, "token-id", time.Now().Add(10*time.Minute)))
+
+		assert.InDelta(t, float64(10*time.Minute), float64(ttl), float64(time.Second))
+		redisService.AssertExpectations(t)
+	})
+
+	t.Run("ExpiredTokenIsNotStored", func(t *testing.T) {
+		redisService := new(mocks.MockRedisService)
+
+		require.NoError(t, services.NewTokenBlacklistService(redisService).Revoke(ctx, "token-id", time.Now().Add(-time.Minute)))
+
+		redisService.AssertNotCalled(t, "Set")
+	})
+
+	t.Run("TokenWithoutIDIsNotStored", func(t *testing.T) {
+		redisService := new(mocks.MockRedisService)
+
+		require.NoError(t, services.NewTokenBlacklistService(redisService).Revoke(ctx, "", time.Now().Add(time.Hour)))
+
+		redisService.AssertNotCalled(t, "Set")
+	})
+
+	t.Run("CacheError", func(t *testing.T) {
+		redisService := new(mocks.MockRedisService)
+		redisService.On("Set", ctx, constants.REVOKED_ACCESS_TOKEN+"token-id", "1", mock.Anything).Return(apperror.NewCacheSetError("connection refused")).Once()
+
+		err := services.NewTokenBlacklistService(redisService).Revoke(ctx, "token-id", time.Now().Add(time.Hour))
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+
+	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+type UserActivityService interface {
+	GetUserActivity(ctx context.Context, userID uint, opts dto.ListOptions) (*dto.UserActivityResponse, error)
+}
+
+type userActivityServiceImpl struct {
+	repo                repositories.UserRepository
+	refreshTokenService RefreshTokenService
+	auditService        AuditService
+}
+
+func NewUserActivityService(repo repositories.UserRepository, refreshTokenService RefreshTokenService, auditService AuditService) UserActivityService {
+	return &userActivityServiceImpl{
+		repo:                repo,
+		refreshTokenService: refreshTokenService,
+		auditService:        auditService,
+	}
+}
+
+// GetUserActivity returns the last login of the user, how many sessions they have open and the page of their
+// audit log entries described by opts
+func (service *userActivityServiceImpl) GetUserActivity(ctx context.Context, userID uint, opts dto.ListOptions) (*dto.UserActivityResponse, error) {
+	user, err := service.repo.GetByID(ctx, userID)
+	if err != nil {
+		if appErr, ok := apperror.ToAppError(err); ok && appErr.Code == apperror.ErrNotFound {
+			return nil, apperror.NewNotFoundError("User not found")
+		}
+		return nil, apperror.NewDBQueryError("Failed to get user")
+	}
+
+	sessions, err := service.refreshTokenService.ListSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	recentActivity, err := service.auditService.GetAuditLogs(ctx, opts, dto.AuditLogFilterInput{ActorUserID: &userID})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.WithContext(ctx).Infof("Retrieved activity of user ID %d", userID)
+	return &dto.UserActivityResponse{
+		UserID:         user.ID,
+		LastLoginAt:    user.LastLoginAt,
+		LastLoginIP:    user.LastLoginIP,
+		ActiveSessions: len(sessions),
+		RecentActivity: recentActivity,
+	}, nil
+}
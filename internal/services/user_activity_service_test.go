@@ -0,0 +1,99 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/tests/mocks"
+)
+
+func TestUserActivityService_GetUserActivity(t *testing.T) {
+	ctx := context.Background()
+	opts := dto.ListOptions{Page: 2, Limit: 5, SortBy: "created_at", SortDir: "desc"}
+
+	setup := func() (*mocks.MockUserRepository, *mocks.MockRefreshTokenService, *mocks.MockAuditService, services.UserActivityService) {
+		repo := new(mocks.MockUserRepository)
+		refreshTokenService := new(mocks.MockRefreshTokenService)
+		auditService := new(mocks.MockAuditService)
+		return repo, refreshTokenService, auditService, services.NewUserActivityService(repo, refreshTokenService, auditService)
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		repo, refreshTokenService, auditService, service := setup()
+		lastLoginAt := time.Date(2025, time.March, 5, 14, 30, 0, 0, time.UTC)
+		lastLoginIP := "203.0.113.7"
+		repo.On("GetByID", mock.Anything, uint(7)).Return(&models.User{ID: 7, LastLoginAt: &lastLoginAt, LastLoginIP: &lastLoginIP}, nil)
+		refreshTokenService.On("ListSessions", mock.Anything, uint(7)).Return([]dto.SessionResponse{{ID: 1}, {ID: 2}}, nil)
+		page := &dto.Pagination[*models.AuditLog]{Page: 2, Limit: 5, TotalItems: 6, TotalPages: 2, Data: []*models.AuditLog{{ID: 9, Action: "auth.login"}}}
+		// Only the actions the user performed are listed
+		auditService.On("GetAuditLogs", mock.Anything, opts, mock.MatchedBy(func(filter dto.AuditLogFilterInput) bool {
+			return filter.ActorUserID != nil && *filter.ActorUserID == 7 && filter.Action == "" && filter.From == nil && filter.To == nil
+		})).Return(page, nil)
+
+		activity, err := service.GetUserActivity(ctx, 7, opts)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint(7), activity.UserID)
+		assert.Equal(t, &lastLoginAt, activity.LastLoginAt)
+		assert.Equal(t, &lastLoginIP, activity.LastLoginIP)
+		assert.Equal(t, 2, activity.ActiveSessions)
+		assert.Equal(t, page, activity.RecentActivity)
+	})
+
+	t.Run("UserNotFound", func(t *testing.T) {
+		repo, refreshTokenService, auditService, service := setup()
+		repo.On("GetByID", mock.Anything, uint(7)).Return(nil, apperror.New(apperror.ErrNotFound, 1001, "User not found"))
+
+		activity, err := service.GetUserActivity(ctx, 7, opts)
+
+		assert.Nil(t, activity)
+		appErr, ok := apperror.ToAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperror.ErrNotFound, appErr.Code)
+		refreshTokenService.AssertNotCalled(t, "ListSessions", mock.Anything, mock.Anything)
+		auditService.AssertNotCalled(t, "GetAuditLogs", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("UserQueryError", func(t *testing.T) {
+		repo, _, _, service := setup()
+		repo.On("GetByID", mock.Anything, uint(7)).Return(nil, apperror.Wrap(apperror.ErrInternalServer, 500, "Failed to fetch user", assert.AnError))
+
+		activity, err := service.GetUserActivity(ctx, 7, opts)
+
+		assert.Nil(t, activity)
+		appErr, ok := apperror.ToAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, apperror.ErrDBQuery, appErr.Code)
+	})
+
+	t.Run("SessionsError", func(t *testing.T) {
+		repo, refreshTokenService, _, service := setup()
+		repo.On("GetByID", mock.Anything, uint(7)).Return(&models.User{ID: 7}, nil)
+		refreshTokenService.On("ListSessions", mock.Anything, uint(7)).Return(nil, apperror.NewDBQueryError("Failed to list sessions"))
+
+		activity, err := service.GetUserActivity(ctx, 7, opts)
+
+		assert.Nil(t, activity)
+		assert.Error(t, err)
+	})
+
+	t.Run("AuditLogsError", func(t *testing.T) {
+		repo, refreshTokenService, auditService, service := setup()
+		repo.On("GetByID", mock.Anything, uint(7)).Return(&models.User{ID: 7}, nil)
+		refreshTokenService.On("ListSessions", mock.Anything, uint(7)).Return([]dto.SessionResponse{}, nil)
+		auditService.On("GetAuditLogs", mock.Anything, opts, mock.Anything).Return(nil, apperror.NewDBQueryError("Failed to get audit logs"))
+
+		activity, err := service.GetUserActivity(ctx, 7, opts)
+
+		assert.Nil(t, activity)
+		assert.Error(t, err)
+	})
+}
@@ -1,74 +1,392 @@
 package services
 
 import (
+	"bufio"
 	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/gin-gonic/gin/binding"
 	"github.com/vfa-khuongdv/golang-cms/internal/models"
 	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
 	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
 	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+	"github.com/vfa-khuongdv/golang-cms/pkg/xlsx"
+	"gorm.io/gorm"
 )
 
 type UserService interface {
-	GetProfile(ctx context.Context, userID uint) (*models.User, error)
+	CreateUser(ctx context.Context, input *dto.CreateUserInput) (*models.User, error)
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, input *dto.ResendVerificationInput) error
+	GetProfile(ctx context.Context, userID uint) (*dto.ProfileResponse, error)
 	UpdateProfile(ctx context.Context, userID uint, input *dto.UpdateProfileInput) error
+	GetUsers(ctx context.Context, opts dto.ListOptions, filter dto.UserFilterInput) (*dto.Pagination[*models.User], error)
+	GetUser(ctx context.Context, id uint, includeDeleted bool) (*models.User, error)
+	SearchUsers(ctx context.Context, query string, limit int) ([]*models.User, error)
+	ExportUsers(ctx context.Context, filter dto.UserFilterInput, format string, w io.Writer) error
+	ImportUsers(ctx context.Context, r io.Reader, dryRun bool) (*dto.UserImportReport, error)
+	RestoreUser(ctx context.Context, id uint) (*models.User, error)
+	DeleteUsers(ctx context.Context, ids []uint) (*dto.BulkDeleteUsersResult, error)
+	GetUserRoles(ctx context.Context, userID uint) ([]dto.UserRoleResponse, error)
+	AssignRoles(ctx context.Context, userID uint, roleIDs []uint) error
+	RemoveRoles(ctx context.Context, userID uint, roleIDs []uint) error
 
 	ForgotPassword(ctx context.Context, input *dto.ForgotPasswordInput) error
 	ResetPassword(ctx context.Context, input *dto.ResetPasswordInput) (*models.User, error)
 	ChangePassword(ctx context.Context, userId uint, input *dto.ChangePasswordInput) (*models.User, error)
+	ForceResetPassword(ctx context.Context, id uint) (string, error)
+	UnlockUser(ctx context.Context, id uint) error
 }
 
+// PROFILE_CACHE_TTL is how long a cached profile is served before it is reloaded from the database,
+// unless PROFILE_CACHE_TTL_MINUTES overrides it
+const PROFILE_CACHE_TTL = 60 * time.Minute
+
+// PROFILE_CACHE_REFRESH_AHEAD is how long before its expiry a cached profile is reloaded in the background,
+// unless PROFILE_CACHE_REFRESH_AHEAD_SECONDS overrides it. It is capped at half the profile cache TTL
+const PROFILE_CACHE_REFRESH_AHEAD = time.Minute
+
+// VERIFICATION_TOKEN_TTL is how long an email verification link stays valid
+const VERIFICATION_TOKEN_TTL = 24 * time.Hour
+
+// DEFAULT_USER_SEARCH_LIMIT is the number of search results returned when no limit is given
+const DEFAULT_USER_SEARCH_LIMIT = 10
+
+// USER_EXPORT_BATCH_SIZE is the number of users read from the database at a time while exporting
+const USER_EXPORT_BATCH_SIZE = 500
+
+// userExportColumns are the columns of a user export. Passwords and tokens are never exported
+var userExportColumns = []any{"id", "name", "email", "gender", "birthday", "address", "created_at"}
+
+// USER_IMPORT_BATCH_SIZE is the number of users inserted per statement while importing
+const USER_IMPORT_BATCH_SIZE = 100
+
+// userImportColumns are the columns a user import must have, in any order
+var userImportColumns = []string{"email", "password", "name", "birthday", "address", "gender"}
+
+// FORGOT_PASSWORD_MAX_REQUESTS is the number of password resets that may be requested for an email per FORGOT_PASSWORD_WINDOW
+const FORGOT_PASSWORD_MAX_REQUESTS = 3
+
+// FORGOT_PASSWORD_WINDOW is the window in which FORGOT_PASSWORD_MAX_REQUESTS applies
+const FORGOT_PASSWORD_WINDOW = time.Hour
+
+// USER_TOKEN_LENGTH is the length of the verification and password reset tokens mailed to users,
+// about 190 bits drawn from crypto/rand
+const USER_TOKEN_LENGTH = 32
+
+// PASSWORD_RESET_TOKEN_TTL is how long a password reset link stays valid
+const PASSWORD_RESET_TOKEN_TTL = time.Hour
+
+// FORGOT_PASSWORD_MIN_DURATION is how long a password reset request past the rate limits takes at least,
+// unless FORGOT_PASSWORD_MIN_DURATION_MS overrides it, so registered and unknown emails are answered as fast
+const FORGOT_PASSWORD_MIN_DURATION = 300 * time.Millisecond
+
+// TEMPORARY_PASSWORD_LENGTH is the length of passwords generated by ForceResetPassword
+const TEMPORARY_PASSWORD_LENGTH = 16
+
 type userServiceImpl struct {
-	repo          repositories.UserRepository
-	bcryptService BcryptService
-	mailerService MailerService
+	repo                repositories.UserRepository
+	bcryptService       BcryptService
+	mailerService       MailerService
+	redisService        RedisService
+	refreshTokenService RefreshTokenService
+	notificationService NotificationService
+	backgroundService   BackgroundService
+	resendCooldown      time.Duration
+	resetCooldown       time.Duration
+	forgotPasswordMin   time.Duration
+	profileCacheTTL     time.Duration
+	profileRefreshAhead time.Duration
 }
 
-func NewUserService(repo repositories.UserRepository, bcryptService BcryptService, mailerService MailerService) UserService {
+func NewUserService(repo repositories.UserRepository, bcryptService BcryptService, mailerService MailerService, redisService RedisService, refreshTokenService RefreshTokenService, notificationService NotificationService, backgroundService BackgroundService) UserService {
+	profileCacheTTL := profileCacheTTLFromEnv()
 	return &userServiceImpl{
-		repo:          repo,
-		bcryptService: bcryptService,
-		mailerService: mailerService,
+		repo:                repo,
+		bcryptService:       bcryptService,
+		mailerService:       mailerService,
+		redisService:        redisService,
+		refreshTokenService: refreshTokenService,
+		notificationService: notificationService,
+		backgroundService:   backgroundService,
+		resendCooldown:      time.Duration(utils.GetEnvAsInt("VERIFICATION_RESEND_COOLDOWN_SECONDS", 120)) * time.Second,
+		resetCooldown:       time.Duration(utils.GetEnvAsInt("PASSWORD_RESET_COOLDOWN_SECONDS", 120)) * time.Second,
+		forgotPasswordMin:   time.Duration(utils.GetEnvAsInt("FORGOT_PASSWORD_MIN_DURATION_MS", int(FORGOT_PASSWORD_MIN_DURATION/time.Millisecond))) * time.Millisecond,
+		profileCacheTTL:     profileCacheTTL,
+		profileRefreshAhead: profileRefreshAheadFromEnv(profileCacheTTL),
 	}
 }
 
-func (service *userServiceImpl) ForgotPassword(ctx context.Context, input *dto.ForgotPasswordInput) error {
-	user, err := service.repo.FindByField(ctx, "email", input.Email)
+// CreateUser creates an unverified user with the roles of input.RoleIDs and mails them a verification link.
+// The user and the roles are stored in one transaction, so a user is never left without its roles;
+// if a role does not exist nothing is created and a 400 error names the missing IDs.
+// A failure to send the mail is only logged; the user can ask for the link again.
+func (service *userServiceImpl) CreateUser(ctx context.Context, input *dto.CreateUserInput) (*models.User, error) {
+	email := utils.NormalizeEmail(input.Email)
+	if _, err := service.repo.FindByField(ctx, "email", email); err == nil {
+		return nil, apperror.NewDuplicateEmailError("Email already registered").
+			WithFields(apperror.FieldError{Field: "email", Message: "email already exists"})
+	}
+
+	hashedPassword, err := service.bcryptService.HashPassword(input.Password)
 	if err != nil {
-		appErr, isAppErr := apperror.ToAppError(err)
-		if isAppErr && appErr.Code == apperror.ErrNotFound {
-			logger.WithContext(ctx).Warnf("Forgot password attempt for non-existent email: %s", input.Email)
-			return nil
+		return nil, apperror.NewPasswordHashFailedError("Failed to hash password")
+	}
+
+	user := &models.User{
+		Email:    email,
+		Password: hashedPassword,
+		Name:     input.Name,
+		Address:  input.Address,
+		Gender:   input.Gender,
+	}
+	if input.Birthday != nil {
+		birthdayDate, err := utils.ParseDateStringYYYYMMDD(*input.Birthday)
+		if err != nil {
+			return nil, err
 		}
-		logger.WithContext(ctx).Errorf("Forgot password failed for email %s: %v", input.Email, err)
-		return apperror.NewDBQueryError("Failed to process forgot password request")
+		user.Birthday = birthdayDate
 	}
+	token := setVerificationToken(user)
 
-	token := utils.GenerateRandomString(32)
-	expiredAt := time.Now().Add(1 * time.Hour).Unix()
+	if err := service.createWithRoles(ctx, user, input.RoleIDs); err != nil {
+		// A missing role, or the email registered by a concurrent request since it was checked
+		if appErr, ok := apperror.ToAppError(err); ok && (appErr.Code == apperror.ErrBadRequest || appErr.Code == apperror.ErrDuplicateEmail) {
+			return nil, appErr
+		}
+		logger.WithContext(ctx).Errorf("Failed to create user %s: %v", email, err)
+		return nil, apperror.NewDBInsertError("Failed to create user")
+	}
 
-	user.Token = &token
-	user.ExpiredAt = &expiredAt
+	if err := service.mailerService.SendMailVerification(NewMailData(user, token)); err != nil {
+		logger.WithContext(ctx).Warnf("Failed to send verification email to user ID %d: %v", user.ID, err)
+	}
 
-	err = service.repo.Update(ctx, user)
+	logger.WithContext(ctx).Infof("Created user ID %d", user.ID)
+	return user, nil
+}
+
+// createWithRoles inserts the user and links it to the roles in one transaction, which is rolled back on any error.
+// The roles are checked before the insert; missing ones are reported as an ErrBadRequest error
+func (service *userServiceImpl) createWithRoles(ctx context.Context, user *models.User, roleIDs []uint) error {
+	tx, err := service.repo.BeginTx(ctx)
 	if err != nil {
-		logger.WithContext(ctx).Errorf("Failed to update user with reset token: %v", err)
-		return apperror.NewDBUpdateError("Failed to save reset token")
+		return err
 	}
 
-	if err := service.mailerService.SendMailForgotPassword(user); err != nil {
+	err = func() error {
+		missing, err := service.repo.FindMissingRolesWithTx(ctx, tx, roleIDs)
+		if err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			return apperror.NewBadRequestError(missingRolesMessage(missing))
+		}
+		if _, err := service.repo.CreateWithTx(ctx, tx, user); err != nil {
+			return err
+		}
+		return service.repo.AssignRolesWithTx(ctx, tx, user.ID, roleIDs)
+	}()
+	if err != nil {
+		if rollbackErr := tx.Rollback().Error; rollbackErr != nil {
+			logger.WithContext(ctx).Errorf("Failed to roll back creation of user %s: %v", user.Email, rollbackErr)
+		}
 		return err
 	}
+	return tx.Commit().Error
+}
+
+// missingRolesMessage names the missing roles, e.g. "Role 42 does not exist" or "Roles 42, 99 do not exist"
+func missingRolesMessage(missing []uint) string {
+	if len(missing) == 1 {
+		return fmt.Sprintf("Role %d does not exist", missing[0])
+	}
+	ids := make([]string, len(missing))
+	for i, id := range missing {
+		ids[i] = strconv.FormatUint(uint64(id), 10)
+	}
+	return fmt.Sprintf("Roles %s do not exist", strings.Join(ids, ", "))
+}
+
+// VerifyEmail marks the owner of the verification token as verified.
+// Verifying an already verified user is a no-op.
+func (service *userServiceImpl) VerifyEmail(ctx context.Context, token string) error {
+	user, err := service.repo.FindByField(ctx, "token", utils.HashToken(token))
+	if err != nil || !hasTokenFor(user, constants.TOKEN_PURPOSE_VERIFY_EMAIL) {
+		return apperror.NewNotFoundError("Invalid token")
+	}
 
+	if user.VerifiedAt != nil {
+		return nil
+	}
+
+	if user.ExpiredAt == nil || time.Now().Unix() > *user.ExpiredAt {
+		return apperror.NewTokenExpiredError("Token has expired")
+	}
+
+	now := time.Now()
+	user.VerifiedAt = &now
+	clearUserToken(user)
+
+	if err := service.repo.Update(ctx, user); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to mark user ID %d as verified: %v", user.ID, err)
+		return apperror.NewDBUpdateError("Failed to verify email")
+	}
+
+	if err := CacheInvalidate(ctx, service.redisService, profileCacheKey(user.ID)); err != nil {
+		logger.WithContext(ctx).Warnf("Failed to invalidate cached profile for user ID %d: %v", user.ID, err)
+	}
 	return nil
 }
 
-func (service *userServiceImpl) ResetPassword(ctx context.Context, input *dto.ResetPasswordInput) (*models.User, error) {
-	user, err := service.repo.FindByField(ctx, "token", input.Token)
+// ResendVerification mails a fresh verification link to an unverified user.
+// Requests for the same email are throttled by a cooldown kept in the cache. Unknown and
+// already verified emails are answered the same way as valid ones so they cannot be probed.
+func (service *userServiceImpl) ResendVerification(ctx context.Context, input *dto.ResendVerificationInput) error {
+	email := utils.NormalizeEmail(input.Email)
+	cooldownKey := constants.VERIFICATION_RESEND + email
+
+	recentlySent, err := service.redisService.Exists(ctx, cooldownKey)
 	if err != nil {
+		logger.WithContext(ctx).Warnf("Failed to check verification resend cooldown for %s: %v", email, err)
+	}
+	if recentlySent {
+		return apperror.New(http.StatusTooManyRequests, 429, "Please wait before requesting another verification email")
+	}
+	if err := service.redisService.Set(ctx, cooldownKey, "1", service.resendCooldown); err != nil {
+		logger.WithContext(ctx).Warnf("Failed to set verification resend cooldown for %s: %v", email, err)
+	}
+
+	user, err := service.repo.FindByField(ctx, "email", email)
+	if err != nil {
+		logger.WithContext(ctx).Warnf("Verification resend requested for unknown email: %s", email)
+		return nil
+	}
+	if user.VerifiedAt != nil {
+		return nil
+	}
+
+	token := setVerificationToken(user)
+	if err := service.repo.Update(ctx, user); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to save verification token for user ID %d: %v", user.ID, err)
+		return apperror.NewDBUpdateError("Failed to save verification token")
+	}
+
+	return service.mailerService.SendMailVerification(NewMailData(user, token))
+}
+
+// setVerificationToken gives the user a new verification token valid for VERIFICATION_TOKEN_TTL and returns it
+func setVerificationToken(user *models.User) string {
+	token := utils.GenerateRandomString(USER_TOKEN_LENGTH)
+	setUserToken(user, constants.TOKEN_PURPOSE_VERIFY_EMAIL, token, time.Now().Add(VERIFICATION_TOKEN_TTL))
+	return token
+}
+
+// setUserToken stores the hash of the token in the user, valid for purpose until expiresAt. The token itself is only
+// ever mailed to the user, so a leaked database holds no working verification or reset link
+func setUserToken(user *models.User, purpose string, token string, expiresAt time.Time) {
+	hash := utils.HashToken(token)
+	expiredAt := expiresAt.Unix()
+	user.Token = &hash
+	user.ExpiredAt = &expiredAt
+	user.TokenPurpose = &purpose
+}
+
+// clearUserToken removes the token of the user once it has been used
+func clearUserToken(user *models.User) {
+	user.Token = nil
+	user.ExpiredAt = nil
+	user.TokenPurpose = nil
+}
+
+// hasTokenFor reports whether the token stored in the user was issued for purpose. Verification and reset
+// tokens share the token column, so a verification link must not be accepted to reset the password
+func hasTokenFor(user *models.User, purpose string) bool {
+	return user.TokenPurpose != nil && *user.TokenPurpose == purpose
+}
+
+// ForgotPassword stores a reset token for the user with the email and mails them the link in the background.
+// It answers the same whether or not the email is registered: the token is generated either way, an unknown
+// email is only logged, and both take at least forgotPasswordMin so the time spent saving the token tells nothing.
+// A failure to look up the user or to save the token is still reported, as it is not caused by the email.
+// Requests for an email are accepted at most once per resetCooldown, unless it is zero, and FORGOT_PASSWORD_MAX_REQUESTS times
+// per FORGOT_PASSWORD_WINDOW. Both limits apply to unknown emails too, so they reveal nothing either
+func (service *userServiceImpl) ForgotPassword(ctx context.Context, input *dto.ForgotPasswordInput) error {
+	email := utils.NormalizeEmail(input.Email)
+
+	// The cooldown is taken with SetNX, so of concurrent requests for an email only one gets through
+	if service.resetCooldown > 0 {
+		acquired, err := service.redisService.SetNX(ctx, constants.RESET_COOLDOWN+email, "1", service.resetCooldown)
+		if err != nil {
+			logger.WithContext(ctx).Warnf("Failed to set password reset cooldown for %s: %v", email, err)
+		} else if !acquired {
+			return apperror.NewTooManyRequestsError("Please wait a few minutes before requesting another password reset email")
+		}
+	}
+
+	count, err := service.redisService.Incr(ctx, constants.FORGOT_PASSWORD+email, FORGOT_PASSWORD_WINDOW)
+	if err != nil {
+		logger.WithContext(ctx).Warnf("Failed to count password reset requests for email %s: %v", email, err)
+	} else if count > FORGOT_PASSWORD_MAX_REQUESTS {
+		logger.WithContext(ctx).Warnf("Forgot password rejected - too many requests for email: %s", email)
+		return apperror.NewTooManyAttemptsError("Too many password reset requests, please try again later")
+	}
+
+	defer waitUntil(ctx, time.Now().Add(service.forgotPasswordMin))
+	token := utils.GenerateRandomString(USER_TOKEN_LENGTH)
+
+	user, err := service.repo.FindByField(ctx, "email", email)
+	if err != nil {
+		appErr, isAppErr := apperror.ToAppError(err)
+		if isAppErr && appErr.Code == apperror.ErrNotFound {
+			logger.WithContext(ctx).Warnf("Forgot password attempt for non-existent email: %s", email)
+			return nil
+		}
+		logger.WithContext(ctx).Errorf("Forgot password failed for email %s: %v", email, err)
+		return apperror.NewDBQueryError("Failed to process forgot password request")
+	}
+
+	setUserToken(user, constants.TOKEN_PURPOSE_RESET_PASSWORD, token, time.Now().Add(PASSWORD_RESET_TOKEN_TTL))
+	if err := service.repo.Update(ctx, user); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to update user with reset token: %v", err)
+		return apperror.NewDBUpdateError("Failed to process forgot password request")
+	}
+
+	// The mail is sent in the background: how long the mail server takes would tell registered emails apart
+	data := NewMailData(user, token)
+	service.backgroundService.Run(ctx, func(ctx context.Context) {
+		if err := service.mailerService.SendMailForgotPassword(data); err != nil {
+			logger.WithContext(ctx).Errorf("Failed to send password reset email to %s: %v", data.Email, err)
+		}
+	})
+	return nil
+}
+
+// waitUntil returns once deadline has passed or ctx is done
+func waitUntil(ctx context.Context, deadline time.Time) {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+func (service *userServiceImpl) ResetPassword(ctx context.Context, input *dto.ResetPasswordInput) (*models.User, error) {
+	tokenHash := utils.HashToken(input.Token)
+	user, err := service.repo.FindByField(ctx, "token", tokenHash)
+	if err != nil || !hasTokenFor(user, constants.TOKEN_PURPOSE_RESET_PASSWORD) {
 		return nil, apperror.NewNotFoundError("Invalid token")
 	}
 
@@ -82,14 +400,26 @@ func (service *userServiceImpl) ResetPassword(ctx context.Context, input *dto.Re
 	}
 
 	user.Password = newPassword
-	user.Token = nil
-	user.ExpiredAt = nil
+	user.MustChangePassword = false
+	clearUserToken(user)
+	// The reset link reached the user's inbox, which proves they own the address
+	if user.VerifiedAt == nil {
+		now := time.Now()
+		user.VerifiedAt = &now
+	}
 
-	err = service.repo.Update(ctx, user)
+	// The token is consumed by the same UPDATE that sets the password, which only matches while the token
+	// is still stored: a concurrent request with the same token that got here first makes this one fail
+	consumed, err := service.repo.UpdateIfToken(ctx, user, tokenHash)
 	if err != nil {
 		logger.WithContext(ctx).Errorf("Failed to update user password: %v", err)
 		return nil, apperror.NewDBUpdateError("Failed to update password")
 	}
+	if !consumed {
+		logger.WithContext(ctx).Warnf("Reset token of user ID %d was consumed by a concurrent request", user.ID)
+		return nil, apperror.NewNotFoundError("Invalid token")
+	}
+	service.notifyPasswordChanged(ctx, user)
 	return user, nil
 }
 
@@ -116,21 +446,582 @@ func (service *userServiceImpl) ChangePassword(ctx context.Context, userId uint,
 		return nil, apperror.NewPasswordUnchangedError("New password must be different from old password")
 	}
 
+	if containsEmailLocalPart(input.NewPassword, user.Email) {
+		return nil, apperror.NewValidationError("Validation failed", []apperror.FieldError{
+			{Field: "new_password", Message: "new_password must not contain your email address"},
+		})
+	}
+
+	mustChangePassword := user.MustChangePassword
 	user.Password = newPassword
+	user.MustChangePassword = false
 	err = service.repo.Update(ctx, user)
 	if err != nil {
 		logger.WithContext(ctx).Errorf("Failed to update user password: %v", err)
 		return nil, apperror.NewDBUpdateError("Failed to update password")
 	}
+
+	// The password is already changed at this point, so a failure to revoke sessions is logged rather than returned
+	if _, err := service.refreshTokenService.DeleteAllByUserID(ctx, user.ID); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to revoke sessions after password change for user ID %d: %v", user.ID, err)
+	}
+	// The cached profile still carries the flag that blocks the user's requests
+	if mustChangePassword {
+		if err := CacheInvalidate(ctx, service.redisService, profileCacheKey(user.ID)); err != nil {
+			logger.WithContext(ctx).Warnf("Failed to invalidate cached profile for user ID %d: %v", user.ID, err)
+		}
+	}
+	service.notifyPasswordChanged(ctx, user)
 	return user, nil
 }
 
-func (service *userServiceImpl) GetProfile(ctx context.Context, userID uint) (*models.User, error) {
-	user, err := service.repo.GetByID(ctx, userID)
+// ForceResetPassword replaces the password of the user with a random temporary one and returns it.
+// All sessions of the user are revoked and the user must change the password after the next login.
+func (service *userServiceImpl) ForceResetPassword(ctx context.Context, id uint) (string, error) {
+	user, err := service.repo.GetByID(ctx, id)
 	if err != nil {
-		return nil, apperror.NewNotFoundError("User not found")
+		return "", apperror.NewNotFoundError("User not found")
+	}
+
+	temporaryPassword := utils.GenerateRandomString(TEMPORARY_PASSWORD_LENGTH)
+	hashedPassword, err := service.bcryptService.HashPassword(temporaryPassword)
+	if err != nil {
+		return "", apperror.NewPasswordHashFailedError("Failed to hash password")
+	}
+
+	user.Password = hashedPassword
+	user.MustChangePassword = true
+	if err := service.repo.Update(ctx, user); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to force reset password for user ID %d: %v", id, err)
+		return "", apperror.NewDBUpdateError("Failed to update password")
+	}
+
+	// Unlike ChangePassword, old sessions must not outlive a reset done on behalf of the user
+	if _, err := service.refreshTokenService.DeleteAllByUserID(ctx, id); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to revoke sessions after force reset for user ID %d: %v", id, err)
+		return "", err
+	}
+	if err := CacheInvalidate(ctx, service.redisService, profileCacheKey(id)); err != nil {
+		logger.WithContext(ctx).Warnf("Failed to invalidate cached profile for user ID %d: %v", id, err)
+	}
+
+	service.notifyPasswordChanged(ctx, user)
+
+	logger.WithContext(ctx).Infof("Forced password reset for user ID %d", id)
+	return temporaryPassword, nil
+}
+
+// UnlockUser clears the failed login count of the user, so a locked-out account can log in again at once
+func (service *userServiceImpl) UnlockUser(ctx context.Context, id uint) error {
+	user, err := service.repo.GetByID(ctx, id)
+	if err != nil {
+		return apperror.NewNotFoundError("User not found")
+	}
+
+	// The count is kept under the normalized email the user logs in with, as in the login of AuthService
+	if err := service.redisService.Delete(ctx, constants.LOGIN_FAIL+utils.NormalizeEmail(user.Email)); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to clear failed logins for user ID %d: %v", id, err)
+		return apperror.NewCacheDeleteError("Failed to unlock user")
+	}
+
+	logger.WithContext(ctx).Infof("Unlocked user ID %d", id)
+	return nil
+}
+
+// notifyPasswordChanged mails the user that their password was changed. The change is already saved, so a failure is only logged
+func (service *userServiceImpl) notifyPasswordChanged(ctx context.Context, user *models.User) {
+	if err := service.notificationService.NotifyPasswordChanged(ctx, user); err != nil {
+		logger.WithContext(ctx).Warnf("Failed to notify user ID %d of the password change: %v", user.ID, err)
+	}
+}
+
+func (service *userServiceImpl) GetProfile(ctx context.Context, userID uint) (*dto.ProfileResponse, error) {
+	return CacheGetOrRefresh(ctx, service.redisService, profileCacheKey(userID), service.profileCacheTTL, service.profileRefreshAhead, func(ctx context.Context) (*dto.ProfileResponse, error) {
+		user, err := service.repo.GetByIDWithRoles(ctx, userID)
+		if err != nil {
+			return nil, apperror.NewNotFoundError("User not found")
+		}
+		logger.WithContext(ctx).Infof("Retrieved profile for user ID %d", userID)
+		return dto.NewProfileResponse(withDefaultPreferences(user)), nil
+	})
+}
+
+func (service *userServiceImpl) GetUsers(ctx context.Context, opts dto.ListOptions, filter dto.UserFilterInput) (*dto.Pagination[*models.User], error) {
+	users, err := service.repo.GetUsers(ctx, opts, filter)
+	if err != nil {
+		if appErr, ok := apperror.ToAppError(err); ok && appErr.Code == apperror.ErrParseError {
+			return nil, err
+		}
+		logger.WithContext(ctx).Errorf("Failed to list users: %v", err)
+		return nil, apperror.NewDBQueryError("Failed to get users")
+	}
+	return users, nil
+}
+
+// SearchUsers returns up to limit users matching query, most relevant first.
+// A non-positive limit means DEFAULT_USER_SEARCH_LIMIT
+func (service *userServiceImpl) SearchUsers(ctx context.Context, query string, limit int) ([]*models.User, error) {
+	if limit <= 0 {
+		limit = DEFAULT_USER_SEARCH_LIMIT
+	}
+	users, err := service.repo.Search(ctx, query, limit)
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to search users: %v", err)
+		return nil, apperror.NewDBQueryError("Failed to search users")
+	}
+	return users, nil
+}
+
+// ExportUsers writes the users matching filter to w as CSV or XLSX, in ID order.
+// Users are read and written in batches, and w is flushed after each batch, so the export is streamed
+// rather than held in memory. Once a batch has been written, an error can only cut the export short.
+func (service *userServiceImpl) ExportUsers(ctx context.Context, filter dto.UserFilterInput, format string, w io.Writer) error {
+	var rows rowWriter
+	switch format {
+	case constants.EXPORT_FORMAT_CSV:
+		rows = &csvRowWriter{writer: csv.NewWriter(w)}
+	case constants.EXPORT_FORMAT_XLSX:
+		sheet, err := xlsx.NewStreamWriter(w, "Users")
+		if err != nil {
+			logger.WithContext(ctx).Errorf("Failed to start users export: %v", err)
+			return apperror.NewInternalServerError("Failed to export users")
+		}
+		rows = sheet
+	default:
+		return apperror.NewBadRequestError("Unsupported export format")
+	}
+
+	if err := rows.WriteRow(userExportColumns); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to write users export: %v", err)
+		return apperror.NewInternalServerError("Failed to export users")
+	}
+	err := service.repo.IterateUsers(ctx, filter, USER_EXPORT_BATCH_SIZE, func(users []*models.User) error {
+		for _, user := range users {
+			if err := rows.WriteRow(userExportRow(user)); err != nil {
+				return err
+			}
+		}
+		if err := rows.Flush(); err != nil {
+			return err
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		logger.WithContext(ctx).Errorf("Failed to export users: %v", err)
+		return apperror.NewDBQueryError("Failed to export users")
+	}
+	if err := rows.Close(); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to finish users export: %v", err)
+		return apperror.NewInternalServerError("Failed to export users")
+	}
+	return nil
+}
+
+// ImportUsers creates users from CSV rows with the columns of userImportColumns, validated like CreateUser.
+// Invalid rows, emails repeated in the file and emails that are already registered are skipped and reported.
+// The valid rows are created in one transaction, without sending verification emails; a dry run only reports.
+// A malformed file, other than a row with the wrong number of columns, is rejected as a whole.
+func (service *userServiceImpl) ImportUsers(ctx context.Context, r io.Reader, dryRun bool) (*dto.UserImportReport, error) {
+	reader := csv.NewReader(skipBOM(r))
+	header, err := reader.Read()
+	if err != nil {
+		return nil, importReadError(err)
+	}
+	columns, err := userImportColumnIndexes(header)
+	if err != nil {
+		return nil, err
+	}
+
+	type importRow struct {
+		row   int
+		email string
+		input dto.CreateUserInput
+	}
+	report := &dto.UserImportReport{DryRun: dryRun, Skipped: []dto.UserImportRowError{}}
+	skip := func(row int, reason string, fields ...apperror.FieldError) {
+		report.Skipped = append(report.Skipped, dto.UserImportRowError{Row: row, Reason: reason, Fields: fields})
+	}
+	var rows []importRow
+	firstRowOf := map[string]int{}
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if errors.Is(err, csv.ErrFieldCount) {
+			report.Rows++
+			skip(row, constants.IMPORT_ROW_INVALID, apperror.FieldError{Field: "row", Message: fmt.Sprintf("Row has %d columns, expected %d", len(record), len(header))})
+			continue
+		}
+		if err != nil {
+			return nil, importReadError(err)
+		}
+		report.Rows++
+
+		input := userImportInput(record, columns)
+		if err := binding.Validator.ValidateStruct(&input); err != nil {
+			skip(row, constants.IMPORT_ROW_INVALID, utils.TranslateValidationErrors(err, input).Fields...)
+			continue
+		}
+		email := utils.NormalizeEmail(input.Email)
+		if first, ok := firstRowOf[email]; ok {
+			skip(row, constants.IMPORT_ROW_DUPLICATE_IN_FILE, apperror.FieldError{Field: "email", Message: fmt.Sprintf("email is already used on row %d", first)})
+			continue
+		}
+		firstRowOf[email] = row
+		rows = append(rows, importRow{row: row, email: email, input: input})
+	}
+
+	emails := make([]string, len(rows))
+	for i, row := range rows {
+		emails[i] = row.email
+	}
+	existing, err := service.repo.FindExistingEmails(ctx, emails)
+	if err != nil {
+		return nil, apperror.NewDBQueryError("Failed to check existing emails")
+	}
+	registered := make(map[string]bool, len(existing))
+	for _, email := range existing {
+		registered[email] = true
+	}
+	rows = slices.DeleteFunc(rows, func(row importRow) bool {
+		if !registered[row.email] {
+			return false
+		}
+		skip(row.row, constants.IMPORT_ROW_ALREADY_REGISTERED, apperror.FieldError{Field: "email", Message: "email is already registered"})
+		return true
+	})
+	slices.SortFunc(report.Skipped, func(a, b dto.UserImportRowError) int { return a.Row - b.Row })
+
+	if dryRun {
+		report.Imported = len(rows)
+		return report, nil
+	}
+
+	users := make([]*models.User, 0, len(rows))
+	mails := make([]MailData, 0, len(rows))
+	for _, row := range rows {
+		hashedPassword, err := service.bcryptService.HashPassword(row.input.Password)
+		if err != nil {
+			return nil, apperror.NewPasswordHashFailedError("Failed to hash password")
+		}
+		birthday, err := utils.ParseDateStringYYYYMMDD(*row.input.Birthday)
+		if err != nil {
+			return nil, err
+		}
+		user := &models.User{
+			Email:    row.email,
+			Password: hashedPassword,
+			Name:     row.input.Name,
+			Birthday: birthday,
+			Address:  row.input.Address,
+			Gender:   row.input.Gender,
+		}
+		token := setVerificationToken(user)
+		users = append(users, user)
+		mails = append(mails, NewMailData(user, token))
+	}
+	if err := service.repo.CreateUsers(ctx, users, USER_IMPORT_BATCH_SIZE); err != nil {
+		return nil, apperror.NewDBInsertError("Failed to import users")
+	}
+
+	// Mailing every imported user would outlast the request, so the verification mails are sent in the background
+	service.backgroundService.Run(ctx, func(ctx context.Context) {
+		service.sendVerificationMails(ctx, mails)
+	})
+
+	report.Imported = len(users)
+	logger.WithContext(ctx).Infof("Imported %d users, skipped %d rows", report.Imported, len(report.Skipped))
+	return report, nil
+}
+
+// sendVerificationMails sends each of the verification mails in turn. The users are already saved, so failures are only logged.
+// When ctx is done, on shutdown, it stops and logs the addresses not mailed yet; those users can ask for the mail again
+func (service *userServiceImpl) sendVerificationMails(ctx context.Context, mails []MailData) {
+	for i, data := range mails {
+		if ctx.Err() != nil {
+			unsent := make([]string, 0, len(mails)-i)
+			for _, data := range mails[i:] {
+				unsent = append(unsent, data.Email)
+			}
+			logger.WithContext(ctx).Errorf("Stopped sending verification emails on shutdown, %d not sent: %s", len(unsent), strings.Join(unsent, ", "))
+			return
+		}
+		if err := service.mailerService.SendMailVerification(data); err != nil {
+			logger.WithContext(ctx).Warnf("Failed to send verification email to %s: %v", data.Email, err)
+		}
+	}
+}
+
+// userImportColumnIndexes maps each of userImportColumns to its index in header.
+// Column names are matched ignoring case and surrounding spaces; unknown, repeated and missing columns are rejected
+func userImportColumnIndexes(header []string) (map[string]int, error) {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(userImportColumns, name) {
+			return nil, apperror.NewBadRequestError(fmt.Sprintf("Unknown column %q", name))
+		}
+		if _, ok := columns[name]; ok {
+			return nil, apperror.NewBadRequestError(fmt.Sprintf("Column %q appears more than once", name))
+		}
+		columns[name] = i
+	}
+	for _, name := range userImportColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, apperror.NewBadRequestError(fmt.Sprintf("Missing column %q", name))
+		}
+	}
+	return columns, nil
+}
+
+// userImportInput reads a row into the input of CreateUser. Values other than the password are trimmed;
+// an empty birthday or address is left nil and a gender that is not a number is kept invalid, for the validator to report
+func userImportInput(record []string, columns map[string]int) dto.CreateUserInput {
+	value := func(column string) string {
+		return strings.TrimSpace(record[columns[column]])
+	}
+	optional := func(column string) *string {
+		if v := value(column); v != "" {
+			return &v
+		}
+		return nil
+	}
+	gender, err := strconv.ParseInt(value("gender"), 10, 16)
+	if err != nil {
+		gender = -1
+	}
+	return dto.CreateUserInput{
+		Email:    value("email"),
+		Password: record[columns["password"]],
+		Name:     value("name"),
+		Birthday: optional("birthday"),
+		Address:  optional("address"),
+		Gender:   int16(gender),
+	}
+}
+
+// importReadError converts an error reading the uploaded CSV into the error returned for the whole import
+func importReadError(err error) error {
+	var tooLarge *http.MaxBytesError
+	var parseErr *csv.ParseError
+	switch {
+	case errors.As(err, &tooLarge):
+		return apperror.NewPayloadTooLargeError(fmt.Sprintf("File must not be larger than %d bytes", tooLarge.Limit))
+	case errors.Is(err, io.EOF):
+		return apperror.NewBadRequestError("File is empty")
+	case errors.As(err, &parseErr):
+		return apperror.NewBadRequestError(fmt.Sprintf("Malformed CSV on line %d: %v", parseErr.Line, parseErr.Err))
+	default:
+		return apperror.Wrap(http.StatusBadRequest, apperror.ErrBadRequest, "Failed to read the file", err)
+	}
+}
+
+// skipBOM drops a UTF-8 byte order mark, as written by spreadsheet programs, from the start of r
+func skipBOM(r io.Reader) io.Reader {
+	reader := bufio.NewReader(r)
+	if bom, err := reader.Peek(3); err == nil && string(bom) == "\ufeff" {
+		_, _ = reader.Discard(3)
+	}
+	return reader
+}
+
+// userExportRow returns the values of user for userExportColumns. Text entered by users is escaped with escapeFormula
+func userExportRow(user *models.User) []any {
+	var birthday, address any
+	if user.Birthday != nil {
+		birthday = user.Birthday.Format(time.DateOnly)
+	}
+	if user.Address != nil {
+		address = escapeFormula(*user.Address)
+	}
+	return []any{user.ID, escapeFormula(user.Name), escapeFormula(user.Email), user.Gender, birthday, address, user.CreatedAt.UTC().Format(time.RFC3339)}
+}
+
+// escapeFormula prefixes value with a quote if it starts with a character that makes spreadsheet applications
+// read a cell as a formula, so an exported name such as =HYPERLINK(...) is shown as text rather than evaluated
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// rowWriter writes the rows of an export in one of the export formats
+type rowWriter interface {
+	WriteRow(values []any) error
+	Flush() error
+	Close() error
+}
+
+// csvRowWriter writes rows as CSV, nil values as empty fields
+type csvRowWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvRowWriter) WriteRow(values []any) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		if value != nil {
+			record[i] = fmt.Sprint(value)
+		}
+	}
+	return w.writer.Write(record)
+}
+
+func (w *csvRowWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func (w *csvRowWriter) Close() error {
+	return w.Flush()
+}
+
+// GetUser returns the user with the given ID. A soft-deleted user is only returned when includeDeleted is set;
+// otherwise it yields a 404 that says so, so admins can tell it apart from a user that never existed.
+func (service *userServiceImpl) GetUser(ctx context.Context, id uint, includeDeleted bool) (*models.User, error) {
+	user, err := service.findUnscoped(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletedAt.Valid && !includeDeleted {
+		return nil, apperror.NewNotFoundError("User has been deleted")
+	}
+	return user, nil
+}
+
+// RestoreUser clears the soft delete of the user. Restoring a user that is not deleted is a no-op.
+func (service *userServiceImpl) RestoreUser(ctx context.Context, id uint) (*models.User, error) {
+	user, err := service.findUnscoped(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !user.DeletedAt.Valid {
+		return user, nil
+	}
+
+	if err := service.repo.Restore(ctx, id); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to restore user ID %d: %v", id, err)
+		return nil, apperror.NewDBUpdateError("Failed to restore user")
+	}
+	user.DeletedAt = gorm.DeletedAt{}
+
+	if err := CacheInvalidate(ctx, service.redisService, profileCacheKey(id)); err != nil {
+		logger.WithContext(ctx).Warnf("Failed to invalidate cached profile for user ID %d: %v", id, err)
+	}
+	logger.WithContext(ctx).Infof("Restored user ID %d", id)
+	return user, nil
+}
+
+// DeleteUsers soft-deletes the given users and reports which IDs were deleted and which did not exist.
+// Sessions and cached profiles of the deleted users are dropped so they are signed out at once.
+func (service *userServiceImpl) DeleteUsers(ctx context.Context, ids []uint) (*dto.BulkDeleteUsersResult, error) {
+	requested := slices.Compact(slices.Sorted(slices.Values(ids)))
+	deleted, err := service.repo.DeleteUsers(ctx, requested)
+	if err != nil {
+		return nil, apperror.NewDBDeleteError("Failed to delete users")
+	}
+
+	result := &dto.BulkDeleteUsersResult{Deleted: []uint{}, NotFound: []uint{}}
+	for _, id := range requested {
+		if !slices.Contains(deleted, id) {
+			result.NotFound = append(result.NotFound, id)
+			continue
+		}
+		result.Deleted = append(result.Deleted, id)
+		if _, err := service.refreshTokenService.DeleteAllByUserID(ctx, id); err != nil {
+			logger.WithContext(ctx).Errorf("Failed to revoke sessions of deleted user ID %d: %v", id, err)
+		}
+		if err := CacheInvalidate(ctx, service.redisService, profileCacheKey(id)); err != nil {
+			logger.WithContext(ctx).Warnf("Failed to invalidate cached profile for user ID %d: %v", id, err)
+		}
+	}
+
+	logger.WithContext(ctx).Infof("Deleted users %v, not found %v", result.Deleted, result.NotFound)
+	return result, nil
+}
+
+// GetUserRoles returns the roles of the user with the permissions each grants. The roles are loaded
+// with the user in one preload query; permissions come from constants.ROLE_PERMISSIONS, not the database
+func (service *userServiceImpl) GetUserRoles(ctx context.Context, userID uint) ([]dto.UserRoleResponse, error) {
+	user, err := service.repo.GetByIDWithRoles(ctx, userID)
+	if err != nil {
+		appErr, isAppErr := apperror.ToAppError(err)
+		if isAppErr && appErr.Code == apperror.ErrNotFound {
+			return nil, apperror.NewNotFoundError("User not found")
+		}
+		logger.WithContext(ctx).Errorf("Failed to get roles of user ID %d: %v", userID, err)
+		return nil, apperror.NewDBQueryError("Failed to get user roles")
+	}
+
+	roles := make([]dto.UserRoleResponse, len(user.Roles))
+	for i, role := range user.Roles {
+		roles[i] = dto.UserRoleResponse{
+			ID:          role.ID,
+			Name:        role.Name,
+			Permissions: append([]string{}, constants.ROLE_PERMISSIONS[role.Name]...),
+		}
+	}
+	return roles, nil
+}
+
+// AssignRoles gives the user the roles. Every role must exist, otherwise nothing changes and a 404 lists the missing IDs
+func (service *userServiceImpl) AssignRoles(ctx context.Context, userID uint, roleIDs []uint) error {
+	return service.changeRoles(ctx, userID, roleIDs, "assign", service.repo.AssignRoles)
+}
+
+// RemoveRoles takes the roles away from the user. Every role must exist, otherwise nothing changes and a 404 lists the missing IDs
+func (service *userServiceImpl) RemoveRoles(ctx context.Context, userID uint, roleIDs []uint) error {
+	return service.changeRoles(ctx, userID, roleIDs, "remove", service.repo.RemoveRoles)
+}
+
+// changeRoles applies a role change to an existing user and drops the cached roles and profile.
+// Failing to drop the cached roles is an error, as the old permissions would otherwise stay in effect until the cache expires
+func (service *userServiceImpl) changeRoles(ctx context.Context, userID uint, roleIDs []uint, action string, change func(ctx context.Context, userID uint, roleIDs []uint) error) error {
+	if _, err := service.repo.GetByID(ctx, userID); err != nil {
+		appErr, isAppErr := apperror.ToAppError(err)
+		if isAppErr && appErr.Code == apperror.ErrNotFound {
+			return apperror.NewNotFoundError("User not found")
+		}
+		logger.WithContext(ctx).Errorf("Failed to get user ID %d: %v", userID, err)
+		return apperror.NewDBQueryError("Failed to get user")
+	}
+
+	if err := change(ctx, userID, roleIDs); err != nil {
+		appErr, isAppErr := apperror.ToAppError(err)
+		if isAppErr && appErr.Code == apperror.ErrNotFound {
+			return appErr
+		}
+		logger.WithContext(ctx).Errorf("Failed to %s roles %v for user ID %d: %v", action, roleIDs, userID, err)
+		return apperror.NewDBUpdateError("Failed to update user roles")
+	}
+
+	if err := service.redisService.Delete(ctx, userRolesCacheKey(userID)); err != nil {
+		logger.WithContext(ctx).Errorf("Failed to invalidate cached roles for user ID %d: %v", userID, err)
+		return apperror.NewCacheDeleteError("Roles were updated but the cached roles could not be cleared")
+	}
+	if err := CacheInvalidate(ctx, service.redisService, profileCacheKey(userID)); err != nil {
+		logger.WithContext(ctx).Warnf("Failed to invalidate cached profile for user ID %d: %v", userID, err)
+	}
+	logger.WithContext(ctx).Infof("Updated roles of user ID %d: %s %v", userID, action, roleIDs)
+	return nil
+}
+
+// findUnscoped loads a user including soft-deleted ones, mapping a missing row to 404
+func (service *userServiceImpl) findUnscoped(ctx context.Context, id uint) (*models.User, error) {
+	user, err := service.repo.GetByIDUnscoped(ctx, id)
+	if err != nil {
+		appErr, isAppErr := apperror.ToAppError(err)
+		if isAppErr && appErr.Code == apperror.ErrNotFound {
+			return nil, apperror.NewNotFoundError("User not found")
+		}
+		logger.WithContext(ctx).Errorf("Failed to get user ID %d: %v", id, err)
+		return nil, apperror.NewDBQueryError("Failed to get user")
 	}
-	logger.WithContext(ctx).Infof("Retrieved profile for user ID %d", userID)
 	return user, nil
 }
 
@@ -163,5 +1054,65 @@ func (service *userServiceImpl) UpdateProfile(ctx context.Context, userID uint,
 		logger.WithContext(ctx).Errorf("Failed to update user profile: %v", err)
 		return apperror.NewDBUpdateError("Failed to update profile")
 	}
+
+	if err := CacheInvalidate(ctx, service.redisService, profileCacheKey(user.ID)); err != nil {
+		logger.WithContext(ctx).Warnf("Failed to invalidate cached profile for user ID %d: %v", user.ID, err)
+	}
 	return nil
 }
+
+// cacheProfile stores the profile of the user under its profile cache key, in the form read by GetProfile
+func cacheProfile(ctx context.Context, redisService RedisService, user *models.User, ttl, refreshAhead time.Duration) error {
+	return CacheSetRefreshAhead(ctx, redisService, profileCacheKey(user.ID), dto.NewProfileResponse(withDefaultPreferences(user)), ttl, refreshAhead)
+}
+
+// profileCacheKey returns the cache key of the profile of the user with the given ID
+func profileCacheKey(id uint) string {
+	return constants.PROFILE + strconv.FormatUint(uint64(id), 10)
+}
+
+// profileCacheTTLFromEnv reads PROFILE_CACHE_TTL_MINUTES. A value that is not a positive
+// number of minutes is logged and PROFILE_CACHE_TTL is used instead
+func profileCacheTTLFromEnv() time.Duration {
+	value := utils.GetEnv("PROFILE_CACHE_TTL_MINUTES", "")
+	if value == "" {
+		return PROFILE_CACHE_TTL
+	}
+	minutes, err := strconv.Atoi(value)
+	if err != nil || minutes <= 0 {
+		logger.Warnf("Invalid PROFILE_CACHE_TTL_MINUTES %q, using the default of %s", value, PROFILE_CACHE_TTL)
+		return PROFILE_CACHE_TTL
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// profileRefreshAheadFromEnv reads PROFILE_CACHE_REFRESH_AHEAD_SECONDS, where 0 disables refreshing ahead.
+// A value that is not a number of seconds is logged and PROFILE_CACHE_REFRESH_AHEAD is used instead,
+// a value not shorter than ttl is logged and refreshing ahead is disabled
+func profileRefreshAheadFromEnv(ttl time.Duration) time.Duration {
+	value := utils.GetEnv("PROFILE_CACHE_REFRESH_AHEAD_SECONDS", "")
+	if value == "" {
+		return min(PROFILE_CACHE_REFRESH_AHEAD, ttl/2)
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		logger.Warnf("Invalid PROFILE_CACHE_REFRESH_AHEAD_SECONDS %q, using the default of %s", value, PROFILE_CACHE_REFRESH_AHEAD)
+		return min(PROFILE_CACHE_REFRESH_AHEAD, ttl/2)
+	}
+	refreshAhead := time.Duration(seconds) * time.Second
+	if refreshAhead >= ttl {
+		logger.Warnf("PROFILE_CACHE_REFRESH_AHEAD_SECONDS %q is not shorter than the profile cache TTL of %s, refreshing ahead is disabled", value, ttl)
+		return 0
+	}
+	return refreshAhead
+}
+
+// containsEmailLocalPart reports whether the password contains the part of the email before the @, ignoring case.
+// Local parts shorter than 3 characters are ignored, as they would rule out too many passwords
+func containsEmailLocalPart(password, email string) bool {
+	localPart, _, _ := strings.Cut(email, "@")
+	if len(localPart) < 3 {
+		return false
+	}
+	return strings.Contains(strings.ToLower(password), strings.ToLower(localPart))
+}
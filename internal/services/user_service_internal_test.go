@@ -0,0 +1,26 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfileCacheKey(t *testing.T) {
+	t.Run("UsesTheDecimalID", func(t *testing.T) {
+		assert.Equal(t, "profile:v2:65", profileCacheKey(65))
+		assert.Equal(t, "profile:v2:4294967296", profileCacheKey(4294967296))
+	})
+
+	t.Run("DistinctIDsGiveDistinctKeys", func(t *testing.T) {
+		// 65 is 'A' as a rune, and 6 and 5 must not run into 65 either
+		ids := []uint{0, 1, 5, 6, 10, 65, 100, 1114112, 4294967296}
+		keys := make(map[string]uint, len(ids))
+		for _, id := range ids {
+			key := profileCacheKey(id)
+			other, exists := keys[key]
+			assert.False(t, exists, "IDs %d and %d share key %s", id, other, key)
+			keys[key] = id
+		}
+	})
+}
@@ -1,15 +1,31 @@
 package services_test
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
 	"testing"
+	"testing/iotest"
 	"time"
 
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
 	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
 	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
@@ -39,11 +55,26 @@ func (m *mockBcryptService) HashPasswordWithCost(password string, _ int) (string
 	return m.HashPassword(password)
 }
 
+func (m *mockBcryptService) NeedsRehash(_ string) bool {
+	return false
+}
+
+// mailWithToken matches the MailData of a mail to email carrying a token and its expiry
+func mailWithToken(email string) any {
+	return mock.MatchedBy(func(data services.MailData) bool {
+		return data.Email == email && data.Token != "" && !data.ExpiresAt.IsZero()
+	})
+}
+
 type UserServiceTestSuite struct {
 	suite.Suite
 	db      *gorm.DB
 	repo    *mocks.MockUserRepository
 	mailer  *mocks.MockMailerService
+	redis   *mocks.MockRedisService
+	tokens  *mocks.MockRefreshTokenService
+	notify  *mocks.MockNotificationService
+	tasks   services.BackgroundService
 	service services.UserService
 	bcrypt  services.BcryptService
 }
@@ -58,14 +89,23 @@ func (s *UserServiceTestSuite) SetupTest() {
 	s.db = db
 	s.repo = new(mocks.MockUserRepository)
 	s.mailer = new(mocks.MockMailerService)
+	s.redis = new(mocks.MockRedisService)
+	s.tokens = new(mocks.MockRefreshTokenService)
+	s.notify = new(mocks.MockNotificationService)
 	s.bcrypt = services.NewBcryptService()
-	s.service = services.NewUserService(s.repo, s.bcrypt, s.mailer)
+	s.tasks = services.NewBackgroundService(time.Second)
+	// Only the test of the minimum duration waits for it
+	s.T().Setenv("FORGOT_PASSWORD_MIN_DURATION_MS", "0")
+	s.service = services.NewUserService(s.repo, s.bcrypt, s.mailer, s.redis, s.tokens, s.notify, s.tasks)
 
 }
 
 func (s *UserServiceTestSuite) TearDownTest() {
 	s.repo.AssertExpectations(s.T())
 	s.mailer.AssertExpectations(s.T())
+	s.redis.AssertExpectations(s.T())
+	s.tokens.AssertExpectations(s.T())
+	s.notify.AssertExpectations(s.T())
 }
 
 func (s *UserServiceTestSuite) TestGetProfile() {
@@ -73,21 +113,153 @@ func (s *UserServiceTestSuite) TestGetProfile() {
 		// Arrange
 
 		userID := uint(1)
-		expectedUser := &models.User{ID: 1, Email: "email@example.com", Password: "password123"}
-		s.repo.On("GetByID", mock.Anything, userID).Return(expectedUser, nil).Once()
+		expectedUser := &models.User{
+			ID:       1,
+			Email:    "email@example.com",
+			Password: "password123",
+			Roles:    []models.Role{{ID: 1, Name: "admin"}, {ID: 2, Name: "editor"}},
+		}
+		s.redis.On("Get", mock.Anything, "profile:v2:1").Return("", services.ErrCacheMiss).Once()
+		s.repo.On("GetByIDWithRoles", mock.Anything, userID).Return(expectedUser, nil).Once()
+		s.redis.On("Set", mock.Anything, "profile:v2:1", mock.MatchedBy(func(value string) bool {
+			return strings.Contains(value, `"roles":[{"id":1,"name":"admin"},{"id":2,"name":"editor"}]`) && !strings.Contains(value, "password123")
+		}), services.PROFILE_CACHE_TTL).Return(nil).Once()
+
+		// Act
+		user, err := s.service.GetProfile(context.Background(), userID)
+
+		// Assert
+		s.NoError(err)
+		s.Equal(dto.NewProfileResponse(expectedUser), user)
+	})
+
+	s.T().Run("EmbedsPreferences", func(t *testing.T) {
+		saved := &models.UserPreference{UserID: 3, Timezone: "Asia/Tokyo", Locale: "vi", Theme: "dark"}
+		s.redis.On("Get", mock.Anything, "profile:v2:3").Return("", services.ErrCacheMiss).Once()
+		s.repo.On("GetByIDWithRoles", mock.Anything, uint(3)).Return(&models.User{ID: 3, Preferences: saved}, nil).Once()
+		s.redis.On("Set", mock.Anything, "profile:v2:3", mock.Anything, services.PROFILE_CACHE_TTL).Return(nil).Once()
+
+		user, err := s.service.GetProfile(context.Background(), 3)
+
+		s.NoError(err)
+		s.Equal(&dto.PreferencesResponse{Timezone: "Asia/Tokyo", Locale: "vi", Theme: "dark"}, user.Preferences)
+	})
+
+	s.T().Run("EmbedsDefaultPreferences", func(t *testing.T) {
+		s.redis.On("Get", mock.Anything, "profile:v2:4").Return("", services.ErrCacheMiss).Once()
+		s.repo.On("GetByIDWithRoles", mock.Anything, uint(4)).Return(&models.User{ID: 4}, nil).Once()
+		s.redis.On("Set", mock.Anything, "profile:v2:4", mock.MatchedBy(func(value string) bool {
+			return strings.Contains(value, `"preferences":{"timezone":"UTC","locale":"en","email_notifications":true,"theme":"system"`)
+		}), services.PROFILE_CACHE_TTL).Return(nil).Once()
+
+		user, err := s.service.GetProfile(context.Background(), 4)
+
+		s.NoError(err)
+		s.Require().NotNil(user.Preferences)
+		s.Equal(&dto.PreferencesResponse{Timezone: "UTC", Locale: "en", EmailNotifications: true, Theme: "system"}, user.Preferences)
+	})
+
+	s.T().Run("CacheHit", func(t *testing.T) {
+		// Arrange
+		userID := uint(2)
+		cached := fmt.Sprintf(`{"value":{"id":2,"email":"cached@example.com","name":"Cached","roles":[{"id":1,"name":"admin"}]},"refresh_at":%q}`, time.Now().Add(time.Hour).Format(time.RFC3339))
+		s.redis.On("Get", mock.Anything, "profile:v2:2").Return(cached, nil).Once()
+
+		// Act
+		user, err := s.service.GetProfile(context.Background(), userID)
+
+		// Assert
+		s.NoError(err)
+		s.Equal(uint(2), user.ID)
+		s.Equal("cached@example.com", user.Email)
+		s.Equal("Cached", user.Name)
+		s.Equal([]dto.RoleResponse{{ID: 1, Name: "admin"}}, user.Roles)
+	})
+
+	s.T().Run("StaleCacheRefreshedInBackground", func(t *testing.T) {
+		// Arrange
+		userID := uint(5)
+		cached := fmt.Sprintf(`{"value":{"id":5,"name":"Stale"},"refresh_at":%q}`, time.Now().Add(-time.Second).Format(time.RFC3339))
+		refreshed := make(chan struct{})
+		s.redis.On("Get", mock.Anything, "profile:v2:5").Return(cached, nil).Once()
+		var lockToken string
+		s.redis.On("SetNX", mock.Anything, "cache_refresh:profile:v2:5", mock.AnythingOfType("string"), services.CACHE_REFRESH_LOCK_TTL).Run(func(args mock.Arguments) {
+			lockToken = args.String(2)
+		}).Return(true, nil).Once()
+		s.repo.On("GetByIDWithRoles", mock.Anything, userID).Return(&models.User{ID: 5, Name: "Fresh"}, nil).Once()
+		s.redis.On("SetIfEquals", mock.Anything, "cache_refresh:profile:v2:5", mock.MatchedBy(func(token string) bool {
+			return token == lockToken
+		}), "profile:v2:5", mock.MatchedBy(func(value string) bool {
+			return strings.Contains(value, `"name":"Fresh"`)
+		}), services.PROFILE_CACHE_TTL).Return(true, nil).Once()
+		s.redis.On("Delete", mock.Anything, "cache_refresh:profile:v2:5").Return(nil).Run(func(mock.Arguments) { close(refreshed) }).Once()
+
+		// Act
+		user, err := s.service.GetProfile(context.Background(), userID)
+
+		// Assert
+		s.NoError(err)
+		s.Equal("Stale", user.Name)
+		select {
+		case <-refreshed:
+		case <-time.After(time.Second):
+			s.Fail("profile was not refreshed in the background")
+		}
+	})
+
+	s.T().Run("LegacyCacheEntryIsReloaded", func(t *testing.T) {
+		// Arrange
+		userID := uint(6)
+		s.redis.On("Get", mock.Anything, "profile:v2:6").Return(`{"id":6,"name":"Legacy"}`, nil).Once()
+		s.repo.On("GetByIDWithRoles", mock.Anything, userID).Return(&models.User{ID: 6, Name: "Current"}, nil).Once()
+		s.redis.On("Set", mock.Anything, "profile:v2:6", mock.AnythingOfType("string"), services.PROFILE_CACHE_TTL).Return(nil).Once()
+
+		// Act
+		user, err := s.service.GetProfile(context.Background(), userID)
+
+		// Assert
+		s.NoError(err)
+		s.Equal("Current", user.Name)
+	})
+
+	s.T().Run("InvalidCacheDataIsReloaded", func(t *testing.T) {
+		// Arrange
+		userID := uint(3)
+		s.redis.On("Get", mock.Anything, "profile:v2:3").Return("not-json", nil).Once()
+		s.redis.On("Delete", mock.Anything, "profile:v2:3").Return(nil).Once()
+		s.repo.On("GetByIDWithRoles", mock.Anything, userID).Return(&models.User{ID: 3, Name: "Current", MustChangePassword: true}, nil).Once()
+		s.redis.On("Set", mock.Anything, "profile:v2:3", mock.AnythingOfType("string"), services.PROFILE_CACHE_TTL).Return(nil).Once()
+
+		// Act
+		user, err := s.service.GetProfile(context.Background(), userID)
+
+		// Assert
+		s.NoError(err)
+		s.Equal("Current", user.Name)
+		s.True(user.MustChangePassword)
+	})
+
+	s.T().Run("CacheUnavailable", func(t *testing.T) {
+		// Arrange
+		userID := uint(4)
+		expectedUser := &models.User{ID: 4, Email: "db@example.com"}
+		s.redis.On("Get", mock.Anything, "profile:v2:4").Return("", apperror.NewCacheGetError("connection refused")).Once()
+		s.repo.On("GetByIDWithRoles", mock.Anything, userID).Return(expectedUser, nil).Once()
+		s.redis.On("Set", mock.Anything, "profile:v2:4", mock.AnythingOfType("string"), services.PROFILE_CACHE_TTL).Return(apperror.NewCacheSetError("connection refused")).Once()
 
 		// Act
 		user, err := s.service.GetProfile(context.Background(), userID)
 
 		// Assert
 		s.NoError(err)
-		s.Equal(expectedUser, user)
+		s.Equal("db@example.com", user.Email)
 	})
 
 	s.T().Run("Error", func(t *testing.T) {
 		// Arrange
 		userID := uint(999)
-		s.repo.On("GetByID", mock.Anything, userID).Return(&models.User{}, errors.New("profile not found")).Once()
+		s.redis.On("Get", mock.Anything, "profile:v2:999").Return("", services.ErrCacheMiss).Once()
+		s.repo.On("GetByIDWithRoles", mock.Anything, userID).Return(&models.User{}, errors.New("profile not found")).Once()
 
 		// Act
 		user, err := s.service.GetProfile(context.Background(), userID)
@@ -112,6 +284,26 @@ func (s *UserServiceTestSuite) TestUpdateProfile() {
 
 		s.repo.On("GetByID", mock.Anything, userID).Return(user, nil).Once()
 		s.repo.On("Update", mock.Anything, user).Return(nil).Once()
+		s.redis.On("Delete", mock.Anything, "profile:v2:1").Return(nil).Once()
+		s.redis.On("Delete", mock.Anything, "cache_refresh:profile:v2:1").Return(nil).Once()
+
+		// Act
+		err := s.service.UpdateProfile(context.Background(), userID, &input)
+
+		// Assert
+		s.NoError(err)
+	})
+
+	s.T().Run("CacheInvalidationFailureIsIgnored", func(t *testing.T) {
+		// Arrange
+		user := &models.User{ID: 5}
+		userID := uint(5)
+		input := dto.UpdateProfileInput{Name: utils.StringToPtr("Jane Doe")}
+
+		s.repo.On("GetByID", mock.Anything, userID).Return(user, nil).Once()
+		s.repo.On("Update", mock.Anything, user).Return(nil).Once()
+		s.redis.On("Delete", mock.Anything, "profile:v2:5").Return(apperror.NewCacheDeleteError("connection refused")).Once()
+		s.redis.On("Delete", mock.Anything, "cache_refresh:profile:v2:5").Return(nil)
 
 		// Act
 		err := s.service.UpdateProfile(context.Background(), userID, &input)
@@ -139,36 +331,141 @@ func (s *UserServiceTestSuite) TestUpdateProfile() {
 }
 
 func (s *UserServiceTestSuite) TestForgotPassword() {
+	// expectCount expects a request for email outside the cooldown, counted as the count-th in the window
+	expectCount := func(email string, count int64, err error) {
+		s.redis.On("SetNX", mock.Anything, constants.RESET_COOLDOWN+email, "1", 2*time.Minute).Return(true, nil).Once()
+		s.redis.On("Incr", mock.Anything, constants.FORGOT_PASSWORD+email, services.FORGOT_PASSWORD_WINDOW).Return(count, err).Once()
+	}
+	// awaitBackground waits for the token to be mailed, which happens after ForgotPassword returns
+	awaitBackground := func(done <-chan struct{}) {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			s.Fail("password reset email was not sent in the background")
+		}
+	}
+
 	s.T().Run("Success", func(t *testing.T) {
 		// Arrange
 		email := "test@example.com"
 		user := &models.User{Email: email}
 
+		expectCount(email, 1, nil)
 		s.repo.On("FindByField", mock.Anything, "email", email).Return(user, nil).Once()
 		s.repo.On("Update", mock.Anything, user).Return(nil).Once()
 
-		// Act
-		s.mailer.On("SendMailForgotPassword", user).Return(nil).Once()
+		var mailed services.MailData
+		done := make(chan struct{})
+		s.mailer.On("SendMailForgotPassword", mailWithToken(user.Email)).Run(func(args mock.Arguments) {
+			mailed = args.Get(0).(services.MailData)
+			close(done)
+		}).Return(nil).Once()
 
+		// Act
 		err := s.service.ForgotPassword(context.Background(), &dto.ForgotPasswordInput{Email: email})
 
 		// Assert
 		s.NoError(err)
-		s.NotNil(user.Token)
+		awaitBackground(done)
 		s.NotNil(user.ExpiredAt)
+		// Only the hash of the mailed token is stored
+		s.Len(mailed.Token, services.USER_TOKEN_LENGTH)
+		s.Require().NotNil(user.Token)
+		s.NotEqual(mailed.Token, *user.Token)
+		s.Equal(utils.HashToken(mailed.Token), *user.Token)
+		s.Require().NotNil(user.TokenPurpose)
+		s.Equal(constants.TOKEN_PURPOSE_RESET_PASSWORD, *user.TokenPurpose)
 	})
 
 	s.T().Run("UserNotFound", func(t *testing.T) {
 		email := "unknown@example.com"
-		s.repo.On("FindByField", mock.Anything, "email", email).Return((*models.User)(nil), apperror.New(apperror.ErrUnauthorized, 1003, "User not found")).Once()
+		expectCount(email, 1, nil)
+		s.repo.On("FindByField", mock.Anything, "email", email).Return((*models.User)(nil), apperror.New(apperror.ErrNotFound, 1001, "User not found")).Once()
+
+		err := s.service.ForgotPassword(context.Background(), &dto.ForgotPasswordInput{Email: email})
+
+		// Answered as for a registered email; no Update or mail is expected, so none happens
+		s.NoError(err)
+	})
+
+	s.T().Run("TooManyRequests", func(t *testing.T) {
+		email := "limited@example.com"
+		expectCount(email, services.FORGOT_PASSWORD_MAX_REQUESTS+1, nil)
+
+		err := s.service.ForgotPassword(context.Background(), &dto.ForgotPasswordInput{Email: email})
+
+		s.Equal(apperror.ErrTooManyAttempts, err.(*apperror.AppError).Code)
+		s.repo.AssertNotCalled(t, "FindByField", mock.Anything, "email", email)
+	})
+
+	s.T().Run("CoolingDown", func(t *testing.T) {
+		email := "cooling@example.com"
+		s.redis.On("SetNX", mock.Anything, constants.RESET_COOLDOWN+email, "1", 2*time.Minute).Return(false, nil).Once()
+
+		err := s.service.ForgotPassword(context.Background(), &dto.ForgotPasswordInput{Email: email})
+
+		s.Equal(apperror.ErrTooManyRequests, err.(*apperror.AppError).Code)
+		s.redis.AssertNotCalled(t, "Incr", mock.Anything, constants.FORGOT_PASSWORD+email, mock.Anything)
+	})
+
+	s.T().Run("ConcurrentRequestsShareTheCooldown", func(t *testing.T) {
+		email := "concurrent@example.com"
+		localService := services.NewUserService(s.repo, s.bcrypt, s.mailer, services.NewMemoryRedisService(0), s.tokens, s.notify, s.tasks)
+		s.repo.On("FindByField", mock.Anything, "email", email).Return((*models.User)(nil), apperror.NewNotFoundError("User not found")).Once()
+
+		errs := make(chan error, 10)
+		var wg sync.WaitGroup
+		for range cap(errs) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- localService.ForgotPassword(context.Background(), &dto.ForgotPasswordInput{Email: email})
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		accepted := 0
+		for err := range errs {
+			if err == nil {
+				accepted++
+				continue
+			}
+			s.Equal(apperror.ErrTooManyRequests, err.(*apperror.AppError).Code)
+		}
+		s.Equal(1, accepted)
+	})
+
+	s.T().Run("CooldownErrorsAreIgnored", func(t *testing.T) {
+		email := "cooldown-fail@example.com"
+		s.redis.On("SetNX", mock.Anything, constants.RESET_COOLDOWN+email, "1", 2*time.Minute).Return(false, errors.New("redis down")).Once()
+		s.redis.On("Incr", mock.Anything, constants.FORGOT_PASSWORD+email, services.FORGOT_PASSWORD_WINDOW).Return(int64(1), nil).Once()
+		s.repo.On("FindByField", mock.Anything, "email", email).Return((*models.User)(nil), apperror.New(apperror.ErrNotFound, 1001, "User not found")).Once()
+
+		err := s.service.ForgotPassword(context.Background(), &dto.ForgotPasswordInput{Email: email})
+
+		s.NoError(err)
+	})
+
+	s.T().Run("CounterErrorIsIgnored", func(t *testing.T) {
+		email := "counter-fail@example.com"
+		user := &models.User{Email: email}
+
+		expectCount(email, 0, errors.New("redis down"))
+		s.repo.On("FindByField", mock.Anything, "email", email).Return(user, nil).Once()
+		s.repo.On("Update", mock.Anything, user).Return(nil).Once()
+		done := make(chan struct{})
+		s.mailer.On("SendMailForgotPassword", mailWithToken(user.Email)).Run(func(mock.Arguments) { close(done) }).Return(nil).Once()
 
 		err := s.service.ForgotPassword(context.Background(), &dto.ForgotPasswordInput{Email: email})
 
 		s.NoError(err)
+		awaitBackground(done)
 	})
 
 	s.T().Run("RepositoryQueryError", func(t *testing.T) {
 		email := "error@example.com"
+		expectCount(email, 1, nil)
 		s.repo.On("FindByField", mock.Anything, "email", email).Return((*models.User)(nil), errors.New("db query failed")).Once()
 
 		err := s.service.ForgotPassword(context.Background(), &dto.ForgotPasswordInput{Email: email})
@@ -180,32 +477,66 @@ func (s *UserServiceTestSuite) TestForgotPassword() {
 		email := "update-fail@example.com"
 		user := &models.User{Email: email}
 
+		expectCount(email, 1, nil)
 		s.repo.On("FindByField", mock.Anything, "email", email).Return(user, nil).Once()
 		s.repo.On("Update", mock.Anything, user).Return(errors.New("update failed")).Once()
 
 		err := s.service.ForgotPassword(context.Background(), &dto.ForgotPasswordInput{Email: email})
 
-		s.Error(err)
+		// The user would never get a working link, so the failure is reported; no mail is expected, so none is sent
+		s.Require().Error(err)
+		s.Equal(apperror.ErrDBUpdate, err.(*apperror.AppError).Code)
 	})
 
-	s.T().Run("SendMailFailure", func(t *testing.T) {
+	s.T().Run("AnswersAfterTheMinimumDuration", func(t *testing.T) {
+		t.Setenv("FORGOT_PASSWORD_MIN_DURATION_MS", "50")
+		localService := services.NewUserService(s.repo, s.bcrypt, s.mailer, s.redis, s.tokens, s.notify, s.tasks)
+		registered := &models.User{Email: "registered-timing@example.com"}
+		unknown := "unknown-timing@example.com"
+
+		expectCount(registered.Email, 1, nil)
+		s.repo.On("FindByField", mock.Anything, "email", registered.Email).Return(registered, nil).Once()
+		s.repo.On("Update", mock.Anything, registered).Return(nil).Once()
+		done := make(chan struct{})
+		s.mailer.On("SendMailForgotPassword", mailWithToken(registered.Email)).Run(func(mock.Arguments) { close(done) }).Return(nil).Once()
+		expectCount(unknown, 1, nil)
+		s.repo.On("FindByField", mock.Anything, "email", unknown).Return((*models.User)(nil), apperror.NewNotFoundError("User not found")).Once()
+
+		// Saving the token does not make registered emails slower to answer than unknown ones
+		for _, email := range []string{registered.Email, unknown} {
+			start := time.Now()
+			err := localService.ForgotPassword(context.Background(), &dto.ForgotPasswordInput{Email: email})
+
+			s.NoError(err)
+			s.GreaterOrEqual(time.Since(start), 50*time.Millisecond, email)
+		}
+		awaitBackground(done)
+	})
+
+	s.T().Run("SendMailFailureIsOnlyLogged", func(t *testing.T) {
 		email := "mail-fail@example.com"
 		user := &models.User{Email: email}
 
+		expectCount(email, 1, nil)
 		s.repo.On("FindByField", mock.Anything, "email", email).Return(user, nil).Once()
 		s.repo.On("Update", mock.Anything, user).Return(nil).Once()
-		s.mailer.On("SendMailForgotPassword", user).Return(errors.New("send mail failed")).Once()
+		done := make(chan struct{})
+		s.mailer.On("SendMailForgotPassword", mailWithToken(user.Email)).Run(func(mock.Arguments) { close(done) }).Return(errors.New("send mail failed")).Once()
 
 		err := s.service.ForgotPassword(context.Background(), &dto.ForgotPasswordInput{Email: email})
 
-		s.Error(err)
+		s.NoError(err)
+		awaitBackground(done)
+		s.NotNil(user.Token)
 	})
 }
 
 func (s *UserServiceTestSuite) TestResetPassword() {
+	purpose := constants.TOKEN_PURPOSE_RESET_PASSWORD
+
 	s.T().Run("TokenNotFound", func(t *testing.T) {
 		input := &dto.ResetPasswordInput{Token: "invalid-token", NewPassword: "new-password"}
-		s.repo.On("FindByField", mock.Anything, "token", input.Token).Return(&models.User{}, errors.New("not found")).Once()
+		s.repo.On("FindByField", mock.Anything, "token", utils.HashToken(input.Token)).Return(&models.User{}, errors.New("not found")).Once()
 
 		user, err := s.service.ResetPassword(context.Background(), input)
 
@@ -213,10 +544,26 @@ func (s *UserServiceTestSuite) TestResetPassword() {
 		s.Error(err)
 	})
 
+	s.T().Run("VerificationTokenIsRejected", func(t *testing.T) {
+		input := &dto.ResetPasswordInput{Token: "verify-token", NewPassword: "new-password"}
+		notExpired := time.Now().Add(10 * time.Minute).Unix()
+		verifyPurpose := constants.TOKEN_PURPOSE_VERIFY_EMAIL
+		user := &models.User{ID: 1, Token: &input.Token, TokenPurpose: &verifyPurpose, ExpiredAt: &notExpired}
+		s.repo.On("FindByField", mock.Anything, "token", utils.HashToken(input.Token)).Return(user, nil).Once()
+
+		result, err := s.service.ResetPassword(context.Background(), input)
+
+		s.Nil(result)
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrNotFound, appErr.Code)
+		s.Equal(&input.Token, user.Token)
+	})
+
 	s.T().Run("TokenExpiredWhenExpiredAtNil", func(t *testing.T) {
 		input := &dto.ResetPasswordInput{Token: "token-1", NewPassword: "new-password"}
-		user := &models.User{ID: 1, Token: &input.Token, ExpiredAt: nil}
-		s.repo.On("FindByField", mock.Anything, "token", input.Token).Return(user, nil).Once()
+		user := &models.User{ID: 1, Token: &input.Token, TokenPurpose: &purpose, ExpiredAt: nil}
+		s.repo.On("FindByField", mock.Anything, "token", utils.HashToken(input.Token)).Return(user, nil).Once()
 
 		result, err := s.service.ResetPassword(context.Background(), input)
 
@@ -227,8 +574,8 @@ func (s *UserServiceTestSuite) TestResetPassword() {
 	s.T().Run("TokenExpiredByTimestamp", func(t *testing.T) {
 		input := &dto.ResetPasswordInput{Token: "token-2", NewPassword: "new-password"}
 		expiredAt := time.Now().Add(-1 * time.Minute).Unix()
-		user := &models.User{ID: 1, Token: &input.Token, ExpiredAt: &expiredAt}
-		s.repo.On("FindByField", mock.Anything, "token", input.Token).Return(user, nil).Once()
+		user := &models.User{ID: 1, Token: &input.Token, TokenPurpose: &purpose, ExpiredAt: &expiredAt}
+		s.repo.On("FindByField", mock.Anything, "token", utils.HashToken(input.Token)).Return(user, nil).Once()
 
 		result, err := s.service.ResetPassword(context.Background(), input)
 
@@ -239,12 +586,12 @@ func (s *UserServiceTestSuite) TestResetPassword() {
 	s.T().Run("HashPasswordFailure", func(t *testing.T) {
 		input := &dto.ResetPasswordInput{Token: "token-3", NewPassword: "new-password"}
 		notExpired := time.Now().Add(10 * time.Minute).Unix()
-		user := &models.User{ID: 1, Token: &input.Token, ExpiredAt: &notExpired}
+		user := &models.User{ID: 1, Token: &input.Token, TokenPurpose: &purpose, ExpiredAt: &notExpired}
 
 		mockBcrypt := &mockBcryptService{hashErr: errors.New("hash failed"), checkValid: true}
-		localService := services.NewUserService(s.repo, mockBcrypt, s.mailer)
+		localService := services.NewUserService(s.repo, mockBcrypt, s.mailer, s.redis, s.tokens, s.notify, s.tasks)
 
-		s.repo.On("FindByField", mock.Anything, "token", input.Token).Return(user, nil).Once()
+		s.repo.On("FindByField", mock.Anything, "token", utils.HashToken(input.Token)).Return(user, nil).Once()
 
 		result, err := localService.ResetPassword(context.Background(), input)
 
@@ -255,10 +602,10 @@ func (s *UserServiceTestSuite) TestResetPassword() {
 	s.T().Run("UpdateFailure", func(t *testing.T) {
 		input := &dto.ResetPasswordInput{Token: "token-4", NewPassword: "new-password"}
 		notExpired := time.Now().Add(10 * time.Minute).Unix()
-		user := &models.User{ID: 1, Token: &input.Token, ExpiredAt: &notExpired}
+		user := &models.User{ID: 1, Token: &input.Token, TokenPurpose: &purpose, ExpiredAt: &notExpired}
 
-		s.repo.On("FindByField", mock.Anything, "token", input.Token).Return(user, nil).Once()
-		s.repo.On("Update", mock.Anything, user).Return(errors.New("update failed")).Once()
+		s.repo.On("FindByField", mock.Anything, "token", utils.HashToken(input.Token)).Return(user, nil).Once()
+		s.repo.On("UpdateIfToken", mock.Anything, user, utils.HashToken(input.Token)).Return(false, errors.New("update failed")).Once()
 
 		result, err := s.service.ResetPassword(context.Background(), input)
 
@@ -266,13 +613,30 @@ func (s *UserServiceTestSuite) TestResetPassword() {
 		s.Error(err)
 	})
 
+	s.T().Run("TokenConsumedConcurrently", func(t *testing.T) {
+		input := &dto.ResetPasswordInput{Token: "token-7", NewPassword: "new-password"}
+		notExpired := time.Now().Add(10 * time.Minute).Unix()
+		user := &models.User{ID: 1, Token: &input.Token, TokenPurpose: &purpose, ExpiredAt: &notExpired}
+
+		s.repo.On("FindByField", mock.Anything, "token", utils.HashToken(input.Token)).Return(user, nil).Once()
+		s.repo.On("UpdateIfToken", mock.Anything, user, utils.HashToken(input.Token)).Return(false, nil).Once()
+
+		result, err := s.service.ResetPassword(context.Background(), input)
+
+		s.Nil(result)
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrNotFound, appErr.Code)
+	})
+
 	s.T().Run("Success", func(t *testing.T) {
 		input := &dto.ResetPasswordInput{Token: "token-5", NewPassword: "new-password"}
 		notExpired := time.Now().Add(10 * time.Minute).Unix()
-		user := &models.User{ID: 1, Token: &input.Token, ExpiredAt: &notExpired}
+		user := &models.User{ID: 1, Token: &input.Token, TokenPurpose: &purpose, ExpiredAt: &notExpired}
 
-		s.repo.On("FindByField", mock.Anything, "token", input.Token).Return(user, nil).Once()
-		s.repo.On("Update", mock.Anything, user).Return(nil).Once()
+		s.repo.On("FindByField", mock.Anything, "token", utils.HashToken(input.Token)).Return(user, nil).Once()
+		s.repo.On("UpdateIfToken", mock.Anything, user, utils.HashToken(input.Token)).Return(true, nil).Once()
+		s.notify.On("NotifyPasswordChanged", mock.Anything, user).Return(nil).Once()
 
 		result, err := s.service.ResetPassword(context.Background(), input)
 
@@ -281,131 +645,603 @@ func (s *UserServiceTestSuite) TestResetPassword() {
 		s.NotEqual(input.NewPassword, result.Password)
 		s.Nil(result.Token)
 		s.Nil(result.ExpiredAt)
+		s.Nil(result.TokenPurpose)
+		s.NotNil(result.VerifiedAt)
+	})
+
+	s.T().Run("NotificationFailureIsOnlyLogged", func(t *testing.T) {
+		input := &dto.ResetPasswordInput{Token: "token-6", NewPassword: "new-password"}
+		notExpired := time.Now().Add(10 * time.Minute).Unix()
+		user := &models.User{ID: 1, Token: &input.Token, TokenPurpose: &purpose, ExpiredAt: &notExpired}
+
+		s.repo.On("FindByField", mock.Anything, "token", utils.HashToken(input.Token)).Return(user, nil).Once()
+		s.repo.On("UpdateIfToken", mock.Anything, user, utils.HashToken(input.Token)).Return(true, nil).Once()
+		s.notify.On("NotifyPasswordChanged", mock.Anything, user).Return(errors.New("smtp down")).Once()
+
+		result, err := s.service.ResetPassword(context.Background(), input)
+
+		s.NoError(err)
+		s.NotNil(result)
 	})
 }
 
-func (s *UserServiceTestSuite) TestChangePassword() {
-	s.T().Run("UserNotFound", func(t *testing.T) {
-		input := &dto.ChangePasswordInput{
-			OldPassword:     "old-password",
-			NewPassword:     "new-password",
-			ConfirmPassword: "new-password",
+func (s *UserServiceTestSuite) TestGetProfileCacheTTL() {
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+		warns    bool
+	}{
+		{name: "Unset", value: "", expected: services.PROFILE_CACHE_TTL},
+		{name: "Configured", value: "15", expected: 15 * time.Minute},
+		{name: "NotANumber", value: "an hour", expected: services.PROFILE_CACHE_TTL, warns: true},
+		{name: "NotPositive", value: "0", expected: services.PROFILE_CACHE_TTL, warns: true},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			t.Setenv("PROFILE_CACHE_TTL_MINUTES", tt.value)
+			hook := logtest.NewGlobal()
+			defer hook.Reset()
+
+			repo := new(mocks.MockUserRepository)
+			redis := new(mocks.MockRedisService)
+			service := services.NewUserService(repo, s.bcrypt, s.mailer, redis, s.tokens, s.notify, s.tasks)
+
+			var warnings []string
+			for _, entry := range hook.AllEntries() {
+				if entry.Level == logrus.WarnLevel {
+					warnings = append(warnings, entry.Message)
+				}
+			}
+			if tt.warns {
+				s.Require().Len(warnings, 1)
+				s.Contains(warnings[0], "PROFILE_CACHE_TTL_MINUTES")
+			} else {
+				s.Empty(warnings)
+			}
+
+			redis.On("Get", mock.Anything, "profile:v2:1").Return("", services.ErrCacheMiss).Once()
+			repo.On("GetByIDWithRoles", mock.Anything, uint(1)).Return(&models.User{ID: 1}, nil).Once()
+			redis.On("Set", mock.Anything, "profile:v2:1", mock.AnythingOfType("string"), tt.expected).Return(nil).Once()
+
+			_, err := service.GetProfile(context.Background(), 1)
+
+			s.NoError(err)
+			redis.AssertExpectations(t)
+		})
+	}
+}
+
+func (s *UserServiceTestSuite) TestGetProfileCacheRefreshAhead() {
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+		warns    bool
+	}{
+		{name: "Unset", value: "", expected: services.PROFILE_CACHE_REFRESH_AHEAD},
+		{name: "Configured", value: "300", expected: 5 * time.Minute},
+		{name: "Disabled", value: "0", expected: 0},
+		{name: "NotANumber", value: "soon", expected: services.PROFILE_CACHE_REFRESH_AHEAD, warns: true},
+		{name: "NotShorterThanTTL", value: "3600", expected: 0, warns: true},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			t.Setenv("PROFILE_CACHE_TTL_MINUTES", "")
+			t.Setenv("PROFILE_CACHE_REFRESH_AHEAD_SECONDS", tt.value)
+			hook := logtest.NewGlobal()
+			defer hook.Reset()
+
+			repo := new(mocks.MockUserRepository)
+			redis := new(mocks.MockRedisService)
+			service := services.NewUserService(repo, s.bcrypt, s.mailer, redis, s.tokens, s.notify, s.tasks)
+
+			var warnings []string
+			for _, entry := range hook.AllEntries() {
+				if entry.Level == logrus.WarnLevel {
+					warnings = append(warnings, entry.Message)
+				}
+			}
+			if tt.warns {
+				s.Require().Len(warnings, 1)
+				s.Contains(warnings[0], "PROFILE_CACHE_REFRESH_AHEAD_SECONDS")
+			} else {
+				s.Empty(warnings)
+			}
+
+			var cached struct {
+				RefreshAt time.Time `json:"refresh_at"`
+			}
+			redis.On("Get", mock.Anything, "profile:v2:1").Return("", services.ErrCacheMiss).Once()
+			repo.On("GetByIDWithRoles", mock.Anything, uint(1)).Return(&models.User{ID: 1}, nil).Once()
+			redis.On("Set", mock.Anything, "profile:v2:1", mock.AnythingOfType("string"), services.PROFILE_CACHE_TTL).Return(nil).Run(func(args mock.Arguments) {
+				s.NoError(json.Unmarshal([]byte(args.String(2)), &cached))
+			}).Once()
+
+			_, err := service.GetProfile(context.Background(), 1)
+
+			s.NoError(err)
+			s.WithinDuration(time.Now().Add(services.PROFILE_CACHE_TTL-tt.expected), cached.RefreshAt, 5*time.Second)
+		})
+	}
+}
+
+func (s *UserServiceTestSuite) TestCreateUser() {
+	birthday := "1990-01-01"
+	address := "123 Main Street"
+	newInput := func() *dto.CreateUserInput {
+		return &dto.CreateUserInput{
+			Email:    "new@example.com",
+			Password: "Password123!",
+			Name:     "New User",
+			Birthday: &birthday,
+			Address:  &address,
+			Gender:   1,
 		}
-		s.repo.On("GetByID", mock.Anything, uint(100)).Return(&models.User{}, errors.New("not found")).Once()
+	}
+	// expectCreate expects the user to be inserted within a transaction, with no roles to check or assign
+	expectCreate := func(createErr error) {
+		s.repo.On("BeginTx", mock.Anything).Return(s.db.Begin(), nil).Once()
+		s.repo.On("FindMissingRolesWithTx", mock.Anything, mock.Anything, []uint(nil)).Return(nil, nil).Once()
+		if createErr != nil {
+			s.repo.On("CreateWithTx", mock.Anything, mock.Anything, mock.AnythingOfType("*models.User")).Return((*models.User)(nil), createErr).Once()
+			return
+		}
+		s.repo.On("CreateWithTx", mock.Anything, mock.Anything, mock.AnythingOfType("*models.User")).Return(&models.User{}, nil).Once()
+		s.repo.On("AssignRolesWithTx", mock.Anything, mock.Anything, mock.Anything, []uint(nil)).Return(nil).Once()
+	}
 
-		result, err := s.service.ChangePassword(context.Background(), 100, input)
+	s.T().Run("Success", func(t *testing.T) {
+		input := newInput()
+		s.repo.On("FindByField", mock.Anything, "email", input.Email).Return((*models.User)(nil), errors.New("not found")).Once()
+		expectCreate(nil)
+		s.mailer.On("SendMailVerification", mock.AnythingOfType("services.MailData")).Return(nil).Once()
 
-		s.Nil(result)
-		s.Error(err)
+		user, err := s.service.CreateUser(context.Background(), input)
+
+		s.NoError(err)
+		s.Equal(input.Email, user.Email)
+		s.NotEqual(input.Password, user.Password)
+		s.Nil(user.VerifiedAt)
+		s.NotNil(user.Token)
+		s.Require().NotNil(user.ExpiredAt)
+		s.Greater(*user.ExpiredAt, time.Now().Unix())
+		s.Require().NotNil(user.Birthday)
+		s.Equal(birthday, user.Birthday.Format("2006-01-02"))
 	})
 
-	s.T().Run("OldPasswordMismatch", func(t *testing.T) {
-		input := &dto.ChangePasswordInput{
-			OldPassword:     "wrong-old",
-			NewPassword:     "new-password",
-			ConfirmPassword: "new-password",
-		}
-		hash, err := s.bcrypt.HashPassword("correct-old")
-		s.Require().NoError(err)
-		user := &models.User{ID: 1, Password: hash}
-		s.repo.On("GetByID", mock.Anything, uint(1)).Return(user, nil).Once()
+	s.T().Run("NormalizesEmail", func(t *testing.T) {
+		input := newInput()
+		input.Email = " New@Example.COM "
+		s.repo.On("FindByField", mock.Anything, "email", "new@example.com").Return((*models.User)(nil), errors.New("not found")).Once()
+		expectCreate(nil)
+		s.mailer.On("SendMailVerification", mock.AnythingOfType("services.MailData")).Return(nil).Once()
 
-		result, err := s.service.ChangePassword(context.Background(), 1, input)
+		user, err := s.service.CreateUser(context.Background(), input)
 
-		s.Nil(result)
-		s.Error(err)
+		s.NoError(err)
+		s.Equal("new@example.com", user.Email)
 	})
 
-	s.T().Run("NewAndConfirmPasswordMismatch", func(t *testing.T) {
-		input := &dto.ChangePasswordInput{
-			OldPassword:     "old-password",
-			NewPassword:     "new-password",
-			ConfirmPassword: "different-password",
-		}
-		hash, err := s.bcrypt.HashPassword(input.OldPassword)
-		s.Require().NoError(err)
-		user := &models.User{ID: 1, Password: hash}
-		s.repo.On("GetByID", mock.Anything, uint(2)).Return(user, nil).Once()
+	s.T().Run("EmailAlreadyExists", func(t *testing.T) {
+		input := newInput()
+		s.repo.On("FindByField", mock.Anything, "email", input.Email).Return(&models.User{ID: 1}, nil).Once()
 
-		result, err := s.service.ChangePassword(context.Background(), 2, input)
+		user, err := s.service.CreateUser(context.Background(), input)
 
-		s.Nil(result)
-		s.Error(err)
+		s.Nil(user)
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrDuplicateEmail, appErr.Code)
+		s.Equal(http.StatusConflict, appErr.HttpStatusCode)
+		s.Equal("Email already registered", appErr.Message)
+		s.Equal([]apperror.FieldError{{Field: "email", Message: "email already exists"}}, appErr.Fields)
 	})
 
-	s.T().Run("NewPasswordUnchanged", func(t *testing.T) {
-		input := &dto.ChangePasswordInput{
-			OldPassword:     "same-password",
-			NewPassword:     "same-password",
-			ConfirmPassword: "same-password",
-		}
-		hash, err := s.bcrypt.HashPassword(input.OldPassword)
-		s.Require().NoError(err)
-		user := &models.User{ID: 1, Password: hash}
-		s.repo.On("GetByID", mock.Anything, uint(3)).Return(user, nil).Once()
+	s.T().Run("EmailRegisteredConcurrently", func(t *testing.T) {
+		input := newInput()
+		s.repo.On("FindByField", mock.Anything, "email", input.Email).Return((*models.User)(nil), errors.New("not found")).Once()
+		duplicateErr := apperror.NewDuplicateEmailError("email already exists").WithFields(apperror.FieldError{Field: "email", Message: "email already exists"})
+		expectCreate(duplicateErr)
 
-		result, err := s.service.ChangePassword(context.Background(), 3, input)
+		user, err := s.service.CreateUser(context.Background(), input)
 
-		s.Nil(result)
-		s.Error(err)
+		s.Nil(user)
+		s.Equal(duplicateErr, err)
 	})
 
-	s.T().Run("HashPasswordFailure", func(t *testing.T) {
-		input := &dto.ChangePasswordInput{
-			OldPassword:     "old-password",
-			NewPassword:     "new-password",
-			ConfirmPassword: "new-password",
-		}
-		mockBcrypt := &mockBcryptService{hashErr: errors.New("hash failed"), checkValid: true}
-		localService := services.NewUserService(s.repo, mockBcrypt, s.mailer)
-		user := &models.User{ID: 1, Password: "existing-hash"}
-		s.repo.On("GetByID", mock.Anything, uint(4)).Return(user, nil).Once()
+	s.T().Run("InvalidBirthday", func(t *testing.T) {
+		input := newInput()
+		invalid := "not-a-date"
+		input.Birthday = &invalid
+		s.repo.On("FindByField", mock.Anything, "email", input.Email).Return((*models.User)(nil), errors.New("not found")).Once()
 
-		result, err := localService.ChangePassword(context.Background(), 4, input)
+		user, err := s.service.CreateUser(context.Background(), input)
 
-		s.Nil(result)
+		s.Nil(user)
 		s.Error(err)
 	})
 
-	s.T().Run("UpdateFailure", func(t *testing.T) {
-		input := &dto.ChangePasswordInput{
-			OldPassword:     "old-password",
-			NewPassword:     "new-password",
-			ConfirmPassword: "new-password",
-		}
-		hash, err := s.bcrypt.HashPassword(input.OldPassword)
-		s.Require().NoError(err)
-		user := &models.User{ID: 1, Password: hash}
-		s.repo.On("GetByID", mock.Anything, uint(5)).Return(user, nil).Once()
-		s.repo.On("Update", mock.Anything, user).Return(errors.New("update failed")).Once()
+	s.T().Run("CreateFailure", func(t *testing.T) {
+		input := newInput()
+		s.repo.On("FindByField", mock.Anything, "email", input.Email).Return((*models.User)(nil), errors.New("not found")).Once()
+		expectCreate(errors.New("insert failed"))
 
-		result, err := s.service.ChangePassword(context.Background(), 5, input)
+		user, err := s.service.CreateUser(context.Background(), input)
 
-		s.Nil(result)
-		s.Error(err)
+		s.Nil(user)
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrDBInsert, appErr.Code)
 	})
 
-	s.T().Run("Success", func(t *testing.T) {
-		input := &dto.ChangePasswordInput{
-			OldPassword:     "old-password",
-			NewPassword:     "new-password",
-			ConfirmPassword: "new-password",
-		}
-		hash, err := s.bcrypt.HashPassword(input.OldPassword)
-		s.Require().NoError(err)
-		user := &models.User{ID: 1, Password: hash}
-		s.repo.On("GetByID", mock.Anything, uint(6)).Return(user, nil).Once()
-		s.repo.On("Update", mock.Anything, user).Return(nil).Once()
+	s.T().Run("MailFailureIsIgnored", func(t *testing.T) {
+		input := newInput()
+		s.repo.On("FindByField", mock.Anything, "email", input.Email).Return((*models.User)(nil), errors.New("not found")).Once()
+		expectCreate(nil)
+		s.mailer.On("SendMailVerification", mock.AnythingOfType("services.MailData")).Return(errors.New("smtp down")).Once()
 
-		result, err := s.service.ChangePassword(context.Background(), 6, input)
+		user, err := s.service.CreateUser(context.Background(), input)
 
 		s.NoError(err)
-		s.NotNil(result)
-		s.True(s.bcrypt.CheckPasswordHash(input.NewPassword, result.Password))
+		s.NotNil(user)
 	})
 }
 
-func (s *UserServiceTestSuite) TestUpdateProfileErrors() {
+func (s *UserServiceTestSuite) TestVerifyEmail() {
+	purpose := constants.TOKEN_PURPOSE_VERIFY_EMAIL
+
+	s.T().Run("Success", func(t *testing.T) {
+		token := "verify-token"
+		notExpired := time.Now().Add(10 * time.Minute).Unix()
+		user := &models.User{ID: 1, Token: &token, TokenPurpose: &purpose, ExpiredAt: &notExpired}
+		s.repo.On("FindByField", mock.Anything, "token", utils.HashToken(token)).Return(user, nil).Once()
+		s.repo.On("Update", mock.Anything, user).Return(nil).Once()
+		s.redis.On("Delete", mock.Anything, "profile:v2:1").Return(nil).Once()
+		s.redis.On("Delete", mock.Anything, "cache_refresh:profile:v2:1").Return(nil).Once()
+
+		err := s.service.VerifyEmail(context.Background(), token)
+
+		s.NoError(err)
+		s.NotNil(user.VerifiedAt)
+		s.Nil(user.Token)
+		s.Nil(user.ExpiredAt)
+		s.Nil(user.TokenPurpose)
+	})
+
+	s.T().Run("InvalidToken", func(t *testing.T) {
+		s.repo.On("FindByField", mock.Anything, "token", utils.HashToken("unknown")).Return((*models.User)(nil), errors.New("not found")).Once()
+
+		err := s.service.VerifyEmail(context.Background(), "unknown")
+
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrNotFound, appErr.Code)
+	})
+
+	s.T().Run("ExpiredToken", func(t *testing.T) {
+		token := "expired-token"
+		expiredAt := time.Now().Add(-1 * time.Minute).Unix()
+		user := &models.User{ID: 1, Token: &token, TokenPurpose: &purpose, ExpiredAt: &expiredAt}
+		s.repo.On("FindByField", mock.Anything, "token", utils.HashToken(token)).Return(user, nil).Once()
+
+		err := s.service.VerifyEmail(context.Background(), token)
+
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrTokenExpired, appErr.Code)
+		s.Nil(user.VerifiedAt)
+	})
+
+	s.T().Run("AlreadyVerified", func(t *testing.T) {
+		token := "verify-token-3"
+		verifiedAt := time.Now().Add(-time.Hour)
+		user := &models.User{ID: 1, Token: &token, TokenPurpose: &purpose, VerifiedAt: &verifiedAt}
+		s.repo.On("FindByField", mock.Anything, "token", utils.HashToken(token)).Return(user, nil).Once()
+
+		err := s.service.VerifyEmail(context.Background(), token)
+
+		s.NoError(err)
+		s.Equal(&token, user.Token)
+	})
+
+	s.T().Run("ResetTokenIsRejected", func(t *testing.T) {
+		token := "reset-token"
+		notExpired := time.Now().Add(10 * time.Minute).Unix()
+		resetPurpose := constants.TOKEN_PURPOSE_RESET_PASSWORD
+		user := &models.User{ID: 1, Token: &token, TokenPurpose: &resetPurpose, ExpiredAt: &notExpired}
+		s.repo.On("FindByField", mock.Anything, "token", utils.HashToken(token)).Return(user, nil).Once()
+
+		err := s.service.VerifyEmail(context.Background(), token)
+
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrNotFound, appErr.Code)
+		s.Nil(user.VerifiedAt)
+		s.Equal(&token, user.Token)
+	})
+
+	s.T().Run("UpdateFailure", func(t *testing.T) {
+		token := "verify-token-2"
+		notExpired := time.Now().Add(10 * time.Minute).Unix()
+		user := &models.User{ID: 1, Token: &token, TokenPurpose: &purpose, ExpiredAt: &notExpired}
+		s.repo.On("FindByField", mock.Anything, "token", utils.HashToken(token)).Return(user, nil).Once()
+		s.repo.On("Update", mock.Anything, user).Return(errors.New("update failed")).Once()
+
+		err := s.service.VerifyEmail(context.Background(), token)
+
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrDBUpdate, appErr.Code)
+	})
+}
+
+func (s *UserServiceTestSuite) TestResendVerification() {
+	input := &dto.ResendVerificationInput{Email: "User@Example.com"}
+	email := "user@example.com"
+	cooldownKey := "verification_resend:" + email
+
+	s.T().Run("Success", func(t *testing.T) {
+		user := &models.User{ID: 1, Email: input.Email}
+		s.redis.On("Exists", mock.Anything, cooldownKey).Return(false, nil).Once()
+		s.redis.On("Set", mock.Anything, cooldownKey, "1", 2*time.Minute).Return(nil).Once()
+		s.repo.On("FindByField", mock.Anything, "email", email).Return(user, nil).Once()
+		s.repo.On("Update", mock.Anything, user).Return(nil).Once()
+		s.mailer.On("SendMailVerification", mailWithToken(user.Email)).Return(nil).Once()
+
+		err := s.service.ResendVerification(context.Background(), input)
+
+		s.NoError(err)
+		s.NotNil(user.Token)
+		s.NotNil(user.ExpiredAt)
+	})
+
+	s.T().Run("Throttled", func(t *testing.T) {
+		s.redis.On("Exists", mock.Anything, cooldownKey).Return(true, nil).Once()
+
+		err := s.service.ResendVerification(context.Background(), input)
+
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(429, appErr.HttpStatusCode)
+	})
+
+	s.T().Run("UnknownEmail", func(t *testing.T) {
+		s.redis.On("Exists", mock.Anything, cooldownKey).Return(false, nil).Once()
+		s.redis.On("Set", mock.Anything, cooldownKey, "1", 2*time.Minute).Return(nil).Once()
+		s.repo.On("FindByField", mock.Anything, "email", email).Return((*models.User)(nil), errors.New("not found")).Once()
+
+		err := s.service.ResendVerification(context.Background(), input)
+
+		s.NoError(err)
+	})
+
+	s.T().Run("AlreadyVerified", func(t *testing.T) {
+		verifiedAt := time.Now()
+		user := &models.User{ID: 1, Email: input.Email, VerifiedAt: &verifiedAt}
+		s.redis.On("Exists", mock.Anything, cooldownKey).Return(false, nil).Once()
+		s.redis.On("Set", mock.Anything, cooldownKey, "1", 2*time.Minute).Return(nil).Once()
+		s.repo.On("FindByField", mock.Anything, "email", email).Return(user, nil).Once()
+
+		err := s.service.ResendVerification(context.Background(), input)
+
+		s.NoError(err)
+		s.Nil(user.Token)
+	})
+
+	s.T().Run("CacheFailureDoesNotBlock", func(t *testing.T) {
+		user := &models.User{ID: 1, Email: input.Email}
+		s.redis.On("Exists", mock.Anything, cooldownKey).Return(false, errors.New("redis down")).Once()
+		s.redis.On("Set", mock.Anything, cooldownKey, "1", 2*time.Minute).Return(errors.New("redis down")).Once()
+		s.repo.On("FindByField", mock.Anything, "email", email).Return(user, nil).Once()
+		s.repo.On("Update", mock.Anything, user).Return(nil).Once()
+		s.mailer.On("SendMailVerification", mailWithToken(user.Email)).Return(nil).Once()
+
+		err := s.service.ResendVerification(context.Background(), input)
+
+		s.NoError(err)
+	})
+
+	s.T().Run("UpdateFailure", func(t *testing.T) {
+		user := &models.User{ID: 1, Email: input.Email}
+		s.redis.On("Exists", mock.Anything, cooldownKey).Return(false, nil).Once()
+		s.redis.On("Set", mock.Anything, cooldownKey, "1", 2*time.Minute).Return(nil).Once()
+		s.repo.On("FindByField", mock.Anything, "email", email).Return(user, nil).Once()
+		s.repo.On("Update", mock.Anything, user).Return(errors.New("update failed")).Once()
+
+		err := s.service.ResendVerification(context.Background(), input)
+
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrDBUpdate, appErr.Code)
+	})
+}
+
+func (s *UserServiceTestSuite) TestChangePassword() {
+	s.T().Run("UserNotFound", func(t *testing.T) {
+		input := &dto.ChangePasswordInput{
+			OldPassword:     "old-password",
+			NewPassword:     "new-password",
+			ConfirmPassword: "new-password",
+		}
+		s.repo.On("GetByID", mock.Anything, uint(100)).Return(&models.User{}, errors.New("not found")).Once()
+
+		result, err := s.service.ChangePassword(context.Background(), 100, input)
+
+		s.Nil(result)
+		s.Error(err)
+	})
+
+	s.T().Run("OldPasswordMismatch", func(t *testing.T) {
+		input := &dto.ChangePasswordInput{
+			OldPassword:     "wrong-old",
+			NewPassword:     "new-password",
+			ConfirmPassword: "new-password",
+		}
+		hash, err := s.bcrypt.HashPassword("correct-old")
+		s.Require().NoError(err)
+		user := &models.User{ID: 1, Password: hash}
+		s.repo.On("GetByID", mock.Anything, uint(1)).Return(user, nil).Once()
+
+		result, err := s.service.ChangePassword(context.Background(), 1, input)
+
+		s.Nil(result)
+		s.Error(err)
+	})
+
+	s.T().Run("NewAndConfirmPasswordMismatch", func(t *testing.T) {
+		input := &dto.ChangePasswordInput{
+			OldPassword:     "old-password",
+			NewPassword:     "new-password",
+			ConfirmPassword: "different-password",
+		}
+		hash, err := s.bcrypt.HashPassword(input.OldPassword)
+		s.Require().NoError(err)
+		user := &models.User{ID: 1, Password: hash}
+		s.repo.On("GetByID", mock.Anything, uint(2)).Return(user, nil).Once()
+
+		result, err := s.service.ChangePassword(context.Background(), 2, input)
+
+		s.Nil(result)
+		s.Error(err)
+	})
+
+	s.T().Run("NewPasswordContainsEmailLocalPart", func(t *testing.T) {
+		input := &dto.ChangePasswordInput{
+			OldPassword:     "old-password",
+			NewPassword:     "My-JohnDoe-2024",
+			ConfirmPassword: "My-JohnDoe-2024",
+		}
+		hash, err := s.bcrypt.HashPassword(input.OldPassword)
+		s.Require().NoError(err)
+		user := &models.User{ID: 1, Email: "johndoe@example.com", Password: hash}
+		s.repo.On("GetByID", mock.Anything, uint(20)).Return(user, nil).Once()
+
+		result, err := s.service.ChangePassword(context.Background(), 20, input)
+
+		s.Nil(result)
+		validationErr, ok := err.(*apperror.ValidationError)
+		s.Require().True(ok)
+		s.Equal([]apperror.FieldError{{Field: "new_password", Message: "new_password must not contain your email address"}}, validationErr.Fields)
+	})
+
+	s.T().Run("ShortEmailLocalPartIsIgnored", func(t *testing.T) {
+		input := &dto.ChangePasswordInput{
+			OldPassword:     "old-password",
+			NewPassword:     "Jo-Passw0rd",
+			ConfirmPassword: "Jo-Passw0rd",
+		}
+		hash, err := s.bcrypt.HashPassword(input.OldPassword)
+		s.Require().NoError(err)
+		user := &models.User{ID: 21, Email: "jo@example.com", Password: hash}
+		s.repo.On("GetByID", mock.Anything, uint(21)).Return(user, nil).Once()
+		s.repo.On("Update", mock.Anything, user).Return(nil).Once()
+		s.tokens.On("DeleteAllByUserID", mock.Anything, uint(21)).Return(int64(0), nil).Once()
+		s.notify.On("NotifyPasswordChanged", mock.Anything, user).Return(nil).Once()
+
+		result, err := s.service.ChangePassword(context.Background(), 21, input)
+
+		s.NoError(err)
+		s.NotNil(result)
+	})
+
+	s.T().Run("NewPasswordUnchanged", func(t *testing.T) {
+		input := &dto.ChangePasswordInput{
+			OldPassword:     "same-password",
+			NewPassword:     "same-password",
+			ConfirmPassword: "same-password",
+		}
+		hash, err := s.bcrypt.HashPassword(input.OldPassword)
+		s.Require().NoError(err)
+		user := &models.User{ID: 1, Password: hash}
+		s.repo.On("GetByID", mock.Anything, uint(3)).Return(user, nil).Once()
+
+		result, err := s.service.ChangePassword(context.Background(), 3, input)
+
+		s.Nil(result)
+		s.Error(err)
+	})
+
+	s.T().Run("HashPasswordFailure", func(t *testing.T) {
+		input := &dto.ChangePasswordInput{
+			OldPassword:     "old-password",
+			NewPassword:     "new-password",
+			ConfirmPassword: "new-password",
+		}
+		mockBcrypt := &mockBcryptService{hashErr: errors.New("hash failed"), checkValid: true}
+		localService := services.NewUserService(s.repo, mockBcrypt, s.mailer, s.redis, s.tokens, s.notify, s.tasks)
+		user := &models.User{ID: 1, Password: "existing-hash"}
+		s.repo.On("GetByID", mock.Anything, uint(4)).Return(user, nil).Once()
+
+		result, err := localService.ChangePassword(context.Background(), 4, input)
+
+		s.Nil(result)
+		s.Error(err)
+	})
+
+	s.T().Run("UpdateFailure", func(t *testing.T) {
+		input := &dto.ChangePasswordInput{
+			OldPassword:     "old-password",
+			NewPassword:     "new-password",
+			ConfirmPassword: "new-password",
+		}
+		hash, err := s.bcrypt.HashPassword(input.OldPassword)
+		s.Require().NoError(err)
+		user := &models.User{ID: 1, Password: hash}
+		s.repo.On("GetByID", mock.Anything, uint(5)).Return(user, nil).Once()
+		s.repo.On("Update", mock.Anything, user).Return(errors.New("update failed")).Once()
+
+		result, err := s.service.ChangePassword(context.Background(), 5, input)
+
+		s.Nil(result)
+		s.Error(err)
+	})
+
+	s.T().Run("Success", func(t *testing.T) {
+		input := &dto.ChangePasswordInput{
+			OldPassword:     "old-password",
+			NewPassword:     "new-password",
+			ConfirmPassword: "new-password",
+		}
+		hash, err := s.bcrypt.HashPassword(input.OldPassword)
+		s.Require().NoError(err)
+		user := &models.User{ID: 1, Password: hash}
+		s.repo.On("GetByID", mock.Anything, uint(6)).Return(user, nil).Once()
+		s.repo.On("Update", mock.Anything, user).Return(nil).Once()
+		s.tokens.On("DeleteAllByUserID", mock.Anything, uint(1)).Return(int64(2), nil).Once()
+		s.notify.On("NotifyPasswordChanged", mock.Anything, user).Return(nil).Once()
+
+		result, err := s.service.ChangePassword(context.Background(), 6, input)
+
+		s.NoError(err)
+		s.NotNil(result)
+		s.True(s.bcrypt.CheckPasswordHash(input.NewPassword, result.Password))
+	})
+
+	s.T().Run("SessionRevocationFailureIsIgnored", func(t *testing.T) {
+		input := &dto.ChangePasswordInput{
+			OldPassword:     "old-password",
+			NewPassword:     "new-password",
+			ConfirmPassword: "new-password",
+		}
+		hash, err := s.bcrypt.HashPassword(input.OldPassword)
+		s.Require().NoError(err)
+		user := &models.User{ID: 7, Password: hash}
+		s.repo.On("GetByID", mock.Anything, uint(7)).Return(user, nil).Once()
+		s.repo.On("Update", mock.Anything, user).Return(nil).Once()
+		s.tokens.On("DeleteAllByUserID", mock.Anything, uint(7)).Return(int64(0), apperror.NewDBDeleteError("Failed to delete refresh tokens")).Once()
+		s.notify.On("NotifyPasswordChanged", mock.Anything, user).Return(nil).Once()
+
+		result, err := s.service.ChangePassword(context.Background(), 7, input)
+
+		s.NoError(err)
+		s.NotNil(result)
+	})
+}
+
+func (s *UserServiceTestSuite) TestUpdateProfileErrors() {
 	s.T().Run("UserNotFound", func(t *testing.T) {
 		input := &dto.UpdateProfileInput{Name: utils.StringToPtr("John")}
 		s.repo.On("GetByID", mock.Anything, uint(77)).Return((*models.User)(nil), errors.New("not found")).Once()
@@ -424,6 +1260,844 @@ func (s *UserServiceTestSuite) TestUpdateProfileErrors() {
 	})
 }
 
-func TestUserServiceTestSuite(t *testing.T) {
-	suite.Run(t, new(UserServiceTestSuite))
+func (s *UserServiceTestSuite) TestGetUsers() {
+	opts := dto.ListOptions{Page: 1, Limit: 10, SortBy: "name", SortDir: "asc"}
+	filter := dto.UserFilterInput{Search: "bob"}
+
+	s.T().Run("Success", func(t *testing.T) {
+		expected := &dto.Pagination[*models.User]{
+			Page:       1,
+			Limit:      10,
+			TotalItems: 1,
+			TotalPages: 1,
+			Data:       []*models.User{{ID: 1, Name: "Bob"}},
+		}
+		s.repo.On("GetUsers", mock.Anything, opts, filter).Return(expected, nil).Once()
+
+		result, err := s.service.GetUsers(context.Background(), opts, filter)
+
+		s.NoError(err)
+		s.Equal(expected, result)
+	})
+
+	s.T().Run("RepositoryError", func(t *testing.T) {
+		s.repo.On("GetUsers", mock.Anything, opts, filter).Return(nil, errors.New("db error")).Once()
+
+		result, err := s.service.GetUsers(context.Background(), opts, filter)
+
+		s.Nil(result)
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrDBQuery, appErr.Code)
+	})
+
+	s.T().Run("InvalidCursor", func(t *testing.T) {
+		s.repo.On("GetUsers", mock.Anything, opts, filter).Return(nil, apperror.NewParseError("Invalid cursor")).Once()
+
+		result, err := s.service.GetUsers(context.Background(), opts, filter)
+
+		s.Nil(result)
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrParseError, appErr.Code)
+	})
+}
+
+func (s *UserServiceTestSuite) TestSearchUsers() {
+	s.T().Run("Success", func(t *testing.T) {
+		users := []*models.User{{ID: 1, Name: "Alice"}}
+		s.repo.On("Search", mock.Anything, "al", 5).Return(users, nil).Once()
+
+		result, err := s.service.SearchUsers(context.Background(), "al", 5)
+
+		s.NoError(err)
+		s.Equal(users, result)
+	})
+
+	s.T().Run("DefaultLimit", func(t *testing.T) {
+		s.repo.On("Search", mock.Anything, "al", services.DEFAULT_USER_SEARCH_LIMIT).Return([]*models.User{}, nil).Once()
+
+		result, err := s.service.SearchUsers(context.Background(), "al", 0)
+
+		s.NoError(err)
+		s.Empty(result)
+	})
+
+	s.T().Run("RepositoryError", func(t *testing.T) {
+		s.repo.On("Search", mock.Anything, "al", 5).Return(nil, errors.New("db error")).Once()
+
+		result, err := s.service.SearchUsers(context.Background(), "al", 5)
+
+		s.Nil(result)
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrDBQuery, appErr.Code)
+	})
+}
+
+// countingWriter records the size of every write it receives
+type countingWriter struct {
+	writes []int
+	total  int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes = append(w.writes, len(p))
+	w.total += len(p)
+	return len(p), nil
+}
+
+// iterateBatches makes the mocked IterateUsers call its callback with each of batches
+func iterateBatches(batches ...[]*models.User) func(mock.Arguments) {
+	return func(args mock.Arguments) {
+		fn := args.Get(3).(func([]*models.User) error)
+		for _, batch := range batches {
+			if err := fn(batch); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *UserServiceTestSuite) TestExportUsers() {
+	birthday := time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC)
+	address := `12 "Main" Street, Springfield`
+	token := "secret-token"
+	createdAt := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	users := []*models.User{
+		{ID: 1, Name: "Alice", Email: "alice@example.com", Password: "secret-hash", Token: &token, Gender: 2, Birthday: &birthday, Address: &address, CreatedAt: createdAt},
+		{ID: 2, Name: "Bob", Email: "bob@example.com", Password: "secret-hash", Gender: 1, CreatedAt: createdAt},
+	}
+
+	s.T().Run("CSV", func(t *testing.T) {
+		filter := dto.UserFilterInput{Search: "example"}
+		s.repo.On("IterateUsers", mock.Anything, filter, services.USER_EXPORT_BATCH_SIZE, mock.Anything).Return(nil).Run(iterateBatches(users)).Once()
+		var buf strings.Builder
+
+		err := s.service.ExportUsers(context.Background(), filter, "csv", &buf)
+
+		s.NoError(err)
+		s.Equal("id,name,email,gender,birthday,address,created_at\n"+
+			`1,Alice,alice@example.com,2,1990-01-02,"12 ""Main"" Street, Springfield",2024-05-06T07:08:09Z`+"\n"+
+			"2,Bob,bob@example.com,1,,,2024-05-06T07:08:09Z\n", buf.String())
+		s.NotContains(buf.String(), "secret-hash")
+		s.NotContains(buf.String(), "secret-token")
+	})
+
+	s.T().Run("EscapesFormulas", func(t *testing.T) {
+		formulaAddress := "@SUM(1+1)"
+		formulaUsers := []*models.User{
+			{ID: 3, Name: `=HYPERLINK("http://evil.example","x")`, Email: "+cmd@example.com", Address: &formulaAddress, CreatedAt: createdAt},
+			{ID: 4, Name: "-2+3", Email: "tab@example.com", CreatedAt: createdAt},
+			{ID: 5, Name: "\tTabbed", Email: "cr@example.com", CreatedAt: createdAt},
+			{ID: 6, Name: "\rReturned", Email: "plain@example.com", CreatedAt: createdAt},
+			{ID: 7, Name: "Carol = Dave", Email: "carol@example.com", CreatedAt: createdAt},
+		}
+		s.repo.On("IterateUsers", mock.Anything, dto.UserFilterInput{}, services.USER_EXPORT_BATCH_SIZE, mock.Anything).Return(nil).Run(iterateBatches(formulaUsers)).Once()
+		var buf strings.Builder
+
+		err := s.service.ExportUsers(context.Background(), dto.UserFilterInput{}, "csv", &buf)
+
+		s.NoError(err)
+		records, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+		s.Require().NoError(err)
+		s.Require().Len(records, 6)
+		s.Equal([]string{"3", `'=HYPERLINK("http://evil.example","x")`, "'+cmd@example.com", "0", "", "'@SUM(1+1)", "2024-05-06T07:08:09Z"}, records[1])
+		s.Equal("'-2+3", records[2][1])
+		s.Equal("'\tTabbed", records[3][1])
+		s.Equal("'\rReturned", records[4][1])
+		s.Equal("Carol = Dave", records[5][1])
+	})
+
+	s.T().Run("XLSX", func(t *testing.T) {
+		s.repo.On("IterateUsers", mock.Anything, dto.UserFilterInput{}, services.USER_EXPORT_BATCH_SIZE, mock.Anything).Return(nil).Run(iterateBatches(users)).Once()
+		var buf bytes.Buffer
+
+		err := s.service.ExportUsers(context.Background(), dto.UserFilterInput{}, "xlsx", &buf)
+
+		s.NoError(err)
+		archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		s.Require().NoError(err)
+		var sheet []byte
+		for _, file := range archive.File {
+			if file.Name == "xl/worksheets/sheet1.xml" {
+				reader, err := file.Open()
+				s.Require().NoError(err)
+				sheet, err = io.ReadAll(reader)
+				s.Require().NoError(err)
+			}
+		}
+		s.Contains(string(sheet), `<t xml:space="preserve">created_at</t>`)
+		s.Contains(string(sheet), `<t xml:space="preserve">12 &#34;Main&#34; Street, Springfield</t>`)
+		s.NotContains(string(sheet), "secret-hash")
+	})
+
+	s.T().Run("StreamsEachBatch", func(t *testing.T) {
+		batches := make([][]*models.User, 20)
+		for i := range batches {
+			batches[i] = make([]*models.User, services.USER_EXPORT_BATCH_SIZE)
+			for j := range batches[i] {
+				id := uint(i*services.USER_EXPORT_BATCH_SIZE + j + 1)
+				batches[i][j] = &models.User{ID: id, Name: fmt.Sprintf("User %d", id), Email: fmt.Sprintf("user%d@example.com", id), CreatedAt: createdAt}
+			}
+		}
+		writer := &countingWriter{}
+		var writtenAfterBatch []int
+		s.repo.On("IterateUsers", mock.Anything, dto.UserFilterInput{}, services.USER_EXPORT_BATCH_SIZE, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			fn := args.Get(3).(func([]*models.User) error)
+			for _, batch := range batches {
+				s.NoError(fn(batch))
+				writtenAfterBatch = append(writtenAfterBatch, writer.total)
+			}
+		}).Once()
+
+		err := s.service.ExportUsers(context.Background(), dto.UserFilterInput{}, "csv", writer)
+
+		s.NoError(err)
+		// Every batch reaches the writer before the next one is read, in writes much smaller than the export
+		for i := 1; i < len(writtenAfterBatch); i++ {
+			s.Greater(writtenAfterBatch[i], writtenAfterBatch[i-1])
+		}
+		s.Equal(writer.total, writtenAfterBatch[len(writtenAfterBatch)-1])
+		for _, size := range writer.writes {
+			s.Less(size, writer.total/10)
+		}
+	})
+
+	s.T().Run("UnsupportedFormat", func(t *testing.T) {
+		err := s.service.ExportUsers(context.Background(), dto.UserFilterInput{}, "pdf", io.Discard)
+
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrBadRequest, appErr.Code)
+	})
+
+	s.T().Run("RepositoryError", func(t *testing.T) {
+		s.repo.On("IterateUsers", mock.Anything, dto.UserFilterInput{}, services.USER_EXPORT_BATCH_SIZE, mock.Anything).Return(errors.New("db error")).Once()
+		writer := &countingWriter{}
+
+		err := s.service.ExportUsers(context.Background(), dto.UserFilterInput{}, "csv", writer)
+
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrDBQuery, appErr.Code)
+		// Nothing was sent, so the caller can still respond with the error
+		s.Zero(writer.total)
+	})
+}
+
+func (s *UserServiceTestSuite) TestImportUsers() {
+	const header = "email,password,name,birthday,address,gender\n"
+	const password = "Str0ng!Passw0rd#2024"
+
+	s.T().Run("DryRunReportsSkippedRows", func(t *testing.T) {
+		file := "\ufeff" + header +
+			"Alice@Example.com," + password + ",Alice,1990-01-02,\"1 Main St, Apt \"\"B\"\"\",2\n" +
+			"not-an-email," + password + ",,1990-01-02,Street,x\n" +
+			"alice@example.com," + password + ",Alice Again,1990-01-02,Street,2\n" +
+			"bob@example.com," + password + ",Bob,1991-03-04,Street,1\n" +
+			"carol@example.com,too,many,columns\n"
+		s.repo.On("FindExistingEmails", mock.Anything, []string{"alice@example.com", "bob@example.com"}).Return([]string{"bob@example.com"}, nil).Once()
+
+		report, err := s.service.ImportUsers(context.Background(), strings.NewReader(file), true)
+
+		s.Require().NoError(err)
+		s.True(report.DryRun)
+		s.Equal(5, report.Rows)
+		s.Equal(1, report.Imported)
+		s.Require().Len(report.Skipped, 4)
+		s.Equal(3, report.Skipped[0].Row)
+		s.Equal("invalid", report.Skipped[0].Reason)
+		fields := make([]string, 0, len(report.Skipped[0].Fields))
+		for _, field := range report.Skipped[0].Fields {
+			fields = append(fields, field.Field)
+		}
+		s.ElementsMatch([]string{"email", "name", "gender"}, fields)
+		s.Equal(dto.UserImportRowError{Row: 4, Reason: "duplicate_in_file", Fields: []apperror.FieldError{{Field: "email", Message: "email is already used on row 2"}}}, report.Skipped[1])
+		s.Equal(dto.UserImportRowError{Row: 5, Reason: "already_registered", Fields: []apperror.FieldError{{Field: "email", Message: "email is already registered"}}}, report.Skipped[2])
+		s.Equal(6, report.Skipped[3].Row)
+		s.Equal("row", report.Skipped[3].Fields[0].Field)
+	})
+
+	s.T().Run("CreatesValidRows", func(t *testing.T) {
+		file := "Gender, Name ,EMAIL,birthday,address,password\n" +
+			"2,Alice,alice@example.com,1990-01-02,\"1 Main St, Apt \"\"B\"\"\"," + password + "\n" +
+			"1,Bob,bob@example.com,1991-03-04,,weak\n"
+		var storedToken string
+		s.repo.On("FindExistingEmails", mock.Anything, []string{"alice@example.com"}).Return([]string{}, nil).Once()
+		s.repo.On("CreateUsers", mock.Anything, mock.MatchedBy(func(users []*models.User) bool {
+			user := users[0]
+			return len(users) == 1 && user.Email == "alice@example.com" && user.Name == "Alice" && user.Gender == 2 &&
+				*user.Address == `1 Main St, Apt "B"` && user.Birthday.Format(time.DateOnly) == "1990-01-02" &&
+				s.bcrypt.CheckPasswordHash(password, user.Password) && user.Token != nil
+		}), services.USER_IMPORT_BATCH_SIZE).Run(func(args mock.Arguments) {
+			storedToken = *args.Get(1).([]*models.User)[0].Token
+		}).Return(nil).Once()
+		mailed := make(chan services.MailData, 1)
+		s.mailer.On("SendMailVerification", mock.Anything).Run(func(args mock.Arguments) {
+			mailed <- args.Get(0).(services.MailData)
+		}).Return(errors.New("smtp down")).Once()
+
+		report, err := s.service.ImportUsers(context.Background(), strings.NewReader(file), false)
+
+		s.Require().NoError(err)
+		s.False(report.DryRun)
+		s.Equal(2, report.Rows)
+		s.Equal(1, report.Imported)
+		s.Require().Len(report.Skipped, 1)
+		s.Equal(3, report.Skipped[0].Row)
+		// A failed mail does not undo the import
+		select {
+		case data := <-mailed:
+			s.Equal("alice@example.com", data.Email)
+			s.Equal(storedToken, utils.HashToken(data.Token))
+		case <-time.After(time.Second):
+			s.Fail("verification email was not sent to the imported user")
+		}
+	})
+
+	s.T().Run("ShutdownStopsTheVerificationMails", func(t *testing.T) {
+		file := header +
+			"first@example.com," + password + ",First,1990-01-02,Street,1\n" +
+			"second@example.com," + password + ",Second,1990-01-02,Street,1\n"
+		tasks := services.NewBackgroundService(10 * time.Millisecond)
+		localService := services.NewUserService(s.repo, s.bcrypt, s.mailer, s.redis, s.tokens, s.notify, tasks)
+		s.repo.On("FindExistingEmails", mock.Anything, []string{"first@example.com", "second@example.com"}).Return([]string{}, nil).Once()
+		s.repo.On("CreateUsers", mock.Anything, mock.Anything, services.USER_IMPORT_BATCH_SIZE).Return(nil).Once()
+		started, gate := make(chan struct{}), make(chan struct{})
+		s.mailer.On("SendMailVerification", mailWithToken("first@example.com")).Run(func(mock.Arguments) {
+			close(started)
+			<-gate
+		}).Return(nil).Once()
+
+		_, err := localService.ImportUsers(context.Background(), strings.NewReader(file), false)
+		s.Require().NoError(err)
+		<-started
+
+		// The first mail outlasts the shutdown timeout, so the second one is not sent
+		closed := make(chan error, 1)
+		go func() { closed <- tasks.Close() }()
+		time.Sleep(50 * time.Millisecond)
+		close(gate)
+
+		s.Error(<-closed)
+		s.mailer.AssertNotCalled(t, "SendMailVerification", mailWithToken("second@example.com"))
+	})
+
+	s.T().Run("RejectsFile", func(t *testing.T) {
+		tests := []struct {
+			name string
+			file io.Reader
+			code int
+		}{
+			{name: "Empty", file: strings.NewReader(""), code: apperror.ErrBadRequest},
+			{name: "MissingColumn", file: strings.NewReader("email,password,name\n"), code: apperror.ErrBadRequest},
+			{name: "UnknownColumn", file: strings.NewReader(strings.TrimSuffix(header, "\n") + ",role\n"), code: apperror.ErrBadRequest},
+			{name: "RepeatedColumn", file: strings.NewReader(strings.TrimSuffix(header, "\n") + ",email\n"), code: apperror.ErrBadRequest},
+			{name: "Malformed", file: strings.NewReader(header + `a@example.com,"unterminated` + "\n"), code: apperror.ErrBadRequest},
+			{name: "TooLarge", file: iotest.ErrReader(&http.MaxBytesError{Limit: 10}), code: apperror.ErrPayloadTooLarge},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				report, err := s.service.ImportUsers(context.Background(), tt.file, false)
+
+				s.Nil(report)
+				appErr, ok := err.(*apperror.AppError)
+				s.Require().True(ok, err)
+				s.Equal(tt.code, appErr.Code)
+			})
+		}
+	})
+
+	s.T().Run("RepositoryErrors", func(t *testing.T) {
+		file := header + "alice@example.com," + password + ",Alice,1990-01-02,Street,2\n"
+		s.repo.On("FindExistingEmails", mock.Anything, []string{"alice@example.com"}).Return(nil, errors.New("db error")).Once()
+
+		_, err := s.service.ImportUsers(context.Background(), strings.NewReader(file), false)
+
+		appErr, ok := err.(*apperror.AppError)
+		s.Require().True(ok)
+		s.Equal(apperror.ErrDBQuery, appErr.Code)
+
+		s.repo.On("FindExistingEmails", mock.Anything, []string{"alice@example.com"}).Return([]string{}, nil).Once()
+		s.repo.On("CreateUsers", mock.Anything, mock.Anything, services.USER_IMPORT_BATCH_SIZE).Return(errors.New("db error")).Once()
+
+		_, err = s.service.ImportUsers(context.Background(), strings.NewReader(file), false)
+
+		appErr, ok = err.(*apperror.AppError)
+		s.Require().True(ok)
+		s.Equal(apperror.ErrDBInsert, appErr.Code)
+	})
+}
+
+func (s *UserServiceTestSuite) TestGetUser() {
+	s.T().Run("Success", func(t *testing.T) {
+		user := &models.User{ID: 1, Name: "Bob"}
+		s.repo.On("GetByIDUnscoped", mock.Anything, uint(1)).Return(user, nil).Once()
+
+		result, err := s.service.GetUser(context.Background(), 1, false)
+
+		s.NoError(err)
+		s.Equal(user, result)
+	})
+
+	s.T().Run("SoftDeleted", func(t *testing.T) {
+		user := &models.User{ID: 1, DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}
+		s.repo.On("GetByIDUnscoped", mock.Anything, uint(1)).Return(user, nil).Once()
+
+		result, err := s.service.GetUser(context.Background(), 1, false)
+
+		s.Nil(result)
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrNotFound, appErr.Code)
+		s.Equal("User has been deleted", appErr.Message)
+	})
+
+	s.T().Run("SoftDeletedIncluded", func(t *testing.T) {
+		user := &models.User{ID: 1, DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}
+		s.repo.On("GetByIDUnscoped", mock.Anything, uint(1)).Return(user, nil).Once()
+
+		result, err := s.service.GetUser(context.Background(), 1, true)
+
+		s.NoError(err)
+		s.Equal(user, result)
+	})
+
+	s.T().Run("NotFound", func(t *testing.T) {
+		s.repo.On("GetByIDUnscoped", mock.Anything, uint(2)).Return(nil, apperror.New(apperror.ErrNotFound, 1001, "User not found")).Once()
+
+		result, err := s.service.GetUser(context.Background(), 2, false)
+
+		s.Nil(result)
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrNotFound, appErr.Code)
+		s.Equal("User not found", appErr.Message)
+	})
+
+	s.T().Run("RepositoryError", func(t *testing.T) {
+		s.repo.On("GetByIDUnscoped", mock.Anything, uint(3)).Return(nil, errors.New("db error")).Once()
+
+		result, err := s.service.GetUser(context.Background(), 3, false)
+
+		s.Nil(result)
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrDBQuery, appErr.Code)
+	})
+}
+
+func (s *UserServiceTestSuite) TestRestoreUser() {
+	s.T().Run("Success", func(t *testing.T) {
+		user := &models.User{ID: 1, DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}
+		s.repo.On("GetByIDUnscoped", mock.Anything, uint(1)).Return(user, nil).Once()
+		s.repo.On("Restore", mock.Anything, uint(1)).Return(nil).Once()
+		s.redis.On("Delete", mock.Anything, "profile:v2:1").Return(nil).Once()
+		s.redis.On("Delete", mock.Anything, "cache_refresh:profile:v2:1").Return(nil).Once()
+
+		result, err := s.service.RestoreUser(context.Background(), 1)
+
+		s.NoError(err)
+		s.False(result.DeletedAt.Valid)
+	})
+
+	s.T().Run("NotDeletedIsNoOp", func(t *testing.T) {
+		user := &models.User{ID: 1}
+		s.repo.On("GetByIDUnscoped", mock.Anything, uint(1)).Return(user, nil).Once()
+
+		result, err := s.service.RestoreUser(context.Background(), 1)
+
+		s.NoError(err)
+		s.Equal(user, result)
+	})
+
+	s.T().Run("NotFound", func(t *testing.T) {
+		s.repo.On("GetByIDUnscoped", mock.Anything, uint(2)).Return(nil, apperror.New(apperror.ErrNotFound, 1001, "User not found")).Once()
+
+		result, err := s.service.RestoreUser(context.Background(), 2)
+
+		s.Nil(result)
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrNotFound, appErr.Code)
+	})
+
+	s.T().Run("RestoreError", func(t *testing.T) {
+		user := &models.User{ID: 1, DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}
+		s.repo.On("GetByIDUnscoped", mock.Anything, uint(1)).Return(user, nil).Once()
+		s.repo.On("Restore", mock.Anything, uint(1)).Return(errors.New("db error")).Once()
+
+		result, err := s.service.RestoreUser(context.Background(), 1)
+
+		s.Nil(result)
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrDBUpdate, appErr.Code)
+	})
+
+	s.T().Run("CacheInvalidationFailureIsIgnored", func(t *testing.T) {
+		user := &models.User{ID: 1, DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}
+		s.repo.On("GetByIDUnscoped", mock.Anything, uint(1)).Return(user, nil).Once()
+		s.repo.On("Restore", mock.Anything, uint(1)).Return(nil).Once()
+		s.redis.On("Delete", mock.Anything, "profile:v2:1").Return(errors.New("redis down")).Once()
+		s.redis.On("Delete", mock.Anything, "cache_refresh:profile:v2:1").Return(nil)
+
+		result, err := s.service.RestoreUser(context.Background(), 1)
+
+		s.NoError(err)
+		s.NotNil(result)
+	})
+}
+
+func (s *UserServiceTestSuite) TestUnlockUser() {
+	s.T().Run("Success", func(t *testing.T) {
+		s.repo.On("GetByID", mock.Anything, uint(1)).Return(&models.User{ID: 1, Email: "Locked@Example.com"}, nil).Once()
+		s.redis.On("Delete", mock.Anything, constants.LOGIN_FAIL+"locked@example.com").Return(nil).Once()
+
+		err := s.service.UnlockUser(context.Background(), 1)
+
+		s.NoError(err)
+	})
+
+	s.T().Run("NotFound", func(t *testing.T) {
+		s.repo.On("GetByID", mock.Anything, uint(2)).Return(nil, errors.New("not found")).Once()
+
+		err := s.service.UnlockUser(context.Background(), 2)
+
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrNotFound, appErr.Code)
+	})
+
+	s.T().Run("CacheFailure", func(t *testing.T) {
+		s.repo.On("GetByID", mock.Anything, uint(3)).Return(&models.User{ID: 3, Email: "locked@example.com"}, nil).Once()
+		s.redis.On("Delete", mock.Anything, constants.LOGIN_FAIL+"locked@example.com").Return(errors.New("redis down")).Once()
+
+		err := s.service.UnlockUser(context.Background(), 3)
+
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrCacheDelete, appErr.Code)
+	})
+}
+
+func (s *UserServiceTestSuite) TestForceResetPassword() {
+	s.T().Run("Success", func(t *testing.T) {
+		user := &models.User{ID: 1, Password: "old-hash"}
+		s.repo.On("GetByID", mock.Anything, uint(1)).Return(user, nil).Once()
+		s.repo.On("Update", mock.Anything, mock.MatchedBy(func(u *models.User) bool {
+			return u.MustChangePassword && u.Password != "old-hash"
+		})).Return(nil).Once()
+		s.tokens.On("DeleteAllByUserID", mock.Anything, uint(1)).Return(int64(3), nil).Once()
+		s.notify.On("NotifyPasswordChanged", mock.Anything, user).Return(nil).Once()
+		s.redis.On("Delete", mock.Anything, "profile:v2:1").Return(nil).Once()
+		s.redis.On("Delete", mock.Anything, "cache_refresh:profile:v2:1").Return(nil).Once()
+
+		temporaryPassword, err := s.service.ForceResetPassword(context.Background(), 1)
+
+		s.NoError(err)
+		s.Len(temporaryPassword, services.TEMPORARY_PASSWORD_LENGTH)
+		s.True(s.bcrypt.CheckPasswordHash(temporaryPassword, user.Password))
+	})
+
+	s.T().Run("NotFound", func(t *testing.T) {
+		s.repo.On("GetByID", mock.Anything, uint(2)).Return(nil, errors.New("not found")).Once()
+
+		temporaryPassword, err := s.service.ForceResetPassword(context.Background(), 2)
+
+		s.Empty(temporaryPassword)
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrNotFound, appErr.Code)
+	})
+
+	s.T().Run("HashPasswordFailure", func(t *testing.T) {
+		mockBcrypt := &mockBcryptService{hashErr: errors.New("hash failed")}
+		localService := services.NewUserService(s.repo, mockBcrypt, s.mailer, s.redis, s.tokens, s.notify, s.tasks)
+		s.repo.On("GetByID", mock.Anything, uint(3)).Return(&models.User{ID: 3}, nil).Once()
+
+		temporaryPassword, err := localService.ForceResetPassword(context.Background(), 3)
+
+		s.Empty(temporaryPassword)
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrPasswordHashFailed, appErr.Code)
+	})
+
+	s.T().Run("UpdateFailure", func(t *testing.T) {
+		user := &models.User{ID: 4}
+		s.repo.On("GetByID", mock.Anything, uint(4)).Return(user, nil).Once()
+		s.repo.On("Update", mock.Anything, user).Return(errors.New("update failed")).Once()
+
+		temporaryPassword, err := s.service.ForceResetPassword(context.Background(), 4)
+
+		s.Empty(temporaryPassword)
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrDBUpdate, appErr.Code)
+	})
+
+	s.T().Run("SessionRevocationFailure", func(t *testing.T) {
+		user := &models.User{ID: 5}
+		s.repo.On("GetByID", mock.Anything, uint(5)).Return(user, nil).Once()
+		s.repo.On("Update", mock.Anything, user).Return(nil).Once()
+		s.tokens.On("DeleteAllByUserID", mock.Anything, uint(5)).Return(int64(0), apperror.NewDBDeleteError("Failed to delete refresh tokens")).Once()
+
+		temporaryPassword, err := s.service.ForceResetPassword(context.Background(), 5)
+
+		s.Empty(temporaryPassword)
+		s.Error(err)
+	})
+
+	s.T().Run("ChangePasswordClearsFlag", func(t *testing.T) {
+		input := &dto.ChangePasswordInput{
+			OldPassword:     "temporary-password",
+			NewPassword:     "new-password",
+			ConfirmPassword: "new-password",
+		}
+		hash, err := s.bcrypt.HashPassword(input.OldPassword)
+		s.Require().NoError(err)
+		user := &models.User{ID: 6, Password: hash, MustChangePassword: true}
+		s.repo.On("GetByID", mock.Anything, uint(6)).Return(user, nil).Once()
+		s.repo.On("Update", mock.Anything, user).Return(nil).Once()
+		s.tokens.On("DeleteAllByUserID", mock.Anything, uint(6)).Return(int64(1), nil).Once()
+		s.notify.On("NotifyPasswordChanged", mock.Anything, user).Return(nil).Once()
+		s.redis.On("Delete", mock.Anything, "profile:v2:6").Return(nil).Once()
+		s.redis.On("Delete", mock.Anything, "cache_refresh:profile:v2:6").Return(nil).Once()
+
+		result, err := s.service.ChangePassword(context.Background(), 6, input)
+
+		s.NoError(err)
+		s.False(result.MustChangePassword)
+	})
+}
+
+func (s *UserServiceTestSuite) TestDeleteUsers() {
+	s.T().Run("Success", func(t *testing.T) {
+		s.repo.On("DeleteUsers", mock.Anything, []uint{1, 2, 3}).Return([]uint{1, 3}, nil).Once()
+		for _, id := range []uint{1, 3} {
+			s.tokens.On("DeleteAllByUserID", mock.Anything, id).Return(int64(1), nil).Once()
+			s.redis.On("Delete", mock.Anything, fmt.Sprintf("profile:v2:%d", id)).Return(nil).Once()
+			s.redis.On("Delete", mock.Anything, fmt.Sprintf("cache_refresh:profile:v2:%d", id)).Return(nil).Once()
+		}
+
+		result, err := s.service.DeleteUsers(context.Background(), []uint{3, 1, 2, 1})
+
+		s.NoError(err)
+		s.Equal([]uint{1, 3}, result.Deleted)
+		s.Equal([]uint{2}, result.NotFound)
+	})
+
+	s.T().Run("NoneFound", func(t *testing.T) {
+		s.repo.On("DeleteUsers", mock.Anything, []uint{8, 9}).Return([]uint{}, nil).Once()
+
+		result, err := s.service.DeleteUsers(context.Background(), []uint{8, 9})
+
+		s.NoError(err)
+		s.Empty(result.Deleted)
+		s.Equal([]uint{8, 9}, result.NotFound)
+	})
+
+	s.T().Run("CleanupFailuresAreIgnored", func(t *testing.T) {
+		s.repo.On("DeleteUsers", mock.Anything, []uint{4}).Return([]uint{4}, nil).Once()
+		s.tokens.On("DeleteAllByUserID", mock.Anything, uint(4)).Return(int64(0), apperror.NewDBDeleteError("Failed to delete refresh tokens")).Once()
+		s.redis.On("Delete", mock.Anything, "profile:v2:4").Return(errors.New("redis down")).Once()
+		s.redis.On("Delete", mock.Anything, "cache_refresh:profile:v2:4").Return(nil)
+
+		result, err := s.service.DeleteUsers(context.Background(), []uint{4})
+
+		s.NoError(err)
+		s.Equal([]uint{4}, result.Deleted)
+	})
+
+	s.T().Run("RepositoryError", func(t *testing.T) {
+		s.repo.On("DeleteUsers", mock.Anything, []uint{5}).Return(nil, errors.New("db error")).Once()
+
+		result, err := s.service.DeleteUsers(context.Background(), []uint{5})
+
+		s.Nil(result)
+		appErr, ok := err.(*apperror.AppError)
+		s.True(ok)
+		s.Equal(apperror.ErrDBDelete, appErr.Code)
+	})
+}
+
+func (s *UserServiceTestSuite) TestGetUserRoles() {
+	s.T().Run("Success", func(t *testing.T) {
+		user := &models.User{ID: 1, Roles: []models.Role{{ID: 1, Name: constants.ROLE_ADMIN}, {ID: 2, Name: "editor"}}}
+		s.repo.On("GetByIDWithRoles", mock.Anything, uint(1)).Return(user, nil).Once()
+
+		roles, err := s.service.GetUserRoles(context.Background(), 1)
+
+		s.NoError(err)
+		s.Require().Len(roles, 2)
+		s.Equal(constants.ROLE_PERMISSIONS[constants.ROLE_ADMIN], roles[0].Permissions)
+		// Roles without configured permissions list none rather than null
+		s.Equal("editor", roles[1].Name)
+		s.NotNil(roles[1].Permissions)
+		s.Empty(roles[1].Permissions)
+	})
+
+	s.T().Run("NoRoles", func(t *testing.T) {
+		s.repo.On("GetByIDWithRoles", mock.Anything, uint(2)).Return(&models.User{ID: 2}, nil).Once()
+
+		roles, err := s.service.GetUserRoles(context.Background(), 2)
+
+		s.NoError(err)
+		s.NotNil(roles)
+		s.Empty(roles)
+	})
+
+	s.T().Run("UserNotFound", func(t *testing.T) {
+		s.repo.On("GetByIDWithRoles", mock.Anything, uint(3)).Return(nil, apperror.New(apperror.ErrNotFound, 1001, "User not found")).Once()
+
+		roles, err := s.service.GetUserRoles(context.Background(), 3)
+
+		s.Nil(roles)
+		assertAppErrorCode(t, err, apperror.ErrNotFound)
+	})
+
+	s.T().Run("RepositoryError", func(t *testing.T) {
+		s.repo.On("GetByIDWithRoles", mock.Anything, uint(4)).Return(nil, errors.New("db error")).Once()
+
+		_, err := s.service.GetUserRoles(context.Background(), 4)
+
+		assertAppErrorCode(t, err, apperror.ErrDBQuery)
+	})
+}
+
+func (s *UserServiceTestSuite) TestAssignRoles() {
+	s.T().Run("Success", func(t *testing.T) {
+		s.repo.On("GetByID", mock.Anything, uint(1)).Return(&models.User{ID: 1}, nil).Once()
+		s.repo.On("AssignRoles", mock.Anything, uint(1), []uint{2, 3}).Return(nil).Once()
+		s.redis.On("Delete", mock.Anything, "user_roles:1").Return(nil).Once()
+		s.redis.On("Delete", mock.Anything, "profile:v2:1").Return(nil).Once()
+		s.redis.On("Delete", mock.Anything, "cache_refresh:profile:v2:1").Return(nil).Once()
+
+		err := s.service.AssignRoles(context.Background(), 1, []uint{2, 3})
+
+		s.NoError(err)
+	})
+
+	s.T().Run("UserNotFound", func(t *testing.T) {
+		s.repo.On("GetByID", mock.Anything, uint(2)).Return(nil, apperror.New(apperror.ErrNotFound, 1001, "User not found")).Once()
+
+		err := s.service.AssignRoles(context.Background(), 2, []uint{2})
+
+		assertAppErrorCode(t, err, apperror.ErrNotFound)
+		s.repo.AssertNotCalled(t, "AssignRoles", mock.Anything, uint(2), mock.Anything)
+	})
+
+	s.T().Run("RolesNotFound", func(t *testing.T) {
+		s.repo.On("GetByID", mock.Anything, uint(1)).Return(&models.User{ID: 1}, nil).Once()
+		s.repo.On("AssignRoles", mock.Anything, uint(1), []uint{2, 99}).Return(apperror.NewNotFoundError("Roles not found: 99")).Once()
+
+		err := s.service.AssignRoles(context.Background(), 1, []uint{2, 99})
+
+		assertAppErrorCode(t, err, apperror.ErrNotFound)
+		s.Equal("Roles not found: 99", err.(*apperror.AppError).Message)
+	})
+
+	s.T().Run("RepositoryError", func(t *testing.T) {
+		s.repo.On("GetByID", mock.Anything, uint(1)).Return(&models.User{ID: 1}, nil).Once()
+		s.repo.On("AssignRoles", mock.Anything, uint(1), []uint{2}).Return(errors.New("db error")).Once()
+
+		err := s.service.AssignRoles(context.Background(), 1, []uint{2})
+
+		assertAppErrorCode(t, err, apperror.ErrDBUpdate)
+	})
+
+	s.T().Run("CachedRolesNotCleared", func(t *testing.T) {
+		s.repo.On("GetByID", mock.Anything, uint(1)).Return(&models.User{ID: 1}, nil).Once()
+		s.repo.On("AssignRoles", mock.Anything, uint(1), []uint{2}).Return(nil).Once()
+		s.redis.On("Delete", mock.Anything, "user_roles:1").Return(apperror.NewCacheDeleteError("connection refused")).Once()
+
+		err := s.service.AssignRoles(context.Background(), 1, []uint{2})
+
+		assertAppErrorCode(t, err, apperror.ErrCacheDelete)
+	})
+}
+
+func (s *UserServiceTestSuite) TestRemoveRoles() {
+	s.T().Run("Success", func(t *testing.T) {
+		s.repo.On("GetByID", mock.Anything, uint(1)).Return(&models.User{ID: 1}, nil).Once()
+		s.repo.On("RemoveRoles", mock.Anything, uint(1), []uint{2}).Return(nil).Once()
+		s.redis.On("Delete", mock.Anything, "user_roles:1").Return(nil).Once()
+		s.redis.On("Delete", mock.Anything, "profile:v2:1").Return(apperror.NewCacheDeleteError("connection refused")).Once()
+		s.redis.On("Delete", mock.Anything, "cache_refresh:profile:v2:1").Return(nil)
+
+		// Failing to clear the cached profile is only logged
+		err := s.service.RemoveRoles(context.Background(), 1, []uint{2})
+
+		s.NoError(err)
+	})
+}
+
+func TestUserServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(UserServiceTestSuite))
+}
+
+func TestCreateUserWithRoles(t *testing.T) {
+	birthday := "1990-01-01"
+	address := "123 Main Street"
+	setup := func(t *testing.T) (*gorm.DB, services.UserService, []models.Role) {
+		db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+		require.NoError(t, err)
+		// The transaction and the queries around it must see the same in-memory database
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		sqlDB.SetMaxOpenConns(1)
+		require.NoError(t, db.AutoMigrate(&models.User{}, &models.Role{}))
+		roles := []models.Role{{Name: constants.ROLE_ADMIN}, {Name: constants.ROLE_USER}}
+		require.NoError(t, db.Create(&roles).Error)
+
+		mailer := new(mocks.MockMailerService)
+		mailer.On("SendMailVerification", mock.AnythingOfType("services.MailData")).Return(nil).Maybe()
+		service := services.NewUserService(repositories.NewUserRepository(db), services.NewBcryptService(), mailer, new(mocks.MockRedisService), new(mocks.MockRefreshTokenService), new(mocks.MockNotificationService), services.NewBackgroundService(time.Second))
+		return db, service, roles
+	}
+	newInput := func(email string, roleIDs ...uint) *dto.CreateUserInput {
+		return &dto.CreateUserInput{Email: email, Password: "Password123!", Name: "New User", Birthday: &birthday, Address: &address, Gender: 1, RoleIDs: roleIDs}
+	}
+	userCount := func(t *testing.T, db *gorm.DB) int64 {
+		var count int64
+		require.NoError(t, db.Model(&models.User{}).Count(&count).Error)
+		return count
+	}
+
+	t.Run("Assigns The Roles", func(t *testing.T) {
+		db, service, roles := setup(t)
+
+		user, err := service.CreateUser(context.Background(), newInput("roles@example.com", roles[0].ID, roles[1].ID, roles[0].ID))
+
+		require.NoError(t, err)
+		var names []string
+		require.NoError(t, db.Table("roles").Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+			Where("user_roles.user_id = ?", user.ID).Order("roles.name").Pluck("roles.name", &names).Error)
+		assert.Equal(t, []string{constants.ROLE_ADMIN, constants.ROLE_USER}, names)
+	})
+
+	t.Run("Missing Roles Create Nothing", func(t *testing.T) {
+		db, service, roles := setup(t)
+
+		_, err := service.CreateUser(context.Background(), newInput("missing@example.com", 42, roles[0].ID))
+		assertAppErrorCode(t, err, apperror.ErrBadRequest)
+		assert.Equal(t, "Role 42 does not exist", err.(*apperror.AppError).Message)
+
+		_, err = service.CreateUser(context.Background(), newInput("missing@example.com", 99, 42, 99))
+		assert.Equal(t, "Roles 42, 99 do not exist", err.(*apperror.AppError).Message)
+		assert.Zero(t, userCount(t, db))
+	})
+
+	t.Run("Failed Role Assignment Rolls Back The User", func(t *testing.T) {
+		db, service, roles := setup(t)
+		// The roles exist, so the user is inserted before linking them fails
+		require.NoError(t, db.Migrator().DropTable("user_roles"))
+
+		_, err := service.CreateUser(context.Background(), newInput("rollback@example.com", roles[0].ID))
+
+		assertAppErrorCode(t, err, apperror.ErrDBInsert)
+		assert.Zero(t, userCount(t, db))
+	})
 }
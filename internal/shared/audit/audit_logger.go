@@ -0,0 +1,173 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionLogin              = "auth.login"
+	ActionLoginFailed        = "auth.login_failed"
+	ActionSessionRevoked     = "auth.session_revoke"
+	ActionDeviceMismatch     = "auth.refresh_device_mismatch"
+	ActionPasswordChanged    = "user.password_changed"
+	ActionPasswordReset      = "user.password_reset"
+	ActionPasswordForceReset = "user.password_force_reset"
+	ActionUserCreated        = "user.create"
+	ActionProfileUpdated     = "user.profile_update"
+	ActionAvatarUpdated      = "user.avatar_update"
+	ActionAvatarDeleted      = "user.avatar_delete"
+	ActionPreferencesUpdated = "user.preferences_update"
+	ActionUserRestored       = "user.restore"
+	ActionUserUnlocked       = "user.unlock"
+	ActionDeletionRequested  = "user.deletion_request"
+	ActionAccountReactivated = "user.reactivate"
+	ActionEmailChangeRequest = "user.email_change_request"
+	ActionEmailChanged       = "user.email_change"
+	ActionUsersDeleted       = "user.bulk_delete"
+	ActionUsersExported      = "user.export"
+	ActionUsersImported      = "user.import"
+	ActionRolesAssigned      = "user.roles_assign"
+	ActionRolesRemoved       = "user.roles_remove"
+	ActionSettingUpdated     = "setting.update"
+	ActionSettingDeleted     = "setting.delete"
+	ActionMaintenanceUpdated = "maintenance.update"
+)
+
+// TargetTypeUser is the target type of actions applied to a user
+const TargetTypeUser = "user"
+
+// maskFields are metadata keys censored before an entry is written
+var maskFields = []string{
+	"password", "old_password", "new_password", "confirm_password",
+	"token", "access_token", "refresh_token", "secret",
+}
+
+// Target is the resource an action was applied to. The zero value means there is none
+type Target struct {
+	Type string
+	ID   uint
+}
+
+// User returns the target for an action applied to the user with the given ID
+func User(id uint) Target {
+	return Target{Type: TargetTypeUser, ID: id}
+}
+
+// Entry is one line of the audit log
+type Entry struct {
+	Timestamp  string         `json:"timestamp"`
+	Action     string         `json:"action"`
+	UserID     uint           `json:"user_id,omitempty"`
+	TargetType string         `json:"target_type,omitempty"`
+	TargetID   uint           `json:"target_id,omitempty"`
+	ClientIP   string         `json:"client_ip"`
+	UserAgent  string         `json:"user_agent,omitempty"`
+	RequestID  string         `json:"request_id,omitempty"`
+	Metadata   map[string]any `json:"metadata,omitempty"`
+}
+
+// Store persists audit entries so they can be queried later. services.AuditService implements it
+type Store interface {
+	Record(ctx context.Context, log *models.AuditLog)
+}
+
+// AuditLogger records security-sensitive actions as JSON lines, separately from the application log
+type AuditLogger interface {
+	Record(ctx *gin.Context, action string, userID uint, target Target, metadata map[string]any)
+}
+
+type auditLoggerImpl struct {
+	mu     sync.Mutex
+	out    io.Writer
+	stores []Store
+	now    func() time.Time
+}
+
+// NewAuditLogger returns an AuditLogger writing one JSON line per entry to out
+// and handing every entry to the given stores
+func NewAuditLogger(out io.Writer, stores ...Store) AuditLogger {
+	return &auditLoggerImpl{
+		out:    out,
+		stores: stores,
+		now:    time.Now,
+	}
+}
+
+// Record writes an entry for the action. A userID of zero means the user is unknown.
+// Metadata values under sensitive keys are censored; a write failure is only logged.
+func (l *auditLoggerImpl) Record(ctx *gin.Context, action string, userID uint, target Target, metadata map[string]any) {
+	now := l.now()
+	entry := Entry{
+		Timestamp:  now.UTC().Format(time.RFC3339Nano),
+		Action:     action,
+		UserID:     userID,
+		TargetType: target.Type,
+		TargetID:   target.ID,
+		ClientIP:   ctx.ClientIP(),
+		UserAgent:  ctx.Request.UserAgent(),
+		RequestID:  logger.RequestIDFromContext(ctx.Request.Context()),
+	}
+	if len(metadata) > 0 {
+		entry.Metadata = utils.CensorSensitiveData(metadata, maskFields).(map[string]any)
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Failed to encode audit entry %s: %v", action, err)
+		return
+	}
+
+	l.mu.Lock()
+	if _, err := l.out.Write(append(line, '\n')); err != nil {
+		logger.WithContext(ctx.Request.Context()).Errorf("Failed to write audit entry %s: %v", action, err)
+	}
+	l.mu.Unlock()
+
+	if len(l.stores) == 0 {
+		return
+	}
+	log := entry.toModel(now)
+	for _, store := range l.stores {
+		store.Record(ctx.Request.Context(), log)
+	}
+}
+
+// toModel converts the entry to the persisted form. The request ID is kept in the metadata
+func (entry Entry) toModel(createdAt time.Time) *models.AuditLog {
+	log := &models.AuditLog{
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		IP:         entry.ClientIP,
+		UserAgent:  entry.UserAgent,
+		CreatedAt:  createdAt,
+	}
+	if entry.UserID != 0 {
+		log.ActorUserID = &entry.UserID
+	}
+	if entry.TargetType != "" {
+		log.TargetID = &entry.TargetID
+	}
+
+	metadata := make(map[string]any, len(entry.Metadata)+1)
+	for key, value := range entry.Metadata {
+		metadata[key] = value
+	}
+	if entry.RequestID != "" {
+		metadata["request_id"] = entry.RequestID
+	}
+	if len(metadata) > 0 {
+		// The metadata already went through json.Marshal as part of the entry, so this cannot fail
+		log.Metadata, _ = json.Marshal(metadata)
+	}
+	return log
+}
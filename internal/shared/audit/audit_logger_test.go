@@ -0,0 +1,147 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
+)
+
+type failingWriter struct{}
+
+type recordingStore struct {
+	logs []*models.AuditLog
+}
+
+func (s *recordingStore) Record(_ context.Context, log *models.AuditLog) {
+	s.logs = append(s.logs, log)
+}
+
+func (failingWriter) Write(_ []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func newTestContext() *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	req, _ := http.NewRequest("POST", "/api/v1/login", nil)
+	req.RemoteAddr = "203.0.113.7:5000"
+	req.Header.Set("User-Agent", "audit-test")
+	c.Request = req.WithContext(logger.WithRequestIDContext(req.Context(), "req-123"))
+	return c
+}
+
+func TestAuditLogger_Record(t *testing.T) {
+	t.Run("WritesJSONLine", func(t *testing.T) {
+		var buf bytes.Buffer
+		auditLogger := NewAuditLogger(&buf).(*auditLoggerImpl)
+		auditLogger.now = func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+		auditLogger.Record(newTestContext(), ActionLogin, 42, User(42), map[string]any{"email": "john@example.com"})
+
+		require.True(t, strings.HasSuffix(buf.String(), "\n"))
+		var entry Entry
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "2025-01-02T03:04:05Z", entry.Timestamp)
+		assert.Equal(t, ActionLogin, entry.Action)
+		assert.Equal(t, uint(42), entry.UserID)
+		assert.Equal(t, TargetTypeUser, entry.TargetType)
+		assert.Equal(t, uint(42), entry.TargetID)
+		assert.Equal(t, "203.0.113.7", entry.ClientIP)
+		assert.Equal(t, "audit-test", entry.UserAgent)
+		assert.Equal(t, "req-123", entry.RequestID)
+		assert.Equal(t, "john@example.com", entry.Metadata["email"])
+	})
+
+	t.Run("CensorsSensitiveMetadata", func(t *testing.T) {
+		var buf bytes.Buffer
+		NewAuditLogger(&buf).Record(newTestContext(), ActionPasswordChanged, 1, Target{}, map[string]any{
+			"new_password": "SuperSecret123!",
+			"details":      map[string]any{"refresh_token": "refresh-token-value"},
+		})
+
+		assert.NotContains(t, buf.String(), "SuperSecret123!")
+		assert.NotContains(t, buf.String(), "refresh-token-value")
+	})
+
+	t.Run("OmitsEmptyMetadata", func(t *testing.T) {
+		var buf bytes.Buffer
+		NewAuditLogger(&buf).Record(newTestContext(), ActionPasswordChanged, 1, Target{}, nil)
+
+		assert.NotContains(t, buf.String(), "metadata")
+	})
+
+	t.Run("OneLinePerEntry", func(t *testing.T) {
+		var buf bytes.Buffer
+		auditLogger := NewAuditLogger(&buf)
+		auditLogger.Record(newTestContext(), ActionLoginFailed, 0, Target{}, nil)
+		auditLogger.Record(newTestContext(), ActionLogin, 1, Target{}, nil)
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Len(t, lines, 2)
+	})
+
+	t.Run("WriteErrorDoesNotPanic", func(t *testing.T) {
+		assert.NotPanics(t, func() {
+			NewAuditLogger(failingWriter{}).Record(newTestContext(), ActionLogin, 1, Target{}, nil)
+		})
+	})
+}
+
+func TestAuditLogger_Stores(t *testing.T) {
+	t.Run("HandsEntryToEveryStore", func(t *testing.T) {
+		first, second := &recordingStore{}, &recordingStore{}
+		auditLogger := NewAuditLogger(io.Discard, first, second).(*auditLoggerImpl)
+		createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+		auditLogger.now = func() time.Time { return createdAt }
+
+		auditLogger.Record(newTestContext(), ActionPasswordForceReset, 1, User(7), map[string]any{"new_password": "SuperSecret123!"})
+
+		require.Len(t, first.logs, 1)
+		assert.Equal(t, first.logs, second.logs)
+		log := first.logs[0]
+		assert.Equal(t, ActionPasswordForceReset, log.Action)
+		require.NotNil(t, log.ActorUserID)
+		assert.Equal(t, uint(1), *log.ActorUserID)
+		assert.Equal(t, TargetTypeUser, log.TargetType)
+		require.NotNil(t, log.TargetID)
+		assert.Equal(t, uint(7), *log.TargetID)
+		assert.Equal(t, "203.0.113.7", log.IP)
+		assert.Equal(t, "audit-test", log.UserAgent)
+		assert.Equal(t, createdAt, log.CreatedAt)
+
+		var metadata map[string]any
+		require.NoError(t, json.Unmarshal(log.Metadata, &metadata))
+		assert.Equal(t, "req-123", metadata["request_id"])
+		assert.NotContains(t, string(log.Metadata), "SuperSecret123!")
+	})
+
+	t.Run("UnknownActorAndNoTarget", func(t *testing.T) {
+		store := &recordingStore{}
+		NewAuditLogger(io.Discard, store).Record(newTestContext(), ActionLoginFailed, 0, Target{}, nil)
+
+		require.Len(t, store.logs, 1)
+		assert.Nil(t, store.logs[0].ActorUserID)
+		assert.Empty(t, store.logs[0].TargetType)
+		assert.Nil(t, store.logs[0].TargetID)
+	})
+
+	t.Run("StoresStillCalledWhenWriteFails", func(t *testing.T) {
+		store := &recordingStore{}
+		NewAuditLogger(failingWriter{}, store).Record(newTestContext(), ActionLogin, 1, User(1), nil)
+
+		assert.Len(t, store.logs, 1)
+	})
+}
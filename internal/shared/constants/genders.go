@@ -0,0 +1,8 @@
+package constants
+
+// Genders stored in users.gender
+const (
+	GENDER_MALE   int16 = 1
+	GENDER_FEMALE int16 = 2
+	GENDER_OTHER  int16 = 3
+)
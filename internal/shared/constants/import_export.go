@@ -0,0 +1,14 @@
+package constants
+
+// Export formats
+const (
+	EXPORT_FORMAT_CSV  string = "csv"
+	EXPORT_FORMAT_XLSX string = "xlsx"
+)
+
+// Reasons a row of a user import is skipped
+const (
+	IMPORT_ROW_INVALID            string = "invalid"
+	IMPORT_ROW_DUPLICATE_IN_FILE  string = "duplicate_in_file"
+	IMPORT_ROW_ALREADY_REGISTERED string = "already_registered"
+)
@@ -2,3 +2,37 @@ package constants
 
 // LIMIT is the maximum number of items to be returned in a single page
 const LIMIT int = 50
+
+// PROFILE is the cache key prefix for user profiles, followed by the user ID. Profiles are cached as
+// dto.ProfileResponse; bump the version when its JSON changes, so entries in the old form are never read
+const PROFILE string = "profile:v2:"
+
+// MAX_LIMIT is the largest page size a client may request
+const MAX_LIMIT int = 100
+
+// VERIFICATION_RESEND is the cache key prefix marking a recent verification email, followed by the email
+const VERIFICATION_RESEND string = "verification_resend:"
+
+// LOGIN_FAIL is the cache key prefix counting consecutive failed logins, followed by the email
+const LOGIN_FAIL string = "login_fail:"
+
+// RESET_COOLDOWN is the cache key prefix marking a recent password reset request, followed by the email
+const RESET_COOLDOWN string = "reset_cooldown:"
+
+// FORGOT_PASSWORD is the cache key prefix counting password reset requests, followed by the email
+const FORGOT_PASSWORD string = "forgot_password:"
+
+// USER_ROLES is the cache key prefix for the role names of a user, followed by the user ID
+const USER_ROLES string = "user_roles:"
+
+// RATE_LIMIT is the cache key prefix for rate limit counters, followed by the scope, client IP and window
+const RATE_LIMIT string = "rate_limit:"
+
+// USER is the cache key prefix for users returned by the admin user lookup, followed by the user ID
+const USER string = "user:"
+
+// CACHE_REFRESH is the cache key prefix of the lock held while a cached value is refreshed ahead of its expiry, followed by the cached key
+const CACHE_REFRESH string = "cache_refresh:"
+
+// REVOKED_ACCESS_TOKEN is the cache key prefix marking a revoked access token, followed by its jti
+const REVOKED_ACCESS_TOKEN string = "revoked_access_token:"
@@ -0,0 +1,32 @@
+package constants
+
+// Permissions checked by PermissionMiddleware
+const (
+	PERMISSION_USERS_READ           string = "users.read"
+	PERMISSION_USERS_CREATE         string = "users.create"
+	PERMISSION_USERS_RESTORE        string = "users.restore"
+	PERMISSION_USERS_DELETE         string = "users.delete"
+	PERMISSION_USERS_RESET_PASSWORD string = "users.reset_password"
+	PERMISSION_USERS_ROLES          string = "users.roles"
+	PERMISSION_USERS_UNLOCK         string = "users.unlock"
+	PERMISSION_AUDIT_LOGS_READ      string = "audit_logs.read"
+	PERMISSION_SETTINGS_READ        string = "settings.read"
+	PERMISSION_SETTINGS_WRITE       string = "settings.write"
+)
+
+// ROLE_PERMISSIONS lists the permissions granted by each role
+var ROLE_PERMISSIONS = map[string][]string{
+	ROLE_ADMIN: {
+		PERMISSION_USERS_READ,
+		PERMISSION_USERS_CREATE,
+		PERMISSION_USERS_RESTORE,
+		PERMISSION_USERS_DELETE,
+		PERMISSION_USERS_RESET_PASSWORD,
+		PERMISSION_USERS_ROLES,
+		PERMISSION_USERS_UNLOCK,
+		PERMISSION_AUDIT_LOGS_READ,
+		PERMISSION_SETTINGS_READ,
+		PERMISSION_SETTINGS_WRITE,
+	},
+	ROLE_USER: {},
+}
@@ -0,0 +1,19 @@
+package constants
+
+// Themes a user may pick in their preferences
+const (
+	THEME_LIGHT  string = "light"
+	THEME_DARK   string = "dark"
+	THEME_SYSTEM string = "system" // Follow the theme of the device
+)
+
+// LOCALES are the locales a user may pick in their preferences, the languages the API has messages in
+var LOCALES = []string{"en", "ja", "vi"}
+
+// Preferences of a user who has not picked their own
+const (
+	DEFAULT_TIMEZONE            string = "UTC"
+	DEFAULT_LOCALE              string = "en"
+	DEFAULT_EMAIL_NOTIFICATIONS bool   = true
+	DEFAULT_THEME               string = THEME_SYSTEM
+)
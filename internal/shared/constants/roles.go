@@ -0,0 +1,7 @@
+package constants
+
+// ROLE_ADMIN is the name of the role allowed to manage other users
+const ROLE_ADMIN string = "admin"
+
+// ROLE_USER is the name of the default role of regular users
+const ROLE_USER string = "user"
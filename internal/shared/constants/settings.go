@@ -0,0 +1,21 @@
+package constants
+
+// Setting types, deciding how the value of a runtime setting is parsed
+const (
+	SETTING_TYPE_STRING string = "string"
+	SETTING_TYPE_INT    string = "int"
+	SETTING_TYPE_BOOL   string = "bool"
+)
+
+// Settings read by the maintenance mode
+const (
+	SETTING_MAINTENANCE_ENABLED string = "maintenance.enabled"
+	SETTING_MAINTENANCE_MODE    string = "maintenance.mode"
+	SETTING_MAINTENANCE_MESSAGE string = "maintenance.message"
+)
+
+// Maintenance modes. In read-only mode requests that do not change data are still served
+const (
+	MAINTENANCE_MODE_FULL      string = "full"
+	MAINTENANCE_MODE_READ_ONLY string = "read_only"
+)
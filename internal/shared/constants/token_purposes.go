@@ -0,0 +1,7 @@
+package constants
+
+// Purposes stored in users.token_purpose, telling what the token in users.token may be used for
+const (
+	TOKEN_PURPOSE_VERIFY_EMAIL   string = "verify_email"
+	TOKEN_PURPOSE_RESET_PASSWORD string = "reset_password"
+)
@@ -0,0 +1,8 @@
+package constants
+
+// Statuses stored in users.status
+const (
+	USER_STATUS_ACTIVE           string = "active"
+	USER_STATUS_PENDING_DELETION string = "pending_deletion" // The user asked to delete their account and can still reactivate it
+	USER_STATUS_ANONYMIZED       string = "anonymized"       // The grace period ended and the personal data was removed
+)
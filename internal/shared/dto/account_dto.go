@@ -0,0 +1,27 @@
+package dto
+
+import "time"
+
+type DeleteAccountInput struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type ReactivateAccountInput struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+type ChangeEmailInput struct {
+	NewEmail string `json:"new_email" binding:"required,email,max=45"`
+	Password string `json:"password" binding:"required"`
+}
+
+type ConfirmEmailChangeInput struct {
+	Token string `form:"token" binding:"required"`
+}
+
+// AccountDeletionResponse tells when an account whose deletion was requested is anonymized,
+// unless it is reactivated before
+type AccountDeletionResponse struct {
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
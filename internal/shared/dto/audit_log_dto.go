@@ -0,0 +1,10 @@
+package dto
+
+import "time"
+
+type AuditLogFilterInput struct {
+	ActorUserID *uint      `form:"actor_user_id" binding:"omitempty,gt=0"`                                       // ActorUserID only lists actions performed by this user
+	Action      string     `form:"action" binding:"omitempty,max=64"`                                            // Action only lists entries with this exact action, e.g. auth.login
+	From        *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`                                 // From only lists entries created at or after this RFC 3339 time
+	To          *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00" binding:"omitempty,gtefield=From"` // To only lists entries created at or before this RFC 3339 time
+}
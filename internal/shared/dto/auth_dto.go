@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type LoginInput struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6,max=255"`
@@ -10,6 +12,24 @@ type RefreshTokenInput struct {
 	AccessToken  string `json:"access_token" binding:"required"`
 }
 
+type LogoutInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LoginUser is the summary of the user returned with a successful login
+type LoginUser struct {
+	ID    uint     `json:"id"`
+	Name  string   `json:"name"`
+	Email string   `json:"email"`
+	Roles []string `json:"roles"` // Names of the roles of the user
+}
+
+// AccessTokenInfo identifies the access token a request was authenticated with
+type AccessTokenInfo struct {
+	ID        string
+	ExpiresAt time.Time
+}
+
 type JwtResult struct {
 	Token     string `json:"token"`
 	ExpiresAt int64  `json:"expires_at"`
@@ -18,4 +38,43 @@ type JwtResult struct {
 type LoginResponse struct {
 	AccessToken  JwtResult `json:"access_token"`
 	RefreshToken JwtResult `json:"refresh_token"`
+	// User is the user who logged in, so clients need not fetch the profile right away. Left out on refresh
+	User *LoginUser `json:"user,omitempty"`
+	// MustChangePassword tells the client to call change-password before any other authenticated endpoint
+	MustChangePassword bool `json:"must_change_password,omitempty"`
+	UserID             uint `json:"-"`
+	// FingerprintMismatch tells the handler the refresh token was presented from another device in log-only mode
+	FingerprintMismatch bool `json:"-"`
+}
+
+// SessionResponse describes an active session, i.e. a refresh token. The token itself is masked
+type SessionResponse struct {
+	ID         uint       `json:"id"`
+	Token      string     `json:"token"`
+	IPAddress  string     `json:"ip_address"`
+	UserAgent  string     `json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  int64      `json:"expires_at"`
+}
+
+// JWTKeyResponse describes a configured JWT signing key. The secret is never included
+type JWTKeyResponse struct {
+	ID     string `json:"id"`
+	Active bool   `json:"active"` // Whether new tokens are signed with this key
+}
+
+// JWKSResponse is a JSON Web Key Set (RFC 7517) of the public keys access tokens can be verified with
+type JWKSResponse struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK is an RSA public key of a JWKSResponse
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"` // Modulus, base64url encoded
+	E   string `json:"e"` // Public exponent, base64url encoded
 }
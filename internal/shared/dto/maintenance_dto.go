@@ -0,0 +1,14 @@
+package dto
+
+type SetMaintenanceInput struct {
+	Enabled *bool  `json:"enabled" binding:"required"`                    // Enabled turns the maintenance mode on or off
+	Mode    string `json:"mode" binding:"omitempty,oneof=full read_only"` // Mode must be one of [full read_only], full when omitted
+	Message string `json:"message" binding:"omitempty,max=255,not_blank"` // Message is returned to blocked clients, a default is used when omitted
+}
+
+// MaintenanceStatus is the current maintenance mode
+type MaintenanceStatus struct {
+	Enabled bool   `json:"enabled"`
+	Mode    string `json:"mode,omitempty"`
+	Message string `json:"message,omitempty"`
+}
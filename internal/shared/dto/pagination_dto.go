@@ -1,9 +1,81 @@
 package dto
 
+import "encoding/json"
+
+// Pagination is one page of a listing.
+// In offset mode Page is the requested page and NextPage and PrevPage number the adjacent pages, when there
+// are any; in cursor mode Page is omitted and NextCursor continues after the last row, null on the last page.
+// Next and Prev are request URLs for the adjacent pages
 type Pagination[T any] struct {
-	Page       int `json:"page"`
-	Limit      int `json:"limit"`
-	TotalItems int `json:"total_items"`
-	TotalPages int `json:"total_pages"`
-	Data       []T `json:"data"`
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	TotalItems int    `json:"total_items"`
+	TotalPages int    `json:"total_pages"`
+	HasNext    bool   `json:"has_next"`
+	HasPrev    bool   `json:"has_prev"`
+	NextPage   *int   `json:"next_page,omitempty"`
+	PrevPage   *int   `json:"prev_page,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Next       string `json:"next,omitempty"`
+	Prev       string `json:"prev,omitempty"`
+	Data       []T    `json:"data"`
+}
+
+// MarshalJSON writes the page, with next_cursor set to null on the last page of cursor paging so cursor
+// clients can stop on it. Offset pages leave next_cursor out
+func (p Pagination[T]) MarshalJSON() ([]byte, error) {
+	type page Pagination[T]
+	if p.Page != 0 {
+		return json.Marshal(page(p))
+	}
+
+	var nextCursor *string
+	if p.NextCursor != "" {
+		nextCursor = &p.NextCursor
+	}
+	return json.Marshal(struct {
+		page
+		NextCursor *string `json:"next_cursor"`
+	}{page(p), nextCursor})
+}
+
+// MapPagination returns the page with each item converted by convert
+func MapPagination[T, U any](p *Pagination[T], convert func(T) U) *Pagination[U] {
+	data := make([]U, 0, len(p.Data))
+	for _, item := range p.Data {
+		data = append(data, convert(item))
+	}
+	return &Pagination[U]{
+		Page:       p.Page,
+		Limit:      p.Limit,
+		TotalItems: p.TotalItems,
+		TotalPages: p.TotalPages,
+		HasNext:    p.HasNext,
+		HasPrev:    p.HasPrev,
+		NextPage:   p.NextPage,
+		PrevPage:   p.PrevPage,
+		NextCursor: p.NextCursor,
+		Next:       p.Next,
+		Prev:       p.Prev,
+		Data:       data,
+	}
+}
+
+// ListOptions holds the validated paging and sorting parameters of a listing request
+type ListOptions struct {
+	Page    int
+	Limit   int
+	SortBy  string
+	SortDir string
+	// Cursor is set when the request pages by cursor instead of by page number.
+	// A zero Cursor starts from the first row
+	Cursor *Cursor
+}
+
+// Cursor identifies the last row of a page in cursor pagination. Clients receive it base64 encoded
+type Cursor struct {
+	SortBy  string          `json:"sort_by"`
+	SortDir string          `json:"sort_dir"`
+	Value   json.RawMessage `json:"value,omitempty"` // Sort key of the last row, unset when sorting by id
+	ID      uint            `json:"id"`
 }
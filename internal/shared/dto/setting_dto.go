@@ -0,0 +1,6 @@
+package dto
+
+type SetSettingInput struct {
+	Value string `json:"value" binding:"max=1000"`                      // Value must fit Type, e.g. 5 for int or true for bool
+	Type  string `json:"type" binding:"required,oneof=string int bool"` // Type must be one of [string int bool]
+}
@@ -1,39 +1,232 @@
 package dto
 
+import (
+	"time"
+
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
 type CreateUserInput struct {
-	Email    string  `json:"email" binding:"required,email"`                     // Email must be valid format
-	Password string  `json:"password" binding:"required,min=6,max=255"`          // Password must be between 6-255 chars
-	Name     string  `json:"name" binding:"required,min=1,max=45,not_blank"`     // Name must be between 1-45 chars and not blank
-	Birthday *string `json:"birthday" binding:"required,valid_birthday"`         // Assumes birthday is valid format: YYYY-MM-DD
-	Address  *string `json:"address" binding:"required,min=1,max=255,not_blank"` // Address must be between 1-255 chars and not blank
-	Gender   int16   `json:"gender" binding:"required,oneof=1 2 3"`
+	Email    string  `json:"email" binding:"required,email"`                                                    // Email must be valid format
+	Password string  `json:"password" binding:"required,min=6,max=255,strong_password,strong_password_entropy"` // Password must be between 6-255 chars, mix character classes and be hard to guess
+	Name     string  `json:"name" binding:"required,min=1,max=45,not_blank"`                                    // Name must be between 1-45 chars and not blank
+	Birthday *string `json:"birthday" binding:"required,valid_birthday"`                                        // Assumes birthday is valid format: YYYY-MM-DD
+	Address  *string `json:"address" binding:"required,min=1,max=255,not_blank"`                                // Address must be between 1-255 chars and not blank
+	Gender   int16   `json:"gender" binding:"required,valid_gender"`
+	RoleIDs  []uint  `json:"role_ids" binding:"omitempty,max=20,dive,gt=0"` // Optional roles assigned with the user, at most 20
 }
 
 type ForgotPasswordInput struct {
 	Email string `json:"email" binding:"required,email"` // Email must be valid format
 }
 
+type VerifyEmailInput struct {
+	Token string `form:"token" binding:"required"` // Token is required
+}
+
+type ResendVerificationInput struct {
+	Email string `json:"email" binding:"required,email"` // Email must be valid format
+}
+
 type ResetPasswordInput struct {
-	Token       string `json:"token" binding:"required"`                      // Token is required
-	NewPassword string `json:"new_password" binding:"required,min=6,max=255"` // New password must be between 6-255 chars
+	Token       string `json:"token" binding:"required"`                                                              // Token is required
+	NewPassword string `json:"new_password" binding:"required,min=6,max=255,strong_password,strong_password_entropy"` // New password must be between 6-255 chars, mix character classes and be hard to guess
 }
 
 type ChangePasswordInput struct {
-	OldPassword     string `json:"old_password" binding:"required,min=6,max=255"`     // Old password must be between 6-255 chars
-	NewPassword     string `json:"new_password" binding:"required,min=6,max=255"`     // New password must be between 6-255 chars
-	ConfirmPassword string `json:"confirm_password" binding:"required,min=6,max=255"` // Confirm password must be between 6-255 chars
+	OldPassword     string `json:"old_password" binding:"required,min=6,max=255"`                                         // Old password must be between 6-255 chars
+	NewPassword     string `json:"new_password" binding:"required,min=6,max=255,strong_password,strong_password_entropy"` // New password must be between 6-255 chars, mix character classes and be hard to guess
+	ConfirmPassword string `json:"confirm_password" binding:"required,min=6,max=255"`                                     // Confirm password must be between 6-255 chars
 }
 
 type UpdateUserInput struct {
 	Name     *string `json:"name" binding:"omitempty,min=1,max=45,not_blank"`     // Name must be between 1-45 chars and not blank
 	Birthday *string `json:"birthday" binding:"omitempty,valid_birthday"`         // Assumes birthday is valid format: YYYY-MM-DD
 	Address  *string `json:"address" binding:"omitempty,min=1,max=255,not_blank"` // Address must be between 1-255 chars and not blank
-	Gender   *int16  `json:"gender" binding:"omitempty,oneof=1 2 3"`              // Gender must be one of [1 2 3]
+	Gender   *int16  `json:"gender" binding:"omitempty,valid_gender"`             // Gender must be male (1), female (2) or other (3)
 }
 
 type UpdateProfileInput struct {
 	Name     *string `json:"name" binding:"omitempty,min=1,max=45,not_blank"`     // Name must be between 1 and 45 characters and not blank if provided
 	Birthday *string `json:"birthday" binding:"omitempty,valid_birthday"`         // Birthday must be a valid date (YYYY-MM-DD) if provided
 	Address  *string `json:"address" binding:"omitempty,min=1,max=255,not_blank"` // Address must be between 1 and 255 characters and not blank if provided
-	Gender   *int16  `json:"gender" binding:"omitempty,oneof=1 2 3"`              // Gender must be 1, 2, or 3 if provided
+	Gender   *int16  `json:"gender" binding:"omitempty,valid_gender"`             // Gender must be male (1), female (2) or other (3) if provided
+}
+
+// UpdatePreferencesInput replaces every preference of the user
+type UpdatePreferencesInput struct {
+	Timezone           string `json:"timezone" binding:"required,max=64,valid_timezone"` // Timezone must be an IANA name, e.g. Asia/Ho_Chi_Minh
+	Locale             string `json:"locale" binding:"required,valid_locale"`            // Locale must be one of constants.LOCALES
+	EmailNotifications *bool  `json:"email_notifications" binding:"required"`            // EmailNotifications is required, false included
+	Theme              string `json:"theme" binding:"required,oneof=light dark system"`  // Theme must be light, dark or system
+}
+
+// UserResponse is a user as returned by the API. Secrets and server-side account state, such as the password,
+// the tokens and their expiry, are not on it, so they cannot be serialized by mistake
+type UserResponse struct {
+	ID                 uint       `json:"id"`
+	Email              string     `json:"email"`
+	Name               string     `json:"name"`
+	Birthday           *string    `json:"birthday"` // Birthday is formatted as YYYY-MM-DD, like CreateUserInput.Birthday
+	Address            *string    `json:"address"`
+	Gender             int16      `json:"gender"`
+	Avatar             *string    `json:"avatar"` // Avatar is the public URL of the avatar, null when the user has none
+	VerifiedAt         *time.Time `json:"verified_at"`
+	MustChangePassword bool       `json:"must_change_password"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+	DeletedAt          *time.Time `json:"deleted_at"` // DeletedAt is null unless the user is soft-deleted
+}
+
+// ProfileResponse is the profile of the authenticated user. It is also the form profiles are cached in
+type ProfileResponse struct {
+	UserResponse
+	PendingEmail *string              `json:"pending_email"` // PendingEmail is the new email waiting for confirmation, if any
+	Roles        []RoleResponse       `json:"roles"`
+	Preferences  *PreferencesResponse `json:"preferences"`
+}
+
+// RoleResponse is a role assigned to a user
+type RoleResponse struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// PreferencesResponse is the preferences of a user within their profile
+type PreferencesResponse struct {
+	Timezone           string    `json:"timezone"`
+	Locale             string    `json:"locale"`
+	EmailNotifications bool      `json:"email_notifications"`
+	Theme              string    `json:"theme"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// NewUserResponse returns the response of the user
+func NewUserResponse(user *models.User) *UserResponse {
+	res := &UserResponse{
+		ID:                 user.ID,
+		Email:              user.Email,
+		Name:               user.Name,
+		Address:            user.Address,
+		Gender:             user.Gender,
+		Avatar:             user.Avatar,
+		VerifiedAt:         user.VerifiedAt,
+		MustChangePassword: user.MustChangePassword,
+		CreatedAt:          user.CreatedAt,
+		UpdatedAt:          user.UpdatedAt,
+	}
+	if user.Birthday != nil {
+		birthday := user.Birthday.Format(time.DateOnly)
+		res.Birthday = &birthday
+	}
+	if user.DeletedAt.Valid {
+		deletedAt := user.DeletedAt.Time
+		res.DeletedAt = &deletedAt
+	}
+	return res
+}
+
+// NewProfileResponse returns the profile of the user, with the roles and the preferences loaded on it
+func NewProfileResponse(user *models.User) *ProfileResponse {
+	res := &ProfileResponse{
+		UserResponse: *NewUserResponse(user),
+		PendingEmail: user.PendingEmail,
+		Roles:        make([]RoleResponse, 0, len(user.Roles)),
+	}
+	for _, role := range user.Roles {
+		res.Roles = append(res.Roles, RoleResponse{ID: role.ID, Name: role.Name})
+	}
+	if user.Preferences != nil {
+		res.Preferences = &PreferencesResponse{
+			Timezone:           user.Preferences.Timezone,
+			Locale:             user.Preferences.Locale,
+			EmailNotifications: user.Preferences.EmailNotifications,
+			Theme:              user.Preferences.Theme,
+			UpdatedAt:          user.Preferences.UpdatedAt,
+		}
+	}
+	return res
+}
+
+// AvatarResponse is the avatar of the user after an upload
+type AvatarResponse struct {
+	Avatar string `json:"avatar"` // Public URL of the avatar
+}
+
+type GetUserInput struct {
+	IncludeDeleted bool `form:"include_deleted"` // IncludeDeleted also returns a soft-deleted user
+}
+
+type BulkDeleteUsersInput struct {
+	IDs []uint `json:"ids" binding:"required,min=1,max=100,dive,gt=0"` // IDs must hold 1 to 100 positive user IDs
+}
+
+// UserRoleResponse is a role of a user with the permissions it grants
+type UserRoleResponse struct {
+	ID          uint     `json:"id"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+type UserRolesInput struct {
+	RoleIDs []uint `json:"role_ids" binding:"required,min=1,max=20,dive,gt=0"` // RoleIDs must hold 1 to 20 positive role IDs
+}
+
+// BulkDeleteUsersResult reports which of the requested users were deleted and which did not exist
+type BulkDeleteUsersResult struct {
+	Deleted  []uint `json:"deleted"`
+	NotFound []uint `json:"not_found"`
+}
+
+type UserFilterInput struct {
+	Gender         *int16 `form:"gender" binding:"omitempty,valid_gender"` // Gender must be male (1), female (2) or other (3) if provided
+	Search         string `form:"search" binding:"omitempty,max=100"`      // Search matches name or email, at most 100 chars
+	IncludeDeleted bool   `form:"include_deleted"`                         // IncludeDeleted also lists soft-deleted users
+}
+
+// UserExportInput selects the format of a user export; the users are filtered by UserFilterInput
+type UserExportInput struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv xlsx"` // Format must be csv or xlsx if provided, csv by default
+}
+
+type ImportUsersInput struct {
+	DryRun bool `form:"dry_run"` // DryRun validates the file and reports what would be imported without creating anyone
+}
+
+// UserImportReport tells how many rows of a user import were created and why the others were skipped
+type UserImportReport struct {
+	DryRun   bool                 `json:"dry_run"`
+	Rows     int                  `json:"rows"`     // Rows is the number of rows below the header
+	Imported int                  `json:"imported"` // Imported is the number of users created, or that would be created in a dry run
+	Skipped  []UserImportRowError `json:"skipped"`
+}
+
+// UserImportRowError explains why a row was skipped. Row numbers count the header as row 1
+type UserImportRowError struct {
+	Row    int                   `json:"row"`
+	Reason string                `json:"reason"` // Reason is invalid, duplicate_in_file or already_registered
+	Fields []apperror.FieldError `json:"fields"`
+}
+
+type UserSearchInput struct {
+	Query string `form:"q" binding:"required,min=2,max=100,not_blank"` // Query must be between 2-100 chars and not blank
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=50"`       // Limit must be between 1-50 if provided
+}
+
+// UserSearchResult is a user as returned by the search, without any account secrets or state
+type UserSearchResult struct {
+	ID     uint   `json:"id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Gender int16  `json:"gender"`
+}
+
+// UserActivityResponse sums up the recent activity of a user for administrators
+type UserActivityResponse struct {
+	UserID         uint                          `json:"user_id"`
+	LastLoginAt    *time.Time                    `json:"last_login_at"`   // LastLoginAt is null until the user logs in
+	LastLoginIP    *string                       `json:"last_login_ip"`   // LastLoginIP is the address of the last login
+	ActiveSessions int                           `json:"active_sessions"` // ActiveSessions counts the refresh tokens that are not expired
+	RecentActivity *Pagination[*models.AuditLog] `json:"recent_activity"` // RecentActivity is a page of the actions the user performed
 }
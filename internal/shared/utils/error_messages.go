@@ -0,0 +1,99 @@
+package utils
+
+// errorMessages translates the English messages of the errors users meet most, keyed by the message:
+// authentication, tokens, passwords, rate limits and their own account. Messages built with values,
+// e.g. "Roles not found: 99", and those only administrators or operators see are sent in English
+var errorMessages = map[string]map[string]string{
+	LANGUAGE_JA: {
+		"Internal server error":                                               "サーバー内部エラーが発生しました",
+		"Request timed out":                                                   "リクエストがタイムアウトしました",
+		"Request body cannot be empty":                                        "リクエストボディを空にすることはできません",
+		"Too many requests. Please try again later.":                          "リクエストが多すぎます。しばらくしてから再度お試しください。",
+		"Unauthorized":                                                        "認証が必要です",
+		"Authorization header required":                                       "Authorization ヘッダーが必要です",
+		"Invalid access token":                                                "アクセストークンが無効です",
+		"Invalid access token scope":                                          "アクセストークンのスコープが無効です",
+		"You do not have permission to perform this action":                   "この操作を行う権限がありません",
+		"Invalid credentials":                                                 "メールアドレスまたはパスワードが正しくありません",
+		"Email address has not been verified":                                 "メールアドレスが確認されていません",
+		"Too many failed login attempts, please try again later":              "ログインの失敗回数が多すぎます。しばらくしてから再度お試しください",
+		"You must change your password before continuing":                     "続行する前にパスワードを変更してください",
+		"Refresh token is required":                                           "リフレッシュトークンは必須です",
+		"Invalid refresh token":                                               "リフレッシュトークンが無効です",
+		"Refresh token not found or expired":                                  "リフレッシュトークンが見つからないか、有効期限が切れています",
+		"Refresh token was issued to another device":                          "リフレッシュトークンは別の端末に発行されています",
+		"Token mismatch: refresh and access tokens belong to different users": "リフレッシュトークンとアクセストークンのユーザーが一致しません",
+		"Invalid token":                                                       "トークンが無効です",
+		"Token has expired":                                                   "トークンの有効期限が切れています",
+		"Please wait a few minutes before requesting another password reset email": "パスワード再設定メールを再度リクエストするには、数分お待ちください",
+		"Too many password reset requests, please try again later":                 "パスワード再設定のリクエストが多すぎます。しばらくしてから再度お試しください",
+		"Please wait before requesting another verification email":                 "確認メールを再度リクエストするには、しばらくお待ちください",
+		"Password is incorrect":                            "パスワードが正しくありません",
+		"Old password is incorrect":                        "現在のパスワードが正しくありません",
+		"New password and confirm password do not match":   "新しいパスワードと確認用パスワードが一致しません",
+		"New password must be different from old password": "新しいパスワードは現在のパスワードと異なる必要があります",
+		"Email already registered":                         "このメールアドレスは既に登録されています",
+		"New email must be different from the current one": "新しいメールアドレスは現在のものと異なる必要があります",
+		"User not found":                                   "ユーザーが見つかりません",
+		"Session not found":                                "セッションが見つかりません",
+		"Preferences not found":                            "設定が見つかりません",
+		"Account is scheduled for deletion, reactivate it with POST /api/v1/reactivate": "アカウントは削除予定です。POST /api/v1/reactivate で再開できます",
+		"Account is not scheduled for deletion":                                         "アカウントは削除予定ではありません",
+		"The grace period to reactivate the account has ended":                          "アカウントを再開できる期間は終了しました",
+		"The account was changed meanwhile, please try again":                           "アカウントが同時に変更されました。もう一度お試しください",
+		"You cannot delete your own account":                                            "自分のアカウントは削除できません",
+		"Expected a multipart/form-data upload":                                         "multipart/form-data 形式でアップロードしてください",
+		"Avatar must be a JPEG, PNG or WebP image":                                      "アバターは JPEG、PNG、WebP のいずれかの画像である必要があります",
+		"Avatar image is corrupted":                                                     "アバター画像が破損しています",
+	},
+	LANGUAGE_VI: {
+		"Internal server error":                                               "Lỗi máy chủ nội bộ",
+		"Request timed out":                                                   "Yêu cầu đã hết thời gian chờ",
+		"Request body cannot be empty":                                        "Nội dung yêu cầu không được để trống",
+		"Too many requests. Please try again later.":                          "Quá nhiều yêu cầu. Vui lòng thử lại sau.",
+		"Unauthorized":                                                        "Chưa được xác thực",
+		"Authorization header required":                                       "Cần có header Authorization",
+		"Invalid access token":                                                "Access token không hợp lệ",
+		"Invalid access token scope":                                          "Phạm vi của access token không hợp lệ",
+		"You do not have permission to perform this action":                   "Bạn không có quyền thực hiện thao tác này",
+		"Invalid credentials":                                                 "Email hoặc mật khẩu không đúng",
+		"Email address has not been verified":                                 "Địa chỉ email chưa được xác minh",
+		"Too many failed login attempts, please try again later":              "Đăng nhập thất bại quá nhiều lần, vui lòng thử lại sau",
+		"You must change your password before continuing":                     "Bạn phải đổi mật khẩu trước khi tiếp tục",
+		"Refresh token is required":                                           "Refresh token là bắt buộc",
+		"Invalid refresh token":                                               "Refresh token không hợp lệ",
+		"Refresh token not found or expired":                                  "Refresh token không tồn tại hoặc đã hết hạn",
+		"Refresh token was issued to another device":                          "Refresh token đã được cấp cho thiết bị khác",
+		"Token mismatch: refresh and access tokens belong to different users": "Refresh token và access token thuộc về hai người dùng khác nhau",
+		"Invalid token":                                                       "Token không hợp lệ",
+		"Token has expired":                                                   "Token đã hết hạn",
+		"Please wait a few minutes before requesting another password reset email": "Vui lòng đợi vài phút trước khi yêu cầu lại email đặt lại mật khẩu",
+		"Too many password reset requests, please try again later":                 "Yêu cầu đặt lại mật khẩu quá nhiều lần, vui lòng thử lại sau",
+		"Please wait before requesting another verification email":                 "Vui lòng đợi trước khi yêu cầu lại email xác minh",
+		"Password is incorrect":                            "Mật khẩu không đúng",
+		"Old password is incorrect":                        "Mật khẩu cũ không đúng",
+		"New password and confirm password do not match":   "Mật khẩu mới và mật khẩu xác nhận không khớp",
+		"New password must be different from old password": "Mật khẩu mới phải khác mật khẩu cũ",
+		"Email already registered":                         "Email đã được đăng ký",
+		"New email must be different from the current one": "Email mới phải khác email hiện tại",
+		"User not found":                                   "Không tìm thấy người dùng",
+		"Session not found":                                "Không tìm thấy phiên đăng nhập",
+		"Preferences not found":                            "Không tìm thấy tùy chọn",
+		"Account is scheduled for deletion, reactivate it with POST /api/v1/reactivate": "Tài khoản đang chờ xóa, hãy kích hoạt lại bằng POST /api/v1/reactivate",
+		"Account is not scheduled for deletion":                                         "Tài khoản không nằm trong lịch xóa",
+		"The grace period to reactivate the account has ended":                          "Đã hết thời hạn để kích hoạt lại tài khoản",
+		"The account was changed meanwhile, please try again":                           "Tài khoản vừa bị thay đổi, vui lòng thử lại",
+		"You cannot delete your own account":                                            "Bạn không thể xóa tài khoản của chính mình",
+		"Expected a multipart/form-data upload":                                         "Cần tải lên theo định dạng multipart/form-data",
+		"Avatar must be a JPEG, PNG or WebP image":                                      "Ảnh đại diện phải là ảnh JPEG, PNG hoặc WebP",
+		"Avatar image is corrupted":                                                     "Ảnh đại diện bị hỏng",
+	},
+}
+
+// translateErrorMessage returns the error message in language, or unchanged if it has no translation
+func translateErrorMessage(language, message string) string {
+	if translated, ok := errorMessages[language][message]; ok {
+		return translated
+	}
+	return message
+}
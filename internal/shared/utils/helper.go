@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/gin-gonic/gin"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
 )
 
 func GetUserIDFromContext(ctx *gin.Context) (uint, error) {
@@ -19,3 +20,18 @@ func GetUserIDFromContext(ctx *gin.Context) (uint, error) {
 
 	return userId, nil
 }
+
+// GetAccessTokenFromContext returns the access token the request was authenticated with
+func GetAccessTokenFromContext(ctx *gin.Context) (*dto.AccessTokenInfo, error) {
+	accessTokenInterface, exists := ctx.Get("AccessToken")
+	if !exists {
+		return nil, errors.New("Access token not found in context")
+	}
+
+	accessToken, ok := accessTokenInterface.(*dto.AccessTokenInfo)
+	if !ok {
+		return nil, errors.New("Access token in context has invalid type")
+	}
+
+	return accessToken, nil
+}
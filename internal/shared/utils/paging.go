@@ -1,10 +1,15 @@
 package utils
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
 )
 
 func CalculateTotalPages(totalRows int64, limit int) int {
@@ -43,3 +48,49 @@ func ParsePageAndLimit(c *gin.Context) (int, int) {
 
 	return int(pageInt), int(limitInt)
 }
+
+// EncodeCursor returns the opaque form of cursor sent to clients
+func EncodeCursor(cursor dto.Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor produced by EncodeCursor
+// Returns an error if the value is not a valid cursor
+func DecodeCursor(value string) (*dto.Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	var cursor dto.Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	if cursor.ID == 0 || cursor.SortBy == "" {
+		return nil, errors.New("cursor is incomplete")
+	}
+	return &cursor, nil
+}
+
+// SetPaginationLinks fills page.Next and page.Prev with the request URL pointing at the adjacent pages.
+// The links are relative so they do not depend on the Host header. Cursor pages only link forward
+func SetPaginationLinks[T any](ctx *gin.Context, page *dto.Pagination[T]) {
+	link := func(set func(query url.Values)) string {
+		query := ctx.Request.URL.Query()
+		set(query)
+		return ctx.Request.URL.Path + "?" + query.Encode()
+	}
+
+	if page.Page == 0 {
+		if page.HasNext {
+			page.Next = link(func(query url.Values) { query.Set("cursor", page.NextCursor) })
+		}
+		return
+	}
+	if page.NextPage != nil {
+		page.Next = link(func(query url.Values) { query.Set("page", strconv.Itoa(*page.NextPage)) })
+	}
+	if page.PrevPage != nil {
+		page.Prev = link(func(query url.Values) { query.Set("page", strconv.Itoa(*page.PrevPage)) })
+	}
+}
@@ -1,13 +1,16 @@
 package utils_test
 
 import (
+	"encoding/base64"
 	"net/http"
 	"net/url"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
 )
 
@@ -69,4 +72,81 @@ func TestPaging(t *testing.T) {
 			assert.Equal(t, tt.expectedLimit, limit)
 		}
 	})
+
+	t.Run("EncodeAndDecodeCursor", func(t *testing.T) {
+		cursor := dto.Cursor{SortBy: "created_at", SortDir: "desc", Value: []byte(`"2025-01-01T00:00:00Z"`), ID: 42}
+
+		encoded := utils.EncodeCursor(cursor)
+		decoded, err := utils.DecodeCursor(encoded)
+
+		require.NoError(t, err)
+		assert.Equal(t, cursor, *decoded)
+		assert.Equal(t, url.QueryEscape(encoded), encoded, "cursor must be safe in a query string")
+	})
+
+	t.Run("DecodeCursorRejectsInvalidValues", func(t *testing.T) {
+		for _, value := range []string{
+			"%%%",
+			base64.RawURLEncoding.EncodeToString([]byte("[]")),
+			utils.EncodeCursor(dto.Cursor{SortBy: "id", SortDir: "asc"}),
+			utils.EncodeCursor(dto.Cursor{SortDir: "asc", ID: 1}),
+		} {
+			_, err := utils.DecodeCursor(value)
+			assert.Error(t, err, value)
+		}
+	})
+
+	t.Run("SetPaginationLinks", func(t *testing.T) {
+		newContext := func(target string) *gin.Context {
+			c, _ := gin.CreateTestContext(nil)
+			c.Request, _ = http.NewRequest("GET", target, nil)
+			return c
+		}
+
+		t.Run("Offset Middle Page", func(t *testing.T) {
+			next, prev := 3, 1
+			page := &dto.Pagination[int]{Page: 2, HasPrev: true, HasNext: true, NextPage: &next, PrevPage: &prev}
+
+			utils.SetPaginationLinks(newContext("/api/v1/users?page=2&limit=5&search=bob"), page)
+
+			assert.Equal(t, "/api/v1/users?limit=5&page=3&search=bob", page.Next)
+			assert.Equal(t, "/api/v1/users?limit=5&page=1&search=bob", page.Prev)
+		})
+
+		t.Run("Offset Past The Last Page", func(t *testing.T) {
+			last := 2
+			page := &dto.Pagination[int]{Page: 5, TotalPages: 2, HasPrev: true, PrevPage: &last}
+
+			utils.SetPaginationLinks(newContext("/api/v1/users?page=5"), page)
+
+			assert.Empty(t, page.Next)
+			assert.Equal(t, "/api/v1/users?page=2", page.Prev)
+		})
+
+		t.Run("Offset Single Page", func(t *testing.T) {
+			page := &dto.Pagination[int]{Page: 1}
+
+			utils.SetPaginationLinks(newContext("/api/v1/users"), page)
+
+			assert.Empty(t, page.Next)
+			assert.Empty(t, page.Prev)
+		})
+
+		t.Run("Cursor", func(t *testing.T) {
+			page := &dto.Pagination[int]{HasPrev: true, HasNext: true, NextCursor: "abc"}
+
+			utils.SetPaginationLinks(newContext("/api/v1/users?cursor=xyz&limit=5"), page)
+
+			assert.Equal(t, "/api/v1/users?cursor=abc&limit=5", page.Next)
+			assert.Empty(t, page.Prev)
+		})
+
+		t.Run("Cursor Last Page", func(t *testing.T) {
+			page := &dto.Pagination[int]{HasPrev: true}
+
+			utils.SetPaginationLinks(newContext("/api/v1/users?cursor=xyz"), page)
+
+			assert.Empty(t, page.Next)
+		})
+	})
 }
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -16,38 +18,51 @@ import (
 //   - 1. If the error is a ValidationError, it includes validation error code, message, and fields.
 //   - 2. If the error is an AppError, it includes application error code and message.
 //   - 3. If the error is neither, it returns a generic internal error response.
+//
+// Each body also carries the stable string identifier of the code under "error", e.g. ERR_TOKEN_EXPIRED.
+// The request ID is added to the body when the request has one, so clients can quote it in bug reports.
+// Once the deadline of the request has passed, see TimeoutMiddleware, any error is reported as a 504 ErrRequestTimeout,
+// as the error is usually just how the cancelled work failed.
 func RespondWithError(ctx *gin.Context, err error) {
+	if ctx.Request != nil && errors.Is(ctx.Request.Context().Err(), context.DeadlineExceeded) {
+		err = apperror.NewRequestTimeoutError("Request timed out")
+	}
+
 	// 1. If the error is a ValidationError, return its code, message, and fields
 	if validateErr, ok := err.(*apperror.ValidationError); ok {
 		ctx.AbortWithStatusJSON(
 			http.StatusBadRequest,
-			gin.H{
+			withRequestID(ctx, gin.H{
 				"code":    validateErr.Code,
+				"error":   apperror.IdentifierOf(validateErr.Code),
 				"message": validateErr.Message,
 				"fields":  validateErr.Fields,
-			},
+			}),
 		)
 		return
 	}
 
-	// 2. If the error is an AppError, return its code and message
+	// 2. If the error is an AppError, return its code and message in the language of the request, and its fields if it has any
 	if appErr, ok := err.(*apperror.AppError); ok {
-		ctx.AbortWithStatusJSON(
-			appErr.HttpStatusCode,
-			gin.H{
-				"code":    appErr.Code,
-				"message": appErr.Message,
-			},
-		)
+		body := gin.H{
+			"code":    appErr.Code,
+			"error":   apperror.IdentifierOf(appErr.Code),
+			"message": translateErrorMessage(RequestLanguage(ctx), appErr.Message),
+		}
+		if len(appErr.Fields) > 0 {
+			body["fields"] = appErr.Fields
+		}
+		ctx.AbortWithStatusJSON(appErr.HttpStatusCode, withRequestID(ctx, body))
 		return
 	}
 	// 3. If the error is not a ValidationError or AppError, return a generic internal error
 	ctx.AbortWithStatusJSON(
 		http.StatusInternalServerError,
-		gin.H{
+		withRequestID(ctx, gin.H{
 			"code":    apperror.ErrInternalServer,
-			"message": "Internal server error",
-		},
+			"error":   apperror.IdentifierOf(apperror.ErrInternalServer),
+			"message": translateErrorMessage(RequestLanguage(ctx), "Internal server error"),
+		}),
 	)
 	if ctx.Request != nil {
 		logger.WithContext(ctx.Request.Context()).Errorf("Unhandled error response: %v", err)
@@ -64,3 +79,14 @@ func RespondWithError(ctx *gin.Context, err error) {
 func RespondWithOK(ctx *gin.Context, statusCode int, body any) {
 	ctx.AbortWithStatusJSON(statusCode, body)
 }
+
+// withRequestID adds the request ID of the request to an error body, if there is one
+func withRequestID(ctx *gin.Context, body gin.H) gin.H {
+	if ctx.Request == nil {
+		return body
+	}
+	if requestID := logger.RequestIDFromContext(ctx.Request.Context()); requestID != "" {
+		body["request_id"] = requestID
+	}
+	return body
+}
@@ -1,15 +1,18 @@
 package utils_test
 
 import (
+	"context"
 	stdErrors "errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
 	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"github.com/vfa-khuongdv/golang-cms/pkg/logger"
 )
 
 func TestRespondWith(t *testing.T) {
@@ -28,10 +31,40 @@ func TestRespondWith(t *testing.T) {
 		utils.RespondWithError(ctx, appErr)
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
-		expectedJSON := `{"code":1001,"message":"App error occurred"}`
+		expectedJSON := `{"code":1001,"error":"ERR_NOT_FOUND","message":"App error occurred"}`
 		assert.JSONEq(t, expectedJSON, w.Body.String())
 	})
 
+	t.Run("RespondWithError_AppErrorWithFields", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+
+		appErr := apperror.NewConflictError("email already exists").WithFields(apperror.FieldError{Field: "email", Message: "email already exists"})
+
+		utils.RespondWithError(ctx, appErr)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		expectedJSON := `{"code":1005,"error":"ERR_CONFLICT","message":"email already exists","fields":[{"field":"email","message":"email already exists"}]}`
+		assert.JSONEq(t, expectedJSON, w.Body.String())
+	})
+
+	t.Run("RespondWithError_IncludesRequestID", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		ctx.Request = req.WithContext(logger.WithRequestIDContext(req.Context(), "req-42"))
+
+		utils.RespondWithError(ctx, apperror.NewNotFoundError("User not found"))
+		assert.JSONEq(t, `{"code":1001,"error":"ERR_NOT_FOUND","message":"User not found","request_id":"req-42"}`, w.Body.String())
+
+		w = httptest.NewRecorder()
+		ctx, _ = gin.CreateTestContext(w)
+		ctx.Request = req.WithContext(logger.WithRequestIDContext(req.Context(), "req-43"))
+
+		utils.RespondWithError(ctx, apperror.NewValidationError("Validation failed", nil))
+		assert.Contains(t, w.Body.String(), `"request_id":"req-43"`)
+	})
+
 	t.Run("RespondWithError_InternalServerError", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		ctx, _ := gin.CreateTestContext(w)
@@ -41,7 +74,7 @@ func TestRespondWith(t *testing.T) {
 		utils.RespondWithError(ctx, internalErr)
 
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
-		expectedJSON := `{"code":1000,"message":"Internal server error"}`
+		expectedJSON := `{"code":1000,"error":"ERR_INTERNAL_SERVER","message":"Internal server error"}`
 		assert.JSONEq(t, expectedJSON, w.Body.String())
 	})
 
@@ -54,7 +87,7 @@ func TestRespondWith(t *testing.T) {
 		utils.RespondWithError(ctx, genericErr)
 
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
-		expectedJSON := `{"code":1000,"message":"generic error message"}`
+		expectedJSON := `{"code":1000,"error":"ERR_INTERNAL_SERVER","message":"generic error message"}`
 		assert.JSONEq(t, expectedJSON, w.Body.String())
 	})
 
@@ -69,10 +102,51 @@ func TestRespondWith(t *testing.T) {
 		utils.RespondWithError(ctx, validationErr)
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
-		expectedJSON := `{"code":4001,"message":"invalid data","fields":[{"field":"email","message":"email is required"}]}`
+		expectedJSON := `{"code":4001,"error":"ERR_VALIDATION_FAILED","message":"invalid data","fields":[{"field":"email","message":"email is required"}]}`
 		assert.JSONEq(t, expectedJSON, w.Body.String())
 	})
 
+	t.Run("RespondWithError_MessageInRequestLanguage", func(t *testing.T) {
+		respond := func(target, acceptLanguage string, err error) string {
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest(http.MethodGet, target, nil)
+			ctx.Request.Header.Set("Accept-Language", acceptLanguage)
+			utils.RespondWithError(ctx, err)
+			return w.Body.String()
+		}
+
+		assert.JSONEq(t, `{"code":1001,"error":"ERR_NOT_FOUND","message":"ユーザーが見つかりません"}`, respond("/?lang=ja", "", apperror.NewNotFoundError("User not found")))
+		assert.JSONEq(t, `{"code":3001,"error":"ERR_TOKEN_EXPIRED","message":"Token đã hết hạn"}`, respond("/", "vi-VN,vi;q=0.9", apperror.NewTokenExpiredError("Token has expired")))
+		assert.JSONEq(t, `{"code":1000,"error":"ERR_INTERNAL_SERVER","message":"サーバー内部エラーが発生しました"}`, respond("/?lang=ja", "", stdErrors.New("boom")))
+		// Messages without a translation, and other languages, are sent in English
+		assert.JSONEq(t, `{"code":1001,"error":"ERR_NOT_FOUND","message":"Setting not found"}`, respond("/?lang=ja", "", apperror.NewNotFoundError("Setting not found")))
+		assert.JSONEq(t, `{"code":1001,"error":"ERR_NOT_FOUND","message":"User not found"}`, respond("/", "fr-FR", apperror.NewNotFoundError("User not found")))
+	})
+
+	t.Run("RespondWithError_UnknownCode", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+
+		utils.RespondWithError(ctx, &apperror.AppError{HttpStatusCode: http.StatusTeapot, Code: 9999, Message: "Unlisted"})
+
+		assert.Equal(t, http.StatusTeapot, w.Code)
+		assert.JSONEq(t, `{"code":9999,"error":"ERR_UNKNOWN","message":"Unlisted"}`, w.Body.String())
+	})
+
+	t.Run("RespondWithError_DeadlineExceeded", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+		requestCtx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil).WithContext(requestCtx)
+
+		utils.RespondWithError(ctx, apperror.NewDBQueryError("Failed to fetch users"))
+
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+		assert.JSONEq(t, `{"code":1008,"error":"ERR_REQUEST_TIMEOUT","message":"Request timed out"}`, w.Body.String())
+	})
+
 	t.Run("RespondWithOK", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		ctx, _ := gin.CreateTestContext(w)
@@ -13,6 +13,23 @@ import (
 const (
 	// MAX_CACHE_ENTRIES limits the size of sensitiveKeyCache to prevent memory leaks
 	MAX_CACHE_ENTRIES = 100
+	// REDACTED replaces sensitive strings entirely in the FullRedact mode
+	REDACTED = "[REDACTED]"
+	// DEFAULT_CENSOR_MAX_DEPTH is how deep CensorSensitiveData descends into nested maps, slices, structs and pointers
+	DEFAULT_CENSOR_MAX_DEPTH = 32
+	// MAX_DEPTH_EXCEEDED replaces maps, slices, structs and pointers nested deeper than the censoring depth limit
+	MAX_DEPTH_EXCEEDED = "[max depth exceeded]"
+)
+
+// CensorMode is how CensorSensitiveDataWithMode censors the values of sensitive fields
+type CensorMode int
+
+const (
+	// PartialMask keeps the first and last character of strings, e.g. "s****t", so values can still be told apart
+	PartialMask CensorMode = iota
+	// FullRedact replaces strings with REDACTED, revealing nothing of them, not even their length.
+	// Use it for short or high-entropy secrets such as tokens, where two characters are too much
+	FullRedact
 )
 
 // CensorSensitiveData recursively censors sensitive fields in complex data structures.
@@ -33,29 +50,83 @@ const (
 //
 // Returns: A new data structure with sensitive fields censored.
 func CensorSensitiveData(data any, maskFields []string) any {
+	return CensorSensitiveDataWithMode(data, maskFields, PartialMask)
+}
+
+// CensorSensitiveDataWithMode is CensorSensitiveData censoring the values of sensitive fields as mode says:
+// PartialMask masks them as CensorSensitiveData does, FullRedact replaces strings with REDACTED.
+// Non-string values are masked the same way in both modes.
+//
+// Parameters:
+//   - data: The data structure to censor (can be any type)
+//   - maskFields: List of field/key names to censor (case-insensitive)
+//   - mode: PartialMask or FullRedact
+//
+// Returns: A new data structure with sensitive fields censored.
+func CensorSensitiveDataWithMode(data any, maskFields []string, mode CensorMode) any {
+	return CensorSensitiveDataWithOptions(data, maskFields, CensorOptions{Mode: mode})
+}
+
+// CensorOptions tunes CensorSensitiveDataWithOptions
+type CensorOptions struct {
+	Mode CensorMode
+	// MaxDepth is how many levels of maps, slices, structs and pointers are descended into;
+	// DEFAULT_CENSOR_MAX_DEPTH when not positive. Maps, slices, structs and pointers nested deeper are
+	// not searched, so censoring a self-referencing structure ends instead of overflowing the stack
+	MaxDepth int
+}
+
+// CensorSensitiveDataWithOptions is CensorSensitiveData with the mode and depth limit given by opts.
+// Maps, slices, structs and pointers nested deeper than the limit may hold sensitive values that were not
+// searched, so they are dropped: replaced by MAX_DEPTH_EXCEEDED where the type allows, by their zero value otherwise.
+func CensorSensitiveDataWithOptions(data any, maskFields []string, opts CensorOptions) any {
+	maxDepth := opts.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DEFAULT_CENSOR_MAX_DEPTH
+	}
+	return censorer{maskFields: maskFields, mode: opts.Mode, maxDepth: maxDepth}.censor(data, 0)
+}
+
+// censorer censors one value as CensorSensitiveDataWithOptions was asked to.
+// depth is the number of maps, slices, structs and pointers the value is nested in
+type censorer struct {
+	maskFields []string
+	mode       CensorMode
+	maxDepth   int
+}
+
+func (c censorer) censor(data any, depth int) any {
 	if data == nil {
 		return nil
 	}
 
 	// Early return if no fields to mask
-	if len(maskFields) == 0 {
+	if len(c.maskFields) == 0 {
 		return data
 	}
 
 	val := reflect.ValueOf(data)
 
+	// Give up on structures nested too deep, most likely cyclic ones, without revealing what they hold
+	switch val.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct, reflect.Ptr:
+		if depth > c.maxDepth {
+			return MAX_DEPTH_EXCEEDED
+		}
+	}
+
 	switch val.Kind() {
 	case reflect.Slice, reflect.Array:
-		return censorSlice(data, maskFields)
+		return c.censorSlice(data, depth)
 	case reflect.Map:
-		return censorMap(data, maskFields)
+		return c.censorMap(data, depth)
 	case reflect.Struct:
-		return censorStruct(data, maskFields)
+		return c.censorStruct(data, depth)
 	case reflect.Ptr:
 		if val.IsNil() {
 			return nil
 		}
-		return CensorSensitiveData(val.Elem().Interface(), maskFields)
+		return c.censor(val.Elem().Interface(), depth+1)
 	case reflect.String:
 		return data
 	default:
@@ -64,7 +135,7 @@ func CensorSensitiveData(data any, maskFields []string) any {
 }
 
 // censorSlice recursively censors each element in a slice/array.
-func censorSlice(data any, maskFields []string) any {
+func (c censorer) censorSlice(data any, depth int) any {
 	val := reflect.ValueOf(data)
 
 	// Handle arrays differently from slices
@@ -77,15 +148,15 @@ func censorSlice(data any, maskFields []string) any {
 
 	for i := 0; i < val.Len(); i++ {
 		item := val.Index(i).Interface()
-		censoredItem := CensorSensitiveData(item, maskFields)
-		censoredSlice.Index(i).Set(reflect.ValueOf(censoredItem))
+		censoredItem := c.censor(item, depth+1)
+		censoredSlice.Index(i).Set(censoredValueOf(censoredItem, val.Type().Elem()))
 	}
 
 	return censoredSlice.Interface()
 }
 
 // censorMap recursively censors map entries based on keys.
-func censorMap(data any, maskFields []string) any {
+func (c censorer) censorMap(data any, depth int) any {
 	val := reflect.ValueOf(data)
 	censoredMap := reflect.MakeMap(val.Type())
 
@@ -97,11 +168,11 @@ func censorMap(data any, maskFields []string) any {
 		keyStr := fmt.Sprintf("%v", key.Interface())
 
 		var censoredValue reflect.Value
-		if containsSensitiveKey(maskFields, keyStr) {
+		if containsSensitiveKey(c.maskFields, keyStr) {
 			// Mask the entire value if key is sensitive
-			censoredValue = reflect.ValueOf(maskValue(value.Interface()))
+			censoredValue = reflect.ValueOf(maskValue(value.Interface(), c.mode))
 		} else {
-			censoredValue = reflect.ValueOf(CensorSensitiveData(value.Interface(), maskFields))
+			censoredValue = censoredValueOf(c.censor(value.Interface(), depth+1), val.Type().Elem())
 		}
 
 		censoredMap.SetMapIndex(key, censoredValue)
@@ -111,7 +182,7 @@ func censorMap(data any, maskFields []string) any {
 }
 
 // censorStruct recursively censors struct fields based on field names.
-func censorStruct(data any, maskFields []string) any {
+func (c censorer) censorStruct(data any, depth int) any {
 	val := reflect.ValueOf(data)
 	typ := val.Type()
 	censoredStruct := reflect.New(typ).Elem()
@@ -120,13 +191,13 @@ func censorStruct(data any, maskFields []string) any {
 		field := val.Field(i)
 		fieldType := typ.Field(i)
 
-		if containsSensitiveKey(maskFields, fieldType.Name) {
+		if containsSensitiveKey(c.maskFields, fieldType.Name) {
 			// Field needs to be masked
 			if field.Kind() == reflect.Ptr {
 				if field.IsNil() {
 					censoredStruct.Field(i).Set(reflect.Zero(field.Type()))
 				} else {
-					maskedVal := maskValue(field.Elem().Interface())
+					maskedVal := maskValue(field.Elem().Interface(), c.mode)
 					maskedValReflect := reflect.ValueOf(maskedVal)
 
 					ptr := reflect.New(fieldType.Type.Elem())
@@ -134,13 +205,13 @@ func censorStruct(data any, maskFields []string) any {
 					censoredStruct.Field(i).Set(ptr)
 				}
 			} else {
-				censoredStruct.Field(i).Set(matchedValOrZero(reflect.ValueOf(maskValue(field.Interface())), fieldType.Type))
+				censoredStruct.Field(i).Set(matchedValOrZero(reflect.ValueOf(maskValue(field.Interface(), c.mode)), fieldType.Type))
 			}
 		} else {
 			// Field does not need to be masked, process recursively
-			censoredValue := CensorSensitiveData(field.Interface(), maskFields)
+			censoredValue := c.censor(field.Interface(), depth+1)
 			if field.Kind() == reflect.Ptr {
-				if field.IsNil() {
+				if field.IsNil() || censoredValue == MAX_DEPTH_EXCEEDED {
 					censoredStruct.Field(i).Set(reflect.Zero(field.Type()))
 				} else {
 					ptr := reflect.New(fieldType.Type.Elem())
@@ -148,7 +219,7 @@ func censorStruct(data any, maskFields []string) any {
 					censoredStruct.Field(i).Set(ptr)
 				}
 			} else {
-				censoredStruct.Field(i).Set(matchedValOrZero(reflect.ValueOf(censoredValue), fieldType.Type))
+				censoredStruct.Field(i).Set(censoredValueOf(censoredValue, fieldType.Type))
 			}
 		}
 	}
@@ -156,6 +227,19 @@ func censorStruct(data any, maskFields []string) any {
 	return censoredStruct.Interface()
 }
 
+// censoredValueOf returns the censored value as a value of typ. Nil, and MAX_DEPTH_EXCEEDED where typ cannot
+// hold a string, give the zero value of typ
+func censoredValueOf(censored any, typ reflect.Type) reflect.Value {
+	if censored == nil {
+		return reflect.Zero(typ)
+	}
+	val := reflect.ValueOf(censored)
+	if censored == MAX_DEPTH_EXCEEDED && !val.Type().AssignableTo(typ) {
+		return reflect.Zero(typ)
+	}
+	return matchedValOrZero(val, typ)
+}
+
 // matchedValOrZero attempts to assign val to typ if compatible, otherwise returns zero value.
 // This prevents panics when types are incompatible during reflection operations.
 func matchedValOrZero(val reflect.Value, typ reflect.Type) reflect.Value {
@@ -233,15 +317,15 @@ func containsSensitiveKey(maskFields []string, item string) bool {
 	return found
 }
 
-// maskValue masks sensitive values based on their type.
-func maskValue(value any) any {
+// maskValue masks sensitive values based on their type, strings as mode says.
+func maskValue(value any, mode CensorMode) any {
 	switch v := value.(type) {
 	case string:
-		return maskString(v)
+		return maskStringWithMode(v, mode)
 	case fmt.Stringer:
-		return maskString(v.String())
+		return maskStringWithMode(v.String(), mode)
 	case []byte:
-		return []byte(maskString(string(v)))
+		return []byte(maskStringWithMode(string(v), mode))
 	case nil:
 		return nil
 	default:
@@ -249,6 +333,14 @@ func maskValue(value any) any {
 	}
 }
 
+// maskStringWithMode replaces the string with REDACTED in the FullRedact mode and masks it with maskString otherwise
+func maskStringWithMode(s string, mode CensorMode) string {
+	if mode == FullRedact {
+		return REDACTED
+	}
+	return maskString(s)
+}
+
 // maskString masks a string by replacing its middle characters with asterisks.
 // For strings longer than 2 characters, it shows the first and last character.
 // For shorter strings, it fully masks with asterisks.
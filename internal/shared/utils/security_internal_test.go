@@ -70,8 +70,8 @@ func TestContainsSensitiveKey_InternalBranches(t *testing.T) {
 
 func TestMaskValue_InternalBranches(t *testing.T) {
 	t.Run("StringerAndNil", func(t *testing.T) {
-		assert.Equal(t, "s****t", maskValue(stringerValue{val: "secret"}))
-		assert.Nil(t, maskValue(nil))
+		assert.Equal(t, "s****t", maskValue(stringerValue{val: "secret"}, PartialMask))
+		assert.Nil(t, maskValue(nil, PartialMask))
 	})
 }
 
@@ -119,7 +119,7 @@ func TestMaskReflectedValue_InternalBranches(t *testing.T) {
 func TestCensorInternalBranches(t *testing.T) {
 	t.Run("ArrayBranchInCensorSlice", func(t *testing.T) {
 		in := [2]string{"ab", "cd"}
-		out := censorSlice(in, []string{"password"}).([2]string)
+		out := censorer{maskFields: []string{"password"}, maxDepth: DEFAULT_CENSOR_MAX_DEPTH}.censorSlice(in, 0).([2]string)
 		assert.Equal(t, in, out)
 	})
 
@@ -129,7 +129,7 @@ func TestCensorInternalBranches(t *testing.T) {
 		}
 
 		in := sample{Name: nil}
-		out := censorStruct(in, []string{"password"}).(sample)
+		out := censorer{maskFields: []string{"password"}, maxDepth: DEFAULT_CENSOR_MAX_DEPTH}.censorStruct(in, 0).(sample)
 		assert.Nil(t, out.Name)
 	})
 }
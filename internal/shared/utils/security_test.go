@@ -1,6 +1,7 @@
 package utils_test
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 
@@ -601,3 +602,132 @@ func TestCensorSensitiveData(t *testing.T) {
 	})
 
 }
+
+func TestCensorSensitiveDataWithMode(t *testing.T) {
+	maskFields := []string{"password", "token", "count"}
+
+	t.Run("FullRedact replaces strings entirely", func(t *testing.T) {
+		type Nested struct {
+			Token string
+		}
+		type Input struct {
+			Name     string
+			Password *string
+			Token    []byte
+			Nested   Nested
+			Items    []map[string]any
+		}
+		password := "secret"
+		input := Input{
+			Name:     "user",
+			Password: &password,
+			Token:    []byte("abc"),
+			Nested:   Nested{Token: "ab"},
+			Items:    []map[string]any{{"token": "xyz123", "count": 42, "name": "item"}},
+		}
+
+		result := utils.CensorSensitiveDataWithMode(input, maskFields, utils.FullRedact).(Input)
+
+		assert.Equal(t, "user", result.Name)
+		assert.Equal(t, utils.REDACTED, *result.Password)
+		assert.Equal(t, []byte(utils.REDACTED), result.Token)
+		assert.Equal(t, utils.REDACTED, result.Nested.Token)
+		assert.Equal(t, utils.REDACTED, result.Items[0]["token"])
+		assert.Equal(t, "*****", result.Items[0]["count"]) // Non-string values are masked as in PartialMask
+		assert.Equal(t, "item", result.Items[0]["name"])
+		assert.Equal(t, "secret", password, "the input is left untouched")
+	})
+
+	t.Run("FullRedact with Stringer and typed map", func(t *testing.T) {
+		input := map[string]any{"token": secretStringer{secret: "s3cr3t"}}
+		result := utils.CensorSensitiveDataWithMode(input, maskFields, utils.FullRedact).(map[string]any)
+		assert.Equal(t, utils.REDACTED, result["token"])
+
+		typed := utils.CensorSensitiveDataWithMode(map[string]string{"password": "x", "name": "n"}, maskFields, utils.FullRedact).(map[string]string)
+		assert.Equal(t, map[string]string{"password": utils.REDACTED, "name": "n"}, typed)
+	})
+
+	t.Run("PartialMask matches CensorSensitiveData", func(t *testing.T) {
+		input := map[string]any{"password": "secret", "count": 42, "name": "n"}
+		assert.Equal(t,
+			utils.CensorSensitiveData(input, maskFields),
+			utils.CensorSensitiveDataWithMode(input, maskFields, utils.PartialMask))
+		assert.Equal(t, "s****t", utils.CensorSensitiveDataWithMode(input, maskFields, utils.PartialMask).(map[string]any)["password"])
+	})
+}
+
+type censorNode struct {
+	Name     string
+	Password string
+	Next     *censorNode
+}
+
+func TestCensorSensitiveDataWithOptions(t *testing.T) {
+	maskFields := []string{"password"}
+
+	t.Run("Self-referencing struct ends", func(t *testing.T) {
+		node := &censorNode{Name: "loop", Password: "secret"}
+		node.Next = node
+
+		result := utils.CensorSensitiveData(node, maskFields).(censorNode)
+
+		assert.Equal(t, "loop", result.Name)
+		assert.Equal(t, "s****t", result.Password)
+		assert.Equal(t, "s****t", result.Next.Password)
+		assert.Equal(t, "secret", node.Password, "the input is left untouched")
+	})
+
+	t.Run("Cyclic map ends", func(t *testing.T) {
+		loop := map[string]any{"password": "secret"}
+		loop["self"] = loop
+
+		result := utils.CensorSensitiveData(loop, maskFields).(map[string]any)
+
+		assert.Equal(t, "s****t", result["password"])
+		assert.Equal(t, "s****t", result["self"].(map[string]any)["password"])
+	})
+
+	t.Run("Values past MaxDepth are dropped", func(t *testing.T) {
+		third := &censorNode{Name: "third", Password: "third-secret"}
+		second := &censorNode{Name: "second", Password: "second-secret", Next: third}
+		first := &censorNode{Name: "first", Password: "first-secret", Next: second}
+
+		// Each node is two levels, its pointer and its struct
+		result := utils.CensorSensitiveDataWithOptions(first, maskFields, utils.CensorOptions{MaxDepth: 3}).(censorNode)
+
+		assert.Equal(t, "f********t", result.Password)
+		assert.Equal(t, "s********t", result.Next.Password)
+		assert.Nil(t, result.Next.Next, "a typed field cannot hold the placeholder, so it is left empty")
+		assert.Equal(t, "third-secret", third.Password, "the input is left untouched")
+	})
+
+	t.Run("Values past MaxDepth are replaced by a placeholder", func(t *testing.T) {
+		data := map[string]any{
+			"name": "top",
+			"nested": map[string]any{
+				"list": []any{map[string]any{"password": "deep-secret"}, "kept"},
+			},
+		}
+
+		result := utils.CensorSensitiveDataWithOptions(data, maskFields, utils.CensorOptions{MaxDepth: 2}).(map[string]any)
+
+		assert.Equal(t, "top", result["name"])
+		list := result["nested"].(map[string]any)["list"].([]any)
+		assert.Equal(t, utils.MAX_DEPTH_EXCEEDED, list[0])
+		assert.Equal(t, "kept", list[1], "plain values are kept at any depth")
+		assert.NotContains(t, fmt.Sprint(result), "deep-secret")
+	})
+
+	t.Run("Typed containers past MaxDepth are left empty", func(t *testing.T) {
+		data := map[string][]map[string]string{"groups": {{"password": "deep-secret"}}}
+
+		result := utils.CensorSensitiveDataWithOptions(data, maskFields, utils.CensorOptions{MaxDepth: 1}).(map[string][]map[string]string)
+
+		assert.Equal(t, []map[string]string{nil}, result["groups"])
+	})
+
+	t.Run("Mode is applied", func(t *testing.T) {
+		result := utils.CensorSensitiveDataWithOptions(map[string]string{"password": "secret"}, maskFields, utils.CensorOptions{Mode: utils.FullRedact})
+		assert.Equal(t, map[string]string{"password": utils.REDACTED}, result)
+	})
+}
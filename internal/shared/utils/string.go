@@ -2,7 +2,11 @@ package utils
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"math/big"
+	"strings"
 )
 
 // GenerateRandomString generates a random string of specified length using alphanumeric characters
@@ -48,3 +52,37 @@ func StringToPtr(s string) *string {
 func IntToPtr[T any](i T) *T {
 	return &i
 }
+
+// NormalizeEmail returns the email trimmed and lower-cased, the form in which emails are stored and looked up
+// Parameters:
+//   - email: the email as entered by the user
+//
+// Returns:
+//   - string: the normalized email
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// HashToken returns the hex encoded SHA-256 hash of a token sent to a user, the form in which it is stored and looked up,
+// so the database never holds a token that works
+// Parameters:
+//   - token: the token as sent to the user
+//
+// Returns:
+//   - string: the 64 character hash of the token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ConstantTimeCompare reports whether two secrets are equal in a time that does not depend on where they differ,
+// so the comparison cannot be used to guess a secret byte by byte. Use it instead of == for tokens and their hashes
+// Parameters:
+//   - a: the first secret
+//   - b: the second secret
+//
+// Returns:
+//   - bool: true if the secrets are equal
+func ConstantTimeCompare(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
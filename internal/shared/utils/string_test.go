@@ -100,3 +100,44 @@ func TestIntToPtr(t *testing.T) {
 		assert.Equal(t, input, *ptr)
 	})
 }
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := map[string]string{
+		"user@example.com":       "user@example.com",
+		"User@Example.COM":       "user@example.com",
+		"  user@example.com\t\n": "user@example.com",
+		"":                       "",
+	}
+	for input, expected := range tests {
+		assert.Equal(t, expected, utils.NormalizeEmail(input), "input %q", input)
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	hash := utils.HashToken("reset-token")
+
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, utils.HashToken("reset-token"))
+	assert.NotEqual(t, hash, utils.HashToken("reset-tokem"))
+	assert.NotContains(t, hash, "reset-token")
+}
+
+func TestConstantTimeCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected bool
+	}{
+		{"Equal", "reset-token", "reset-token", true},
+		{"BothEmpty", "", "", true},
+		{"DifferentLastByte", "reset-token", "reset-tokem", false},
+		{"Prefix", "reset-token", "reset", false},
+		{"OneEmpty", "reset-token", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, utils.ConstantTimeCompare(tt.a, tt.b))
+			assert.Equal(t, tt.expected, utils.ConstantTimeCompare(tt.b, tt.a))
+		})
+	}
+}
@@ -1,14 +1,22 @@
 package utils
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+	// Timezones are checked against the embedded database, the runtime image has none
+	_ "time/tzdata"
+	"unicode"
 
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
 	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
 )
 
@@ -17,8 +25,15 @@ import (
 func InitValidator() {
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		_ = v.RegisterValidation("valid_birthday", ValidateBirthday)
+		_ = v.RegisterValidation("date_only", ValidateDateOnly)
+		_ = v.RegisterValidation("not_future", ValidateNotFuture)
 		_ = v.RegisterValidation("not_blank", ValidateNotBlank)
+		_ = v.RegisterValidation("valid_gender", ValidateGender)
+		_ = v.RegisterValidation("valid_timezone", ValidateTimezone)
+		_ = v.RegisterValidation("valid_locale", ValidateLocale)
 		_ = v.RegisterValidation("password_complexity", ValidatePasswordComplexity)
+		_ = v.RegisterValidation("strong_password_entropy", ValidatePasswordEntropy)
+		_ = v.RegisterValidation("strong_password", ValidateStrongPassword)
 	}
 }
 
@@ -59,28 +74,169 @@ func ValidatePasswordComplexity(fl validator.FieldLevel) bool {
 	return hasUpper && hasLower && hasDigit && hasSpecial
 }
 
-// ValidateBirthday checks if the birthday is in a valid format and not a future date.
-func ValidateBirthday(fl validator.FieldLevel) bool {
-	birthdayStr := fl.Field().String()
-	layout := "2006-01-02" // Format: YYYY-MM-DD
+// PasswordEntropyBits estimates the entropy of a password in bits using the Shannon
+// entropy of its character distribution multiplied by its length.
+// Repeated characters and short passwords score low even when they satisfy
+// complexity rules, e.g. "Password1!" scores ~31 bits.
+func PasswordEntropyBits(password string) float64 {
+	runes := []rune(password)
+	if len(runes) == 0 {
+		return 0
+	}
+
+	frequencies := make(map[rune]int, len(runes))
+	for _, r := range runes {
+		frequencies[r]++
+	}
+
+	length := float64(len(runes))
+	var entropyPerChar float64
+	for _, count := range frequencies {
+		p := float64(count) / length
+		entropyPerChar -= p * math.Log2(p)
+	}
+
+	return entropyPerChar * length
+}
+
+// ValidatePasswordEntropy checks that the password entropy reaches the minimum
+// configured by PASSWORD_MIN_ENTROPY_BITS. The check is disabled when the
+// variable is unset or not positive.
+func ValidatePasswordEntropy(fl validator.FieldLevel) bool {
+	minBits := GetEnvAsInt("PASSWORD_MIN_ENTROPY_BITS", 0)
+	if minBits <= 0 {
+		return true
+	}
+	return PasswordEntropyBits(fl.Field().String()) >= float64(minBits)
+}
+
+// Password policies selected by PASSWORD_POLICY
+const (
+	PASSWORD_POLICY_RELAXED = "relaxed" // Any letter and a digit
+	PASSWORD_POLICY_BASIC   = "basic"   // Upper case, lower case and digit
+	PASSWORD_POLICY_STRICT  = "strict"  // Basic plus a symbol
+)
+
+// MissingPasswordClasses lists the character classes the password lacks under the
+// PASSWORD_POLICY policy, e.g. ["an uppercase letter", "a digit"]. Unknown policies fall back to basic
+func MissingPasswordClasses(password string) []string {
+	var hasLetter, hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, ch := range password {
+		if unicode.IsLetter(ch) {
+			hasLetter = true
+		}
+		switch {
+		case unicode.IsUpper(ch):
+			hasUpper = true
+		case unicode.IsLower(ch):
+			hasLower = true
+		case unicode.IsDigit(ch):
+			hasDigit = true
+		case unicode.IsPunct(ch) || unicode.IsSymbol(ch):
+			hasSymbol = true
+		}
+	}
+
+	policy := GetEnv("PASSWORD_POLICY", PASSWORD_POLICY_BASIC)
+	var missing []string
+	if policy == PASSWORD_POLICY_RELAXED {
+		if !hasLetter {
+			missing = append(missing, "a letter")
+		}
+	} else {
+		if !hasUpper {
+			missing = append(missing, "an uppercase letter")
+		}
+		if !hasLower {
+			missing = append(missing, "a lowercase letter")
+		}
+	}
+	if !hasDigit {
+		missing = append(missing, "a digit")
+	}
+	if !hasSymbol && policy == PASSWORD_POLICY_STRICT {
+		missing = append(missing, "a symbol")
+	}
+	return missing
+}
+
+// ValidateStrongPassword checks that the password contains every character class required by PASSWORD_POLICY
+func ValidateStrongPassword(fl validator.FieldLevel) bool {
+	return len(MissingPasswordClasses(fl.Field().String())) == 0
+}
+
+// ValidateGender checks that the integer is one of the stored genders: male, female or other
+func ValidateGender(fl validator.FieldLevel) bool {
+	if !fl.Field().CanInt() {
+		return false
+	}
+	return slices.Contains([]int16{constants.GENDER_MALE, constants.GENDER_FEMALE, constants.GENDER_OTHER}, int16(fl.Field().Int()))
+}
 
-	// Parse the birthday to check the format
-	parsedDate, err := time.Parse(layout, birthdayStr)
-	if err != nil {
-		return false // Invalid date format
+// ValidateTimezone checks that the string is an IANA timezone name such as "Asia/Ho_Chi_Minh" or "UTC".
+// "Local" is refused, it means whatever timezone the server runs in
+func ValidateTimezone(fl validator.FieldLevel) bool {
+	name := fl.Field().String()
+	if name == "" || name == "Local" {
+		return false
 	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
+
+// ValidateLocale checks that the string is one of constants.LOCALES
+func ValidateLocale(fl validator.FieldLevel) bool {
+	return slices.Contains(constants.LOCALES, fl.Field().String())
+}
+
+// ValidateDateOnly checks that the string is a date in the YYYY-MM-DD format
+func ValidateDateOnly(fl validator.FieldLevel) bool {
+	_, err := time.Parse(time.DateOnly, fl.Field().String())
+	return err == nil
+}
 
-	// Check if the birthday is in the future
-	if parsedDate.After(time.Now()) {
-		return false // Invalid: birthday can't be in the future
+// ValidateNotFuture checks that a time.Time, or a string holding a YYYY-MM-DD date or an RFC 3339 timestamp,
+// is not after the current time. Strings in neither format fail; combine with date_only for a clearer message
+func ValidateNotFuture(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	if t, ok := field.Interface().(time.Time); ok {
+		return !t.After(time.Now())
 	}
+	if field.Kind() != reflect.String {
+		return false
+	}
+	for _, layout := range []string{time.DateOnly, time.RFC3339} {
+		if t, err := time.Parse(layout, field.String()); err == nil {
+			return !t.After(time.Now())
+		}
+	}
+	return false
+}
 
-	return true // Valid birthday
+// ValidateBirthday checks if the birthday is in a valid format and not a future date.
+func ValidateBirthday(fl validator.FieldLevel) bool {
+	return ValidateDateOnly(fl) && ValidateNotFuture(fl)
 }
 
 // TranslateValidationErrors converts validation errors from the validator package
 // into a structured ValidationError that can be returned in API responses.
+// Messages are in English; see TranslateValidationErrorsIn for other languages
 func TranslateValidationErrors(err error, obj any) *apperror.ValidationError {
+	return TranslateValidationErrorsIn(LANGUAGE_EN, err, obj)
+}
+
+// TranslateValidationErrorsIn is TranslateValidationErrors with messages in language, e.g. from RequestLanguage.
+// Unsupported languages get English messages
+func TranslateValidationErrorsIn(language string, err error, obj any) *apperror.ValidationError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		field := jsonFieldPath(typeErr.Field)
+		return apperror.NewValidationError(translatePhrase(language, "Validation failed"), []apperror.FieldError{{
+			Field:   field,
+			Message: validationMessage(language, "json_type", field, translatePhrase(language, describeJSONType(typeErr.Type))),
+		}})
+	}
+
 	var ve validator.ValidationErrors
 	if !errors.As(err, &ve) {
 		return &apperror.ValidationError{
@@ -150,88 +306,14 @@ func TranslateValidationErrors(err error, obj any) *apperror.ValidationError {
 		fieldName := strings.Join(jsonParts, ".")
 
 		param := fe.Param()
-		var msg string
-
-		switch fe.Tag() {
-		case "required":
-			msg = fmt.Sprintf("%s is required", fieldName)
-		case "email":
-			msg = fmt.Sprintf("%s must be a valid email address", fieldName)
-		case "url":
-			msg = fmt.Sprintf("%s must be a valid URL", fieldName)
-		case "uuid":
-			msg = fmt.Sprintf("%s must be a valid UUID", fieldName)
-		case "len":
-			msg = fmt.Sprintf("%s must be exactly %s characters long", fieldName, param)
-		case "min":
-			msg = fmt.Sprintf("%s must be at least %s characters long or numeric", fieldName, param)
-		case "max":
-			msg = fmt.Sprintf("%s must be at most %s characters long or numeric", fieldName, param)
-		case "eq":
-			msg = fmt.Sprintf("%s must be equal to %s", fieldName, param)
-		case "ne":
-			msg = fmt.Sprintf("%s must not be equal to %s", fieldName, param)
-		case "lt":
-			msg = fmt.Sprintf("%s must be less than %s", fieldName, param)
-		case "lte":
-			msg = fmt.Sprintf("%s must be less than or equal to %s", fieldName, param)
-		case "gt":
-			msg = fmt.Sprintf("%s must be greater than %s", fieldName, param)
-		case "gte":
-			msg = fmt.Sprintf("%s must be greater than or equal to %s", fieldName, param)
-		case "oneof":
-			msg = fmt.Sprintf("%s must be one of [%s]", fieldName, param)
-		case "contains":
-			msg = fmt.Sprintf("%s must contain '%s'", fieldName, param)
-		case "excludes":
-			msg = fmt.Sprintf("%s must not contain '%s'", fieldName, param)
-		case "startswith":
-			msg = fmt.Sprintf("%s must start with '%s'", fieldName, param)
-		case "endswith":
-			msg = fmt.Sprintf("%s must end with '%s'", fieldName, param)
-		case "ip":
-			msg = fmt.Sprintf("%s must be a valid IP address", fieldName)
-		case "ipv4":
-			msg = fmt.Sprintf("%s must be a valid IPv4 address", fieldName)
-		case "ipv6":
-			msg = fmt.Sprintf("%s must be a valid IPv6 address", fieldName)
-		case "datetime":
-			msg = fmt.Sprintf("%s must be a valid datetime (format: %s)", fieldName, param)
-		case "numeric":
-			msg = fmt.Sprintf("%s must be a numeric value", fieldName)
-		case "boolean":
-			msg = fmt.Sprintf("%s must be a boolean value", fieldName)
-		case "alpha":
-			msg = fmt.Sprintf("%s must contain only letters", fieldName)
-		case "alphanum":
-			msg = fmt.Sprintf("%s must contain only letters and numbers", fieldName)
-		case "alphanumunicode":
-			msg = fmt.Sprintf("%s must contain only unicode letters and numbers", fieldName)
-		case "ascii":
-			msg = fmt.Sprintf("%s must contain only ASCII characters", fieldName)
-		case "printascii":
-			msg = fmt.Sprintf("%s must contain only printable ASCII characters", fieldName)
-		case "base64":
-			msg = fmt.Sprintf("%s must be a valid base64 string", fieldName)
-		case "containsany":
-			msg = fmt.Sprintf("%s must contain at least one of the characters in '%s'", fieldName, param)
-		case "excludesall":
-			msg = fmt.Sprintf("%s must not contain any of the characters in '%s'", fieldName, param)
-		case "excludesrune":
-			msg = fmt.Sprintf("%s must not contain the rune '%s'", fieldName, param)
-		case "isdefault":
-			msg = fmt.Sprintf("%s must be the default value", fieldName)
-		case "unique":
-			msg = fmt.Sprintf("%s must contain unique values", fieldName)
-		case "valid_birthday":
-			msg = fmt.Sprintf("%s must be a valid date (YYYY-MM-DD) and not in the future", fieldName)
-		case "not_blank":
-			msg = fmt.Sprintf("%s must not be blank", fieldName)
-		case "password_complexity":
-			msg = fmt.Sprintf("%s must be at least 8 characters and contain uppercase, lowercase, digit, and special character", fieldName)
-		default:
-			msg = fmt.Sprintf("%s is invalid", fieldName)
+		if fe.Tag() == "strong_password" {
+			missing := MissingPasswordClasses(fmt.Sprint(fe.Value()))
+			for i := range missing {
+				missing[i] = translatePhrase(language, missing[i])
+			}
+			param = joinWithAnd(language, missing)
 		}
+		msg := validationMessage(language, fe.Tag(), fieldName, param)
 
 		fieldErrors = append(fieldErrors, apperror.FieldError{
 			Field:   fieldName,
@@ -239,7 +321,20 @@ func TranslateValidationErrors(err error, obj any) *apperror.ValidationError {
 		})
 	}
 
-	return apperror.NewValidationError("Validation failed", fieldErrors)
+	return apperror.NewValidationError(translatePhrase(language, "Validation failed"), fieldErrors)
+}
+
+// joinWithAnd joins items as "a, b and c" in language
+func joinWithAnd(language string, items []string) string {
+	if len(items) <= 1 {
+		return strings.Join(items, "")
+	}
+	comma, and := ", ", " "+translatePhrase(language, "and")+" "
+	if language == LANGUAGE_JA {
+		// Japanese lists are written without spaces, as in 大文字、小文字と数字
+		comma, and = "、", translatePhrase(language, "and")
+	}
+	return strings.Join(items[:len(items)-1], comma) + and + items[len(items)-1]
 }
 
 // The utility function to map JSON errors to FieldError structs.
@@ -262,3 +357,44 @@ func ToFieldErrors(json any) []apperror.FieldError {
 
 	return fieldErrors
 }
+
+// jsonFieldPath writes the dotted path of a json.UnmarshalTypeError, e.g. "items.0.enabled",
+// with array indexes in brackets as the validator paths are, e.g. "items[0].enabled"
+func jsonFieldPath(path string) string {
+	parts := strings.Split(path, ".")
+	var b strings.Builder
+	for i, part := range parts {
+		if _, err := strconv.Atoi(part); err == nil && i > 0 {
+			b.WriteString("[" + part + "]")
+			continue
+		}
+		if i > 0 {
+			b.WriteString(".")
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+// describeJSONType names the JSON value expected for a Go type, for messages about values of the wrong type
+func describeJSONType(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	default:
+		return "a valid value"
+	}
+}
@@ -1,6 +1,7 @@
 package utils_test
 
 import (
+	"encoding/json"
 	"errors"
 	"testing"
 	"time"
@@ -8,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
 	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
 )
@@ -255,17 +257,170 @@ func TestTranslateValidationErrors(t *testing.T) {
 	}
 }
 
+func TestValidateDateOnly(t *testing.T) {
+	validate := validator.New()
+	_ = validate.RegisterValidation("date_only", utils.ValidateDateOnly)
+
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "Date", value: "2000-01-01", wantErr: false},
+		{name: "Future date", value: "3000-01-01", wantErr: false},
+		{name: "Other format", value: "01-01-2000", wantErr: true},
+		{name: "Timestamp", value: "2000-01-01T00:00:00Z", wantErr: true},
+		{name: "Impossible date", value: "2000-02-30", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate.Var(tt.value, "date_only")
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateNotFuture(t *testing.T) {
+	validate := validator.New()
+	_ = validate.RegisterValidation("not_future", utils.ValidateNotFuture)
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	tests := []struct {
+		name    string
+		value   any
+		wantErr bool
+	}{
+		{name: "Past date", value: "2000-01-01", wantErr: false},
+		{name: "Future date", value: time.Now().AddDate(1, 0, 0).Format(time.DateOnly), wantErr: true},
+		{name: "Past timestamp", value: past.Format(time.RFC3339), wantErr: false},
+		{name: "Future timestamp", value: future.Format(time.RFC3339), wantErr: true},
+		{name: "Past time", value: past, wantErr: false},
+		{name: "Future time", value: future, wantErr: true},
+		{name: "Past time pointer", value: &past, wantErr: false},
+		{name: "Not a date", value: "yesterday", wantErr: true},
+		{name: "Not a string", value: 2000, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate.Var(tt.value, "not_future")
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateGender(t *testing.T) {
+	validate := validator.New()
+	_ = validate.RegisterValidation("valid_gender", utils.ValidateGender)
+
+	tests := []struct {
+		name    string
+		gender  int16
+		wantErr bool
+	}{
+		{name: "Male", gender: 1, wantErr: false},
+		{name: "Female", gender: 2, wantErr: false},
+		{name: "Other", gender: 3, wantErr: false},
+		{name: "Unknown", gender: 4, wantErr: true},
+		{name: "Zero", gender: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate.Struct(struct {
+				Gender  int16  `validate:"valid_gender"`
+				Pointer *int16 `validate:"omitempty,valid_gender"`
+			}{Gender: tt.gender, Pointer: &tt.gender})
+			if tt.wantErr {
+				var ve validator.ValidationErrors
+				assert.ErrorAs(t, err, &ve)
+				assert.Len(t, ve, 2)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateTimezone(t *testing.T) {
+	validate := validator.New()
+	_ = validate.RegisterValidation("valid_timezone", utils.ValidateTimezone)
+
+	tests := []struct {
+		name     string
+		timezone string
+		wantErr  bool
+	}{
+		{name: "UTC", timezone: "UTC", wantErr: false},
+		{name: "Region", timezone: "Asia/Ho_Chi_Minh", wantErr: false},
+		{name: "Bogus", timezone: "Mars/Olympus_Mons", wantErr: true},
+		{name: "Local", timezone: "Local", wantErr: true},
+		{name: "Empty", timezone: "", wantErr: true},
+		{name: "Path", timezone: "../../etc/passwd", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate.Struct(struct {
+				Timezone string `validate:"valid_timezone"`
+			}{Timezone: tt.timezone})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateLocale(t *testing.T) {
+	validate := validator.New()
+	_ = validate.RegisterValidation("valid_locale", utils.ValidateLocale)
+
+	for locale, valid := range map[string]bool{"en": true, "vi": true, "fr": false, "EN": false, "": false} {
+		err := validate.Struct(struct {
+			Locale string `validate:"valid_locale"`
+		}{Locale: locale})
+		assert.Equal(t, valid, err == nil, locale)
+	}
+}
+
 func TestTranslateValidationErrors_ExtraCases(t *testing.T) {
 	validate := validator.New()
 
 	_ = validate.RegisterValidation("valid_birthday", utils.ValidateBirthday)
 	_ = validate.RegisterValidation("not_blank", utils.ValidateNotBlank)
+	_ = validate.RegisterValidation("valid_gender", utils.ValidateGender)
+	_ = validate.RegisterValidation("date_only", utils.ValidateDateOnly)
+	_ = validate.RegisterValidation("not_future", utils.ValidateNotFuture)
 
 	tests := []struct {
 		name     string
 		input    any
 		expected []apperror.FieldError
 	}{
+		{
+			name: "valid_gender (unknown gender)",
+			input: struct {
+				Gender int16 `json:"gender" validate:"valid_gender"`
+			}{Gender: 4},
+			expected: []apperror.FieldError{
+				{
+					Field:   "gender",
+					Message: "gender must be male, female, or other",
+				},
+			},
+		},
 		{
 			name: "valid_birthday (future date)",
 			input: struct {
@@ -278,6 +433,30 @@ func TestTranslateValidationErrors_ExtraCases(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "date_only (other format)",
+			input: struct {
+				StartsOn string `json:"starts_on" validate:"date_only"`
+			}{StartsOn: "01/02/2000"},
+			expected: []apperror.FieldError{
+				{
+					Field:   "starts_on",
+					Message: "starts_on must be a valid date (YYYY-MM-DD)",
+				},
+			},
+		},
+		{
+			name: "not_future (future date)",
+			input: struct {
+				StartedOn string `json:"started_on" validate:"date_only,not_future"`
+			}{StartedOn: "3000-01-01"},
+			expected: []apperror.FieldError{
+				{
+					Field:   "started_on",
+					Message: "started_on must not be in the future",
+				},
+			},
+		},
 		{
 			name: "not_blank (blank field)",
 			input: struct {
@@ -359,6 +538,55 @@ func TestTranslateValidationErrors_DefaultCase(t *testing.T) {
 	})
 }
 
+func TestTranslateValidationErrors_UnmarshalTypeError(t *testing.T) {
+	type Item struct {
+		Enabled bool `json:"enabled"`
+	}
+	type Input struct {
+		Gender int16   `json:"gender"`
+		Name   *string `json:"name"`
+		Tags   []uint  `json:"tags"`
+		Items  []Item  `json:"items"`
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		expected apperror.FieldError
+	}{
+		{"Number", `{"gender": "male"}`, apperror.FieldError{Field: "gender", Message: "gender must be a number"}},
+		{"String", `{"name": 1}`, apperror.FieldError{Field: "name", Message: "name must be a string"}},
+		{"Array", `{"tags": "a"}`, apperror.FieldError{Field: "tags", Message: "tags must be an array"}},
+		{"ArrayElement", `{"tags": [1, "a"]}`, apperror.FieldError{Field: "tags[1]", Message: "tags[1] must be a number"}},
+		{"NestedField", `{"items": [{"enabled": "yes"}]}`, apperror.FieldError{Field: "items[0].enabled", Message: "items[0].enabled must be a boolean"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var input Input
+			err := json.Unmarshal([]byte(tc.body), &input)
+			require.Error(t, err)
+
+			result := utils.TranslateValidationErrors(err, &input)
+
+			assert.Equal(t, apperror.ErrValidationFailed, result.Code)
+			assert.Equal(t, "Validation failed", result.Message)
+			assert.Equal(t, []apperror.FieldError{tc.expected}, result.Fields)
+		})
+	}
+
+	t.Run("SyntaxErrorKeepsMessage", func(t *testing.T) {
+		var input Input
+		err := json.Unmarshal([]byte(`{"gender":`), &input)
+		require.Error(t, err)
+
+		result := utils.TranslateValidationErrors(err, &input)
+
+		assert.Equal(t, err.Error(), result.Message)
+		assert.Empty(t, result.Fields)
+	})
+}
+
 func TestToFieldErrors(t *testing.T) {
 	t.Run("MapArrayToFieldErrors", func(t *testing.T) {
 		input := []any{
@@ -497,3 +725,112 @@ func TestValidatePasswordComplexity(t *testing.T) {
 		})
 	}
 }
+
+func TestPasswordEntropyBits(t *testing.T) {
+	t.Run("Empty password has no entropy", func(t *testing.T) {
+		assert.Equal(t, 0.0, utils.PasswordEntropyBits(""))
+	})
+
+	t.Run("Repeated characters have no entropy", func(t *testing.T) {
+		assert.Equal(t, 0.0, utils.PasswordEntropyBits("aaaaaaaa"))
+	})
+
+	t.Run("Distinct characters score higher than repeated ones", func(t *testing.T) {
+		assert.Greater(t, utils.PasswordEntropyBits("xK9#mQ2$vL7@pR4!"), utils.PasswordEntropyBits("Password1!"))
+	})
+}
+
+func TestValidatePasswordEntropy(t *testing.T) {
+	validate := validator.New()
+	_ = validate.RegisterValidation("strong_password_entropy", utils.ValidatePasswordEntropy)
+
+	type input struct {
+		Password string `validate:"strong_password_entropy"`
+	}
+
+	t.Run("Disabled when minimum is not configured", func(t *testing.T) {
+		t.Setenv("PASSWORD_MIN_ENTROPY_BITS", "")
+
+		err := validate.Struct(input{Password: "aaaaaa"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Rejects low-entropy password that satisfies complexity rules", func(t *testing.T) {
+		t.Setenv("PASSWORD_MIN_ENTROPY_BITS", "40")
+
+		err := validate.Struct(input{Password: "Password1!"})
+		assert.Error(t, err)
+
+		result := utils.TranslateValidationErrors(err, input{})
+		assert.Equal(t, []apperror.FieldError{
+			{Field: "Password", Message: "Password is too easy to guess, use a longer password with more varied characters"},
+		}, result.Fields)
+	})
+
+	t.Run("Accepts high-entropy password", func(t *testing.T) {
+		t.Setenv("PASSWORD_MIN_ENTROPY_BITS", "40")
+
+		err := validate.Struct(input{Password: "xK9#mQ2$vL7@pR4!"})
+		assert.NoError(t, err)
+	})
+}
+
+func TestValidateStrongPassword(t *testing.T) {
+	validate := validator.New()
+	_ = validate.RegisterValidation("strong_password", utils.ValidateStrongPassword)
+
+	type input struct {
+		Password string `json:"password" validate:"strong_password"`
+	}
+
+	tests := []struct {
+		name     string
+		policy   string
+		password string
+		message  string
+	}{
+		{"Basic - Missing uppercase", "basic", "passw0rd", "password must contain an uppercase letter"},
+		{"Basic - Missing lowercase", "basic", "PASSW0RD", "password must contain a lowercase letter"},
+		{"Basic - Missing digit", "basic", "Password", "password must contain a digit"},
+		{"Basic - Missing several classes", "basic", "aaaaaa", "password must contain an uppercase letter and a digit"},
+		{"Basic - Missing every class", "basic", "!!!!!!", "password must contain an uppercase letter, a lowercase letter and a digit"},
+		{"Relaxed - Missing letter", "relaxed", "123456", "password must contain a letter"},
+		{"Relaxed - Missing digit", "relaxed", "password", "password must contain a digit"},
+		{"Relaxed - Missing every class", "relaxed", "!!!!!!", "password must contain a letter and a digit"},
+		{"Strict - Missing symbol", "strict", "Passw0rd", "password must contain a symbol"},
+		{"Strict - Missing digit and symbol", "strict", "Password", "password must contain a digit and a symbol"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PASSWORD_POLICY", tt.policy)
+
+			err := validate.Struct(input{Password: tt.password})
+			assert.Error(t, err)
+
+			result := utils.TranslateValidationErrors(err, input{})
+			assert.Equal(t, []apperror.FieldError{{Field: "password", Message: tt.message}}, result.Fields)
+		})
+	}
+
+	t.Run("Basic - Accepts password without symbol", func(t *testing.T) {
+		t.Setenv("PASSWORD_POLICY", "basic")
+		assert.NoError(t, validate.Struct(input{Password: "Passw0rd"}))
+	})
+
+	t.Run("Relaxed - Accepts lowercase letters and digits", func(t *testing.T) {
+		t.Setenv("PASSWORD_POLICY", "relaxed")
+		assert.NoError(t, validate.Struct(input{Password: "passw0rd"}))
+	})
+
+	t.Run("Strict - Accepts password with every class", func(t *testing.T) {
+		t.Setenv("PASSWORD_POLICY", "strict")
+		assert.NoError(t, validate.Struct(input{Password: "Passw0rd!"}))
+	})
+
+	t.Run("Defaults to basic policy", func(t *testing.T) {
+		t.Setenv("PASSWORD_POLICY", "")
+		assert.NoError(t, validate.Struct(input{Password: "Passw0rd"}))
+		assert.Error(t, validate.Struct(input{Password: "password"}))
+	})
+}
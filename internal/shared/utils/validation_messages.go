@@ -0,0 +1,279 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Languages of the validation and error messages. Requests in any other language get English
+const (
+	LANGUAGE_EN = "en"
+	LANGUAGE_JA = "ja"
+	LANGUAGE_VI = "vi"
+)
+
+// LanguageKey is the context key LanguageMiddleware stores the language of the request under
+const LanguageKey = "Language"
+
+// validationMessages holds the validation message formats of each language, keyed by validator tag.
+// Formats take the JSON field name as %[1]s and the tag parameter as %[2]s. "json_type" is the message
+// for a value of the wrong JSON type, with the expected type as %[2]s; "" is used for tags without a message
+var validationMessages = map[string]map[string]string{
+	LANGUAGE_EN: {
+		"required":                "%[1]s is required",
+		"email":                   "%[1]s must be a valid email address",
+		"url":                     "%[1]s must be a valid URL",
+		"uuid":                    "%[1]s must be a valid UUID",
+		"len":                     "%[1]s must be exactly %[2]s characters long",
+		"min":                     "%[1]s must be at least %[2]s characters long or numeric",
+		"max":                     "%[1]s must be at most %[2]s characters long or numeric",
+		"eq":                      "%[1]s must be equal to %[2]s",
+		"ne":                      "%[1]s must not be equal to %[2]s",
+		"lt":                      "%[1]s must be less than %[2]s",
+		"lte":                     "%[1]s must be less than or equal to %[2]s",
+		"gt":                      "%[1]s must be greater than %[2]s",
+		"gte":                     "%[1]s must be greater than or equal to %[2]s",
+		"oneof":                   "%[1]s must be one of [%[2]s]",
+		"contains":                "%[1]s must contain '%[2]s'",
+		"excludes":                "%[1]s must not contain '%[2]s'",
+		"startswith":              "%[1]s must start with '%[2]s'",
+		"endswith":                "%[1]s must end with '%[2]s'",
+		"ip":                      "%[1]s must be a valid IP address",
+		"ipv4":                    "%[1]s must be a valid IPv4 address",
+		"ipv6":                    "%[1]s must be a valid IPv6 address",
+		"datetime":                "%[1]s must be a valid datetime (format: %[2]s)",
+		"numeric":                 "%[1]s must be a numeric value",
+		"boolean":                 "%[1]s must be a boolean value",
+		"alpha":                   "%[1]s must contain only letters",
+		"alphanum":                "%[1]s must contain only letters and numbers",
+		"alphanumunicode":         "%[1]s must contain only unicode letters and numbers",
+		"ascii":                   "%[1]s must contain only ASCII characters",
+		"printascii":              "%[1]s must contain only printable ASCII characters",
+		"base64":                  "%[1]s must be a valid base64 string",
+		"containsany":             "%[1]s must contain at least one of the characters in '%[2]s'",
+		"excludesall":             "%[1]s must not contain any of the characters in '%[2]s'",
+		"excludesrune":            "%[1]s must not contain the rune '%[2]s'",
+		"isdefault":               "%[1]s must be the default value",
+		"unique":                  "%[1]s must contain unique values",
+		"valid_birthday":          "%[1]s must be a valid date (YYYY-MM-DD) and not in the future",
+		"date_only":               "%[1]s must be a valid date (YYYY-MM-DD)",
+		"not_future":              "%[1]s must not be in the future",
+		"not_blank":               "%[1]s must not be blank",
+		"valid_gender":            "%[1]s must be male, female, or other",
+		"valid_timezone":          "%[1]s must be a valid IANA timezone, e.g. Asia/Ho_Chi_Minh",
+		"valid_locale":            "%[1]s must be a supported locale",
+		"password_complexity":     "%[1]s must be at least 8 characters and contain uppercase, lowercase, digit, and special character",
+		"strong_password_entropy": "%[1]s is too easy to guess, use a longer password with more varied characters",
+		"strong_password":         "%[1]s must contain %[2]s",
+		"json_type":               "%[1]s must be %[2]s",
+		"":                        "%[1]s is invalid",
+	},
+	LANGUAGE_JA: {
+		"required":                "%[1]sは必須です",
+		"email":                   "%[1]sは有効なメールアドレスでなければなりません",
+		"url":                     "%[1]sは有効なURLでなければなりません",
+		"uuid":                    "%[1]sは有効なUUIDでなければなりません",
+		"len":                     "%[1]sはちょうど%[2]s文字でなければなりません",
+		"min":                     "%[1]sは%[2]s文字以上または%[2]s以上でなければなりません",
+		"max":                     "%[1]sは%[2]s文字以下または%[2]s以下でなければなりません",
+		"eq":                      "%[1]sは%[2]sでなければなりません",
+		"ne":                      "%[1]sは%[2]s以外でなければなりません",
+		"lt":                      "%[1]sは%[2]s未満でなければなりません",
+		"lte":                     "%[1]sは%[2]s以下でなければなりません",
+		"gt":                      "%[1]sは%[2]sより大きくなければなりません",
+		"gte":                     "%[1]sは%[2]s以上でなければなりません",
+		"oneof":                   "%[1]sは[%[2]s]のいずれかでなければなりません",
+		"contains":                "%[1]sは'%[2]s'を含まなければなりません",
+		"excludes":                "%[1]sに'%[2]s'を含めることはできません",
+		"startswith":              "%[1]sは'%[2]s'で始まらなければなりません",
+		"endswith":                "%[1]sは'%[2]s'で終わらなければなりません",
+		"ip":                      "%[1]sは有効なIPアドレスでなければなりません",
+		"ipv4":                    "%[1]sは有効なIPv4アドレスでなければなりません",
+		"ipv6":                    "%[1]sは有効なIPv6アドレスでなければなりません",
+		"datetime":                "%[1]sは有効な日時でなければなりません（形式: %[2]s）",
+		"numeric":                 "%[1]sは数値でなければなりません",
+		"boolean":                 "%[1]sは真偽値でなければなりません",
+		"alpha":                   "%[1]sには英字のみ使用できます",
+		"alphanum":                "%[1]sには英数字のみ使用できます",
+		"alphanumunicode":         "%[1]sには文字と数字のみ使用できます",
+		"ascii":                   "%[1]sにはASCII文字のみ使用できます",
+		"printascii":              "%[1]sには印字可能なASCII文字のみ使用できます",
+		"base64":                  "%[1]sは有効なbase64文字列でなければなりません",
+		"containsany":             "%[1]sは'%[2]s'のいずれかの文字を含まなければなりません",
+		"excludesall":             "%[1]sに'%[2]s'のいずれの文字も含めることはできません",
+		"excludesrune":            "%[1]sに文字'%[2]s'を含めることはできません",
+		"isdefault":               "%[1]sはデフォルト値でなければなりません",
+		"unique":                  "%[1]sの値は重複できません",
+		"valid_birthday":          "%[1]sは未来ではない有効な日付（YYYY-MM-DD）でなければなりません",
+		"date_only":               "%[1]sは有効な日付（YYYY-MM-DD）でなければなりません",
+		"not_future":              "%[1]sに未来の日付は指定できません",
+		"not_blank":               "%[1]sは空白にできません",
+		"valid_gender":            "%[1]sは男性、女性、その他のいずれかでなければなりません",
+		"valid_timezone":          "%[1]sは有効なIANAタイムゾーン（例: Asia/Tokyo）でなければなりません",
+		"valid_locale":            "%[1]sはサポートされているロケールでなければなりません",
+		"password_complexity":     "%[1]sは8文字以上で、大文字、小文字、数字、記号を含まなければなりません",
+		"strong_password_entropy": "%[1]sは推測されやすすぎます。より長く、多様な文字を使ったパスワードにしてください",
+		"strong_password":         "%[1]sには%[2]sを含めてください",
+		"json_type":               "%[1]sは%[2]sでなければなりません",
+		"":                        "%[1]sが正しくありません",
+	},
+	LANGUAGE_VI: {
+		"required":                "%[1]s là bắt buộc",
+		"email":                   "%[1]s phải là địa chỉ email hợp lệ",
+		"url":                     "%[1]s phải là URL hợp lệ",
+		"uuid":                    "%[1]s phải là UUID hợp lệ",
+		"len":                     "%[1]s phải dài đúng %[2]s ký tự",
+		"min":                     "%[1]s phải dài ít nhất %[2]s ký tự hoặc không nhỏ hơn %[2]s",
+		"max":                     "%[1]s phải dài tối đa %[2]s ký tự hoặc không lớn hơn %[2]s",
+		"eq":                      "%[1]s phải bằng %[2]s",
+		"ne":                      "%[1]s không được bằng %[2]s",
+		"lt":                      "%[1]s phải nhỏ hơn %[2]s",
+		"lte":                     "%[1]s phải nhỏ hơn hoặc bằng %[2]s",
+		"gt":                      "%[1]s phải lớn hơn %[2]s",
+		"gte":                     "%[1]s phải lớn hơn hoặc bằng %[2]s",
+		"oneof":                   "%[1]s phải là một trong [%[2]s]",
+		"contains":                "%[1]s phải chứa '%[2]s'",
+		"excludes":                "%[1]s không được chứa '%[2]s'",
+		"startswith":              "%[1]s phải bắt đầu bằng '%[2]s'",
+		"endswith":                "%[1]s phải kết thúc bằng '%[2]s'",
+		"ip":                      "%[1]s phải là địa chỉ IP hợp lệ",
+		"ipv4":                    "%[1]s phải là địa chỉ IPv4 hợp lệ",
+		"ipv6":                    "%[1]s phải là địa chỉ IPv6 hợp lệ",
+		"datetime":                "%[1]s phải là ngày giờ hợp lệ (định dạng: %[2]s)",
+		"numeric":                 "%[1]s phải là giá trị số",
+		"boolean":                 "%[1]s phải là giá trị boolean",
+		"alpha":                   "%[1]s chỉ được chứa chữ cái",
+		"alphanum":                "%[1]s chỉ được chứa chữ cái và chữ số",
+		"alphanumunicode":         "%[1]s chỉ được chứa chữ cái và chữ số unicode",
+		"ascii":                   "%[1]s chỉ được chứa ký tự ASCII",
+		"printascii":              "%[1]s chỉ được chứa ký tự ASCII in được",
+		"base64":                  "%[1]s phải là chuỗi base64 hợp lệ",
+		"containsany":             "%[1]s phải chứa ít nhất một trong các ký tự '%[2]s'",
+		"excludesall":             "%[1]s không được chứa ký tự nào trong '%[2]s'",
+		"excludesrune":            "%[1]s không được chứa ký tự '%[2]s'",
+		"isdefault":               "%[1]s phải là giá trị mặc định",
+		"unique":                  "%[1]s phải chứa các giá trị không trùng nhau",
+		"valid_birthday":          "%[1]s phải là ngày hợp lệ (YYYY-MM-DD) và không ở tương lai",
+		"date_only":               "%[1]s phải là ngày hợp lệ (YYYY-MM-DD)",
+		"not_future":              "%[1]s không được ở tương lai",
+		"not_blank":               "%[1]s không được để trống",
+		"valid_gender":            "%[1]s phải là nam, nữ hoặc khác",
+		"valid_timezone":          "%[1]s phải là múi giờ IANA hợp lệ, ví dụ Asia/Ho_Chi_Minh",
+		"valid_locale":            "%[1]s phải là ngôn ngữ được hỗ trợ",
+		"password_complexity":     "%[1]s phải dài ít nhất 8 ký tự và chứa chữ hoa, chữ thường, chữ số và ký tự đặc biệt",
+		"strong_password_entropy": "%[1]s quá dễ đoán, hãy dùng mật khẩu dài hơn với các ký tự đa dạng hơn",
+		"strong_password":         "%[1]s phải chứa %[2]s",
+		"json_type":               "%[1]s phải là %[2]s",
+		"":                        "%[1]s không hợp lệ",
+	},
+}
+
+// validationPhrases translates the English phrases put into validation messages from the code:
+// the summary message, password character classes, JSON types and the "and" joining lists
+var validationPhrases = map[string]map[string]string{
+	LANGUAGE_JA: {
+		"Validation failed":   "入力内容に誤りがあります",
+		"and":                 "と",
+		"a letter":            "英字",
+		"an uppercase letter": "大文字",
+		"a lowercase letter":  "小文字",
+		"a digit":             "数字",
+		"a symbol":            "記号",
+		"a number":            "数値",
+		"a boolean":           "真偽値",
+		"a string":            "文字列",
+		"an array":            "配列",
+		"an object":           "オブジェクト",
+		"a valid value":       "有効な値",
+	},
+	LANGUAGE_VI: {
+		"Validation failed":   "Dữ liệu không hợp lệ",
+		"and":                 "và",
+		"a letter":            "một chữ cái",
+		"an uppercase letter": "một chữ hoa",
+		"a lowercase letter":  "một chữ thường",
+		"a digit":             "một chữ số",
+		"a symbol":            "một ký hiệu",
+		"a number":            "một số",
+		"a boolean":           "giá trị boolean",
+		"a string":            "một chuỗi",
+		"an array":            "một mảng",
+		"an object":           "một đối tượng",
+		"a valid value":       "giá trị hợp lệ",
+	},
+}
+
+// ParseAcceptLanguage returns the supported language the Accept-Language header prefers, e.g. "vi" for
+// "vi-VN,vi;q=0.9,en;q=0.8". Regions are ignored and languages with q=0 are refused; without a supported language it returns English
+func ParseAcceptLanguage(header string) string {
+	language, best := LANGUAGE_EN, 0.0
+	for _, item := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if _, ok := validationMessages[base]; !ok {
+			continue
+		}
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality > best {
+			language, best = base, quality
+		}
+	}
+	return language
+}
+
+// ParseLanguage returns the language of a request: the supported language of the lang query parameter, e.g. "ja"
+// or "ja-JP", else the one the Accept-Language header prefers, else English
+func ParseLanguage(query, header string) string {
+	base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(query)), "-")
+	if _, ok := validationMessages[base]; ok {
+		return base
+	}
+	return ParseAcceptLanguage(header)
+}
+
+// RequestLanguage returns the language of the validation and error messages for the request: the one stored by
+// LanguageMiddleware, or else the one of its lang query parameter or Accept-Language header
+func RequestLanguage(ctx *gin.Context) string {
+	if language := ctx.GetString(LanguageKey); language != "" {
+		return language
+	}
+	if ctx.Request == nil {
+		return LANGUAGE_EN
+	}
+	return ParseLanguage(ctx.Query("lang"), ctx.GetHeader("Accept-Language"))
+}
+
+// validationMessage formats the message of the validator tag in language. A tag without a message in
+// language gets the English one, and a tag without any message the generic one of language
+func validationMessage(language, tag, field, param string) string {
+	messages, ok := validationMessages[language]
+	if !ok {
+		messages = validationMessages[LANGUAGE_EN]
+	}
+	format, ok := messages[tag]
+	if !ok {
+		format, ok = validationMessages[LANGUAGE_EN][tag]
+	}
+	if !ok {
+		format = messages[""]
+	}
+	return fmt.Sprintf(format, field, param)
+}
+
+// translatePhrase returns the phrase in language, or unchanged if it has no translation
+func translatePhrase(language, phrase string) string {
+	if translated, ok := validationPhrases[language][phrase]; ok {
+		return translated
+	}
+	return phrase
+}
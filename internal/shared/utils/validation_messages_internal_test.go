@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidationMessages_EveryLanguageHasEveryTag(t *testing.T) {
+	for language, messages := range validationMessages {
+		for tag := range validationMessages[LANGUAGE_EN] {
+			assert.Contains(t, messages, tag, "language %s", language)
+		}
+		assert.Len(t, messages, len(validationMessages[LANGUAGE_EN]), "language %s", language)
+	}
+}
+
+func TestValidationMessage(t *testing.T) {
+	assert.Equal(t, "name must be at least 3 characters long or numeric", validationMessage(LANGUAGE_EN, "min", "name", "3"))
+	assert.Equal(t, "name is required", validationMessage(LANGUAGE_EN, "required", "name", ""))
+	assert.Equal(t, "name không hợp lệ", validationMessage(LANGUAGE_VI, "unknown_tag", "name", ""))
+	assert.Equal(t, "name is invalid", validationMessage("fr", "unknown_tag", "name", ""))
+	assert.Equal(t, "nameは必須です", validationMessage(LANGUAGE_JA, "required", "name", ""))
+}
+
+func TestValidationMessage_MissingTranslationFallsBackToEnglish(t *testing.T) {
+	format := validationMessages[LANGUAGE_JA]["not_blank"]
+	delete(validationMessages[LANGUAGE_JA], "not_blank")
+	t.Cleanup(func() { validationMessages[LANGUAGE_JA]["not_blank"] = format })
+
+	assert.Equal(t, "name must not be blank", validationMessage(LANGUAGE_JA, "not_blank", "name", ""))
+	assert.Equal(t, "nameが正しくありません", validationMessage(LANGUAGE_JA, "unknown_tag", "name", ""))
+}
+
+func TestErrorMessages_EveryLanguageHasEveryMessage(t *testing.T) {
+	for language, messages := range errorMessages {
+		for message := range errorMessages[LANGUAGE_JA] {
+			assert.Contains(t, messages, message, "language %s", language)
+		}
+		assert.Len(t, messages, len(errorMessages[LANGUAGE_JA]), "language %s", language)
+	}
+}
+
+func TestJoinWithAnd(t *testing.T) {
+	assert.Equal(t, "a digit", joinWithAnd(LANGUAGE_EN, []string{"a digit"}))
+	assert.Equal(t, "a, b and c", joinWithAnd(LANGUAGE_EN, []string{"a", "b", "c"}))
+	assert.Equal(t, "a, b và c", joinWithAnd(LANGUAGE_VI, []string{"a", "b", "c"}))
+	assert.Equal(t, "大文字、小文字と数字", joinWithAnd(LANGUAGE_JA, []string{"大文字", "小文字", "数字"}))
+}
@@ -0,0 +1,110 @@
+package utils_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{header: "", expected: utils.LANGUAGE_EN},
+		{header: "vi", expected: utils.LANGUAGE_VI},
+		{header: "vi-VN,vi;q=0.9,en;q=0.8", expected: utils.LANGUAGE_VI},
+		{header: "VI-vn", expected: utils.LANGUAGE_VI},
+		{header: "en;q=0.5, vi;q=0.8", expected: utils.LANGUAGE_VI},
+		{header: "fr-FR, vi;q=0.2", expected: utils.LANGUAGE_VI},
+		{header: "vi;q=0, en;q=0.1", expected: utils.LANGUAGE_EN},
+		{header: "vi;q=abc", expected: utils.LANGUAGE_EN},
+		{header: "fr-FR,de;q=0.9,*;q=0.5", expected: utils.LANGUAGE_EN},
+		{header: "ja-JP,ja;q=0.9,en-US;q=0.8", expected: utils.LANGUAGE_JA},
+		{header: "en;q=0.5, ja;q=0.7, vi;q=0.6", expected: utils.LANGUAGE_JA},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			assert.Equal(t, tt.expected, utils.ParseAcceptLanguage(tt.header))
+		})
+	}
+}
+
+func TestParseLanguage(t *testing.T) {
+	assert.Equal(t, utils.LANGUAGE_JA, utils.ParseLanguage("ja", "vi"))
+	assert.Equal(t, utils.LANGUAGE_VI, utils.ParseLanguage(" VI-vn ", ""))
+	assert.Equal(t, utils.LANGUAGE_VI, utils.ParseLanguage("fr", "vi-VN"))
+	assert.Equal(t, utils.LANGUAGE_JA, utils.ParseLanguage("", "ja"))
+	assert.Equal(t, utils.LANGUAGE_EN, utils.ParseLanguage("xx", "zz"))
+}
+
+func TestTranslateValidationErrorsIn(t *testing.T) {
+	validate := validator.New()
+	_ = validate.RegisterValidation("strong_password", utils.ValidateStrongPassword)
+	_ = validate.RegisterValidation("valid_gender", utils.ValidateGender)
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string { return field.Tag.Get("json") })
+
+	type Input struct {
+		Email    string `json:"email" validate:"required"`
+		Password string `json:"password" validate:"strong_password"`
+		Gender   int16  `json:"gender" validate:"valid_gender"`
+		Name     string `json:"name" validate:"alpha"`
+	}
+	input := Input{Password: "password", Gender: 4, Name: "123"}
+	err := validate.Struct(input)
+	require.Error(t, err)
+
+	t.Run("Vietnamese", func(t *testing.T) {
+		t.Setenv("PASSWORD_POLICY", utils.PASSWORD_POLICY_BASIC)
+
+		result := utils.TranslateValidationErrorsIn(utils.LANGUAGE_VI, err, input)
+
+		assert.Equal(t, apperror.ErrValidationFailed, result.Code)
+		assert.Equal(t, "Dữ liệu không hợp lệ", result.Message)
+		assert.Equal(t, []apperror.FieldError{
+			{Field: "email", Message: "email là bắt buộc"},
+			{Field: "password", Message: "password phải chứa một chữ hoa và một chữ số"},
+			{Field: "gender", Message: "gender phải là nam, nữ hoặc khác"},
+			{Field: "name", Message: "name chỉ được chứa chữ cái"},
+		}, result.Fields)
+	})
+
+	t.Run("Japanese", func(t *testing.T) {
+		t.Setenv("PASSWORD_POLICY", utils.PASSWORD_POLICY_BASIC)
+
+		result := utils.TranslateValidationErrorsIn(utils.LANGUAGE_JA, err, input)
+
+		assert.Equal(t, apperror.ErrValidationFailed, result.Code)
+		assert.Equal(t, "入力内容に誤りがあります", result.Message)
+		assert.Equal(t, []apperror.FieldError{
+			{Field: "email", Message: "emailは必須です"},
+			{Field: "password", Message: "passwordには大文字と数字を含めてください"},
+			{Field: "gender", Message: "genderは男性、女性、その他のいずれかでなければなりません"},
+			{Field: "name", Message: "nameには英字のみ使用できます"},
+		}, result.Fields)
+	})
+
+	t.Run("UnsupportedLanguageFallsBackToEnglish", func(t *testing.T) {
+		t.Setenv("PASSWORD_POLICY", utils.PASSWORD_POLICY_BASIC)
+
+		assert.Equal(t, utils.TranslateValidationErrors(err, input), utils.TranslateValidationErrorsIn("fr", err, input))
+		assert.Equal(t, "password must contain an uppercase letter and a digit", utils.TranslateValidationErrorsIn("fr", err, input).Fields[1].Message)
+	})
+
+	t.Run("JSONTypeError", func(t *testing.T) {
+		var target Input
+		err := json.Unmarshal([]byte(`{"gender": "male"}`), &target)
+		require.Error(t, err)
+
+		result := utils.TranslateValidationErrorsIn(utils.LANGUAGE_VI, err, &target)
+
+		assert.Equal(t, []apperror.FieldError{{Field: "gender", Message: "gender phải là một số"}}, result.Fields)
+	})
+}
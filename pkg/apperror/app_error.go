@@ -4,10 +4,11 @@ import "fmt"
 
 // AppError represents a custom error with a code and message.
 type AppError struct {
-	HttpStatusCode int    `json:"-"`       // HTTP status code (optional)
-	Code           int    `json:"code"`    // Error code
-	Message        string `json:"message"` // Error message
-	Err            error  `json:"-"`       // Underlying error (optional)
+	HttpStatusCode int          `json:"-"`                // HTTP status code (optional)
+	Code           int          `json:"code"`             // Error code
+	Message        string       `json:"message"`          // Error message
+	Fields         []FieldError `json:"fields,omitempty"` // Fields the error is about (optional)
+	Err            error        `json:"-"`                // Underlying error (optional)
 }
 
 // Error implements the error interface.
@@ -33,6 +34,12 @@ func Wrap(httpStatusCode, code int, message string, err error) *AppError {
 	}
 }
 
+// WithFields adds the fields the error is about, e.g. the field holding a duplicate value, and returns the error.
+func (e *AppError) WithFields(fields ...FieldError) *AppError {
+	e.Fields = append(e.Fields, fields...)
+	return e
+}
+
 // IsAppError checks if the provided error is of type AppError.
 func IsAppError(err error) bool {
 	_, ok := err.(*AppError)
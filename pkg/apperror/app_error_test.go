@@ -59,6 +59,14 @@ func TestNew(t *testing.T) {
 	assert.Equal(t, "unauthorized", appErr.Message)
 }
 
+func TestWithFields(t *testing.T) {
+	appErr := apperror.NewConflictError("email already exists").
+		WithFields(apperror.FieldError{Field: "email", Message: "email already exists"})
+
+	assert.Equal(t, []apperror.FieldError{{Field: "email", Message: "email already exists"}}, appErr.Fields)
+	assert.Empty(t, apperror.NewConflictError("conflict").Fields)
+}
+
 func TestIsAppError(t *testing.T) {
 	t.Run("is AppError", func(t *testing.T) {
 		// Arrange
@@ -0,0 +1,71 @@
+package apperror
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// UNKNOWN_IDENTIFIER is the identifier reported for codes that are not in the catalog
+const UNKNOWN_IDENTIFIER = "ERR_UNKNOWN"
+
+// Definition describes an error code: the HTTP status it is returned with and a stable identifier,
+// such as ERR_TOKEN_EXPIRED, that clients can match on instead of the number
+type Definition struct {
+	Code        int    `json:"code"`
+	Identifier  string `json:"error"`
+	HttpStatus  int    `json:"http_status"`
+	Description string `json:"description"`
+}
+
+// catalog holds every defined error code, keyed by code
+var catalog = map[int]Definition{}
+
+// define adds an error code to the catalog. It panics if the code or the identifier is already defined,
+// so collisions are caught when the package is initialized
+func define(code, httpStatus int, identifier, description string) Definition {
+	if existing, ok := catalog[code]; ok {
+		panic(fmt.Sprintf("apperror: code %d of %s is already defined by %s", code, identifier, existing.Identifier))
+	}
+	for _, existing := range catalog {
+		if existing.Identifier == identifier {
+			panic(fmt.Sprintf("apperror: identifier %s of code %d is already defined by code %d", identifier, code, existing.Code))
+		}
+	}
+
+	definition := Definition{Code: code, Identifier: identifier, HttpStatus: httpStatus, Description: description}
+	catalog[code] = definition
+	return definition
+}
+
+// New returns an error with the code and HTTP status of the definition
+func (d Definition) New(message string) *AppError {
+	return &AppError{
+		HttpStatusCode: d.HttpStatus,
+		Code:           d.Code,
+		Message:        message,
+	}
+}
+
+// Catalog returns every defined error code, ordered by code
+func Catalog() []Definition {
+	definitions := make([]Definition, 0, len(catalog))
+	for _, code := range slices.Sorted(maps.Keys(catalog)) {
+		definitions = append(definitions, catalog[code])
+	}
+	return definitions
+}
+
+// Lookup returns the definition of the code, if it is in the catalog
+func Lookup(code int) (Definition, bool) {
+	definition, ok := catalog[code]
+	return definition, ok
+}
+
+// IdentifierOf returns the identifier of the code, or UNKNOWN_IDENTIFIER if it is not in the catalog
+func IdentifierOf(code int) string {
+	if definition, ok := catalog[code]; ok {
+		return definition.Identifier
+	}
+	return UNKNOWN_IDENTIFIER
+}
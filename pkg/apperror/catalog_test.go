@@ -0,0 +1,47 @@
+package apperror
+
+import (
+	"testing"
+)
+
+func TestDefinePanicsOnCollision(t *testing.T) {
+	tests := []struct {
+		name       string
+		code       int
+		identifier string
+	}{
+		{"DuplicateCode", ErrNotFound, "ERR_SOMETHING_ELSE"},
+		{"DuplicateIdentifier", 9999, IdentifierOf(ErrNotFound)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected define to panic")
+				}
+			}()
+			define(tt.code, 400, tt.identifier, "collision")
+		})
+	}
+
+	if _, ok := Lookup(9999); ok {
+		t.Errorf("expected a colliding definition not to be added to the catalog")
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	definitions := Catalog()
+	for i := 1; i < len(definitions); i++ {
+		if definitions[i-1].Code >= definitions[i].Code {
+			t.Errorf("expected catalog to be ordered by code, got %d before %d", definitions[i-1].Code, definitions[i].Code)
+		}
+	}
+
+	if got := IdentifierOf(ErrTokenExpired); got != "ERR_TOKEN_EXPIRED" {
+		t.Errorf("expected ERR_TOKEN_EXPIRED, got %s", got)
+	}
+	if got := IdentifierOf(9999); got != UNKNOWN_IDENTIFIER {
+		t.Errorf("expected %s for an unknown code, got %s", UNKNOWN_IDENTIFIER, got)
+	}
+}
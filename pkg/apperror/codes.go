@@ -2,12 +2,16 @@ package apperror
 
 const (
 	// General errors
-	ErrInternalServer = 1000 // Internal server error
-	ErrNotFound       = 1001 // Resource not found
-	ErrBadRequest     = 1002 // Invalid or bad request
-	ErrUnauthorized   = 1003 // Unauthorized access
-	ErrForbidden      = 1004 // Forbidden access
-	ErrConflict       = 1005 // Conflict error
+	ErrInternalServer   = 1000 // Internal server error
+	ErrNotFound         = 1001 // Resource not found
+	ErrBadRequest       = 1002 // Invalid or bad request
+	ErrUnauthorized     = 1003 // Unauthorized access
+	ErrForbidden        = 1004 // Forbidden access
+	ErrConflict         = 1005 // Conflict error
+	ErrUnavailable      = 1006 // Service unavailable, e.g. during maintenance
+	ErrPayloadTooLarge  = 1007 // Request body too large
+	ErrRequestTimeout   = 1008 // Request took longer than its deadline
+	ErrUnsupportedMedia = 1009 // Uploaded file is not of an accepted type
 
 	// Database errors
 	ErrDBConnection = 2000 // Failed to connect to DB
@@ -17,16 +21,23 @@ const (
 	ErrDBDelete     = 2004 // DB delete error
 
 	// Authentication errors
-	ErrTokenExpired       = 3001 // Token has expired
-	ErrInvalidPassword    = 3002 // Invalid password
-	ErrPasswordHashFailed = 3003 // Failed to hash password
-	ErrPasswordMismatch   = 3004 // Password mismatch
-	ErrPasswordUnchanged  = 3005 // Old and new password are the same
+	ErrTokenExpired           = 3001 // Token has expired
+	ErrInvalidPassword        = 3002 // Invalid password
+	ErrPasswordHashFailed     = 3003 // Failed to hash password
+	ErrPasswordMismatch       = 3004 // Password mismatch
+	ErrPasswordUnchanged      = 3005 // Old and new password are the same
+	ErrEmailNotVerified       = 3006 // Email address has not been verified
+	ErrTooManyAttempts        = 3007 // Too many failed attempts, temporarily locked
+	ErrPasswordChangeRequired = 3008 // Password must be changed before continuing
+	ErrDuplicateEmail         = 3009 // Email address is already registered
+	ErrDeviceMismatch         = 3010 // Refresh token was issued to another device
+	ErrAccountPendingDeletion = 3011 // Account is scheduled for deletion
 
 	// Common
 	ErrParseError       = 4000 // Parsing or field error
 	ErrValidationFailed = 4001 // Validation failed
 	ErrEmptyData        = 4007 // No data provided
+	ErrTooManyRequests  = 4008 // Request rate limit exceeded
 
 	// Cache errors
 	ErrCacheSet    = 4002 // Set cache error
@@ -2,182 +2,200 @@ package apperror
 
 import "net/http"
 
+// Every error code is defined here, which adds it to the catalog served to clients.
+// Codes without a constructor of their own are defined too, so the catalog is complete.
+
 // === Generic errors ===
+var (
+	internalServerError   = define(ErrInternalServer, http.StatusInternalServerError, "ERR_INTERNAL_SERVER", "Internal server error")
+	notFoundError         = define(ErrNotFound, http.StatusNotFound, "ERR_NOT_FOUND", "Resource not found")
+	badRequestError       = define(ErrBadRequest, http.StatusBadRequest, "ERR_BAD_REQUEST", "Invalid or bad request")
+	unauthorizedError     = define(ErrUnauthorized, http.StatusUnauthorized, "ERR_UNAUTHORIZED", "Unauthorized access")
+	forbiddenError        = define(ErrForbidden, http.StatusForbidden, "ERR_FORBIDDEN", "Forbidden access")
+	conflictError         = define(ErrConflict, http.StatusConflict, "ERR_CONFLICT", "Conflict error")
+	unavailableError      = define(ErrUnavailable, http.StatusServiceUnavailable, "ERR_UNAVAILABLE", "Service unavailable, e.g. during maintenance")
+	payloadTooLargeError  = define(ErrPayloadTooLarge, http.StatusRequestEntityTooLarge, "ERR_PAYLOAD_TOO_LARGE", "Request body too large")
+	requestTimeoutError   = define(ErrRequestTimeout, http.StatusGatewayTimeout, "ERR_REQUEST_TIMEOUT", "Request took longer than its deadline")
+	unsupportedMediaError = define(ErrUnsupportedMedia, http.StatusUnsupportedMediaType, "ERR_UNSUPPORTED_MEDIA", "Uploaded file is not of an accepted type")
+)
+
 func NewInternalServerError(message string) *AppError {
-	return &AppError{
-		HttpStatusCode: http.StatusInternalServerError,
-		Code:           ErrInternalServer,
-		Message:        message,
-	}
+	return internalServerError.New(message)
 }
 
 func NewNotFoundError(message string) *AppError {
-	return &AppError{
-		HttpStatusCode: http.StatusNotFound,
-		Code:           ErrNotFound,
-		Message:        message,
-	}
+	return notFoundError.New(message)
 }
 
 func NewBadRequestError(message string) *AppError {
-	return &AppError{
-		HttpStatusCode: http.StatusBadRequest,
-		Code:           ErrBadRequest,
-		Message:        message,
-	}
+	return badRequestError.New(message)
 }
 
 func NewUnauthorizedError(message string) *AppError {
-	return &AppError{
-		HttpStatusCode: http.StatusUnauthorized,
-		Code:           ErrUnauthorized,
-		Message:        message,
-	}
+	return unauthorizedError.New(message)
 }
 
 func NewForbiddenError(message string) *AppError {
-	return &AppError{
-		HttpStatusCode: http.StatusForbidden,
-		Code:           ErrForbidden,
-		Message:        message,
-	}
+	return forbiddenError.New(message)
 }
 
 func NewConflictError(message string) *AppError {
-	return &AppError{
-		HttpStatusCode: http.StatusConflict,
-		Code:           ErrConflict,
-		Message:        message,
-	}
+	return conflictError.New(message)
+}
+
+func NewUnavailableError(message string) *AppError {
+	return unavailableError.New(message)
+}
+
+func NewPayloadTooLargeError(message string) *AppError {
+	return payloadTooLargeError.New(message)
+}
+
+func NewRequestTimeoutError(message string) *AppError {
+	return requestTimeoutError.New(message)
+}
+
+func NewUnsupportedMediaError(message string) *AppError {
+	return unsupportedMediaError.New(message)
 }
 
 // === Database errors ===
+var (
+	dbConnectionError = define(ErrDBConnection, http.StatusInternalServerError, "ERR_DB_CONNECTION", "Failed to connect to DB")
+	dbQueryError      = define(ErrDBQuery, http.StatusInternalServerError, "ERR_DB_QUERY", "DB query error")
+	dbInsertError     = define(ErrDBInsert, http.StatusInternalServerError, "ERR_DB_INSERT", "DB insert error")
+	dbUpdateError     = define(ErrDBUpdate, http.StatusInternalServerError, "ERR_DB_UPDATE", "DB update error")
+	dbDeleteError     = define(ErrDBDelete, http.StatusInternalServerError, "ERR_DB_DELETE", "DB delete error")
+)
+
 func NewDBConnectionError(message string) *AppError {
-	return &AppError{
-		HttpStatusCode: http.StatusInternalServerError,
-		Code:           ErrDBConnection,
-		Message:        message,
-	}
+	return dbConnectionError.New(message)
 }
 
 func NewDBQueryError(message string) *AppError {
-	return &AppError{
-		HttpStatusCode: http.StatusInternalServerError,
-		Code:           ErrDBQuery,
-		Message:        message,
-	}
+	return dbQueryError.New(message)
 }
 
 func NewDBInsertError(message string) *AppError {
-	return &AppError{
-		HttpStatusCode: http.StatusInternalServerError,
-		Code:           ErrDBInsert,
-		Message:        message,
-	}
+	return dbInsertError.New(message)
 }
 
 func NewDBUpdateError(message string) *AppError {
-	return &AppError{
-		HttpStatusCode: http.StatusInternalServerError,
-		Code:           ErrDBUpdate,
-		Message:        message,
-	}
+	return dbUpdateError.New(message)
 }
 
 func NewDBDeleteError(message string) *AppError {
-	return &AppError{
-		HttpStatusCode: http.StatusInternalServerError,
-		Code:           ErrDBDelete,
-		Message:        message,
-	}
+	return dbDeleteError.New(message)
 }
 
 // === Cache errors ===
+var (
+	cacheSetError    = define(ErrCacheSet, http.StatusInternalServerError, "ERR_CACHE_SET", "Set cache error")
+	cacheGetError    = define(ErrCacheGet, http.StatusInternalServerError, "ERR_CACHE_GET", "Get cache error")
+	cacheDeleteError = define(ErrCacheDelete, http.StatusInternalServerError, "ERR_CACHE_DELETE", "Delete cache error")
+	cacheListError   = define(ErrCacheList, http.StatusInternalServerError, "ERR_CACHE_LIST", "List cache error")
+	cacheExistsError = define(ErrCacheExists, http.StatusInternalServerError, "ERR_CACHE_EXISTS", "Cache key exists check error")
+)
+
 func NewCacheSetError(message string) *AppError {
-	return &AppError{
-		HttpStatusCode: http.StatusInternalServerError,
-		Code:           ErrCacheSet,
-		Message:        message,
-	}
+	return cacheSetError.New(message)
 }
+
 func NewCacheGetError(message string) *AppError {
-	return &AppError{
-		HttpStatusCode: http.StatusInternalServerError,
-		Code:           ErrCacheGet,
-		Message:        message,
-	}
+	return cacheGetError.New(message)
 }
+
 func NewCacheDeleteError(message string) *AppError {
-	return &AppError{
-		HttpStatusCode: http.StatusInternalServerError,
-		Code:           ErrCacheDelete,
-		Message:        message,
-	}
+	return cacheDeleteError.New(message)
 }
+
 func NewCacheListError(message string) *AppError {
-	return &AppError{
-		HttpStatusCode: http.StatusInternalServerError,
-		Code:           ErrCacheList,
-		Message:        message,
-	}
+	return cacheListError.New(message)
 }
+
 func NewCacheExistsError(message string) *AppError {
-	return &AppError{
-		HttpStatusCode: http.StatusInternalServerError,
-		Code:           ErrCacheExists,
-		Message:        message,
-	}
+	return cacheExistsError.New(message)
 }
 
 // === Authentication errors ===
+var (
+	tokenExpiredError           = define(ErrTokenExpired, http.StatusBadRequest, "ERR_TOKEN_EXPIRED", "Token has expired")
+	invalidPasswordError        = define(ErrInvalidPassword, http.StatusBadRequest, "ERR_INVALID_PASSWORD", "Invalid password")
+	passwordHashFailedError     = define(ErrPasswordHashFailed, http.StatusInternalServerError, "ERR_PASSWORD_HASH_FAILED", "Failed to hash password")
+	passwordMismatchError       = define(ErrPasswordMismatch, http.StatusBadRequest, "ERR_PASSWORD_MISMATCH", "Password mismatch")
+	passwordUnchangedError      = define(ErrPasswordUnchanged, http.StatusBadRequest, "ERR_PASSWORD_UNCHANGED", "Old and new password are the same")
+	emailNotVerifiedError       = define(ErrEmailNotVerified, http.StatusForbidden, "ERR_EMAIL_NOT_VERIFIED", "Email address has not been verified")
+	tooManyAttemptsError        = define(ErrTooManyAttempts, http.StatusTooManyRequests, "ERR_TOO_MANY_ATTEMPTS", "Too many failed attempts, temporarily locked")
+	passwordChangeRequiredError = define(ErrPasswordChangeRequired, http.StatusForbidden, "ERR_PASSWORD_CHANGE_REQUIRED", "Password must be changed before continuing")
+	duplicateEmailError         = define(ErrDuplicateEmail, http.StatusConflict, "ERR_DUPLICATE_EMAIL", "Email address is already registered")
+	deviceMismatchError         = define(ErrDeviceMismatch, http.StatusUnauthorized, "ERR_DEVICE_MISMATCH", "Refresh token was issued to another device")
+	accountPendingDeletionError = define(ErrAccountPendingDeletion, http.StatusForbidden, "ERR_ACCOUNT_PENDING_DELETION", "Account is scheduled for deletion")
+)
+
 func NewTokenExpiredError(message string) *AppError {
-	return &AppError{
-		HttpStatusCode: http.StatusBadRequest,
-		Code:           ErrTokenExpired,
-		Message:        message,
-	}
+	return tokenExpiredError.New(message)
 }
+
 func NewInvalidPasswordError(message string) *AppError {
-	return &AppError{
-		HttpStatusCode: http.StatusBadRequest,
-		Code:           ErrInvalidPassword,
-		Message:        message,
-	}
+	return invalidPasswordError.New(message)
 }
+
 func NewPasswordHashFailedError(message string) *AppError {
-	return &AppError{
-		HttpStatusCode: http.StatusInternalServerError,
-		Code:           ErrPasswordHashFailed,
-		Message:        message,
-	}
+	return passwordHashFailedError.New(message)
 }
+
 func NewPasswordMismatchError(message string) *AppError {
-	return &AppError{
-		HttpStatusCode: http.StatusBadRequest,
-		Code:           ErrPasswordMismatch,
-		Message:        message,
-	}
+	return passwordMismatchError.New(message)
 }
+
 func NewPasswordUnchangedError(message string) *AppError {
-	return &AppError{
-		HttpStatusCode: http.StatusBadRequest,
-		Code:           ErrPasswordUnchanged,
-		Message:        message,
-	}
+	return passwordUnchangedError.New(message)
+}
+
+func NewEmailNotVerifiedError(message string) *AppError {
+	return emailNotVerifiedError.New(message)
+}
+
+func NewTooManyAttemptsError(message string) *AppError {
+	return tooManyAttemptsError.New(message)
+}
+
+func NewPasswordChangeRequiredError(message string) *AppError {
+	return passwordChangeRequiredError.New(message)
+}
+
+func NewDuplicateEmailError(message string) *AppError {
+	return duplicateEmailError.New(message)
+}
+
+func NewDeviceMismatchError(message string) *AppError {
+	return deviceMismatchError.New(message)
+}
+
+func NewAccountPendingDeletionError(message string) *AppError {
+	return accountPendingDeletionError.New(message)
 }
 
 // === Common errors ===
+var (
+	parseError           = define(ErrParseError, http.StatusBadRequest, "ERR_PARSE", "Parsing or field error")
+	validationDataError  = define(ErrValidationFailed, http.StatusBadRequest, "ERR_VALIDATION_FAILED", "Validation failed")
+	tooManyRequestsError = define(ErrTooManyRequests, http.StatusTooManyRequests, "ERR_TOO_MANY_REQUESTS", "Request rate limit exceeded")
+	emptyDataError       = define(ErrEmptyData, http.StatusBadRequest, "ERR_EMPTY_DATA", "No data provided")
+)
+
 func NewParseError(message string) *AppError {
-	return &AppError{
-		HttpStatusCode: http.StatusBadRequest,
-		Code:           ErrParseError,
-		Message:        message,
-	}
+	return parseError.New(message)
 }
+
 func NewValidationDataError(message string) *AppError {
-	return &AppError{
-		HttpStatusCode: http.StatusBadRequest,
-		Code:           ErrValidationFailed,
-		Message:        message,
-	}
+	return validationDataError.New(message)
+}
+
+func NewTooManyRequestsError(message string) *AppError {
+	return tooManyRequestsError.New(message)
+}
+
+func NewEmptyDataError(message string) *AppError {
+	return emptyDataError.New(message)
 }
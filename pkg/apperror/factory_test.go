@@ -19,6 +19,10 @@ func TestErrorConstructors(t *testing.T) {
 		{"UnauthorizedError", NewUnauthorizedError, ErrUnauthorized, http.StatusUnauthorized},
 		{"ForbiddenError", NewForbiddenError, ErrForbidden, http.StatusForbidden},
 		{"ConflictError", NewConflictError, ErrConflict, http.StatusConflict},
+		{"UnavailableError", NewUnavailableError, ErrUnavailable, http.StatusServiceUnavailable},
+		{"PayloadTooLargeError", NewPayloadTooLargeError, ErrPayloadTooLarge, http.StatusRequestEntityTooLarge},
+		{"RequestTimeoutError", NewRequestTimeoutError, ErrRequestTimeout, http.StatusGatewayTimeout},
+		{"UnsupportedMediaError", NewUnsupportedMediaError, ErrUnsupportedMedia, http.StatusUnsupportedMediaType},
 
 		// Database errors
 		{"DBConnectionError", NewDBConnectionError, ErrDBConnection, http.StatusInternalServerError},
@@ -40,10 +44,18 @@ func TestErrorConstructors(t *testing.T) {
 		{"PasswordHashFailedError", NewPasswordHashFailedError, ErrPasswordHashFailed, http.StatusInternalServerError},
 		{"PasswordMismatchError", NewPasswordMismatchError, ErrPasswordMismatch, http.StatusBadRequest},
 		{"PasswordUnchangedError", NewPasswordUnchangedError, ErrPasswordUnchanged, http.StatusBadRequest},
+		{"EmailNotVerifiedError", NewEmailNotVerifiedError, ErrEmailNotVerified, http.StatusForbidden},
+		{"TooManyAttemptsError", NewTooManyAttemptsError, ErrTooManyAttempts, http.StatusTooManyRequests},
+		{"PasswordChangeRequiredError", NewPasswordChangeRequiredError, ErrPasswordChangeRequired, http.StatusForbidden},
+		{"DuplicateEmailError", NewDuplicateEmailError, ErrDuplicateEmail, http.StatusConflict},
+		{"DeviceMismatchError", NewDeviceMismatchError, ErrDeviceMismatch, http.StatusUnauthorized},
+		{"AccountPendingDeletionError", NewAccountPendingDeletionError, ErrAccountPendingDeletion, http.StatusForbidden},
 
 		// Common errors
 		{"ParseError", NewParseError, ErrParseError, http.StatusBadRequest},
 		{"ValidationDataError", NewValidationDataError, ErrValidationFailed, http.StatusBadRequest},
+		{"EmptyDataError", NewEmptyDataError, ErrEmptyData, http.StatusBadRequest},
+		{"TooManyRequestsError", NewTooManyRequestsError, ErrTooManyRequests, http.StatusTooManyRequests},
 	}
 
 	for _, tt := range tests {
@@ -60,6 +72,17 @@ func TestErrorConstructors(t *testing.T) {
 			if err.Message != msg {
 				t.Errorf("expected Message %s, got %s", msg, err.Message)
 			}
+
+			definition, ok := Lookup(tt.wantCode)
+			if !ok {
+				t.Fatalf("expected code %d to be in the catalog", tt.wantCode)
+			}
+			if definition.HttpStatus != tt.wantHTTP {
+				t.Errorf("expected catalog HttpStatus %d, got %d", tt.wantHTTP, definition.HttpStatus)
+			}
 		})
 	}
+	if got := len(Catalog()); got != len(tests) {
+		t.Errorf("expected %d codes in the catalog, got %d", len(tests), got)
+	}
 }
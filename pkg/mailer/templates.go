@@ -0,0 +1,67 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+	"time"
+)
+
+// Names of the email templates. Each is a pair of files in templates/: <name>.html and its plain-text alternative <name>.txt
+const (
+	TEMPLATE_FORGOT_PASSWORD  = "forgot_password"
+	TEMPLATE_VERIFY_EMAIL     = "verify_email"
+	TEMPLATE_PASSWORD_CHANGED = "password_changed"
+	TEMPLATE_NEW_LOGIN        = "new_login"
+	// TEMPLATE_CONFIRM_EMAIL_CHANGE is sent to the new address, TEMPLATE_EMAIL_CHANGE_REQUESTED to the current one
+	TEMPLATE_CONFIRM_EMAIL_CHANGE   = "confirm_email_change"
+	TEMPLATE_EMAIL_CHANGE_REQUESTED = "email_change_requested"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// templateFuncs are the formatting functions available in every template
+var templateFuncs = map[string]any{
+	// formatDate writes a time as e.g. "March 5, 2025"
+	"formatDate": func(t time.Time) string {
+		return t.UTC().Format("January 2, 2006")
+	},
+	// formatDateTime writes a time as e.g. "March 5, 2025 14:30 UTC"
+	"formatDateTime": func(t time.Time) string {
+		return t.UTC().Format("January 2, 2006 15:04 MST")
+	},
+	// year is the current year, for copyright lines
+	"year": func() int {
+		return time.Now().Year()
+	},
+}
+
+// TemplateRenderer renders the email templates embedded in the binary, so they are found whatever the working directory.
+// A template referring to a field or key missing from its data fails to render rather than writing "<no value>"
+type TemplateRenderer struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// NewTemplateRenderer parses the embedded templates. It panics if they do not parse, which the tests of this package rule out
+func NewTemplateRenderer() *TemplateRenderer {
+	return &TemplateRenderer{
+		html: htmltemplate.Must(htmltemplate.New("").Funcs(templateFuncs).Option("missingkey=error").ParseFS(templateFS, "templates/*.html")),
+		text: texttemplate.Must(texttemplate.New("").Funcs(templateFuncs).Option("missingkey=error").ParseFS(templateFS, "templates/*.txt")),
+	}
+}
+
+// Render returns the HTML body and the plain-text body of the template name, one of the TEMPLATE_ names, rendered with data
+func (r *TemplateRenderer) Render(name string, data any) (html string, text string, err error) {
+	var htmlBody, textBody bytes.Buffer
+	if err := r.html.ExecuteTemplate(&htmlBody, name+".html", data); err != nil {
+		return "", "", fmt.Errorf("render %s.html: %w", name, err)
+	}
+	if err := r.text.ExecuteTemplate(&textBody, name+".txt", data); err != nil {
+		return "", "", fmt.Errorf("render %s.txt: %w", name, err)
+	}
+	return htmlBody.String(), textBody.String(), nil
+}
@@ -0,0 +1,66 @@
+package mailer_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/pkg/mailer"
+)
+
+func TestTemplateRenderer_Render(t *testing.T) {
+	renderer := mailer.NewTemplateRenderer()
+	at := time.Date(2025, time.March, 5, 14, 30, 0, 0, time.UTC)
+	data := map[string]any{
+		"Name":      "Jane <Doe>",
+		"Email":     "jane@example.com",
+		"URL":       "https://example.com/link?token=abc",
+		"ExpiresAt": at,
+		"ChangedAt": at,
+		"LoginAt":   at,
+		"IPAddress": "203.0.113.7",
+		"UserAgent": "Mozilla/5.0",
+		"NewEmail":  "jane.new@example.com",
+	}
+
+	for _, name := range []string{mailer.TEMPLATE_FORGOT_PASSWORD, mailer.TEMPLATE_VERIFY_EMAIL, mailer.TEMPLATE_PASSWORD_CHANGED, mailer.TEMPLATE_NEW_LOGIN, mailer.TEMPLATE_CONFIRM_EMAIL_CHANGE, mailer.TEMPLATE_EMAIL_CHANGE_REQUESTED} {
+		t.Run(name, func(t *testing.T) {
+			html, text, err := renderer.Render(name, data)
+
+			require.NoError(t, err)
+			assert.Contains(t, html, "<!DOCTYPE html>")
+			assert.Contains(t, html, "Jane &lt;Doe&gt;")
+			assert.Contains(t, html, "March 5, 2025 14:30 UTC")
+			assert.Contains(t, html, strconv.Itoa(time.Now().Year()))
+			// The plain-text alternative is not HTML escaped
+			assert.NotContains(t, text, "<p>")
+			assert.Contains(t, text, "Jane <Doe>")
+			assert.Contains(t, text, "https://example.com/link?token=abc")
+			assert.Contains(t, text, "March 5, 2025 14:30 UTC")
+		})
+	}
+
+	t.Run("Expiry Omitted When Zero", func(t *testing.T) {
+		html, text, err := renderer.Render(mailer.TEMPLATE_FORGOT_PASSWORD, map[string]any{
+			"Name": "Jane", "URL": "https://example.com", "ExpiresAt": time.Time{},
+		})
+
+		require.NoError(t, err)
+		assert.NotContains(t, html, "expires")
+		assert.NotContains(t, text, "expires")
+	})
+
+	t.Run("Missing Key", func(t *testing.T) {
+		_, _, err := renderer.Render(mailer.TEMPLATE_PASSWORD_CHANGED, map[string]any{"Name": "Jane"})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("Unknown Template", func(t *testing.T) {
+		_, _, err := renderer.Render("unknown", data)
+
+		assert.Error(t, err)
+	})
+}
@@ -3,12 +3,22 @@ package migrator
 import (
 	"database/sql"
 	"fmt"
+	"net"
+	"net/url"
 
 	_ "github.com/go-sql-driver/mysql" // MySQL database/sql driver
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database"
 	"github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/golang-migrate/migrate/v4/database/pgx/v5"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	_ "github.com/jackc/pgx/v5/stdlib" // Postgres database/sql driver
+)
+
+// Database drivers supported by NewMigrator
+const (
+	DRIVER_MYSQL    = "mysql"
+	DRIVER_POSTGRES = "postgres"
 )
 
 // MigrateIface makes Migrator testable without a real DB.
@@ -16,6 +26,7 @@ type MigrateIface interface {
 	Up() error
 	Down() error
 	Steps(int) error
+	Force(int) error
 	Version() (uint, bool, error)
 	Close() (error, error)
 }
@@ -32,6 +43,15 @@ type MySQLConfig struct {
 	DBName   string
 }
 
+type PostgresConfig struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	DBName   string
+	SSLMode  string // disable, require, verify-ca or verify-full; disable if empty
+}
+
 var (
 	openSQLConnection = func(driverName, dsn string) (*sql.DB, error) {
 		return sql.Open(driverName, dsn)
@@ -41,29 +61,50 @@ var (
 			MigrationsTable: "schema_migrations",
 		})
 	}
-	createMigrateInstance = func(sourceURL string, driver database.Driver) (MigrateIface, error) {
-		return migrate.NewWithDatabaseInstance(sourceURL, "mysql", driver)
+	buildPostgresDriver = func(db *sql.DB) (database.Driver, error) {
+		return pgx.WithInstance(db, &pgx.Config{
+			MigrationsTable: "schema_migrations",
+		})
+	}
+	createMigrateInstance = func(sourceURL, databaseName string, driver database.Driver) (MigrateIface, error) {
+		return migrate.NewWithDatabaseInstance(sourceURL, databaseName, driver)
 	}
 )
 
 // NewMigrator creates a new database migrator instance.
-// It takes a migrations path and a MySQL DSN string as input.
-func NewMigrator(migrationsPath, dsn string) (*Migrator, error) {
+// It takes a migrations path, the driver of the database, DRIVER_MYSQL if empty, and its DSN as input.
+// The migrations must be written for that database.
+func NewMigrator(migrationsPath, driverName, dsn string) (*Migrator, error) {
+	if driverName == "" {
+		driverName = DRIVER_MYSQL
+	}
+
+	var label, sqlDriverName string
+	var buildDriver func(*sql.DB) (database.Driver, error)
+	switch driverName {
+	case DRIVER_MYSQL:
+		label, sqlDriverName, buildDriver = "MySQL", "mysql", buildMySQLDriver
+	case DRIVER_POSTGRES:
+		label, sqlDriverName, buildDriver = "Postgres", "pgx", buildPostgresDriver
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", driverName)
+	}
+
 	if dsn == "" {
-		return nil, fmt.Errorf("MySQL DSN must not be empty")
+		return nil, fmt.Errorf("%s DSN must not be empty", label)
 	}
 
-	db, err := openSQLConnection("mysql", dsn)
+	db, err := openSQLConnection(sqlDriverName, dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	driver, err := buildMySQLDriver(db)
+	driver, err := buildDriver(db)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create MySQL driver: %w", err)
+		return nil, fmt.Errorf("failed to create %s driver: %w", label, err)
 	}
 
-	m, err := createMigrateInstance(fmt.Sprintf("file://%s", migrationsPath), driver)
+	m, err := createMigrateInstance(fmt.Sprintf("file://%s", migrationsPath), driverName, driver)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize migrator: %w", err)
 	}
@@ -90,6 +131,22 @@ func NewMySQLDSN(config MySQLConfig) string {
 	)
 }
 
+// NewPostgresDSN creates a Postgres connection URL from individual connection parameters.
+func NewPostgresDSN(config PostgresConfig) string {
+	sslMode := config.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(config.User, config.Password),
+		Host:     net.JoinHostPort(config.Host, config.Port),
+		Path:     "/" + config.DBName,
+		RawQuery: url.Values{"sslmode": {sslMode}, "TimeZone": {"UTC"}}.Encode(),
+	}
+	return dsn.String()
+}
+
 // Up applies all available up migrations.
 func (m *Migrator) Up() error {
 	if err := m.m.Up(); err != nil && err != migrate.ErrNoChange {
@@ -114,6 +171,16 @@ func (m *Migrator) Steps(steps int) error {
 	return nil
 }
 
+// Force sets the migration version and clears the dirty state without running any migration.
+// It is meant to recover from a migration that failed halfway, once the schema has been fixed by hand.
+// A version of -1 means no migration is applied.
+func (m *Migrator) Force(version int) error {
+	if err := m.m.Force(version); err != nil {
+		return fmt.Errorf("force migration failed: %w", err)
+	}
+	return nil
+}
+
 // Version returns the current migration version and dirty state.
 func (m *Migrator) Version() (uint, bool, error) {
 	return m.m.Version()
@@ -0,0 +1,53 @@
+package storage_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/pkg/storage"
+)
+
+func TestLocalStorage(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "uploads")
+	store, err := storage.NewLocalStorage(dir, "http://localhost:3000/uploads/")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("PutAndReplace", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "avatars/1/a.png", []byte("first"), "image/png"))
+		require.NoError(t, store.Put(ctx, "avatars/1/a.png", []byte("second"), "image/png"))
+
+		content, err := os.ReadFile(filepath.Join(dir, "avatars", "1", "a.png"))
+		require.NoError(t, err)
+		assert.Equal(t, "second", string(content))
+		entries, err := os.ReadDir(filepath.Join(dir, "avatars", "1"))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "no temporary file is left behind")
+	})
+
+	t.Run("URL", func(t *testing.T) {
+		assert.Equal(t, "http://localhost:3000/uploads/avatars/1/a.png", store.URL("avatars/1/a.png"))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "avatars/2/b.png", []byte("content"), "image/png"))
+
+		require.NoError(t, store.Delete(ctx, "avatars/2/b.png"))
+
+		_, err := os.Stat(filepath.Join(dir, "avatars", "2", "b.png"))
+		assert.True(t, os.IsNotExist(err))
+		assert.NoError(t, store.Delete(ctx, "avatars/2/b.png"), "deleting a missing file is not an error")
+	})
+
+	t.Run("KeyOutsideDirectory", func(t *testing.T) {
+		assert.Error(t, store.Put(ctx, "../escape.png", []byte("content"), "image/png"))
+		assert.Error(t, store.Delete(ctx, "avatars/../../escape.png"))
+		assert.Error(t, store.Put(ctx, "", []byte("content"), "image/png"))
+		_, err := os.Stat(filepath.Join(filepath.Dir(dir), "escape.png"))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ObjectClient is the part of the S3 API the S3 storage uses. Interface for mocking S3Client
+type ObjectClient interface {
+	PutObject(ctx context.Context, key string, content []byte, contentType string) error
+	DeleteObject(ctx context.Context, key string) error
+}
+
+// S3Storage keeps files in an S3 compatible bucket, which is expected to be readable at PublicURL
+type S3Storage struct {
+	Client    ObjectClient
+	PublicURL string
+}
+
+// NewS3Storage returns an S3Storage storing files with client. Their URLs start with publicURL,
+// e.g. the bucket URL or a CDN in front of it
+func NewS3Storage(client ObjectClient, publicURL string) *S3Storage {
+	return &S3Storage{Client: client, PublicURL: publicURL}
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, content []byte, contentType string) error {
+	return s.Client.PutObject(ctx, key, content, contentType)
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	return s.Client.DeleteObject(ctx, key)
+}
+
+func (s *S3Storage) URL(key string) string {
+	return joinURL(s.PublicURL, key)
+}
+
+type S3Config struct {
+	Endpoint        string // e.g. https://s3.eu-west-1.amazonaws.com or http://localhost:9000 for MinIO
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// UsePathStyle addresses the bucket as Endpoint/Bucket rather than as a subdomain of Endpoint,
+	// which most S3 compatible servers need
+	UsePathStyle bool
+}
+
+// S3Client sends object requests to an S3 compatible API, signed with AWS Signature Version 4
+type S3Client struct {
+	Config     S3Config
+	HTTPClient *http.Client
+	now        func() time.Time
+}
+
+func NewS3Client(config S3Config) *S3Client {
+	return &S3Client{
+		Config:     config,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		now:        time.Now,
+	}
+}
+
+// BucketURL returns the URL of the bucket, under which its objects are addressed
+func (c *S3Client) BucketURL() string {
+	endpoint := strings.TrimRight(c.Config.Endpoint, "/")
+	if c.Config.UsePathStyle {
+		return endpoint + "/" + c.Config.Bucket
+	}
+	if scheme, host, ok := strings.Cut(endpoint, "://"); ok {
+		return scheme + "://" + c.Config.Bucket + "." + host
+	}
+	return c.Config.Bucket + "." + endpoint
+}
+
+func (c *S3Client) PutObject(ctx context.Context, key string, content []byte, contentType string) error {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	return c.do(ctx, http.MethodPut, key, content, header)
+}
+
+// DeleteObject deletes the object. S3 answers 204 whether or not it existed; 404 is accepted for servers that do not
+func (c *S3Client) DeleteObject(ctx context.Context, key string) error {
+	return c.do(ctx, http.MethodDelete, key, nil, http.Header{})
+}
+
+func (c *S3Client) do(ctx context.Context, method, key string, body []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.BucketURL()+"/"+encodeKey(key), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header = header
+	req.ContentLength = int64(len(body))
+	c.sign(req, body, c.now().UTC())
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 == 2 || (method == http.MethodDelete && res.StatusCode == http.StatusNotFound) {
+		return nil
+	}
+	message, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	return fmt.Errorf("s3 %s %s: %s: %s", method, key, res.Status, strings.TrimSpace(string(message)))
+}
+
+// sign adds the x-amz-date, x-amz-content-sha256 and Authorization headers of AWS Signature Version 4.
+// Every header already set on the request is signed along with the host
+func (c *S3Client) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256Hex(body)
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + c.Config.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.Config.SecretAccessKey), date)
+	for _, part := range []string{c.Config.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.Config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// encodeKey percent-encodes every byte of the key but the unreserved characters and slashes, as signing requires
+func encodeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(url.QueryEscape(segment), "+", "%20")
+	}
+	return strings.Join(segments, "/")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,123 @@
+package storage_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/pkg/storage"
+)
+
+type MockObjectClient struct {
+	mock.Mock
+}
+
+func (m *MockObjectClient) PutObject(ctx context.Context, key string, content []byte, contentType string) error {
+	args := m.Called(ctx, key, content, contentType)
+	return args.Error(0)
+}
+
+func (m *MockObjectClient) DeleteObject(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func TestS3Storage(t *testing.T) {
+	t.Run("Put", func(t *testing.T) {
+		client := new(MockObjectClient)
+		store := storage.NewS3Storage(client, "https://cdn.example.com")
+		client.On("PutObject", mock.Anything, "avatars/1/a.png", []byte("content"), "image/png").Return(nil).Once()
+
+		err := store.Put(context.Background(), "avatars/1/a.png", []byte("content"), "image/png")
+
+		assert.NoError(t, err)
+		client.AssertExpectations(t)
+	})
+
+	t.Run("PutError", func(t *testing.T) {
+		client := new(MockObjectClient)
+		store := storage.NewS3Storage(client, "https://cdn.example.com")
+		client.On("PutObject", mock.Anything, "avatars/1/a.png", mock.Anything, mock.Anything).Return(assert.AnError).Once()
+
+		err := store.Put(context.Background(), "avatars/1/a.png", []byte("content"), "image/png")
+
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		client := new(MockObjectClient)
+		store := storage.NewS3Storage(client, "https://cdn.example.com")
+		client.On("DeleteObject", mock.Anything, "avatars/1/a.png").Return(nil).Once()
+
+		assert.NoError(t, store.Delete(context.Background(), "avatars/1/a.png"))
+		client.AssertExpectations(t)
+	})
+
+	t.Run("URL", func(t *testing.T) {
+		store := storage.NewS3Storage(new(MockObjectClient), "https://cdn.example.com/")
+
+		assert.Equal(t, "https://cdn.example.com/avatars/1/a.png", store.URL("avatars/1/a.png"))
+	})
+}
+
+func TestS3Client(t *testing.T) {
+	config := storage.S3Config{Region: "us-east-1", Bucket: "avatars-bucket", AccessKeyID: "key-id", SecretAccessKey: "secret", UsePathStyle: true}
+
+	t.Run("PutObject", func(t *testing.T) {
+		var method, path, contentType, authorization, body string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			method, path, contentType, authorization = r.Method, r.URL.Path, r.Header.Get("Content-Type"), r.Header.Get("Authorization")
+			content, _ := io.ReadAll(r.Body)
+			body = string(content)
+		}))
+		defer server.Close()
+		config.Endpoint = server.URL
+		client := storage.NewS3Client(config)
+
+		err := client.PutObject(context.Background(), "avatars/1/a.png", []byte("content"), "image/png")
+
+		require.NoError(t, err)
+		assert.Equal(t, http.MethodPut, method)
+		assert.Equal(t, "/avatars-bucket/avatars/1/a.png", path)
+		assert.Equal(t, "image/png", contentType)
+		assert.Equal(t, "content", body)
+		assert.True(t, strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=key-id/"), authorization)
+		assert.Contains(t, authorization, "SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date")
+	})
+
+	t.Run("DeleteMissingObject", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+		config.Endpoint = server.URL
+
+		assert.NoError(t, storage.NewS3Client(config).DeleteObject(context.Background(), "avatars/1/a.png"))
+	})
+
+	t.Run("ErrorResponse", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte("<Error><Code>AccessDenied</Code></Error>"))
+		}))
+		defer server.Close()
+		config.Endpoint = server.URL
+
+		err := storage.NewS3Client(config).PutObject(context.Background(), "avatars/1/a.png", []byte("content"), "image/png")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403")
+		assert.Contains(t, err.Error(), "AccessDenied")
+	})
+
+	t.Run("BucketURL", func(t *testing.T) {
+		assert.Equal(t, "http://localhost:9000/avatars-bucket", storage.NewS3Client(storage.S3Config{Endpoint: "http://localhost:9000/", Bucket: "avatars-bucket", UsePathStyle: true}).BucketURL())
+		assert.Equal(t, "https://avatars-bucket.s3.eu-west-1.amazonaws.com", storage.NewS3Client(storage.S3Config{Endpoint: "https://s3.eu-west-1.amazonaws.com", Bucket: "avatars-bucket"}).BucketURL())
+	})
+}
@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"context"
+	"strings"
+)
+
+// Storage keeps uploaded files under keys such as "avatars/1/abc.png" and knows the public URL each is served at
+type Storage interface {
+	// Put stores content under key, replacing the file already stored there if any
+	Put(ctx context.Context, key string, content []byte, contentType string) error
+	// Delete removes the file stored under key. Deleting a file that does not exist is not an error
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL of the file stored under key
+	URL(key string) string
+}
+
+// joinURL appends the key to the base URL, with exactly one slash between them
+func joinURL(baseURL, key string) string {
+	return strings.TrimRight(baseURL, "/") + "/" + strings.TrimLeft(key, "/")
+}
@@ -0,0 +1,86 @@
+package xlsx_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/pkg/xlsx"
+)
+
+type worksheet struct {
+	Rows []struct {
+		Ref   int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readParts(t *testing.T, data []byte) map[string][]byte {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	parts := map[string][]byte{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		parts[file.Name] = content
+	}
+	return parts
+}
+
+func TestStreamWriter(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := xlsx.NewStreamWriter(&buf, "Users & Roles")
+	require.NoError(t, err)
+
+	require.NoError(t, writer.WriteRow([]any{"id", "name", "address"}))
+	require.NoError(t, writer.WriteRow([]any{uint(1), "Tom <admin>", nil}))
+	wide := make([]any, 28)
+	wide[27] = "last"
+	require.NoError(t, writer.WriteRow(wide))
+	require.NoError(t, writer.Close())
+
+	parts := readParts(t, buf.Bytes())
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		assert.Contains(t, parts, name)
+	}
+	assert.Contains(t, string(parts["xl/workbook.xml"]), `name="Users &amp; Roles"`)
+
+	var sheet worksheet
+	require.NoError(t, xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &sheet))
+	require.Len(t, sheet.Rows, 3)
+	assert.Equal(t, 1, sheet.Rows[0].Ref)
+	assert.Equal(t, "name", sheet.Rows[0].Cells[1].Inline)
+	assert.Equal(t, "inlineStr", sheet.Rows[0].Cells[1].Type)
+
+	// Numbers are stored as values, nil cells are left out
+	require.Len(t, sheet.Rows[1].Cells, 2)
+	assert.Equal(t, "A2", sheet.Rows[1].Cells[0].Ref)
+	assert.Equal(t, "", sheet.Rows[1].Cells[0].Type)
+	assert.Equal(t, "1", sheet.Rows[1].Cells[0].Value)
+	assert.Equal(t, "Tom <admin>", sheet.Rows[1].Cells[1].Inline)
+
+	require.Len(t, sheet.Rows[2].Cells, 1)
+	assert.Equal(t, "AB3", sheet.Rows[2].Cells[0].Ref)
+}
+
+func TestStreamWriter_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := xlsx.NewStreamWriter(&buf, "Empty")
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	var sheet worksheet
+	require.NoError(t, xml.Unmarshal(readParts(t, buf.Bytes())["xl/worksheets/sheet1.xml"], &sheet))
+	assert.Empty(t, sheet.Rows)
+}
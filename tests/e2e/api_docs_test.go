@@ -0,0 +1,119 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openAPISpec holds the parts of the OpenAPI document the tests look at
+type openAPISpec struct {
+	OpenAPI    string                                 `json:"openapi"`
+	Paths      map[string]map[string]openAPIOperation `json:"paths"`
+	Components struct {
+		Schemas         map[string]openAPISchema `json:"schemas"`
+		SecuritySchemes map[string]struct {
+			Type   string `json:"type"`
+			Scheme string `json:"scheme"`
+		} `json:"securitySchemes"`
+	} `json:"components"`
+}
+
+type openAPIOperation struct {
+	RequestBody struct {
+		Content map[string]struct {
+			Schema openAPISchema `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+}
+
+type openAPISchema struct {
+	Ref      string   `json:"$ref"`
+	Required []string `json:"required"`
+}
+
+// pathParam matches the gin path parameters, e.g. :id, which OpenAPI writes as {id}
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+func TestAPIDocs(t *testing.T) {
+	router, _ := setupTestRouter()
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/api/v1/openapi.json")
+	require.Equal(t, http.StatusOK, w.Code)
+	var spec openAPISpec
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &spec))
+
+	// requestSchema resolves the JSON request body schema of an operation
+	requestSchema := func(t *testing.T, path, method string) openAPISchema {
+		operation, ok := spec.Paths[path][method]
+		require.True(t, ok, "%s %s is not documented", method, path)
+		schema := operation.RequestBody.Content["application/json"].Schema
+		if name, ok := strings.CutPrefix(schema.Ref, "#/components/schemas/"); ok {
+			schema = spec.Components.Schemas[name]
+		}
+		return schema
+	}
+
+	t.Run("API Docs - Spec Parses", func(t *testing.T) {
+		assert.True(t, strings.HasPrefix(spec.OpenAPI, "3."))
+		assert.Equal(t, "bearer", spec.Components.SecuritySchemes["bearerAuth"].Scheme)
+		assert.Contains(t, spec.Components.Schemas, "ErrorResponse")
+	})
+
+	t.Run("API Docs - Login", func(t *testing.T) {
+		schema := requestSchema(t, "/api/v1/login", "post")
+
+		assert.ElementsMatch(t, []string{"email", "password"}, schema.Required)
+	})
+
+	t.Run("API Docs - Create User", func(t *testing.T) {
+		schema := requestSchema(t, "/api/v1/users", "post")
+
+		assert.ElementsMatch(t, []string{"email", "password", "name", "birthday", "address", "gender"}, schema.Required)
+	})
+
+	t.Run("API Docs - Every Route Is Documented", func(t *testing.T) {
+		for _, route := range router.Routes() {
+			// The docs themselves and the uploaded files are not part of the API
+			if route.Method == http.MethodHead || route.Path == "/api/v1/openapi.json" || strings.HasPrefix(route.Path, "/docs") ||
+				route.Path == "/swagger" || route.Path == "/api-docs" || route.Path == "/metrics" || strings.HasPrefix(route.Path, "/uploads") {
+				continue
+			}
+			path := pathParam.ReplaceAllString(route.Path, "{$1}")
+			assert.Contains(t, spec.Paths[path], strings.ToLower(route.Method), "%s %s is not documented", route.Method, route.Path)
+		}
+	})
+
+	t.Run("API Docs - Swagger UI", func(t *testing.T) {
+		w := get("/docs")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "/api/v1/openapi.json")
+	})
+
+	t.Run("API Docs - Not Served In Production", func(t *testing.T) {
+		t.Setenv("STAGE", "prod")
+		router, _ := setupTestRouter()
+
+		for _, path := range []string{"/api/v1/openapi.json", "/docs"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", path, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusNotFound, w.Code, path)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/audit"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+func TestAuditLogs(t *testing.T) {
+	router, db, auditService := setupTestRouterWithAudit()
+
+	adminRole := models.Role{Name: constants.ROLE_ADMIN}
+	require.NoError(t, db.Create(&adminRole).Error)
+
+	verifiedAt := time.Now()
+	password := "Passw0rd123"
+	admin := models.User{Name: "Admin", Email: "audit_admin@example.com", Password: "password", Gender: 1, Roles: []models.Role{adminRole}}
+	member := models.User{Name: "Member", Email: "audit_member@example.com", Password: utils.HashPassword(password), Gender: 1, VerifiedAt: &verifiedAt}
+	for _, user := range []*models.User{&admin, &member} {
+		require.NoError(t, db.Create(user).Error)
+	}
+
+	jwtService, err := services.NewJWTService()
+	require.NoError(t, err)
+	adminToken, err := jwtService.GenerateAccessToken(admin.ID)
+	require.NoError(t, err)
+	memberToken, err := jwtService.GenerateAccessToken(member.ID)
+	require.NoError(t, err)
+
+	call := func(method, path, token string, payload any) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			require.NoError(t, json.NewEncoder(&body).Encode(payload))
+		}
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, &body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "audit-e2e")
+		req.RemoteAddr = "192.0.2.1:1234"
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	listAuditLogs := func(t *testing.T, query url.Values) dto.Pagination[*models.AuditLog] {
+		w := call("GET", "/api/v1/audit-logs?"+query.Encode(), adminToken.Token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var page dto.Pagination[*models.AuditLog]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return page
+	}
+
+	// Hooked actions
+	require.Equal(t, http.StatusOK, call("POST", "/api/v1/login", "", map[string]string{"email": member.Email, "password": password}).Code)
+	require.Equal(t, http.StatusBadRequest, call("POST", "/api/v1/login", "", map[string]string{"email": member.Email, "password": "WrongPassw0rd"}).Code)
+	require.Equal(t, http.StatusOK, call("PATCH", "/api/v1/profile", memberToken.Token, map[string]string{"name": "Renamed"}).Code)
+
+	// Closing flushes the queued entries; later entries are written synchronously
+	require.NoError(t, auditService.Close())
+
+	t.Run("Audit Logs - Hooked Actions Are Recorded", func(t *testing.T) {
+		page := listAuditLogs(t, url.Values{"sort": {"id:asc"}})
+
+		require.Equal(t, 3, page.TotalItems)
+		actions := []string{page.Data[0].Action, page.Data[1].Action, page.Data[2].Action}
+		assert.Equal(t, []string{audit.ActionLogin, audit.ActionLoginFailed, audit.ActionProfileUpdated}, actions)
+
+		login := page.Data[0]
+		require.NotNil(t, login.ActorUserID)
+		assert.Equal(t, member.ID, *login.ActorUserID)
+		assert.Equal(t, audit.TargetTypeUser, login.TargetType)
+		assert.Equal(t, "audit-e2e", login.UserAgent)
+		assert.Equal(t, "192.0.2.1", login.IP)
+
+		failed := page.Data[1]
+		assert.Nil(t, failed.ActorUserID)
+		assert.NotContains(t, string(failed.Metadata), "WrongPassw0rd")
+	})
+
+	t.Run("Audit Logs - Filter By Action", func(t *testing.T) {
+		page := listAuditLogs(t, url.Values{"action": {audit.ActionLoginFailed}})
+
+		require.Equal(t, 1, page.TotalItems)
+		assert.Equal(t, audit.ActionLoginFailed, page.Data[0].Action)
+	})
+
+	t.Run("Audit Logs - Filter By Actor", func(t *testing.T) {
+		page := listAuditLogs(t, url.Values{"actor_user_id": {strconv.FormatUint(uint64(member.ID), 10)}})
+
+		assert.Equal(t, 2, page.TotalItems)
+	})
+
+	t.Run("Audit Logs - Filter By Date Range", func(t *testing.T) {
+		future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		assert.Equal(t, 0, listAuditLogs(t, url.Values{"from": {future}}).TotalItems)
+
+		past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+		assert.Equal(t, 3, listAuditLogs(t, url.Values{"from": {past}, "to": {future}}).TotalItems)
+	})
+
+	t.Run("Audit Logs - Paging", func(t *testing.T) {
+		page := listAuditLogs(t, url.Values{"page": {"2"}, "limit": {"2"}})
+
+		assert.Equal(t, 3, page.TotalItems)
+		assert.Equal(t, 2, page.TotalPages)
+		assert.Len(t, page.Data, 1)
+	})
+
+	t.Run("Audit Logs - Invalid Date Range", func(t *testing.T) {
+		w := call("GET", "/api/v1/audit-logs?from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z", adminToken.Token, nil)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Audit Logs - Forbidden For Non Admin", func(t *testing.T) {
+		w := call("GET", "/api/v1/audit-logs", memberToken.Token, nil)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, apperror.ErrForbidden, errResp.Code)
+	})
+}
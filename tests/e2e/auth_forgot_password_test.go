@@ -10,11 +10,16 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
 	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
 )
 
 func TestAuthForgotPassword(t *testing.T) {
+	// Keep the per client IP limit and the cooldown out of the way of the per email limit
+	t.Setenv("AUTH_RATE_LIMIT_REQUESTS", "100")
+	t.Setenv("PASSWORD_RESET_COOLDOWN_SECONDS", "0")
+	t.Setenv("FORGOT_PASSWORD_MIN_DURATION_MS", "0")
 	router, db := setupTestRouter()
 
 	// Helper to create a user directly in DB
@@ -29,50 +34,30 @@ func TestAuthForgotPassword(t *testing.T) {
 	result := db.Create(&user)
 	require.NoError(t, result.Error)
 
-	t.Run("Forgot Password - Success", func(t *testing.T) {
-		// Note: This test will fail at email sending step due to missing SMTP config,
-		// but we can verify the token was generated in DB before that
-		payload := map[string]string{
-			"email": "test_forgot@example.com",
-		}
-		payloadBytes, _ := json.Marshal(payload)
-
+	forgotPassword := func(email string) *httptest.ResponseRecorder {
+		payloadBytes, _ := json.Marshal(map[string]string{"email": email})
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("POST", "/api/v1/forgot-password", bytes.NewBuffer(payloadBytes))
 		req.Header.Set("Content-Type", "application/json")
-
 		router.ServeHTTP(w, req)
+		return w
+	}
 
-		// The request will fail at email sending (500) due to missing SMTP config
-		// but the token should still be generated in DB
-		if w.Code == http.StatusInternalServerError {
-			// Verify token was generated despite email failure
-			var updatedUser models.User
-			db.First(&updatedUser, user.ID)
-			assert.NotNil(t, updatedUser.Token)
-			assert.NotNil(t, updatedUser.ExpiredAt)
+	t.Run("Forgot Password - Success", func(t *testing.T) {
+		// Sending the email fails without SMTP config, which is only logged
+		w := forgotPassword("test_forgot@example.com")
 
-			var errResp ErrorResponse
-			err := json.Unmarshal(w.Body.Bytes(), &errResp)
-			require.NoError(t, err)
-			assert.Equal(t, apperror.ErrInternalServer, errResp.Code)
-		} else {
-			// If SMTP is configured, should succeed
-			assert.Equal(t, http.StatusOK, w.Code)
-		}
+		assert.Equal(t, http.StatusOK, w.Code)
+		// The token is saved before answering, only the mail is sent in the background
+		var updatedUser models.User
+		require.NoError(t, db.First(&updatedUser, user.ID).Error)
+		require.NotNil(t, updatedUser.Token)
+		assert.Len(t, *updatedUser.Token, 64, "only the SHA-256 hash of the mailed token is stored")
+		assert.NotNil(t, updatedUser.ExpiredAt)
 	})
 
 	t.Run("Forgot Password - Email Not Found", func(t *testing.T) {
-		payload := map[string]string{
-			"email": "nonexistent@example.com",
-		}
-		payloadBytes, _ := json.Marshal(payload)
-
-		w := httptest.NewRecorder()
-		req, _ := http.NewRequest("POST", "/api/v1/forgot-password", bytes.NewBuffer(payloadBytes))
-		req.Header.Set("Content-Type", "application/json")
-
-		router.ServeHTTP(w, req)
+		w := forgotPassword("nonexistent@example.com")
 
 		assert.Equal(t, http.StatusOK, w.Code)
 
@@ -82,17 +67,54 @@ func TestAuthForgotPassword(t *testing.T) {
 		assert.Equal(t, "If your email is in our system, you will receive instructions to reset your password", resp["message"])
 	})
 
-	t.Run("Forgot Password - Invalid Email Format", func(t *testing.T) {
-		payload := map[string]string{
-			"email": "invalid-email",
+	t.Run("Forgot Password - Same Response For Registered And Unknown Emails", func(t *testing.T) {
+		registered := forgotPassword("test_forgot@example.com")
+		unknown := forgotPassword("someone-else@example.com")
+
+		assert.Equal(t, registered.Code, unknown.Code)
+		assert.Equal(t, registered.Body.String(), unknown.Body.String())
+	})
+
+	t.Run("Forgot Password - Limited Per Email", func(t *testing.T) {
+		registered := models.User{Name: "Limited User", Email: "limited_registered@example.com", Password: hashedPassword, Gender: 1}
+		require.NoError(t, db.Create(&registered).Error)
+
+		// Unknown emails are counted too, so the limit does not tell them apart either
+		for _, email := range []string{registered.Email, "limited_unknown@example.com"} {
+			for range services.FORGOT_PASSWORD_MAX_REQUESTS {
+				assert.Equal(t, http.StatusOK, forgotPassword(email).Code, email)
+			}
+
+			w := forgotPassword(email)
+
+			assert.Equal(t, http.StatusTooManyRequests, w.Code, email)
+			var errResp ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+			assert.Equal(t, apperror.ErrTooManyAttempts, errResp.Code)
 		}
-		payloadBytes, _ := json.Marshal(payload)
+	})
 
-		w := httptest.NewRecorder()
-		req, _ := http.NewRequest("POST", "/api/v1/forgot-password", bytes.NewBuffer(payloadBytes))
-		req.Header.Set("Content-Type", "application/json")
+	t.Run("Forgot Password - Failed Token Save", func(t *testing.T) {
+		failing := models.User{Name: "Failing User", Email: "save_fails@example.com", Password: hashedPassword, Gender: 1}
+		require.NoError(t, db.Create(&failing).Error)
+		require.NoError(t, db.Exec(`CREATE TRIGGER fail_token_save BEFORE UPDATE ON users
+			WHEN NEW.email = 'save_fails@example.com' BEGIN SELECT RAISE(ABORT, 'disk full'); END`).Error)
+		defer db.Exec("DROP TRIGGER fail_token_save")
 
-		router.ServeHTTP(w, req)
+		w := forgotPassword(failing.Email)
+
+		// The user would never get a working link, so the failure is not hidden behind the usual answer
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, apperror.ErrDBUpdate, errResp.Code)
+		var reloaded models.User
+		require.NoError(t, db.First(&reloaded, failing.ID).Error)
+		assert.Nil(t, reloaded.Token)
+	})
+
+	t.Run("Forgot Password - Invalid Email Format", func(t *testing.T) {
+		w := forgotPassword("invalid-email")
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 
@@ -102,3 +124,31 @@ func TestAuthForgotPassword(t *testing.T) {
 		assert.Equal(t, apperror.ErrValidationFailed, errResp.Code)
 	})
 }
+
+func TestAuthForgotPasswordCooldown(t *testing.T) {
+	router, db := setupTestRouter()
+	user := models.User{Name: "Cooldown User", Email: "cooldown@example.com", Password: utils.HashPassword("password123"), Gender: 1}
+	require.NoError(t, db.Create(&user).Error)
+
+	forgotPassword := func(email string) *httptest.ResponseRecorder {
+		payloadBytes, _ := json.Marshal(map[string]string{"email": email})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/forgot-password", bytes.NewBuffer(payloadBytes))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Registered and unknown emails cool down alike
+	for _, email := range []string{user.Email, "cooldown_unknown@example.com"} {
+		assert.Equal(t, http.StatusOK, forgotPassword(email).Code, email)
+
+		w := forgotPassword(email)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code, email)
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, apperror.ErrTooManyRequests, errResp.Code)
+		assert.Equal(t, "Please wait a few minutes before requesting another password reset email", errResp.Message)
+	}
+}
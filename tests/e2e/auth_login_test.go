@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -13,6 +14,7 @@ import (
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
 	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestAuthLogin(t *testing.T) {
@@ -21,11 +23,13 @@ func TestAuthLogin(t *testing.T) {
 	// Helper to create a user directly in DB
 	password := "password123"
 	hashedPassword := utils.HashPassword(password)
+	verifiedAt := time.Now()
 	user := models.User{
-		Name:     "Test User",
-		Email:    "test_login@example.com",
-		Password: hashedPassword,
-		Gender:   1,
+		Name:       "Test User",
+		Email:      "test_login@example.com",
+		Password:   hashedPassword,
+		Gender:     1,
+		VerifiedAt: &verifiedAt,
 	}
 	result := db.Create(&user)
 	require.NoError(t, result.Error)
@@ -51,6 +55,41 @@ func TestAuthLogin(t *testing.T) {
 
 		assert.NotEmpty(t, response.AccessToken.Token)
 		assert.NotEmpty(t, response.RefreshToken.Token)
+		require.NotNil(t, response.User)
+		assert.Equal(t, "test_login@example.com", response.User.Email)
+		assert.Equal(t, []string{}, response.User.Roles)
+	})
+
+	t.Run("Login - Email Is Case Insensitive", func(t *testing.T) {
+		loginPayload := map[string]string{
+			"email":    "Test_Login@EXAMPLE.com",
+			"password": password,
+		}
+		payloadBytes, _ := json.Marshal(loginPayload)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/login", bytes.NewBuffer(payloadBytes))
+		req.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Login - Password Is Case Sensitive", func(t *testing.T) {
+		loginPayload := map[string]string{
+			"email":    "test_login@example.com",
+			"password": "PASSWORD123",
+		}
+		payloadBytes, _ := json.Marshal(loginPayload)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/login", bytes.NewBuffer(payloadBytes))
+		req.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 
 	t.Run("Login - Invalid Credentials", func(t *testing.T) {
@@ -94,3 +133,113 @@ func TestAuthLogin(t *testing.T) {
 		assert.Equal(t, apperror.ErrValidationFailed, errResp.Code)
 	})
 }
+
+func TestAuthLoginLockout(t *testing.T) {
+	router, db := setupTestRouter()
+
+	password := "password123"
+	verifiedAt := time.Now()
+	user := models.User{
+		Name:       "Test User Lockout",
+		Email:      "test_lockout@example.com",
+		Password:   utils.HashPassword(password),
+		Gender:     1,
+		VerifiedAt: &verifiedAt,
+	}
+	require.NoError(t, db.Create(&user).Error)
+
+	login := func(password string) *httptest.ResponseRecorder {
+		payloadBytes, _ := json.Marshal(map[string]string{
+			"email":    "test_lockout@example.com",
+			"password": password,
+		})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/login", bytes.NewBuffer(payloadBytes))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Login - Locked After Repeated Failures", func(t *testing.T) {
+		for i := 0; i < 4; i++ {
+			assert.Equal(t, http.StatusBadRequest, login("wrongpassword").Code)
+		}
+
+		w := login("wrongpassword")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, apperror.ErrTooManyAttempts, errResp.Code)
+	})
+
+	t.Run("Login - Correct Password Rejected While Locked", func(t *testing.T) {
+		w := login(password)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+}
+
+func TestAuthLoginFromNewIP(t *testing.T) {
+	// Nothing listens on port 1, so every notification email fails to send
+	t.Setenv("MAIL_HOST", "127.0.0.1")
+	t.Setenv("MAIL_PORT", "1")
+	router, db := setupTestRouter()
+
+	password := "password123"
+	verifiedAt := time.Now()
+	user := models.User{
+		Name:       "Test User",
+		Email:      "new_ip@example.com",
+		Password:   utils.HashPassword(password),
+		Gender:     1,
+		VerifiedAt: &verifiedAt,
+	}
+	require.NoError(t, db.Create(&user).Error)
+
+	login := func(remoteAddr string) int {
+		payloadBytes, _ := json.Marshal(map[string]string{"email": user.Email, "password": password})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/login", bytes.NewBuffer(payloadBytes))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = remoteAddr
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// The first login is not notified, the second one from a new IP is, and the failing email does not change the response
+	assert.Equal(t, http.StatusOK, login("198.51.100.1:40000"))
+	assert.Equal(t, http.StatusOK, login("203.0.113.7:40000"))
+	assert.Equal(t, http.StatusOK, login("203.0.113.7:40001"))
+
+	var loginIPs []models.UserLoginIP
+	require.NoError(t, db.Where("user_id = ?", user.ID).Order("ip_address").Find(&loginIPs).Error)
+	require.Len(t, loginIPs, 2)
+	assert.Equal(t, "198.51.100.1", loginIPs[0].IpAddress)
+	assert.Equal(t, "203.0.113.7", loginIPs[1].IpAddress)
+}
+
+func TestAuthLoginRehashesOutdatedPassword(t *testing.T) {
+	t.Setenv("BCRYPT_COST", "5")
+	router, db := setupTestRouter()
+
+	password := "password123"
+	oldHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+	verifiedAt := time.Now()
+	user := models.User{Name: "Old Hash", Email: "old_hash@example.com", Password: string(oldHash), Gender: 1, VerifiedAt: &verifiedAt}
+	require.NoError(t, db.Create(&user).Error)
+
+	payloadBytes, _ := json.Marshal(map[string]string{"email": user.Email, "password": password})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/login", bytes.NewBuffer(payloadBytes))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var updated models.User
+	require.NoError(t, db.First(&updated, user.ID).Error)
+	cost, err := bcrypt.Cost([]byte(updated.Password))
+	require.NoError(t, err)
+	assert.Equal(t, 5, cost)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(updated.Password), []byte(password)))
+}
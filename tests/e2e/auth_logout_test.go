@@ -0,0 +1,144 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+func TestAuthLogout(t *testing.T) {
+	router, db := setupTestRouter()
+
+	// Helper to create a user directly in DB
+	password := "password123"
+	verifiedAt := time.Now()
+	user := models.User{
+		Name:       "Test User Logout",
+		Email:      "test_logout@example.com",
+		Password:   utils.HashPassword(password),
+		Gender:     1,
+		VerifiedAt: &verifiedAt,
+	}
+	result := db.Create(&user)
+	require.NoError(t, result.Error)
+
+	// Login to get tokens
+	loginPayload, _ := json.Marshal(map[string]string{
+		"email":    "test_logout@example.com",
+		"password": password,
+	})
+	login := func() dto.LoginResponse {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/login", bytes.NewBuffer(loginPayload))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var loginResponse dto.LoginResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &loginResponse))
+		return loginResponse
+	}
+
+	loginResponse := login()
+	accessToken := loginResponse.AccessToken.Token
+	refreshToken := loginResponse.RefreshToken.Token
+
+	getProfile := func(token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/profile", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	logout := func(token string, body map[string]string) *httptest.ResponseRecorder {
+		payloadBytes, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/logout", bytes.NewBuffer(payloadBytes))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Logout - Missing Refresh Token", func(t *testing.T) {
+		w := logout(accessToken, map[string]string{"foo": "bar"})
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		var errResp ErrorResponse
+		err := json.Unmarshal(w.Body.Bytes(), &errResp)
+		require.NoError(t, err)
+		assert.Equal(t, apperror.ErrUnauthorized, errResp.Code)
+	})
+
+	t.Run("Logout - Unauthorized Without Access Token", func(t *testing.T) {
+		w := logout("", map[string]string{"refresh_token": refreshToken})
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Logout - Success Revokes Refresh Token", func(t *testing.T) {
+		w := logout(accessToken, map[string]string{"refresh_token": refreshToken})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var count int64
+		db.Model(&models.RefreshToken{}).Where("refresh_token = ?", refreshToken).Count(&count)
+		assert.Equal(t, int64(0), count)
+
+		// The revoked refresh token can no longer be used
+		refreshPayload, _ := json.Marshal(map[string]string{
+			"refresh_token": refreshToken,
+			"access_token":  accessToken,
+		})
+		w = httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/refresh-token", bytes.NewBuffer(refreshPayload))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		// Neither can the access token of the logged out session
+		assert.Equal(t, http.StatusUnauthorized, getProfile(accessToken).Code)
+	})
+
+	t.Run("Logout - Already Revoked Is Idempotent", func(t *testing.T) {
+		w := logout(login().AccessToken.Token, map[string]string{"refresh_token": refreshToken})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Logout All - Revokes Every Session", func(t *testing.T) {
+		// The session of the idempotency check is still open
+		currentToken := login().AccessToken.Token
+		require.Equal(t, http.StatusOK, getProfile(currentToken).Code)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/logout-all", nil)
+		req.Header.Set("Authorization", "Bearer "+currentToken)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, float64(2), response["revoked_count"])
+
+		var count int64
+		db.Model(&models.RefreshToken{}).Where("user_id = ?", user.ID).Count(&count)
+		assert.Equal(t, int64(0), count)
+		assert.Equal(t, http.StatusUnauthorized, getProfile(currentToken).Code)
+	})
+}
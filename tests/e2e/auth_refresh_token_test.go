@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,11 +22,13 @@ func TestAuthRefreshToken(t *testing.T) {
 	// Helper to create a user directly in DB
 	password := "password123"
 	hashedPassword := utils.HashPassword(password)
+	verifiedAt := time.Now()
 	user := models.User{
-		Name:     "Test User Refresh",
-		Email:    "test_refresh@example.com",
-		Password: hashedPassword,
-		Gender:   1,
+		Name:       "Test User Refresh",
+		Email:      "test_refresh@example.com",
+		Password:   hashedPassword,
+		Gender:     1,
+		VerifiedAt: &verifiedAt,
 	}
 	result := db.Create(&user)
 	require.NoError(t, result.Error)
@@ -112,3 +115,43 @@ func TestAuthRefreshToken(t *testing.T) {
 		assert.Equal(t, apperror.ErrValidationFailed, errResp.Code)
 	})
 }
+
+func TestAuthRefreshTokenFromAnotherDevice(t *testing.T) {
+	t.Setenv("REFRESH_TOKEN_FINGERPRINT_MODE", "enforce")
+	router, db := setupTestRouter()
+
+	password := "password123"
+	verifiedAt := time.Now()
+	user := models.User{Name: "Device User", Email: "device_user@example.com", Password: utils.HashPassword(password), Gender: 1, VerifiedAt: &verifiedAt}
+	require.NoError(t, db.Create(&user).Error)
+
+	call := func(path, fingerprint string, payload map[string]string) *httptest.ResponseRecorder {
+		payloadBytes, _ := json.Marshal(payload)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, bytes.NewBuffer(payloadBytes))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Device-Fingerprint", fingerprint)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := call("/api/v1/login", "laptop", map[string]string{"email": user.Email, "password": password})
+	require.Equal(t, http.StatusOK, w.Code)
+	var loginResponse dto.LoginResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &loginResponse))
+	tokens := map[string]string{"refresh_token": loginResponse.RefreshToken.Token, "access_token": loginResponse.AccessToken.Token}
+
+	w = call("/api/v1/refresh-token", "stolen", tokens)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	var errResp ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+	assert.Equal(t, apperror.ErrDeviceMismatch, errResp.Code)
+
+	// The token is revoked, so not even the device it was issued to can use it anymore
+	w = call("/api/v1/refresh-token", "laptop", tokens)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	var count int64
+	require.NoError(t, db.Model(&models.RefreshToken{}).Where("user_id = ?", user.ID).Count(&count).Error)
+	assert.Zero(t, count)
+}
@@ -5,12 +5,14 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
 	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
 )
@@ -20,21 +22,24 @@ func TestAuthResetPassword(t *testing.T) {
 
 	hashedPassword := utils.HashPassword("oldpassword123")
 	token := "valid_reset_token"
+	tokenHash := utils.HashToken(token)
 	expiredAt := time.Now().Add(time.Hour).Unix()
+	purpose := constants.TOKEN_PURPOSE_RESET_PASSWORD
 
 	user := models.User{
-		Name:      "Test User Reset",
-		Email:     "test_reset@example.com",
-		Password:  hashedPassword,
-		Gender:    1,
-		Token:     &token,
-		ExpiredAt: &expiredAt,
+		Name:         "Test User Reset",
+		Email:        "test_reset@example.com",
+		Password:     hashedPassword,
+		Gender:       1,
+		Token:        &tokenHash,
+		TokenPurpose: &purpose,
+		ExpiredAt:    &expiredAt,
 	}
 	result := db.Create(&user)
 	require.NoError(t, result.Error)
 
 	t.Run("Reset Password - Success", func(t *testing.T) {
-		newPassword := "newpassword123"
+		newPassword := "NewPassw0rd123"
 		payload := map[string]string{
 			"token":        token,
 			"new_password": newPassword,
@@ -61,7 +66,7 @@ func TestAuthResetPassword(t *testing.T) {
 	t.Run("Reset Password - Invalid Token", func(t *testing.T) {
 		payload := map[string]string{
 			"token":        "invalid_token",
-			"new_password": "newpassword123",
+			"new_password": "NewPassw0rd123",
 		}
 		payloadBytes, _ := json.Marshal(payload)
 
@@ -81,21 +86,23 @@ func TestAuthResetPassword(t *testing.T) {
 
 	t.Run("Reset Password - Expired Token", func(t *testing.T) {
 		expiredToken := "expired_token"
+		expiredHash := utils.HashToken(expiredToken)
 		expiredTime := time.Now().Add(-time.Hour).Unix()
 
 		expiredUser := models.User{
-			Name:      "Expired User",
-			Email:     "expired@example.com",
-			Password:  hashedPassword,
-			Gender:    1,
-			Token:     &expiredToken,
-			ExpiredAt: &expiredTime,
+			Name:         "Expired User",
+			Email:        "expired@example.com",
+			Password:     hashedPassword,
+			Gender:       1,
+			Token:        &expiredHash,
+			TokenPurpose: &purpose,
+			ExpiredAt:    &expiredTime,
 		}
 		db.Create(&expiredUser)
 
 		payload := map[string]string{
 			"token":        expiredToken,
-			"new_password": "newpassword123",
+			"new_password": "NewPassw0rd123",
 		}
 		payloadBytes, _ := json.Marshal(payload)
 
@@ -112,4 +119,40 @@ func TestAuthResetPassword(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, apperror.ErrTokenExpired, errResp.Code)
 	})
+
+	t.Run("Reset Password - Concurrent Requests Use The Token Once", func(t *testing.T) {
+		raceToken := "race_token"
+		raceHash := utils.HashToken(raceToken)
+		raceExpiredAt := time.Now().Add(time.Hour).Unix()
+		raceUser := models.User{Name: "Race User", Email: "race_reset@example.com", Password: hashedPassword, Gender: 1, Token: &raceHash, TokenPurpose: &purpose, ExpiredAt: &raceExpiredAt}
+		require.NoError(t, db.Create(&raceUser).Error)
+
+		// All requests find the token before any has consumed it, hashing the password gives them time to
+		const requests = 5
+		codes := make([]int, requests)
+		var wg sync.WaitGroup
+		for i := range requests {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				payloadBytes, _ := json.Marshal(map[string]string{"token": raceToken, "new_password": "NewPassw0rd12" + string(rune('a'+i))})
+				w := httptest.NewRecorder()
+				req, _ := http.NewRequest("POST", "/api/v1/reset-password", bytes.NewBuffer(payloadBytes))
+				req.Header.Set("Content-Type", "application/json")
+				router.ServeHTTP(w, req)
+				codes[i] = w.Code
+			}()
+		}
+		wg.Wait()
+
+		succeeded := 0
+		for _, code := range codes {
+			if code == http.StatusOK {
+				succeeded++
+			} else {
+				assert.Equal(t, http.StatusNotFound, code)
+			}
+		}
+		assert.Equal(t, 1, succeeded)
+	})
 }
@@ -0,0 +1,145 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+func TestAuthVerifyEmail(t *testing.T) {
+	router, db := setupTestRouter()
+
+	adminRole := models.Role{Name: constants.ROLE_ADMIN}
+	require.NoError(t, db.Create(&adminRole).Error)
+	verifiedAt := time.Now()
+	admin := models.User{Name: "Admin", Email: "admin_verify@example.com", Password: utils.HashPassword("password123"), Gender: 1, VerifiedAt: &verifiedAt, Roles: []models.Role{adminRole}}
+	require.NoError(t, db.Create(&admin).Error)
+
+	jwtService, err := services.NewJWTService()
+	require.NoError(t, err)
+	adminToken, err := jwtService.GenerateAccessToken(admin.ID)
+	require.NoError(t, err)
+
+	post := func(path string, payload any, token string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(payload)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+	verify := func(token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/verify-email?token="+token, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+	login := func(email string) *httptest.ResponseRecorder {
+		return post("/api/v1/login", map[string]string{"email": email, "password": "Str0ng!Passw0rd#2024"}, "")
+	}
+
+	t.Run("Create, Verify And Login", func(t *testing.T) {
+		// The verification mail cannot be sent in tests, which must not fail the creation
+		w := post("/api/v1/users", map[string]any{
+			"email":    "new_user@example.com",
+			"password": "Str0ng!Passw0rd#2024",
+			"name":     "New User",
+			"birthday": "1990-01-01",
+			"address":  "123 Main Street",
+			"gender":   1,
+		}, adminToken.Token)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var created models.User
+		require.NoError(t, db.Where("email = ?", "new_user@example.com").First(&created).Error)
+		assert.Nil(t, created.VerifiedAt)
+		require.NotNil(t, created.Token)
+
+		w = login("new_user@example.com")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, apperror.ErrEmailNotVerified, errResp.Code)
+		assert.Equal(t, "ERR_EMAIL_NOT_VERIFIED", errResp.Error)
+
+		// Only the hash of the mailed token is stored, which is itself no working token
+		assert.Len(t, *created.Token, 64)
+		w = verify(*created.Token)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		// The mailed token is unknown to the test, so store the hash of one it knows
+		token := "known-verification-token"
+		require.NoError(t, db.Model(&created).Update("token", utils.HashToken(token)).Error)
+
+		// A verification link cannot be used to reset the password
+		w = post("/api/v1/reset-password", map[string]string{"token": token, "new_password": "An0ther!Passw0rd#2024"}, "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = verify(token)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = login("new_user@example.com")
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// The token is consumed by the first verification
+		w = verify(token)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Create - Duplicate Email", func(t *testing.T) {
+		w := post("/api/v1/users", map[string]any{
+			"email":    "admin_verify@example.com",
+			"password": "Str0ng!Passw0rd#2024",
+			"name":     "Duplicate",
+			"birthday": "1990-01-01",
+			"address":  "123 Main Street",
+			"gender":   1,
+		}, adminToken.Token)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, apperror.ErrDuplicateEmail, errResp.Code)
+		assert.Equal(t, "Email already registered", errResp.Message)
+	})
+
+	t.Run("Verify - Expired Token", func(t *testing.T) {
+		token := "expired-verification-token"
+		hash := utils.HashToken(token)
+		expiredAt := time.Now().Add(-time.Minute).Unix()
+		purpose := constants.TOKEN_PURPOSE_VERIFY_EMAIL
+		user := models.User{Name: "Expired", Email: "expired_verify@example.com", Password: "password", Gender: 1, Token: &hash, TokenPurpose: &purpose, ExpiredAt: &expiredAt}
+		require.NoError(t, db.Create(&user).Error)
+
+		w := verify(token)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, apperror.ErrTokenExpired, errResp.Code)
+	})
+
+	t.Run("Resend - Throttled", func(t *testing.T) {
+		payload := map[string]string{"email": "nobody@example.com"}
+
+		w := post("/api/v1/resend-verification", payload, "")
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = post("/api/v1/resend-verification", payload, "")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+}
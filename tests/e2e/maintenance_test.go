@@ -0,0 +1,91 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	router, db := setupTestRouter()
+
+	adminRole := models.Role{Name: constants.ROLE_ADMIN}
+	require.NoError(t, db.Create(&adminRole).Error)
+	admin := models.User{Name: "Admin", Email: "maintenance_admin@example.com", Password: utils.HashPassword("Passw0rd123"), Gender: 1, Roles: []models.Role{adminRole}}
+	member := models.User{Name: "Member", Email: "maintenance_member@example.com", Password: "password", Gender: 1}
+	for _, user := range []*models.User{&admin, &member} {
+		require.NoError(t, db.Create(user).Error)
+	}
+
+	jwtService, err := services.NewJWTService()
+	require.NoError(t, err)
+	adminToken, err := jwtService.GenerateAccessToken(admin.ID)
+	require.NoError(t, err)
+	memberToken, err := jwtService.GenerateAccessToken(member.ID)
+	require.NoError(t, err)
+
+	call := func(method, path, token string, payload any) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			require.NoError(t, json.NewEncoder(&body).Encode(payload))
+		}
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, &body)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+	setMaintenance := func(t *testing.T, payload map[string]any) {
+		w := call("POST", "/api/v1/admin/maintenance", adminToken.Token, payload)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	assertUnavailable := func(t *testing.T, w *httptest.ResponseRecorder, message string) {
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "300", w.Header().Get("Retry-After"))
+		var response ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, apperror.ErrUnavailable, response.Code)
+		assert.Equal(t, message, response.Message)
+	}
+
+	t.Run("Maintenance - Read Only", func(t *testing.T) {
+		setMaintenance(t, map[string]any{"enabled": true, "mode": "read_only", "message": "Read only until 10:00"})
+
+		assert.Equal(t, http.StatusOK, call("GET", "/api/v1/profile", memberToken.Token, nil).Code)
+		assertUnavailable(t, call("PATCH", "/api/v1/profile", memberToken.Token, map[string]string{"name": "Renamed"}), "Read only until 10:00")
+	})
+
+	t.Run("Maintenance - Full", func(t *testing.T) {
+		setMaintenance(t, map[string]any{"enabled": true})
+
+		assertUnavailable(t, call("GET", "/api/v1/profile", memberToken.Token, nil), services.DEFAULT_MAINTENANCE_MESSAGE)
+		assertUnavailable(t, call("GET", "/api/v1/settings", adminToken.Token, nil), services.DEFAULT_MAINTENANCE_MESSAGE)
+	})
+
+	t.Run("Maintenance - Allowed Routes Stay Reachable", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, call("GET", "/healthz", "", nil).Code)
+		// Admins can still sign in to switch the maintenance mode off
+		login := call("POST", "/api/v1/login", "", map[string]string{"email": admin.Email, "password": "Passw0rd123"})
+		assert.NotEqual(t, http.StatusServiceUnavailable, login.Code)
+		assert.Equal(t, http.StatusForbidden, call("POST", "/api/v1/admin/maintenance", memberToken.Token, map[string]any{"enabled": false}).Code)
+	})
+
+	t.Run("Maintenance - Disabled", func(t *testing.T) {
+		setMaintenance(t, map[string]any{"enabled": false})
+
+		assert.Equal(t, http.StatusOK, call("PATCH", "/api/v1/profile", memberToken.Token, map[string]string{"name": "Renamed"}).Code)
+	})
+}
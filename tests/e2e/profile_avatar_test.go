@@ -0,0 +1,119 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+func TestProfileAvatar(t *testing.T) {
+	t.Setenv("STORAGE_LOCAL_DIR", t.TempDir())
+	t.Setenv("AVATAR_MAX_BYTES", "65536")
+	router, db := setupTestRouter()
+
+	user := models.User{Name: "Avatar User", Email: "avatar_user@example.com", Password: utils.HashPassword("password123"), Gender: 1}
+	require.NoError(t, db.Create(&user).Error)
+	jwtService, err := services.NewJWTService()
+	require.NoError(t, err)
+	token, err := jwtService.GenerateAccessToken(user.ID)
+	require.NoError(t, err)
+
+	upload := func(content []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, _ := form.CreateFormFile("avatar", "avatar.png")
+		_, _ = part.Write(content)
+		_ = form.Close()
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/profile/avatar", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+token.Token)
+		router.ServeHTTP(w, req)
+		return w
+	}
+	call := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token.Token)
+		router.ServeHTTP(w, req)
+		return w
+	}
+	profileAvatar := func(t *testing.T) *string {
+		w := call("GET", "/api/v1/profile")
+		require.Equal(t, http.StatusOK, w.Code)
+		var profile dto.ProfileResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &profile))
+		return profile.Avatar
+	}
+
+	var avatarURL string
+	t.Run("Upload", func(t *testing.T) {
+		assert.Nil(t, profileAvatar(t), "the profile is cached before the upload")
+		var content bytes.Buffer
+		require.NoError(t, png.Encode(&content, image.NewRGBA(image.Rect(0, 0, 400, 300))))
+
+		w := upload(content.Bytes())
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response dto.AvatarResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		avatarURL = response.Avatar
+		require.NotNil(t, profileAvatar(t))
+		assert.Equal(t, avatarURL, *profileAvatar(t))
+
+		// The local storage serves the file itself
+		parsed, err := url.Parse(avatarURL)
+		require.NoError(t, err)
+		w = call("GET", parsed.Path)
+		require.Equal(t, http.StatusOK, w.Code)
+		stored, err := png.Decode(w.Body)
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 256, 256), stored.Bounds())
+	})
+
+	t.Run("Upload - Unsupported Type", func(t *testing.T) {
+		w := upload([]byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;"))
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, apperror.ErrUnsupportedMedia, errResp.Code)
+	})
+
+	t.Run("Upload - Too Large", func(t *testing.T) {
+		w := upload(append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 65536)...))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		w := call("DELETE", "/api/v1/profile/avatar")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Nil(t, profileAvatar(t))
+		parsed, _ := url.Parse(avatarURL)
+		assert.Equal(t, http.StatusNotFound, call("GET", parsed.Path).Code)
+	})
+
+	t.Run("Requires Authentication", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/api/v1/profile/avatar", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
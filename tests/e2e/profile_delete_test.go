@@ -0,0 +1,115 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+func TestProfileDelete(t *testing.T) {
+	router, db := setupTestRouter()
+
+	password := "password123"
+	verifiedAt := time.Now()
+	user := models.User{
+		Name:       "Test User Delete",
+		Email:      "test_delete@example.com",
+		Password:   utils.HashPassword(password),
+		Gender:     1,
+		VerifiedAt: &verifiedAt,
+	}
+	require.NoError(t, db.Create(&user).Error)
+
+	send := func(method, path, token string, body map[string]string) *httptest.ResponseRecorder {
+		payloadBytes, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(payloadBytes))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+	credentials := map[string]string{"email": user.Email, "password": password}
+	login := func() *httptest.ResponseRecorder {
+		return send("POST", "/api/v1/login", "", credentials)
+	}
+	errorCode := func(t *testing.T, w *httptest.ResponseRecorder) int {
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		return errResp.Code
+	}
+
+	w := login()
+	require.Equal(t, http.StatusOK, w.Code)
+	var loginResponse dto.LoginResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &loginResponse))
+	accessToken := loginResponse.AccessToken.Token
+
+	t.Run("Delete - Wrong Password", func(t *testing.T) {
+		w := send("DELETE", "/api/v1/profile", accessToken, map[string]string{"password": "wrongpassword"})
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, apperror.ErrInvalidPassword, errorCode(t, w))
+	})
+
+	t.Run("Delete - Success", func(t *testing.T) {
+		w := send("DELETE", "/api/v1/profile", accessToken, map[string]string{"password": password})
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var res dto.AccountDeletionResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), res.DeletionScheduledAt, time.Minute)
+
+		var stored models.User
+		require.NoError(t, db.First(&stored, user.ID).Error)
+		assert.Equal(t, constants.USER_STATUS_PENDING_DELETION, stored.Status)
+		assert.NotNil(t, stored.DeletionRequestedAt)
+	})
+
+	t.Run("Delete - Access Token Is Revoked", func(t *testing.T) {
+		w := send("GET", "/api/v1/profile", accessToken, nil)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Login - Refused While Pending Deletion", func(t *testing.T) {
+		w := login()
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, apperror.ErrAccountPendingDeletion, errorCode(t, w))
+	})
+
+	t.Run("Reactivate - Wrong Password", func(t *testing.T) {
+		w := send("POST", "/api/v1/reactivate", "", map[string]string{"email": user.Email, "password": "wrongpassword"})
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, apperror.ErrInvalidPassword, errorCode(t, w))
+	})
+
+	t.Run("Reactivate - Success", func(t *testing.T) {
+		w := send("POST", "/api/v1/reactivate", "", credentials)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusOK, login().Code)
+	})
+
+	t.Run("Reactivate - Not Pending Deletion", func(t *testing.T) {
+		w := send("POST", "/api/v1/reactivate", "", credentials)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, apperror.ErrBadRequest, errorCode(t, w))
+	})
+}
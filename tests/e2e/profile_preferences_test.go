@@ -0,0 +1,91 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+)
+
+func TestProfilePreferences(t *testing.T) {
+	router, db := setupTestRouter()
+
+	user := models.User{Name: "Preferences User", Email: "preferences_user@example.com", Password: utils.HashPassword("password123"), Gender: 1}
+	require.NoError(t, db.Create(&user).Error)
+	jwtService, err := services.NewJWTService()
+	require.NoError(t, err)
+	token, err := jwtService.GenerateAccessToken(user.ID)
+	require.NoError(t, err)
+
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token.Token)
+		router.ServeHTTP(w, req)
+		return w
+	}
+	profilePreferences := func(t *testing.T) *dto.PreferencesResponse {
+		w := call("GET", "/api/v1/profile", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var profile dto.ProfileResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &profile))
+		require.NotNil(t, profile.Preferences)
+		return profile.Preferences
+	}
+
+	t.Run("Profile Embeds Defaults Before First Read", func(t *testing.T) {
+		preferences := profilePreferences(t)
+		assert.Equal(t, "UTC", preferences.Timezone)
+		assert.Equal(t, "system", preferences.Theme)
+	})
+
+	t.Run("First Read Saves Defaults", func(t *testing.T) {
+		w := call("GET", "/api/v1/profile/preferences", "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var preferences models.UserPreference
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &preferences))
+		assert.Equal(t, "UTC", preferences.Timezone)
+		assert.Equal(t, "en", preferences.Locale)
+		assert.True(t, preferences.EmailNotifications)
+		assert.Equal(t, "system", preferences.Theme)
+		var count int64
+		require.NoError(t, db.Model(&models.UserPreference{}).Where("user_id = ?", user.ID).Count(&count).Error)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("Update Shows In Profile", func(t *testing.T) {
+		// The profile read above is cached, the update must invalidate it
+		w := call("PUT", "/api/v1/profile/preferences", `{"timezone":"Asia/Ho_Chi_Minh","locale":"vi","email_notifications":false,"theme":"dark"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		preferences := profilePreferences(t)
+		assert.Equal(t, "Asia/Ho_Chi_Minh", preferences.Timezone)
+		assert.Equal(t, "vi", preferences.Locale)
+		assert.False(t, preferences.EmailNotifications)
+		assert.Equal(t, "dark", preferences.Theme)
+	})
+
+	t.Run("Bogus Timezone", func(t *testing.T) {
+		w := call("PUT", "/api/v1/profile/preferences", `{"timezone":"Mars/Olympus_Mons","locale":"en","email_notifications":true,"theme":"light"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "Asia/Ho_Chi_Minh", profilePreferences(t).Timezone)
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/profile/preferences", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
@@ -0,0 +1,108 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+)
+
+func TestProfileSessions(t *testing.T) {
+	router, db := setupTestRouter()
+
+	password := "password123"
+	verifiedAt := time.Now()
+	users := []*models.User{
+		{Name: "Sessions User", Email: "sessions@example.com", Password: utils.HashPassword(password), Gender: 1, VerifiedAt: &verifiedAt},
+		{Name: "Other User", Email: "sessions_other@example.com", Password: utils.HashPassword(password), Gender: 1, VerifiedAt: &verifiedAt},
+	}
+	for _, user := range users {
+		require.NoError(t, db.Create(user).Error)
+	}
+
+	call := func(method, path, token, userAgent string, payload any) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			require.NoError(t, json.NewEncoder(&body).Encode(payload))
+		}
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, &body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", userAgent)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+	login := func(email, userAgent string) dto.LoginResponse {
+		w := call("POST", "/api/v1/login", "", userAgent, map[string]string{"email": email, "password": password})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response dto.LoginResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+	listSessions := func(accessToken string) []dto.SessionResponse {
+		w := call("GET", "/api/v1/profile/sessions", accessToken, "test", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response struct {
+			Data []dto.SessionResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Data
+	}
+
+	laptop := login("sessions@example.com", "Laptop Browser")
+	phone := login("sessions@example.com", "Phone App")
+	other := login("sessions_other@example.com", "Other Browser")
+	// An expired session is not listed
+	require.NoError(t, db.Create(&models.RefreshToken{RefreshToken: "expired_session_token", IpAddress: "10.0.0.1", ExpiredAt: time.Now().Add(-time.Hour).Unix(), UserID: users[0].ID}).Error)
+
+	t.Run("Sessions - List Active Sessions", func(t *testing.T) {
+		sessions := listSessions(laptop.AccessToken.Token)
+
+		require.Len(t, sessions, 2)
+		agents := []string{sessions[0].UserAgent, sessions[1].UserAgent}
+		assert.ElementsMatch(t, []string{"Laptop Browser", "Phone App"}, agents)
+		for _, session := range sessions {
+			assert.True(t, strings.HasPrefix(session.Token, "********"), session.Token)
+			assert.NotContains(t, []string{laptop.RefreshToken.Token, phone.RefreshToken.Token}, session.Token)
+			assert.NotNil(t, session.LastUsedAt)
+		}
+	})
+
+	t.Run("Sessions - Revoking Another User's Session Is Not Found", func(t *testing.T) {
+		otherSessions := listSessions(other.AccessToken.Token)
+		require.Len(t, otherSessions, 1)
+
+		w := call("DELETE", fmt.Sprintf("/api/v1/profile/sessions/%d", otherSessions[0].ID), laptop.AccessToken.Token, "test", nil)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Len(t, listSessions(other.AccessToken.Token), 1)
+	})
+
+	t.Run("Sessions - Revoke The Current Session", func(t *testing.T) {
+		var current models.RefreshToken
+		require.NoError(t, db.Where("refresh_token = ?", laptop.RefreshToken.Token).First(&current).Error)
+
+		w := call("DELETE", fmt.Sprintf("/api/v1/profile/sessions/%d", current.ID), laptop.AccessToken.Token, "Laptop Browser", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		// The revoked refresh token can no longer be used, the other session still works
+		w = call("POST", "/api/v1/refresh-token", "", "Laptop Browser", map[string]string{"refresh_token": laptop.RefreshToken.Token, "access_token": laptop.AccessToken.Token})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		sessions := listSessions(phone.AccessToken.Token)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, "Phone App", sessions[0].UserAgent)
+	})
+}
@@ -0,0 +1,100 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+func TestRateLimit(t *testing.T) {
+	t.Setenv("AUTH_RATE_LIMIT_REQUESTS", "2")
+	router, _ := setupTestRouter()
+
+	forgotPassword := func(remoteAddr string) *httptest.ResponseRecorder {
+		payloadBytes, _ := json.Marshal(map[string]string{"email": "invalid-email"})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/forgot-password", bytes.NewBuffer(payloadBytes))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = remoteAddr
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Forgot Password - Limited Per Client IP", func(t *testing.T) {
+		for range 2 {
+			w := forgotPassword("203.0.113.1:1234")
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		}
+
+		w := forgotPassword("203.0.113.1:1234")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, apperror.ErrTooManyRequests, errResp.Code)
+
+		w = forgotPassword("203.0.113.2:1234")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Resend Verification - Limited Per Client IP", func(t *testing.T) {
+		resend := func() *httptest.ResponseRecorder {
+			payloadBytes, _ := json.Marshal(map[string]string{"email": "invalid-email"})
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/api/v1/resend-verification", bytes.NewBuffer(payloadBytes))
+			req.Header.Set("Content-Type", "application/json")
+			req.RemoteAddr = "203.0.113.3:1234"
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		for range 2 {
+			assert.Equal(t, http.StatusBadRequest, resend().Code)
+		}
+		w := resend()
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, apperror.ErrTooManyRequests, errResp.Code)
+	})
+
+	t.Run("Token Endpoints - Limited Per Client IP", func(t *testing.T) {
+		tests := []struct {
+			method string
+			path   string
+			body   string
+		}{
+			{"POST", "/api/v1/refresh-token", `{"refresh_token":"unknown"}`},
+			{"POST", "/api/v1/reset-password", `{"token":"unknown","password":"NewPassw0rd!"}`},
+			{"GET", "/api/v1/verify-email?token=unknown", ""},
+			{"GET", "/api/v1/confirm-email-change?token=unknown", ""},
+		}
+
+		for i, tt := range tests {
+			t.Run(tt.path, func(t *testing.T) {
+				send := func() *httptest.ResponseRecorder {
+					w := httptest.NewRecorder()
+					req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+					req.Header.Set("Content-Type", "application/json")
+					req.RemoteAddr = fmt.Sprintf("203.0.113.%d:1234", 10+i)
+					router.ServeHTTP(w, req)
+					return w
+				}
+
+				for range 2 {
+					assert.NotEqual(t, http.StatusTooManyRequests, send().Code)
+				}
+				assert.Equal(t, http.StatusTooManyRequests, send().Code)
+			})
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	router, _ := setupTestRouter()
+
+	t.Run("Echoes Client Request ID In Error Response", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/profile", nil)
+		req.Header.Set("X-Request-ID", "client-req-123")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "client-req-123", w.Header().Get("X-Request-ID"))
+
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, "client-req-123", errResp.RequestID)
+	})
+
+	t.Run("Generated Request ID Matches Header", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/profile", nil)
+		router.ServeHTTP(w, req)
+
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.NotEmpty(t, errResp.RequestID)
+		assert.Equal(t, w.Header().Get("X-Request-ID"), errResp.RequestID)
+	})
+}
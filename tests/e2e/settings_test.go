@@ -0,0 +1,93 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+)
+
+func TestSettings(t *testing.T) {
+	router, db := setupTestRouter()
+
+	adminRole := models.Role{Name: constants.ROLE_ADMIN}
+	require.NoError(t, db.Create(&adminRole).Error)
+	admin := models.User{Name: "Admin", Email: "settings_admin@example.com", Password: "password", Gender: 1, Roles: []models.Role{adminRole}}
+	member := models.User{Name: "Member", Email: "settings_member@example.com", Password: "password", Gender: 1}
+	for _, user := range []*models.User{&admin, &member} {
+		require.NoError(t, db.Create(user).Error)
+	}
+
+	jwtService, err := services.NewJWTService()
+	require.NoError(t, err)
+	adminToken, err := jwtService.GenerateAccessToken(admin.ID)
+	require.NoError(t, err)
+	memberToken, err := jwtService.GenerateAccessToken(member.ID)
+	require.NoError(t, err)
+
+	call := func(method, path, token string, payload any) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			require.NoError(t, json.NewEncoder(&body).Encode(payload))
+		}
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, &body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Settings - Create, Update And Delete", func(t *testing.T) {
+		w := call("PUT", "/api/v1/settings/login.max_attempts", adminToken.Token, map[string]string{"value": "7", "type": "int"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = call("PUT", "/api/v1/settings/signup.enabled", adminToken.Token, map[string]string{"value": "TRUE", "type": "bool"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var setting models.Setting
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &setting))
+		assert.Equal(t, "true", setting.Value)
+
+		w = call("PUT", "/api/v1/settings/login.max_attempts", adminToken.Token, map[string]string{"value": "3", "type": "int"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = call("GET", "/api/v1/settings", adminToken.Token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var list struct {
+			Data []models.Setting `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		require.Len(t, list.Data, 2)
+		assert.Equal(t, "login.max_attempts", list.Data[0].Key)
+		assert.Equal(t, "3", list.Data[0].Value)
+
+		w = call("DELETE", "/api/v1/settings/signup.enabled", adminToken.Token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusNotFound, call("GET", "/api/v1/settings/signup.enabled", adminToken.Token, nil).Code)
+		assert.Equal(t, http.StatusNotFound, call("DELETE", "/api/v1/settings/signup.enabled", adminToken.Token, nil).Code)
+	})
+
+	t.Run("Settings - Value Must Match Type", func(t *testing.T) {
+		w := call("PUT", "/api/v1/settings/login.max_attempts", adminToken.Token, map[string]string{"value": "abc", "type": "int"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = call("GET", "/api/v1/settings/login.max_attempts", adminToken.Token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var setting models.Setting
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &setting))
+		assert.Equal(t, "3", setting.Value)
+	})
+
+	t.Run("Settings - Require Permission", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, call("GET", "/api/v1/settings", memberToken.Token, nil).Code)
+		assert.Equal(t, http.StatusForbidden, call("PUT", "/api/v1/settings/site.name", memberToken.Token, map[string]string{"value": "x", "type": "string"}).Code)
+		assert.Equal(t, http.StatusForbidden, call("DELETE", "/api/v1/settings/login.max_attempts", memberToken.Token, nil).Code)
+	})
+}
@@ -2,10 +2,13 @@ package e2e
 
 import (
 	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/repositories"
 	"github.com/vfa-khuongdv/golang-cms/internal/routes"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -13,17 +16,29 @@ import (
 
 // ErrorResponse represents the standard error response structure
 type ErrorResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code      int    `json:"code"`
+	Error     string `json:"error"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id"`
 }
 
 func init() {
 	// Change to project root to allow loading templates
 	_ = os.Chdir("../..")
+	// Keep uploaded files out of the source tree
+	if dir, err := os.MkdirTemp("", "e2e-storage-"); err == nil {
+		_ = os.Setenv("STORAGE_LOCAL_DIR", dir)
+	}
 }
 
 // setupTestRouter initializes the router with an in-memory SQLite database
 func setupTestRouter() (*gin.Engine, *gorm.DB) {
+	router, db, _ := setupTestRouterWithAudit()
+	return router, db
+}
+
+// setupTestRouterWithAudit is setupTestRouter also returning the audit service, which tests close to flush the audit log
+func setupTestRouterWithAudit() (*gin.Engine, *gorm.DB, services.AuditService) {
 	_ = os.Setenv("JWT_KEY", "this-is-a-very-long-secret-key-for-e2e-testing-purposes-32-chars")
 
 	// Set Gin to Test Mode
@@ -34,11 +49,22 @@ func setupTestRouter() (*gin.Engine, *gorm.DB) {
 	if err != nil {
 		panic("failed to connect to test database")
 	}
+	// Every connection to :memory: opens a separate database, so the audit writer must share the single connection
+	sqlDB, err := db.DB()
+	if err != nil {
+		panic("failed to get test database connection")
+	}
+	sqlDB.SetMaxOpenConns(1)
 
 	// Migrate the schema
 	err = db.AutoMigrate(
 		&models.User{},
+		&models.Role{},
 		&models.RefreshToken{},
+		&models.AuditLog{},
+		&models.Setting{},
+		&models.UserLoginIP{},
+		&models.UserPreference{},
 	)
 	if err != nil {
 		panic("failed to migrate test database")
@@ -48,7 +74,8 @@ func setupTestRouter() (*gin.Engine, *gorm.DB) {
 	utils.InitValidator()
 
 	// Setup Router
-	router := routes.SetupRouter(db)
+	auditService := services.NewAuditService(repositories.NewAuditLogRepository(db), 0)
+	router := routes.SetupRouter(db, services.NewMemoryRedisService(0), auditService, services.NewBackgroundService(time.Second))
 
-	return router, db
+	return router, db, auditService
 }
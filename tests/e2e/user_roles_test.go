@@ -0,0 +1,134 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"gorm.io/gorm"
+)
+
+func TestUserRoles(t *testing.T) {
+	router, db := setupTestRouter()
+
+	adminRole := models.Role{Name: constants.ROLE_ADMIN}
+	editorRole := models.Role{Name: "editor"}
+	require.NoError(t, db.Create(&adminRole).Error)
+	require.NoError(t, db.Create(&editorRole).Error)
+	admin := models.User{Name: "Admin", Email: "roles_admin@example.com", Password: "password", Gender: 1, Roles: []models.Role{adminRole}}
+	member := models.User{Name: "Member", Email: "roles_member@example.com", Password: "password", Gender: 1}
+	for _, user := range []*models.User{&admin, &member} {
+		require.NoError(t, db.Create(user).Error)
+	}
+
+	jwtService, err := services.NewJWTService()
+	require.NoError(t, err)
+	adminToken, err := jwtService.GenerateAccessToken(admin.ID)
+	require.NoError(t, err)
+	memberToken, err := jwtService.GenerateAccessToken(member.ID)
+	require.NoError(t, err)
+
+	call := func(method, path, token string, payload any) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			require.NoError(t, json.NewEncoder(&body).Encode(payload))
+		}
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, &body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(w, req)
+		return w
+	}
+	rolesPath := fmt.Sprintf("/api/v1/users/%d/roles", member.ID)
+
+	t.Run("UserRoles - Permissions Follow Assignment", func(t *testing.T) {
+		// The member's roles are cached by the first permission check
+		assert.Equal(t, http.StatusForbidden, call("GET", "/api/v1/settings", memberToken.Token, nil).Code)
+
+		w := call("POST", rolesPath, adminToken.Token, map[string]any{"role_ids": []uint{adminRole.ID, editorRole.ID}})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, int64(2), db.Model(&member).Association("Roles").Count())
+		assert.Equal(t, http.StatusOK, call("GET", "/api/v1/settings", memberToken.Token, nil).Code)
+
+		w = call("DELETE", rolesPath, adminToken.Token, map[string]any{"role_ids": []uint{adminRole.ID}})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, int64(1), db.Model(&member).Association("Roles").Count())
+		assert.Equal(t, http.StatusForbidden, call("GET", "/api/v1/settings", memberToken.Token, nil).Code)
+	})
+
+	t.Run("UserRoles - List Roles With Permissions", func(t *testing.T) {
+		w := call("GET", rolesPath, adminToken.Token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response struct {
+			Data []dto.UserRoleResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Data, 1)
+		assert.Equal(t, "editor", response.Data[0].Name)
+		assert.Empty(t, response.Data[0].Permissions)
+
+		w = call("GET", fmt.Sprintf("/api/v1/users/%d/roles", admin.ID), adminToken.Token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), constants.PERMISSION_USERS_ROLES)
+
+		w = call("GET", "/api/v1/users/999/roles", adminToken.Token, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("UserRoles - Missing Roles Change Nothing", func(t *testing.T) {
+		w := call("POST", rolesPath, adminToken.Token, map[string]any{"role_ids": []uint{adminRole.ID, 999}})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "999")
+		assert.Equal(t, int64(1), db.Model(&member).Association("Roles").Count())
+	})
+
+	t.Run("UserRoles - Create User With Roles", func(t *testing.T) {
+		newUser := func(email string, roleIDs ...uint) map[string]any {
+			return map[string]any{"email": email, "password": "Str0ng!Passw0rd", "name": "New User", "birthday": "1990-01-01", "address": "123 Main Street", "gender": 1, "role_ids": roleIDs}
+		}
+
+		w := call("POST", "/api/v1/users", adminToken.Token, newUser("roles_created@example.com", editorRole.ID))
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var created models.User
+		require.NoError(t, db.Preload("Roles").Where("email = ?", "roles_created@example.com").First(&created).Error)
+		require.Len(t, created.Roles, 1)
+		assert.Equal(t, "editor", created.Roles[0].Name)
+
+		w = call("POST", "/api/v1/users", adminToken.Token, newUser("roles_rejected@example.com", editorRole.ID, 42))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, "Role 42 does not exist", errResp.Message)
+		assert.ErrorIs(t, db.Where("email = ?", "roles_rejected@example.com").First(&models.User{}).Error, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("UserRoles - Unknown User", func(t *testing.T) {
+		w := call("POST", "/api/v1/users/999/roles", adminToken.Token, map[string]any{"role_ids": []uint{editorRole.ID}})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("UserRoles - Member Without Roles", func(t *testing.T) {
+		other := models.User{Name: "Other", Email: "roles_other@example.com", Password: "password", Gender: 1}
+		require.NoError(t, db.Create(&other).Error)
+
+		w := call("GET", fmt.Sprintf("/api/v1/users/%d/roles", other.ID), adminToken.Token, nil)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"data":[]}`, w.Body.String())
+	})
+
+	t.Run("UserRoles - Require Permission", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, call("POST", rolesPath, memberToken.Token, map[string]any{"role_ids": []uint{adminRole.ID}}).Code)
+		assert.Equal(t, http.StatusForbidden, call("DELETE", rolesPath, memberToken.Token, map[string]any{"role_ids": []uint{editorRole.ID}}).Code)
+	})
+}
@@ -0,0 +1,129 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/audit"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+)
+
+func TestUserActivity(t *testing.T) {
+	router, db, auditService := setupTestRouterWithAudit()
+
+	adminRole := models.Role{Name: constants.ROLE_ADMIN}
+	require.NoError(t, db.Create(&adminRole).Error)
+
+	verifiedAt := time.Now()
+	password := "Passw0rd123"
+	admin := models.User{Name: "Admin", Email: "activity_admin@example.com", Password: "password", Gender: 1, Roles: []models.Role{adminRole}}
+	member := models.User{Name: "Member", Email: "activity_member@example.com", Password: utils.HashPassword(password), Gender: 1, VerifiedAt: &verifiedAt}
+	for _, user := range []*models.User{&admin, &member} {
+		require.NoError(t, db.Create(user).Error)
+	}
+
+	jwtService, err := services.NewJWTService()
+	require.NoError(t, err)
+	adminToken, err := jwtService.GenerateAccessToken(admin.ID)
+	require.NoError(t, err)
+
+	call := func(method, path, token string, payload any) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			require.NoError(t, json.NewEncoder(&body).Encode(payload))
+		}
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, &body)
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "192.0.2.1:1234"
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+	activityPath := func(id uint) string {
+		return "/api/v1/users/" + strconv.FormatUint(uint64(id), 10) + "/activity"
+	}
+
+	// The member logs in twice and renames themself, three audited actions and two sessions
+	var memberToken string
+	for range 2 {
+		w := call("POST", "/api/v1/login", "", map[string]string{"email": member.Email, "password": password})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var login dto.LoginResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &login))
+		memberToken = login.AccessToken.Token
+	}
+	require.Equal(t, http.StatusOK, call("PATCH", "/api/v1/profile", memberToken, map[string]string{"name": "Renamed"}).Code)
+	// Closing flushes the queued entries
+	require.NoError(t, auditService.Close())
+
+	t.Run("User Activity - Success", func(t *testing.T) {
+		w := call("GET", activityPath(member.ID)+"?limit=2", adminToken.Token, nil)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var activity dto.UserActivityResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &activity))
+		assert.Equal(t, member.ID, activity.UserID)
+		require.NotNil(t, activity.LastLoginAt)
+		assert.WithinDuration(t, time.Now(), *activity.LastLoginAt, time.Minute)
+		require.NotNil(t, activity.LastLoginIP)
+		assert.Equal(t, "192.0.2.1", *activity.LastLoginIP)
+		assert.Equal(t, 2, activity.ActiveSessions)
+		// Newest first, paged
+		require.NotNil(t, activity.RecentActivity)
+		assert.Equal(t, 3, activity.RecentActivity.TotalItems)
+		require.Len(t, activity.RecentActivity.Data, 2)
+		assert.Equal(t, audit.ActionProfileUpdated, activity.RecentActivity.Data[0].Action)
+		assert.True(t, activity.RecentActivity.HasNext)
+		assert.Contains(t, activity.RecentActivity.Next, "page=2")
+
+		w = call("GET", activityPath(member.ID)+"?limit=2&page=2", adminToken.Token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &activity))
+		require.Len(t, activity.RecentActivity.Data, 1)
+		assert.Equal(t, audit.ActionLogin, activity.RecentActivity.Data[0].Action)
+	})
+
+	t.Run("User Activity - Never Logged In", func(t *testing.T) {
+		w := call("GET", activityPath(admin.ID), adminToken.Token, nil)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var activity dto.UserActivityResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &activity))
+		assert.Nil(t, activity.LastLoginAt)
+		assert.Nil(t, activity.LastLoginIP)
+		assert.Equal(t, 0, activity.ActiveSessions)
+		assert.Equal(t, 0, activity.RecentActivity.TotalItems)
+	})
+
+	t.Run("User Activity - Unknown User", func(t *testing.T) {
+		w := call("GET", activityPath(9999), adminToken.Token, nil)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("User Activity - Forbidden For Members", func(t *testing.T) {
+		w := call("GET", activityPath(member.ID), memberToken, nil)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("User Activity - Unauthorized", func(t *testing.T) {
+		w := call("GET", activityPath(member.ID), "", nil)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
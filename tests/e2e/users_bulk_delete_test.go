@@ -0,0 +1,90 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+func TestUsersBulkDelete(t *testing.T) {
+	router, db := setupTestRouter()
+
+	adminRole := models.Role{Name: constants.ROLE_ADMIN}
+	require.NoError(t, db.Create(&adminRole).Error)
+
+	admin := models.User{Name: "Admin", Email: "admin@example.com", Password: "password", Gender: 1, Roles: []models.Role{adminRole}}
+	spam1 := models.User{Name: "Spam 1", Email: "spam1@example.com", Password: "password", Gender: 1}
+	spam2 := models.User{Name: "Spam 2", Email: "spam2@example.com", Password: "password", Gender: 2}
+	for _, user := range []*models.User{&admin, &spam1, &spam2} {
+		require.NoError(t, db.Create(user).Error)
+	}
+
+	jwtService, err := services.NewJWTService()
+	require.NoError(t, err)
+	adminToken, err := jwtService.GenerateAccessToken(admin.ID)
+	require.NoError(t, err)
+	memberToken, err := jwtService.GenerateAccessToken(spam1.ID)
+	require.NoError(t, err)
+
+	bulkDelete := func(token string, ids []uint) *httptest.ResponseRecorder {
+		payloadBytes, _ := json.Marshal(map[string]any{"ids": ids})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/users/bulk-delete", bytes.NewBuffer(payloadBytes))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Bulk Delete - Forbidden For Non Admin", func(t *testing.T) {
+		w := bulkDelete(memberToken.Token, []uint{spam2.ID})
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, apperror.ErrForbidden, errResp.Code)
+	})
+
+	t.Run("Bulk Delete - Empty IDs", func(t *testing.T) {
+		w := bulkDelete(adminToken.Token, []uint{})
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Bulk Delete - Success With Missing IDs", func(t *testing.T) {
+		w := bulkDelete(adminToken.Token, []uint{spam1.ID, spam2.ID, 9999})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var result dto.BulkDeleteUsersResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Equal(t, []uint{spam1.ID, spam2.ID}, result.Deleted)
+		assert.Equal(t, []uint{9999}, result.NotFound)
+
+		var remaining int64
+		db.Model(&models.User{}).Where("id IN ?", []uint{spam1.ID, spam2.ID}).Count(&remaining)
+		assert.Equal(t, int64(0), remaining)
+	})
+
+	t.Run("Bulk Delete - Already Deleted Reported As Not Found", func(t *testing.T) {
+		w := bulkDelete(adminToken.Token, []uint{spam1.ID})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var result dto.BulkDeleteUsersResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Empty(t, result.Deleted)
+		assert.Equal(t, []uint{spam1.ID}, result.NotFound)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -39,10 +40,13 @@ func TestUsersChangePassword(t *testing.T) {
 	accessToken := tokenResult.Token
 
 	t.Run("Change Password - Success", func(t *testing.T) {
+		session := models.RefreshToken{RefreshToken: "change_password_session", IpAddress: "127.0.0.1", ExpiredAt: time.Now().Add(time.Hour).Unix(), UserID: testUser.ID}
+		require.NoError(t, db.Omit("User").Create(&session).Error)
+
 		payload := map[string]string{
 			"old_password":     password,
-			"new_password":     "newpassword123",
-			"confirm_password": "newpassword123",
+			"new_password":     "NewPassw0rd123",
+			"confirm_password": "NewPassw0rd123",
 		}
 		payloadBytes, _ := json.Marshal(payload)
 
@@ -64,14 +68,19 @@ func TestUsersChangePassword(t *testing.T) {
 		var updatedUser models.User
 		db.First(&updatedUser, testUser.ID)
 		bcryptService := services.NewBcryptService()
-		assert.True(t, bcryptService.CheckPasswordHash("newpassword123", updatedUser.Password))
+		assert.True(t, bcryptService.CheckPasswordHash("NewPassw0rd123", updatedUser.Password))
+
+		// Verify existing sessions were revoked
+		var count int64
+		db.Model(&models.RefreshToken{}).Where("user_id = ?", testUser.ID).Count(&count)
+		assert.Equal(t, int64(0), count)
 	})
 
 	t.Run("Change Password - Incorrect Old Password", func(t *testing.T) {
 		payload := map[string]string{
 			"old_password":     "wrongpassword",
-			"new_password":     "newpassword456",
-			"confirm_password": "newpassword456",
+			"new_password":     "NewPassw0rd456",
+			"confirm_password": "NewPassw0rd456",
 		}
 		payloadBytes, _ := json.Marshal(payload)
 
@@ -92,9 +101,9 @@ func TestUsersChangePassword(t *testing.T) {
 
 	t.Run("Change Password - New Password Same as Old", func(t *testing.T) {
 		payload := map[string]string{
-			"old_password":     "newpassword123",
-			"new_password":     "newpassword123",
-			"confirm_password": "newpassword123",
+			"old_password":     "NewPassw0rd123",
+			"new_password":     "NewPassw0rd123",
+			"confirm_password": "NewPassw0rd123",
 		}
 		payloadBytes, _ := json.Marshal(payload)
 
@@ -115,8 +124,8 @@ func TestUsersChangePassword(t *testing.T) {
 
 	t.Run("Change Password - Password Mismatch", func(t *testing.T) {
 		payload := map[string]string{
-			"old_password":     "newpassword123",
-			"new_password":     "newpassword456",
+			"old_password":     "NewPassw0rd123",
+			"new_password":     "NewPassw0rd456",
 			"confirm_password": "differentpassword",
 		}
 		payloadBytes, _ := json.Marshal(payload)
@@ -138,8 +147,8 @@ func TestUsersChangePassword(t *testing.T) {
 
 	t.Run("Change Password - Missing Fields", func(t *testing.T) {
 		payload := map[string]string{
-			"old_password": "newpassword123",
-			"new_password": "newpassword789",
+			"old_password": "NewPassw0rd123",
+			"new_password": "NewPassw0rd789",
 		}
 		payloadBytes, _ := json.Marshal(payload)
 
@@ -160,7 +169,7 @@ func TestUsersChangePassword(t *testing.T) {
 
 	t.Run("Change Password - Password Too Short", func(t *testing.T) {
 		payload := map[string]string{
-			"old_password":     "newpassword123",
+			"old_password":     "NewPassw0rd123",
 			"new_password":     "12345",
 			"confirm_password": "12345",
 		}
@@ -181,11 +190,54 @@ func TestUsersChangePassword(t *testing.T) {
 		assert.Equal(t, apperror.ErrValidationFailed, errResp.Code)
 	})
 
+	changePassword := func(newPassword string) *httptest.ResponseRecorder {
+		payloadBytes, _ := json.Marshal(map[string]string{
+			"old_password":     "NewPassw0rd123",
+			"new_password":     newPassword,
+			"confirm_password": newPassword,
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/change-password", bytes.NewBuffer(payloadBytes))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	fieldMessages := func(t *testing.T, w *httptest.ResponseRecorder) []string {
+		var resp struct {
+			Code   int                   `json:"code"`
+			Fields []apperror.FieldError `json:"fields"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, apperror.ErrValidationFailed, resp.Code)
+		messages := make([]string, 0, len(resp.Fields))
+		for _, field := range resp.Fields {
+			messages = append(messages, field.Message)
+		}
+		return messages
+	}
+
+	t.Run("Change Password - Missing Character Classes", func(t *testing.T) {
+		w := changePassword("lowercaseonly")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, fieldMessages(t, w), "new_password must contain an uppercase letter and a digit")
+	})
+
+	t.Run("Change Password - Contains Email Local Part", func(t *testing.T) {
+		w := changePassword("TestUser2024")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, []string{"new_password must not contain your email address"}, fieldMessages(t, w))
+	})
+
 	t.Run("Change Password - Unauthorized without Token", func(t *testing.T) {
 		payload := map[string]string{
-			"old_password":     "newpassword123",
-			"new_password":     "anotherpassword",
-			"confirm_password": "anotherpassword",
+			"old_password":     "NewPassw0rd123",
+			"new_password":     "An0therPassw0rd",
+			"confirm_password": "An0therPassw0rd",
 		}
 		payloadBytes, _ := json.Marshal(payload)
 
@@ -0,0 +1,108 @@
+package e2e
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+)
+
+func TestUsersExport(t *testing.T) {
+	router, db := setupTestRouter()
+
+	adminRole := models.Role{Name: constants.ROLE_ADMIN}
+	require.NoError(t, db.Create(&adminRole).Error)
+
+	address := `1 "Quoted" Road, Apt 2`
+	users := []models.User{
+		{Name: "Admin", Email: "export_admin@example.com", Password: "hashed-password", Gender: 1, Roles: []models.Role{adminRole}},
+		{Name: "Alice", Email: "export_alice@example.com", Password: "hashed-password", Gender: 2, Address: &address},
+		{Name: "Bob", Email: "export_bob@example.com", Password: "hashed-password", Gender: 1},
+	}
+	for i := range users {
+		require.NoError(t, db.Create(&users[i]).Error)
+	}
+
+	jwtService, err := services.NewJWTService()
+	require.NoError(t, err)
+	adminToken, err := jwtService.GenerateAccessToken(users[0].ID)
+	require.NoError(t, err)
+	memberToken, err := jwtService.GenerateAccessToken(users[1].ID)
+	require.NoError(t, err)
+
+	exportWithEncoding := func(query, token, acceptEncoding string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/users/export?"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+	export := func(query string, token string) *httptest.ResponseRecorder {
+		return exportWithEncoding(query, token, "")
+	}
+
+	t.Run("Export Users - CSV With Filters", func(t *testing.T) {
+		w := export("gender=2", adminToken.Token)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Header().Get("Content-Disposition"), `attachment; filename="users-`)
+		assert.NotContains(t, w.Body.String(), "hashed-password")
+		records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		assert.Equal(t, []string{"id", "name", "email", "gender", "birthday", "address", "created_at"}, records[0])
+		assert.Equal(t, "export_alice@example.com", records[1][2])
+		assert.Equal(t, address, records[1][5])
+	})
+
+	t.Run("Export Users - XLSX", func(t *testing.T) {
+		w := export("format=xlsx&search=export_bob", adminToken.Token)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		require.NoError(t, err)
+		var names []string
+		for _, file := range archive.File {
+			names = append(names, file.Name)
+		}
+		assert.Contains(t, names, "xl/worksheets/sheet1.xml")
+	})
+
+	t.Run("Export Users - CSV Is Gzipped", func(t *testing.T) {
+		w := exportWithEncoding("", adminToken.Token, "gzip")
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		reader, err := gzip.NewReader(w.Body)
+		require.NoError(t, err)
+		records, err := csv.NewReader(reader).ReadAll()
+		require.NoError(t, err)
+		assert.Len(t, records, 4)
+	})
+
+	t.Run("Export Users - XLSX Is Not Compressed Again", func(t *testing.T) {
+		w := exportWithEncoding("format=xlsx", adminToken.Token, "gzip")
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		_, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Export Users - Requires Permission", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, export("", memberToken.Token).Code)
+	})
+}
@@ -0,0 +1,120 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+func TestUsersForceResetPassword(t *testing.T) {
+	router, db := setupTestRouter()
+
+	adminRole := models.Role{Name: constants.ROLE_ADMIN}
+	require.NoError(t, db.Create(&adminRole).Error)
+
+	password := "password123"
+	verifiedAt := time.Now()
+	admin := models.User{Name: "Admin", Email: "admin@example.com", Password: "password", Gender: 1, Roles: []models.Role{adminRole}}
+	member := models.User{Name: "Member", Email: "member@example.com", Password: utils.HashPassword(password), Gender: 1, VerifiedAt: &verifiedAt}
+	for _, user := range []*models.User{&admin, &member} {
+		require.NoError(t, db.Create(user).Error)
+	}
+
+	jwtService, err := services.NewJWTService()
+	require.NoError(t, err)
+	adminToken, err := jwtService.GenerateAccessToken(admin.ID)
+	require.NoError(t, err)
+	memberToken, err := jwtService.GenerateAccessToken(member.ID)
+	require.NoError(t, err)
+
+	call := func(method, path, token string, payload any) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			require.NoError(t, json.NewEncoder(&body).Encode(payload))
+		}
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, &body)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+	forceResetPath := fmt.Sprintf("/api/v1/users/%d/force-reset-password", member.ID)
+
+	t.Run("Force Reset Password - Forbidden For Non Admin", func(t *testing.T) {
+		w := call("POST", forceResetPath, memberToken.Token, nil)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, apperror.ErrForbidden, errResp.Code)
+	})
+
+	t.Run("Force Reset Password - Not Found", func(t *testing.T) {
+		w := call("POST", "/api/v1/users/9999/force-reset-password", adminToken.Token, nil)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Force Reset Password - Requires Password Change", func(t *testing.T) {
+		session := models.RefreshToken{RefreshToken: "force_reset_session", IpAddress: "127.0.0.1", ExpiredAt: time.Now().Add(time.Hour).Unix(), UserID: member.ID}
+		require.NoError(t, db.Omit("User").Create(&session).Error)
+
+		w := call("POST", forceResetPath, adminToken.Token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resetResp map[string]string
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resetResp))
+		temporaryPassword := resetResp["temporary_password"]
+		assert.Len(t, temporaryPassword, services.TEMPORARY_PASSWORD_LENGTH)
+
+		var count int64
+		db.Model(&models.RefreshToken{}).Where("user_id = ?", member.ID).Count(&count)
+		assert.Equal(t, int64(0), count)
+
+		// The old password no longer works
+		w = call("POST", "/api/v1/login", "", map[string]string{"email": member.Email, "password": password})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = call("POST", "/api/v1/login", "", map[string]string{"email": member.Email, "password": temporaryPassword})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var loginResp dto.LoginResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &loginResp))
+		assert.True(t, loginResp.MustChangePassword)
+		accessToken := loginResp.AccessToken.Token
+
+		w = call("GET", "/api/v1/profile", accessToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, apperror.ErrPasswordChangeRequired, errResp.Code)
+
+		w = call("POST", "/api/v1/change-password", accessToken, map[string]string{
+			"old_password":     temporaryPassword,
+			"new_password":     "NewPassw0rd123",
+			"confirm_password": "NewPassw0rd123",
+		})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = call("GET", "/api/v1/profile", accessToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/vfa-khuongdv/golang-cms/internal/models"
 	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
 	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
 )
 
@@ -50,18 +51,77 @@ func TestUsersGetProfile(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, w.Code)
 
-		var response models.User
+		var response dto.ProfileResponse
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		require.NoError(t, err)
 		assert.Equal(t, testUser.ID, response.ID)
 		assert.Equal(t, testUser.Email, response.Email)
 		assert.Equal(t, testUser.Name, response.Name)
 
-		assert.Equal(t, birthday.Format("2006-01-02"), response.Birthday.Format("2006-01-02"))
+		require.NotNil(t, response.Birthday)
+		assert.Equal(t, birthday.Format("2006-01-02"), *response.Birthday)
+		assert.NotContains(t, w.Body.String(), `"password"`)
 		assert.Equal(t, address, *response.Address)
 		assert.Equal(t, int16(1), response.Gender)
 	})
 
+	t.Run("Get Profile - Includes Assigned Roles", func(t *testing.T) {
+		roleUser := models.User{
+			Name:     "Role User",
+			Email:    "roleuser@example.com",
+			Password: hashedPassword,
+			Gender:   2,
+			Roles:    []models.Role{{Name: "admin"}, {Name: "editor"}},
+		}
+		require.NoError(t, db.Create(&roleUser).Error)
+		roleToken, err := jwtService.GenerateAccessToken(roleUser.ID)
+		require.NoError(t, err)
+
+		// The second request is served from the profile cache and must keep the roles
+		for _, source := range []string{"database", "cache"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/api/v1/profile", nil)
+			req.Header.Set("Authorization", "Bearer "+roleToken.Token)
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code, source)
+
+			var response dto.ProfileResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			roleNames := make([]string, 0, len(response.Roles))
+			for _, role := range response.Roles {
+				roleNames = append(roleNames, role.Name)
+			}
+			assert.ElementsMatch(t, []string{"admin", "editor"}, roleNames, source)
+		}
+	})
+
+	t.Run("Get Me - Same As Profile", func(t *testing.T) {
+		get := func(path string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", path, nil)
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		profile := get("/api/v1/profile")
+		me := get("/api/v1/me")
+
+		assert.Equal(t, http.StatusOK, me.Code)
+		assert.JSONEq(t, profile.Body.String(), me.Body.String())
+	})
+
+	t.Run("Get Me - Unauthorized without Token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/me", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
 	t.Run("Get Profile - Unauthorized without Token", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", "/api/v1/profile", nil)
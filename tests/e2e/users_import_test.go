@@ -0,0 +1,110 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+)
+
+func TestUsersImport(t *testing.T) {
+	t.Setenv("USER_IMPORT_MAX_BYTES", "2048")
+	router, db := setupTestRouter()
+
+	adminRole := models.Role{Name: constants.ROLE_ADMIN}
+	require.NoError(t, db.Create(&adminRole).Error)
+	admin := models.User{Name: "Admin", Email: "import_admin@example.com", Password: "password", Gender: 1, Roles: []models.Role{adminRole}}
+	require.NoError(t, db.Create(&admin).Error)
+
+	jwtService, err := services.NewJWTService()
+	require.NoError(t, err)
+	adminToken, err := jwtService.GenerateAccessToken(admin.ID)
+	require.NoError(t, err)
+
+	upload := func(query, content string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("file", "users.csv")
+		require.NoError(t, err)
+		_, _ = part.Write([]byte(content))
+		require.NoError(t, form.Close())
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/users/import?"+query, &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+adminToken.Token)
+		router.ServeHTTP(w, req)
+		return w
+	}
+	countUsers := func() int64 {
+		var count int64
+		require.NoError(t, db.Model(&models.User{}).Count(&count).Error)
+		return count
+	}
+
+	file := "\ufeffemail,password,name,birthday,address,gender\n" +
+		"new_one@example.com,Str0ng!Passw0rd#2024,New One,1990-01-02,\"1 Main St, Apt 2\",1\n" +
+		"import_admin@example.com,Str0ng!Passw0rd#2024,Admin Again,1990-01-02,Street,1\n" +
+		"bad-email,Str0ng!Passw0rd#2024,Bad,1990-01-02,Street,1\n" +
+		"NEW_ONE@example.com,Str0ng!Passw0rd#2024,Repeated,1990-01-02,Street,2\n"
+
+	t.Run("Import Users - Dry Run Writes Nothing", func(t *testing.T) {
+		w := upload("dry_run=true", file)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var report dto.UserImportReport
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		assert.True(t, report.DryRun)
+		assert.Equal(t, 4, report.Rows)
+		assert.Equal(t, 1, report.Imported)
+		reasons := map[int]string{}
+		for _, skipped := range report.Skipped {
+			reasons[skipped.Row] = skipped.Reason
+		}
+		assert.Equal(t, map[int]string{3: "already_registered", 4: "invalid", 5: "duplicate_in_file"}, reasons)
+		assert.Equal(t, int64(1), countUsers())
+	})
+
+	t.Run("Import Users - Creates Valid Rows", func(t *testing.T) {
+		w := upload("", file)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var report dto.UserImportReport
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		assert.Equal(t, 1, report.Imported)
+		assert.Len(t, report.Skipped, 3)
+
+		var user models.User
+		require.NoError(t, db.Where("email = ?", "new_one@example.com").First(&user).Error)
+		assert.Equal(t, "1 Main St, Apt 2", *user.Address)
+		assert.NotEqual(t, "Str0ng!Passw0rd#2024", user.Password)
+
+		// Importing the same file again finds the user already registered
+		w = upload("", file)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		assert.Equal(t, 0, report.Imported)
+	})
+
+	t.Run("Import Users - Malformed CSV", func(t *testing.T) {
+		w := upload("", "email,password,name,birthday,address,gender\n\"unterminated\n")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Import Users - File Too Large", func(t *testing.T) {
+		w := upload("", "email,password,name,birthday,address,gender\n"+strings.Repeat("x", 4096))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Equal(t, int64(2), countUsers())
+	})
+}
@@ -0,0 +1,217 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+func TestUsersList(t *testing.T) {
+	router, db := setupTestRouter()
+
+	adminRole := models.Role{Name: constants.ROLE_ADMIN}
+	require.NoError(t, db.Create(&adminRole).Error)
+
+	users := []models.User{
+		{Name: "Bob Smith", Email: "bob@example.com", Password: "password", Gender: 1, Roles: []models.Role{adminRole}},
+		{Name: "Alice", Email: "alice@bobcat.io", Password: "password", Gender: 2},
+		{Name: "Carol", Email: "carol@example.com", Password: "password", Gender: 1},
+		{Name: "Bobby", Email: "bobby@example.com", Password: "password", Gender: 2},
+	}
+	for i := range users {
+		require.NoError(t, db.Create(&users[i]).Error)
+	}
+
+	jwtService, err := services.NewJWTService()
+	require.NoError(t, err)
+	tokenResult, err := jwtService.GenerateAccessToken(users[0].ID)
+	require.NoError(t, err)
+	accessToken := tokenResult.Token
+	memberTokenResult, err := jwtService.GenerateAccessToken(users[2].ID)
+	require.NoError(t, err)
+	memberAccessToken := memberTokenResult.Token
+
+	listUsers := func(query string, token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/users?"+query, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	names := func(t *testing.T, w *httptest.ResponseRecorder) []string {
+		var response dto.Pagination[dto.UserResponse]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		result := make([]string, 0, len(response.Data))
+		for _, user := range response.Data {
+			result = append(result, user.Name)
+		}
+		return result
+	}
+
+	t.Run("List Users - Default Options", func(t *testing.T) {
+		w := listUsers("", accessToken)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response dto.Pagination[dto.UserResponse]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Page)
+		assert.Equal(t, 10, response.Limit)
+		assert.Equal(t, 4, response.TotalItems)
+		assert.Equal(t, []string{"Bobby", "Carol", "Alice", "Bob Smith"}, names(t, w))
+	})
+
+	t.Run("List Users - Filter Combinations", func(t *testing.T) {
+		tests := []struct {
+			query    string
+			expected []string
+		}{
+			{"search=bob&sort=name:asc", []string{"Alice", "Bob Smith", "Bobby"}},
+			{"gender=1&sort=name:asc", []string{"Bob Smith", "Carol"}},
+			{"gender=1&sort=-name", []string{"Carol", "Bob Smith"}},
+			{"search=BOB&sort=email", []string{"Alice", "Bob Smith", "Bobby"}},
+			{"gender=2&search=bob&sort=email:desc", []string{"Bobby", "Alice"}},
+			{"search=bob&sort_by=name&sort_dir=desc&page=2&limit=2", []string{"Alice"}},
+			{"search=nobody", []string{}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.query, func(t *testing.T) {
+				w := listUsers(tt.query, accessToken)
+
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, tt.expected, names(t, w))
+			})
+		}
+	})
+
+	t.Run("List Users - Invalid Params", func(t *testing.T) {
+		for _, query := range []string{
+			"sort=password:asc",
+			"sort=name%3BDROP%20TABLE%20users:asc",
+			"sort=name:sideways",
+			"sort=-password",
+			"page=-1",
+			"page=abc",
+			"limit=0",
+			"cursor=not-a-cursor",
+			"cursor=&page=2",
+		} {
+			t.Run(query, func(t *testing.T) {
+				w := listUsers(query, accessToken)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+
+				var errResp ErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+				assert.Equal(t, apperror.ErrParseError, errResp.Code)
+			})
+		}
+
+		var count int64
+		db.Model(&models.User{}).Count(&count)
+		assert.Equal(t, int64(4), count)
+	})
+
+	t.Run("List Users - Page Links", func(t *testing.T) {
+		w := listUsers("sort=name:asc&limit=1&page=2", accessToken)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response dto.Pagination[dto.UserResponse]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response.HasPrev)
+		assert.True(t, response.HasNext)
+		assert.Equal(t, "/api/v1/users?limit=1&page=3&sort=name%3Aasc", response.Next)
+		assert.Equal(t, "/api/v1/users?limit=1&page=1&sort=name%3Aasc", response.Prev)
+	})
+
+	t.Run("List Users - Cursor Follows Next Links", func(t *testing.T) {
+		var visited []string
+		next := "/api/v1/users?cursor=&limit=3&sort=name:asc"
+		for next != "" {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", next, nil)
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+			router.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var response dto.Pagination[dto.UserResponse]
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Zero(t, response.Page)
+			assert.Equal(t, 4, response.TotalItems)
+			visited = append(visited, names(t, w)...)
+			next = response.Next
+
+			// The next cursor is always present, null on the last page
+			var raw map[string]any
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &raw))
+			require.Contains(t, raw, "next_cursor")
+			if next == "" {
+				assert.Nil(t, raw["next_cursor"])
+			} else {
+				assert.NotEmpty(t, raw["next_cursor"])
+			}
+		}
+
+		assert.Equal(t, []string{"Alice", "Bob Smith", "Bobby", "Carol"}, visited)
+	})
+
+	t.Run("List Users - Limit Is Capped", func(t *testing.T) {
+		w := listUsers("limit=1000", accessToken)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response dto.Pagination[dto.UserResponse]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 100, response.Limit)
+	})
+
+	t.Run("List Users - Invalid Gender", func(t *testing.T) {
+		w := listUsers("gender=9", accessToken)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("List Users - Include Deleted", func(t *testing.T) {
+		deleted := models.User{Name: "Dave", Email: "dave@example.com", Password: "password", Gender: 1}
+		require.NoError(t, db.Create(&deleted).Error)
+		require.NoError(t, db.Delete(&deleted).Error)
+
+		w := listUsers("sort=name:asc", accessToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, names(t, w), "Dave")
+
+		w = listUsers("sort=name:asc&include_deleted=true", accessToken)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, names(t, w), "Dave")
+	})
+
+	t.Run("List Users - Unauthorized", func(t *testing.T) {
+		w := listUsers("", "")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("List Users - Forbidden For Non Admin", func(t *testing.T) {
+		w := listUsers("", memberAccessToken)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, apperror.ErrForbidden, errResp.Code)
+	})
+}
@@ -0,0 +1,123 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+func TestUsersRestore(t *testing.T) {
+	router, db := setupTestRouter()
+
+	adminRole := models.Role{Name: constants.ROLE_ADMIN}
+	require.NoError(t, db.Create(&adminRole).Error)
+
+	admin := models.User{Name: "Admin", Email: "admin@example.com", Password: "password", Gender: 1, Roles: []models.Role{adminRole}}
+	member := models.User{Name: "Member", Email: "member@example.com", Password: "password", Gender: 1}
+	deleted := models.User{Name: "Deleted", Email: "deleted@example.com", Password: "password", Gender: 2}
+	for _, user := range []*models.User{&admin, &member, &deleted} {
+		require.NoError(t, db.Create(user).Error)
+	}
+	require.NoError(t, db.Delete(&deleted).Error)
+
+	jwtService, err := services.NewJWTService()
+	require.NoError(t, err)
+	adminToken, err := jwtService.GenerateAccessToken(admin.ID)
+	require.NoError(t, err)
+	memberToken, err := jwtService.GenerateAccessToken(member.ID)
+	require.NoError(t, err)
+
+	call := func(method, path, token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Get User - Deleted", func(t *testing.T) {
+		w := call("GET", fmt.Sprintf("/api/v1/users/%d", deleted.ID), adminToken.Token)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, "User has been deleted", errResp.Message)
+	})
+
+	t.Run("Get User - Include Deleted", func(t *testing.T) {
+		w := call("GET", fmt.Sprintf("/api/v1/users/%d?include_deleted=true", deleted.ID), adminToken.Token)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, float64(deleted.ID), response["id"])
+		assert.NotNil(t, response["deleted_at"])
+	})
+
+	t.Run("Get User - Not Found", func(t *testing.T) {
+		w := call("GET", "/api/v1/users/9999", adminToken.Token)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, "User not found", errResp.Message)
+	})
+
+	t.Run("Restore User - Forbidden For Non Admin", func(t *testing.T) {
+		w := call("POST", fmt.Sprintf("/api/v1/users/%d/restore", deleted.ID), memberToken.Token)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, apperror.ErrForbidden, errResp.Code)
+	})
+
+	t.Run("Restore User - Success", func(t *testing.T) {
+		w := call("POST", fmt.Sprintf("/api/v1/users/%d/restore", deleted.ID), adminToken.Token)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var restored models.User
+		require.NoError(t, db.First(&restored, deleted.ID).Error)
+		assert.False(t, restored.DeletedAt.Valid)
+
+		w = call("GET", fmt.Sprintf("/api/v1/users/%d", deleted.ID), adminToken.Token)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Restore User - Not Deleted Is No-op", func(t *testing.T) {
+		w := call("POST", fmt.Sprintf("/api/v1/users/%d/restore", member.ID), adminToken.Token)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response dto.UserResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, member.ID, response.ID)
+	})
+
+	t.Run("Restore User - Not Found", func(t *testing.T) {
+		w := call("POST", "/api/v1/users/9999/restore", adminToken.Token)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Restore User - Invalid ID", func(t *testing.T) {
+		w := call("POST", "/api/v1/users/abc/restore", adminToken.Token)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,87 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+func TestUsersSearch(t *testing.T) {
+	router, db := setupTestRouter()
+
+	adminRole := models.Role{Name: constants.ROLE_ADMIN}
+	require.NoError(t, db.Create(&adminRole).Error)
+
+	users := []models.User{
+		{Name: "Admin", Email: "admin@example.com", Password: "password", Gender: 1, Roles: []models.Role{adminRole}},
+		{Name: "Malcolm", Email: "malcolm@example.com", Password: "password", Gender: 1},
+		{Name: "Alice", Email: "alice@example.com", Password: "password", Gender: 2},
+	}
+	for i := range users {
+		require.NoError(t, db.Create(&users[i]).Error)
+	}
+
+	jwtService, err := services.NewJWTService()
+	require.NoError(t, err)
+	adminToken, err := jwtService.GenerateAccessToken(users[0].ID)
+	require.NoError(t, err)
+	memberToken, err := jwtService.GenerateAccessToken(users[1].ID)
+	require.NoError(t, err)
+
+	search := func(query string, token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/users/search?"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Search Users - Relevance Order", func(t *testing.T) {
+		w := search("q=al", adminToken.Token)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Data []dto.UserSearchResult `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Data, 2)
+		// The email prefix match ranks above the name match
+		assert.Equal(t, "Alice", response.Data[0].Name)
+		assert.Equal(t, "Malcolm", response.Data[1].Name)
+		assert.NotContains(t, w.Body.String(), "password")
+	})
+
+	t.Run("Search Users - Does Not Clash With User Lookup", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/users/%d", users[2].ID), nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken.Token)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Search Users - Query Too Short", func(t *testing.T) {
+		w := search("q=a", adminToken.Token)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Search Users - Forbidden For Non Admin", func(t *testing.T) {
+		w := search("q=al", memberToken.Token)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, apperror.ErrForbidden, errResp.Code)
+	})
+}
@@ -0,0 +1,98 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/audit"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/constants"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/utils"
+	"github.com/vfa-khuongdv/golang-cms/pkg/apperror"
+)
+
+func TestUsersUnlock(t *testing.T) {
+	router, db, auditService := setupTestRouterWithAudit()
+	// Closing first makes every later entry be written synchronously
+	require.NoError(t, auditService.Close())
+
+	adminRole := models.Role{Name: constants.ROLE_ADMIN}
+	require.NoError(t, db.Create(&adminRole).Error)
+
+	password := "password123"
+	verifiedAt := time.Now()
+	admin := models.User{Name: "Admin", Email: "unlock_admin@example.com", Password: "password", Gender: 1, Roles: []models.Role{adminRole}}
+	member := models.User{Name: "Member", Email: "unlock_member@example.com", Password: utils.HashPassword(password), Gender: 1, VerifiedAt: &verifiedAt}
+	for _, user := range []*models.User{&admin, &member} {
+		require.NoError(t, db.Create(user).Error)
+	}
+
+	jwtService, err := services.NewJWTService()
+	require.NoError(t, err)
+	adminToken, err := jwtService.GenerateAccessToken(admin.ID)
+	require.NoError(t, err)
+	memberToken, err := jwtService.GenerateAccessToken(member.ID)
+	require.NoError(t, err)
+
+	call := func(method, path, token string, payload any) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			require.NoError(t, json.NewEncoder(&body).Encode(payload))
+		}
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, &body)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+	login := func(password string) *httptest.ResponseRecorder {
+		return call("POST", "/api/v1/login", "", map[string]string{"email": member.Email, "password": password})
+	}
+	unlockPath := fmt.Sprintf("/api/v1/users/%d/unlock", member.ID)
+
+	t.Run("Unlock - Forbidden For Non Admin", func(t *testing.T) {
+		w := call("POST", unlockPath, memberToken.Token, nil)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		assert.Equal(t, apperror.ErrForbidden, errResp.Code)
+	})
+
+	t.Run("Unlock - Not Found", func(t *testing.T) {
+		w := call("POST", "/api/v1/users/9999/unlock", adminToken.Token, nil)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Unlock - Locked Account Can Log In Again", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			login("wrongpassword")
+		}
+		require.Equal(t, http.StatusTooManyRequests, login(password).Code)
+
+		w := call("POST", unlockPath, adminToken.Token, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		assert.Equal(t, http.StatusOK, login(password).Code)
+
+		var entry models.AuditLog
+		require.NoError(t, db.Where("action = ?", audit.ActionUserUnlocked).First(&entry).Error)
+		require.NotNil(t, entry.ActorUserID)
+		assert.Equal(t, admin.ID, *entry.ActorUserID)
+		require.NotNil(t, entry.TargetID)
+		assert.Equal(t, member.ID, *entry.TargetID)
+	})
+}
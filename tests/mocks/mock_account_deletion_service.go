@@ -0,0 +1,25 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+)
+
+type MockAccountDeletionService struct {
+	mock.Mock
+}
+
+func (m *MockAccountDeletionService) RequestDeletion(ctx context.Context, userID uint, password string, accessToken *dto.AccessTokenInfo) (*dto.AccountDeletionResponse, error) {
+	args := m.Called(ctx, userID, password, accessToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.AccountDeletionResponse), args.Error(1)
+}
+
+func (m *MockAccountDeletionService) Reactivate(ctx context.Context, email, password string) (uint, error) {
+	args := m.Called(ctx, email, password)
+	return args.Get(0).(uint), args.Error(1)
+}
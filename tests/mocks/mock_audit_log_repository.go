@@ -0,0 +1,26 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+)
+
+type MockAuditLogRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditLogRepository) CreateBatch(ctx context.Context, logs []*models.AuditLog) error {
+	args := m.Called(ctx, logs)
+	return args.Error(0)
+}
+
+func (m *MockAuditLogRepository) GetAuditLogs(ctx context.Context, opts dto.ListOptions, filter dto.AuditLogFilterInput) (*dto.Pagination[*models.AuditLog], error) {
+	args := m.Called(ctx, opts, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.Pagination[*models.AuditLog]), args.Error(1)
+}
@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+)
+
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) Record(ctx context.Context, log *models.AuditLog) {
+	m.Called(ctx, log)
+}
+
+func (m *MockAuditService) GetAuditLogs(ctx context.Context, opts dto.ListOptions, filter dto.AuditLogFilterInput) (*dto.Pagination[*models.AuditLog], error) {
+	args := m.Called(ctx, opts, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.Pagination[*models.AuditLog]), args.Error(1)
+}
+
+func (m *MockAuditService) Close() error {
+	args := m.Called()
+	return args.Error(0)
+}
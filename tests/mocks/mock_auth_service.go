@@ -11,18 +11,28 @@ type MockAuthService struct {
 	mock.Mock
 }
 
-func (m *MockAuthService) Login(ctx context.Context, email string, password string, ipAddress string) (*dto.LoginResponse, error) {
-	args := m.Called(ctx, email, password, ipAddress)
+func (m *MockAuthService) Login(ctx context.Context, email string, password string, ipAddress, userAgent, fingerprint string) (*dto.LoginResponse, error) {
+	args := m.Called(ctx, email, password, ipAddress, userAgent, fingerprint)
 	if res, ok := args.Get(0).(*dto.LoginResponse); ok {
 		return res, args.Error(1)
 	}
 	return nil, args.Error(1)
 }
 
-func (m *MockAuthService) RefreshToken(ctx context.Context, refreshToken, accessToken string, ipAddress string) (*dto.LoginResponse, error) {
-	args := m.Called(ctx, refreshToken, accessToken, ipAddress)
+func (m *MockAuthService) RefreshToken(ctx context.Context, refreshToken, accessToken string, ipAddress, userAgent, fingerprint string) (*dto.LoginResponse, error) {
+	args := m.Called(ctx, refreshToken, accessToken, ipAddress, userAgent, fingerprint)
 	if res, ok := args.Get(0).(*dto.LoginResponse); ok {
 		return res, args.Error(1)
 	}
 	return nil, args.Error(1)
 }
+
+func (m *MockAuthService) Logout(ctx context.Context, userID uint, refreshToken string, accessToken *dto.AccessTokenInfo) error {
+	args := m.Called(ctx, userID, refreshToken, accessToken)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) LogoutAll(ctx context.Context, userID uint, accessToken *dto.AccessTokenInfo) (int64, error) {
+	args := m.Called(ctx, userID, accessToken)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,26 @@
+package mocks
+
+import (
+	"context"
+	"io"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+)
+
+type MockAvatarService struct {
+	mock.Mock
+}
+
+func (m *MockAvatarService) Upload(ctx context.Context, userID uint, r io.Reader) (*dto.AvatarResponse, error) {
+	args := m.Called(ctx, userID, r)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.AvatarResponse), args.Error(1)
+}
+
+func (m *MockAvatarService) Delete(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
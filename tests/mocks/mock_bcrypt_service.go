@@ -22,3 +22,8 @@ func (m *MockBcryptService) HashPasswordWithCost(password string, cost int) (str
 	args := m.Called(password, cost)
 	return args.String(0), args.Error(1)
 }
+
+func (m *MockBcryptService) NeedsRehash(hashPassword string) bool {
+	args := m.Called(hashPassword)
+	return args.Bool(0)
+}
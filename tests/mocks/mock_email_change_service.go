@@ -0,0 +1,26 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+)
+
+type MockEmailChangeService struct {
+	mock.Mock
+}
+
+func (m *MockEmailChangeService) RequestEmailChange(ctx context.Context, userID uint, input *dto.ChangeEmailInput) error {
+	args := m.Called(ctx, userID, input)
+	return args.Error(0)
+}
+
+func (m *MockEmailChangeService) ConfirmEmailChange(ctx context.Context, token string) (*models.User, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
@@ -50,3 +50,13 @@ func (m *MockJWTService) ValidateTokenIgnoreExpiration(tokenString string) (*ser
 	}
 	return args.Get(0).(*services.CustomClaims), args.Error(1)
 }
+
+func (m *MockJWTService) Keys() []dto.JWTKeyResponse {
+	args := m.Called()
+	return args.Get(0).([]dto.JWTKeyResponse)
+}
+
+func (m *MockJWTService) JWKS() dto.JWKSResponse {
+	args := m.Called()
+	return args.Get(0).(dto.JWKSResponse)
+}
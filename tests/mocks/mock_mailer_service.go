@@ -2,14 +2,39 @@ package mocks
 
 import (
 	"github.com/stretchr/testify/mock"
-	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/services"
 )
 
 type MockMailerService struct {
 	mock.Mock
 }
 
-func (m *MockMailerService) SendMailForgotPassword(user *models.User) error {
-	args := m.Called(user)
+func (m *MockMailerService) SendMailForgotPassword(data services.MailData) error {
+	args := m.Called(data)
+	return args.Error(0)
+}
+
+func (m *MockMailerService) SendMailVerification(data services.MailData) error {
+	args := m.Called(data)
+	return args.Error(0)
+}
+
+func (m *MockMailerService) SendMailPasswordChanged(data services.MailData) error {
+	args := m.Called(data)
+	return args.Error(0)
+}
+
+func (m *MockMailerService) SendMailNewLogin(data services.MailData) error {
+	args := m.Called(data)
+	return args.Error(0)
+}
+
+func (m *MockMailerService) SendMailEmailChangeConfirmation(data services.MailData) error {
+	args := m.Called(data)
+	return args.Error(0)
+}
+
+func (m *MockMailerService) SendMailEmailChangeRequested(data services.MailData) error {
+	args := m.Called(data)
 	return args.Error(0)
 }
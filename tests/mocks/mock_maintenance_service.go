@@ -0,0 +1,25 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+)
+
+type MockMaintenanceService struct {
+	mock.Mock
+}
+
+func (m *MockMaintenanceService) GetStatus(ctx context.Context) dto.MaintenanceStatus {
+	args := m.Called(ctx)
+	return args.Get(0).(dto.MaintenanceStatus)
+}
+
+func (m *MockMaintenanceService) SetStatus(ctx context.Context, input *dto.SetMaintenanceInput) (*dto.MaintenanceStatus, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.MaintenanceStatus), args.Error(1)
+}
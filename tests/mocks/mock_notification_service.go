@@ -0,0 +1,28 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+)
+
+type MockNotificationService struct {
+	mock.Mock
+}
+
+func (m *MockNotificationService) NotifyPasswordChanged(ctx context.Context, user *models.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) NotifyNewLogin(ctx context.Context, user *models.User, ipAddress, userAgent string, at time.Time) error {
+	args := m.Called(ctx, user, ipAddress, userAgent, at)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) NotifyEmailChangeRequested(ctx context.Context, user *models.User, newEmail string) error {
+	args := m.Called(ctx, user, newEmail)
+	return args.Error(0)
+}
@@ -0,0 +1,29 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+)
+
+type MockPreferencesService struct {
+	mock.Mock
+}
+
+func (m *MockPreferencesService) GetPreferences(ctx context.Context, userID uint) (*models.UserPreference, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserPreference), args.Error(1)
+}
+
+func (m *MockPreferencesService) UpdatePreferences(ctx context.Context, userID uint, input *dto.UpdatePreferencesInput) (*models.UserPreference, error) {
+	args := m.Called(ctx, userID, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserPreference), args.Error(1)
+}
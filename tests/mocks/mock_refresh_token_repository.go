@@ -17,9 +17,9 @@ func (m *MockRefreshTokenRepository) Create(ctx context.Context, token *models.R
 	return args.Error(0)
 }
 
-func (m *MockRefreshTokenRepository) Update(ctx context.Context, token *models.RefreshToken) error {
-	args := m.Called(ctx, token)
-	return args.Error(0)
+func (m *MockRefreshTokenRepository) Rotate(ctx context.Context, token *models.RefreshToken, previous string) (bool, error) {
+	args := m.Called(ctx, token, previous)
+	return args.Bool(0), args.Error(1)
 }
 
 func (m *MockRefreshTokenRepository) FindByToken(ctx context.Context, token string) (*models.RefreshToken, error) {
@@ -31,3 +31,26 @@ func (m *MockRefreshTokenRepository) UpdateWithTx(ctx context.Context, token *mo
 	args := m.Called(ctx, token, tx)
 	return args.Error(0)
 }
+
+func (m *MockRefreshTokenRepository) DeleteByToken(ctx context.Context, userID uint, token string) error {
+	args := m.Called(ctx, userID, token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) DeleteAllByUserID(ctx context.Context, userID uint) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) FindActiveByUserID(ctx context.Context, userID uint) ([]*models.RefreshToken, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) DeleteByID(ctx context.Context, userID, id uint) (bool, error) {
+	args := m.Called(ctx, userID, id)
+	return args.Bool(0), args.Error(1)
+}
@@ -13,8 +13,8 @@ type MockRefreshTokenService struct {
 	mock.Mock
 }
 
-func (m *MockRefreshTokenService) Create(ctx context.Context, user *models.User, ipAddress string) (*dto.JwtResult, error) {
-	args := m.Called(ctx, user, ipAddress)
+func (m *MockRefreshTokenService) Create(ctx context.Context, user *models.User, ipAddress, userAgent, fingerprint string) (*dto.JwtResult, error) {
+	args := m.Called(ctx, user, ipAddress, userAgent, fingerprint)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -22,11 +22,34 @@ func (m *MockRefreshTokenService) Create(ctx context.Context, user *models.User,
 	return result, args.Error(1)
 }
 
-func (m *MockRefreshTokenService) Update(ctx context.Context, token string, ipAddress string) (*services.RefreshTokenResult, error) {
-	args := m.Called(ctx, token, ipAddress)
+func (m *MockRefreshTokenService) Update(ctx context.Context, token string, ipAddress, userAgent, fingerprint string) (*services.RefreshTokenResult, error) {
+	args := m.Called(ctx, token, ipAddress, userAgent, fingerprint)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	result, _ := args.Get(0).(*services.RefreshTokenResult)
 	return result, args.Error(1)
 }
+
+func (m *MockRefreshTokenService) Delete(ctx context.Context, userID uint, token string) error {
+	args := m.Called(ctx, userID, token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenService) DeleteAllByUserID(ctx context.Context, userID uint) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRefreshTokenService) ListSessions(ctx context.Context, userID uint) ([]dto.SessionResponse, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]dto.SessionResponse), args.Error(1)
+}
+
+func (m *MockRefreshTokenService) RevokeSession(ctx context.Context, userID, sessionID uint) error {
+	args := m.Called(ctx, userID, sessionID)
+	return args.Error(0)
+}
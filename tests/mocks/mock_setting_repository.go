@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+)
+
+type MockSettingRepository struct {
+	mock.Mock
+}
+
+func (m *MockSettingRepository) GetAll(ctx context.Context) ([]*models.Setting, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Setting), args.Error(1)
+}
+
+func (m *MockSettingRepository) GetByKey(ctx context.Context, key string) (*models.Setting, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Setting), args.Error(1)
+}
+
+func (m *MockSettingRepository) Upsert(ctx context.Context, setting *models.Setting) error {
+	args := m.Called(ctx, setting)
+	return args.Error(0)
+}
+
+func (m *MockSettingRepository) Delete(ctx context.Context, key string) (bool, error) {
+	args := m.Called(ctx, key)
+	return args.Bool(0), args.Error(1)
+}
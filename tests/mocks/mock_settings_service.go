@@ -0,0 +1,57 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+)
+
+type MockSettingsService struct {
+	mock.Mock
+}
+
+func (m *MockSettingsService) GetString(ctx context.Context, key string, def string) (string, error) {
+	args := m.Called(ctx, key, def)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSettingsService) GetInt(ctx context.Context, key string, def int) (int, error) {
+	args := m.Called(ctx, key, def)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockSettingsService) GetBool(ctx context.Context, key string, def bool) (bool, error) {
+	args := m.Called(ctx, key, def)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSettingsService) ListSettings(ctx context.Context) ([]*models.Setting, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Setting), args.Error(1)
+}
+
+func (m *MockSettingsService) GetSetting(ctx context.Context, key string) (*models.Setting, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Setting), args.Error(1)
+}
+
+func (m *MockSettingsService) SetSetting(ctx context.Context, key string, input *dto.SetSettingInput) (*models.Setting, error) {
+	args := m.Called(ctx, key, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Setting), args.Error(1)
+}
+
+func (m *MockSettingsService) DeleteSetting(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
@@ -0,0 +1,20 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/vfa-khuongdv/golang-cms/internal/shared/dto"
+)
+
+type MockUserActivityService struct {
+	mock.Mock
+}
+
+func (m *MockUserActivityService) GetUserActivity(ctx context.Context, userID uint, opts dto.ListOptions) (*dto.UserActivityResponse, error) {
+	args := m.Called(ctx, userID, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.UserActivityResponse), args.Error(1)
+}
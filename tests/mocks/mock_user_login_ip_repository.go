@@ -0,0 +1,27 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+)
+
+type MockUserLoginIPRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserLoginIPRepository) Exists(ctx context.Context, userID uint, ipAddress string) (bool, error) {
+	args := m.Called(ctx, userID, ipAddress)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserLoginIPRepository) CountByUserID(ctx context.Context, userID uint) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserLoginIPRepository) Upsert(ctx context.Context, loginIP *models.UserLoginIP) error {
+	args := m.Called(ctx, loginIP)
+	return args.Error(0)
+}
@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/vfa-khuongdv/golang-cms/internal/models"
+)
+
+type MockUserPreferenceRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserPreferenceRepository) GetByUserID(ctx context.Context, userID uint) (*models.UserPreference, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserPreference), args.Error(1)
+}
+
+func (m *MockUserPreferenceRepository) CreateIfMissing(ctx context.Context, preference *models.UserPreference) error {
+	args := m.Called(ctx, preference)
+	return args.Error(0)
+}
+
+func (m *MockUserPreferenceRepository) Upsert(ctx context.Context, preference *models.UserPreference) error {
+	args := m.Called(ctx, preference)
+	return args.Error(0)
+}
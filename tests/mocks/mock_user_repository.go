@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/vfa-khuongdv/golang-cms/internal/models"
@@ -13,14 +14,22 @@ type MockUserRepository struct {
 	mock.Mock
 }
 
-func (m *MockUserRepository) GetUsers(ctx context.Context, page int, limit int) (*dto.Pagination[*models.User], error) {
-	args := m.Called(ctx, page, limit)
+func (m *MockUserRepository) GetUsers(ctx context.Context, opts dto.ListOptions, filter dto.UserFilterInput) (*dto.Pagination[*models.User], error) {
+	args := m.Called(ctx, opts, filter)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*dto.Pagination[*models.User]), args.Error(1)
 }
 
+func (m *MockUserRepository) GetRecentlyActive(ctx context.Context, limit int) ([]*models.User, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.User), args.Error(1)
+}
+
 func (m *MockUserRepository) GetAll(ctx context.Context) ([]*models.User, error) {
 	args := m.Called(ctx)
 	return args.Get(0).([]*models.User), args.Error(1)
@@ -34,6 +43,14 @@ func (m *MockUserRepository) GetByID(ctx context.Context, id uint) (*models.User
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *MockUserRepository) GetByIDWithRoles(ctx context.Context, id uint) (*models.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
 func (m *MockUserRepository) Create(ctx context.Context, user *models.User) (*models.User, error) {
 	args := m.Called(ctx, user)
 	return args.Get(0).(*models.User), args.Error(1)
@@ -44,11 +61,62 @@ func (m *MockUserRepository) Update(ctx context.Context, user *models.User) erro
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) UpdateIfToken(ctx context.Context, user *models.User, token string) (bool, error) {
+	args := m.Called(ctx, user, token)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateLastLogin(ctx context.Context, user *models.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdatePasswordIfUnchanged(ctx context.Context, user *models.User, oldHash string) (bool, error) {
+	args := m.Called(ctx, user, oldHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateStatus(ctx context.Context, user *models.User, fromStatus string) (bool, error) {
+	args := m.Called(ctx, user, fromStatus)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdatePendingEmail(ctx context.Context, user *models.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ConfirmEmailChange(ctx context.Context, user *models.User, token string) (bool, error) {
+	args := m.Called(ctx, user, token)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) FindPendingDeletion(ctx context.Context, requestedBefore time.Time, limit int) ([]*models.User, error) {
+	args := m.Called(ctx, requestedBefore, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Anonymize(ctx context.Context, user *models.User) (bool, error) {
+	args := m.Called(ctx, user)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockUserRepository) Delete(ctx context.Context, userId uint) error {
 	args := m.Called(ctx, userId)
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) DeleteUsers(ctx context.Context, ids []uint) ([]uint, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
 func (m *MockUserRepository) FindByField(ctx context.Context, field string, value string) (*models.User, error) {
 	args := m.Called(ctx, field, value)
 	return args.Get(0).(*models.User), args.Error(1)
@@ -66,3 +134,73 @@ func (m *MockUserRepository) BeginTx(ctx context.Context) (*gorm.DB, error) {
 	}
 	return args.Get(0).(*gorm.DB), args.Error(1)
 }
+
+func (m *MockUserRepository) GetByIDUnscoped(ctx context.Context, id uint) (*models.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Restore(ctx context.Context, userId uint) error {
+	args := m.Called(ctx, userId)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetByIDs(ctx context.Context, ids []uint) ([]*models.User, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) CreateUsers(ctx context.Context, users []*models.User, batchSize int) error {
+	args := m.Called(ctx, users, batchSize)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) FindExistingEmails(ctx context.Context, emails []string) ([]string, error) {
+	args := m.Called(ctx, emails)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockUserRepository) AssignRoles(ctx context.Context, userID uint, roleIDs []uint) error {
+	args := m.Called(ctx, userID, roleIDs)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) AssignRolesWithTx(ctx context.Context, tx *gorm.DB, userID uint, roleIDs []uint) error {
+	args := m.Called(ctx, tx, userID, roleIDs)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) FindMissingRolesWithTx(ctx context.Context, tx *gorm.DB, roleIDs []uint) ([]uint, error) {
+	args := m.Called(ctx, tx, roleIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockUserRepository) RemoveRoles(ctx context.Context, userID uint, roleIDs []uint) error {
+	args := m.Called(ctx, userID, roleIDs)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Search(ctx context.Context, query string, limit int) ([]*models.User, error) {
+	args := m.Called(ctx, query, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) IterateUsers(ctx context.Context, filter dto.UserFilterInput, batchSize int, fn func(users []*models.User) error) error {
+	args := m.Called(ctx, filter, batchSize, fn)
+	return args.Error(0)
+}
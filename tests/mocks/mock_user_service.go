@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"io"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/vfa-khuongdv/golang-cms/internal/models"
@@ -12,9 +13,9 @@ type MockUserService struct {
 	mock.Mock
 }
 
-func (m *MockUserService) GetProfile(ctx context.Context, userID uint) (*models.User, error) {
+func (m *MockUserService) GetProfile(ctx context.Context, userID uint) (*dto.ProfileResponse, error) {
 	args := m.Called(ctx, userID)
-	return args.Get(0).(*models.User), args.Error(1)
+	return args.Get(0).(*dto.ProfileResponse), args.Error(1)
 }
 
 func (m *MockUserService) UpdateProfile(ctx context.Context, userID uint, input *dto.UpdateProfileInput) error {
@@ -36,3 +37,102 @@ func (m *MockUserService) ChangePassword(ctx context.Context, userId uint, input
 	args := m.Called(ctx, userId, input)
 	return args.Get(0).(*models.User), args.Error(1)
 }
+
+func (m *MockUserService) GetUsers(ctx context.Context, opts dto.ListOptions, filter dto.UserFilterInput) (*dto.Pagination[*models.User], error) {
+	args := m.Called(ctx, opts, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.Pagination[*models.User]), args.Error(1)
+}
+
+func (m *MockUserService) GetUser(ctx context.Context, id uint, includeDeleted bool) (*models.User, error) {
+	args := m.Called(ctx, id, includeDeleted)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserService) DeleteUsers(ctx context.Context, ids []uint) (*dto.BulkDeleteUsersResult, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.BulkDeleteUsersResult), args.Error(1)
+}
+
+func (m *MockUserService) RestoreUser(ctx context.Context, id uint) (*models.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserService) CreateUser(ctx context.Context, input *dto.CreateUserInput) (*models.User, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserService) VerifyEmail(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ResendVerification(ctx context.Context, input *dto.ResendVerificationInput) error {
+	args := m.Called(ctx, input)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ForceResetPassword(ctx context.Context, id uint) (string, error) {
+	args := m.Called(ctx, id)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUserService) UnlockUser(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserService) SearchUsers(ctx context.Context, query string, limit int) ([]*models.User, error) {
+	args := m.Called(ctx, query, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.User), args.Error(1)
+}
+
+func (m *MockUserService) GetUserRoles(ctx context.Context, userID uint) ([]dto.UserRoleResponse, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]dto.UserRoleResponse), args.Error(1)
+}
+
+func (m *MockUserService) AssignRoles(ctx context.Context, userID uint, roleIDs []uint) error {
+	args := m.Called(ctx, userID, roleIDs)
+	return args.Error(0)
+}
+
+func (m *MockUserService) RemoveRoles(ctx context.Context, userID uint, roleIDs []uint) error {
+	args := m.Called(ctx, userID, roleIDs)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ImportUsers(ctx context.Context, r io.Reader, dryRun bool) (*dto.UserImportReport, error) {
+	args := m.Called(ctx, r, dryRun)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.UserImportReport), args.Error(1)
+}
+
+func (m *MockUserService) ExportUsers(ctx context.Context, filter dto.UserFilterInput, format string, w io.Writer) error {
+	args := m.Called(ctx, filter, format, w)
+	return args.Error(0)
+}